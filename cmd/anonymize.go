@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newAnonymizeCmd() *cobra.Command {
+	var confirm bool
+
+	anonymize := &cobra.Command{
+		Use:   "anonymize",
+		Short: "Remove player data, sign text, books, custom names and account identifiers before sharing a world",
+		Long: `Remove data which could identify the players of a world before sharing it publicly, and report what was removed.
+
+Player records are deleted, so everyone joining the shared world starts as a new player with an empty inventory. Sign
+text, the pages, title and author of books, custom names given to entities, containers and items, and account or device
+identifiers found in other records are removed.
+
+The world is changed in place and this can't be undone, so run it on a copy of the world. --confirm must be given.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !confirm {
+				fatal(usagef("anonymize changes the world in place: run it on a copy of the world with --confirm"))
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			report, err := w.Anonymize()
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Println(report)
+		},
+	}
+
+	anonymize.Flags().BoolVar(&confirm, "confirm", false, "confirm that the world should be changed in place")
+
+	return anonymize
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/danhale-git/mine/render"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newBannersCmd() *cobra.Command {
+	var pngDir string
+	var scale int
+
+	banners := &cobra.Command{
+		Use:   "banners",
+		Short: "List placed banners and their patterns",
+		Long: `List placed banners and their patterns, from the bottom layer up.
+
+If --png is set, an image of each banner is written to the directory as banner_<x>_<y>_<z>.png.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			banners, err := w.Banners()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, b := range banners {
+				ominous := ""
+				if b.Ominous {
+					ominous = " (ominous)"
+				}
+
+				fmt.Printf("%s banner%s at %d %d %d\n", b.Base, ominous, b.X, b.Y, b.Z)
+
+				for _, p := range b.Patterns {
+					fmt.Printf("  %s %s\n", p.Color, p.Name())
+				}
+
+				if pngDir != "" {
+					if err := writeBannerPNG(pngDir, b, scale); err != nil {
+						fatal(err)
+					}
+				}
+			}
+
+			fmt.Printf("%d banners found\n", len(banners))
+		},
+	}
+
+	banners.Flags().StringVar(&pngDir, "png", "", "write an image of each banner to this directory")
+	banners.Flags().IntVar(&scale, "scale", 4, "the size of each banner pixel in the written images")
+
+	return banners
+}
+
+func writeBannerPNG(dir string, b world.Banner, scale int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("banner_%d_%d_%d.png", b.X, b.Y, b.Z))
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, render.Banner(b, scale)); err != nil {
+		return fmt.Errorf("encoding %s: %w", path, err)
+	}
+
+	return nil
+}
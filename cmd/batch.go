@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/danhale-git/mine/remote"
+	"github.com/spf13/cobra"
+)
+
+// batchResult is the outcome of running a command on one world of a batch.
+type batchResult struct {
+	world discoveredWorld
+	dir   string // The output directory of the world
+	took  time.Duration
+	err   error
+}
+
+func newBatchCmd() *cobra.Command {
+	var out string
+	var workers int
+
+	batch := &cobra.Command{
+		Use:   "batch <world or directory>... -- <command> [args...]",
+		Short: "Run a command on several worlds, one after another or in parallel",
+		Long: `Run a mine command on each of several worlds, for servers which host many. Each argument before -- is a
+world directory, an object storage URI or a directory of worlds, such as the game's minecraftWorlds directory.
+
+The command runs in its own output directory for each world, named after the world's directory under --out, so files
+it writes such as map.png or report.html are kept apart. What it prints is written to output.txt in the same
+directory. Relative paths given to the command are relative to that directory too.
+
+  mine batch /srv/worlds -- report
+  mine batch --parallel 4 /srv/worlds s3://backups/world -- map 0 0 512 512`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if dash := cmd.ArgsLenAtDash(); dash < 1 || dash == len(args) {
+				return errors.New("expected worlds, then -- and the command to run")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			dash := cmd.ArgsLenAtDash()
+			command := args[dash:]
+
+			worlds, err := batchWorlds(args[:dash])
+			if err != nil {
+				fatal(err)
+			}
+
+			exe, err := os.Executable()
+			if err != nil {
+				fatalf("finding the mine executable: %s", err)
+			}
+
+			if workers == 0 {
+				workers = parallelism()
+			}
+
+			results := make(chan batchResult)
+			sem := make(chan struct{}, workers)
+			var wg sync.WaitGroup
+
+			for i, w := range worlds {
+				wg.Add(1)
+				go func(w discoveredWorld, dir string) {
+					defer wg.Done()
+					sem <- struct{}{}
+					defer func() { <-sem }()
+
+					start := time.Now()
+					err := runBatchCommand(exe, w.path, dir, command)
+					results <- batchResult{world: w, dir: dir, took: time.Since(start), err: err}
+				}(w, filepath.Join(out, batchDirName(worlds, i)))
+			}
+
+			go func() {
+				wg.Wait()
+				close(results)
+			}()
+
+			failed := 0
+			for r := range results {
+				if r.err != nil {
+					failed++
+					fmt.Printf("%s (%s): failed after %s: %s: see %s\n", r.world.name, r.world.path,
+						r.took.Round(time.Millisecond), r.err, filepath.Join(r.dir, "output.txt"))
+					continue
+				}
+				fmt.Printf("%s (%s): done in %s\n", r.world.name, r.world.path, r.took.Round(time.Millisecond))
+			}
+
+			if failed > 0 {
+				fatalf("%d of %d worlds failed", failed, len(worlds))
+			}
+			fmt.Printf("%d worlds done\n", len(worlds))
+		},
+	}
+
+	batch.Flags().StringVarP(&out, "out", "o", "batch", "the directory holding the output directory of each world")
+	batch.Flags().IntVar(&workers, "parallel", 1,
+		"the number of worlds to process at once, 0 meaning the parallelism setting or one per CPU")
+
+	return batch
+}
+
+// batchWorlds returns the worlds given by the arguments of the batch command: world directories, object storage URIs
+// and directories holding worlds.
+func batchWorlds(args []string) ([]discoveredWorld, error) {
+	worlds := make([]discoveredWorld, 0)
+
+	for _, arg := range args {
+		if remote.IsURI(arg) {
+			worlds = append(worlds, discoveredWorld{path: arg, name: path.Base(arg)})
+			continue
+		}
+
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if w, ok := discoverWorld(abs); ok {
+			worlds = append(worlds, w)
+			continue
+		}
+
+		found := worldsIn(abs)
+		if len(found) == 0 {
+			return nil, fmt.Errorf("no worlds found in '%s'", arg)
+		}
+		worlds = append(worlds, found...)
+	}
+
+	return worlds, nil
+}
+
+// batchDirName returns the name of the output directory of world i, the name of its directory followed by a number
+// if an earlier world has a directory with the same name.
+func batchDirName(worlds []discoveredWorld, i int) string {
+	name := path.Base(filepath.ToSlash(worlds[i].path))
+
+	same := 0
+	for _, w := range worlds[:i] {
+		if path.Base(filepath.ToSlash(w.path)) == name {
+			same++
+		}
+	}
+
+	if same > 0 {
+		return fmt.Sprintf("%s-%d", name, same+1)
+	}
+	return name
+}
+
+// runBatchCommand runs mine with the given arguments on the world at path, in the output directory dir, writing what
+// it prints to output.txt.
+func runBatchCommand(exe, path, dir string, args []string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	output, err := os.Create(filepath.Join(dir, "output.txt"))
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	c := exec.Command(exe, append([]string{"--world", path}, args...)...)
+	c.Dir = dir
+	c.Stdout = output
+	c.Stderr = output
+
+	return c.Run()
+}
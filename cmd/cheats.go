@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newCheatsCmd() *cobra.Command {
+	cheats := &cobra.Command{
+		Use:   "cheats [on|off]",
+		Short: "Show or change whether cheats are enabled and achievements are disabled",
+		Long: `Show whether cheats are enabled and achievements are disabled, or turn cheats on or off.
+
+As in game, turning cheats on disables achievements for good. Use 'mine cheats reenable-achievements' to undo this.
+
+The world must not be open in the game or a server, which would overwrite the change when it closes.`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"on", "off"},
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			if len(args) == 1 {
+				switch args[0] {
+				case "on", "off":
+					if err := w.SetCommandsEnabled(args[0] == "on"); err != nil {
+						fatal(err)
+					}
+				default:
+					fatal(usagef("invalid argument '%s': expected on or off", args[0]))
+				}
+			}
+
+			c, err := w.Cheats()
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("cheats: %t\n", c.CommandsEnabled)
+			fmt.Printf("achievements disabled: %t\n", c.AchievementsDisabled)
+			fmt.Printf("creative: %t\n", c.Creative)
+		},
+	}
+
+	cheats.AddCommand(&cobra.Command{
+		Use:   "reenable-achievements",
+		Short: "Enable achievements in a world which has used cheats or creative mode",
+		Long: `Enable achievements in a world which has used cheats or creative mode, by clearing the hasBeenLoadedInCreative
+flag in level.dat. This can't be done in game and is intended for worlds where cheats were turned on by mistake.
+
+Cheats must be off and the world game mode must not be creative, or the game would disable achievements again when the
+world is loaded. Back up the world first: the change can't be detected or undone by the game.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			if err := w.ReenableAchievements(); errors.Is(err, world.ErrAchievementsBlocked) {
+				fatalf("%s: turn cheats off and change the game mode first", err)
+			} else if err != nil {
+				fatal(err)
+			}
+
+			fmt.Println("achievements enabled")
+		},
+	})
+
+	return cheats
+}
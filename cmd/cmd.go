@@ -2,44 +2,46 @@ package cmd
 
 import (
 	"fmt"
-	"log"
-	"path/filepath"
+	"os"
 	"strconv"
+	"time"
 
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/remote"
 	"github.com/danhale-git/mine/world"
 	"github.com/spf13/cobra"
 )
 
-const worldDirPath = `C:\Users\danha\AppData\Local\Packages\Microsoft.MinecraftUWP_8wekyb3d8bbwe\LocalState\games\com.mojang\minecraftWorlds\`
-
-//const worldFileName = `VsgSYaaGAAA=` // MINETEST  16 64 16
-const worldFileName = `97caYQjdAgA=` // MINETESTFLAT 0 0 0
-
 func Init() error {
 	root := &cobra.Command{
-		Use:  "mine <x> <y> <z>",
-		Args: cobra.MinimumNArgs(1),
+		Use:   "mine <x> <y> <z>",
+		Short: "Read and edit Minecraft Bedrock Edition worlds",
+		Long: `Read and edit Minecraft Bedrock Edition worlds. Given coordinates, print the block at them.
+
+` + exitCodesHelp,
+		Args:          cobra.ExactArgs(3),
+		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			w, err := world.New(filepath.Join(worldDirPath, worldFileName))
+			w, err := openWorld()
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 
 			b, err := w.GetBlock(
 				atoi(args[0]),
 				atoi(args[1]),
 				atoi(args[2]),
-				0,
+				int(cfg.Dimension),
 			)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 
 			fmt.Println(b)
 
 			/*c, err := strconv.Atoi(args[0])
 			if err != nil {
-				log.Fatalf("invalid argument '%s': %s", args[0], err)
+				fatal(usagef("invalid argument '%s': %s", args[0], err))
 			}
 
 			i := 0
@@ -57,13 +59,235 @@ func Init() error {
 		},
 	}
 
-	return root.Execute()
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		// Arguments have been validated, so errors from here on are not usage errors
+		cmd.SilenceUsage = true
+		commandName = cmd.CommandPath()
+
+		if err := loadConfig(configPath, cmd.Flags().Changed("config")); err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("world") {
+			cfg.World = worldFlag
+		}
+
+		if cmd.Flags().Changed("dimension") {
+			d, err := world.ParseDimension(dimensionFlag)
+			if err != nil {
+				return err
+			}
+			cfg.Dimension = d
+		}
+
+		if cmd.Flags().Changed("memory") {
+			cfg.Memory = memory
+		}
+
+		var err error
+		if memoryBudget, err = parseSize(cfg.Memory); err != nil {
+			return fmt.Errorf("invalid memory budget: %w", err)
+		}
+
+		if strictPalettes {
+			cfg.PaletteValidation = world.StrictPalette
+		}
+		world.SetPaletteValidation(cfg.PaletteValidation)
+
+		if err := startProfiling(); err != nil {
+			return err
+		}
+
+		started = true
+
+		return nil
+	}
+
+	root.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
+		if err := releaseLocks(); err != nil {
+			return err
+		}
+
+		if err := syncRemoteWorlds(); err != nil {
+			return err
+		}
+
+		return writeTimings()
+	}
+
+	root.PersistentFlags().StringVar(&configPath, "config", defaultConfigPath(),
+		"config file holding default settings such as the world path and dimension")
+	root.PersistentFlags().StringVar(&worldFlag, "world", "",
+		"the world directory, object storage URI, or folder ID or level name of a world in the game's worlds directory")
+	root.PersistentFlags().StringVar(&dimensionFlag, "dimension", "",
+		"the dimension of commands which take coordinates, by name or number, e.g. nether (default the overworld)")
+	root.PersistentFlags().BoolVar(&errorJSON, "error-json", false,
+		"write failures to stderr as JSON with a stable exit code and reason, for scripts")
+	root.PersistentFlags().BoolVar(&trace, "trace", false,
+		"log every database key read or written to stderr as JSON lines")
+	root.PersistentFlags().StringVar(&memory, "memory", "",
+		"memory budget of whole world commands, e.g. 2GB, beyond which intermediate results are written to temporary files")
+	root.PersistentFlags().DurationVar(&timeBudget, "time-budget", 0,
+		"stop whole world scans after this long, e.g. 10s: find, grep and report use what was found so far")
+	root.PersistentFlags().BoolVar(&strictPalettes, "strict-palettes", false,
+		"fail to read sub chunks with unexpected block storage layouts, such as extra storages, instead of warning")
+	root.PersistentFlags().DurationVar(&wait, "wait", 0,
+		"wait up to this long, e.g. 30s, for other mine processes using the same part of the world, instead of failing")
+	root.PersistentFlags().StringVar(&pprofAddr, "pprof", "",
+		"serve runtime profiles at this address, e.g. localhost:6060, under /debug/pprof/ while the command runs")
+	root.PersistentFlags().BoolVar(&showTimings, "timings", false,
+		"write the time, CPU time and memory in each stage of the command and the palette cache hit rate to stderr")
+
+	if err := root.RegisterFlagCompletionFunc("world", completeWorldFlag); err != nil {
+		return err
+	}
+
+	root.AddCommand(newDebugCmd())
+	root.AddCommand(newOptimizeCmd())
+	root.AddCommand(newRepairCmd())
+	root.AddCommand(newMapsCmd())
+	root.AddCommand(newIDsCmd())
+	root.AddCommand(newFindCmd())
+	root.AddCommand(newGrepCmd())
+	root.AddCommand(newRecordCmd())
+	root.AddCommand(newLintCmd())
+	root.AddCommand(newTUICmd())
+	root.AddCommand(newGetCmd())
+	root.AddCommand(newSetCmd())
+	root.AddCommand(newFillCmd())
+	root.AddCommand(newCloneCmd())
+	root.AddCommand(newTransferCmd())
+	root.AddCommand(newPlaceCmd())
+	root.AddCommand(newPlayerCmd())
+	root.AddCommand(newBannersCmd())
+	root.AddCommand(newTimeCmd())
+	root.AddCommand(newWeatherCmd())
+	root.AddCommand(newEventsCmd())
+	root.AddCommand(newSettingsCmd())
+	root.AddCommand(newCheatsCmd())
+	root.AddCommand(newFlatCmd())
+	root.AddCommand(newCheckExportCmd())
+	root.AddCommand(newExportCmd())
+	root.AddCommand(newExportFeatureCmd())
+	root.AddCommand(newImportStructureCmd())
+	root.AddCommand(newMapCmd())
+	root.AddCommand(newTimelapseCmd())
+	root.AddCommand(newMaterialsCmd())
+	root.AddCommand(newSymmetryCmd())
+	root.AddCommand(newDumpCmd())
+	root.AddCommand(newLoadCmd())
+	root.AddCommand(newKeygenCmd())
+	root.AddCommand(newAnonymizeCmd())
+	root.AddCommand(newReportCmd())
+	root.AddCommand(newRestoreCmd())
+	root.AddCommand(newLagCmd())
+	root.AddCommand(newBatchCmd())
+	root.AddCommand(newWorldsCmd())
+
+	if err := root.Execute(); err != nil {
+		// Errors before the command started are from its arguments, flags or settings
+		if !started {
+			// The flag isn't set if parsing the flags failed
+			errorJSON = errorJSONArg(os.Args[1:], errorJSON)
+			exit(err.Error(), exitUsage)
+		}
+
+		exit(err.Error(), exitCode(err))
+	}
+
+	return nil
+}
+
+var (
+	// started is set once the command's arguments, flags and settings are checked and it is about to run.
+	started bool
+
+	worldFlag      string
+	dimensionFlag  string
+	trace          bool
+	memory         string
+	strictPalettes bool
+	timeBudget     time.Duration
+
+	// memoryBudget is the memory budget in bytes set by the memory flag or config setting, 0 meaning no limit.
+	memoryBudget int64
+)
+
+// openWorld opens the world set by the world flag or setting, or the only world in the game's worlds directory.
+func openWorld() (*world.World, error) {
+	path, err := worldPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return openWorldPath(path)
+}
+
+// openWorldPath opens the world in the given directory, or in object storage if path is a URI such as
+// s3://bucket/world. Remote worlds are downloaded, and uploaded again after the command if it changed them.
+func openWorldPath(path string) (*world.World, error) {
+	return openWorldClaim(path, world.WorldClaim(commandName))
+}
+
+// openWorldClaim opens a world as openWorldPath does, first claiming the given part of it in the world's lock file
+// so that other mine processes don't edit it at the same time. The claim is released after the command.
+func openWorldClaim(path string, claim world.Claim) (*world.World, error) {
+	var r *remoteWorld
+	if remote.IsURI(path) {
+		var err error
+		if r, err = mirrorRemoteWorld(path); err != nil {
+			return nil, err
+		}
+		path = r.mirror.Dir
+	}
+
+	defer timings.Start("open world")()
+
+	// A missing world folder is reported by world.New
+	var lock *world.Lock
+	if _, err := os.Stat(path); err == nil {
+		if lock, err = world.LockRegion(path, claim, wait); err != nil {
+			return nil, fmt.Errorf("%w: use --wait to wait for it to finish", err)
+		}
+	}
+
+	w, err := openLocked(path)
+	if lock != nil {
+		if err != nil {
+			lock.Release()
+		} else {
+			locks = append(locks, lock)
+		}
+	}
+
+	if leveldb.IsLocked(err) {
+		return nil, fmt.Errorf("%w: the world is open in another program, such as the game or another mine command", err)
+	}
+	if leveldb.IsCorrupted(err) {
+		return nil, fmt.Errorf("%w: the database may be damaged by a crash: run 'repair database' to recover it", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r != nil {
+		r.watch(w)
+	}
+
+	if trace {
+		w.Trace(os.Stderr)
+	}
+
+	w.SetMemoryBudget(memoryBudget)
+	w.SetTimeBudget(timeBudget)
+
+	return w, nil
 }
 
 func atoi(s string) int {
 	i, err := strconv.Atoi(s)
 	if err != nil {
-		log.Fatalf("invalid arg: '%s'", s)
+		exit(fmt.Sprintf("invalid arg: '%s'", s), exitUsage)
 	}
 
 	return i
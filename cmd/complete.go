@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// maxCoordinateHistory is the number of recently used coordinates offered as completions.
+const maxCoordinateHistory = 20
+
+// coordinateArgs parses x y z coordinate arguments and remembers them for shell completion.
+func coordinateArgs(args []string) (x, y, z int) {
+	x, y, z = atoi(args[0]), atoi(args[1]), atoi(args[2])
+	rememberCoordinates(x, y, z)
+
+	return
+}
+
+// coordinateHistoryPath returns the path of the file listing recently used coordinates, most recent first.
+func coordinateHistoryPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "mine", "coordinates"), nil
+}
+
+// coordinateHistory returns recently used coordinates, most recent first. A missing history is empty.
+func coordinateHistory() [][3]string {
+	path, err := coordinateHistoryPath()
+	if err != nil {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	history := make([][3]string, 0)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if f := strings.Fields(line); len(f) == 3 {
+			history = append(history, [3]string{f[0], f[1], f[2]})
+		}
+	}
+
+	return history
+}
+
+// rememberCoordinates adds coordinates to the front of the history. Failing to write the history is not an error, as
+// it only affects shell completion.
+func rememberCoordinates(x, y, z int) {
+	path, err := coordinateHistoryPath()
+	if err != nil {
+		return
+	}
+
+	c := [3]string{strconv.Itoa(x), strconv.Itoa(y), strconv.Itoa(z)}
+	lines := []string{strings.Join(c[:], " ")}
+
+	for _, h := range coordinateHistory() {
+		if h != c && len(lines) < maxCoordinateHistory {
+			lines = append(lines, strings.Join(h[:], " "))
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+
+	_ = ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// completeCoordinates completes x y z arguments from recently used coordinates which match the arguments already given.
+func completeCoordinates(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 3 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	seen := make(map[string]bool)
+	completions := make([]string, 0)
+
+	for _, h := range coordinateHistory() {
+		matches := true
+		for i, a := range args {
+			matches = matches && h[i] == a
+		}
+
+		v := h[len(args)]
+		if !matches || seen[v] || !strings.HasPrefix(v, toComplete) {
+			continue
+		}
+
+		seen[v] = true
+		completions = append(completions, v+"\trecently used "+strings.Join(h[:], " "))
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeWorldPaths completes a world path argument with the worlds in the game's worlds directory, described by
+// their level name. Other directories may still be given.
+func completeWorldPaths(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	completions := make([]string, 0)
+
+	for _, w := range discoverWorlds() {
+		if strings.HasPrefix(w.path, toComplete) {
+			completions = append(completions, w.path+"\t"+w.name)
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveFilterDirs
+}
+
+// completeWorldFlag completes the world flag with the folder IDs of the worlds in the game's worlds directory,
+// described by their level name.
+func completeWorldFlag(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	completions := make([]string, 0)
+
+	for _, w := range discoverWorlds() {
+		if strings.HasPrefix(w.id, toComplete) {
+			completions = append(completions, w.id+"\t"+w.name)
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveFilterDirs
+}
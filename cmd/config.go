@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/danhale-git/mine/lang"
+	"github.com/danhale-git/mine/render"
+	"github.com/danhale-git/mine/world"
+	"gopkg.in/yaml.v2"
+)
+
+// configFileName is the name of the config file in the user's home directory.
+const configFileName = ".mine.yaml"
+
+// config holds defaults read from the config file. Flags given on the command line take precedence.
+type config struct {
+	// The world directory, an object storage URI such as s3://bucket/world, or the folder ID or level name of a world
+	// in the worlds directory
+	World string `yaml:"world"`
+	// The directory holding the game's worlds. Empty means where the game saves worlds on this OS.
+	WorldsDir   string `yaml:"worlds_dir"`
+	Output      string `yaml:"output"`      // The output format of commands which support machine readable output
+	Palette     string `yaml:"palette"`     // The path of a block color palette file used by renders
+	Parallelism int    `yaml:"parallelism"` // The number of workers used by parallel commands, 0 meaning one per CPU
+	Lang        string `yaml:"lang"`        // The path of a .lang file used to show block, item and entity names
+	// The memory budget of whole world commands such as stats and find, e.g. 2GB. Intermediate results beyond it are
+	// written to temporary files. Empty means no limit.
+	Memory string `yaml:"memory"`
+	// The dimension used by commands which take coordinates, by name or number, e.g. nether
+	Dimension world.Dimension `yaml:"dimension"`
+	// How sub chunks with unexpected block storage layouts are read: warn and continue, or strict to fail
+	PaletteValidation world.PaletteValidation `yaml:"palette_validation"`
+}
+
+// outputFormats are the valid values of the output setting.
+var outputFormats = map[string]bool{"text": true, "json": true}
+
+var (
+	cfg        = config{Output: "text"}
+	configPath string
+
+	// names translates IDs to display names in the language set in the config file, or English if none is set.
+	names lang.Names
+	// blockPalette holds the block colors of the palette set in the config file, used by renders in place of the built
+	// in colors. It is nil if none is set.
+	blockPalette render.Palette
+)
+
+// defaultConfigPath returns the path of the config file in the user's home directory.
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, configFileName)
+}
+
+// loadConfig reads the config file at path into cfg. A missing file leaves the defaults unchanged unless the path was
+// given explicitly.
+func loadConfig(path string, explicit bool) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return fmt.Errorf("parsing config file '%s': %w", path, err)
+	}
+
+	if !outputFormats[cfg.Output] {
+		return fmt.Errorf("config file '%s': invalid output format '%s': expected text or json", path, cfg.Output)
+	}
+
+	if cfg.Parallelism < 0 {
+		return fmt.Errorf("config file '%s': parallelism may not be negative", path)
+	}
+
+	if _, err := parseSize(cfg.Memory); err != nil {
+		return fmt.Errorf("config file '%s': invalid memory budget: %w", path, err)
+	}
+
+	if cfg.Lang != "" {
+		if names, err = lang.LoadFile(cfg.Lang); err != nil {
+			return fmt.Errorf("config file '%s': %w", path, err)
+		}
+	}
+
+	if cfg.Palette != "" {
+		if blockPalette, err = render.LoadPaletteFile(cfg.Palette); err != nil {
+			return fmt.Errorf("config file '%s': %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// parallelism returns the number of workers parallel commands should use.
+func parallelism() int {
+	if cfg.Parallelism == 0 {
+		return runtime.NumCPU()
+	}
+
+	return cfg.Parallelism
+}
+
+// sizeUnits are the multipliers of the units accepted by parseSize.
+var sizeUnits = []struct {
+	suffix string
+	n      int64
+}{
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"TB", 1 << 40}, {"B", 1},
+}
+
+// parseSize parses a number of bytes with an optional unit, e.g. 512MB or 8GB. Units are powers of 1024. An empty
+// string is 0.
+func parseSize(s string) (int64, error) {
+	num := strings.ToUpper(strings.TrimSpace(s))
+	if num == "" {
+		return 0, nil
+	}
+
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(num, u.suffix) {
+			num, unit = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.n
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("'%s' is not a size such as 512MB or 8GB", s)
+	}
+
+	return int64(n * float64(unit)), nil
+}
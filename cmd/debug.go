@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/remote"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+const hexDumpWidth = 16
+
+func newDebugCmd() *cobra.Command {
+	debug := &cobra.Command{
+		Use:   "debug",
+		Short: "Inspect raw world records",
+	}
+
+	var annotate bool
+
+	subChunk := &cobra.Command{
+		Use:               "subchunk <x> <y> <z>",
+		Short:             "Print the raw bytes of the sub chunk containing the given block",
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completeCoordinates,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+
+			x, y, z := coordinateArgs(args)
+
+			data, err := w.SubChunkValue(x, y, z, int(cfg.Dimension))
+			if err != nil {
+				fatal(err)
+			}
+
+			if !annotate {
+				fmt.Print(hex.Dump(data))
+				return
+			}
+
+			annotations, err := world.AnnotateSubChunk(data)
+			printAnnotated(os.Stdout, data, annotations)
+
+			if err != nil {
+				fatalf("annotating sub chunk: %s", err)
+			}
+		},
+	}
+
+	subChunk.Flags().BoolVarP(&annotate, "annotate", "a", false,
+		"label the version byte, storage count, bits per block, words and palette entries")
+
+	debug.AddCommand(subChunk)
+	debug.AddCommand(newDebugJournalCmd())
+
+	return debug
+}
+
+func newDebugJournalCmd() *cobra.Command {
+	var keys bool
+
+	journal := &cobra.Command{
+		Use:   "journal",
+		Short: "Show the most recent saved changes, which are in the database journal rather than its table files",
+		Long: `Show the writes in the world database journal (.log files), which holds the changes saved most recently by the
+game before they are compacted into the table files. Every command reads these changes, as the journal is replayed
+when the database is opened.
+
+The journal files are only read, so this may be run while the game has the world open.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := worldPath()
+			if err != nil {
+				fatal(err)
+			}
+			if remote.IsURI(path) {
+				fatal("debug journal can't be run on a world in object storage")
+			}
+
+			j, err := leveldb.ReadJournal(path)
+			if err != nil {
+				fatal(err)
+			}
+
+			puts, deletes := 0, 0
+			for _, e := range j.Entries {
+				if e.Deleted {
+					deletes++
+				} else {
+					puts++
+				}
+			}
+
+			latest := j.Latest()
+
+			fmt.Printf("%d journal files, %d bytes: %d puts and %d deletes of %d keys\n",
+				len(j.Files), j.Size, puts, deletes, len(latest))
+
+			if !keys {
+				return
+			}
+
+			sorted := make([]string, 0, len(latest))
+			for k := range latest {
+				sorted = append(sorted, k)
+			}
+			sort.Strings(sorted)
+
+			for _, k := range sorted {
+				e := latest[k]
+				if e.Deleted {
+					fmt.Printf("%x deleted in %s\n", k, e.File)
+				} else {
+					fmt.Printf("%x %d bytes in %s\n", k, len(e.Value), e.File)
+				}
+			}
+		},
+	}
+
+	journal.Flags().BoolVar(&keys, "keys", false, "list each key whose latest value is in the journal")
+
+	return journal
+}
+
+// printAnnotated writes each annotated span of data as hex with its label alongside the first line. Any bytes after
+// the last annotation are printed as unparsed.
+func printAnnotated(out io.Writer, data []byte, annotations []world.Annotation) {
+	end := 0
+
+	for _, a := range annotations {
+		printSpan(out, data[a.Offset:a.Offset+a.Length], a.Offset, a.Label)
+		end = a.Offset + a.Length
+	}
+
+	if end < len(data) {
+		printSpan(out, data[end:], end, "UNPARSED")
+	}
+}
+
+func printSpan(out io.Writer, span []byte, offset int, label string) {
+	for i := 0; i < len(span) || i == 0; i += hexDumpWidth {
+		line := span[i:minInt(i+hexDumpWidth, len(span))]
+
+		hexBytes := make([]string, len(line))
+		for j, b := range line {
+			hexBytes[j] = fmt.Sprintf("%02x", b)
+		}
+
+		l := fmt.Sprintf("%08x  %-*s  %s", offset+i, hexDumpWidth*3-1, strings.Join(hexBytes, " "), label)
+		_, _ = fmt.Fprintln(out, strings.TrimRight(l, " "))
+
+		label = ""
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danhale-git/mine/output"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newDumpCmd() *cobra.Command {
+	var stream, all bool
+	var out, format, compress, keyFile string
+
+	dump := &cobra.Command{
+		Use:   "dump",
+		Short: "Write the world's chunk records to a file or stdout for other tools to transform",
+		Long: `Write the world's chunk records to a file, or to stdout with --stream, so that other processes can read or
+transform world data. The records can be written back with load.
+
+The ndjson format writes one JSON object per line with the hex encoded key, base64 encoded value and, for chunk
+records, the decoded chunk position and tag. The binary format is smaller and faster to read and write.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			f, err := world.ParseStreamFormat(format)
+			if err != nil {
+				fatal(usageError{err})
+			}
+
+			c, err := output.ParseCompression(compress)
+			if err != nil {
+				fatal(usageError{err})
+			}
+
+			if c.Key, err = readKeyFlag(keyFile); err != nil {
+				fatal(err)
+			}
+
+			if stream == (out != "") {
+				fatal(usagef("exactly one of --stream or --out must be given"))
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			var dst io.WriteCloser
+			if stream {
+				dst, err = c.NewWriter(os.Stdout)
+			} else {
+				dst, err = createOutput(out, c)
+			}
+			if err != nil {
+				fatal(err)
+			}
+
+			include := world.IsChunkKey
+			if all {
+				include = nil
+			}
+
+			end := timings.Start("dump")
+			n, err := w.Dump(dst, f, include)
+			if err != nil {
+				fatal(err)
+			}
+			end()
+
+			if err := dst.Close(); err != nil {
+				fatal(err)
+			}
+
+			// Stdout may be the stream itself
+			fmt.Fprintf(os.Stderr, "%d records written\n", n)
+		},
+	}
+
+	dump.Flags().BoolVar(&stream, "stream", false, "write records to stdout")
+	dump.Flags().StringVarP(&out, "out", "o", "", "the file, or object storage URI such as s3://bucket/key, to write records to")
+	dump.Flags().StringVar(&format, "format", "ndjson", "the stream format: ndjson or binary")
+	dump.Flags().StringVar(&compress, "compress", "none",
+		"compress the output with none, gzip or zstd, optionally with a level e.g. zstd:19")
+	dump.Flags().BoolVar(&all, "all", false, "write every record, not only chunk records")
+	dump.Flags().StringVar(&keyFile, "key", "", "encrypt the output with the key in this file, created with keygen")
+
+	return dump
+}
+
+func newLoadCmd() *cobra.Command {
+	var stream bool
+	var keyFile string
+
+	load := &cobra.Command{
+		Use:   "load [file]",
+		Short: "Write records from a dump to the world, replacing records with the same key",
+		Long: `Write records from a file or object storage URI written by dump, or from stdin with --stream, to the world.
+Records with the same key are replaced. The format and compression are detected from the data. Encrypted dumps need the
+--key they were written with.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if stream == (len(args) == 1) {
+				fatal(usagef("exactly one of --stream or a file must be given"))
+			}
+
+			key, err := readKeyFlag(keyFile)
+			if err != nil {
+				fatal(err)
+			}
+
+			var src io.ReadCloser
+			if stream {
+				src, err = output.NewReader(os.Stdin, key)
+			} else {
+				src, err = openInput(args[0], key)
+			}
+			if err != nil {
+				fatal(err)
+			}
+			defer src.Close()
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			end := timings.Start("load")
+			n, err := w.Load(src)
+			if err != nil {
+				fatalf("%d records written before error: %s", n, err)
+			}
+			end()
+
+			fmt.Printf("%d records written\n", n)
+		},
+	}
+
+	load.Flags().BoolVar(&stream, "stream", false, "read records from stdin")
+	load.Flags().StringVar(&keyFile, "key", "", "decrypt the input with the key in this file")
+
+	return load
+}
+
+func newKeygenCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "keygen <file>",
+		Short: "Create a key file for encrypting dumps with --key",
+		Long: `Create a file holding a new random key for encrypting dumps with --key. The file is readable only by its owner
+and an existing file is never replaced. Anyone with the key can read dumps encrypted with it, and without it they can't
+be read at all, so keep it safe and separate from the dumps.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			key, err := output.GenerateKey()
+			if err != nil {
+				fatal(err)
+			}
+
+			if err := output.WriteKey(args[0], key); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("key written to %s\n", args[0])
+		},
+	}
+}
+
+// readKeyFlag reads the encryption key file given with --key, returning nil if none was given.
+func readKeyFlag(path string) (*output.Key, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	return output.ReadKey(path)
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newEventsCmd() *cobra.Command {
+	var enable, disable []string
+	var resetTrader bool
+
+	events := &cobra.Command{
+		Use:   "events",
+		Short: "Show or change mob events, the wandering trader timer, raids and bad omen",
+		Long: `Show or change mob events, the wandering trader timer, raids and bad omen.
+
+Mob events such as minecraft:wandering_trader_event and minecraft:pillager_patrols_event are turned on and off with
+--enable and --disable. The events_enabled event turns all of them on or off. --reset-trader makes the game try to
+spawn a wandering trader on its next check.
+
+Stuck raids and bad omen are removed with 'mine repair raids'.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			for _, name := range enable {
+				if err := w.SetMobEvent(name, true); err != nil {
+					fatal(err)
+				}
+			}
+
+			for _, name := range disable {
+				if err := w.SetMobEvent(name, false); err != nil {
+					fatal(err)
+				}
+			}
+
+			if resetTrader {
+				if err := w.SetTraderSchedule(world.TraderSchedule{}); err != nil {
+					fatal(err)
+				}
+			}
+
+			if err := printEvents(w); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	events.Flags().StringSliceVar(&enable, "enable", nil, "turn on the named mob events")
+	events.Flags().StringSliceVar(&disable, "disable", nil, "turn off the named mob events")
+	events.Flags().BoolVar(&resetTrader, "reset-trader", false, "reset the wandering trader spawn timer")
+
+	return events
+}
+
+func printEvents(w *world.World) error {
+	mobEvents, err := w.MobEvents()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(mobEvents))
+	for name := range mobEvents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("mob events:")
+	for _, name := range names {
+		fmt.Printf("  %s: %t\n", name, mobEvents[name])
+	}
+
+	schedule, ok, err := w.TraderSchedule()
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		fmt.Printf("wandering trader: %d days since last spawn, next check at tick %d, spawning %t\n",
+			schedule.DaysSinceLastSpawn, schedule.NextSpawnCheckTick, schedule.Spawning)
+	} else {
+		fmt.Println("wandering trader: not scheduled yet")
+	}
+
+	raids, err := w.Raids()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range raids {
+		fmt.Printf("raid in village %s: wave %d of %d, started at tick %d, status %d\n",
+			r.VillageID, r.GroupNum, r.NumGroups, r.GameTick, r.Status)
+	}
+
+	omens, err := w.BadOmens()
+	if err != nil {
+		return err
+	}
+
+	for _, o := range omens {
+		fmt.Printf("%s has %s\n", o.PlayerKey, o.Effect)
+	}
+
+	return nil
+}
+
+func newRepairRaidsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "raids",
+		Short: "Find raids in progress and players with bad omen, and remove them to end stuck raids",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			raids, err := w.Raids()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, r := range raids {
+				fmt.Printf("raid in village %s: wave %d of %d\n", r.VillageID, r.GroupNum, r.NumGroups)
+			}
+
+			omens, err := w.BadOmens()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, o := range omens {
+				fmt.Printf("%s has %s\n", o.PlayerKey, o.Effect)
+			}
+
+			fmt.Printf("%d raids and %d players with bad omen found\n", len(raids), len(omens))
+
+			if !fix || len(raids)+len(omens) == 0 {
+				return
+			}
+
+			for _, r := range raids {
+				if err := w.RemoveRaid(r.VillageID); err != nil {
+					fatal(err)
+				}
+			}
+
+			for _, o := range omens {
+				if err := w.ClearBadOmen(o.PlayerKey); err != nil {
+					fatal(err)
+				}
+			}
+
+			fmt.Println("raids and bad omen removed")
+		},
+	}
+}
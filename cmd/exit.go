@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/world"
+)
+
+// Exit codes, which scripts may rely on to tell failures apart. They must not be renumbered.
+const (
+	exitError              = 1 // Any failure not covered by another code
+	exitUsage              = 2 // Invalid arguments, flags or settings
+	exitNotFound           = 3 // The world, a file or the requested data doesn't exist
+	exitLocked             = 4 // The world is open in the game or claimed by another mine process
+	exitCorrupt            = 5 // The world database or a record in it is damaged
+	exitUnsupportedVersion = 6 // Data is saved in a format version which isn't supported
+)
+
+// exitReasons are the names of the exit codes given in error JSON.
+var exitReasons = map[int]string{
+	exitError:              "error",
+	exitUsage:              "usage",
+	exitNotFound:           "not-found",
+	exitLocked:             "locked",
+	exitCorrupt:            "corrupt",
+	exitUnsupportedVersion: "unsupported-version",
+}
+
+const exitCodesHelp = `Exit codes:
+  0  success
+  1  any other failure
+  2  invalid arguments, flags or settings
+  3  the world, a file or the requested data doesn't exist
+  4  the world is open in the game or claimed by another mine process
+  5  the world database or a record in it is damaged
+  6  data is saved in a format version which isn't supported
+
+With --error-json a failure is written to stderr as a JSON object with the message, exit code and reason, for example
+{"error": "...", "code": 4, "reason": "locked"}.`
+
+// errorJSON is set by the error-json flag.
+var errorJSON bool
+
+// errorJSONArg returns the value of the error-json flag given in args, or def if it isn't given. It reads the flag
+// from the arguments when parsing the flags failed. Like the flag parser, the last value given wins.
+func errorJSONArg(args []string, def bool) bool {
+	for _, a := range args {
+		if a == "--" {
+			break
+		}
+
+		if !strings.HasPrefix(a, "--error-json") {
+			continue
+		}
+
+		v := strings.TrimPrefix(a, "--error-json")
+		if v == "" {
+			def = true
+			continue
+		}
+
+		if !strings.HasPrefix(v, "=") {
+			continue // Another flag starting with the same name
+		}
+
+		if b, err := strconv.ParseBool(v[1:]); err == nil {
+			def = b
+		}
+	}
+
+	return def
+}
+
+// errWorldNotFound is returned when the world to open can't be found.
+var errWorldNotFound = errors.New("world not found")
+
+// usageError is an error in the arguments, flags or settings of a command found once it has started to run, which
+// exits with exitUsage like those found before.
+type usageError struct {
+	error
+}
+
+// usagef returns a usageError with the formatted message.
+func usagef(format string, v ...interface{}) error {
+	return usageError{fmt.Errorf(format, v...)}
+}
+
+// exitCode returns the exit code for a command which failed with err.
+func exitCode(err error) int {
+	var usage usageError
+	var locked *world.LockedError
+	var notSaved *world.SubChunkNotSavedError
+	var palette *world.CorruptPaletteError
+	var version *world.UnsupportedVersionError
+
+	switch {
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.As(err, &version):
+		return exitUnsupportedVersion
+	case errors.As(err, &locked), leveldb.IsLocked(err):
+		return exitLocked
+	case errors.As(err, &palette), errors.Is(err, world.ErrUnexpectedPalette), leveldb.IsCorrupted(err):
+		return exitCorrupt
+	case errors.As(err, &notSaved), errors.Is(err, leveldb.ErrNotFound), errors.Is(err, os.ErrNotExist),
+		errors.Is(err, errWorldNotFound):
+		return exitNotFound
+	}
+
+	return exitError
+}
+
+// fatal replaces log.Fatal, printing its arguments and exiting with the code of the first error among them.
+func fatal(v ...interface{}) {
+	exit(fmt.Sprint(v...), exitCodeOf(v))
+}
+
+// fatalf replaces log.Fatalf, printing the formatted message and exiting with the code of the first error among the
+// arguments.
+func fatalf(format string, v ...interface{}) {
+	exit(fmt.Sprintf(format, v...), exitCodeOf(v))
+}
+
+// exitCodeOf returns the exit code of the first error in v, or exitError if there is none.
+func exitCodeOf(v []interface{}) int {
+	for _, a := range v {
+		if err, ok := a.(error); ok {
+			return exitCode(err)
+		}
+	}
+
+	return exitError
+}
+
+// exit prints the message of a failed command, as JSON with the error-json flag, and exits with the given code. The
+// command's world claims are released first, as os.Exit skips PersistentPostRunE. A claim left by another host is never
+// taken to be stale, so it would lock the world until removed by hand.
+func exit(msg string, code int) {
+	if err := releaseLocks(); err != nil {
+		msg = fmt.Sprintf("%s (releasing world claims: %s)", msg, err)
+	}
+
+	if !errorJSON {
+		log.Print(msg)
+		os.Exit(code)
+	}
+
+	data, err := json.Marshal(struct {
+		Error  string `json:"error"`
+		Code   int    `json:"code"`
+		Reason string `json:"reason"`
+	}{msg, code, exitReasons[code]})
+	if err != nil {
+		log.Print(msg)
+		os.Exit(code)
+	}
+
+	fmt.Fprintln(os.Stderr, string(data))
+	os.Exit(code)
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/danhale-git/mine/output"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newCheckExportCmd() *cobra.Command {
+	var template bool
+
+	check := &cobra.Command{
+		Use:   "check-export",
+		Short: "Check the world directory can be exported as an .mcworld or world template",
+		Long: `Check the world directory can be exported as an .mcworld file, or as an .mctemplate world template if --template
+is given. Missing files, symbolic links, absolute paths and references to packs which aren't included with the world
+are reported with a suggested fix.
+
+The exit status is 1 if any issues are found.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := worldPath()
+			if err != nil {
+				fatal(err)
+			}
+
+			issues, err := world.CheckExport(path, template)
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, i := range issues {
+				fmt.Println(i)
+			}
+
+			fmt.Printf("%d issues found\n", len(issues))
+
+			if len(issues) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	check.Flags().BoolVar(&template, "template", false, "also check world template requirements such as manifest.json")
+
+	return check
+}
+
+func newExportFeatureCmd() *cobra.Command {
+	var (
+		out  string
+		pack world.FeaturePack
+	)
+
+	export := &cobra.Command{
+		Use:   "export-feature <name> <x1> <y1> <z1> <x2> <y2> <z2>",
+		Short: "Export the selection as a behavior pack which places it during world generation",
+		Long: `Export the selection between two corners as a behavior pack which places it during world generation.
+The pack is written to a directory named after the structure in --out and contains the structure as an .mcstructure
+file, a structure template feature and a feature rule which places the feature on the surface of chunks in biomes with
+the --biome tag.
+
+Copy the pack to a world's behavior_packs directory and reference it in world_behavior_packs.json, or to the game's
+development_behavior_packs directory, to use it. Only newly generated chunks are affected.
+
+Block states, block entities and entities are not exported. Positions which are not saved are left unchanged when the
+structure is placed.`,
+		Args: cobra.ExactArgs(7),
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			s := world.NewEditorSession(w)
+			s.Select(world.NewSelection(
+				atoi(args[1]), atoi(args[2]), atoi(args[3]),
+				atoi(args[4]), atoi(args[5]), atoi(args[6]),
+				int(cfg.Dimension),
+			))
+
+			if err := s.Copy(); err != nil {
+				fatal(err)
+			}
+
+			pack.Name = args[0]
+			dir, err := s.Clipboard.WriteFeaturePack(out, pack, s.Selection.Min)
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d blocks exported to %s\n", len(s.Clipboard.Blocks), dir)
+		},
+	}
+
+	export.Flags().StringVar(&out, "out", ".", "the directory to write the pack directory to")
+	export.Flags().StringVar(&pack.Namespace, "namespace", "mine",
+		"the namespace of the structure and feature identifiers")
+	export.Flags().IntVar(&pack.Chance, "chance", 50, "place the structure in one in this many chunks")
+	export.Flags().StringVar(&pack.BiomeTag, "biome", "overworld",
+		"place the structure only in biomes with this tag, e.g. forest or plains")
+
+	return export
+}
+
+func newImportStructureCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import-structure <file.mcstructure> <x> <y> <z>",
+		Short: "Place an .mcstructure file in the world at the given coordinates",
+		Long: `Place an .mcstructure file, as saved by a structure block or written by export-feature, with its lowest
+corner at the given coordinates. Block states, water logging and block entities are kept. Structure voids leave the
+world unchanged. Entities in the structure are not imported.`,
+		Args: cobra.ExactArgs(4),
+		Run: func(cmd *cobra.Command, args []string) {
+			f, err := os.Open(args[0])
+			if err != nil {
+				fatal(err)
+			}
+			defer f.Close()
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			at := [3]int{atoi(args[1]), atoi(args[2]), atoi(args[3])}
+			n, err := w.ImportStructure(f, at, int(cfg.Dimension))
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d blocks placed at %d %d %d\n", n, at[0], at[1], at[2])
+		},
+	}
+}
+
+func newExportCmd() *cobra.Command {
+	var from, to, format, out string
+	var air bool
+
+	export := &cobra.Command{
+		Use:   "export --from <x,y,z> --to <x,y,z>",
+		Short: "Write every block between two corners to a JSON or CSV file for analysis in other tools",
+		Long: `Write the coordinates, name and states of every saved block between two corners in the configured dimension
+to a JSON or CSV file, or to stdout, for analysis in other tools without writing Go. Air is left out unless --air is
+given.
+
+  mine export --from 0,-64,0 --to 63,320,63 --format csv -o blocks.csv
+
+JSON is an array of objects with x, y, z, name and states. CSV has the columns x, y, z, name and states, with the states
+written as the game's commands give them, e.g. ["facing_direction"=3].`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			f, err := world.ParseBlockFormat(format)
+			if err != nil {
+				fatal(usageError{err})
+			}
+
+			a, err := parsePosition(from)
+			if err != nil {
+				exit(fmt.Sprintf("invalid --from: %s", err), exitUsage)
+			}
+			b, err := parsePosition(to)
+			if err != nil {
+				exit(fmt.Sprintf("invalid --to: %s", err), exitUsage)
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			var dst io.WriteCloser
+			if out == "" {
+				dst, err = output.Compression{}.NewWriter(os.Stdout)
+			} else {
+				dst, err = createOutput(out, output.Compression{})
+			}
+			if err != nil {
+				fatal(err)
+			}
+
+			region := world.NewSelection(a[0], a[1], a[2], b[0], b[1], b[2], int(cfg.Dimension))
+
+			var match world.BlockMatcher
+			if !air {
+				match = func(b world.Block, _ int) (bool, error) { return b.ID != world.BlockAir, nil }
+			}
+
+			end := timings.Start("export")
+			n, err := w.ExportBlocks(dst, region, f, match, parallelism())
+			if err != nil {
+				dst.Close()
+				fatal(err)
+			}
+			end()
+
+			if err := dst.Close(); err != nil {
+				fatal(err)
+			}
+
+			// Stdout may be the export itself
+			fmt.Fprintf(os.Stderr, "%d blocks written\n", n)
+		},
+	}
+
+	export.Flags().StringVar(&from, "from", "", "the x,y,z coordinates of one corner")
+	export.Flags().StringVar(&to, "to", "", "the x,y,z coordinates of the opposite corner")
+	export.Flags().StringVar(&format, "format", "json", "the file format: json or csv")
+	export.Flags().StringVarP(&out, "out", "o", "", "the file, or object storage URI, to write to instead of stdout")
+	export.Flags().BoolVar(&air, "air", false, "include air blocks")
+	_ = export.MarkFlagRequired("from")
+	_ = export.MarkFlagRequired("to")
+
+	return export
+}
+
+// parsePosition parses x,y,z block coordinates.
+func parsePosition(s string) ([3]int, error) {
+	coords := strings.Split(s, ",")
+	if len(coords) != 3 {
+		return [3]int{}, fmt.Errorf("invalid position '%s': expected x,y,z", s)
+	}
+
+	var p [3]int
+	for i, c := range coords {
+		v, err := strconv.Atoi(strings.TrimSpace(c))
+		if err != nil {
+			return [3]int{}, fmt.Errorf("invalid position '%s': expected x,y,z", s)
+		}
+		p[i] = v
+	}
+
+	return p, nil
+}
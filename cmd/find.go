@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/mine/filter"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+const filterHelp = `The expression may compare numbers and strings with == != < <= > >=, combine conditions with && || and !, do
+arithmetic with + - * / % and test strings with contains(s, t), prefix(s, t) and suffix(s, t).`
+
+func newFindCmd() *cobra.Command {
+	find := &cobra.Command{
+		Use:   "find",
+		Short: "Find blocks or entities matching a filter expression",
+	}
+
+	var order, near string
+	var limit int
+
+	blocks := &cobra.Command{
+		Use:   "blocks <expression>",
+		Short: "List every saved block matching the expression",
+		Long: `List every saved block matching the expression.
+
+The variables id, x, y, z and dimension are available, for example:
+
+  mine find blocks 'id == "minecraft:chest" && y < 0'
+
+With --order spiral chunks are searched in rings outwards from the world spawn, or from --near, so with --limit the
+search stops at the nearest matches:
+
+  mine find blocks 'id == "minecraft:diamond_ore"' --order spiral --near 120,-340 --limit 1
+
+With --time-budget the search stops after that long, printing the blocks found so far and how much was searched.
+
+` + filterHelp,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			expr, err := filter.Compile(args[0])
+			if err != nil {
+				fatal(err)
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			o, err := scanOrder(w, order, near)
+			if err != nil {
+				fatal(err)
+			}
+			w.SetScanOrder(o)
+
+			found := 0
+			defer timings.Start("search")()
+
+			// Blocks are printed as they are found, as there may be too many to hold in memory
+			err = w.ForEachBlock(func(b world.Block, dimension int) (bool, error) {
+				return expr.Match(blockEnv(b, dimension))
+			}, func(b world.Block) error {
+				found++
+				fmt.Printf("%s (%s) at %d %d %d\n", names.Block(b.ID), b.ID, b.X, b.Y, b.Z)
+
+				if found == limit {
+					return errLimitReached
+				}
+				return nil
+			})
+
+			var partial *world.PartialResultError
+			if err != nil && !errors.Is(err, errLimitReached) && !errors.As(err, &partial) {
+				fatal(err)
+			}
+
+			fmt.Printf("%d blocks found\n", found)
+			if partial != nil {
+				fmt.Printf("stopped early: %s\n", partial)
+			}
+		},
+	}
+
+	blocks.Flags().StringVar(&order, "order", "key",
+		"the order chunks are searched in: key (fastest), row, spiral (nearest first) or hilbert")
+	blocks.Flags().StringVar(&near, "near", "", "the x,z block coordinates a spiral starts from (default the world spawn)")
+	blocks.Flags().IntVar(&limit, "limit", 0, "stop after this many blocks are found, 0 meaning no limit")
+	find.AddCommand(blocks)
+
+	var from string
+	var count int
+
+	nearest := &cobra.Command{
+		Use:   "nearest <block id>",
+		Short: "List the saved blocks with the ID nearest to a position",
+		Long: `List the saved blocks with the ID nearest to a position in the configured dimension, nearest first, for
+example the closest diamond ore to the player:
+
+  mine find nearest minecraft:diamond_ore --from 120,-40,-340 --count 3
+
+Chunks are searched outwards from the position, stopping once no nearer block can be found, so this is much faster
+than searching the whole world.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			var x, y, z int
+			if from == "" {
+				if x, y, z, err = w.SpawnPoint(); err != nil {
+					fatalf("finding the world spawn: %s: use --from to set the position", err)
+				}
+			} else {
+				coords := strings.Split(from, ",")
+				if len(coords) != 3 {
+					fatal(usagef("invalid position '%s': expected x,y,z", from))
+				}
+				x, y, z = atoi(coords[0]), atoi(coords[1]), atoi(coords[2])
+			}
+
+			end := timings.Start("search")
+			found, err := w.FindNearest(x, y, z, int(cfg.Dimension), args[0], count)
+			if err != nil {
+				fatal(err)
+			}
+			end()
+
+			for _, b := range found {
+				fmt.Printf("%s (%s) at %d %d %d, %.1f blocks away\n", names.Block(b.ID), b.ID, b.X, b.Y, b.Z, b.Distance)
+			}
+
+			fmt.Printf("%d blocks found\n", len(found))
+		},
+	}
+
+	nearest.Flags().StringVar(&from, "from", "", "the x,y,z block coordinates to search from (default the world spawn)")
+	nearest.Flags().IntVarP(&count, "count", "n", 1, "the number of blocks to find")
+	find.AddCommand(nearest)
+
+	contains := &cobra.Command{
+		Use:   "contains <block id> <x1> <y1> <z1> <x2> <y2> <z2>",
+		Short: "Print whether any saved block between two corners in the configured dimension has the ID",
+		Long: `Print whether any saved block between two corners in the configured dimension has the ID, for example to
+check for bedrock above Y=0:
+
+  mine find contains minecraft:bedrock 0 1 0 255 319 255
+
+Only sub chunk palettes are read, so this is much faster than listing the blocks.`,
+		Args: cobra.ExactArgs(7),
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			region := world.NewSelection(atoi(args[1]), atoi(args[2]), atoi(args[3]),
+				atoi(args[4]), atoi(args[5]), atoi(args[6]), int(cfg.Dimension))
+
+			ok, err := w.ContainsBlock(region, args[0])
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Println(ok)
+		},
+	}
+
+	find.AddCommand(contains)
+
+	var remove, verbose, links bool
+
+	entities := &cobra.Command{
+		Use:   "entities <expression>",
+		Short: "List or remove every entity matching the expression",
+		Long: `List or remove every entity matching the expression.
+
+The variables identifier, uniqueID, x, y, z and dimension are available, for example:
+
+  mine find entities 'contains(identifier, "zombie") && y < 0' --remove
+
+With --links each entity is followed by what it rides, is leashed to or is owned by, and what rides, is leashed to or
+is owned by it.
+
+` + filterHelp,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			expr, err := filter.Compile(args[0])
+			if err != nil {
+				fatal(err)
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			var resolver *world.EntityResolver
+			if links {
+				end := timings.Start("resolve links")
+				if resolver, err = w.EntityResolver(); err != nil {
+					fatal(err)
+				}
+				end()
+			}
+
+			matched := make([]world.Entity, 0)
+			end := timings.Start("search")
+
+			err = w.ForEachEntity(func(e world.Entity) error {
+				ok, err := expr.Match(entityEnv(e))
+				if err != nil || !ok {
+					return err
+				}
+
+				matched = append(matched, e)
+				fmt.Printf("%s (%s) %d at %.1f %.1f %.1f in dimension %d\n",
+					names.Entity(e.Identifier), e.Identifier, e.UniqueID, e.X, e.Y, e.Z, e.Dimension)
+
+				if verbose {
+					printEntityDetails(e)
+				}
+
+				if resolver != nil {
+					printEntityLinks(resolver, e)
+				}
+
+				return nil
+			})
+			if err != nil {
+				fatal(err)
+			}
+			end()
+
+			fmt.Printf("%d entities found\n", len(matched))
+
+			if !remove {
+				return
+			}
+
+			for _, e := range matched {
+				if err := w.RemoveEntity(e); err != nil {
+					fatal(err)
+				}
+			}
+
+			fmt.Printf("%d entities removed\n", len(matched))
+		},
+	}
+
+	entities.Flags().BoolVar(&remove, "remove", false, "remove the matching entities from the world")
+	entities.Flags().BoolVarP(&verbose, "verbose", "v", false, "also list the items and status effects of each entity")
+	entities.Flags().BoolVar(&links, "links", false, "also list what each entity rides, is leashed to or is owned by")
+	find.AddCommand(entities)
+
+	return find
+}
+
+// printEntityLinks prints the links from and to an entity, such as the mob it rides or the player who owns it.
+func printEntityLinks(r *world.EntityResolver, e world.Entity) {
+	describe := func(id int64) string {
+		d, err := r.Describe(id)
+		if err != nil {
+			return fmt.Sprintf("missing entity %d", id)
+		}
+		return d
+	}
+
+	for _, l := range r.LinksOf(e.UniqueID) {
+		if l.From == e.UniqueID {
+			fmt.Printf("  %s %s\n", l.Kind, describe(l.To))
+		} else {
+			fmt.Printf("  %s %s\n", linkedBy[l.Kind], describe(l.From))
+		}
+	}
+}
+
+// linkedBy describes each kind of link from the entity it points to.
+var linkedBy = map[world.LinkKind]string{
+	world.Rides:     "ridden by",
+	world.LeashedTo: "holds the leash of",
+	world.OwnedBy:   "owns",
+}
+
+// errLimitReached stops a search when enough results have been found.
+var errLimitReached = errors.New("limit reached")
+
+// scanOrder returns the scan order with the given name. A spiral starts from the chunk holding the x,z block
+// coordinates near, or the world spawn if near is empty.
+func scanOrder(w *world.World, name, near string) (world.ScanOrder, error) {
+	o, err := world.ParseOrder(name)
+	if err != nil {
+		return world.ScanOrder{}, usageError{err}
+	}
+
+	if o != world.Spiral {
+		return world.ScanOrder{Order: o}, nil
+	}
+
+	var x, z int
+	if near == "" {
+		if x, _, z, err = w.SpawnPoint(); err != nil {
+			return world.ScanOrder{}, fmt.Errorf("finding the spiral center: %w: use --near to set it", err)
+		}
+	} else {
+		coords := strings.Split(near, ",")
+		if len(coords) != 2 {
+			return world.ScanOrder{}, usagef("invalid position '%s': expected x,z", near)
+		}
+		x, z = atoi(coords[0]), atoi(coords[1])
+	}
+
+	return world.ScanOrder{Order: o, Center: world.ChunkPos{X: floorDiv(x, 16), Z: floorDiv(z, 16)}}, nil
+}
+
+// printEntityDetails prints the items and status effects of an entity, indented below it.
+func printEntityDetails(e world.Entity) {
+	for _, i := range e.Items() {
+		name := names.Item(i.Name)
+		if p, ok := i.Potion(); ok {
+			name = p
+		}
+		if i.CustomName != "" {
+			name = fmt.Sprintf("%q (%s)", i.CustomName, name)
+		}
+
+		details := make([]string, len(i.Enchantments))
+		for j, ench := range i.Enchantments {
+			details[j] = ench.String()
+		}
+
+		line := fmt.Sprintf("  %dx %s", i.Count, name)
+		if len(details) > 0 {
+			line += ": " + strings.Join(details, ", ")
+		}
+
+		fmt.Println(line)
+	}
+
+	for _, effect := range e.Effects() {
+		fmt.Printf("  effect %s\n", effect)
+	}
+}
+
+func blockEnv(b world.Block, dimension int) filter.Env {
+	return filter.Env{"id": b.ID, "x": b.X, "y": b.Y, "z": b.Z, "dimension": dimension}
+}
+
+func entityEnv(e world.Entity) filter.Env {
+	return filter.Env{
+		"identifier": e.Identifier,
+		"uniqueID":   e.UniqueID,
+		"x":          e.X, "y": e.Y, "z": e.Z,
+		"dimension": e.Dimension,
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newFlatCmd() *cobra.Command {
+	var layers string
+	var biome int
+
+	flat := &cobra.Command{
+		Use:   "flat",
+		Short: "Show or change the superflat layer preset",
+		Long: `Show or change the superflat layer preset used to generate new chunks in a superflat world. Existing chunks are
+not changed.
+
+Layers are given from the bottom up as comma separated block IDs, each optionally preceded by a count and *:
+
+  mine flat --layers 'bedrock,3*stone,2*dirt,grass_block'
+
+The world must not be open in the game or a server, which would overwrite the change when it closes.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			p, err := w.FlatPreset()
+			if err != nil {
+				fatal(err)
+			}
+
+			flags := cmd.Flags()
+
+			if flags.Changed("layers") {
+				if p.Layers, err = world.ParseFlatLayers(layers); err != nil {
+					fatal(usageError{err})
+				}
+			}
+
+			if flags.Changed("biome") {
+				p.BiomeID = biome
+			}
+
+			if flags.Changed("layers") || flags.Changed("biome") {
+				if err := w.SetFlatPreset(p); err != nil {
+					fatal(err)
+				}
+			}
+
+			fmt.Printf("biome: %d\n", p.BiomeID)
+
+			for i := len(p.Layers) - 1; i >= 0; i-- {
+				l := p.Layers[i]
+				fmt.Printf("%4d %s (%s)\n", l.Count, names.Block(l.Block), l.Block)
+			}
+
+			fmt.Printf("%d blocks high\n", p.Height())
+		},
+	}
+
+	flat.Flags().StringVar(&layers, "layers", "", "replace the layers, from the bottom up")
+	flat.Flags().IntVar(&biome, "biome", 0, "set the numeric biome ID")
+
+	return flat
+}
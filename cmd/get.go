@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danhale-git/mine/nbt"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newGetCmd() *cobra.Command {
+	var verbose bool
+
+	c := &cobra.Command{
+		Use:   "get <x> <y> <z>",
+		Short: "Print the block at the given coordinates",
+		Long: `Print the block at the given coordinates.
+
+With --verbose the NBT of the block entity at the coordinates, if there is one, is printed as a tree.`,
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completeCoordinates,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			x, y, z := coordinateArgs(args)
+
+			b, err := w.GetBlock(x, y, z, int(cfg.Dimension))
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Println(b)
+
+			if !verbose {
+				return
+			}
+
+			if t, ok := blockEntityAt(w, b.X, b.Y, b.Z, int(cfg.Dimension)); ok {
+				fmt.Print(nbt.Format(t, colorOutput()))
+			}
+		},
+	}
+
+	c.Flags().BoolVarP(&verbose, "verbose", "v", false, "also print the block entity NBT")
+
+	return c
+}
+
+// blockEntityAt returns the NBT of the block entity at the given coordinates, if there is one.
+func blockEntityAt(w *world.World, x, y, z, dimension int) (nbt.NBTTag, bool) {
+	e, ok, err := w.BlockEntityAt(x, y, z, dimension)
+	if err != nil || !ok {
+		return nbt.NBTTag{}, false
+	}
+
+	return e.NBT, true
+}
+
+// colorOutput returns true if standard output is a terminal and the NO_COLOR environment variable is not set.
+func colorOutput() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	info, err := os.Stdout.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+
+	return a / b
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newGrepCmd() *cobra.Command {
+	var ignoreCase bool
+
+	grep := &cobra.Command{
+		Use:   "grep <regex>",
+		Short: "Search the text of every string tag in the world",
+		Long: `Search every string tag in every record holding NBT data with a regular expression, to find where text
+appears anywhere in the world: sign text, book pages, custom names, command block commands and so on.
+
+Each match is printed with the record key, the path of the tag in the record, the coordinates of the block entity or
+entity it belongs to if any, and the tag's value, for example:
+
+  mine grep -i 'treasure'
+
+The first element of the path is the index of the root tag in the record, followed by compound names and list indices.
+A record can be edited with mine record get and put, using the key as printed.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			expr := args[0]
+			if ignoreCase {
+				expr = "(?i)" + expr
+			}
+
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				fatal(err)
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			found := 0
+			defer timings.Start("search")()
+
+			err = w.SearchText(re, func(m world.TextMatch) error {
+				found++
+
+				at := ""
+				if m.HasPosition {
+					at = fmt.Sprintf(" at %d %d %d in dimension %d", m.X, m.Y, m.Z, m.Dimension)
+				}
+				fmt.Printf("%s %s%s: %q\n", keyString(m.Key), strings.Join(m.Path, "/"), at, m.Value)
+
+				return nil
+			})
+
+			var partial *world.PartialResultError
+			if err != nil && !errors.As(err, &partial) {
+				fatal(err)
+			}
+
+			fmt.Printf("%d matches found\n", found)
+			if partial != nil {
+				fmt.Printf("stopped early: %s\n", partial)
+			}
+		},
+	}
+
+	grep.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "match letters in either case")
+
+	return grep
+}
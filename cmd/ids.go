@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newIDsCmd() *cobra.Command {
+	ids := &cobra.Command{
+		Use:   "ids",
+		Short: "Inspect and remap map and entity IDs for merging worlds",
+	}
+
+	ids.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List every map and entity ID defined in the world",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			ids, err := w.IDs()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, kind := range []world.IDKind{world.MapID, world.EntityID} {
+				for _, id := range ids[kind] {
+					fmt.Printf("%s %d\n", kind, id)
+				}
+			}
+		},
+	})
+
+	ids.AddCommand(&cobra.Command{
+		Use:   "renumber <other world path>",
+		Short: "Renumber every ID which collides with an ID in another world",
+		Long: `Renumber every ID which collides with an ID in another world.
+
+Map records, map items, entity unique IDs and every reference to them (owners, leashes, riders, village dwellers) are
+rewritten consistently, so the two worlds may be merged without losing or mixing up maps and entities.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeWorldPaths,
+		Run: func(cmd *cobra.Command, args []string) {
+			other, err := openWorldPath(args[0])
+			if err != nil {
+				fatal(err)
+			}
+
+			taken, err := other.IDs()
+			if err != nil {
+				fatal(err)
+			}
+
+			if err := other.Close(); err != nil {
+				fatal(err)
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			ids, err := w.IDs()
+			if err != nil {
+				fatal(err)
+			}
+
+			remap := world.NonCollidingIDs(ids, taken)
+
+			if err := w.RemapIDs(remap); err != nil {
+				fatal(err)
+			}
+
+			for _, c := range remap.Changes() {
+				fmt.Printf("%s %d renumbered to %d\n", c.Kind, c.From, c.To)
+			}
+		},
+	})
+
+	return ids
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newLagCmd() *cobra.Command {
+	var top int
+
+	lag := &cobra.Command{
+		Use:   "lag",
+		Short: "List the chunks most likely to cause lag",
+		Long: `List the chunks most likely to cause lag, highest lag score first.
+
+The score combines the entities, block entities, redstone components and pending block updates saved in each chunk.
+Hoppers count more than other block entities as they search for items every tick. Scores are estimates for finding
+problem areas and are only comparable to each other.
+
+If the output setting in the config file is json, the list is printed as JSON.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			end := timings.Start("lag scores")
+			scores, err := w.LagScores()
+			if err != nil {
+				fatal(err)
+			}
+			end()
+
+			if top > 0 && len(scores) > top {
+				scores = scores[:top]
+			}
+
+			if cfg.Output == "json" {
+				printLagScoresJSON(scores)
+				return
+			}
+
+			fmt.Printf("%-24s %8s %9s %14s %9s %13s\n", "chunk", "score", "entities", "block entities", "redstone",
+				"pending ticks")
+
+			for _, s := range scores {
+				fmt.Printf("%-24s %8.1f %9d %14d %9d %13d\n", fmt.Sprintf("%d %d dim %d", s.X, s.Z, s.Dimension),
+					s.Score, s.Entities, s.BlockEntities, s.Redstone, s.PendingTicks)
+			}
+		},
+	}
+
+	lag.Flags().IntVar(&top, "top", 20, "the number of chunks to list, 0 listing every chunk with a score")
+
+	return lag
+}
+
+func printLagScoresJSON(scores []world.LagScore) {
+	type lagScore struct {
+		X             int     `json:"x"`
+		Z             int     `json:"z"`
+		Dimension     int     `json:"dimension"`
+		Score         float64 `json:"score"`
+		Entities      int     `json:"entities"`
+		BlockEntities int     `json:"blockEntities"`
+		Redstone      int     `json:"redstone"`
+		PendingTicks  int     `json:"pendingTicks"`
+	}
+
+	out := make([]lagScore, len(scores))
+	for i, s := range scores {
+		out[i] = lagScore{s.X, s.Z, s.Dimension, s.Score, s.Entities, s.BlockEntities, s.Redstone, s.PendingTicks}
+	}
+
+	e := json.NewEncoder(os.Stdout)
+	e.SetIndent("", "  ")
+
+	if err := e.Encode(out); err != nil {
+		fatal(err)
+	}
+}
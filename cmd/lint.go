@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newLintCmd() *cobra.Command {
+	var severity string
+	var list bool
+
+	lint := &cobra.Command{
+		Use:   "lint [rule...]",
+		Short: "Check the world for likely problems, such as containers next to lava",
+		Long: `Check the world for things which are likely to cause trouble in game, such as command blocks left in the
+spawn chunks, builds with no lighting and containers close to lava. Each problem is printed with its severity, the rule
+which found it and its coordinates, most severe first.
+
+Every rule is run unless some are named. Use --list to see the rules, which include any added by record handlers.
+
+  mine lint --severity warning
+  mine lint containers-near-lava`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if list {
+				for _, r := range world.LintRules() {
+					fmt.Printf("%s: %s\n", r.Name(), r.Description())
+				}
+				return
+			}
+
+			least, err := world.ParseSeverity(severity)
+			if err != nil {
+				fatal(usageError{err})
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			end := timings.Start("lint")
+			findings, err := w.Lint(args...)
+			if err != nil {
+				fatal(err)
+			}
+			end()
+
+			shown := 0
+			for _, f := range findings {
+				if f.Severity < least {
+					continue
+				}
+				shown++
+
+				if f.Key != nil {
+					fmt.Printf("%s %s in record %s: %s\n", f.Severity, f.Rule, keyString(f.Key), f.Message)
+					continue
+				}
+				fmt.Printf("%s %s at %d %d %d in dimension %d: %s\n",
+					f.Severity, f.Rule, f.X, f.Y, f.Z, f.Dimension, f.Message)
+			}
+
+			fmt.Printf("%d problems found\n", shown)
+		},
+	}
+
+	lint.Flags().StringVar(&severity, "severity", "info",
+		"only print problems at least this severe: info, warning or error")
+	lint.Flags().BoolVar(&list, "list", false, "list the rules instead of running them")
+
+	return lint
+}
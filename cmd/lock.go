@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/world"
+)
+
+var (
+	// wait is how long to wait for other processes using a world, set by the wait flag.
+	wait time.Duration
+	// commandName is the command being run, which is recorded in the claims of worlds it opens.
+	commandName string
+	// locks are the claims of the worlds opened by the command, released after it has run.
+	locks []*world.Lock
+)
+
+// openLocked opens the world in the given directory. If another process has the database open, opening is retried
+// until the wait flag's time has passed.
+func openLocked(path string) (*world.World, error) {
+	deadline := time.Now().Add(wait)
+
+	for {
+		w, err := world.New(path)
+		if !leveldb.IsLocked(err) || time.Now().After(deadline) {
+			return w, err
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// releaseLocks releases the claims of every world opened by the command, returning the first error. Every claim is
+// released even if one fails.
+func releaseLocks() error {
+	defer func() { locks = nil }()
+
+	var first error
+	for _, l := range locks {
+		if err := l.Release(); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newMapsCmd() *cobra.Command {
+	maps := &cobra.Command{
+		Use:   "maps",
+		Short: "List and renumber map records",
+	}
+
+	maps.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the ID of every map record",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			ids, err := w.MapIDs()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, id := range ids {
+				fmt.Println(id)
+			}
+		},
+	})
+
+	maps.AddCommand(&cobra.Command{
+		Use:   "renumber <other world path>",
+		Short: "Renumber maps which have the same ID as a map in another world",
+		Long: `Renumber maps which have the same ID as a map in another world.
+
+Every map item referring to a renumbered map is updated, so maps from both worlds survive when they are merged.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeWorldPaths,
+		Run: func(cmd *cobra.Command, args []string) {
+			other, err := openWorldPath(args[0])
+			if err != nil {
+				fatal(err)
+			}
+
+			taken, err := other.MapIDs()
+			if err != nil {
+				fatal(err)
+			}
+
+			if err := other.Close(); err != nil {
+				fatal(err)
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			ids, err := w.MapIDs()
+			if err != nil {
+				fatal(err)
+			}
+
+			remap := world.NonCollidingMapIDs(ids, taken)
+
+			if err := w.RemapMaps(remap); err != nil {
+				fatal(err)
+			}
+
+			for _, c := range (world.IDRemap{world.MapID: remap}).Changes() {
+				fmt.Printf("map %d renumbered to %d\n", c.From, c.To)
+			}
+
+			fmt.Printf("%d maps renumbered\n", len(remap))
+		},
+	})
+
+	return maps
+}
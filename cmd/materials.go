@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newMaterialsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "materials <x1> <y1> <z1> <x2> <y2> <z2>",
+		Short: "List the blocks needed to build the selection, with stack and shulker box counts",
+		Long: `List the blocks needed to build the selection between two corners, with stack and shulker box counts. Air is
+not counted.
+
+If the output setting in the config file is json, the list is printed as JSON.`,
+		Args: cobra.ExactArgs(6),
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			s := world.NewEditorSession(w)
+			s.Select(world.NewSelection(
+				atoi(args[0]), atoi(args[1]), atoi(args[2]),
+				atoi(args[3]), atoi(args[4]), atoi(args[5]),
+				int(cfg.Dimension),
+			))
+
+			if err := s.Copy(); err != nil {
+				fatal(err)
+			}
+
+			materials := s.Clipboard.Materials()
+
+			if cfg.Output == "json" {
+				printMaterialsJSON(materials)
+				return
+			}
+
+			total := 0
+
+			for _, m := range materials {
+				stacks, remainder := m.Stacks()
+				fmt.Printf("%7d %-30s %4d x %d + %-3d %3d shulker boxes\n",
+					m.Count, names.Block(m.ID), stacks, m.StackSize(), remainder, m.ShulkerBoxes())
+				total += m.Count
+			}
+
+			fmt.Printf("%d blocks of %d types\n", total, len(materials))
+		},
+	}
+}
+
+func printMaterialsJSON(materials []world.Material) {
+	type material struct {
+		ID           string `json:"id"`
+		Name         string `json:"name"`
+		Count        int    `json:"count"`
+		StackSize    int    `json:"stackSize"`
+		Stacks       int    `json:"stacks"`
+		Remainder    int    `json:"remainder"`
+		ShulkerBoxes int    `json:"shulkerBoxes"`
+	}
+
+	out := make([]material, len(materials))
+	for i, m := range materials {
+		stacks, remainder := m.Stacks()
+		out[i] = material{m.ID, names.Block(m.ID), m.Count, m.StackSize(), stacks, remainder, m.ShulkerBoxes()}
+	}
+
+	e := json.NewEncoder(os.Stdout)
+	e.SetIndent("", "  ")
+
+	if err := e.Encode(out); err != nil {
+		fatal(err)
+	}
+}
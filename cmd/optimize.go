@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/danhale-git/mine/remote"
+	"github.com/spf13/cobra"
+)
+
+func newOptimizeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "optimize",
+		Short: "Re-encode every sub chunk with normalized palettes and compact the database",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := worldPath()
+			if err != nil {
+				fatal(err)
+			}
+			if remote.IsURI(path) {
+				fatal("optimize can't be run on a world in object storage: download the world first")
+			}
+
+			dbPath := filepath.Join(path, "db")
+
+			before, err := dirSize(dbPath)
+			if err != nil {
+				fatal(err)
+			}
+
+			w, err := openWorldPath(path)
+			if err != nil {
+				fatal(err)
+			}
+
+			end := timings.Start("optimize")
+			report, err := w.Optimize()
+			if err != nil {
+				fatal(err)
+			}
+			end()
+
+			if err := w.Close(); err != nil {
+				fatal(err)
+			}
+
+			after, err := dirSize(dbPath)
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("re-encoded %d of %d sub chunks\n", report.Rewritten, report.SubChunks)
+			fmt.Printf("sub chunk data: %d -> %d bytes (%s)\n",
+				report.BytesBefore, report.BytesAfter, percentChange(report.BytesBefore, report.BytesAfter))
+			fmt.Printf("database size: %d -> %d bytes (%s)\n", before, after, percentChange(int(before), int(after)))
+		},
+	}
+}
+
+// dirSize returns the total size of all files in the given directory.
+func dirSize(path string) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size, err
+}
+
+func percentChange(before, after int) string {
+	if before == 0 {
+		return "n/a"
+	}
+
+	return fmt.Sprintf("%+.1f%%", float64(after-before)/float64(before)*100)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newPlaceCmd() *cobra.Command {
+	var (
+		wood string
+		opts world.SchematicOptions
+	)
+
+	place := &cobra.Command{
+		Use:   "place <schematic> <x> <y> <z>",
+		Short: "Build a tree or house from the schematic library at the given coordinates",
+		Long: fmt.Sprintf(`Build a structure from the schematic library with its lowest corner at the given coordinates,
+which should be one block above the ground. The schematics are: %s.
+
+--size sets the height of a tree's trunk or the width and depth of a house. --seed varies random details such as the
+leaves at the corners of a tree's canopy.`, strings.Join(world.Schematics(), ", ")),
+		Args:      cobra.ExactArgs(4),
+		ValidArgs: world.Schematics(),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if opts.Wood, err = world.ParseWood(wood); err != nil {
+				fatal(usageError{err})
+			}
+
+			c, err := world.BuildSchematic(args[0], opts)
+			if err != nil {
+				fatal(err)
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			s := world.NewEditorSession(w)
+			s.Clipboard = c
+
+			x, y, z := atoi(args[1]), atoi(args[2]), atoi(args[3])
+			if err := s.Paste(x, y, z, int(cfg.Dimension)); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%s placed at %d %d %d\n", args[0], x, y, z)
+		},
+	}
+
+	place.Flags().StringVar(&wood, "wood", "oak",
+		"the tree species or the wood to build with: oak, birch, spruce or jungle")
+	place.Flags().IntVar(&opts.Size, "size", 0, "the size of the structure, or 0 for the schematic's default")
+	place.Flags().Int64Var(&opts.Seed, "seed", 0, "the seed of random details")
+
+	return place
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/danhale-git/mine/remote"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+const playerHelp = `The player is "local" for the owner of a single player world, or the ID after player_server_ in the
+key of a multiplayer player record, as listed by 'mine player list'. Player names are not saved in the world, but for a
+world in a dedicated server directory the names in the server's allowlist are shown beside the keys of player records
+and may be used instead. The dimension defaults to the configured dimension. Negative coordinates must follow --.`
+
+func newPlayerCmd() *cobra.Command {
+	player := &cobra.Command{
+		Use:   "player",
+		Short: "List and compare players, move them and set their spawn points without opening the game",
+	}
+
+	player.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the position and spawn point of every player",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			positions, err := w.PlayerPositions()
+			if err != nil {
+				fatal(err)
+			}
+
+			players := serverPlayers(w)
+
+			for _, p := range positions {
+				spawn := "world spawn"
+				if p.HasSpawn {
+					spawn = fmt.Sprintf("%d %d %d in the %s", p.SpawnX, p.SpawnY, p.SpawnZ, world.Dimension(p.SpawnDimension))
+				}
+
+				fmt.Printf("%s at %.1f %.1f %.1f in the %s, spawns at %s\n",
+					playerName(players, p.Key), p.X, p.Y, p.Z, world.Dimension(p.Dimension), spawn)
+			}
+		},
+	})
+
+	player.AddCommand(&cobra.Command{
+		Use:   "compare",
+		Short: "Compare the position, level, health, last death and wealth of every player",
+		Long: `Compare the position, level, health, last death and wealth of every player, richest first, to audit the
+players of a multiplayer world offline.
+
+Wealth is a rough value of the items each player carries, wears and has in their ender chest, with iron worth 1,
+diamond 8 and netherite 36. Rare items such as elytra are worth more and each enchantment level adds 2. The contents
+of shulker boxes are not counted.
+
+If the output setting in the config file is json, the table is printed as JSON.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			stats, err := w.PlayerStats()
+			if err != nil {
+				fatal(err)
+			}
+
+			players := serverPlayers(w)
+
+			if cfg.Output == "json" {
+				printPlayerStatsJSON(stats, players)
+				return
+			}
+
+			fmt.Printf("%-50s %-9s %-26s %5s %6s %-26s %6s %7s\n",
+				"player", "mode", "position", "level", "health", "last death", "items", "wealth")
+
+			for _, s := range stats {
+				death := "never"
+				if s.HasDied {
+					death = fmt.Sprintf("%d %d %d %s", s.DeathX, s.DeathY, s.DeathZ, world.Dimension(s.DeathDimension))
+				}
+
+				position := fmt.Sprintf("%.0f %.0f %.0f %s", s.X, s.Y, s.Z, world.Dimension(s.Dimension))
+
+				fmt.Printf("%-50s %-9s %-26s %5d %6.1f %-26s %6d %7d\n",
+					playerName(players, s.Key), world.GameTypeName(s.GameType), position, s.Level, s.Health, death, s.Items, s.Value)
+			}
+		},
+	})
+
+	player.AddCommand(&cobra.Command{
+		Use:   "tp <player> <x> <y> <z> [dimension]",
+		Short: "Move a player, for example out of a broken chunk",
+		Long: `Move a player so that their feet are at the center of the given block, for example:
+
+  mine player tp local -- 120 70 -340 nether
+
+` + playerHelp,
+		Args: cobra.RangeArgs(4, 5),
+		Run: func(cmd *cobra.Command, args []string) {
+			w, key, d := openPlayer(args)
+			defer w.Close()
+
+			x, y, z := atoi(args[1]), atoi(args[2]), atoi(args[3])
+
+			if err := w.TeleportPlayer(key, float64(x)+0.5, float64(y), float64(z)+0.5, int(d)); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%s moved to %d %d %d in the %s\n", key, x, y, z, d)
+		},
+	})
+
+	player.AddCommand(&cobra.Command{
+		Use:   "spawn <player> <x> <y> <z> [dimension]",
+		Short: "Set the point a player respawns at",
+		Long: `Set the point a player respawns at, as if they had slept in a bed there, for example:
+
+  mine player spawn abc123 -- 120 70 -340
+
+` + playerHelp,
+		Args: cobra.RangeArgs(4, 5),
+		Run: func(cmd *cobra.Command, args []string) {
+			w, key, d := openPlayer(args)
+			defer w.Close()
+
+			x, y, z := atoi(args[1]), atoi(args[2]), atoi(args[3])
+
+			if err := w.SetPlayerSpawn(key, x, y, z, int(d)); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%s spawn point set to %d %d %d in the %s\n", key, x, y, z, d)
+		},
+	})
+
+	return player
+}
+
+func printPlayerStatsJSON(stats []world.PlayerStats, players map[string]world.ServerPlayer) {
+	type position struct {
+		X         float64         `json:"x"`
+		Y         float64         `json:"y"`
+		Z         float64         `json:"z"`
+		Dimension world.Dimension `json:"dimension"`
+	}
+
+	type player struct {
+		Key        string    `json:"key"`
+		Name       string    `json:"name,omitempty"`
+		Permission string    `json:"permission,omitempty"`
+		GameType   string    `json:"gameType"`
+		Position   position  `json:"position"`
+		Spawn      *position `json:"spawn"`
+		Level      int       `json:"level"`
+		Health     float64   `json:"health"`
+		Hunger     float64   `json:"hunger"`
+		LastDeath  *position `json:"lastDeath"`
+		Items      int       `json:"items"`
+		Wealth     int       `json:"wealth"`
+	}
+
+	out := make([]player, len(stats))
+	for i, s := range stats {
+		out[i] = player{
+			Key:        s.Key,
+			Name:       players[s.Key].Name,
+			Permission: players[s.Key].Permission,
+			GameType:   world.GameTypeName(s.GameType),
+			Position:   position{s.X, s.Y, s.Z, world.Dimension(s.Dimension)},
+			Level:      s.Level,
+			Health:     s.Health,
+			Hunger:     s.Hunger,
+			Items:      s.Items,
+			Wealth:     s.Value,
+		}
+
+		if s.HasSpawn {
+			d := world.Dimension(s.SpawnDimension)
+			out[i].Spawn = &position{float64(s.SpawnX), float64(s.SpawnY), float64(s.SpawnZ), d}
+		}
+
+		if s.HasDied {
+			d := world.Dimension(s.DeathDimension)
+			out[i].LastDeath = &position{float64(s.DeathX), float64(s.DeathY), float64(s.DeathZ), d}
+		}
+	}
+
+	e := json.NewEncoder(os.Stdout)
+	e.SetIndent("", "  ")
+
+	if err := e.Encode(out); err != nil {
+		fatal(err)
+	}
+}
+
+// serverPlayers returns the players named in the files of the dedicated server the world is in, by the key of their
+// player record. It returns nil if the world is not in a server directory.
+func serverPlayers(w *world.World) map[string]world.ServerPlayer {
+	path, err := worldPath()
+	if err != nil || remote.IsURI(path) {
+		return nil
+	}
+
+	dir, ok := world.ServerDir(path)
+	if !ok {
+		return nil
+	}
+
+	f, err := world.ReadServerFiles(dir)
+	if err != nil {
+		fatal(err)
+	}
+
+	players, err := w.ServerPlayers(f)
+	if err != nil {
+		fatal(err)
+	}
+
+	return players
+}
+
+// playerName returns the name and permission of the player with the given record key, followed by the key, or just the
+// key if the player is not named in the server files.
+func playerName(players map[string]world.ServerPlayer, key string) string {
+	p, ok := players[key]
+	if !ok {
+		return key
+	}
+
+	if p.Permission != "" {
+		return fmt.Sprintf("%s [%s] (%s)", p.Name, p.Permission, key)
+	}
+
+	return fmt.Sprintf("%s (%s)", p.Name, key)
+}
+
+// openPlayer opens the world and returns the record key of the player named by the first argument and the dimension
+// given by the optional fifth argument.
+func openPlayer(args []string) (*world.World, string, world.Dimension) {
+	d := cfg.Dimension
+	if len(args) == 5 {
+		var err error
+		if d, err = world.ParseDimension(args[4]); err != nil {
+			fatal(usageError{err})
+		}
+	}
+
+	w, err := openWorld()
+	if err != nil {
+		fatal(err)
+	}
+
+	key, err := w.PlayerKey(args[0])
+	if errors.Is(err, world.ErrPlayerNotFound) {
+		for k, p := range serverPlayers(w) {
+			if strings.EqualFold(p.Name, args[0]) {
+				key, err = k, nil
+			}
+		}
+	}
+	if err != nil {
+		w.Close()
+		fatal(err)
+	}
+
+	return w, key, d
+}
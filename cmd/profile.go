@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danhale-git/mine/profile"
+	"github.com/danhale-git/mine/world"
+)
+
+var (
+	pprofAddr   string
+	showTimings bool
+
+	// timings records the stages of the command if the timings flag is set, otherwise it is nil and records nothing.
+	timings *profile.Timings
+)
+
+// startProfiling starts recording stage timings and serving pprof endpoints, as set by the timings and pprof flags.
+func startProfiling() error {
+	if showTimings {
+		timings = profile.NewTimings()
+	}
+
+	if pprofAddr == "" {
+		return nil
+	}
+
+	addr, err := profile.Serve(pprofAddr)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "serving profiles at http://%s/debug/pprof/\n", addr)
+
+	return nil
+}
+
+// writeTimings writes the stage timings and the hit rate of the decoded palette cache to stderr, if the timings flag
+// is set.
+func writeTimings() error {
+	if timings == nil {
+		return nil
+	}
+
+	if err := timings.WriteSummary(os.Stderr); err != nil {
+		return err
+	}
+
+	if s := world.PaletteCache(); s.Hits+s.Misses > 0 {
+		fmt.Fprintf(os.Stderr, "palette cache: %d hits, %d decoded, %.1f%% hit rate\n",
+			s.Hits, s.Misses, 100*s.HitRate())
+	}
+
+	return nil
+}
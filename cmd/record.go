@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/danhale-git/mine/nbt"
+	"github.com/spf13/cobra"
+)
+
+const keyHelp = `Keys which are printable text, such as portals or ~local_player, are given as text. Others, such as
+chunk records, are given in hexadecimal prefixed with 0x, as printed by mine grep.`
+
+func newRecordCmd() *cobra.Command {
+	record := &cobra.Command{
+		Use:   "record",
+		Short: "Read or write any NBT record as JSON",
+		Long: `Read or write any record holding NBT data as JSON, to edit records this program doesn't otherwise
+support.
+
+The JSON is in the form written by nbt2json. Every tag has its tagType, so the record is written back exactly as it
+was read, apart from any edits. Longs are objects holding two 32 bit halves, or may be replaced with decimal strings.
+
+  mine record get portals > portals.json
+  mine record put portals portals.json
+
+` + keyHelp,
+	}
+
+	record.AddCommand(&cobra.Command{
+		Use:   "get <key>",
+		Short: "Print a record as JSON",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			key, err := parseKey(args[0])
+			if err != nil {
+				fatal(err)
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			tags, err := w.Record(key)
+			if err != nil {
+				fatal(err)
+			}
+
+			data, err := nbt.EncodeJSON(tags)
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Println(string(data))
+		},
+	})
+
+	record.AddCommand(&cobra.Command{
+		Use:   "put <key> <file.json>",
+		Short: "Write a record from JSON, given as a file or - for standard input",
+		Long: `Write a record from JSON in the form printed by mine record get, given as a file or - for standard
+input. The record is created if it doesn't exist, and deleted if the JSON has no tags. Sub chunks and other chunk
+records which don't hold NBT data can't be written.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			key, err := parseKey(args[0])
+			if err != nil {
+				fatal(err)
+			}
+
+			var data []byte
+			if args[1] == "-" {
+				data, err = ioutil.ReadAll(os.Stdin)
+			} else {
+				data, err = ioutil.ReadFile(args[1])
+			}
+			if err != nil {
+				fatal(err)
+			}
+
+			tags, err := nbt.DecodeJSON(data)
+			if err != nil {
+				fatalf("reading %s: %s", args[1], err)
+			}
+
+			// Tags are encoded before the world is opened, so invalid values are found without changing anything
+			if _, err := nbt.Encode(tags); err != nil {
+				fatalf("encoding %s: %s", args[1], err)
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			if err := w.SetRecord(key, tags); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d tags written to %s\n", len(tags), keyString(key))
+		},
+	})
+
+	return record
+}
+
+// parseKey returns the key given as text, or in hexadecimal prefixed with 0x.
+func parseKey(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return []byte(s), nil
+	}
+
+	key, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid hexadecimal key '%s': %w", s, err)
+	}
+
+	return key, nil
+}
+
+// keyString returns a key as text if every byte of it is printable ASCII, otherwise in hexadecimal prefixed with 0x, as
+// read by parseKey.
+func keyString(key []byte) string {
+	for _, b := range key {
+		if b > unicode.MaxASCII || !unicode.IsPrint(rune(b)) {
+			return fmt.Sprintf("0x%x", key)
+		}
+	}
+
+	if strings.HasPrefix(string(key), "0x") {
+		return fmt.Sprintf("0x%x", key)
+	}
+
+	return string(key)
+}
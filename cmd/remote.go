@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/danhale-git/mine/output"
+	"github.com/danhale-git/mine/remote"
+	"github.com/danhale-git/mine/world"
+)
+
+// remoteWorld is a world in object storage which has been downloaded to a temporary directory to be opened.
+type remoteWorld struct {
+	uri     string
+	mirror  *remote.Mirror
+	changed bool // Set when a record is changed through the world
+}
+
+// remoteWorlds are the worlds in object storage opened by the current command.
+var remoteWorlds []*remoteWorld
+
+// mirrorRemoteWorld downloads a world in object storage to a temporary directory.
+func mirrorRemoteWorld(uri string) (*remoteWorld, error) {
+	loc, err := remote.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := remote.NewClient(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "mine-world-")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary directory: %w", err)
+	}
+
+	m, err := remote.NewMirror(context.Background(), c, loc, dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("downloading %s: %w", uri, err)
+	}
+
+	r := &remoteWorld{uri: uri, mirror: m}
+	remoteWorlds = append(remoteWorlds, r)
+
+	return r, nil
+}
+
+// watch records whether the world opened from the mirror is changed.
+func (r *remoteWorld) watch(w *world.World) {
+	w.OnChange(func(world.ChangeEvent) { r.changed = true })
+}
+
+// syncRemoteWorlds uploads remote worlds changed by the command and removes the local copies. LevelDB rewrites some of
+// its files whenever it is opened, so the database is only uploaded if a record was changed, while other files such as
+// level.dat are uploaded whenever they change.
+func syncRemoteWorlds() error {
+	defer func() { remoteWorlds = nil }()
+
+	for _, r := range remoteWorlds {
+		modified, err := r.mirror.Modified()
+		if err != nil {
+			return err
+		}
+
+		upload := r.changed
+		for _, path := range modified {
+			upload = upload || !strings.HasPrefix(path, "db/")
+		}
+
+		if upload {
+			up, del, err := r.mirror.Sync(context.Background())
+			if err != nil {
+				return fmt.Errorf("uploading %s: %w", r.uri, err)
+			}
+			fmt.Fprintf(os.Stderr, "%s: %d files uploaded, %d deleted\n", r.uri, up, del)
+		}
+
+		if err := r.mirror.Remove(); err != nil {
+			return fmt.Errorf("removing local copy of %s: %w", r.uri, err)
+		}
+	}
+
+	return nil
+}
+
+// createOutput creates a local file or an object in object storage which compresses everything written to it.
+func createOutput(path string, c output.Compression) (io.WriteCloser, error) {
+	if !remote.IsURI(path) {
+		return output.Create(path, c)
+	}
+
+	loc, err := remote.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := remote.NewClient(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := remote.Create(context.Background(), client, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := c.NewWriter(obj)
+	if err != nil {
+		obj.Close()
+		return nil, err
+	}
+
+	return &chainedWriter{WriteCloser: w, next: obj}, nil
+}
+
+// chainedWriter closes a compressing writer and then the writer it compresses to.
+type chainedWriter struct {
+	io.WriteCloser
+	next io.Closer
+}
+
+func (w *chainedWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		w.next.Close()
+		return err
+	}
+
+	return w.next.Close()
+}
+
+// openInput opens a local file or an object in object storage, decrypting it with the key if it is encrypted and
+// decompressing it as it is read.
+func openInput(path string, key *output.Key) (io.ReadCloser, error) {
+	if !remote.IsURI(path) {
+		return output.Open(path, key)
+	}
+
+	loc, err := remote.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := remote.NewClient(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := remote.Open(context.Background(), client, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := output.NewReader(obj, key)
+	if err != nil {
+		obj.Close()
+		return nil, err
+	}
+
+	return &chainedReader{ReadCloser: r, next: obj}, nil
+}
+
+// chainedReader closes a decompressing reader and then the reader it decompresses.
+type chainedReader struct {
+	io.ReadCloser
+	next io.Closer
+}
+
+func (r *chainedReader) Close() error {
+	err := r.ReadCloser.Close()
+	if nerr := r.next.Close(); err == nil {
+		err = nerr
+	}
+
+	return err
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danhale-git/mine/render"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// mapOptions are the renderer settings shared by commands which draw maps.
+type mapOptions struct {
+	contours int
+	caves    bool
+	mode     string
+	slice    int
+	ceiling  bool
+	grid     bool
+	labels   bool
+	markers  string
+
+	flags *pflag.FlagSet
+}
+
+func (o *mapOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&o.contours, "contours", 0, "draw elevation contour lines every this many blocks")
+	cmd.Flags().BoolVar(&o.caves, "caves", false, "darken the map where there is air below the surface")
+	cmd.Flags().StringVar(&o.mode, "mode", "block",
+		"color columns by surface 'block', by 'biome', or by block 'blended' with biome grass, foliage and water tints")
+	cmd.Flags().IntVar(&o.slice, "slice", 0, "ignore blocks above this height, to see below the surface")
+	cmd.Flags().BoolVar(&o.ceiling, "ceiling", false,
+		"draw the floor below the first air in each column rather than the roof above it (default in the Nether)")
+	cmd.Flags().BoolVar(&o.grid, "grid", false, "draw chunk grid lines")
+	cmd.Flags().BoolVar(&o.labels, "labels", false, "label chunk grid lines with their block coordinates")
+	cmd.Flags().StringVar(&o.markers, "markers", "",
+		`JSON file of markers to draw, e.g. [{"name": "Base", "x": 10, "z": -20, "icon": "house"}]`)
+
+	o.flags = cmd.Flags()
+}
+
+func (o *mapOptions) overlay() render.Overlay {
+	overlay := render.Overlay{Grid: o.grid, Labels: o.labels}
+
+	if o.markers != "" {
+		markers, err := render.ReadMarkers(o.markers)
+		if err != nil {
+			fatal(err)
+		}
+		overlay.Markers = markers
+	}
+
+	return overlay
+}
+
+func (o *mapOptions) renderer() *render.Renderer {
+	mode, err := render.ParseMode(o.mode)
+	if err != nil {
+		fatal(usageError{err})
+	}
+
+	r := render.NewRenderer()
+	r.Mode = mode
+	r.Palette = blockPalette
+	r.ContourInterval = o.contours
+	r.Caves = o.caves
+	r.Workers = parallelism()
+
+	if o.flags.Changed("slice") || o.flags.Changed("ceiling") {
+		surface := render.DefaultSurface(int(cfg.Dimension))
+		if o.flags.Changed("slice") {
+			surface.MaxY = &o.slice
+		}
+		if o.flags.Changed("ceiling") {
+			surface.BelowCeiling = o.ceiling
+		}
+		r.Surface = &surface
+	}
+
+	return r
+}
+
+func newMapCmd() *cobra.Command {
+	var out, since string
+	var scale int
+	var opts mapOptions
+
+	m := &cobra.Command{
+		Use:   "map <x1> <z1> <x2> <z2>",
+		Short: "Render a top down map of an area as a PNG",
+		Args:  cobra.ExactArgs(4),
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			area := areaArgs(args)
+
+			end := timings.Start("render")
+			img, err := opts.renderer().Map(w, area)
+			if err != nil {
+				fatal(err)
+			}
+			end()
+
+			overlay := opts.overlay()
+			if since != "" {
+				end := timings.Start("compare")
+				overlay.Changes = changesSince(w, since, area.Dimension)
+				end()
+				fmt.Printf("%d chunks changed since %s\n", len(overlay.Changes), since)
+			}
+
+			img = render.Scale(img, scale)
+			overlay.Draw(img, area, scale)
+
+			defer timings.Start("write")()
+
+			f, err := os.Create(out)
+			if err != nil {
+				fatal(err)
+			}
+			defer f.Close()
+
+			if err := png.Encode(f, img); err != nil {
+				fatalf("encoding %s: %s", out, err)
+			}
+		},
+	}
+
+	m.Flags().StringVarP(&out, "out", "o", "map.png", "the PNG file to write")
+	m.Flags().IntVar(&scale, "scale", 1, "the size of each block in pixels")
+	m.Flags().StringVar(&since, "changes-since", "",
+		"shade each chunk by the number of blocks changed since this earlier backup of the world")
+	opts.addFlags(m)
+
+	return m
+}
+
+func newTimelapseCmd() *cobra.Command {
+	var out string
+	var scale, delay int
+	var heatmap bool
+	var opts mapOptions
+
+	timelapse := &cobra.Command{
+		Use:   "timelapse <backups directory> <x1> <z1> <x2> <z2>",
+		Short: "Render the same area from a series of world backups as an animation",
+		Long: `Render the same area from each world in a directory of backups, in order of directory name, so backups named by date
+play in date order. Each directory holding a world database is one frame.
+
+If --out ends with .gif an animated GIF is written, otherwise a directory of numbered PNG frames. Chunks which are the
+same as in the previous backup are not redrawn. With --heatmap each frame is shaded by the number of blocks changed in
+each chunk since the previous backup.`,
+		Args: cobra.ExactArgs(5),
+		Run: func(cmd *cobra.Command, args []string) {
+			backups := worldsIn(args[0])
+			if len(backups) == 0 {
+				fatalf("no worlds found in %s", args[0])
+			}
+
+			area := areaArgs(args[1:])
+			r := opts.renderer()
+			overlay := opts.overlay()
+			frames := make([]*image.RGBA, 0, len(backups))
+
+			// With --heatmap the previous backup stays open to compare with the next
+			var previous *world.World
+
+			for _, b := range backups {
+				w, err := openWorldPath(b.path)
+				if err != nil {
+					fatalf("opening %s: %s", b.path, err)
+				}
+
+				end := timings.Start("render")
+				img, err := r.Map(w, area)
+				if err != nil {
+					fatalf("rendering %s: %s", b.path, err)
+				}
+				end()
+
+				if heatmap && previous != nil {
+					end := timings.Start("compare")
+					if overlay.Changes, err = w.ChunkChanges(previous, area.Dimension); err != nil {
+						fatalf("comparing %s with the previous backup: %s", b.path, err)
+					}
+					end()
+					previous.Close()
+				}
+
+				if heatmap {
+					previous = w
+				} else {
+					w.Close()
+				}
+
+				img = render.Scale(img, scale)
+				overlay.Draw(img, area, scale)
+
+				frames = append(frames, img)
+				fmt.Printf("rendered %s\n", filepath.Base(b.path))
+			}
+
+			if previous != nil {
+				previous.Close()
+			}
+
+			fmt.Printf("%d chunks drawn, %d reused from earlier backups\n", r.Drawn, r.Reused)
+
+			defer timings.Start("write")()
+
+			if strings.EqualFold(filepath.Ext(out), ".gif") {
+				if err := render.WriteGIF(out, frames, delay); err != nil {
+					fatal(err)
+				}
+				return
+			}
+
+			if err := render.WriteFrames(out, frames); err != nil {
+				fatal(err)
+			}
+		},
+	}
+
+	timelapse.Flags().StringVarP(&out, "out", "o", "timelapse.gif", "the GIF file or frame directory to write")
+	timelapse.Flags().IntVar(&scale, "scale", 1, "the size of each block in pixels")
+	timelapse.Flags().IntVar(&delay, "delay", 50, "the time each frame is shown in a GIF, in hundredths of a second")
+	timelapse.Flags().BoolVar(&heatmap, "heatmap", false,
+		"shade each chunk by the number of blocks changed since the previous backup")
+	opts.addFlags(timelapse)
+
+	return timelapse
+}
+
+// changesSince returns the number of blocks changed in each chunk of a dimension of w since the backup at path.
+func changesSince(w *world.World, path string, dimension int) map[[2]int]int {
+	before, err := openWorldPath(path)
+	if err != nil {
+		fatalf("opening %s: %s", path, err)
+	}
+	defer before.Close()
+
+	changes, err := w.ChunkChanges(before, dimension)
+	if err != nil {
+		fatalf("comparing with %s: %s", path, err)
+	}
+
+	return changes
+}
+
+// areaArgs parses x1 z1 x2 z2 arguments as an area in the configured dimension.
+func areaArgs(args []string) render.Area {
+	return render.NewArea(atoi(args[0]), atoi(args[1]), atoi(args[2]), atoi(args[3]), int(cfg.Dimension))
+}
@@ -0,0 +1,479 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/remote"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newRepairCmd() *cobra.Command {
+	repair := &cobra.Command{
+		Use:   "repair",
+		Short: "Detect and fix inconsistent world records",
+		Long: `Detect and fix inconsistent world records.
+
+Each repair reports the problems it finds. Nothing is written to the world unless --fix is given.`,
+	}
+
+	repair.PersistentFlags().BoolVar(&fix, "fix", false, "apply the repair instead of only reporting problems")
+
+	repair.AddCommand(newRepairGhostBlockEntitiesCmd())
+	repair.AddCommand(newRepairStrayEntitiesCmd())
+	repair.AddCommand(newRepairRidesCmd())
+	repair.AddCommand(newRepairPortalsCmd())
+	repair.AddCommand(newRepairVillagesCmd())
+	repair.AddCommand(newRepairMapsCmd())
+	repair.AddCommand(newRepairRecordsCmd())
+	repair.AddCommand(newRepairRaidsCmd())
+	repair.AddCommand(newRepairDatabaseCmd())
+	repair.AddCommand(newRepairLocksCmd())
+
+	return repair
+}
+
+var fix bool
+
+func newRepairGhostBlockEntitiesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ghost-block-entities",
+		Short: "Find block entities (e.g. chest contents) whose block no longer exists",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			ghosts, err := w.GhostBlockEntities()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, g := range ghosts {
+				id := g.Block.ID
+				if id == "" {
+					id = "unsaved sub chunk"
+				}
+
+				fmt.Printf("%s at %d %d %d: block is %s\n", g.ID, g.X, g.Y, g.Z, id)
+			}
+
+			fmt.Printf("%d ghost block entities found\n", len(ghosts))
+
+			if !fix || len(ghosts) == 0 {
+				return
+			}
+
+			if err := w.RemoveGhostBlockEntities(ghosts); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d ghost block entities removed\n", len(ghosts))
+		},
+	}
+}
+
+func newRepairStrayEntitiesCmd() *cobra.Command {
+	var teleport bool
+
+	c := &cobra.Command{
+		Use:   "stray-entities",
+		Short: "Find entities below the void or outside generated chunks",
+		Long: `Find entities below the void or outside generated chunks.
+
+With --fix stray entities are deleted, or moved to the world spawn point if --teleport is also given.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			strays, err := w.StrayEntities()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, s := range strays {
+				fmt.Printf("%s %d at %.1f %.1f %.1f: %s\n", s.Identifier, s.UniqueID, s.X, s.Y, s.Z, s.Reason)
+			}
+
+			fmt.Printf("%d stray entities found\n", len(strays))
+
+			if !fix {
+				return
+			}
+
+			for _, s := range strays {
+				if teleport {
+					_, err = w.TeleportToSpawn(s.Entity)
+				} else {
+					err = w.RemoveEntity(s.Entity)
+				}
+
+				if err != nil {
+					fatalf("repairing %s %d: %s", s.Identifier, s.UniqueID, err)
+				}
+			}
+
+			if teleport {
+				fmt.Printf("%d stray entities moved to spawn\n", len(strays))
+			} else {
+				fmt.Printf("%d stray entities removed\n", len(strays))
+			}
+		},
+	}
+
+	c.Flags().BoolVar(&teleport, "teleport", false, "move stray entities to spawn instead of deleting them")
+
+	return c
+}
+
+func newRepairRidesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rides",
+		Short: "Find vehicles and mounts listing riders which no longer exist",
+		Long: `Find vehicles and mounts listing riders which no longer exist, usually left behind when the rider was
+deleted by an external edit. These can cause invisible mobs or crashes when the vehicle is loaded.
+
+With --fix the missing riders are removed from the vehicles.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			broken, err := w.BrokenRides()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, l := range broken {
+				fmt.Printf("entity %d lists missing rider %d\n", l.To, l.From)
+			}
+
+			fmt.Printf("%d broken rides found\n", len(broken))
+
+			if !fix || len(broken) == 0 {
+				return
+			}
+
+			if err := w.RemoveRides(broken); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d broken rides removed\n", len(broken))
+		},
+	}
+}
+
+func newRepairPortalsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "portals",
+		Short: "Compare the portals record with the portal blocks in the world",
+		Long: `Compare the portals record with the portal blocks in the world.
+
+With --fix the portals record is rebuilt from a scan of every sub chunk.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			mismatches, err := w.PortalMismatches()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, m := range mismatches {
+				fmt.Printf("dimension %d portal at %d %d %d: %s\n", m.Dimension, m.TpX, m.TpY, m.TpZ, m.Reason)
+			}
+
+			fmt.Printf("%d portal mismatches found\n", len(mismatches))
+
+			if !fix || len(mismatches) == 0 {
+				return
+			}
+
+			portals, err := w.RebuildPortals()
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("portals record rebuilt with %d portals\n", len(portals))
+		},
+	}
+}
+
+func newRepairVillagesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "villages",
+		Short: "Find village dwellers and points of interest referencing missing entities or chunks",
+		Long: `Find village dwellers and points of interest referencing missing entities or chunks.
+
+With --fix the stale dwellers and points of interest are removed from the VILLAGE_ records.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			villages, err := w.Villages()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, v := range villages {
+				fmt.Printf("village %s: %d dwellers, %d points of interest\n", v.ID, len(v.Dwellers), len(v.POIs))
+			}
+
+			problems, err := w.VillageProblems()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, p := range problems {
+				if p.POI != nil {
+					fmt.Printf("village %s: %s at %d %d %d %s\n", p.VillageID, p.POI.Name, p.POI.X, p.POI.Y, p.POI.Z, p.Reason)
+				} else {
+					fmt.Printf("village %s: dweller %d %s\n", p.VillageID, p.Dweller, p.Reason)
+				}
+			}
+
+			fmt.Printf("%d village problems found\n", len(problems))
+
+			if !fix || len(problems) == 0 {
+				return
+			}
+
+			if err := w.RemoveVillageProblems(problems); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d village problems removed\n", len(problems))
+		},
+	}
+}
+
+func newRepairMapsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "maps",
+		Short: "Find map records which are not referenced by any map item",
+		Long: `Find map records which are not referenced by any map item.
+
+With --fix the unreferenced map records are deleted.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			unreferenced, err := w.UnreferencedMaps()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, id := range unreferenced {
+				fmt.Printf("map %d is not referenced\n", id)
+			}
+
+			fmt.Printf("%d unreferenced maps found\n", len(unreferenced))
+
+			if !fix || len(unreferenced) == 0 {
+				return
+			}
+
+			if err := w.DeleteMaps(unreferenced); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d unreferenced maps deleted\n", len(unreferenced))
+		},
+	}
+}
+
+func newRepairRecordsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "records",
+		Short: "Validate every record which has a registered record handler",
+		Long: `Validate every record which has a registered record handler.
+
+With --fix records whose handlers support migration are upgraded to the current format.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			problems, err := w.ValidateRecords()
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, p := range problems {
+				fmt.Printf("%s record '%x': %s\n", p.Handler, p.Key, p.Err)
+			}
+
+			fmt.Printf("%d invalid records found\n", len(problems))
+
+			if !fix {
+				return
+			}
+
+			n, err := w.MigrateRecords()
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d records migrated\n", n)
+		},
+	}
+}
+
+func newRepairDatabaseCmd() *cobra.Command {
+	var backup string
+
+	c := &cobra.Command{
+		Use:   "database",
+		Short: "Check for and recover from damage to the world database, such as after a crash while saving",
+		Long: `Check the world database for damage, such as when the game crashed while saving, and report what can't be
+read.
+
+With --fix the database is recovered to its latest consistent state. Damaged records in the journal, which holds the
+most recent changes, are dropped, and if the manifest listing the database files is damaged it is rebuilt from the
+files found. The database files are copied to --backup first, which defaults to a directory next to the world.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := worldPath()
+			if err != nil {
+				fatal(err)
+			}
+			if remote.IsURI(path) {
+				fatal("repair database can't be run on a world in object storage: download the world first")
+			}
+
+			r, err := leveldb.Check(path)
+			if err != nil {
+				fatal(err)
+			}
+
+			printRecoveryReport(r)
+
+			if !fix || !r.Damaged() {
+				return
+			}
+
+			if backup == "" {
+				backup = filepath.Clean(path) + "-db-backup-" + time.Now().Format("20060102-150405")
+			}
+
+			if err := leveldb.Backup(path, backup); err != nil {
+				fatal(err)
+			}
+			fmt.Printf("database copied to %s\n", backup)
+
+			db, r, err := leveldb.Recover(path)
+			if err != nil {
+				fatal(err)
+			}
+
+			if err := db.Close(); err != nil {
+				fatal(err)
+			}
+
+			if r.Rebuilt {
+				fmt.Println("manifest rebuilt from the database files")
+			}
+			fmt.Printf("database recovered with %d keys\n", r.Keys)
+		},
+	}
+
+	c.Flags().StringVar(&backup, "backup", "", "the directory to copy the database files to before recovering")
+
+	return c
+}
+
+func newRepairLocksCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "locks",
+		Short: "List the parts of the world claimed by mine processes and remove claims left by crashed processes",
+		Long: `List the parts of the world claimed by mine processes editing it. Claims of processes on this computer
+which are no longer running are stale, and are removed with --fix. They are also ignored by other mine commands.
+
+Claims made on another computer, such as when the world is on a network share, can't be checked and are never stale.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := worldPath()
+			if err != nil {
+				fatal(err)
+			}
+			if remote.IsURI(path) {
+				fatal("repair locks can't be run on a world in object storage")
+			}
+
+			claims, err := world.Claims(path)
+			if err != nil {
+				fatal(err)
+			}
+
+			stale := 0
+			for _, c := range claims {
+				if c.IsStale() {
+					stale++
+					fmt.Printf("stale: %s\n", c)
+				} else {
+					fmt.Println(c)
+				}
+			}
+
+			fmt.Printf("%d claims found, %d stale\n", len(claims), stale)
+
+			if !fix || stale == 0 {
+				return
+			}
+
+			removed, err := world.RemoveStaleClaims(path)
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d stale claims removed\n", len(removed))
+		},
+	}
+}
+
+func printRecoveryReport(r leveldb.Report) {
+	for _, p := range r.Problems {
+		fmt.Println(p)
+	}
+
+	if r.OpenErr != nil {
+		fmt.Printf("database is damaged: %s\n", r.OpenErr)
+		return
+	}
+
+	if len(r.Problems) > 0 {
+		fmt.Printf("database opened with %d keys, but %d damaged journal records will be dropped\n",
+			r.Keys, len(r.Problems))
+		return
+	}
+
+	fmt.Printf("no damage found in %d keys\n", r.Keys)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/danhale-git/mine/output"
+	"github.com/danhale-git/mine/render"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newReportCmd() *cobra.Command {
+	var out string
+	var thumbnails bool
+
+	report := &cobra.Command{
+		Use:   "report",
+		Short: "Write a single HTML file summarising the world, to share with others",
+		Long: `Write a single HTML file summarising the world, with charts of ore distribution by height, the biomes,
+blocks and entities found, details from level.dat such as when the world was last played, and the largest builds.
+
+Builds are found by looking for groups of chunks with many blocks usually placed by players, such as planks and glass,
+so villages and other generated structures may also be listed. Each is shown with a map unless --thumbnails=false.
+
+The file has no scripts or links to other files, so it can be opened anywhere or attached to a message.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			r := render.Report{Palette: blockPalette}
+
+			// A world without a readable level.dat can still be summarised
+			if info, err := w.LevelInfo(); err == nil {
+				r.Info = &info
+			} else {
+				fmt.Fprintf(os.Stderr, "level.dat details left out of report: %s\n", err)
+			}
+
+			// A report of what was read before the time budget ran out is still written
+			end := timings.Start("stats")
+			var partial *world.PartialResultError
+			if r.Stats, err = w.Stats(); err != nil && !errors.As(err, &partial) {
+				fatal(err)
+			}
+			end()
+
+			if thumbnails {
+				end := timings.Start("thumbnails")
+				renderer := render.NewRenderer()
+				renderer.Palette = blockPalette
+				for _, b := range r.Stats.Builds {
+					img, err := renderer.Map(w, render.BuildArea(b))
+					if err != nil {
+						fatal(err)
+					}
+
+					// Small builds are enlarged so they can be seen
+					if img.Bounds().Dx() <= 64 && img.Bounds().Dy() <= 64 {
+						img = render.Scale(img, 2)
+					}
+					r.Thumbnails = append(r.Thumbnails, img)
+				}
+				end()
+			}
+
+			defer timings.Start("write")()
+
+			f, err := createOutput(out, output.Compression{})
+			if err != nil {
+				fatal(err)
+			}
+
+			if err := r.WriteHTML(f); err != nil {
+				f.Close()
+				fatal(err)
+			}
+
+			if err := f.Close(); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("report written to %s\n", out)
+			if partial != nil {
+				fmt.Printf("stopped early: %s\n", partial)
+			}
+		},
+	}
+
+	report.Flags().StringVarP(&out, "out", "o", "report.html", "the file, or object storage URI, to write the report to")
+	report.Flags().BoolVar(&thumbnails, "thumbnails", true, "include a map of each build")
+
+	return report
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newRestoreCmd() *cobra.Command {
+	var from, region string
+
+	restore := &cobra.Command{
+		Use:   "restore --from <backup> --region <x1,z1,x2,z2>",
+		Short: "Replace chunks in the world with the same chunks from a backup",
+		Long: `Replace every chunk in a region of the world with the same chunk from a backup, leaving the rest of the world
+as it is, for example to undo griefing or an accident without losing other progress.
+
+The region is given in block coordinates in the configured dimension, and every chunk it touches is restored whole,
+including its entities and the contents of its containers. Chunks which are not saved in the backup are removed, so
+the game generates them again. Entities which were in the region in the backup but have since left it are not copied,
+so they aren't duplicated.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if from == "" || region == "" {
+				fatal(usagef("--from and --region must be given"))
+			}
+
+			corners := strings.Split(region, ",")
+			if len(corners) != 4 {
+				fatal(usagef("invalid region '%s': expected x1,z1,x2,z2", region))
+			}
+			area := areaArgs(corners)
+
+			backup, err := openWorldPath(from)
+			if err != nil {
+				fatalf("opening %s: %s", from, err)
+			}
+			defer backup.Close()
+
+			minCX, minCZ := floorDiv(area.MinX, 16), floorDiv(area.MinZ, 16)
+			maxCX, maxCZ := floorDiv(area.MaxX, 16), floorDiv(area.MaxZ, 16)
+
+			// Only the region is claimed, so other mine processes may wait to edit the rest of the world
+			path, err := worldPath()
+			if err != nil {
+				fatal(err)
+			}
+
+			w, err := openWorldClaim(path, world.RegionClaim(commandName, minCX, minCZ, maxCX, maxCZ, area.Dimension))
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			report, err := w.RestoreChunks(backup, minCX, minCZ, maxCX, maxCZ, area.Dimension)
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Println(report)
+		},
+	}
+
+	restore.Flags().StringVar(&from, "from", "", "the backup world to copy chunks from")
+	restore.Flags().StringVar(&region, "region", "",
+		"the corners of the region to restore in block coordinates, e.g. -100,-100,50,80")
+
+	return restore
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <x> <y> <z> <block id>",
+		Short: "Set the block at the given coordinates",
+		Long: `Set the block at the given coordinates in the configured dimension, for example:
+
+  mine set 10 64 -20 minecraft:diamond_block
+
+The block has no block states. The chunk must have been generated by the game.`,
+		Args: cobra.ExactArgs(4),
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			x, y, z := atoi(args[0]), atoi(args[1]), atoi(args[2])
+
+			if err := w.SetBlock(x, y, z, int(cfg.Dimension), world.Block{ID: args[3]}); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("set %d %d %d to %s\n", x, y, z, args[3])
+		},
+	}
+}
+
+func newFillCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "fill <x1> <y1> <z1> <x2> <y2> <z2> <block id>",
+		Short: "Set every block between two corners",
+		Long: `Set every block between two corners in the configured dimension, for example:
+
+  mine fill 0 64 0 31 70 31 minecraft:air
+
+The block has no block states. Block entities in the region are removed. Chunks must have been generated by the game,
+unless the block is air.`,
+		Args: cobra.ExactArgs(7),
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			region := world.NewSelection(
+				atoi(args[0]), atoi(args[1]), atoi(args[2]),
+				atoi(args[3]), atoi(args[4]), atoi(args[5]),
+				int(cfg.Dimension),
+			)
+
+			n, err := w.Fill(region, world.Block{ID: args[6]})
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d blocks set to %s\n", n, args[6])
+		},
+	}
+}
+
+func newCloneCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clone <x1> <y1> <z1> <x2> <y2> <z2> <x> <y> <z>",
+		Short: "Copy the blocks between two corners to the given coordinates",
+		Long: `Copy the blocks between two corners, with their block states and block entities, to the region of the
+same size whose lowest corner is at the given coordinates, in the configured dimension. The regions may overlap.`,
+		Args: cobra.ExactArgs(9),
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			src := world.NewSelection(
+				atoi(args[0]), atoi(args[1]), atoi(args[2]),
+				atoi(args[3]), atoi(args[4]), atoi(args[5]),
+				int(cfg.Dimension),
+			)
+			dst := [3]int{atoi(args[6]), atoi(args[7]), atoi(args[8])}
+
+			n, err := w.Clone(src, dst)
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("%d blocks copied to %d %d %d\n", n, dst[0], dst[1], dst[2])
+		},
+	}
+}
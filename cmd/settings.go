@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newSettingsCmd() *cobra.Command {
+	var difficulty, permissions, xbl, platform string
+	var multiplayer, lan bool
+
+	settings := &cobra.Command{
+		Use:   "settings",
+		Short: "Show or change the difficulty and multiplayer settings",
+		Long: `Show or change the difficulty and multiplayer settings.
+
+Only the settings given as flags are changed, for example:
+
+  mine settings --difficulty hard --xbl friends-only
+
+The world must not be open in the game or a server, which would overwrite the change when it closes.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			s, err := w.Settings()
+			if err != nil {
+				fatal(err)
+			}
+
+			flags := cmd.Flags()
+			changed := false
+
+			if flags.Changed("difficulty") {
+				if s.Difficulty, err = world.ParseDifficulty(difficulty); err != nil {
+					fatal(usageError{err})
+				}
+				changed = true
+			}
+
+			if flags.Changed("permissions") {
+				if s.DefaultPermissions, err = world.ParsePermissionLevel(permissions); err != nil {
+					fatal(usageError{err})
+				}
+				changed = true
+			}
+
+			if flags.Changed("xbl") {
+				if s.XBLBroadcast, err = world.ParseBroadcastMode(xbl); err != nil {
+					fatal(usageError{err})
+				}
+				changed = true
+			}
+
+			if flags.Changed("platform") {
+				if s.PlatformBroadcast, err = world.ParseBroadcastMode(platform); err != nil {
+					fatal(usageError{err})
+				}
+				changed = true
+			}
+
+			if flags.Changed("multiplayer") {
+				s.Multiplayer = multiplayer
+				changed = true
+			}
+
+			if flags.Changed("lan") {
+				s.LANBroadcast = lan
+				changed = true
+			}
+
+			if changed {
+				if err := w.SetSettings(s); err != nil {
+					fatal(err)
+				}
+			}
+
+			fmt.Printf("difficulty: %s\n", s.Difficulty)
+			fmt.Printf("default permissions: %s\n", s.DefaultPermissions)
+			fmt.Printf("multiplayer: %t\n", s.Multiplayer)
+			fmt.Printf("lan broadcast: %t\n", s.LANBroadcast)
+			fmt.Printf("xbox live broadcast: %s\n", s.XBLBroadcast)
+			fmt.Printf("platform broadcast: %s\n", s.PlatformBroadcast)
+		},
+	}
+
+	settings.Flags().StringVar(&difficulty, "difficulty", "", "peaceful, easy, normal or hard")
+	settings.Flags().StringVar(&permissions, "permissions", "", "the default permission level: visitor, member or operator")
+	settings.Flags().StringVar(&xbl, "xbl", "", "xbox live broadcast: none, invite-only, friends-only, friends-of-friends or public")
+	settings.Flags().StringVar(&platform, "platform", "", "platform broadcast, with the same values as --xbl")
+	settings.Flags().BoolVar(&multiplayer, "multiplayer", false, "allow other players to join")
+	settings.Flags().BoolVar(&lan, "lan", false, "show the world to players on the local network")
+
+	return settings
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newSymmetryCmd() *cobra.Command {
+	var axis string
+
+	symmetry := &cobra.Command{
+		Use:   "symmetry <x1> <y1> <z1> <x2> <y2> <z2>",
+		Short: "Report blocks which break mirror symmetry in the selection",
+		Long: `Report blocks which break mirror symmetry in the selection between two corners, when it is mirrored along the
+given axis about its center. Each mismatched pair of blocks is reported once, with the block expected to match.
+
+Only block IDs are compared, so blocks which face in different directions are not reported.`,
+		Args: cobra.ExactArgs(6),
+		Run: func(cmd *cobra.Command, args []string) {
+			a, err := world.ParseAxis(axis)
+			if err != nil {
+				fatal(usageError{err})
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			s := world.NewEditorSession(w)
+			s.Select(world.NewSelection(
+				atoi(args[0]), atoi(args[1]), atoi(args[2]),
+				atoi(args[3]), atoi(args[4]), atoi(args[5]),
+				int(cfg.Dimension),
+			))
+
+			if err := s.Copy(); err != nil {
+				fatal(err)
+			}
+
+			issues, err := s.Clipboard.SymmetryIssues(a)
+			if err != nil {
+				fatal(err)
+			}
+
+			// Report world coordinates rather than coordinates relative to the selection
+			min := s.Selection.Min
+			for _, i := range issues {
+				i.X, i.Y, i.Z = i.X+min[0], i.Y+min[1], i.Z+min[2]
+				i.MirrorX, i.MirrorY, i.MirrorZ = i.MirrorX+min[0], i.MirrorY+min[1], i.MirrorZ+min[2]
+				fmt.Println(i)
+			}
+
+			fmt.Printf("%d asymmetric blocks found\n", len(issues))
+		},
+	}
+
+	symmetry.Flags().StringVar(&axis, "axis", "x", "the axis to mirror along: x, y or z")
+
+	return symmetry
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+func newTransferCmd() *cobra.Command {
+	var to string
+
+	c := &cobra.Command{
+		Use:   "transfer <unique id> <x> <y> <z>",
+		Short: "Move an entity and anything riding it to a position in any dimension",
+		Long: `Move an entity and anything riding it to a position in the configured dimension, or the dimension given by
+--to, for example to rescue a pet stuck in the Nether:
+
+  mine transfer --to overworld -- -4294967295 120 70 -340
+
+Unique IDs are listed by 'mine find entities'. Negative IDs and coordinates must follow --. The chunk at the position must have been generated by the game.`,
+		Args: cobra.ExactArgs(4),
+		Run: func(cmd *cobra.Command, args []string) {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				fatal(usagef("invalid unique id: '%s'", args[0]))
+			}
+
+			d := cfg.Dimension
+			if to != "" {
+				if d, err = world.ParseDimension(to); err != nil {
+					fatal(usageError{err})
+				}
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			x, y, z := float64(atoi(args[1])), float64(atoi(args[2])), float64(atoi(args[3]))
+
+			moved, err := w.TransferEntity(id, x+0.5, y, z+0.5, int(d))
+			if err != nil {
+				fatal(err)
+			}
+
+			for _, e := range moved {
+				fmt.Printf("%s (%s) %d moved to %.1f %.1f %.1f in the %s\n",
+					names.Entity(e.Identifier), e.Identifier, e.UniqueID, e.X, e.Y, e.Z, d)
+			}
+		},
+	}
+
+	c.Flags().StringVar(&to, "to", "", "the dimension to move the entity to: overworld, nether or end")
+
+	return c
+}
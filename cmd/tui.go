@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/danhale-git/mine/filter"
+	"github.com/danhale-git/mine/nbt"
+	"github.com/danhale-git/mine/world"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/spf13/cobra"
+)
+
+const tuiHelp = `[yellow]arrows[-] move  [yellow][ ][-] layer down/up  [yellow]tab[-] switch pane  [yellow]/[-] find blocks  [yellow]q[-] quit`
+
+func newTUICmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tui",
+		Short: "Explore the world in an interactive terminal UI",
+		Long: `Explore the world in an interactive terminal UI.
+
+Browse saved chunks, move a cursor over a layer of blocks to inspect them, view the NBT of block entities and find
+blocks matching a filter expression (see 'mine find blocks --help').`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			t, err := newExplorer(w)
+			if err != nil {
+				fatal(err)
+			}
+
+			if err := t.app.Run(); err != nil {
+				fatal(err)
+			}
+		},
+	}
+}
+
+// explorer is the state of the terminal UI.
+type explorer struct {
+	w *world.World
+
+	app     *tview.Application
+	pages   *tview.Pages
+	chunks  *tview.List
+	layer   *tview.Table
+	info    *tview.TextView
+	nbtTree *tview.TreeView
+	results *tview.List
+
+	chunk world.ChunkPos
+	y     int
+}
+
+func newExplorer(w *world.World) (*explorer, error) {
+	chunks, err := w.Chunks()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &explorer{
+		w:       w,
+		app:     tview.NewApplication(),
+		chunks:  tview.NewList().ShowSecondaryText(false),
+		layer:   tview.NewTable().SetSelectable(true, true),
+		info:    tview.NewTextView().SetDynamicColors(true),
+		nbtTree: tview.NewTreeView(),
+		results: tview.NewList().ShowSecondaryText(false),
+	}
+
+	t.chunks.SetBorder(true).SetTitle(" Chunks ")
+	t.layer.SetBorder(true)
+	t.info.SetBorder(true).SetTitle(" Block ")
+	t.nbtTree.SetBorder(true).SetTitle(" Block entity ")
+	t.results.SetBorder(true).SetTitle(" Find results (esc to close) ")
+
+	for _, c := range chunks {
+		c := c
+		t.chunks.AddItem(fmt.Sprintf("%d %d  dim %d", c.X, c.Z, c.Dimension), "", 0, func() {
+			t.showChunk(c)
+			t.app.SetFocus(t.layer)
+		})
+	}
+
+	t.layer.SetSelectionChangedFunc(func(row, col int) { t.inspect(col, row) })
+
+	side := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(t.info, 8, 0, false).
+		AddItem(t.nbtTree, 0, 1, false)
+
+	main := tview.NewFlex().
+		AddItem(t.chunks, 22, 0, true).
+		AddItem(t.layer, 36, 0, false).
+		AddItem(side, 0, 1, false)
+
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(main, 0, 1, true).
+		AddItem(tview.NewTextView().SetDynamicColors(true).SetText(tuiHelp), 1, 0, false)
+
+	t.pages = tview.NewPages().
+		AddPage("main", root, true, true).
+		AddPage("results", t.results, true, false)
+
+	t.app.SetRoot(t.pages, true).SetInputCapture(t.handleKey)
+
+	if len(chunks) > 0 {
+		t.showChunk(chunks[0])
+	}
+
+	return t, nil
+}
+
+// handleKey handles keys which apply to every pane.
+func (t *explorer) handleKey(e *tcell.EventKey) *tcell.EventKey {
+	if _, ok := t.app.GetFocus().(*tview.InputField); ok {
+		return e
+	}
+
+	switch {
+	case e.Key() == tcell.KeyTab:
+		if t.app.GetFocus() == t.chunks {
+			t.app.SetFocus(t.layer)
+		} else {
+			t.app.SetFocus(t.chunks)
+		}
+		return nil
+	case e.Key() == tcell.KeyEscape && t.frontPage() == "results":
+		t.pages.SwitchToPage("main")
+		t.app.SetFocus(t.layer)
+		return nil
+	case e.Rune() == 'q':
+		t.app.Stop()
+		return nil
+	case e.Rune() == '[':
+		t.y--
+		t.showChunk(t.chunk)
+		return nil
+	case e.Rune() == ']':
+		t.y++
+		t.showChunk(t.chunk)
+		return nil
+	case e.Rune() == '/':
+		t.promptFind()
+		return nil
+	}
+
+	return e
+}
+
+func (t *explorer) frontPage() string {
+	name, _ := t.pages.GetFrontPage()
+	return name
+}
+
+// showChunk draws one layer of the given chunk, one character per block.
+func (t *explorer) showChunk(c world.ChunkPos) {
+	t.chunk = c
+	t.layer.Clear()
+	t.layer.SetTitle(fmt.Sprintf(" Chunk %d %d  y %d ", c.X, c.Z, t.y))
+
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			t.layer.SetCell(z, x, blockCell(t.block(x, z)))
+		}
+	}
+
+	row, col := t.layer.GetSelection()
+	t.inspect(col, row)
+}
+
+// block returns the block at the given position in the current chunk and layer. Blocks in sub chunks which are not
+// saved have an empty ID.
+func (t *explorer) block(x, z int) world.Block {
+	wx, wz := t.chunk.X*16+x, t.chunk.Z*16+z
+
+	b, err := t.w.GetBlock(wx, t.y, wz, t.chunk.Dimension)
+	if err != nil {
+		return world.Block{X: wx, Y: t.y, Z: wz}
+	}
+
+	return b
+}
+
+// blockCell returns a table cell showing the first letter of the block name, colored by block ID.
+func blockCell(b world.Block) *tview.TableCell {
+	name := strings.TrimPrefix(b.ID, "minecraft:")
+
+	switch name {
+	case "":
+		return tview.NewTableCell(" ")
+	case "air":
+		return tview.NewTableCell(".").SetTextColor(tcell.ColorGray)
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(b.ID))
+	color := tcell.PaletteColor(int(h.Sum32()%214) + 17) // Skip the basic and greyscale palette colors
+
+	return tview.NewTableCell(strings.ToUpper(name[:1])).SetTextColor(color)
+}
+
+// inspect shows the block under the cursor and its block entity, if it has one.
+func (t *explorer) inspect(x, z int) {
+	b := t.block(x, z)
+
+	name := "[gray]sub chunk not saved[-]"
+	if b.ID != "" {
+		name = fmt.Sprintf("[yellow]%s[-]\n%s", tview.Escape(names.Block(b.ID)), b.ID)
+	}
+
+	t.info.SetText(fmt.Sprintf("%s\n\nx %d\ny %d\nz %d\ndimension %d", name, b.X, b.Y, b.Z, t.chunk.Dimension))
+
+	t.nbtTree.SetRoot(nil)
+
+	if be, ok := blockEntityAt(t.w, b.X, b.Y, b.Z, t.chunk.Dimension); ok {
+		root := nbtTreeNode(be)
+		t.nbtTree.SetRoot(root).SetCurrentNode(root)
+	}
+}
+
+// nbtTreeNode returns a tree node for the given tag and its children.
+func nbtTreeNode(t nbt.NBTTag) *tview.TreeNode {
+	var children []nbt.NBTTag
+
+	switch t.Type {
+	case nbt.TagCompound:
+		children = t.Tags()
+	case nbt.TagList:
+		children = t.List()
+	}
+
+	text := fmt.Sprintf("%s [gray](%s)[-]", t.Name, nbt.TypeName(t.Type))
+	if children == nil {
+		text += " " + tview.Escape(t.FormatValue())
+	}
+
+	n := tview.NewTreeNode(text).SetSelectable(true)
+
+	for i, c := range children {
+		if t.Type == nbt.TagList {
+			c.Name = strconv.Itoa(i)
+		}
+
+		n.AddChild(nbtTreeNode(c))
+	}
+
+	return n
+}
+
+// promptFind asks for a filter expression and lists the matching blocks.
+func (t *explorer) promptFind() {
+	input := tview.NewInputField().SetLabel("find blocks: ")
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		t.pages.RemovePage("find")
+
+		if key != tcell.KeyEnter {
+			t.app.SetFocus(t.layer)
+			return
+		}
+
+		if err := t.find(input.GetText()); err != nil {
+			t.info.SetText(fmt.Sprintf("[red]%s[-]", err))
+			t.app.SetFocus(t.layer)
+		}
+	})
+
+	modal := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(nil, 0, 1, false).
+		AddItem(input, 1, 0, true)
+
+	t.pages.AddPage("find", modal, true, true)
+	t.app.SetFocus(input)
+}
+
+// find lists every block in the current dimension matching the expression. Selecting a result moves the cursor to it.
+func (t *explorer) find(src string) error {
+	expr, err := filter.Compile(src)
+	if err != nil {
+		return err
+	}
+
+	d := t.chunk.Dimension
+
+	blocks, err := t.w.FindBlocks(func(b world.Block, dimension int) (bool, error) {
+		if dimension != d {
+			return false, nil
+		}
+		return expr.Match(blockEnv(b, dimension))
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(blocks) == 0 {
+		return errors.New("no blocks found")
+	}
+
+	t.results.Clear()
+
+	for _, b := range blocks {
+		b := b
+		t.results.AddItem(fmt.Sprintf("%s at %d %d %d", names.Block(b.ID), b.X, b.Y, b.Z), "", 0, func() {
+			t.y = b.Y
+			t.showChunk(world.ChunkPos{X: floorDiv(b.X, 16), Z: floorDiv(b.Z, 16), Dimension: d})
+			t.layer.Select(b.Z-floorDiv(b.Z, 16)*16, b.X-floorDiv(b.X, 16)*16)
+			t.pages.SwitchToPage("main")
+			t.app.SetFocus(t.layer)
+		})
+	}
+
+	t.pages.SwitchToPage("results")
+	t.app.SetFocus(t.results)
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+// namedTimes are the times of day accepted by the time set command, as used by the in-game /time command.
+var namedTimes = map[string]int{
+	"sunrise":  23000,
+	"day":      1000,
+	"noon":     6000,
+	"sunset":   12000,
+	"night":    13000,
+	"midnight": 18000,
+}
+
+func newTimeCmd() *cobra.Command {
+	timeCmd := &cobra.Command{
+		Use:   "time",
+		Short: "Show the time of day and day count",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			wt, err := w.Weather()
+			if err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("day %d, time %d\n", wt.Time/world.DayLength, wt.TimeOfDay())
+			fmt.Printf("daylight cycle: %t\n", wt.DaylightCycle)
+		},
+	}
+
+	timeCmd.AddCommand(&cobra.Command{
+		Use:   "set <ticks|sunrise|day|noon|sunset|night|midnight>",
+		Short: "Set the time of day, keeping the day count",
+		Long: `Set the time of day, keeping the day count. The time is given in ticks from 0 to 23999 or by name.
+
+The world must not be open in the game or a server, which would overwrite the change when it closes.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ticks, ok := namedTimes[args[0]]
+			if !ok {
+				var err error
+				if ticks, err = strconv.Atoi(args[0]); err != nil || ticks < 0 || ticks >= world.DayLength {
+					fatal(usagef("invalid time '%s': expected 0-%d or a named time", args[0], world.DayLength-1))
+				}
+			}
+
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			wt, err := w.Weather()
+			if err != nil {
+				fatal(err)
+			}
+
+			wt.SetTimeOfDay(ticks)
+
+			if err := w.SetWeather(wt); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("time set to %d\n", ticks)
+		},
+	})
+
+	return timeCmd
+}
+
+func newWeatherCmd() *cobra.Command {
+	var duration int
+
+	weather := &cobra.Command{
+		Use:   "weather [clear|rain|thunder]",
+		Short: "Show or set the weather",
+		Long: `Show the weather, or set it for the given duration in ticks.
+
+Setting the weather can fix a thunderstorm which never ends. The world must not be open in the game or a server,
+which would overwrite the change when it closes.`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"clear", "rain", "thunder"},
+		Run: func(cmd *cobra.Command, args []string) {
+			w, err := openWorld()
+			if err != nil {
+				fatal(err)
+			}
+			defer w.Close()
+
+			wt, err := w.Weather()
+			if err != nil {
+				fatal(err)
+			}
+
+			if len(args) == 0 {
+				state := "clear"
+				if wt.Thundering() {
+					state = "thunder"
+				} else if wt.Raining() {
+					state = "rain"
+				}
+
+				fmt.Printf("weather: %s\n", state)
+				fmt.Printf("rain level %g, changes in %d ticks\n", wt.RainLevel, wt.RainTime)
+				fmt.Printf("lightning level %g, changes in %d ticks\n", wt.LightningLevel, wt.LightningTime)
+				fmt.Printf("weather cycle: %t\n", wt.WeatherCycle)
+
+				return
+			}
+
+			switch args[0] {
+			case "clear":
+				wt.Clear(duration)
+			case "rain":
+				wt.Rain(duration)
+			case "thunder":
+				wt.Thunder(duration)
+			default:
+				fatal(usagef("invalid weather '%s': expected clear, rain or thunder", args[0]))
+			}
+
+			if err := w.SetWeather(wt); err != nil {
+				fatal(err)
+			}
+
+			fmt.Printf("weather set to %s for %d ticks\n", args[0], duration)
+		},
+	}
+
+	weather.Flags().IntVar(&duration, "duration", 6000, "the number of ticks before the weather may change")
+
+	return weather
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danhale-git/mine/remote"
+	"github.com/danhale-git/mine/world"
+	"github.com/spf13/cobra"
+)
+
+// discoveredWorld is a world found in a directory of worlds.
+type discoveredWorld struct {
+	path string
+	id   string // The name of the world's directory, which the game generates
+	name string // The level name shown in game
+}
+
+// worldsDir returns the directory holding the game's worlds: the worlds_dir setting, or the first of the directories
+// the game saves worlds in on this OS which exists. It returns "" if there is none.
+func worldsDir() string {
+	if cfg.WorldsDir != "" {
+		return cfg.WorldsDir
+	}
+
+	for _, dir := range gameWorldsDirs() {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+
+	return ""
+}
+
+// discoverWorlds returns every world in the game's worlds directory.
+func discoverWorlds() []discoveredWorld {
+	dir := worldsDir()
+	if dir == "" {
+		return nil
+	}
+
+	return worldsIn(dir)
+}
+
+// worldsIn returns every directory in dir which contains a world database, sorted by directory name.
+func worldsIn(dir string) []discoveredWorld {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	worlds := make([]discoveredWorld, 0)
+
+	for _, e := range entries {
+		if w, ok := discoverWorld(filepath.Join(dir, e.Name())); ok {
+			worlds = append(worlds, w)
+		}
+	}
+
+	return worlds
+}
+
+// discoverWorld returns the world in the directory at path, named by its level.dat or else its levelname.txt. The
+// returned bool is false if the directory doesn't contain a world database.
+func discoverWorld(path string) (discoveredWorld, bool) {
+	if info, err := os.Stat(filepath.Join(path, "db")); err != nil || !info.IsDir() {
+		return discoveredWorld{}, false
+	}
+
+	id := filepath.Base(path)
+	name, err := world.LevelName(path)
+	if err != nil || name == "" {
+		name = id
+		if levelName, err := ioutil.ReadFile(filepath.Join(path, "levelname.txt")); err == nil {
+			name = strings.TrimSpace(string(levelName))
+		}
+	}
+
+	return discoveredWorld{path: path, id: id, name: name}, true
+}
+
+// worldPath returns the directory or object storage URI of the world set by the world flag or setting, or of the only
+// world in the game's worlds directory if none is set.
+func worldPath() (string, error) {
+	if cfg.World != "" {
+		return resolveWorld(cfg.World)
+	}
+
+	worlds := discoverWorlds()
+	if len(worlds) == 1 {
+		return worlds[0].path, nil
+	}
+
+	return "", fmt.Errorf("%w: use --world or the world setting, and 'mine worlds list' to find worlds",
+		errWorldNotFound)
+}
+
+// resolveWorld returns the world given by s: a directory, an object storage URI, or the folder ID or level name of a
+// world in the game's worlds directory.
+func resolveWorld(s string) (string, error) {
+	if remote.IsURI(s) {
+		return s, nil
+	}
+
+	if info, err := os.Stat(s); err == nil && info.IsDir() {
+		return s, nil
+	}
+
+	dir := worldsDir()
+	if dir == "" {
+		return "", fmt.Errorf("%w: '%s' is not a directory and the game's worlds directory was not found",
+			errWorldNotFound, s)
+	}
+
+	matches := make([]discoveredWorld, 0)
+	for _, w := range worldsIn(dir) {
+		if w.id == s {
+			return w.path, nil
+		}
+		if strings.EqualFold(w.name, s) {
+			matches = append(matches, w)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: '%s' is not a directory or a world in '%s'", errWorldNotFound, s, dir)
+	case 1:
+		return matches[0].path, nil
+	}
+
+	ids := make([]string, len(matches))
+	for i, w := range matches {
+		ids[i] = w.id
+	}
+
+	return "", fmt.Errorf("more than one world is named '%s': use its folder ID, one of %s", s, strings.Join(ids, ", "))
+}
+
+func newWorldsCmd() *cobra.Command {
+	worlds := &cobra.Command{
+		Use:   "worlds",
+		Short: "List the worlds in the game's worlds directory",
+	}
+
+	worlds.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the folder ID and level name of every world in the game's worlds directory",
+		Long: `List the folder ID and level name of every world in the game's worlds directory, which is found where the
+game saves worlds on this OS unless the worlds_dir setting is given. Either may be given to --world.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := worldsDir()
+			if dir == "" {
+				fatal("the game's worlds directory was not found: use the worlds_dir setting to give it")
+			}
+
+			found := worldsIn(dir)
+			fmt.Printf("%d worlds in %s\n", len(found), dir)
+
+			for _, w := range found {
+				fmt.Printf("%s  %s\n", w.id, w.name)
+			}
+		},
+	})
+
+	return worlds
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// gameWorldsDirs returns the directories worlds are saved in where the game has no official release: those of the
+// unofficial launcher, then the worlds directory of a dedicated server run from the working directory.
+func gameWorldsDirs() []string {
+	dirs := make([]string, 0)
+
+	if home, err := os.UserHomeDir(); err == nil {
+		data := os.Getenv("XDG_DATA_HOME")
+		if data == "" {
+			data = filepath.Join(home, ".local", "share")
+		}
+		if runtime.GOOS == "darwin" {
+			data = filepath.Join(home, "Library", "Application Support")
+		}
+
+		dirs = append(dirs, filepath.Join(data, "mcpelauncher", "games", "com.mojang", "minecraftWorlds"))
+	}
+
+	return append(dirs, "worlds")
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// gameWorldsDirs returns the directories the game saves worlds in, of the release and then the preview.
+func gameWorldsDirs() []string {
+	local := os.Getenv("LOCALAPPDATA")
+	if local == "" {
+		return nil
+	}
+
+	packages := []string{"Microsoft.MinecraftUWP_8wekyb3d8bbwe", "Microsoft.MinecraftWindowsBeta_8wekyb3d8bbwe"}
+
+	dirs := make([]string, 0, len(packages))
+	for _, p := range packages {
+		dirs = append(dirs, filepath.Join(local, "Packages", p, "LocalState", "games", "com.mojang", "minecraftWorlds"))
+	}
+
+	return dirs
+}
@@ -0,0 +1,273 @@
+// Package filter implements a small expression language used to select blocks and entities from the command line
+// without recompiling the tool, for example:
+//
+//	id == "minecraft:chest" && y < 0
+//	contains(identifier, "zombie") || (x > 100 && dimension == 1)
+//
+// Values are numbers, strings or booleans. The supported operators, from lowest to highest precedence, are ||, &&,
+// comparison (== != < <= > >=), + -, * / % and the unary operators ! and -. The functions contains, prefix and suffix
+// test strings.
+package filter
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Expr is a compiled filter expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Env holds the values of the variables which may be referenced by an expression.
+type Env map[string]interface{}
+
+// Compile parses an expression.
+func Compile(src string) (*Expr, error) {
+	p := &parser{lex: lexer{src: src}}
+	p.next()
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("parsing '%s': %w", src, err)
+	}
+
+	if p.tok.kind != tokEOF {
+		return nil, fmt.Errorf("parsing '%s': unexpected %s at offset %d", src, p.tok, p.tok.pos)
+	}
+
+	return &Expr{src: src, root: n}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression with the given variables.
+func (e *Expr) Eval(env Env) (interface{}, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating '%s': %w", e.src, err)
+	}
+
+	return v, nil
+}
+
+// Match evaluates the expression and returns its result, which must be a boolean.
+func (e *Expr) Match(env Env) (bool, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("evaluating '%s': result %v is not a boolean", e.src, v)
+	}
+
+	return b, nil
+}
+
+type node interface {
+	eval(env Env) (interface{}, error)
+}
+
+type literal struct{ v interface{} }
+
+func (n literal) eval(Env) (interface{}, error) { return n.v, nil }
+
+type variable struct{ name string }
+
+func (n variable) eval(env Env) (interface{}, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable '%s'", n.name)
+	}
+
+	return normalize(v), nil
+}
+
+type unary struct {
+	op      string
+	operand node
+}
+
+func (n unary) eval(env Env) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "!":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! needs a boolean: got %v", v)
+		}
+		return !b, nil
+	case "-":
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("operator - needs a number: got %v", v)
+		}
+		return -f, nil
+	}
+
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+func (n binary) eval(env Env) (interface{}, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logical operators short circuit
+	if n.op == "&&" || n.op == "||" {
+		lb, ok := l.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s needs booleans: got %v", n.op, l)
+		}
+
+		if (n.op == "&&" && !lb) || (n.op == "||" && lb) {
+			return lb, nil
+		}
+
+		r, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		rb, ok := r.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s needs booleans: got %v", n.op, r)
+		}
+
+		return rb, nil
+	}
+
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+
+	if ls, ok := l.(string); ok {
+		rs, ok := r.(string)
+		if !ok {
+			return nil, fmt.Errorf("operator %s can't compare string %q with %v", n.op, ls, r)
+		}
+
+		switch n.op {
+		case "+":
+			return ls + rs, nil
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		}
+
+		return nil, fmt.Errorf("operator %s needs numbers: got %q", n.op, ls)
+	}
+
+	lf, lok := l.(float64)
+	rf, rok := r.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s needs numbers: got %v and %v", n.op, l, r)
+	}
+
+	switch n.op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	case ">=":
+		return lf >= rf, nil
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		return lf / rf, nil
+	case "%":
+		return math.Mod(lf, rf), nil
+	}
+
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+// functions are the functions which may be called from an expression. Each takes two strings.
+var functions = map[string]func(s, t string) bool{
+	"contains": strings.Contains,
+	"prefix":   strings.HasPrefix,
+	"suffix":   strings.HasSuffix,
+}
+
+type call struct {
+	name string
+	args []node
+}
+
+func (n call) eval(env Env) (interface{}, error) {
+	f := functions[n.name]
+
+	strs := make([]string, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument %d of %s is not a string: got %v", i+1, n.name, v)
+		}
+		strs[i] = s
+	}
+
+	return f(strs[0], strs[1]), nil
+}
+
+// normalize converts numeric variable values to float64 so they may be compared with number literals.
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int8:
+		return float64(n)
+	case int16:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint8:
+		return float64(n)
+	case float32:
+		return float64(n)
+	}
+
+	return v
+}
@@ -0,0 +1,81 @@
+package filter
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	env := Env{"id": "minecraft:chest", "x": 12, "y": int64(-4), "z": 3.5, "dimension": 1}
+
+	cases := map[string]bool{
+		`id == "minecraft:chest"`: true,
+		`id != 'minecraft:chest'`: false,
+		`y < 0 && x >= 12`:        true,
+		`y < 0 && x > 12`:         false,
+		`y > 0 || dimension == 1`: true,
+		`!(y > 0)`:                true,
+		`x % 4 == 0 && -y == 4`:   true,
+		`z * 2 == 7`:              true,
+		`1 + 2 * 3 == 7`:          true,
+		`contains(id, "che") && prefix(id, "minecraft:")`: true,
+		`suffix(id, "barrel")`:                            false,
+		`id + "!" == "minecraft:chest!"`:                  true,
+		`true || missing`:                                 true, // Unevaluated operands are not checked
+	}
+
+	for src, want := range cases {
+		e, err := Compile(src)
+		if err != nil {
+			t.Errorf("%s: unexpected error compiling: %s", src, err)
+			continue
+		}
+
+		got, err := e.Match(env)
+		if err != nil {
+			t.Errorf("%s: unexpected error evaluating: %s", src, err)
+			continue
+		}
+
+		if got != want {
+			t.Errorf("%s: expected %t: got %t", src, want, got)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`x ==`,
+		`(x == 1`,
+		`x == 1)`,
+		`"unterminated`,
+		`nope(id, "a")`,
+		`contains(id)`,
+		`x $ 1`,
+	} {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("%q: expected error", src)
+		}
+	}
+}
+
+func TestMatchTypeErrors(t *testing.T) {
+	env := Env{"id": "minecraft:stone", "y": 1}
+
+	for _, src := range []string{
+		`y`,
+		`id < 1`,
+		`id * 2 == 1`,
+		`!id`,
+		`y && true`,
+		`false || missing`,
+	} {
+		e, err := Compile(src)
+		if err != nil {
+			t.Errorf("%s: unexpected error compiling: %s", src, err)
+			continue
+		}
+
+		if _, err := e.Match(env); err == nil {
+			t.Errorf("%s: expected error", src)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// operators are the operator and punctuation tokens, longest first so that e.g. <= is not read as <.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", ","}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(rune(l.src[l.pos])) {
+		l.pos++
+	}
+
+	start := l.pos
+
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	c := l.src[l.pos]
+
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for l.pos < len(l.src) && (l.src[l.pos] >= '0' && l.src[l.pos] <= '9' || l.src[l.pos] == '.') {
+			l.pos++
+		}
+		return token{kind: tokNumber, text: l.src[start:l.pos], pos: start}, nil
+
+	case c == '"' || c == '\'':
+		l.pos++
+		for l.pos < len(l.src) && l.src[l.pos] != c {
+			if l.src[l.pos] == '\\' {
+				l.pos++
+			}
+			l.pos++
+		}
+		if l.pos >= len(l.src) {
+			return token{}, fmt.Errorf("unterminated string at offset %d", start)
+		}
+		l.pos++
+
+		s, err := unquote(l.src[start:l.pos])
+		if err != nil {
+			return token{}, fmt.Errorf("string at offset %d: %w", start, err)
+		}
+		return token{kind: tokString, text: s, pos: start}, nil
+
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || unicode.IsLetter(rune(l.src[l.pos])) || unicode.IsDigit(rune(l.src[l.pos]))) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.src[start:l.pos], pos: start}, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+
+	return token{}, fmt.Errorf("unexpected character '%c' at offset %d", c, start)
+}
+
+// unquote returns the value of a single or double quoted string literal.
+func unquote(s string) (string, error) {
+	if s[0] == '\'' {
+		s = `"` + strings.ReplaceAll(strings.ReplaceAll(s[1:len(s)-1], `\'`, `'`), `"`, `\"`) + `"`
+	}
+
+	return strconv.Unquote(s)
+}
+
+// parser is a recursive descent parser with one token of lookahead.
+type parser struct {
+	lex lexer
+	tok token
+	err error
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+
+	p.tok, p.err = p.lex.next()
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	if p.tok.kind != tokOp {
+		return false
+	}
+
+	for _, op := range ops {
+		if p.tok.text == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseBinary parses a left associative sequence of operands joined by any of ops.
+func (p *parser) parseBinary(operand func() (node, error), ops ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOp(ops...) {
+		op := p.tok.text
+		p.next()
+
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary{op: op, left: left, right: right}
+	}
+
+	return left, p.err
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseBinary(p.parseComparison, "&&")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	return p.parseBinary(p.parseSum, "==", "!=", "<", "<=", ">", ">=")
+}
+
+func (p *parser) parseSum() (node, error) {
+	return p.parseBinary(p.parseProduct, "+", "-")
+}
+
+func (p *parser) parseProduct() (node, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOp("!", "-") {
+		op := p.tok.text
+		p.next()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return unary{op: op, operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	t := p.tok
+
+	switch t.kind {
+	case tokNumber:
+		p.next()
+
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' at offset %d", t.text, t.pos)
+		}
+
+		return literal{f}, p.err
+
+	case tokString:
+		p.next()
+		return literal{t.text}, p.err
+
+	case tokIdent:
+		p.next()
+
+		switch t.text {
+		case "true":
+			return literal{true}, p.err
+		case "false":
+			return literal{false}, p.err
+		}
+
+		if !p.isOp("(") {
+			return variable{t.text}, p.err
+		}
+
+		return p.parseCall(t)
+
+	case tokOp:
+		if t.text != "(" {
+			break
+		}
+		p.next()
+
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.isOp(")") {
+			return nil, fmt.Errorf("expected ')' at offset %d: got %s", p.tok.pos, p.tok)
+		}
+		p.next()
+
+		return n, p.err
+	}
+
+	return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+}
+
+// parseCall parses the arguments of a function call. The current token is the opening parenthesis.
+func (p *parser) parseCall(name token) (node, error) {
+	if _, ok := functions[name.text]; !ok {
+		return nil, fmt.Errorf("unknown function '%s' at offset %d", name.text, name.pos)
+	}
+	p.next()
+
+	c := call{name: name.text}
+
+	for !p.isOp(")") {
+		if len(c.args) > 0 {
+			if !p.isOp(",") {
+				return nil, fmt.Errorf("expected ',' or ')' at offset %d: got %s", p.tok.pos, p.tok)
+			}
+			p.next()
+		}
+
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		c.args = append(c.args, arg)
+	}
+	p.next()
+
+	if len(c.args) != 2 {
+		return nil, fmt.Errorf("function '%s' takes 2 arguments: got %d", name.text, len(c.args))
+	}
+
+	return c, p.err
+}
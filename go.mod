@@ -4,6 +4,12 @@ go 1.16
 
 require (
 	github.com/danhale-git/nbt2json v0.5.0
-	github.com/midnightfreddie/McpeTool v0.3.2
+	github.com/gdamore/tcell/v2 v2.4.0
+	github.com/golang/snappy v0.0.1
+	github.com/klauspost/compress v1.13.6
+	github.com/midnightfreddie/goleveldb v0.0.0-20180127105940-fb12d34a9c1f
+	github.com/rivo/tview v0.0.0-20210624165335-29d673af0ce2
 	github.com/spf13/cobra v1.2.1
+	github.com/spf13/pflag v1.0.5
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,111 @@
+// Package lang maps Minecraft IDs to display names using the key=value .lang files shipped with the game's resource
+// packs, for example resource_packs/vanilla/texts/de_DE.lang.
+package lang
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Names maps translation keys such as tile.stone.name to display names in one language. A nil Names is valid and
+// translates every ID with Fallback.
+type Names map[string]string
+
+// Load reads a .lang file. Lines hold key=value pairs, ## starts a comment and values may be followed by a tab and a
+// # comment.
+func Load(r io.Reader) (Names, error) {
+	names := make(Names)
+	s := bufio.NewScanner(r)
+
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimPrefix(s.Text(), "\ufeff")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "##") {
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("line %d: expected key=value", n)
+		}
+
+		value := line[i+1:]
+		if c := strings.Index(value, "\t#"); c >= 0 {
+			value = value[:c]
+		}
+
+		names[strings.TrimSpace(line[:i])] = strings.TrimSpace(value)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// LoadFile reads the .lang file at path.
+func LoadFile(path string) (Names, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening lang file: %w", err)
+	}
+	defer f.Close()
+
+	names, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading lang file '%s': %w", path, err)
+	}
+
+	return names, nil
+}
+
+// Block returns the display name of a block ID such as minecraft:stone.
+func (n Names) Block(id string) string {
+	return n.lookup(id, "tile.%s.name", "item.%s.name")
+}
+
+// Item returns the display name of an item ID such as minecraft:apple.
+func (n Names) Item(id string) string {
+	return n.lookup(id, "item.%s.name", "tile.%s.name")
+}
+
+// Entity returns the display name of an entity ID such as minecraft:cow.
+func (n Names) Entity(id string) string {
+	return n.lookup(id, "entity.%s.name")
+}
+
+// lookup returns the first translation of id found using the key formats, or the fallback name.
+func (n Names) lookup(id string, formats ...string) string {
+	name := strings.TrimPrefix(id, "minecraft:")
+
+	for _, f := range formats {
+		if v, ok := n[fmt.Sprintf(f, name)]; ok {
+			return v
+		}
+	}
+
+	return Fallback(id)
+}
+
+// Fallback returns an English name derived from an ID, for IDs with no translation e.g. minecraft:oak_log is
+// Oak Log. Namespaces other than minecraft are shown in brackets.
+func Fallback(id string) string {
+	namespace, name := "", id
+	if i := strings.Index(id, ":"); i >= 0 {
+		namespace, name = id[:i], id[i+1:]
+	}
+
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '_' })
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+
+	if namespace != "" && namespace != "minecraft" {
+		words = append(words, "("+namespace+")")
+	}
+
+	return strings.Join(words, " ")
+}
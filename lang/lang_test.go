@@ -0,0 +1,30 @@
+package lang
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	names, err := Load(strings.NewReader("\ufeff## Comment\n\ntile.stone.name=Stein\nitem.apple.name=Apfel\t#\nentity.cow.name = Kuh\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for got, want := range map[string]string{
+		names.Block("minecraft:stone"):      "Stein",
+		names.Item("minecraft:apple"):       "Apfel",
+		names.Entity("minecraft:cow"):       "Kuh",
+		names.Block("minecraft:oak_log"):    "Oak Log",
+		Names(nil).Block("minecraft:stone"): "Stone",
+		Fallback("mymod:glass_pane"):        "Glass Pane (mymod)",
+	} {
+		if got != want {
+			t.Errorf("expected %q: got %q", want, got)
+		}
+	}
+
+	if _, err := Load(strings.NewReader("no separator")); err == nil {
+		t.Errorf("expected error for line without =")
+	}
+}
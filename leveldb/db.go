@@ -0,0 +1,102 @@
+//go:build !js
+// +build !js
+
+package leveldb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/midnightfreddie/goleveldb/leveldb"
+	lerrors "github.com/midnightfreddie/goleveldb/leveldb/errors"
+	"github.com/midnightfreddie/goleveldb/leveldb/util"
+)
+
+// DB is the LevelDB database in the db directory of a Bedrock world folder.
+type DB struct {
+	db *leveldb.DB
+}
+
+// Open opens the LevelDB database in the given world folder.
+func Open(worldPath string) (*DB, error) {
+	dbPath := filepath.Join(worldPath, "db")
+
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening world database: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory: this must be run against a valid world folder", dbPath)
+	}
+
+	db, err := leveldb.OpenFile(dbPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("opening leveldb: %w", err)
+	}
+
+	return &DB{db: db}, nil
+}
+
+// Get returns the value for the given key or ErrNotFound if the key does not exist.
+func (d *DB) Get(key []byte) ([]byte, error) {
+	value, err := d.db.Get(key, nil)
+	if errors.Is(err, lerrors.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+
+	return value, err
+}
+
+// Put sets the value for the given key, replacing any existing value.
+func (d *DB) Put(key, value []byte) error {
+	return d.db.Put(key, value, nil)
+}
+
+// Delete removes the given key. It is not an error if the key does not exist.
+func (d *DB) Delete(key []byte) error {
+	return d.db.Delete(key, nil)
+}
+
+// Keys returns every key in the database.
+func (d *DB) Keys() ([][]byte, error) {
+	keys := make([][]byte, 0)
+
+	iter := d.db.NewIterator(nil, nil)
+	for iter.Next() {
+		key := make([]byte, len(iter.Key()))
+		copy(key, iter.Key())
+		keys = append(keys, key)
+	}
+	iter.Release()
+
+	return keys, iter.Error()
+}
+
+// ForEachKey calls f with every key in the database in sorted order, stopping at the first error, without holding the
+// keys in memory. The key passed to f is only valid until f returns. Changes made while iterating are not seen.
+func (d *DB) ForEachKey(f func(key []byte) error) error {
+	iter := d.db.NewIterator(nil, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if err := f(iter.Key()); err != nil {
+			return err
+		}
+	}
+
+	return iter.Error()
+}
+
+// CompactRange compacts the keys from start up to but not including limit, discarding deleted and overwritten data. A
+// nil start or limit means the start or end of the database.
+func (d *DB) CompactRange(start, limit []byte) error {
+	return d.db.CompactRange(util.Range{Start: start, Limit: limit})
+}
+
+// Close closes the database, releasing its lock. It must be called before the game or another process may open it.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
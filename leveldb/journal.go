@@ -0,0 +1,159 @@
+//go:build !js
+// +build !js
+
+package leveldb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/midnightfreddie/goleveldb/leveldb"
+	"github.com/midnightfreddie/goleveldb/leveldb/journal"
+)
+
+// JournalEntry is one write found in a journal (.log) file.
+type JournalEntry struct {
+	File    string // The name of the journal file in the db directory
+	Seq     uint64 // The sequence number of the write. Later writes have higher numbers.
+	Key     []byte
+	Value   []byte // Nil if the key was deleted
+	Deleted bool
+}
+
+// Journal is the content of a database's journal files. The journal holds the most recent writes to the database,
+// which the game has saved but not yet compacted into the table files. Open replays the journal, so reads through DB
+// include these writes.
+type Journal struct {
+	Files   []string // The names of the journal files, oldest first
+	Size    int64    // The total size of the journal files in bytes
+	Entries []JournalEntry
+}
+
+// ReadJournal reads the journal files of the database in the given world folder without opening the database, so it
+// may be used while the game has the world open. Damaged records are skipped, see Check.
+func ReadJournal(worldPath string) (*Journal, error) {
+	dbPath := filepath.Join(worldPath, "db")
+
+	names, err := filepath.Glob(filepath.Join(dbPath, "*.log"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Journal files are numbered in the order they were created, with zero padding
+	sort.Strings(names)
+
+	j := &Journal{Files: make([]string, 0, len(names)), Entries: make([]JournalEntry, 0)}
+
+	for _, path := range names {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading journal: %w", err)
+		}
+
+		j.Files = append(j.Files, filepath.Base(path))
+		j.Size += info.Size()
+
+		if err := j.readFile(path); err != nil {
+			return nil, fmt.Errorf("reading journal %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	return j, nil
+}
+
+func (j *Journal) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	name := filepath.Base(path)
+	r := journal.NewReader(f, nil, false, true)
+
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// A batch cut short by a crash ends the journal
+			return nil
+		}
+
+		data, err := ioutil.ReadAll(record)
+		if err != nil {
+			return nil
+		}
+
+		if len(data) < batchHeaderSize {
+			continue
+		}
+
+		b := leveldb.Batch{}
+		if err := b.Load(data); err != nil {
+			continue
+		}
+
+		replay := &journalReplay{j: j, file: name, seq: binary.LittleEndian.Uint64(data)}
+		if err := b.Replay(replay); err != nil {
+			return err
+		}
+	}
+}
+
+// journalReplay adds the writes of a batch to a journal, numbering them from the batch's sequence number.
+type journalReplay struct {
+	j    *Journal
+	file string
+	seq  uint64
+}
+
+func (r *journalReplay) Put(key, value []byte) {
+	r.add(JournalEntry{Key: key, Value: value})
+}
+
+func (r *journalReplay) Delete(key []byte) {
+	r.add(JournalEntry{Key: key, Deleted: true})
+}
+
+func (r *journalReplay) add(e JournalEntry) {
+	e.File, e.Seq = r.file, r.seq
+	e.Key = append([]byte{}, e.Key...)
+	if e.Value != nil {
+		e.Value = append([]byte{}, e.Value...)
+	}
+
+	r.j.Entries = append(r.j.Entries, e)
+	r.seq++
+}
+
+// Latest returns the last write of each key in the journal, keyed by the key as a string. These are the freshest
+// values of those keys, newer than any value in the table files.
+func (j *Journal) Latest() map[string]JournalEntry {
+	latest := make(map[string]JournalEntry)
+
+	for _, e := range j.Entries {
+		if l, ok := latest[string(e.Key)]; !ok || e.Seq >= l.Seq {
+			latest[string(e.Key)] = e
+		}
+	}
+
+	return latest
+}
+
+// Contains returns true if the freshest value of the key is in the journal.
+func (j *Journal) Contains(key []byte) bool {
+	for _, e := range j.Entries {
+		if string(e.Key) == string(key) {
+			return true
+		}
+	}
+
+	return false
+}
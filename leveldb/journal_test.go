@@ -0,0 +1,100 @@
+//go:build !js
+// +build !js
+
+package leveldb
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/midnightfreddie/goleveldb/leveldb"
+	"github.com/midnightfreddie/goleveldb/leveldb/util"
+)
+
+// TestJournalWrites saves changes the way the game does, as batches written to the journal which are not compacted
+// before the database is closed, and checks that they are read.
+func TestJournalWrites(t *testing.T) {
+	world := t.TempDir()
+
+	db, err := leveldb.OpenFile(filepath.Join(world, "db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Older records are compacted into table files
+	for _, k := range []string{"chunk", "player", "village"} {
+		if err := db.Put([]byte(k), []byte("old "+k), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.CompactRange(util.Range{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The last save is only in the journal
+	b := new(leveldb.Batch)
+	b.Put([]byte("chunk"), []byte("new chunk"))
+	b.Put([]byte("portals"), []byte("new portals"))
+	b.Delete([]byte("village"))
+	if err := db.Write(b, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("chunk"), []byte("newer chunk"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := ReadJournal(world)
+	if err != nil {
+		t.Fatalf("unexpected error reading journal: %s", err)
+	}
+
+	if len(j.Files) == 0 || j.Size == 0 || len(j.Entries) != 4 {
+		t.Fatalf("expected 4 writes in the journal: got %+v", j)
+	}
+
+	latest := j.Latest()
+	if len(latest) != 3 {
+		t.Errorf("expected 3 keys in the journal: got %d", len(latest))
+	}
+
+	if e := latest["chunk"]; string(e.Value) != "newer chunk" || e.Seq <= latest["portals"].Seq {
+		t.Errorf("expected the latest write of chunk to be last: got %+v", e)
+	}
+
+	if !latest["village"].Deleted || j.Contains([]byte("player")) {
+		t.Errorf("expected village to be deleted in the journal and player not to be in it")
+	}
+
+	// Opening the database replays the journal, so the latest save is what is read
+	d, err := Open(world)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	want := map[string]string{"chunk": "newer chunk", "portals": "new portals", "player": "old player"}
+	for k, v := range want {
+		got, err := d.Get([]byte(k))
+		if err != nil || string(got) != v {
+			t.Errorf("%s: expected %s: got %s, %v", k, v, got, err)
+		}
+	}
+
+	if _, err := d.Get([]byte("village")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected village deleted in the journal not to be found: got %v", err)
+	}
+
+	keys, err := d.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 || !bytes.Equal(keys[0], []byte("chunk")) {
+		t.Errorf("expected keys chunk, player and portals: got %q", keys)
+	}
+}
@@ -1,7 +1,10 @@
 package leveldb
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"math"
 )
 
@@ -9,13 +12,51 @@ const (
 	chunkSize = 16
 )
 
-// SubChunkKey builds the levelDB key for the sub chunk at the given x/y/z coordinates.
+// ErrNotFound is returned by DB.Get and MemDB.Get when the key is not present in the database.
+var ErrNotFound = errors.New("leveldb: not found")
+
+// Chunk record key type tags.
+//
+// https://minecraft.fandom.com/wiki/Bedrock_Edition_level_format#Chunk_key_format
+const (
+	Data3D               byte = 43
+	Version              byte = 44
+	Data2D               byte = 45
+	Data2DLegacy         byte = 46
+	SubChunkPrefix       byte = 47
+	LegacyTerrain        byte = 48
+	BlockEntity          byte = 49
+	Entity               byte = 50
+	PendingTicks         byte = 51
+	LegacyBlockExtraData byte = 52
+	BiomeState           byte = 53
+	FinalizedState       byte = 54
+	BorderBlocks         byte = 56
+	HardCodedSpawnAreas  byte = 57
+	RandomTicks          byte = 58
+	Checksums            byte = 59
+	VersionOld           byte = 118
+)
+
+// ChunkKey is a parsed chunk record key.
+type ChunkKey struct {
+	X, Z      int32
+	Dimension int32
+	Tag       byte
+	SubChunkY int8 // SubChunkY is only present in keys with the SubChunkPrefix tag
+}
+
+// SubChunkKey builds the levelDB key for the sub chunk at the given x/y/z coordinates. The sub chunk index is signed,
+// so blocks below Y=0 such as the overworld's Y=-64 are in sub chunks with negative indices.
 //
 // https://minecraft.fandom.com/wiki/Bedrock_Edition_level_format#NBT_Structure
 func SubChunkKey(x, y, z, dimension int) ([]byte, error) {
 	xi := int32(math.Floor(float64(x) / chunkSize))
 	zi := int32(math.Floor(float64(z) / chunkSize))
 	yi := int(math.Floor(float64(y) / chunkSize))
+	if yi < math.MinInt8 || yi > math.MaxInt8 {
+		return nil, fmt.Errorf("y %d is outside the range of sub chunk indices", y)
+	}
 
 	key := make([]byte, 0)
 
@@ -26,14 +67,130 @@ func SubChunkKey(x, y, z, dimension int) ([]byte, error) {
 		key = append(key, littleEndianBytes(int32(dimension))...)
 	}
 
-	key = append(key, []byte{47}...) // 47 is the SubChunkPrefix key type tag
+	key = append(key, []byte{SubChunkPrefix}...)
 	key = append(key, byte(yi))
 
 	return key, nil
 }
 
+// Bytes returns the levelDB key for k.
+func (k ChunkKey) Bytes() []byte {
+	key := make([]byte, 0, 14)
+
+	key = append(key, littleEndianBytes(k.X)...)
+	key = append(key, littleEndianBytes(k.Z)...)
+
+	if k.Dimension != 0 {
+		key = append(key, littleEndianBytes(k.Dimension)...)
+	}
+
+	key = append(key, k.Tag)
+
+	if k.Tag == SubChunkPrefix {
+		key = append(key, byte(k.SubChunkY))
+	}
+
+	return key
+}
+
+// ParseChunkKey parses a chunk record key. The returned bool is false if key is not a chunk record, for example a
+// player or village key.
+func ParseChunkKey(key []byte) (ChunkKey, bool) {
+	k := ChunkKey{}
+
+	switch len(key) {
+	case 9, 10:
+		k.Tag = key[8]
+	case 13, 14:
+		k.Dimension = int32(binary.LittleEndian.Uint32(key[8:12]))
+		k.Tag = key[12]
+	default:
+		return ChunkKey{}, false
+	}
+
+	if !isChunkTag(k.Tag) {
+		return ChunkKey{}, false
+	}
+
+	// Only sub chunk keys have the trailing y byte
+	if (len(key) == 10 || len(key) == 14) != (k.Tag == SubChunkPrefix) {
+		return ChunkKey{}, false
+	}
+
+	k.X = int32(binary.LittleEndian.Uint32(key[0:4]))
+	k.Z = int32(binary.LittleEndian.Uint32(key[4:8]))
+
+	if k.Tag == SubChunkPrefix {
+		k.SubChunkY = int8(key[len(key)-1])
+	}
+
+	return k, true
+}
+
+func isChunkTag(t byte) bool {
+	return (t >= Data3D && t <= Checksums && t != 55) || t == VersionOld
+}
+
 func littleEndianBytes(i int32) []byte {
 	b := make([]byte, 4)
 	binary.LittleEndian.PutUint32(b, uint32(i))
 	return b
 }
+
+// Prefixes of the keys used by the actor storage format introduced in 1.18.30. Each chunk has a digest record listing
+// the 8 byte storage IDs of the actors in it, and each actor is stored in its own record keyed by that ID.
+const (
+	DigestPrefix = "digp"
+	ActorPrefix  = "actorprefix"
+)
+
+// DigestKey builds the key of the actor digest record for the given chunk.
+func DigestKey(x, z, dimension int32) []byte {
+	key := []byte(DigestPrefix)
+
+	key = append(key, littleEndianBytes(x)...)
+	key = append(key, littleEndianBytes(z)...)
+
+	if dimension != 0 {
+		key = append(key, littleEndianBytes(dimension)...)
+	}
+
+	return key
+}
+
+// ParseDigestKey returns the chunk coordinates and dimension of an actor digest key. The returned bool is false if key
+// is not a digest key.
+func ParseDigestKey(key []byte) (x, z, dimension int32, ok bool) {
+	if !bytes.HasPrefix(key, []byte(DigestPrefix)) {
+		return 0, 0, 0, false
+	}
+
+	k := key[len(DigestPrefix):]
+
+	switch len(k) {
+	case 8:
+	case 12:
+		dimension = int32(binary.LittleEndian.Uint32(k[8:12]))
+	default:
+		return 0, 0, 0, false
+	}
+
+	x = int32(binary.LittleEndian.Uint32(k[0:4]))
+	z = int32(binary.LittleEndian.Uint32(k[4:8]))
+
+	return x, z, dimension, true
+}
+
+// ActorKey builds the key of the actor record with the given 8 byte storage ID.
+func ActorKey(storageID []byte) []byte {
+	return append([]byte(ActorPrefix), storageID...)
+}
+
+// ParseActorKey returns the storage ID of an actor key. The returned bool is false if key is not an actor key.
+func ParseActorKey(key []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(key, []byte(ActorPrefix)) || len(key) != len(ActorPrefix)+8 {
+		return nil, false
+	}
+
+	return key[len(ActorPrefix):], true
+}
@@ -10,6 +10,12 @@ func TestSubChunkKey(t *testing.T) {
 	testSubChunkKey(0, 0, 0, "00000000000000002F00", t)
 	testSubChunkKey(16, 16, 16, "01000000010000002F01", t)
 	testSubChunkKey(-1, 32, -1, "FFFFFFFFFFFFFFFF2F02", t)
+	testSubChunkKey(0, -1, 0, "00000000000000002FFF", t)
+	testSubChunkKey(0, -64, 0, "00000000000000002FFC", t)
+
+	if _, err := SubChunkKey(0, 2048, 0, 0); err == nil {
+		t.Error("expected an error for a y outside the range of sub chunk indices")
+	}
 }
 
 func testSubChunkKey(x, y, z int, want string, t *testing.T) {
@@ -24,3 +30,27 @@ func testSubChunkKey(x, y, z int, want string, t *testing.T) {
 		t.Errorf("unexpected key '%s': expected '%s'", got, want)
 	}
 }
+
+func TestParseChunkKey(t *testing.T) {
+	for _, want := range []ChunkKey{
+		{X: 0, Z: 0, Tag: SubChunkPrefix, SubChunkY: 0},
+		{X: -1, Z: 25, Tag: SubChunkPrefix, SubChunkY: 5},
+		{X: 3, Z: -4, Dimension: 1, Tag: SubChunkPrefix, SubChunkY: 2},
+		{X: 3, Z: -4, Dimension: 2, Tag: BlockEntity},
+		{X: 7, Z: 8, Tag: Data2D},
+	} {
+		got, ok := ParseChunkKey(want.Bytes())
+		if !ok {
+			t.Errorf("key %+v was not parsed", want)
+			continue
+		}
+
+		if got != want {
+			t.Errorf("unexpected key parsed: expected %+v: got %+v", want, got)
+		}
+	}
+
+	if _, ok := ParseChunkKey([]byte("~local_player")); ok {
+		t.Errorf("player key was parsed as a chunk key")
+	}
+}
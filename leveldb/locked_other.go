@@ -0,0 +1,9 @@
+//go:build !darwin && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package leveldb
+
+// IsLocked returns false, as locked databases can't be detected on this platform.
+func IsLocked(err error) bool {
+	return false
+}
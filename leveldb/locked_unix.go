@@ -0,0 +1,14 @@
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package leveldb
+
+import (
+	"errors"
+	"syscall"
+)
+
+// IsLocked returns true if err was caused by the database being open in another process.
+func IsLocked(err error) bool {
+	return errors.Is(err, syscall.EWOULDBLOCK)
+}
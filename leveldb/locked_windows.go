@@ -0,0 +1,14 @@
+package leveldb
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorSharingViolation is returned when opening a file which another process has open without sharing.
+const errorSharingViolation = syscall.Errno(32)
+
+// IsLocked returns true if err was caused by the database being open in another process.
+func IsLocked(err error) bool {
+	return errors.Is(err, errorSharingViolation)
+}
@@ -0,0 +1,75 @@
+package leveldb
+
+import (
+	"sort"
+)
+
+// MemDB is a database held in memory, such as one read from the files of a world uploaded to a browser, where there
+// is no file system to open the database in. Writes change only the copy in memory.
+type MemDB struct {
+	records map[string][]byte
+}
+
+// NewMemDB returns an empty database held in memory.
+func NewMemDB() *MemDB {
+	return &MemDB{records: make(map[string][]byte)}
+}
+
+// Get returns the value for the given key or ErrNotFound if the key does not exist.
+func (m *MemDB) Get(key []byte) ([]byte, error) {
+	value, ok := m.records[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return value, nil
+}
+
+// Put sets the value for the given key, replacing any existing value.
+func (m *MemDB) Put(key, value []byte) error {
+	m.records[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+// Delete removes the given key. It is not an error if the key does not exist.
+func (m *MemDB) Delete(key []byte) error {
+	delete(m.records, string(key))
+	return nil
+}
+
+// Keys returns every key in the database in sorted order.
+func (m *MemDB) Keys() ([][]byte, error) {
+	keys := make([][]byte, 0, len(m.records))
+	for _, k := range m.sortedKeys() {
+		keys = append(keys, []byte(k))
+	}
+
+	return keys, nil
+}
+
+// ForEachKey calls f with every key in the database in sorted order, stopping at the first error. Changes made while
+// iterating are not seen.
+func (m *MemDB) ForEachKey(f func(key []byte) error) error {
+	for _, k := range m.sortedKeys() {
+		if err := f([]byte(k)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of records in the database.
+func (m *MemDB) Len() int {
+	return len(m.records)
+}
+
+func (m *MemDB) sortedKeys() []string {
+	keys := make([]string, 0, len(m.records))
+	for k := range m.records {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
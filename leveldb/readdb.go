@@ -0,0 +1,513 @@
+package leveldb
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/snappy"
+)
+
+// The formats of the files read here are described at
+// https://github.com/google/leveldb/blob/main/doc/table_format.md and
+// https://github.com/google/leveldb/blob/main/doc/log_format.md
+
+const (
+	journalBlockSize  = 32 * 1024
+	journalHeaderSize = 7 // Checksum, length and record type
+
+	tableFooterSize  = 48
+	tableMagic       = 0xdb4775248b80fb57
+	blockTrailerSize = 5 // Compression type and checksum
+)
+
+// Journal record types, telling whether a record is whole or a fragment of one which spans journal blocks.
+const (
+	journalFull   = 1
+	journalFirst  = 2
+	journalMiddle = 3
+	journalLast   = 4
+)
+
+// Table block compression types. The game compresses blocks with raw deflate and goleveldb with snappy.
+const (
+	blockNoCompression = 0
+	blockSnappy        = 1
+	blockZlib          = 2
+	blockRawZlib       = 4
+)
+
+// Record kinds, telling whether a write in a batch or table sets or deletes its key.
+const (
+	recordKindDeletion = 0
+	recordKindValue    = 1
+)
+
+const (
+	// batchHeaderSize is the length of the sequence number and record count at the start of each batch in a journal.
+	batchHeaderSize = 12
+	// internalKeyTrailer is the length of the sequence number and record kind at the end of each key in a table.
+	internalKeyTrailer   = 8
+	maskedChecksumOffset = 0xa282ead8
+)
+
+// Version edit tags in the manifest, which lists the table files in use.
+const (
+	manifestComparer       = 1
+	manifestJournal        = 2
+	manifestNextFile       = 3
+	manifestLastSequence   = 4
+	manifestCompactPointer = 5
+	manifestDeleteTable    = 6
+	manifestAddTable       = 7
+	manifestPrevJournal    = 9
+)
+
+var (
+	checksumTable = crc32.MakeTable(crc32.Castagnoli)
+	errShortData  = errors.New("data is cut short")
+)
+
+// ReadDB reads a database from the content of the files in its db directory, keyed by file name, without a file
+// system, such as a world uploaded to a browser. Only the table files listed in the manifest and the journal files
+// still in use are read, so the result is what opening the database would give. A journal record which is damaged or
+// cut short by a crash ends its journal, as it does when the database is opened.
+func ReadDB(files map[string][]byte) (*MemDB, error) {
+	tables, journals, err := liveFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]readRecord)
+
+	for _, name := range tables {
+		if err := readTable(files[name], latest); err != nil {
+			return nil, fmt.Errorf("reading table %s: %w", name, err)
+		}
+	}
+
+	for _, name := range journals {
+		for _, batch := range journalRecords(files[name]) {
+			if err := readBatch(batch, latest); err != nil {
+				return nil, fmt.Errorf("reading journal %s: %w", name, err)
+			}
+		}
+	}
+
+	db := NewMemDB()
+	for k, r := range latest {
+		if !r.deleted {
+			db.records[k] = r.value
+		}
+	}
+
+	return db, nil
+}
+
+// readRecord is the newest write of a key found so far.
+type readRecord struct {
+	seq     uint64
+	value   []byte
+	deleted bool
+}
+
+// add records a write of key, unless a newer write of it has been read.
+func add(latest map[string]readRecord, key []byte, r readRecord) {
+	if l, ok := latest[string(key)]; ok && l.seq > r.seq {
+		return
+	}
+
+	latest[string(key)] = r
+}
+
+// liveFiles returns the names of the table and journal files in use. Without a manifest every table and journal file
+// is used.
+func liveFiles(files map[string][]byte) (tables, journals []string, err error) {
+	tables, journals = make([]string, 0), make([]string, 0)
+	numbers := make(map[uint64]string)
+
+	for name := range files {
+		if n, ext, ok := fileNumber(name); ok && (ext == "ldb" || ext == "sst") {
+			numbers[n] = name
+		}
+	}
+
+	current, ok := files["CURRENT"]
+	if !ok {
+		for _, name := range numbers {
+			tables = append(tables, name)
+		}
+		sort.Strings(tables)
+
+		return tables, journalsFrom(files, 0), nil
+	}
+
+	manifestName := strings.TrimSpace(string(current))
+	manifest, ok := files[manifestName]
+	if !ok {
+		return nil, nil, fmt.Errorf("manifest %s not found", manifestName)
+	}
+
+	live, journal, err := readManifest(manifest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading manifest %s: %w", manifestName, err)
+	}
+
+	for n := range live {
+		name, ok := numbers[n]
+		if !ok {
+			return nil, nil, fmt.Errorf("table file %06d listed in the manifest not found", n)
+		}
+		tables = append(tables, name)
+	}
+	sort.Strings(tables)
+
+	return tables, journalsFrom(files, journal), nil
+}
+
+// journalsFrom returns the names of the journal files numbered from first, in the order they were written.
+func journalsFrom(files map[string][]byte, first uint64) []string {
+	type journal struct {
+		n    uint64
+		name string
+	}
+
+	found := make([]journal, 0)
+	for name := range files {
+		if n, ext, ok := fileNumber(name); ok && ext == "log" && n >= first {
+			found = append(found, journal{n, name})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].n < found[j].n })
+
+	names := make([]string, len(found))
+	for i, j := range found {
+		names[i] = j.name
+	}
+
+	return names
+}
+
+// fileNumber returns the number and extension of a numbered database file such as 000005.ldb.
+func fileNumber(name string) (uint64, string, bool) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return 0, "", false
+	}
+
+	n, err := strconv.ParseUint(name[:i], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+
+	return n, name[i+1:], true
+}
+
+// readManifest returns the numbers of the table files in use and the number of the oldest journal file in use.
+func readManifest(data []byte) (map[uint64]bool, uint64, error) {
+	live := make(map[uint64]bool)
+	var journal uint64
+
+	for _, edit := range journalRecords(data) {
+		r := byteReader{b: edit}
+
+		for len(r.b) > 0 && r.err == nil {
+			switch tag := r.uvarint(); tag {
+			case manifestComparer:
+				r.lengthPrefixed()
+			case manifestJournal:
+				journal = r.uvarint()
+			case manifestNextFile, manifestLastSequence, manifestPrevJournal:
+				r.uvarint()
+			case manifestCompactPointer:
+				r.uvarint()
+				r.lengthPrefixed()
+			case manifestDeleteTable:
+				r.uvarint()
+				delete(live, r.uvarint())
+			case manifestAddTable:
+				r.uvarint()
+				live[r.uvarint()] = true
+				r.uvarint()
+				r.lengthPrefixed()
+				r.lengthPrefixed()
+			default:
+				return nil, 0, fmt.Errorf("unknown version edit tag %d", tag)
+			}
+		}
+
+		if r.err != nil {
+			return nil, 0, r.err
+		}
+	}
+
+	return live, journal, nil
+}
+
+// journalRecords returns the records in a journal file, in the format shared by .log files and the manifest. A record
+// which is damaged or cut short ends the journal.
+func journalRecords(data []byte) [][]byte {
+	records := make([][]byte, 0)
+	var record []byte
+	fragmented := false
+
+	for start := 0; start < len(data); start += journalBlockSize {
+		block := data[start:]
+		if len(block) > journalBlockSize {
+			block = block[:journalBlockSize]
+		}
+
+		for len(block) >= journalHeaderSize {
+			length := int(binary.LittleEndian.Uint16(block[4:6]))
+			kind := block[6]
+
+			// The rest of the block is padding
+			if kind == 0 && length == 0 {
+				break
+			}
+
+			if journalHeaderSize+length > len(block) ||
+				maskedChecksum(block[6:journalHeaderSize+length]) != binary.LittleEndian.Uint32(block[0:4]) {
+				return records
+			}
+
+			payload := block[journalHeaderSize : journalHeaderSize+length]
+			block = block[journalHeaderSize+length:]
+
+			switch kind {
+			case journalFull:
+				records = append(records, payload)
+				fragmented = false
+			case journalFirst:
+				record = append([]byte{}, payload...)
+				fragmented = true
+			case journalMiddle:
+				if fragmented {
+					record = append(record, payload...)
+				}
+			case journalLast:
+				if fragmented {
+					records = append(records, append(record, payload...))
+				}
+				fragmented = false
+			default:
+				return records
+			}
+		}
+	}
+
+	return records
+}
+
+// readBatch adds the writes of a batch from a journal, numbered from the batch's sequence number.
+func readBatch(data []byte, latest map[string]readRecord) error {
+	if len(data) < batchHeaderSize {
+		return errShortData
+	}
+
+	seq := binary.LittleEndian.Uint64(data)
+	count := binary.LittleEndian.Uint32(data[8:])
+	r := byteReader{b: data[batchHeaderSize:]}
+
+	for i := uint32(0); i < count; i++ {
+		kind := r.next(1)
+		key := r.lengthPrefixed()
+		if r.err != nil {
+			return r.err
+		}
+
+		switch kind[0] {
+		case recordKindValue:
+			value := r.lengthPrefixed()
+			if r.err != nil {
+				return r.err
+			}
+			add(latest, key, readRecord{seq: seq + uint64(i), value: value})
+		case recordKindDeletion:
+			add(latest, key, readRecord{seq: seq + uint64(i), deleted: true})
+		default:
+			return fmt.Errorf("unknown record kind %d", kind[0])
+		}
+	}
+
+	return nil
+}
+
+// readTable adds the records of a table file.
+func readTable(data []byte, latest map[string]readRecord) error {
+	if len(data) < tableFooterSize {
+		return errShortData
+	}
+
+	footer := data[len(data)-tableFooterSize:]
+	if binary.LittleEndian.Uint64(footer[tableFooterSize-8:]) != tableMagic {
+		return errors.New("not a table file")
+	}
+
+	r := byteReader{b: footer}
+	r.uvarint() // The meta index block isn't needed to read records
+	r.uvarint()
+	index, err := readBlock(data, &r)
+	if err != nil {
+		return fmt.Errorf("index block: %w", err)
+	}
+
+	return eachBlockEntry(index, func(_, handle []byte) error {
+		block, err := readBlock(data, &byteReader{b: handle})
+		if err != nil {
+			return fmt.Errorf("data block: %w", err)
+		}
+
+		return eachBlockEntry(block, func(key, value []byte) error {
+			if len(key) < internalKeyTrailer {
+				return errors.New("key is too short")
+			}
+
+			trailer := binary.LittleEndian.Uint64(key[len(key)-internalKeyTrailer:])
+			user := key[:len(key)-internalKeyTrailer]
+
+			switch byte(trailer) {
+			case recordKindValue:
+				add(latest, user, readRecord{seq: trailer >> 8, value: value})
+			case recordKindDeletion:
+				add(latest, user, readRecord{seq: trailer >> 8, deleted: true})
+			default:
+				return fmt.Errorf("unknown record kind %d", byte(trailer))
+			}
+
+			return nil
+		})
+	})
+}
+
+// readBlock returns the uncompressed content of the table block at the offset and size read from handle.
+func readBlock(data []byte, handle *byteReader) ([]byte, error) {
+	offset, size := handle.uvarint(), handle.uvarint()
+	if handle.err != nil {
+		return nil, handle.err
+	}
+
+	if offset+size+blockTrailerSize > uint64(len(data)) {
+		return nil, errShortData
+	}
+
+	block := data[offset : offset+size]
+	trailer := data[offset+size : offset+size+blockTrailerSize]
+
+	if maskedChecksum(data[offset:offset+size+1]) != binary.LittleEndian.Uint32(trailer[1:]) {
+		return nil, errors.New("checksum mismatch")
+	}
+
+	switch trailer[0] {
+	case blockNoCompression:
+		return block, nil
+	case blockSnappy:
+		return snappy.Decode(nil, block)
+	case blockZlib:
+		z, err := zlib.NewReader(bytes.NewReader(block))
+		if err != nil {
+			return nil, err
+		}
+		defer z.Close()
+
+		return ioutil.ReadAll(z)
+	case blockRawZlib:
+		z := flate.NewReader(bytes.NewReader(block))
+		defer z.Close()
+
+		return ioutil.ReadAll(z)
+	}
+
+	return nil, fmt.Errorf("unknown compression type %d", trailer[0])
+}
+
+// eachBlockEntry calls f with the key and value of each entry in a table block. Keys share a prefix with the previous
+// key, so the key passed to f is a new slice each time.
+func eachBlockEntry(block []byte, f func(key, value []byte) error) error {
+	if len(block) < 4 {
+		return errShortData
+	}
+
+	restarts := uint64(binary.LittleEndian.Uint32(block[len(block)-4:]))
+	if (restarts+1)*4 > uint64(len(block)) {
+		return errShortData
+	}
+
+	r := byteReader{b: block[:uint64(len(block))-(restarts+1)*4]}
+	var key []byte
+
+	for len(r.b) > 0 {
+		shared, unshared, length := r.uvarint(), r.uvarint(), r.uvarint()
+		delta, value := r.next(unshared), r.next(length)
+		if r.err != nil {
+			return r.err
+		}
+
+		if shared > uint64(len(key)) {
+			return errors.New("key shares more bytes than the previous key has")
+		}
+
+		key = append(append(make([]byte, 0, shared+unshared), key[:shared]...), delta...)
+		if err := f(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// maskedChecksum returns the checksum of data, masked as it is stored in journals and tables.
+func maskedChecksum(data []byte) uint32 {
+	c := crc32.Checksum(data, checksumTable)
+	return (c>>15 | c<<17) + maskedChecksumOffset
+}
+
+// byteReader reads values from the start of a byte slice. After the first error, reads return zero values and the
+// error is kept in err.
+type byteReader struct {
+	b   []byte
+	err error
+}
+
+func (r *byteReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.err = errShortData
+		return 0
+	}
+	r.b = r.b[n:]
+
+	return v
+}
+
+func (r *byteReader) next(n uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if n > uint64(len(r.b)) {
+		r.err = errShortData
+		return nil
+	}
+
+	v := r.b[:n]
+	r.b = r.b[n:]
+
+	return v
+}
+
+func (r *byteReader) lengthPrefixed() []byte {
+	return r.next(r.uvarint())
+}
@@ -0,0 +1,178 @@
+//go:build !js
+// +build !js
+
+package leveldb
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/midnightfreddie/goleveldb/leveldb"
+	"github.com/midnightfreddie/goleveldb/leveldb/opt"
+	"github.com/midnightfreddie/goleveldb/leveldb/util"
+)
+
+// testDBFiles returns the content of the files in the db directory of a world.
+func testDBFiles(t *testing.T, world string) map[string][]byte {
+	infos, err := ioutil.ReadDir(filepath.Join(world, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string][]byte)
+	for _, info := range infos {
+		data, err := ioutil.ReadFile(filepath.Join(world, "db", info.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[info.Name()] = data
+	}
+
+	return files
+}
+
+func TestReadDB(t *testing.T) {
+	for _, c := range []opt.Compression{opt.NoCompression, opt.SnappyCompression} {
+		t.Run(c.String(), func(t *testing.T) {
+			world := t.TempDir()
+
+			db, err := leveldb.OpenFile(filepath.Join(world, "db"), &opt.Options{Compression: c})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Enough records to fill several table blocks and a journal record spanning journal blocks
+			for i := 0; i < 500; i++ {
+				err := db.Put([]byte(fmt.Sprintf("key%03d", i)), bytes.Repeat([]byte{byte(i)}, 100), nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := db.CompactRange(util.Range{}); err != nil {
+				t.Fatal(err)
+			}
+
+			// The last writes are only in the journal
+			b := new(leveldb.Batch)
+			b.Put([]byte("key000"), []byte("new"))
+			b.Put([]byte("large"), bytes.Repeat([]byte{1}, 100000))
+			b.Delete([]byte("key001"))
+			if err := db.Write(b, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := db.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := ReadDB(testDBFiles(t, world))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			d, err := Open(world)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			keys, err := d.Keys()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m.Len() != len(keys) || m.Len() != 500 {
+				t.Fatalf("expected %d keys: got %d", len(keys), m.Len())
+			}
+
+			for _, k := range keys {
+				expected, err := d.Get(k)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if v, err := m.Get(k); err != nil || !bytes.Equal(v, expected) {
+					t.Errorf("expected the value of %s read by leveldb: got %d bytes with error %v", k, len(v), err)
+				}
+			}
+
+			if _, err := m.Get([]byte("key001")); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected the deleted key not to be found: got %v", err)
+			}
+		})
+	}
+}
+
+func TestReadDBMissingTable(t *testing.T) {
+	world := t.TempDir()
+
+	db, err := leveldb.OpenFile(filepath.Join(world, "db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("key"), []byte("value"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CompactRange(util.Range{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := testDBFiles(t, world)
+	for name := range files {
+		if filepath.Ext(name) == ".ldb" {
+			delete(files, name)
+		}
+	}
+
+	if _, err := ReadDB(files); err == nil {
+		t.Errorf("expected an error reading a database without the tables listed in its manifest")
+	}
+}
+
+// TestReadBlockRawDeflate reads a table block compressed with raw deflate, as the game compresses them.
+func TestReadBlockRawDeflate(t *testing.T) {
+	content := bytes.Repeat([]byte("minecraft:stone"), 100)
+
+	buf := bytes.Buffer{}
+	z, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := z.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := append(buf.Bytes(), blockRawZlib)
+	data = append(data, make([]byte, 4)...)
+	binary.LittleEndian.PutUint32(data[len(data)-4:], maskedChecksum(data[:len(data)-4]))
+
+	handle := make([]byte, 2*binary.MaxVarintLen64)
+	n := binary.PutUvarint(handle, 0)
+	handle = handle[:n+binary.PutUvarint(handle[n:], uint64(buf.Len()))]
+
+	block, err := readBlock(data, &byteReader{b: handle})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !bytes.Equal(block, content) {
+		t.Errorf("expected the uncompressed block: got %d bytes", len(block))
+	}
+
+	data[0]++
+	if _, err := readBlock(data, &byteReader{b: handle}); err == nil {
+		t.Errorf("expected an error reading a damaged block")
+	}
+}
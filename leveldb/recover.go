@@ -0,0 +1,244 @@
+//go:build !js
+// +build !js
+
+package leveldb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/midnightfreddie/goleveldb/leveldb"
+	lerrors "github.com/midnightfreddie/goleveldb/leveldb/errors"
+	"github.com/midnightfreddie/goleveldb/leveldb/journal"
+	"github.com/midnightfreddie/goleveldb/leveldb/opt"
+	"github.com/midnightfreddie/goleveldb/leveldb/storage"
+)
+
+// Problem is damage found in a file of a database.
+type Problem struct {
+	File   string // The name of the file in the db directory
+	Bytes  int    // The number of bytes which can't be read, or 0 if not known
+	Reason string
+}
+
+func (p Problem) String() string {
+	if p.Bytes == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Reason)
+	}
+
+	return fmt.Sprintf("%s: %d bytes lost: %s", p.File, p.Bytes, p.Reason)
+}
+
+// Report is the result of checking or recovering a database.
+type Report struct {
+	// OpenErr is the error opening the database with every integrity check enabled, or nil if it opened cleanly.
+	OpenErr error
+	// Problems are the damaged parts of the database's files.
+	Problems []Problem
+	// Rebuilt is true if the manifest, which lists the database's table files, was damaged or listed missing files and
+	// was rebuilt from the table files found.
+	Rebuilt bool
+	// Keys is the number of keys which could be read.
+	Keys int
+}
+
+// Damaged returns true if any damage was found.
+func (r Report) Damaged() bool {
+	return r.OpenErr != nil || len(r.Problems) > 0
+}
+
+// IsCorrupted returns true if err was caused by damaged database files, such as after the game crashed while saving.
+func IsCorrupted(err error) bool {
+	var c *lerrors.ErrCorrupted
+	var s *storage.ErrCorrupted
+
+	return errors.As(err, &c) || errors.As(err, &s)
+}
+
+// Check looks for damage to the database in the given world folder without changing it. The database is opened read
+// only with every integrity check enabled, each key is read and the journal (.log) files, which hold the most recent
+// writes, are scanned for damaged records.
+func Check(worldPath string) (Report, error) {
+	dbPath := filepath.Join(worldPath, "db")
+
+	r := Report{}
+
+	problems, err := scanJournals(dbPath)
+	if err != nil {
+		return r, err
+	}
+	r.Problems = problems
+
+	db, err := leveldb.OpenFile(dbPath, &opt.Options{ReadOnly: true, Strict: opt.StrictAll})
+	if err != nil {
+		r.OpenErr = err
+		return r, nil
+	}
+	defer db.Close()
+
+	if r.Keys, err = countKeys(db); err != nil {
+		r.OpenErr = err
+	}
+
+	return r, nil
+}
+
+// Recover opens a database which may be damaged, such as after the game crashed while saving. Damaged journal records
+// are dropped, and if the manifest is damaged or lists missing files it is rebuilt from the table files, so the
+// database is returned in its latest consistent state. The report lists what was lost. Recovery changes the database
+// files, so copy them first with Backup.
+func Recover(worldPath string) (*DB, Report, error) {
+	dbPath := filepath.Join(worldPath, "db")
+
+	r, err := Check(worldPath)
+	if err != nil {
+		return nil, r, err
+	}
+
+	// Journal records which fail their checksums are dropped by the default options
+	db, err := leveldb.OpenFile(dbPath, nil)
+	if IsCorrupted(err) {
+		r.Rebuilt = true
+		db, err = leveldb.RecoverFile(dbPath, nil)
+	}
+	if err != nil {
+		return nil, r, fmt.Errorf("recovering leveldb: %w", err)
+	}
+
+	if r.Keys, err = countKeys(db); err != nil {
+		db.Close()
+		return nil, r, fmt.Errorf("reading recovered leveldb: %w", err)
+	}
+
+	return &DB{db: db}, r, nil
+}
+
+// countKeys returns the number of keys in the database, returning an error if any can't be read.
+func countKeys(db *leveldb.DB) (int, error) {
+	n := 0
+
+	iter := db.NewIterator(nil, nil)
+	for iter.Next() {
+		n++
+	}
+	iter.Release()
+
+	return n, iter.Error()
+}
+
+// journalDropper records the parts of a journal file which can't be read.
+type journalDropper struct {
+	file     string
+	problems *[]Problem
+}
+
+func (d journalDropper) Drop(err error) {
+	p := Problem{File: d.file, Reason: err.Error()}
+
+	var c *journal.ErrCorrupted
+	if errors.As(err, &c) {
+		p.Bytes, p.Reason = c.Size, c.Reason
+	}
+
+	*d.problems = append(*d.problems, p)
+}
+
+// scanJournals reads every record of the journal files in a db directory and returns the damaged parts.
+func scanJournals(dbPath string) ([]Problem, error) {
+	names, err := filepath.Glob(filepath.Join(dbPath, "*.log"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	problems := make([]Problem, 0)
+
+	for _, path := range names {
+		if err := scanJournal(path, &problems); err != nil {
+			return nil, err
+		}
+	}
+
+	return problems, nil
+}
+
+func scanJournal(path string, problems *[]Problem) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening journal: %w", err)
+	}
+	defer f.Close()
+
+	name := filepath.Base(path)
+	r := journal.NewReader(f, journalDropper{file: name, problems: problems}, false, true)
+
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// A record cut short by a crash ends the journal
+			*problems = append(*problems, Problem{File: name, Reason: err.Error()})
+			return nil
+		}
+
+		if _, err := io.Copy(ioutil.Discard, record); err != nil {
+			*problems = append(*problems, Problem{File: name, Reason: err.Error()})
+			return nil
+		}
+	}
+}
+
+// Backup copies the files of the database in the given world folder to a new directory, such as before recovering it.
+func Backup(worldPath, dst string) error {
+	dbPath := filepath.Join(worldPath, "db")
+
+	if err := os.Mkdir(dst, 0755); err != nil {
+		return fmt.Errorf("creating backup directory: %w", err)
+	}
+
+	entries, err := ioutil.ReadDir(dbPath)
+	if err != nil {
+		return fmt.Errorf("reading database directory: %w", err)
+	}
+
+	for _, e := range entries {
+		// The lock file is held by any process with the database open and is not needed in a copy
+		if e.IsDir() || strings.EqualFold(e.Name(), "LOCK") {
+			continue
+		}
+
+		if err := copyFile(filepath.Join(dbPath, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", dst, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("copying %s: %w", src, err)
+	}
+
+	return out.Close()
+}
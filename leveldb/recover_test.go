@@ -0,0 +1,182 @@
+//go:build !js
+// +build !js
+
+package leveldb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/midnightfreddie/goleveldb/leveldb"
+	"github.com/midnightfreddie/goleveldb/leveldb/util"
+)
+
+const testRecoverKeys = 200
+
+// testRecoverWorld returns a world folder with a database of testRecoverKeys records, each with a 1KiB value. If
+// compact is true the records are written to table files, otherwise they are only in the journal.
+func testRecoverWorld(t *testing.T, compact bool) string {
+	world := t.TempDir()
+
+	db, err := leveldb.OpenFile(filepath.Join(world, "db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < testRecoverKeys; i++ {
+		if err := db.Put([]byte(fmt.Sprintf("key%03d", i)), bytes.Repeat([]byte{byte(i)}, 1024), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if compact {
+		if err := db.CompactRange(util.Range{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return world
+}
+
+func TestCheckClean(t *testing.T) {
+	r, err := Check(testRecoverWorld(t, false))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if r.Damaged() || r.Keys != testRecoverKeys {
+		t.Errorf("expected an undamaged database with %d keys: got %+v", testRecoverKeys, r)
+	}
+}
+
+func TestRecoverJournal(t *testing.T) {
+	world := testRecoverWorld(t, false)
+
+	logs, _ := filepath.Glob(filepath.Join(world, "db", "*.log"))
+	if len(logs) != 1 {
+		t.Fatalf("expected one journal file: got %v", logs)
+	}
+
+	// Damage the second 32KiB journal block, as if a write to it was cut short
+	data, err := ioutil.ReadFile(logs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 40000; i < 40100; i++ {
+		data[i] = 0xff
+	}
+	if err := ioutil.WriteFile(logs[0], data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Check(world)
+	if err != nil {
+		t.Fatalf("unexpected error checking: %s", err)
+	}
+
+	if r.OpenErr == nil || len(r.Problems) == 0 || r.Problems[0].File != filepath.Base(logs[0]) {
+		t.Errorf("expected journal damage to be found: got %+v", r)
+	}
+
+	db, r, err := Recover(world)
+	if err != nil {
+		t.Fatalf("unexpected error recovering: %s", err)
+	}
+	defer db.Close()
+
+	if r.Rebuilt || r.Keys == 0 || r.Keys >= testRecoverKeys {
+		t.Errorf("expected records in the damaged block to be dropped: got %+v", r)
+	}
+
+	if _, err := db.Get([]byte("key000")); err != nil {
+		t.Errorf("expected records before the damage to be kept: got %s", err)
+	}
+}
+
+func TestRecoverManifest(t *testing.T) {
+	world := testRecoverWorld(t, true)
+
+	manifests, _ := filepath.Glob(filepath.Join(world, "db", "MANIFEST-*"))
+	for _, m := range manifests {
+		if err := os.Remove(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := Open(world); !IsCorrupted(err) {
+		t.Fatalf("expected a corruption error opening the database: got %v", err)
+	}
+
+	backup := filepath.Join(t.TempDir(), "backup")
+	if err := Backup(world, backup); err != nil {
+		t.Fatalf("unexpected error backing up: %s", err)
+	}
+
+	db, r, err := Recover(world)
+	if err != nil {
+		t.Fatalf("unexpected error recovering: %s", err)
+	}
+	defer db.Close()
+
+	if !r.Rebuilt || r.Keys != testRecoverKeys {
+		t.Errorf("expected the manifest to be rebuilt with %d keys: got %+v", testRecoverKeys, r)
+	}
+
+	// The backup is of the database before recovery
+	if m, _ := filepath.Glob(filepath.Join(backup, "MANIFEST-*")); len(m) != 0 {
+		t.Errorf("expected the backup to have no manifest: got %v", m)
+	}
+}
+
+func TestForEachKey(t *testing.T) {
+	db, err := Open(testRecoverWorld(t, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	want, err := db.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := 0
+	err = db.ForEachKey(func(key []byte) error {
+		if i >= len(want) || !bytes.Equal(key, want[i]) {
+			t.Errorf("key %d: expected the same order as Keys: got %q", i, key)
+		}
+		i++
+
+		// Changes while iterating are not seen
+		return db.Put([]byte(fmt.Sprintf("new%03d", i)), []byte{})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if i != testRecoverKeys {
+		t.Errorf("expected %d keys: got %d", testRecoverKeys, i)
+	}
+}
+
+func TestIsLocked(t *testing.T) {
+	world := testRecoverWorld(t, false)
+
+	db, err := Open(world)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := Open(world); !IsLocked(err) {
+		t.Errorf("expected a locked error opening an open database: got %v", err)
+	}
+}
@@ -1,13 +1,62 @@
 package mock
 
+import (
+	"sort"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// LevelDB is an in memory database. Keys which have not been Put return the default value, if one is set.
 type LevelDB struct {
-	data []byte
+	data    []byte
+	records map[string][]byte
 }
 
-func (w *LevelDB) Get(_ []byte) ([]byte, error) {
+func (w *LevelDB) Get(key []byte) ([]byte, error) {
+	if v, ok := w.records[string(key)]; ok {
+		return v, nil
+	}
+
+	if w.data == nil {
+		return nil, leveldb.ErrNotFound
+	}
+
 	return w.data, nil
 }
 
+func (w *LevelDB) Put(key, value []byte) error {
+	v := make([]byte, len(value))
+	copy(v, value)
+	w.records[string(key)] = v
+
+	return nil
+}
+
+func (w *LevelDB) Delete(key []byte) error {
+	delete(w.records, string(key))
+	return nil
+}
+
+// Keys returns every key which has been Put, in sorted order.
+func (w *LevelDB) Keys() ([][]byte, error) {
+	keys := make([][]byte, 0, len(w.records))
+	for k := range w.records {
+		keys = append(keys, []byte(k))
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return string(keys[i]) < string(keys[j])
+	})
+
+	return keys, nil
+}
+
+// NewLevelDB returns an empty database.
+func NewLevelDB() *LevelDB {
+	return &LevelDB{records: make(map[string][]byte)}
+}
+
+// ValidLevelDB returns a database which returns SubChunkValue for any key which has not been Put.
 func ValidLevelDB() *LevelDB {
-	return &LevelDB{SubChunkValue}
+	return &LevelDB{data: SubChunkValue, records: make(map[string][]byte)}
 }
@@ -0,0 +1,260 @@
+package nbt
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// NBT tag types.
+const (
+	TagEnd byte = iota
+	TagByte
+	TagShort
+	TagInt
+	TagLong
+	TagFloat
+	TagDouble
+	TagByteArray
+	TagString
+	TagList
+	TagCompound
+	TagIntArray
+	TagLongArray
+)
+
+// Values are held in the form produced by nbt2json: compound values are a slice of tags as
+// map[string]interface{}, list values are a map with tagListType and list keys, longs are a map of valueLeast and
+// valueMost and all other numbers are float64 when decoded.
+
+// Decode reads every top level tag in data.
+func Decode(data []byte) ([]NBTTag, error) {
+	return Read(bytes.NewReader(data), -1)
+}
+
+// Read reads count top level tags from r. If count is negative tags are read until r is empty.
+func Read(r *bytes.Reader, count int) ([]NBTTag, error) {
+	var d *Decoder
+	return d.Read(r, count)
+}
+
+// Tags returns the children of a compound tag. It returns nil if n is not a compound.
+func (n *NBTTag) Tags() []NBTTag {
+	values, ok := n.Value.([]interface{})
+	if !ok || n.Type != TagCompound {
+		return nil
+	}
+
+	tags := make([]NBTTag, 0, len(values))
+	for _, v := range values {
+		if t, ok := tagFromValue(v); ok {
+			tags = append(tags, t)
+		}
+	}
+
+	return tags
+}
+
+// Child returns the child of a compound tag with the given name.
+func (n *NBTTag) Child(name string) (NBTTag, bool) {
+	for _, t := range n.Tags() {
+		if t.Name == name {
+			return t, true
+		}
+	}
+
+	return NBTTag{}, false
+}
+
+// Path returns the descendant of a compound tag found by following the given child names.
+func (n *NBTTag) Path(names ...string) (NBTTag, bool) {
+	t := *n
+
+	for _, name := range names {
+		var ok bool
+		if t, ok = t.Child(name); !ok {
+			return NBTTag{}, false
+		}
+	}
+
+	return t, true
+}
+
+// SetChild replaces the child of a compound tag which has the same name as tag, or appends tag if there is none.
+func (n *NBTTag) SetChild(tag NBTTag) error {
+	if n.Type != TagCompound {
+		return fmt.Errorf("tag '%s' of type %d is not a compound", n.Name, n.Type)
+	}
+
+	values, _ := n.Value.([]interface{})
+	m := tag.toMap()
+
+	for i, v := range values {
+		if t, ok := tagFromValue(v); ok && t.Name == tag.Name {
+			values[i] = m
+			return nil
+		}
+	}
+
+	n.Value = append(values, m)
+
+	return nil
+}
+
+// RemoveChild removes the child of a compound tag with the given name. The returned bool is false if there was none.
+func (n *NBTTag) RemoveChild(name string) bool {
+	values, _ := n.Value.([]interface{})
+
+	for i, v := range values {
+		if t, ok := tagFromValue(v); ok && t.Name == name {
+			n.Value = append(values[:i], values[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// List returns the elements of a list tag as unnamed tags of the list's element type.
+func (n *NBTTag) List() []NBTTag {
+	m, ok := n.Value.(map[string]interface{})
+	if !ok || n.Type != TagList {
+		return nil
+	}
+
+	listType, _ := toFloat(m["tagListType"])
+	values, _ := m["list"].([]interface{})
+
+	tags := make([]NBTTag, len(values))
+	for i, v := range values {
+		tags[i] = NBTTag{Type: byte(listType), Value: v}
+	}
+
+	return tags
+}
+
+// SetList replaces the elements of a list tag. The elements must have the list's element type.
+func (n *NBTTag) SetList(elements []NBTTag) error {
+	m, ok := n.Value.(map[string]interface{})
+	if !ok || n.Type != TagList {
+		return fmt.Errorf("tag '%s' of type %d is not a list", n.Name, n.Type)
+	}
+
+	listType, _ := toFloat(m["tagListType"])
+
+	values := make([]interface{}, len(elements))
+	for i, e := range elements {
+		if e.Type != byte(listType) {
+			return fmt.Errorf("element %d has type %d: list type is %d", i, e.Type, byte(listType))
+		}
+		values[i] = e.Value
+	}
+
+	n.Value = map[string]interface{}{
+		"tagListType": listType,
+		"list":        values,
+	}
+
+	return nil
+}
+
+// Int returns the value of a byte, short, int or long tag.
+func (n *NBTTag) Int() (int64, bool) {
+	switch n.Type {
+	case TagByte, TagShort, TagInt:
+		f, ok := toFloat(n.Value)
+		return int64(f), ok
+	case TagLong:
+		switch v := n.Value.(type) {
+		case map[string]interface{}:
+			least, ok1 := toFloat(v["valueLeast"])
+			most, ok2 := toFloat(v["valueMost"])
+			return int64(uint32(least)) | int64(uint32(most))<<32, ok1 && ok2
+		case string:
+			var i int64
+			_, err := fmt.Sscan(v, &i)
+			return i, err == nil
+		}
+	}
+
+	return 0, false
+}
+
+// Float returns the value of a float or double tag, or of any integer tag.
+func (n *NBTTag) Float() (float64, bool) {
+	switch n.Type {
+	case TagFloat, TagDouble:
+		return toFloat(n.Value)
+	}
+
+	i, ok := n.Int()
+
+	return float64(i), ok
+}
+
+// StringValue returns the value of a string tag.
+func (n *NBTTag) StringValue() (string, bool) {
+	if n.Type != TagString {
+		return "", false
+	}
+
+	s, ok := n.Value.(string)
+
+	return s, ok
+}
+
+// Long returns the value representation of a long tag.
+func Long(i int64) interface{} {
+	return map[string]interface{}{
+		"valueLeast": float64(uint32(i)),
+		"valueMost":  float64(uint32(i >> 32)),
+	}
+}
+
+func (n NBTTag) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"tagType": n.Type,
+		"name":    n.Name,
+		"value":   n.Value,
+	}
+}
+
+func tagFromValue(v interface{}) (NBTTag, bool) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		tagType, ok := toFloat(t["tagType"])
+		if !ok {
+			return NBTTag{}, false
+		}
+
+		name, _ := t["name"].(string)
+
+		return NBTTag{Type: byte(tagType), Name: name, Value: t["value"]}, true
+	case NBTTag:
+		return t, true
+	}
+
+	return NBTTag{}, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case byte:
+		return float64(n), true
+	}
+
+	return 0, false
+}
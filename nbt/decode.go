@@ -0,0 +1,376 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// Decoder decodes little endian Bedrock NBT into tags holding values in the form produced by nbt2json, without
+// converting to JSON and back as nbt2json does. A Decoder takes the maps and slices holding compound, list, long and
+// array values from pools shared by every Decoder, and Release returns them, so a scan which decodes one record at a
+// time and releases it before the next allocates little once it has warmed up.
+//
+// Tags decoded by a Decoder, and any value taken from them, must not be used after Release. A nil Decoder allocates
+// values which are never reused, as Decode does.
+type Decoder struct {
+	maps   []map[string]interface{}
+	slices []*[]interface{}
+	names  map[string]string // Tag names and short strings, so that repeated strings are allocated once
+}
+
+// maxInterned is the number of strings a Decoder keeps for reuse, so that decoding many distinct strings doesn't
+// grow it without limit.
+const maxInterned = 4096
+
+var (
+	mapPool   = sync.Pool{New: func() interface{} { return make(map[string]interface{}, 3) }}
+	slicePool = sync.Pool{New: func() interface{} { s := make([]interface{}, 0, 8); return &s }}
+)
+
+// smallNumbers are the values of integers from -128 to 255 as held in tags, boxed once so that the common small
+// values of byte, short and int tags don't each allocate.
+var smallNumbers = func() (n [384]interface{}) {
+	for i := range n {
+		n[i] = float64(i - 128)
+	}
+	return n
+}()
+
+// NewDecoder returns a Decoder with no values to release.
+func NewDecoder() *Decoder {
+	return &Decoder{names: make(map[string]string)}
+}
+
+// Decode reads every top level tag in data.
+func (d *Decoder) Decode(data []byte) ([]NBTTag, error) {
+	return d.Read(bytes.NewReader(data), -1)
+}
+
+// Read reads count top level tags from r. If count is negative tags are read until r is empty.
+func (d *Decoder) Read(r *bytes.Reader, count int) ([]NBTTag, error) {
+	tags := make([]NBTTag, 0)
+
+	for i := 0; i != count && r.Len() > 0; i++ {
+		tagType, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading type of tag %d: %w", i, err)
+		}
+
+		t := NBTTag{Type: tagType}
+
+		if tagType != TagEnd {
+			if t.Name, err = d.string(r); err != nil {
+				return nil, fmt.Errorf("reading name of tag %d: %w", i, err)
+			}
+
+			if t.Value, err = d.payload(r, tagType); err != nil {
+				return nil, fmt.Errorf("reading tag %d '%s': %w", i, t.Name, err)
+			}
+		}
+
+		tags = append(tags, t)
+	}
+
+	if count >= 0 && len(tags) != count {
+		return nil, fmt.Errorf("%d tags read: expected %d", len(tags), count)
+	}
+
+	return tags, nil
+}
+
+// Release returns the values of every tag decoded since the last Release to the pools, for reuse by later decoding.
+func (d *Decoder) Release() {
+	if d == nil {
+		return
+	}
+
+	for _, m := range d.maps {
+		for k := range m {
+			delete(m, k)
+		}
+		mapPool.Put(m)
+	}
+
+	for _, s := range d.slices {
+		for i := range *s {
+			(*s)[i] = nil
+		}
+		*s = (*s)[:0]
+		slicePool.Put(s)
+	}
+
+	d.maps, d.slices = d.maps[:0], d.slices[:0]
+}
+
+// newMap returns an empty map, from the pool unless d is nil.
+func (d *Decoder) newMap() map[string]interface{} {
+	if d == nil {
+		return make(map[string]interface{}, 3)
+	}
+
+	m := mapPool.Get().(map[string]interface{})
+	d.maps = append(d.maps, m)
+
+	return m
+}
+
+// newSlice returns a pointer to an empty slice, from the pool unless d is nil. The slice appended to must be stored
+// back through the pointer, so that the grown slice is the one returned to the pool.
+func (d *Decoder) newSlice(capacity int) *[]interface{} {
+	if d == nil {
+		s := make([]interface{}, 0, capacity)
+		return &s
+	}
+
+	s := slicePool.Get().(*[]interface{})
+	d.slices = append(d.slices, s)
+
+	return s
+}
+
+// payload reads the payload of a tag of the given type.
+func (d *Decoder) payload(r *bytes.Reader, tagType byte) (interface{}, error) {
+	var buf [8]byte
+
+	switch tagType {
+	case TagByte:
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		return number(int64(int8(b))), nil
+	case TagShort:
+		if _, err := io.ReadFull(r, buf[:2]); err != nil {
+			return nil, err
+		}
+		return number(int64(int16(binary.LittleEndian.Uint16(buf[:])))), nil
+	case TagInt:
+		if _, err := io.ReadFull(r, buf[:4]); err != nil {
+			return nil, err
+		}
+		return number(int64(int32(binary.LittleEndian.Uint32(buf[:])))), nil
+	case TagLong:
+		if _, err := io.ReadFull(r, buf[:8]); err != nil {
+			return nil, err
+		}
+		return d.long(int64(binary.LittleEndian.Uint64(buf[:]))), nil
+	case TagFloat:
+		if _, err := io.ReadFull(r, buf[:4]); err != nil {
+			return nil, err
+		}
+		return float32Value(math.Float32frombits(binary.LittleEndian.Uint32(buf[:]))), nil
+	case TagDouble:
+		if _, err := io.ReadFull(r, buf[:8]); err != nil {
+			return nil, err
+		}
+		f := math.Float64frombits(binary.LittleEndian.Uint64(buf[:]))
+		if math.IsNaN(f) {
+			return "NaN", nil
+		}
+		return f, nil
+	case TagString:
+		return d.string(r)
+	case TagByteArray, TagIntArray, TagLongArray:
+		return d.array(r, tagType)
+	case TagList:
+		return d.list(r)
+	case TagCompound:
+		return d.compound(r)
+	}
+
+	return nil, fmt.Errorf("invalid tag type %d", tagType)
+}
+
+// number returns the value of an integer tag.
+func number(i int64) interface{} {
+	if i >= -128 && i < 256 {
+		return smallNumbers[i+128]
+	}
+
+	return float64(i)
+}
+
+// float32Value returns the value of a float tag, rounded as nbt2json's JSON gives it: the shortest decimal which
+// reads back as the same float32, read as a float64.
+func float32Value(f float32) interface{} {
+	v, err := strconv.ParseFloat(strconv.FormatFloat(float64(f), 'g', -1, 32), 64)
+	if err != nil {
+		return float64(f)
+	}
+
+	return v
+}
+
+// long returns the value of a long tag, as returned by Long.
+func (d *Decoder) long(i int64) interface{} {
+	m := d.newMap()
+	m["valueLeast"] = float64(uint32(i))
+	m["valueMost"] = float64(uint32(i >> 32))
+
+	return m
+}
+
+// string reads a string prefixed with its 16 bit length.
+func (d *Decoder) string(r *bytes.Reader) (string, error) {
+	var buf [2]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return "", fmt.Errorf("reading string length: %w", err)
+	}
+
+	length := int(binary.LittleEndian.Uint16(buf[:]))
+	if r.Len() < length {
+		return "", fmt.Errorf("string of length %d: %d bytes remain: %w", length, r.Len(), io.ErrUnexpectedEOF)
+	}
+
+	// Most strings are short enough to read without allocating anything but the string
+	var scratch [64]byte
+	b := scratch[:0]
+	if length > len(scratch) {
+		b = make([]byte, 0, length)
+	}
+	b = b[:length]
+	_, _ = r.Read(b)
+
+	if d == nil || length > len(scratch) {
+		return string(b), nil
+	}
+
+	if s, ok := d.names[string(b)]; ok {
+		return s, nil
+	}
+
+	s := string(b)
+	if len(d.names) < maxInterned {
+		d.names[s] = s
+	}
+
+	return s, nil
+}
+
+// array reads a byte, int or long array. Empty arrays are nil.
+func (d *Decoder) array(r *bytes.Reader, tagType byte) (interface{}, error) {
+	length, err := readLength(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if length == 0 {
+		return nil, nil
+	}
+
+	size := map[byte]int64{TagByteArray: 1, TagIntArray: 4, TagLongArray: 8}[tagType]
+	if int64(r.Len()) < length*size {
+		return nil, fmt.Errorf("array of length %d: %d bytes remain: %w", length, r.Len(), io.ErrUnexpectedEOF)
+	}
+
+	s := d.newSlice(int(length))
+	values := (*s)[:0]
+
+	var buf [8]byte
+	for i := int64(0); i < length; i++ {
+		_, _ = r.Read(buf[:size])
+
+		switch tagType {
+		case TagByteArray:
+			values = append(values, number(int64(int8(buf[0]))))
+		case TagIntArray:
+			values = append(values, number(int64(int32(binary.LittleEndian.Uint32(buf[:])))))
+		case TagLongArray:
+			values = append(values, d.long(int64(binary.LittleEndian.Uint64(buf[:]))))
+		}
+	}
+
+	*s = values
+
+	return values, nil
+}
+
+// list reads the element type and length of a list followed by the payload of each element. The list of an empty
+// list is nil.
+func (d *Decoder) list(r *bytes.Reader) (interface{}, error) {
+	listType, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("reading list type: %w", err)
+	}
+
+	length, err := readLength(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m := d.newMap()
+	m["tagListType"] = number(int64(listType))
+	m["list"] = nil
+
+	if length == 0 {
+		return m, nil
+	}
+
+	// End tags have no payload so only an empty list may hold them. Every other element is at least a byte long, so
+	// the length is checked against the bytes remaining before it sizes the slice.
+	if listType == TagEnd {
+		return nil, fmt.Errorf("list of end tags has length %d: expected 0", length)
+	}
+	if int64(r.Len()) < length {
+		return nil, fmt.Errorf("list of length %d: %d bytes remain: %w", length, r.Len(), io.ErrUnexpectedEOF)
+	}
+
+	s := d.newSlice(int(length))
+	values := (*s)[:0]
+
+	for i := int64(0); i < length; i++ {
+		v, err := d.payload(r, listType)
+		if err != nil {
+			return nil, fmt.Errorf("list element %d: %w", i, err)
+		}
+		values = append(values, v)
+	}
+
+	*s = values
+	m["list"] = values
+
+	return m, nil
+}
+
+// compound reads named tags up to the end tag which closes a compound.
+func (d *Decoder) compound(r *bytes.Reader) (interface{}, error) {
+	s := d.newSlice(8)
+	values := (*s)[:0]
+
+	for {
+		tagType, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading tag type: %w", err)
+		}
+
+		if tagType == TagEnd {
+			break
+		}
+
+		name, err := d.string(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading name: %w", err)
+		}
+
+		value, err := d.payload(r, tagType)
+		if err != nil {
+			return nil, fmt.Errorf("reading '%s': %w", name, err)
+		}
+
+		m := d.newMap()
+		m["tagType"] = number(int64(tagType))
+		m["name"] = name
+		m["value"] = value
+
+		values = append(values, m)
+	}
+
+	*s = values
+
+	return values, nil
+}
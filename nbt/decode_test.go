@@ -0,0 +1,177 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/nbt2json"
+)
+
+// decodeJSON decodes tags by converting them to JSON with nbt2json, as Decode did before it decoded them itself.
+func decodeJSON(t testing.TB, data []byte) []NBTTag {
+	r := bytes.NewReader(data)
+	tags := make([]NBTTag, 0)
+
+	for r.Len() > 0 {
+		j, err := nbt2json.ReadNbt2Json(r, "", 1)
+		if err != nil {
+			t.Fatalf("unexpected error calling nbt2json: %s", err)
+		}
+
+		nbtData := struct {
+			NBT []NBTTag
+		}{}
+		if err := json.Unmarshal(j, &nbtData); err != nil {
+			t.Fatalf("unexpected error unmarshaling json: %s", err)
+		}
+
+		tags = append(tags, nbtData.NBT...)
+	}
+
+	return tags
+}
+
+// testDecodeData returns tags of every type nbt2json can decode, and the palettes of a sub chunk saved by the game.
+func testDecodeData(t testing.TB) [][]byte {
+	root := NBTTag{Type: TagCompound, Name: "root", Value: []interface{}{}}
+	for _, c := range []NBTTag{
+		{Type: TagByte, Name: "byte", Value: -3.0},
+		{Type: TagShort, Name: "short", Value: 1000},
+		{Type: TagInt, Name: "int", Value: int32(-70000)},
+		{Type: TagLong, Name: "long", Value: Long(-1 << 40)},
+		{Type: TagFloat, Name: "float", Value: 0.1},
+		{Type: TagDouble, Name: "double", Value: -1.5e300},
+		{Type: TagByteArray, Name: "bytes", Value: []interface{}{1.0, -1.0}},
+		{Type: TagByteArray, Name: "no bytes", Value: nil},
+		{Type: TagString, Name: "string", Value: "minecraft:stone"},
+		{Type: TagString, Name: "long string", Value: string(bytes.Repeat([]byte("ab"), 100))},
+		{Type: TagList, Name: "empty", Value: map[string]interface{}{"tagListType": TagCompound}},
+		{Type: TagIntArray, Name: "ints", Value: []interface{}{1.0, 2.0, 300000.0}},
+		{Type: TagCompound, Name: "compound", Value: []interface{}{}},
+	} {
+		_ = root.SetChild(c)
+	}
+
+	floats := NBTTag{Type: TagList, Name: "floats", Value: map[string]interface{}{"tagListType": TagFloat}}
+	_ = floats.SetList([]NBTTag{{Type: TagFloat, Value: 1.0}, {Type: TagFloat, Value: -2.3}})
+	_ = root.SetChild(floats)
+
+	compounds := NBTTag{Type: TagList, Name: "compounds", Value: map[string]interface{}{"tagListType": TagCompound}}
+	_ = compounds.SetList([]NBTTag{
+		{Type: TagCompound, Value: []interface{}{NBTTag{Type: TagString, Name: "name", Value: "a"}.toMap()}},
+		{Type: TagCompound, Value: []interface{}{}},
+	})
+	_ = root.SetChild(compounds)
+
+	b, err := Encode([]NBTTag{root, {Type: TagInt, Name: "second", Value: 1}})
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+
+	data := [][]byte{b}
+
+	// The palettes of the two block storages in a sub chunk saved by the game
+	r := bytes.NewReader(mock.SubChunkValue)
+	_, _ = r.Seek(2, io.SeekStart)
+
+	for storage := 0; storage < 2; storage++ {
+		bitsPerBlock, _ := r.ReadByte()
+		blocksPerWord := 32 / int(bitsPerBlock>>1)
+		_, _ = r.Seek(int64((4096+blocksPerWord-1)/blocksPerWord*4), io.SeekCurrent)
+
+		var size int32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			t.Fatal(err)
+		}
+
+		n, err := Size(r, int(size))
+		if err != nil {
+			t.Fatalf("storage %d: unexpected error getting palette size: %s", storage, err)
+		}
+
+		start := len(mock.SubChunkValue) - r.Len()
+		data = append(data, mock.SubChunkValue[start:start+n])
+		_, _ = r.Seek(int64(n), io.SeekCurrent)
+	}
+
+	return data
+}
+
+func TestDecode(t *testing.T) {
+	d := NewDecoder()
+
+	for i, data := range testDecodeData(t) {
+		want := decodeJSON(t, data)
+
+		got, err := Decode(data)
+		if err != nil {
+			t.Fatalf("data %d: unexpected error decoding: %s", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("data %d: decoded tags differ from nbt2json's:\ngot  %#v\nwant %#v", i, got, want)
+		}
+
+		// Pooled values are reused once released, so decoding twice checks that released values are emptied
+		for j := 0; j < 2; j++ {
+			pooled, err := d.Decode(data)
+			if err != nil {
+				t.Fatalf("data %d: unexpected error decoding with a decoder: %s", i, err)
+			}
+			if !reflect.DeepEqual(pooled, want) {
+				t.Errorf("data %d: tags decoded with a decoder differ from nbt2json's:\ngot  %#v\nwant %#v",
+					i, pooled, want)
+			}
+			d.Release()
+		}
+	}
+
+	if _, err := Decode([]byte{TagString, 1, 0, 'a', 5, 0, 'b'}); err == nil {
+		t.Error("expected an error decoding a truncated string")
+	}
+
+	if _, err := Decode([]byte{99, 0, 0}); err == nil {
+		t.Error("expected an error decoding an invalid tag type")
+	}
+
+	// A list of end tags can't hold any elements, however long it claims to be
+	for _, d := range []*Decoder{nil, NewDecoder()} {
+		if _, err := d.Decode([]byte{TagList, 1, 0, 'l', TagEnd, 0xff, 0xff, 0xff, 0x7f}); err == nil {
+			t.Error("expected an error decoding a list of end tags with a length")
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	data := testDecodeData(b)[0]
+
+	b.Run("nbt2json", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			decodeJSON(b, data)
+		}
+	})
+
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Decode(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		d := NewDecoder()
+		for i := 0; i < b.N; i++ {
+			if _, err := d.Decode(data); err != nil {
+				b.Fatal(err)
+			}
+			d.Release()
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Encode serializes the given tags as little endian Bedrock NBT, byte for byte as the game writes them.
+func Encode(tags []NBTTag) ([]byte, error) {
+	buf := bytes.Buffer{}
+
+	if err := Write(&buf, tags); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Write writes the given tags to w as little endian Bedrock NBT.
+func Write(w io.Writer, tags []NBTTag) error {
+	e := encoder{w: w}
+
+	for _, t := range tags {
+		e.tag(t)
+		if e.err != nil {
+			return e.err
+		}
+	}
+
+	return nil
+}
+
+// encoder writes tags, holding the first error so that payloads can be written without checking each write.
+type encoder struct {
+	w   io.Writer
+	err error
+}
+
+func (e *encoder) write(v interface{}) {
+	if e.err == nil {
+		e.err = binary.Write(e.w, binary.LittleEndian, v)
+	}
+}
+
+func (e *encoder) fail(format string, args ...interface{}) {
+	if e.err == nil {
+		e.err = fmt.Errorf(format, args...)
+	}
+}
+
+// tag writes a named tag: its type, name and payload.
+func (e *encoder) tag(t NBTTag) {
+	// The decoder ignores end tags, which only terminate compounds
+	if t.Type == TagEnd {
+		return
+	}
+
+	e.write(t.Type)
+	e.string(t.Name)
+	e.payload(t.Type, t.Value)
+
+	if e.err != nil {
+		e.err = fmt.Errorf("tag '%s': %w", t.Name, e.err)
+	}
+}
+
+// payload writes the value of a tag of the given type, in the forms described at the top of compound.go.
+func (e *encoder) payload(tagType byte, v interface{}) {
+	switch tagType {
+	case TagByte:
+		e.write(int8(e.integer(v, math.MinInt8, math.MaxInt8)))
+	case TagShort:
+		e.write(int16(e.integer(v, math.MinInt16, math.MaxInt16)))
+	case TagInt:
+		e.write(int32(e.integer(v, math.MinInt32, math.MaxInt32)))
+	case TagLong:
+		e.write(e.long(v))
+	case TagFloat:
+		e.write(float32(e.float(v)))
+	case TagDouble:
+		e.write(e.float(v))
+	case TagString:
+		s, ok := v.(string)
+		if !ok {
+			e.fail("string value %v is not a string", v)
+		}
+		e.string(s)
+	case TagByteArray, TagIntArray, TagLongArray:
+		e.array(tagType, v)
+	case TagList:
+		e.list(v)
+	case TagCompound:
+		values, ok := v.([]interface{})
+		if !ok && v != nil {
+			e.fail("compound value %v is not a slice of tags", v)
+		}
+
+		for _, value := range values {
+			t, ok := tagFromValue(value)
+			if !ok {
+				e.fail("compound element %v is not a tag", value)
+				return
+			}
+			e.tag(t)
+		}
+
+		e.write(TagEnd)
+	default:
+		e.fail("unknown tag type %d", tagType)
+	}
+}
+
+// string writes a string prefixed with its length in bytes.
+func (e *encoder) string(s string) {
+	if len(s) > math.MaxUint16 {
+		e.fail("string of length %d is too long", len(s))
+		return
+	}
+
+	e.write(uint16(len(s)))
+	e.write([]byte(s))
+}
+
+// list writes the element type and length of a list followed by the payload of each element.
+func (e *encoder) list(v interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		e.fail("list value %v is not a map", v)
+		return
+	}
+
+	listType, _ := toFloat(m["tagListType"])
+
+	// Empty lists may have a nil list
+	values, _ := m["list"].([]interface{})
+
+	e.write(byte(listType))
+	e.write(int32(len(values)))
+
+	for i, value := range values {
+		e.payload(byte(listType), value)
+		if e.err != nil {
+			e.err = fmt.Errorf("list element %d: %w", i, e.err)
+			return
+		}
+	}
+}
+
+// array writes the length of a byte, int or long array followed by its elements.
+//
+// Long array lengths are 32 bits, as the game writes them. nbt2json reads them as 64 bits, so it can't decode long
+// arrays encoded here, though Decode can. The game doesn't save long arrays in any known record.
+func (e *encoder) array(tagType byte, v interface{}) {
+	values, ok := v.([]interface{})
+	if !ok && v != nil {
+		e.fail("array value %v is not a slice", v)
+		return
+	}
+
+	e.write(int32(len(values)))
+
+	for _, value := range values {
+		switch tagType {
+		case TagByteArray:
+			e.write(int8(e.integer(value, math.MinInt8, math.MaxInt8)))
+		case TagIntArray:
+			e.write(int32(e.integer(value, math.MinInt32, math.MaxInt32)))
+		case TagLongArray:
+			e.write(e.long(value))
+		}
+	}
+}
+
+// integer returns a numeric value, failing if it is out of the range of its tag type.
+func (e *encoder) integer(v interface{}, min, max float64) int64 {
+	f, ok := toFloat(v)
+	if !ok {
+		e.fail("value %v is not a number", v)
+		return 0
+	}
+
+	if f < min || f > max || f != math.Trunc(f) {
+		e.fail("value %v is not an integer between %v and %v", v, min, max)
+		return 0
+	}
+
+	return int64(f)
+}
+
+// float returns a numeric value, or the value of a string such as NaN or +Inf as given by EncodeJSON. Other values are
+// written as NaN, which is valid in the game's floats and doubles.
+func (e *encoder) float(v interface{}) float64 {
+	if s, ok := v.(string); ok {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
+	f, ok := toFloat(v)
+	if !ok {
+		return math.NaN()
+	}
+
+	return f
+}
+
+// long returns the value of a long given as the pair of 32 bit halves returned by Long, a decimal string or an int64.
+func (e *encoder) long(v interface{}) int64 {
+	switch l := v.(type) {
+	case int64:
+		return l
+	case string:
+		i, err := strconv.ParseInt(l, 10, 64)
+		if err != nil {
+			e.fail("long value '%s' is not an integer", l)
+		}
+		return i
+	case map[string]interface{}:
+		least, ok1 := toFloat(l["valueLeast"])
+		most, ok2 := toFloat(l["valueMost"])
+		if !ok1 || !ok2 {
+			e.fail("long value %v does not have numeric valueLeast and valueMost", l)
+		}
+		return int64(uint32(least)) | int64(uint32(most))<<32
+	}
+
+	e.fail("long value %v is not a pair of 32 bit values", v)
+
+	return 0
+}
@@ -0,0 +1,106 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/nbt2json"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	// The palettes of the two block storages in a sub chunk saved by the game
+	r := bytes.NewReader(mock.SubChunkValue)
+	r.Seek(2, 0)
+
+	for storage := 0; storage < 2; storage++ {
+		bitsPerBlock, _ := r.ReadByte()
+		blocksPerWord := 32 / int(bitsPerBlock>>1)
+		r.Seek(int64((4096+blocksPerWord-1)/blocksPerWord*4), 1)
+
+		var size int32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			t.Fatal(err)
+		}
+
+		start := len(mock.SubChunkValue) - r.Len()
+		tags, err := Read(r, int(size))
+		if err != nil {
+			t.Fatalf("storage %d: unexpected error decoding palette: %s", storage, err)
+		}
+		original := mock.SubChunkValue[start : len(mock.SubChunkValue)-r.Len()]
+
+		b, err := Encode(tags)
+		if err != nil {
+			t.Fatalf("storage %d: unexpected error encoding palette: %s", storage, err)
+		}
+
+		if !bytes.Equal(b, original) {
+			t.Errorf("storage %d: encoded palette is not identical to the original: got %d bytes, expected %d",
+				storage, len(b), len(original))
+		}
+	}
+}
+
+func TestEncodeTagTypes(t *testing.T) {
+	root := NBTTag{Type: TagCompound, Value: []interface{}{}}
+	for _, c := range []NBTTag{
+		{Type: TagByte, Name: "byte", Value: -3.0},
+		{Type: TagShort, Name: "short", Value: 1000},
+		{Type: TagInt, Name: "int", Value: int32(-70000)},
+		{Type: TagLong, Name: "long", Value: Long(-1 << 40)},
+		{Type: TagFloat, Name: "float", Value: 0.25},
+		{Type: TagDouble, Name: "double", Value: -1.5e300},
+		{Type: TagByteArray, Name: "bytes", Value: []interface{}{1.0, -1.0}},
+		{Type: TagString, Name: "string", Value: "minecraft:stone"},
+		{Type: TagList, Name: "empty", Value: map[string]interface{}{"tagListType": TagCompound}},
+		{Type: TagIntArray, Name: "ints", Value: []interface{}{1.0, 2.0, 3.0}},
+		{Type: TagCompound, Name: "compound", Value: []interface{}{}},
+	} {
+		_ = root.SetChild(c)
+	}
+
+	list := NBTTag{Type: TagList, Name: "list", Value: map[string]interface{}{"tagListType": TagFloat}}
+	_ = list.SetList([]NBTTag{{Type: TagFloat, Value: 1.0}, {Type: TagFloat, Value: 2.5}})
+	_ = root.SetChild(list)
+
+	b, err := Encode([]NBTTag{root})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// nbt2json's encoder needs values in the form produced by decoding JSON
+	j, _ := json.Marshal(struct {
+		NBT []NBTTag `json:"nbt"`
+	}{[]NBTTag{root}})
+	want, err := nbt2json.Json2Nbt(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(b, want) {
+		t.Errorf("encoding differs from nbt2json:\ngot  %x\nwant %x", b, want)
+	}
+
+	tags, err := Decode(b)
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+
+	if again, _ := Encode(tags); !bytes.Equal(again, b) {
+		t.Error("decoded tags did not encode to the same bytes")
+	}
+
+	for _, bad := range []NBTTag{
+		{Type: TagByte, Name: "b", Value: 300.0},
+		{Type: TagString, Name: "s", Value: 1.0},
+		{Type: TagList, Name: "l", Value: []interface{}{}},
+		{Type: 99, Name: "x"},
+	} {
+		if _, err := Encode([]NBTTag{bad}); err == nil {
+			t.Errorf("expected an error encoding %+v", bad)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package nbt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var typeNames = map[byte]string{
+	TagEnd:       "end",
+	TagByte:      "byte",
+	TagShort:     "short",
+	TagInt:       "int",
+	TagLong:      "long",
+	TagFloat:     "float",
+	TagDouble:    "double",
+	TagByteArray: "byte array",
+	TagString:    "string",
+	TagList:      "list",
+	TagCompound:  "compound",
+	TagIntArray:  "int array",
+	TagLongArray: "long array",
+}
+
+// TypeName returns the name of an NBT tag type, for example "compound".
+func TypeName(t byte) string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("type %d", t)
+}
+
+// ANSI escape codes used by Format.
+const (
+	colorReset = "\x1b[0m"
+	colorType  = "\x1b[2m"  // Dim
+	colorName  = "\x1b[36m" // Cyan
+	colorStr   = "\x1b[32m" // Green
+	colorNum   = "\x1b[35m" // Magenta
+)
+
+// Format returns t and every tag nested inside it as an indented tree with one tag per line, showing the name, type and
+// value of each tag. If color is true the output is colored with ANSI escape codes for display in a terminal.
+func Format(t NBTTag, color bool) string {
+	b := &strings.Builder{}
+	format(b, t, 0, color)
+
+	return b.String()
+}
+
+func format(b *strings.Builder, t NBTTag, depth int, color bool) {
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + colorReset
+	}
+
+	b.WriteString(strings.Repeat("  ", depth))
+
+	if t.Name != "" {
+		b.WriteString(paint(colorName, t.Name) + " ")
+	}
+
+	var children []NBTTag
+
+	switch t.Type {
+	case TagCompound:
+		children = t.Tags()
+		b.WriteString(paint(colorType, fmt.Sprintf("(compound, %d entries)", len(children))))
+	case TagList:
+		children = t.List()
+		m, _ := t.Value.(map[string]interface{})
+		elementType, _ := toFloat(m["tagListType"])
+		b.WriteString(paint(colorType, fmt.Sprintf("(list of %s, %d entries)", TypeName(byte(elementType)), len(children))))
+	default:
+		code := colorNum
+		if t.Type == TagString {
+			code = colorStr
+		}
+		b.WriteString(paint(colorType, "("+TypeName(t.Type)+")") + " " + paint(code, t.FormatValue()))
+	}
+
+	b.WriteString("\n")
+
+	for i, c := range children {
+		if t.Type == TagList {
+			c.Name = strconv.Itoa(i)
+		}
+
+		format(b, c, depth+1, color)
+	}
+}
+
+// FormatValue returns the value of a tag which is not a compound or list as text. Strings are quoted, longs are shown
+// as integers and arrays are shown as a bracketed list of numbers.
+func (n *NBTTag) FormatValue() string {
+	switch n.Type {
+	case TagString:
+		s, _ := n.StringValue()
+		return strconv.Quote(s)
+	case TagByte, TagShort, TagInt, TagLong:
+		i, _ := n.Int()
+		return strconv.FormatInt(i, 10)
+	case TagFloat, TagDouble:
+		f, _ := n.Float()
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	case TagByteArray, TagIntArray, TagLongArray:
+		values, _ := n.Value.([]interface{})
+		elementType := map[byte]byte{TagByteArray: TagByte, TagIntArray: TagInt, TagLongArray: TagLong}[n.Type]
+
+		elements := make([]string, len(values))
+		for i, v := range values {
+			e := NBTTag{Type: elementType, Value: v}
+			elements[i] = e.FormatValue()
+		}
+
+		return "[" + strings.Join(elements, " ") + "]"
+	}
+
+	return fmt.Sprintf("%v", n.Value)
+}
@@ -0,0 +1,35 @@
+package nbt
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	root := NBTTag{Type: TagCompound, Value: []interface{}{}}
+	_ = root.SetChild(NBTTag{Type: TagString, Name: "id", Value: "Chest"})
+	_ = root.SetChild(NBTTag{Type: TagLong, Name: "UniqueID", Value: Long(-2)})
+	_ = root.SetChild(NBTTag{Type: TagIntArray, Name: "Pos", Value: []interface{}{1.0, -2.0, 3.0}})
+
+	items := NBTTag{Type: TagList, Name: "Items", Value: map[string]interface{}{"tagListType": TagCompound}}
+	item := NBTTag{Type: TagCompound, Value: []interface{}{}}
+	_ = item.SetChild(NBTTag{Type: TagByte, Name: "Count", Value: 3.0})
+	_ = item.SetChild(NBTTag{Type: TagFloat, Name: "Damage", Value: 0.5})
+	_ = items.SetList([]NBTTag{item})
+	_ = root.SetChild(items)
+
+	want := `(compound, 4 entries)
+  id (string) "Chest"
+  UniqueID (long) -2
+  Pos (int array) [1 -2 3]
+  Items (list of compound, 1 entries)
+    0 (compound, 2 entries)
+      Count (byte) 3
+      Damage (float) 0.5
+`
+
+	if got := Format(root, false); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	if got := Format(NBTTag{Type: TagString, Name: "s", Value: "x"}, true); got != "\x1b[36ms\x1b[0m \x1b[2m(string)\x1b[0m \x1b[32m\"x\"\x1b[0m\n" {
+		t.Errorf("unexpected colored output %q", got)
+	}
+}
@@ -0,0 +1,97 @@
+package nbt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// jsonDocument is the JSON form of a list of tags, as nbt2json writes it.
+type jsonDocument struct {
+	NBT []NBTTag `json:"nbt"`
+}
+
+// EncodeJSON returns the given tags as indented JSON in the form nbt2json writes: an object with an nbt array of tags,
+// each with its tagType, name and value. Values are in the forms described at the top of compound.go, so the type of
+// every tag is kept and DecodeJSON followed by Encode gives the original NBT. Floats and doubles which aren't finite
+// are written as the strings NaN, +Inf and -Inf.
+func EncodeJSON(tags []NBTTag) ([]byte, error) {
+	doc := jsonDocument{NBT: make([]NBTTag, len(tags))}
+	for i, t := range tags {
+		t.Value = jsonValue(t.Type, t.Value)
+		doc.NBT[i] = t
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling tags: %w", err)
+	}
+
+	return data, nil
+}
+
+// DecodeJSON reads tags from JSON written by EncodeJSON or nbt2json. The values aren't checked against their tag types
+// until the tags are encoded. Longs may also be given as decimal strings.
+func DecodeJSON(data []byte) ([]NBTTag, error) {
+	var doc jsonDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unmarshaling tags: %w", err)
+	}
+
+	if doc.NBT == nil {
+		return nil, fmt.Errorf("no nbt array of tags")
+	}
+
+	return doc.NBT, nil
+}
+
+// jsonValue returns a copy of a value of the given tag type in which floats and doubles which can't be held in JSON
+// are strings. The value is returned unchanged if it has no such numbers.
+func jsonValue(tagType byte, v interface{}) interface{} {
+	switch tagType {
+	case TagFloat, TagDouble:
+		if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	case TagList:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+
+		listType, _ := toFloat(m["tagListType"])
+		values, _ := m["list"].([]interface{})
+		if values == nil {
+			return v
+		}
+
+		list := make([]interface{}, len(values))
+		for i, e := range values {
+			list[i] = jsonValue(byte(listType), e)
+		}
+
+		return map[string]interface{}{"tagListType": m["tagListType"], "list": list}
+	case TagCompound:
+		values, ok := v.([]interface{})
+		if !ok {
+			return v
+		}
+
+		children := make([]interface{}, len(values))
+		for i, c := range values {
+			t, ok := tagFromValue(c)
+			if !ok {
+				children[i] = c
+				continue
+			}
+
+			t.Value = jsonValue(t.Type, t.Value)
+			children[i] = t
+		}
+
+		return children
+	}
+
+	return v
+}
@@ -0,0 +1,58 @@
+package nbt
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	special := NBTTag{Type: TagCompound, Name: "special", Value: []interface{}{}}
+	for _, c := range []NBTTag{
+		{Type: TagFloat, Name: "nan", Value: math.NaN()},
+		{Type: TagDouble, Name: "inf", Value: math.Inf(1)},
+		{Type: TagDouble, Name: "-inf", Value: math.Inf(-1)},
+		{Type: TagLongArray, Name: "longs", Value: []interface{}{Long(math.MaxInt64), Long(-2)}},
+	} {
+		_ = special.SetChild(c)
+	}
+
+	floats := NBTTag{Type: TagList, Name: "floats", Value: map[string]interface{}{"tagListType": TagFloat}}
+	_ = floats.SetList([]NBTTag{{Type: TagFloat, Value: math.Inf(-1)}, {Type: TagFloat, Value: 0.5}})
+	_ = special.SetChild(floats)
+
+	b, err := Encode([]NBTTag{special})
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+
+	for i, data := range append(testDecodeData(t), b) {
+		tags, err := Decode(data)
+		if err != nil {
+			t.Fatalf("%d: unexpected error decoding: %s", i, err)
+		}
+
+		j, err := EncodeJSON(tags)
+		if err != nil {
+			t.Fatalf("%d: unexpected error encoding JSON: %s", i, err)
+		}
+
+		decoded, err := DecodeJSON(j)
+		if err != nil {
+			t.Fatalf("%d: unexpected error decoding JSON: %s", i, err)
+		}
+
+		got, err := Encode(decoded)
+		if err != nil {
+			t.Fatalf("%d: unexpected error encoding decoded JSON: %s", i, err)
+		}
+
+		if !bytes.Equal(got, data) {
+			t.Errorf("%d: expected the NBT to be unchanged by a JSON round trip:\n%s", i, j)
+		}
+	}
+
+	if _, err := DecodeJSON([]byte(`{"tags": []}`)); err == nil {
+		t.Error("expected an error decoding JSON without an nbt array")
+	}
+}
@@ -1,25 +1,23 @@
 package nbt
 
-import "fmt"
-
 type NBTTag struct {
 	Type  byte        `json:"tagType"`
 	Name  string      `json:"name"`
 	Value interface{} `json:"value"`
 }
 
+// BlockID returns the value of the name child of a compound tag, such as a block palette entry. It returns an empty
+// string if n is not a compound or has no string name child.
 func (n *NBTTag) BlockID() string {
-	//	fmt.Printf("%+v\n", n)
 	if vs, ok := n.Value.([]interface{}); ok {
 		for _, t := range vs {
 			if tMap, ok := t.(map[string]interface{}); ok {
 				if tMap["name"] == "name" {
-					return tMap["value"].(string)
+					id, _ := tMap["value"].(string)
+					return id
 				}
 			}
 		}
-	} else {
-		fmt.Println("failed to convert to NBTTag")
 	}
 
 	return ""
@@ -0,0 +1,142 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Size returns the length in bytes of the next count tags read from r, without decoding them. The position of r is
+// not changed.
+func Size(r *bytes.Reader, count int) (int, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("getting position: %w", err)
+	}
+
+	defer func() { _, _ = r.Seek(start, io.SeekStart) }()
+
+	for i := 0; i < count; i++ {
+		if err := skipTag(r); err != nil {
+			return 0, fmt.Errorf("tag %d: %w", i, err)
+		}
+	}
+
+	end, _ := r.Seek(0, io.SeekCurrent)
+
+	return int(end - start), nil
+}
+
+// skipTag reads past a named tag: its type, name and payload.
+func skipTag(r *bytes.Reader) error {
+	tagType, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("reading tag type: %w", err)
+	}
+
+	if tagType == TagEnd {
+		return nil
+	}
+
+	if err := skipString(r); err != nil {
+		return fmt.Errorf("reading name: %w", err)
+	}
+
+	return skipPayload(r, tagType)
+}
+
+// payloadSizes are the lengths of the payloads of fixed size tag types.
+var payloadSizes = map[byte]int64{TagByte: 1, TagShort: 2, TagInt: 4, TagLong: 8, TagFloat: 4, TagDouble: 8}
+
+// arrayElementSizes are the lengths of the elements of array tag types.
+var arrayElementSizes = map[byte]int64{TagByteArray: 1, TagIntArray: 4, TagLongArray: 8}
+
+// skipPayload reads past the payload of a tag of the given type.
+func skipPayload(r *bytes.Reader, tagType byte) error {
+	if n, ok := payloadSizes[tagType]; ok {
+		return skip(r, n)
+	}
+
+	if n, ok := arrayElementSizes[tagType]; ok {
+		length, err := readLength(r)
+		if err != nil {
+			return err
+		}
+		return skip(r, length*n)
+	}
+
+	switch tagType {
+	case TagString:
+		return skipString(r)
+	case TagList:
+		elementType, err := r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("reading list type: %w", err)
+		}
+
+		length, err := readLength(r)
+		if err != nil {
+			return err
+		}
+
+		for i := int64(0); i < length; i++ {
+			if err := skipPayload(r, elementType); err != nil {
+				return fmt.Errorf("list element %d: %w", i, err)
+			}
+		}
+
+		return nil
+	case TagCompound:
+		for {
+			t, err := r.ReadByte()
+			if err != nil {
+				return fmt.Errorf("reading tag type: %w", err)
+			}
+			if t == TagEnd {
+				return nil
+			}
+
+			_ = r.UnreadByte()
+			if err := skipTag(r); err != nil {
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("invalid tag type %d", tagType)
+}
+
+// skipString reads past a string prefixed with its 16 bit length.
+func skipString(r *bytes.Reader) error {
+	var length uint16
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return fmt.Errorf("reading string length: %w", err)
+	}
+
+	return skip(r, int64(length))
+}
+
+// readLength reads the 32 bit length of a list or array.
+func readLength(r *bytes.Reader) (int64, error) {
+	var length int32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return 0, fmt.Errorf("reading length: %w", err)
+	}
+	if length < 0 {
+		return 0, fmt.Errorf("negative length %d", length)
+	}
+
+	return int64(length), nil
+}
+
+// skip reads past n bytes, failing if fewer remain.
+func skip(r *bytes.Reader, n int64) error {
+	if int64(r.Len()) < n {
+		return fmt.Errorf("%d bytes remain: expected at least %d: %w", r.Len(), n, io.ErrUnexpectedEOF)
+	}
+
+	_, err := r.Seek(n, io.SeekCurrent)
+
+	return err
+}
@@ -0,0 +1,57 @@
+package nbt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestSize(t *testing.T) {
+	root := NBTTag{Type: TagCompound, Name: "root", Value: []interface{}{}}
+	for _, c := range []NBTTag{
+		{Type: TagByte, Name: "byte", Value: -3.0},
+		{Type: TagShort, Name: "short", Value: 1000},
+		{Type: TagLong, Name: "long", Value: Long(-1 << 40)},
+		{Type: TagDouble, Name: "double", Value: -1.5e300},
+		{Type: TagByteArray, Name: "bytes", Value: []interface{}{1.0, -1.0}},
+		{Type: TagString, Name: "string", Value: "minecraft:stone"},
+		{Type: TagIntArray, Name: "ints", Value: []interface{}{1.0, 2.0, 3.0}},
+		{Type: TagCompound, Name: "compound", Value: []interface{}{}},
+	} {
+		_ = root.SetChild(c)
+	}
+
+	list := NBTTag{Type: TagList, Name: "list", Value: map[string]interface{}{"tagListType": TagCompound}}
+	_ = list.SetList([]NBTTag{
+		{Type: TagCompound, Value: []interface{}{map[string]interface{}{"tagType": TagInt, "name": "a", "value": 1.0}}},
+		{Type: TagCompound, Value: []interface{}{}},
+	})
+	_ = root.SetChild(list)
+
+	tags := []NBTTag{root, {Type: TagString, Name: "second", Value: "x"}}
+	b, err := Encode(tags)
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+
+	// Bytes following the tags are not counted
+	r := bytes.NewReader(append([]byte{9, 9, 9}, append(b, 1, 2, 3)...))
+	_, _ = r.Seek(3, io.SeekStart)
+
+	n, err := Size(r, 2)
+	if err != nil {
+		t.Fatalf("unexpected error getting size: %s", err)
+	}
+
+	if n != len(b) {
+		t.Errorf("expected size %d: got %d", len(b), n)
+	}
+
+	if r.Len() != len(b)+3 {
+		t.Errorf("expected the position to be unchanged: %d bytes remain", r.Len())
+	}
+
+	if _, err := Size(bytes.NewReader(b[:len(b)-4]), 2); err == nil {
+		t.Error("expected an error getting the size of truncated tags")
+	}
+}
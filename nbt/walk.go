@@ -0,0 +1,46 @@
+package nbt
+
+import "strconv"
+
+// Walk calls visit for t and every tag nested inside it, depth first. The path holds the names of the compound children
+// and list indices leading from t to each tag.
+func Walk(t NBTTag, visit func(path []string, t NBTTag)) {
+	walk(t, nil, visit)
+}
+
+func walk(t NBTTag, path []string, visit func(path []string, t NBTTag)) {
+	visit(path, t)
+
+	switch t.Type {
+	case TagCompound:
+		for _, c := range t.Tags() {
+			walk(c, append(path[:len(path):len(path)], c.Name), visit)
+		}
+	case TagList:
+		for i, e := range t.List() {
+			walk(e, append(path[:len(path):len(path)], strconv.Itoa(i)), visit)
+		}
+	}
+}
+
+// Rewrite calls f for t and every tag nested inside it, replacing each tag with the one returned by f. Nested tags are
+// rewritten before their parent is passed to f. The rewritten tag is returned and t may be modified.
+func Rewrite(t NBTTag, f func(t NBTTag) NBTTag) NBTTag {
+	switch t.Type {
+	case TagCompound:
+		values, _ := t.Value.([]interface{})
+		for i, v := range values {
+			if c, ok := tagFromValue(v); ok {
+				values[i] = Rewrite(c, f).toMap()
+			}
+		}
+	case TagList:
+		elements := t.List()
+		for i, e := range elements {
+			elements[i] = Rewrite(e, f)
+		}
+		_ = t.SetList(elements)
+	}
+
+	return f(t)
+}
@@ -0,0 +1,281 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// KeySize is the length in bytes of an encryption key. Keys are used with AES-256-GCM.
+const KeySize = 32
+
+// Key is an encryption key.
+type Key [KeySize]byte
+
+// encryptedMagic is the start of an encrypted stream, followed by a random nonce prefix.
+var encryptedMagic = []byte("MINEENC\x01")
+
+// Encrypted streams are a series of segments, each holding up to segmentSize bytes of plain text sealed with
+// AES-256-GCM. Each segment has a header of a final flag byte and the big endian length of the sealed segment, which is
+// authenticated with it, so segments can't be reordered and a truncated stream is detected.
+const (
+	segmentSize       = 64 * 1024
+	segmentHeaderSize = 5
+	noncePrefixSize   = 8
+)
+
+var (
+	// ErrEncrypted is returned when reading an encrypted stream without a key.
+	ErrEncrypted = errors.New("stream is encrypted: a key is required")
+	// ErrTruncated is returned when an encrypted stream ends before its final segment.
+	ErrTruncated = errors.New("encrypted stream is truncated")
+)
+
+// GenerateKey returns a new random encryption key.
+func GenerateKey() (*Key, error) {
+	key := &Key{}
+	if _, err := rand.Read(key[:]); err != nil {
+		return nil, fmt.Errorf("generating key: %w", err)
+	}
+
+	return key, nil
+}
+
+// WriteKey writes a key to a new file as hex, readable only by its owner. An existing file is not replaced.
+func WriteKey(path string, key *Key) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("creating key file: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(f, hex.EncodeToString(key[:])); err != nil {
+		f.Close()
+		return fmt.Errorf("writing key file: %w", err)
+	}
+
+	return f.Close()
+}
+
+// ReadKey reads a key written by WriteKey.
+func ReadKey(path string) (*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+
+	b, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("key file %s is not hex encoded: %w", path, err)
+	}
+
+	if len(b) != KeySize {
+		return nil, fmt.Errorf("key in %s is %d bytes long: expected %d", path, len(b), KeySize)
+	}
+
+	key := &Key{}
+	copy(key[:], b)
+
+	return key, nil
+}
+
+// newAEAD returns an AES-256-GCM cipher for the key.
+func newAEAD(key *Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// segmentNonce returns the nonce of the segment with the given index.
+func segmentNonce(prefix []byte, index uint32) []byte {
+	nonce := make([]byte, noncePrefixSize+4)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], index)
+
+	return nonce
+}
+
+// encrypter seals everything written to it into segments.
+type encrypter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	prefix []byte
+	index  uint32
+	buf    []byte
+	closed bool
+}
+
+// NewEncrypter returns a writer which encrypts to w with the key. The returned writer must be closed to write the final
+// segment, which does not close w.
+func NewEncrypter(w io.Writer, key *Key) (io.WriteCloser, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	if _, err := w.Write(append(append([]byte{}, encryptedMagic...), prefix...)); err != nil {
+		return nil, fmt.Errorf("writing stream header: %w", err)
+	}
+
+	return &encrypter{w: w, aead: aead, prefix: prefix, buf: make([]byte, 0, segmentSize)}, nil
+}
+
+func (e *encrypter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed encrypter")
+	}
+
+	n := 0
+	for len(p) > 0 {
+		// A full segment is only sealed when more data arrives, so the last segment can be marked final on Close
+		if len(e.buf) == segmentSize {
+			if err := e.seal(false); err != nil {
+				return n, err
+			}
+		}
+
+		c := copy(e.buf[len(e.buf):segmentSize], p)
+		e.buf = e.buf[:len(e.buf)+c]
+		p = p[c:]
+		n += c
+	}
+
+	return n, nil
+}
+
+// seal writes the buffered plain text as a segment.
+func (e *encrypter) seal(final bool) error {
+	header := make([]byte, segmentHeaderSize)
+	if final {
+		header[0] = 1
+	}
+	binary.BigEndian.PutUint32(header[1:], uint32(len(e.buf)+e.aead.Overhead()))
+
+	sealed := e.aead.Seal(header, segmentNonce(e.prefix, e.index), e.buf, header)
+	if _, err := e.w.Write(sealed); err != nil {
+		return fmt.Errorf("writing segment %d: %w", e.index, err)
+	}
+
+	e.index++
+	e.buf = e.buf[:0]
+
+	return nil
+}
+
+func (e *encrypter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	return e.seal(true)
+}
+
+// decrypter reads the plain text of an encrypted stream.
+type decrypter struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	prefix []byte
+	index  uint32
+	plain  []byte
+	final  bool
+}
+
+// NewDecrypter returns a reader of the plain text of an encrypted stream written by NewEncrypter. Reading returns an
+// error if the key is wrong, the stream was changed or it ends early.
+func NewDecrypter(r io.Reader, key *Key) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, len(encryptedMagic)+noncePrefixSize)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, fmt.Errorf("reading stream header: %w", err)
+	}
+
+	if !bytes.Equal(head[:len(encryptedMagic)], encryptedMagic) {
+		return nil, errors.New("stream is not encrypted")
+	}
+
+	return &decrypter{r: r, aead: aead, prefix: head[len(encryptedMagic):]}, nil
+}
+
+func (d *decrypter) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.final {
+			return 0, io.EOF
+		}
+
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+
+	return n, nil
+}
+
+// open reads and decrypts the next segment.
+func (d *decrypter) open() error {
+	header := make([]byte, segmentHeaderSize)
+	if _, err := io.ReadFull(d.r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrTruncated
+		}
+		return fmt.Errorf("reading segment %d: %w", d.index, err)
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if size < uint32(d.aead.Overhead()) || size > segmentSize+uint32(d.aead.Overhead()) {
+		return fmt.Errorf("segment %d has invalid length %d", d.index, size)
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrTruncated
+		}
+		return fmt.Errorf("reading segment %d: %w", d.index, err)
+	}
+
+	plain, err := d.aead.Open(sealed[:0], segmentNonce(d.prefix, d.index), sealed, header)
+	if err != nil {
+		return fmt.Errorf("decrypting segment %d: wrong key or changed data", d.index)
+	}
+
+	d.index++
+	d.plain = plain
+	d.final = header[0] == 1
+
+	return nil
+}
+
+// isEncrypted returns true if the buffered stream starts with the encrypted stream header.
+func isEncrypted(br *bufio.Reader) (bool, error) {
+	head, err := br.Peek(len(encryptedMagic))
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("reading stream header: %w", err)
+	}
+
+	return bytes.Equal(head, encryptedMagic), nil
+}
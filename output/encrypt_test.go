@@ -0,0 +1,124 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// testEncrypted returns data compressed and encrypted with the key.
+func testEncrypted(t *testing.T, data []byte, c Compression) []byte {
+	var buf bytes.Buffer
+
+	w, err := c.NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("unexpected error writing: %s", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing: %s", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestEncryption(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// More than two segments of plain text
+	data := bytes.Repeat([]byte("secret server coordinates "), 3*segmentSize/20)
+
+	for _, c := range []Compression{{Key: key}, {Format: Zstd, Key: key}} {
+		encrypted := testEncrypted(t, data, c)
+
+		if bytes.Contains(encrypted, []byte("secret")) {
+			t.Errorf("%s: expected plain text not to be written", c)
+		}
+
+		r, err := NewReader(bytes.NewReader(encrypted), key)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", c, err)
+		}
+
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: unexpected error reading: %s", c, err)
+		}
+
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s: read data differs from written data", c)
+		}
+	}
+
+	encrypted := testEncrypted(t, data, Compression{Key: key})
+
+	if _, err := NewReader(bytes.NewReader(encrypted), nil); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("expected ErrEncrypted reading without a key: got %v", err)
+	}
+
+	read := func(data []byte, key *Key) error {
+		r, err := NewReader(bytes.NewReader(data), key)
+		if err != nil {
+			return err
+		}
+		_, err = ioutil.ReadAll(r)
+		return err
+	}
+
+	other, _ := GenerateKey()
+	if err := read(encrypted, other); err == nil {
+		t.Errorf("expected error reading with the wrong key")
+	}
+
+	// Cut at a segment boundary, so every segment read is valid but the final segment is missing
+	cut := len(encryptedMagic) + noncePrefixSize + segmentHeaderSize + segmentSize + 16
+	if err := read(encrypted[:cut], key); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated: got %v", err)
+	}
+
+	changed := append([]byte{}, encrypted...)
+	changed[len(changed)/2] ^= 1
+	if err := read(changed, key); err == nil {
+		t.Errorf("expected error reading changed data")
+	}
+
+	// A key may be given for streams which are not encrypted
+	if err := read(testEncrypted(t, data, Compression{Format: Gzip}), key); err != nil {
+		t.Errorf("unexpected error reading unencrypted stream with a key: %s", err)
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.key")
+
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteKey(path, key); err != nil {
+		t.Fatalf("unexpected error writing key: %s", err)
+	}
+
+	got, err := ReadKey(path)
+	if err != nil {
+		t.Fatalf("unexpected error reading key: %s", err)
+	}
+
+	if *got != *key {
+		t.Errorf("read key differs from written key")
+	}
+
+	if err := WriteKey(path, key); err == nil {
+		t.Errorf("expected error replacing an existing key file")
+	}
+}
@@ -0,0 +1,298 @@
+// Package output writes exported files, optionally compressed and encrypted as they are written so that large exports
+// never need to be held in memory or written to disk uncompressed or unencrypted.
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format is a compression format.
+type Format int
+
+// Compression formats.
+const (
+	None Format = iota
+	Gzip
+	Zstd
+)
+
+var formatNames = []string{"none", "gzip", "zstd"}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+
+	return formatNames[f]
+}
+
+// Ext returns the file extension conventionally added by the format, or an empty string for None.
+func (f Format) Ext() string {
+	switch f {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	}
+
+	return ""
+}
+
+// Compression is a compression format and level. Level 0 is the format's default. Gzip levels are 1 (fastest) to 9
+// (smallest) and zstd levels are 1 to 22, as in the gzip and zstd command line tools.
+type Compression struct {
+	Format Format
+	Level  int
+	// Key, if set, encrypts the compressed stream with AES-256-GCM. See NewEncrypter.
+	Key *Key
+}
+
+// ParseCompression parses a format name optionally followed by a colon and level, e.g. gzip, gzip:9 or zstd:19.
+func ParseCompression(s string) (Compression, error) {
+	name, level := s, ""
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		name, level = s[:i], s[i+1:]
+	}
+
+	c := Compression{Format: -1}
+	for i, n := range formatNames {
+		if strings.EqualFold(n, name) {
+			c.Format = Format(i)
+		}
+	}
+
+	if c.Format < 0 {
+		return Compression{}, fmt.Errorf("invalid compression format '%s': expected one of %s",
+			name, strings.Join(formatNames, ", "))
+	}
+
+	if level != "" {
+		l, err := strconv.Atoi(level)
+		if err != nil {
+			return Compression{}, fmt.Errorf("invalid compression level '%s': %w", level, err)
+		}
+		c.Level = l
+	}
+
+	if err := c.validate(); err != nil {
+		return Compression{}, err
+	}
+
+	return c, nil
+}
+
+func (c Compression) String() string {
+	if c.Level == 0 {
+		return c.Format.String()
+	}
+
+	return fmt.Sprintf("%s:%d", c.Format, c.Level)
+}
+
+func (c Compression) validate() error {
+	max := map[Format]int{None: 0, Gzip: gzip.BestCompression, Zstd: 22}[c.Format]
+
+	if c.Level < 0 || c.Level > max {
+		return fmt.Errorf("invalid %s compression level %d: expected 0 to %d", c.Format, c.Level, max)
+	}
+
+	return nil
+}
+
+// NewWriter returns a writer which compresses, and encrypts if there is a key, to w. The returned writer must be closed
+// to flush the compressed stream, which does not close w.
+func (c Compression) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	if c.Key == nil {
+		return c.compressor(w)
+	}
+
+	// Compress before encrypting, as encrypted data can't be compressed
+	enc, err := NewEncrypter(w, c.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	comp, err := c.compressor(enc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encrypted{WriteCloser: comp, enc: enc}, nil
+}
+
+// encrypted closes a compressing writer and then the encrypter it writes to.
+type encrypted struct {
+	io.WriteCloser
+	enc io.Closer
+}
+
+func (e *encrypted) Close() error {
+	if err := e.WriteCloser.Close(); err != nil {
+		return err
+	}
+
+	return e.enc.Close()
+}
+
+// compressor returns a writer which compresses to w.
+func (c Compression) compressor(w io.Writer) (io.WriteCloser, error) {
+	switch c.Format {
+	case Gzip:
+		level := c.Level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case Zstd:
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if c.Level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.Level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	}
+
+	return nopCloser{w}, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// file closes a compressing writer and then the file it writes to.
+type file struct {
+	io.WriteCloser
+	f   *os.File
+	buf *bufio.Writer
+}
+
+func (f *file) Close() error {
+	if err := f.WriteCloser.Close(); err != nil {
+		f.f.Close()
+		return fmt.Errorf("closing compressed stream: %w", err)
+	}
+
+	if err := f.buf.Flush(); err != nil {
+		f.f.Close()
+		return fmt.Errorf("writing %s: %w", f.f.Name(), err)
+	}
+
+	return f.f.Close()
+}
+
+// Create creates a file at path which compresses everything written to it. Closing the returned writer flushes the
+// compressed stream and closes the file. The format's extension is not added to path.
+func Create(path string, c Compression) (io.WriteCloser, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s: %w", path, err)
+	}
+
+	buf := bufio.NewWriter(f)
+
+	w, err := c.NewWriter(buf)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("starting %s stream: %w", c.Format, err)
+	}
+
+	return &file{WriteCloser: w, f: f, buf: buf}, nil
+}
+
+// Magic numbers at the start of compressed streams.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// NewReader returns a reader which decrypts with the key and decompresses r, detecting the format from the start of the
+// stream. Uncompressed streams are read as they are. If the stream is encrypted and key is nil, ErrEncrypted is
+// returned.
+func NewReader(r io.Reader, key *Key) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+
+	encrypted, err := isEncrypted(br)
+	if err != nil {
+		return nil, err
+	}
+
+	if encrypted {
+		if key == nil {
+			return nil, ErrEncrypted
+		}
+
+		d, err := NewDecrypter(br, key)
+		if err != nil {
+			return nil, err
+		}
+		br = bufio.NewReader(d)
+	}
+
+	head, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("reading stream header: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(head, zstdMagic):
+		d, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+
+	return io.NopCloser(br), nil
+}
+
+// readFile closes a decompressing reader and then the file it reads from.
+type readFile struct {
+	io.ReadCloser
+	f *os.File
+}
+
+func (f *readFile) Close() error {
+	err := f.ReadCloser.Close()
+	if cerr := f.f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// Open opens a file written by Create in any format, decrypting it with the key if it is encrypted and decompressing it
+// as it is read.
+func Open(path string, key *Key) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+
+	r, err := NewReader(f, key)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return &readFile{ReadCloser: r, f: f}, nil
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCompression(t *testing.T) {
+	valid := map[string]Compression{
+		"none":    {Format: None},
+		"gzip":    {Format: Gzip},
+		"GZIP:9":  {Format: Gzip, Level: 9},
+		"zstd:19": {Format: Zstd, Level: 19},
+	}
+
+	for s, want := range valid {
+		got, err := ParseCompression(s)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", s, err)
+			continue
+		}
+
+		if got != want {
+			t.Errorf("%s: expected %+v: got %+v", s, want, got)
+		}
+	}
+
+	for _, s := range []string{"lz4", "gzip:10", "zstd:x", "none:1", "gzip:-1"} {
+		if _, err := ParseCompression(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+
+	if s := (Compression{Format: Zstd, Level: 3}).String(); s != "zstd:3" {
+		t.Errorf("expected zstd:3: got %s", s)
+	}
+}
+
+func TestCreateOpen(t *testing.T) {
+	data := bytes.Repeat([]byte("minecraft:stone,minecraft:dirt\n"), 10000)
+
+	for _, c := range []Compression{
+		{Format: None}, {Format: Gzip}, {Format: Gzip, Level: 1}, {Format: Zstd}, {Format: Zstd, Level: 19},
+	} {
+		path := filepath.Join(t.TempDir(), "export"+c.Format.Ext())
+
+		w, err := Create(path, c)
+		if err != nil {
+			t.Fatalf("%s: unexpected error creating file: %s", c, err)
+		}
+
+		// Written in parts to check the stream is not reset between writes
+		for i := 0; i < len(data); i += 4096 {
+			end := i + 4096
+			if end > len(data) {
+				end = len(data)
+			}
+			if _, err := w.Write(data[i:end]); err != nil {
+				t.Fatalf("%s: unexpected error writing: %s", c, err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: unexpected error closing: %s", c, err)
+		}
+
+		written, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.Format != None && len(written) >= len(data)/10 {
+			t.Errorf("%s: expected repetitive data to compress: got %d bytes from %d", c, len(written), len(data))
+		}
+
+		r, err := Open(path, nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error opening: %s", c, err)
+		}
+
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: unexpected error reading: %s", c, err)
+		}
+
+		if err := r.Close(); err != nil {
+			t.Errorf("%s: unexpected error closing reader: %s", c, err)
+		}
+
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s: read data differs from written data", c)
+		}
+	}
+}
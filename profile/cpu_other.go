@@ -0,0 +1,11 @@
+//go:build !darwin && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package profile
+
+import "time"
+
+// cpuTime returns 0 as the CPU time of the process can't be measured on this platform.
+func cpuTime() time.Duration {
+	return 0
+}
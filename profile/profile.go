@@ -0,0 +1,164 @@
+// Package profile measures the time spent in the stages of long running commands and serves the runtime profiles of
+// net/http/pprof, so that slow commands can be reported with details of where the time went.
+package profile
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Stage is the time spent in one stage of a command, added up over every time it ran.
+type Stage struct {
+	Name  string
+	Runs  int
+	Wall  time.Duration
+	CPU   time.Duration // User and system CPU time of the whole process, or 0 where it can't be measured
+	Alloc uint64        // Bytes allocated on the heap
+}
+
+// Timings records the stages of a command. A nil Timings records nothing, so stages may always be timed.
+type Timings struct {
+	mu     sync.Mutex
+	start  time.Time
+	cpu    time.Duration
+	stages []*Stage
+}
+
+// NewTimings returns a Timings which measures its total from now.
+func NewTimings() *Timings {
+	return &Timings{start: time.Now(), cpu: cpuTime()}
+}
+
+// Start begins a run of the named stage and returns a function which ends it. Stages may be nested. CPU time and
+// allocations are those of the whole process, so stages running at the same time share them.
+func (t *Timings) Start(name string) (end func()) {
+	if t == nil {
+		return func() {}
+	}
+
+	start, cpu, alloc := time.Now(), cpuTime(), totalAlloc()
+
+	return func() {
+		wall, cpu, alloc := time.Since(start), cpuTime()-cpu, totalAlloc()-alloc
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		s := t.stage(name)
+		s.Runs++
+		s.Wall += wall
+		s.CPU += cpu
+		s.Alloc += alloc
+	}
+}
+
+// stage returns the stage with the given name, adding it if it has not run before.
+func (t *Timings) stage(name string) *Stage {
+	for _, s := range t.stages {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	s := &Stage{Name: name}
+	t.stages = append(t.stages, s)
+
+	return s
+}
+
+// Stages returns the stages which have ended, in the order they first ended.
+func (t *Timings) Stages() []Stage {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stages := make([]Stage, len(t.stages))
+	for i, s := range t.stages {
+		stages[i] = *s
+	}
+
+	return stages
+}
+
+// WriteSummary writes a table of the stages and the total since NewTimings.
+func (t *Timings) WriteSummary(w io.Writer) error {
+	if t == nil {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "stage\truns\twall\tcpu\tallocated")
+
+	for _, s := range t.Stages() {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", s.Name, s.Runs, round(s.Wall), round(s.CPU), formatBytes(s.Alloc))
+	}
+
+	fmt.Fprintf(tw, "total\t\t%s\t%s\n", round(time.Since(t.start)), round(cpuTime()-t.cpu))
+
+	return tw.Flush()
+}
+
+// round rounds a duration to a precision which is readable in a summary.
+func round(d time.Duration) time.Duration {
+	if d > time.Second {
+		return d.Round(time.Millisecond)
+	}
+
+	return d.Round(time.Microsecond)
+}
+
+// formatBytes formats a number of bytes with a binary unit.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// totalAlloc returns the number of bytes allocated on the heap since the process started.
+func totalAlloc() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return m.TotalAlloc
+}
+
+// Serve serves the net/http/pprof endpoints under /debug/pprof/ at the given address, such as localhost:6060, until the
+// process exits. The address listened on is returned, which has the port chosen if the address has port 0.
+func Serve(addr string) (net.Addr, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening for pprof: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	go func() {
+		_ = http.Serve(l, mux)
+	}()
+
+	return l.Addr(), nil
+}
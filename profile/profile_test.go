@@ -0,0 +1,84 @@
+package profile
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimings(t *testing.T) {
+	timings := NewTimings()
+
+	for i := 0; i < 3; i++ {
+		end := timings.Start("render")
+		time.Sleep(time.Millisecond)
+		end()
+	}
+
+	timings.Start("write")()
+
+	stages := timings.Stages()
+	if len(stages) != 2 || stages[0].Name != "render" || stages[1].Name != "write" {
+		t.Fatalf("expected render and write stages in order: got %+v", stages)
+	}
+
+	if stages[0].Runs != 3 || stages[0].Wall < 3*time.Millisecond {
+		t.Errorf("expected 3 runs of render taking at least 3ms: got %+v", stages[0])
+	}
+
+	buf := &bytes.Buffer{}
+	if err := timings.WriteSummary(buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, s := range []string{"stage", "render", "write", "total"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected summary to contain %q: got\n%s", s, buf)
+		}
+	}
+}
+
+func TestNilTimings(t *testing.T) {
+	var timings *Timings
+
+	timings.Start("render")()
+
+	if s := timings.Stages(); len(s) != 0 {
+		t.Errorf("expected no stages: got %+v", s)
+	}
+
+	if err := timings.WriteSummary(&bytes.Buffer{}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[uint64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 3 << 30: "3.0 GiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("%d: expected %s: got %s", n, want, got)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	addr, err := Serve("localhost:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/debug/pprof/goroutine?debug=1", addr))
+	if err != nil {
+		t.Fatalf("unexpected error requesting profile: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "goroutine") {
+		t.Errorf("expected a goroutine profile: got status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,222 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Mirror is a local copy of the objects below a location, for using a world in object storage with code which needs
+// files, such as LevelDB. Objects are files named by their key relative to the location.
+type Mirror struct {
+	Dir string
+
+	client *Client
+	loc    Location
+	synced map[string]time.Time // Modification times of files as last downloaded or uploaded, by relative path
+}
+
+// NewMirror downloads every object below loc into dir, which is created if it doesn't exist.
+func NewMirror(ctx context.Context, c *Client, loc Location, dir string) (*Mirror, error) {
+	m := &Mirror{Dir: dir, client: c, loc: loc, synced: make(map[string]time.Time)}
+
+	prefix := loc.Join("").Key
+
+	objects, err := c.List(ctx, loc.Bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("no objects found at %s", loc)
+	}
+
+	for _, o := range objects {
+		rel := strings.TrimPrefix(o.Key, prefix)
+		if rel == "" || strings.HasSuffix(rel, "/") {
+			continue // Directory placeholders made by some storage consoles
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(filepath.Separator)) {
+			return nil, fmt.Errorf("object %s is outside the mirror directory", o.Key)
+		}
+
+		if err := m.download(ctx, o.Key, path); err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		m.synced[filepath.ToSlash(rel)] = info.ModTime()
+	}
+
+	return m, nil
+}
+
+func (m *Mirror) download(ctx context.Context, key, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", key, err)
+	}
+
+	body, err := m.client.Get(ctx, m.loc.Bucket, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return fmt.Errorf("downloading %s: %w", key, err)
+	}
+
+	return f.Close()
+}
+
+// Modified returns the relative paths, with forward slashes, of files which are new, changed or deleted since they were
+// downloaded or last uploaded.
+func (m *Mirror) Modified() ([]string, error) {
+	modified := make([]string, 0)
+	local := make(map[string]bool)
+
+	err := filepath.Walk(m.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(m.Dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		local[rel] = true
+
+		if t, ok := m.synced[rel]; !ok || !t.Equal(info.ModTime()) {
+			modified = append(modified, rel)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", m.Dir, err)
+	}
+
+	for rel := range m.synced {
+		if !local[rel] {
+			modified = append(modified, rel)
+		}
+	}
+
+	sort.Strings(modified)
+
+	return modified, nil
+}
+
+// Sync uploads files which are new or have changed since they were downloaded or last uploaded, and deletes the objects
+// of files which have been deleted. It returns the number of objects uploaded and deleted.
+func (m *Mirror) Sync(ctx context.Context) (uploaded, deleted int, err error) {
+	local := make(map[string]bool)
+
+	err = filepath.Walk(m.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(m.Dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		local[rel] = true
+
+		if t, ok := m.synced[rel]; ok && t.Equal(info.ModTime()) {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if err := m.client.Put(ctx, m.loc.Bucket, m.loc.Join(rel).Key, f); err != nil {
+			return err
+		}
+
+		m.synced[rel] = info.ModTime()
+		uploaded++
+
+		return nil
+	})
+	if err != nil {
+		return uploaded, deleted, fmt.Errorf("uploading to %s: %w", m.loc, err)
+	}
+
+	for rel := range m.synced {
+		if local[rel] {
+			continue
+		}
+
+		if err := m.client.Delete(ctx, m.loc.Bucket, m.loc.Join(rel).Key); err != nil {
+			return uploaded, deleted, err
+		}
+
+		delete(m.synced, rel)
+		deleted++
+	}
+
+	return uploaded, deleted, nil
+}
+
+// Remove deletes the local copy.
+func (m *Mirror) Remove() error {
+	return os.RemoveAll(m.Dir)
+}
+
+// Open returns the content of the object at loc. The caller must close it.
+func Open(ctx context.Context, c *Client, loc Location) (io.ReadCloser, error) {
+	return c.Get(ctx, loc.Bucket, loc.Key)
+}
+
+// objectWriter buffers written data in a temporary file and uploads it on Close, as the size and hash of an object must
+// be known before it is sent.
+type objectWriter struct {
+	*os.File
+	ctx    context.Context
+	client *Client
+	loc    Location
+}
+
+// Create returns a writer whose content is written to the object at loc when it is closed.
+func Create(ctx context.Context, c *Client, loc Location) (io.WriteCloser, error) {
+	f, err := ioutil.TempFile("", "mine-upload-")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary file: %w", err)
+	}
+
+	return &objectWriter{File: f, ctx: ctx, client: c, loc: loc}, nil
+}
+
+func (w *objectWriter) Close() error {
+	defer os.Remove(w.File.Name())
+	defer w.File.Close()
+
+	if _, err := w.File.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return w.client.Put(w.ctx, w.loc.Bucket, w.loc.Key, w.File)
+}
@@ -0,0 +1,312 @@
+// Package remote reads and writes objects in S3 compatible object storage, so that worlds and exports kept in the
+// cloud can be used without copying them by hand. Google Cloud Storage is used through its S3 compatible API with HMAC
+// keys.
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Location is an object, or a prefix of objects, in a bucket.
+type Location struct {
+	Scheme string // s3 or gs
+	Bucket string
+	Key    string // The object key or key prefix, without a leading slash
+}
+
+// schemes are the supported URI schemes.
+var schemes = map[string]bool{"s3": true, "gs": true}
+
+// IsURI returns true if s is an object storage URI such as s3://bucket/key, rather than a local path.
+func IsURI(s string) bool {
+	i := strings.Index(s, "://")
+	return i > 0 && schemes[strings.ToLower(s[:i])]
+}
+
+// Parse parses an object storage URI such as s3://bucket/worlds/survival.
+func Parse(uri string) (Location, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return Location{}, fmt.Errorf("parsing '%s': %w", uri, err)
+	}
+
+	l := Location{Scheme: strings.ToLower(u.Scheme), Bucket: u.Host, Key: strings.TrimPrefix(u.Path, "/")}
+
+	if !schemes[l.Scheme] {
+		return Location{}, fmt.Errorf("'%s' has unsupported scheme '%s': expected s3 or gs", uri, u.Scheme)
+	}
+
+	if l.Bucket == "" {
+		return Location{}, fmt.Errorf("'%s' has no bucket", uri)
+	}
+
+	return l, nil
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s://%s/%s", l.Scheme, l.Bucket, l.Key)
+}
+
+// Join returns the location of a path below l, as if l were a directory.
+func (l Location) Join(path string) Location {
+	key := strings.TrimSuffix(l.Key, "/")
+	if key != "" {
+		key += "/"
+	}
+	l.Key = key + strings.TrimPrefix(path, "/")
+
+	return l
+}
+
+// Client makes authenticated requests to an S3 compatible API.
+type Client struct {
+	// Endpoint is the base URL of the API, e.g. https://storage.googleapis.com. If empty, the AWS endpoint of the region
+	// is used with the bucket in the host name. Otherwise the bucket is the first part of the path.
+	Endpoint string
+	Region   string
+
+	AccessKey, SecretKey, SessionToken string
+
+	HTTP *http.Client
+	now  func() time.Time
+}
+
+// NewClient returns a client for the scheme of the given location, with credentials from the environment. S3 uses the
+// standard AWS variables: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION and, for other S3
+// compatible services, AWS_ENDPOINT_URL. Google Cloud Storage uses the HMAC key in GS_ACCESS_KEY_ID and
+// GS_SECRET_ACCESS_KEY.
+func NewClient(l Location) (*Client, error) {
+	c := &Client{HTTP: http.DefaultClient}
+
+	var keyVar, secretVar string
+
+	switch l.Scheme {
+	case "s3":
+		keyVar, secretVar = "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"
+		c.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		c.Endpoint = os.Getenv("AWS_ENDPOINT_URL")
+		c.Region = firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+		if c.Region == "" {
+			c.Region = "us-east-1"
+		}
+	case "gs":
+		keyVar, secretVar = "GS_ACCESS_KEY_ID", "GS_SECRET_ACCESS_KEY"
+		c.Endpoint = "https://storage.googleapis.com"
+		c.Region = "auto"
+	default:
+		return nil, fmt.Errorf("unsupported scheme '%s'", l.Scheme)
+	}
+
+	c.AccessKey, c.SecretKey = os.Getenv(keyVar), os.Getenv(secretVar)
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return nil, fmt.Errorf("no credentials for %s: set %s and %s", l.Scheme, keyVar, secretVar)
+	}
+
+	return c, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, n := range names {
+		if v := os.Getenv(n); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// objectURL returns the URL of an object, or of the bucket if key is empty.
+func (c *Client) objectURL(bucket, key string) (*url.URL, error) {
+	var u *url.URL
+	var err error
+	path := "/" + key
+
+	if c.Endpoint == "" {
+		u, err = url.Parse(fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, c.Region))
+	} else {
+		u, err = url.Parse(strings.TrimSuffix(c.Endpoint, "/"))
+		path = "/" + bucket + path
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint: %w", err)
+	}
+
+	u.Path = u.Path + path
+	u.RawPath = escape(u.Path, false)
+
+	return u, nil
+}
+
+// do sends a signed request with a body with the given SHA-256 hash, returning an error for any response which is not a
+// success.
+func (c *Client) do(ctx context.Context, method string, u *url.URL, body io.Reader, size int64, hash string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	// NewRequest parses the URL again, losing the escaping used for signing
+	req.URL = u
+
+	if body != nil {
+		req.ContentLength = size
+	}
+
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	c.sign(req, hash, now())
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if xml.Unmarshal(data, &e) != nil || e.Code == "" {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+
+	return nil, fmt.Errorf("%s: %s: %s", resp.Status, e.Code, e.Message)
+}
+
+// Object is an object in a bucket listing.
+type Object struct {
+	Key  string `xml:"Key"`
+	Size int64  `xml:"Size"`
+}
+
+// List returns every object in the bucket whose key starts with prefix.
+func (c *Client) List(ctx context.Context, bucket, prefix string) ([]Object, error) {
+	objects := make([]Object, 0)
+	marker := ""
+
+	for {
+		u, err := c.objectURL(bucket, "")
+		if err != nil {
+			return nil, err
+		}
+
+		q := url.Values{"prefix": {prefix}}
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+		u.RawQuery = q.Encode()
+
+		resp, err := c.do(ctx, http.MethodGet, u, nil, 0, emptyPayloadHash)
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", bucket, err)
+		}
+
+		var result struct {
+			IsTruncated bool     `xml:"IsTruncated"`
+			NextMarker  string   `xml:"NextMarker"`
+			Contents    []Object `xml:"Contents"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding listing of %s: %w", bucket, err)
+		}
+
+		objects = append(objects, result.Contents...)
+
+		if !result.IsTruncated || len(result.Contents) == 0 {
+			return objects, nil
+		}
+
+		marker = result.NextMarker
+		if marker == "" {
+			marker = result.Contents[len(result.Contents)-1].Key
+		}
+	}
+}
+
+// Get returns the content of an object. The caller must close it.
+func (c *Client) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	u, err := c.objectURL(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, u, nil, 0, emptyPayloadHash)
+	if err != nil {
+		return nil, fmt.Errorf("getting %s: %w", key, err)
+	}
+
+	return resp.Body, nil
+}
+
+// Put writes the content of r to an object, replacing any existing object. r is read twice: once to sign the request
+// and once to send it.
+func (c *Client) Put(ctx context.Context, bucket, key string, r io.ReadSeeker) error {
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return fmt.Errorf("reading content of %s: %w", key, err)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("reading content of %s: %w", key, err)
+	}
+
+	u, err := c.objectURL(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, http.MethodPut, u, ioutil.NopCloser(r), size, hex.EncodeToString(h.Sum(nil)))
+	if err != nil {
+		return fmt.Errorf("putting %s: %w", key, err)
+	}
+
+	return resp.Body.Close()
+}
+
+// Delete deletes an object. It is not an error if the object does not exist.
+func (c *Client) Delete(ctx context.Context, bucket, key string) error {
+	u, err := c.objectURL(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, http.MethodDelete, u, nil, 0, emptyPayloadHash)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("deleting %s: %w", key, err)
+	}
+
+	return resp.Body.Close()
+}
@@ -0,0 +1,234 @@
+// Package render draws images of world data.
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/danhale-git/mine/world"
+)
+
+// Banner face size in pixels, matching the game's banner textures.
+const (
+	bannerWidth  = 20
+	bannerHeight = 40
+)
+
+// bannerMask returns the coverage of a pattern at a pixel of the banner face, from 0 to 1.
+type bannerMask func(x, y int) float64
+
+func cover(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// diagonal returns the distance of a pixel from the line joining the top left and bottom right corners, in pixels
+// along x. If flip is true the line joins the top right and bottom left corners.
+func diagonal(x, y int, flip bool) float64 {
+	u := (float64(x) + 0.5) / bannerWidth
+	v := (float64(y) + 0.5) / bannerHeight
+
+	if flip {
+		u = 1 - u
+	}
+
+	return (u - v) * bannerWidth
+}
+
+// emblem returns a mask drawing a bitmap at the center of the banner, scaled up by 2. Each string is a row and # marks
+// a covered pixel. The game's emblem textures are more detailed, so emblems are only recognisable approximations.
+func emblem(rows ...string) bannerMask {
+	h, w := len(rows)*2, len(rows[0])*2
+	ox, oy := (bannerWidth-w)/2, (bannerHeight-h)/2
+
+	return func(x, y int) float64 {
+		x, y = x-ox, y-oy
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return 0
+		}
+
+		return cover(rows[y/2][x/2] == '#')
+	}
+}
+
+var bannerMasks = map[string]bannerMask{
+	"bs":  func(x, y int) float64 { return cover(y >= bannerHeight*2/3) },
+	"ts":  func(x, y int) float64 { return cover(y < bannerHeight/3) },
+	"ls":  func(x, y int) float64 { return cover(x < bannerWidth/3) },
+	"rs":  func(x, y int) float64 { return cover(x >= bannerWidth*2/3) },
+	"cs":  func(x, y int) float64 { return cover(x >= bannerWidth*3/8 && x < bannerWidth*5/8) },
+	"ms":  func(x, y int) float64 { return cover(y >= bannerHeight*3/8 && y < bannerHeight*5/8) },
+	"drs": func(x, y int) float64 { return cover(math.Abs(diagonal(x, y, false)) < 2.5) },
+	"dls": func(x, y int) float64 { return cover(math.Abs(diagonal(x, y, true)) < 2.5) },
+	"ss": func(x, y int) float64 {
+		return cover(x > 0 && x < bannerWidth-1 && (x-1)/2%2 == 0 && y < bannerHeight-2)
+	},
+	"cr": func(x, y int) float64 {
+		return cover(math.Abs(diagonal(x, y, false)) < 2.5 || math.Abs(diagonal(x, y, true)) < 2.5)
+	},
+	"sc": func(x, y int) float64 {
+		return cover(x >= bannerWidth*3/8 && x < bannerWidth*5/8 || y >= bannerHeight*3/8 && y < bannerHeight*5/8)
+	},
+	"ld":  func(x, y int) float64 { return cover(diagonal(x, y, true) > 0) },
+	"rud": func(x, y int) float64 { return cover(diagonal(x, y, true) <= 0) },
+	"lud": func(x, y int) float64 { return cover(diagonal(x, y, false) < 0) },
+	"rd":  func(x, y int) float64 { return cover(diagonal(x, y, false) >= 0) },
+	"vh":  func(x, y int) float64 { return cover(x < bannerWidth/2) },
+	"vhr": func(x, y int) float64 { return cover(x >= bannerWidth/2) },
+	"hh":  func(x, y int) float64 { return cover(y < bannerHeight/2) },
+	"hhb": func(x, y int) float64 { return cover(y >= bannerHeight/2) },
+	"bl":  func(x, y int) float64 { return cover(x < bannerWidth/2 && y >= bannerHeight*3/4) },
+	"br":  func(x, y int) float64 { return cover(x >= bannerWidth/2 && y >= bannerHeight*3/4) },
+	"tl":  func(x, y int) float64 { return cover(x < bannerWidth/2 && y < bannerHeight/4) },
+	"tr":  func(x, y int) float64 { return cover(x >= bannerWidth/2 && y < bannerHeight/4) },
+	"bt": func(x, y int) float64 {
+		d := math.Abs(float64(x) + 0.5 - bannerWidth/2)
+		return cover(float64(bannerHeight-y) <= bannerWidth/2-d)
+	},
+	"tt": func(x, y int) float64 {
+		d := math.Abs(float64(x) + 0.5 - bannerWidth/2)
+		return cover(float64(y+1) <= bannerWidth/2-d)
+	},
+	"bts": func(x, y int) float64 {
+		tooth := 2 - math.Abs(float64(x%5)-2)
+		return cover(float64(bannerHeight-y) <= 2+tooth)
+	},
+	"tts": func(x, y int) float64 {
+		tooth := 2 - math.Abs(float64(x%5)-2)
+		return cover(float64(y+1) <= 2+tooth)
+	},
+	"mc": func(x, y int) float64 {
+		dx, dy := float64(x)+0.5-bannerWidth/2, float64(y)+0.5-bannerHeight/2
+		return cover(dx*dx+dy*dy < 36)
+	},
+	"mr": func(x, y int) float64 {
+		dx, dy := float64(x)+0.5-bannerWidth/2, float64(y)+0.5-bannerHeight/2
+		return cover(math.Abs(dx)/6+math.Abs(dy)/10 < 1)
+	},
+	"bo": func(x, y int) float64 {
+		return cover(x < 2 || y < 2 || x >= bannerWidth-2 || y >= bannerHeight-2)
+	},
+	"cbo": func(x, y int) float64 {
+		w := 1 + (x+y)%2
+		return cover(x < w || y < w || x >= bannerWidth-w || y >= bannerHeight-w)
+	},
+	"bri": func(x, y int) float64 {
+		return cover(y%4 == 3 || (x+y/4%2*3)%6 == 5)
+	},
+	"gra": func(x, y int) float64 { return 1 - (float64(y)+0.5)/bannerHeight },
+	"gru": func(x, y int) float64 { return (float64(y) + 0.5) / bannerHeight },
+	"cre": emblem(
+		"##..##",
+		"##..##",
+		"..##..",
+		".####.",
+		".#..#.",
+	),
+	"sku": emblem(
+		".####.",
+		"######",
+		"#..#.#",
+		"######",
+		".#.#..",
+		"..##..",
+		"#....#",
+		".#..#.",
+	),
+	"flo": emblem(
+		"..#..",
+		".#.#.",
+		"#.#.#",
+		".#.#.",
+		"..#..",
+	),
+	"moj": emblem(
+		".###..",
+		"#...#.",
+		"#.#..#",
+		"#..#.#",
+		".#...#",
+		"..###.",
+	),
+	"glb": emblem(
+		".####.",
+		"#.#..#",
+		"######",
+		"#..#.#",
+		".####.",
+	),
+	"pig": emblem(
+		"######",
+		"#.##.#",
+		"######",
+	),
+	"flw": emblem(
+		"#...#",
+		".#.#.",
+		"..#..",
+		".#.#.",
+		"#...#",
+	),
+	"gus": emblem(
+		"#.#.#",
+		".###.",
+		"##.##",
+		".###.",
+		"#.#.#",
+	),
+}
+
+// Banner draws the face of a banner, scaled up by the given factor. Patterns with unknown codes are not drawn.
+func Banner(b world.Banner, scale int) *image.RGBA {
+	if scale < 1 {
+		scale = 1
+	}
+
+	face := [bannerHeight][bannerWidth]color.RGBA{}
+	for y := range face {
+		for x := range face[y] {
+			face[y][x] = b.Base.RGBA()
+		}
+	}
+
+	for _, p := range b.Patterns {
+		mask, ok := bannerMasks[p.Code]
+		if !ok {
+			continue
+		}
+
+		c := p.Color.RGBA()
+
+		for y := range face {
+			for x := range face[y] {
+				face[y][x] = blend(face[y][x], c, mask(x, y))
+			}
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, bannerWidth*scale, bannerHeight*scale))
+
+	for y := 0; y < bannerHeight*scale; y++ {
+		for x := 0; x < bannerWidth*scale; x++ {
+			img.SetRGBA(x, y, face[y/scale][x/scale])
+		}
+	}
+
+	return img
+}
+
+// blend mixes the color c over dst with the given coverage.
+func blend(dst, c color.RGBA, alpha float64) color.RGBA {
+	if alpha <= 0 {
+		return dst
+	}
+
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a)*(1-alpha) + float64(b)*alpha))
+	}
+
+	return color.RGBA{mix(dst.R, c.R), mix(dst.G, c.G), mix(dst.B, c.B), 0xff}
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/danhale-git/mine/world"
+)
+
+func TestBanner(t *testing.T) {
+	red := world.DyeColor(1).RGBA()
+	white := world.DyeColor(15).RGBA()
+
+	// A white banner with a red top half
+	img := Banner(world.Banner{Base: 15, Patterns: []world.BannerPattern{{Code: "hh", Color: 1}, {Code: "??", Color: 0}}}, 2)
+
+	if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 80 {
+		t.Fatalf("expected 40x80 image: got %dx%d", b.Dx(), b.Dy())
+	}
+
+	for _, c := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{0, 0, red},
+		{39, 39, red},
+		{0, 40, white},
+		{39, 79, white},
+	} {
+		if got := img.RGBAAt(c.x, c.y); got != c.want {
+			t.Errorf("pixel %d %d: expected %v: got %v", c.x, c.y, c.want, got)
+		}
+	}
+}
+
+func TestBannerMasks(t *testing.T) {
+	for code := range bannerMasks {
+		covered := 0
+		for y := 0; y < bannerHeight; y++ {
+			for x := 0; x < bannerWidth; x++ {
+				if a := bannerMasks[code](x, y); a < 0 || a > 1 {
+					t.Fatalf("%s: coverage %f out of range at %d %d", code, a, x, y)
+				} else if a > 0 {
+					covered++
+				}
+			}
+		}
+
+		if covered == 0 || covered == bannerWidth*bannerHeight && code != "gra" && code != "gru" {
+			t.Errorf("%s: expected pattern to partly cover the banner: covers %d pixels", code, covered)
+		}
+	}
+}
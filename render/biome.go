@@ -0,0 +1,207 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/danhale-git/mine/world"
+)
+
+// Mode selects how map columns are colored.
+type Mode int
+
+// Render modes.
+const (
+	BlockMode   Mode = iota // The color of the surface block
+	BiomeMode               // A flat color for each biome
+	BlendedMode             // The color of the surface block, with grass, foliage and water tinted by biome as in game
+)
+
+var modeNames = []string{"block", "biome", "blended"}
+
+func (m Mode) String() string {
+	if m < 0 || int(m) >= len(modeNames) {
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+
+	return modeNames[m]
+}
+
+// ParseMode returns the render mode with the given name: block, biome or blended.
+func ParseMode(s string) (Mode, error) {
+	for i, n := range modeNames {
+		if strings.EqualFold(n, s) {
+			return Mode(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid render mode '%s': expected one of %s", s, strings.Join(modeNames, ", "))
+}
+
+// biomeColors are the colors of biomes in biome mode, by name. Variants such as hills and mutated biomes which aren't
+// listed take the color of the biome their name starts with.
+var biomeColors = map[string]color.RGBA{
+	"ocean":                    {0x00, 0x00, 0x70, 0xff},
+	"deep_ocean":               {0x00, 0x00, 0x30, 0xff},
+	"warm_ocean":               {0x00, 0x00, 0xac, 0xff},
+	"lukewarm_ocean":           {0x00, 0x00, 0x90, 0xff},
+	"cold_ocean":               {0x20, 0x20, 0x70, 0xff},
+	"frozen_ocean":             {0x70, 0x70, 0xd6, 0xff},
+	"legacy_frozen_ocean":      {0x70, 0x70, 0xd6, 0xff},
+	"river":                    {0x00, 0x00, 0xff, 0xff},
+	"frozen_river":             {0xa0, 0xa0, 0xff, 0xff},
+	"plains":                   {0x8d, 0xb3, 0x60, 0xff},
+	"sunflower_plains":         {0xb5, 0xdb, 0x88, 0xff},
+	"desert":                   {0xfa, 0x94, 0x18, 0xff},
+	"extreme_hills":            {0x60, 0x60, 0x60, 0xff},
+	"stone_beach":              {0xa2, 0xa2, 0x84, 0xff},
+	"forest":                   {0x05, 0x66, 0x21, 0xff},
+	"flower_forest":            {0x2d, 0x8e, 0x49, 0xff},
+	"birch_forest":             {0x30, 0x74, 0x44, 0xff},
+	"roofed_forest":            {0x40, 0x51, 0x1a, 0xff},
+	"taiga":                    {0x0b, 0x66, 0x59, 0xff},
+	"cold_taiga":               {0x31, 0x55, 0x4a, 0xff},
+	"mega_taiga":               {0x59, 0x66, 0x51, 0xff},
+	"redwood_taiga":            {0x59, 0x66, 0x51, 0xff},
+	"swampland":                {0x07, 0xf9, 0xb2, 0xff},
+	"mangrove_swamp":           {0x2c, 0xcc, 0x8e, 0xff},
+	"ice_plains":               {0xff, 0xff, 0xff, 0xff},
+	"ice_mountains":            {0xa0, 0xa0, 0xa0, 0xff},
+	"mushroom_island":          {0xff, 0x00, 0xff, 0xff},
+	"beach":                    {0xfa, 0xde, 0x55, 0xff},
+	"cold_beach":               {0xfa, 0xf0, 0xc0, 0xff},
+	"jungle":                   {0x53, 0x7b, 0x09, 0xff},
+	"jungle_edge":              {0x62, 0x8b, 0x17, 0xff},
+	"bamboo_jungle":            {0x76, 0x8e, 0x14, 0xff},
+	"savanna":                  {0xbd, 0xb2, 0x5f, 0xff},
+	"mesa":                     {0xd9, 0x45, 0x15, 0xff},
+	"hell":                     {0xbf, 0x3b, 0x3b, 0xff},
+	"soulsand_valley":          {0x5e, 0x38, 0x30, 0xff},
+	"crimson_forest":           {0xdd, 0x08, 0x08, 0xff},
+	"warped_forest":            {0x49, 0x90, 0x7b, 0xff},
+	"basalt_deltas":            {0x40, 0x36, 0x36, 0xff},
+	"the_end":                  {0x80, 0x80, 0xff, 0xff},
+	"meadow":                   {0x83, 0xbb, 0x6d, 0xff},
+	"grove":                    {0x8a, 0xb4, 0x7a, 0xff},
+	"snowy_slopes":             {0xc4, 0xc4, 0xc4, 0xff},
+	"frozen_peaks":             {0xa0, 0xa0, 0xc0, 0xff},
+	"jagged_peaks":             {0xdc, 0xdc, 0xc8, 0xff},
+	"stony_peaks":              {0x7b, 0x8f, 0x74, 0xff},
+	"lush_caves":               {0x28, 0x3c, 0x00, 0xff},
+	"dripstone_caves":          {0x4e, 0x3e, 0x2e, 0xff},
+	"deep_dark":                {0x0a, 0x1e, 0x1e, 0xff},
+	"cherry_grove":             {0xf4, 0xbd, 0xd9, 0xff},
+	"extreme_hills_plus_trees": {0x50, 0x70, 0x50, 0xff},
+}
+
+// biomeTint is the color of grass, foliage and water in a biome.
+type biomeTint struct {
+	grass, foliage, water color.RGBA
+}
+
+// defaultTint is the tint of biomes not listed in biomeTints.
+var defaultTint = biomeTint{
+	grass:   color.RGBA{0x91, 0xbd, 0x59, 0xff},
+	foliage: color.RGBA{0x77, 0xab, 0x2f, 0xff},
+	water:   color.RGBA{0x44, 0xaf, 0xf5, 0xff},
+}
+
+// biomeTints are the grass, foliage and water colors of biomes, by name prefix. The longest matching prefix is used.
+var biomeTints = map[string]biomeTint{
+	"desert":         {color.RGBA{0xbf, 0xb7, 0x55, 0xff}, color.RGBA{0xae, 0xa4, 0x2a, 0xff}, color.RGBA{0x32, 0xa5, 0x98, 0xff}},
+	"savanna":        {color.RGBA{0xbf, 0xb7, 0x55, 0xff}, color.RGBA{0xae, 0xa4, 0x2a, 0xff}, color.RGBA{0x2c, 0x8b, 0x9c, 0xff}},
+	"mesa":           {color.RGBA{0x90, 0x81, 0x4d, 0xff}, color.RGBA{0x9e, 0x81, 0x4d, 0xff}, color.RGBA{0x4e, 0x7f, 0x81, 0xff}},
+	"forest":         {color.RGBA{0x79, 0xc0, 0x5a, 0xff}, color.RGBA{0x59, 0xae, 0x30, 0xff}, color.RGBA{0x1e, 0x97, 0xf2, 0xff}},
+	"birch_forest":   {color.RGBA{0x88, 0xbb, 0x67, 0xff}, color.RGBA{0x6b, 0xa9, 0x41, 0xff}, color.RGBA{0x06, 0x77, 0xce, 0xff}},
+	"roofed_forest":  {color.RGBA{0x50, 0x7a, 0x32, 0xff}, color.RGBA{0x59, 0xae, 0x30, 0xff}, color.RGBA{0x3b, 0x6c, 0xd1, 0xff}},
+	"taiga":          {color.RGBA{0x86, 0xb7, 0x83, 0xff}, color.RGBA{0x68, 0xa4, 0x64, 0xff}, color.RGBA{0x28, 0x63, 0x78, 0xff}},
+	"cold_taiga":     {color.RGBA{0x80, 0xb4, 0x97, 0xff}, color.RGBA{0x60, 0xa1, 0x7b, 0xff}, color.RGBA{0x20, 0x5e, 0x83, 0xff}},
+	"mega_taiga":     {color.RGBA{0x86, 0xb8, 0x7f, 0xff}, color.RGBA{0x68, 0xa4, 0x64, 0xff}, color.RGBA{0x2d, 0x6d, 0x77, 0xff}},
+	"swampland":      {color.RGBA{0x6a, 0x70, 0x39, 0xff}, color.RGBA{0x6a, 0x70, 0x39, 0xff}, color.RGBA{0x4c, 0x65, 0x59, 0xff}},
+	"mangrove_swamp": {color.RGBA{0x6a, 0x70, 0x39, 0xff}, color.RGBA{0x8d, 0xb1, 0x27, 0xff}, color.RGBA{0x3a, 0x7a, 0x6a, 0xff}},
+	"jungle":         {color.RGBA{0x59, 0xc9, 0x3c, 0xff}, color.RGBA{0x30, 0xbb, 0x0b, 0xff}, color.RGBA{0x14, 0xa2, 0xc5, 0xff}},
+	"bamboo_jungle":  {color.RGBA{0x59, 0xc9, 0x3c, 0xff}, color.RGBA{0x30, 0xbb, 0x0b, 0xff}, color.RGBA{0x14, 0xa2, 0xc5, 0xff}},
+	"ice":            {color.RGBA{0x80, 0xb4, 0x97, 0xff}, color.RGBA{0x60, 0xa1, 0x7b, 0xff}, color.RGBA{0x14, 0x55, 0x9b, 0xff}},
+	"frozen":         {color.RGBA{0x80, 0xb4, 0x97, 0xff}, color.RGBA{0x60, 0xa1, 0x7b, 0xff}, color.RGBA{0x25, 0x70, 0xb5, 0xff}},
+	"mushroom":       {color.RGBA{0x55, 0xc9, 0x3f, 0xff}, color.RGBA{0x2b, 0xbb, 0x0f, 0xff}, color.RGBA{0x8a, 0x89, 0x97, 0xff}},
+	"ocean":          {color.RGBA{0x8e, 0xb9, 0x71, 0xff}, color.RGBA{0x71, 0xa7, 0x4d, 0xff}, color.RGBA{0x17, 0x87, 0xd4, 0xff}},
+	"warm_ocean":     {color.RGBA{0x8e, 0xb9, 0x71, 0xff}, color.RGBA{0x71, 0xa7, 0x4d, 0xff}, color.RGBA{0x02, 0xb0, 0xe5, 0xff}},
+	"cherry_grove":   {color.RGBA{0xb6, 0xdb, 0x61, 0xff}, color.RGBA{0xb6, 0xdb, 0x61, 0xff}, color.RGBA{0x5d, 0xb7, 0xef, 0xff}},
+}
+
+// biomeColor returns the color of a biome in biome mode.
+func biomeColor(id int) color.RGBA {
+	return biomeNameColor(world.BiomeName(id))
+}
+
+// biomeNameColor returns the color of the biome with the given name in biome mode.
+func biomeNameColor(name string) color.RGBA {
+	best := ""
+	for n := range biomeColors {
+		if strings.HasPrefix(name, n) && len(n) > len(best) {
+			best = n
+		}
+	}
+
+	if best == "" {
+		return derivedColor(name)
+	}
+
+	return biomeColors[best]
+}
+
+// tint returns the grass, foliage and water colors of a biome.
+func tint(id int) biomeTint {
+	name := world.BiomeName(id)
+
+	best := ""
+	for n := range biomeTints {
+		if strings.HasPrefix(name, n) && len(n) > len(best) {
+			best = n
+		}
+	}
+
+	if best == "" {
+		return defaultTint
+	}
+
+	return biomeTints[best]
+}
+
+// tintedBlocks are the blocks which are tinted by biome in blended mode, by ID without the minecraft: prefix.
+var tintedBlocks = map[string]func(biomeTint) color.RGBA{
+	"grass":           func(t biomeTint) color.RGBA { return t.grass },
+	"grass_block":     func(t biomeTint) color.RGBA { return t.grass },
+	"tallgrass":       func(t biomeTint) color.RGBA { return t.grass },
+	"short_grass":     func(t biomeTint) color.RGBA { return t.grass },
+	"double_plant":    func(t biomeTint) color.RGBA { return t.grass },
+	"fern":            func(t biomeTint) color.RGBA { return t.grass },
+	"leaves":          func(t biomeTint) color.RGBA { return t.foliage },
+	"leaves2":         func(t biomeTint) color.RGBA { return t.foliage },
+	"oak_leaves":      func(t biomeTint) color.RGBA { return t.foliage },
+	"jungle_leaves":   func(t biomeTint) color.RGBA { return t.foliage },
+	"acacia_leaves":   func(t biomeTint) color.RGBA { return t.foliage },
+	"dark_oak_leaves": func(t biomeTint) color.RGBA { return t.foliage },
+	"vine":            func(t biomeTint) color.RGBA { return t.foliage },
+	"water":           func(t biomeTint) color.RGBA { return t.water },
+	"flowing_water":   func(t biomeTint) color.RGBA { return t.water },
+}
+
+// columnColor returns the color of a column's surface block in the given mode. Blocks in the palette keep the palette's
+// color in blended mode, rather than being tinted.
+func columnColor(b world.Block, biome int, mode Mode, p Palette) color.RGBA {
+	switch mode {
+	case BiomeMode:
+		return biomeColor(biome)
+	case BlendedMode:
+		if _, ok := p.lookup(b.ID); ok {
+			break
+		}
+		if f, ok := tintedBlocks[strings.TrimPrefix(b.ID, "minecraft:")]; ok {
+			return f(tint(biome))
+		}
+	}
+
+	return p.Color(b.ID)
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/world"
+)
+
+func TestParseMode(t *testing.T) {
+	for _, m := range []Mode{BlockMode, BiomeMode, BlendedMode} {
+		got, err := ParseMode(m.String())
+		if err != nil {
+			t.Fatalf("unexpected error parsing %s: %s", m, err)
+		}
+
+		if got != m {
+			t.Errorf("expected %s: got %s", m, got)
+		}
+	}
+
+	if _, err := ParseMode("height"); err == nil {
+		t.Errorf("expected error parsing unknown mode")
+	}
+}
+
+func TestColumnColor(t *testing.T) {
+	const plains, desert, desertHills = 1, 2, 17
+
+	grass := world.Block{ID: "minecraft:grass"}
+	stone := world.Block{ID: "minecraft:stone"}
+
+	if got := columnColor(stone, desert, BiomeMode, nil); got != biomeColors["desert"] {
+		t.Errorf("expected desert color in biome mode: got %v", got)
+	}
+
+	if got := columnColor(stone, desertHills, BiomeMode, nil); got != biomeColors["desert"] {
+		t.Errorf("expected desert hills to take the desert color: got %v", got)
+	}
+
+	if got := columnColor(grass, plains, BlockMode, nil); got != BlockColor(grass.ID) {
+		t.Errorf("expected block color in block mode: got %v", got)
+	}
+
+	if got := columnColor(stone, desert, BlendedMode, nil); got != BlockColor(stone.ID) {
+		t.Errorf("expected untinted block to keep its color in blended mode: got %v", got)
+	}
+
+	if got := columnColor(grass, desert, BlendedMode, nil); got != biomeTints["desert"].grass {
+		t.Errorf("expected desert grass tint in blended mode: got %v", got)
+	}
+
+	if got := columnColor(grass, plains, BlendedMode, nil); got != defaultTint.grass {
+		t.Errorf("expected default grass tint for plains: got %v", got)
+	}
+
+	water := columnColor(world.Block{ID: "minecraft:water"}, 6, BlendedMode, nil)
+	if water != biomeTints["swampland"].water {
+		t.Errorf("expected swamp water tint: got %v", water)
+	}
+}
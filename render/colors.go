@@ -0,0 +1,103 @@
+package render
+
+import (
+	"hash/fnv"
+	"image/color"
+	"strings"
+)
+
+// blockColors are the map colors of common blocks, by block ID without the minecraft: prefix.
+var blockColors = map[string]color.RGBA{
+	"grass":                 {0x7f, 0xb2, 0x38, 0xff},
+	"grass_block":           {0x7f, 0xb2, 0x38, 0xff},
+	"dirt":                  {0x97, 0x6d, 0x4d, 0xff},
+	"coarse_dirt":           {0x97, 0x6d, 0x4d, 0xff},
+	"podzol":                {0x81, 0x56, 0x31, 0xff},
+	"mycelium":              {0x7f, 0x3f, 0xb2, 0xff},
+	"farmland":              {0x97, 0x6d, 0x4d, 0xff},
+	"grass_path":            {0x94, 0x79, 0x4a, 0xff},
+	"stone":                 {0x70, 0x70, 0x70, 0xff},
+	"cobblestone":           {0x70, 0x70, 0x70, 0xff},
+	"deepslate":             {0x64, 0x64, 0x64, 0xff},
+	"gravel":                {0x88, 0x82, 0x7f, 0xff},
+	"sand":                  {0xf7, 0xe9, 0xa3, 0xff},
+	"sandstone":             {0xf7, 0xe9, 0xa3, 0xff},
+	"red_sand":              {0xd8, 0x7f, 0x33, 0xff},
+	"clay":                  {0xa4, 0xa8, 0xb8, 0xff},
+	"water":                 {0x40, 0x40, 0xff, 0xff},
+	"flowing_water":         {0x40, 0x40, 0xff, 0xff},
+	"lava":                  {0xff, 0x00, 0x00, 0xff},
+	"flowing_lava":          {0xff, 0x00, 0x00, 0xff},
+	"ice":                   {0xa0, 0xa0, 0xff, 0xff},
+	"packed_ice":            {0xa0, 0xa0, 0xff, 0xff},
+	"blue_ice":              {0xa0, 0xa0, 0xff, 0xff},
+	"snow":                  {0xff, 0xff, 0xff, 0xff},
+	"snow_layer":            {0xff, 0xff, 0xff, 0xff},
+	"bedrock":               {0x4a, 0x4a, 0x4a, 0xff},
+	"leaves":                {0x00, 0x7c, 0x00, 0xff},
+	"leaves2":               {0x00, 0x7c, 0x00, 0xff},
+	"azalea_leaves":         {0x00, 0x7c, 0x00, 0xff},
+	"log":                   {0x8f, 0x77, 0x48, 0xff},
+	"log2":                  {0x8f, 0x77, 0x48, 0xff},
+	"oak_log":               {0x8f, 0x77, 0x48, 0xff},
+	"planks":                {0x8f, 0x77, 0x48, 0xff},
+	"tallgrass":             {0x00, 0x7c, 0x00, 0xff},
+	"double_plant":          {0x00, 0x7c, 0x00, 0xff},
+	"cactus":                {0x00, 0x7c, 0x00, 0xff},
+	"reeds":                 {0x00, 0x7c, 0x00, 0xff},
+	"pumpkin":               {0xd8, 0x7f, 0x33, 0xff},
+	"melon_block":           {0x7f, 0xcc, 0x19, 0xff},
+	"netherrack":            {0x70, 0x02, 0x00, 0xff},
+	"soul_sand":             {0x66, 0x4c, 0x33, 0xff},
+	"soul_soil":             {0x66, 0x4c, 0x33, 0xff},
+	"glowstone":             {0xf7, 0xe9, 0xa3, 0xff},
+	"crimson_nylium":        {0xbd, 0x30, 0x31, 0xff},
+	"warped_nylium":         {0x16, 0x7e, 0x86, 0xff},
+	"basalt":                {0x19, 0x19, 0x19, 0xff},
+	"blackstone":            {0x19, 0x19, 0x19, 0xff},
+	"end_stone":             {0xf7, 0xe9, 0xa3, 0xff},
+	"obsidian":              {0x19, 0x19, 0x19, 0xff},
+	"purpur_block":          {0xb2, 0x4c, 0xd8, 0xff},
+	"chorus_plant":          {0x7f, 0x3f, 0xb2, 0xff},
+	"terracotta":            {0xd1, 0xb1, 0xa1, 0xff},
+	"hardened_clay":         {0x98, 0x5e, 0x43, 0xff},
+	"stained_hardened_clay": {0x98, 0x5e, 0x43, 0xff},
+	"brick_block":           {0x99, 0x33, 0x33, 0xff},
+	"stonebrick":            {0x70, 0x70, 0x70, 0xff},
+	"glass":                 {0xff, 0xff, 0xff, 0xff},
+	"moss_block":            {0x66, 0x7f, 0x33, 0xff},
+	"mud":                   {0x57, 0x5c, 0x5c, 0xff},
+}
+
+// BlockColor returns the color of a block seen from above. Blocks without a known color are given a muted color derived
+// from their ID, so the same block always has the same color.
+func BlockColor(id string) color.RGBA {
+	name := strings.TrimPrefix(id, "minecraft:")
+
+	if c, ok := blockColors[name]; ok {
+		return c
+	}
+
+	switch {
+	case strings.HasSuffix(name, "_leaves"):
+		return blockColors["leaves"]
+	case strings.HasSuffix(name, "_log"), strings.HasSuffix(name, "_planks"), strings.HasSuffix(name, "_wood"):
+		return blockColors["log"]
+	case strings.HasSuffix(name, "_terracotta"):
+		return blockColors["hardened_clay"]
+	case strings.Contains(name, "stone"), strings.Contains(name, "ore"):
+		return blockColors["stone"]
+	}
+
+	return derivedColor(name)
+}
+
+// derivedColor returns a muted color derived from a name, so the same name always has the same color.
+func derivedColor(name string) color.RGBA {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	v := h.Sum32()
+
+	// Keep derived colors in the middle of the range so they don't look like water, snow or the void
+	return color.RGBA{0x50 + uint8(v)%0x60, 0x50 + uint8(v>>8)%0x60, 0x50 + uint8(v>>16)%0x60, 0xff}
+}
@@ -0,0 +1,114 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"unicode"
+)
+
+// Glyph size in pixels before scaling.
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+)
+
+// glyphs is a small bitmap font for map labels. Each string is a row and # marks a drawn pixel. Lower case letters are
+// drawn as upper case and characters with no glyph are drawn as ?.
+var glyphs = map[rune][glyphHeight]string{
+	'0':  {"###", "#.#", "#.#", "#.#", "###"},
+	'1':  {".#.", "##.", ".#.", ".#.", "###"},
+	'2':  {"###", "..#", "###", "#..", "###"},
+	'3':  {"###", "..#", ".##", "..#", "###"},
+	'4':  {"#.#", "#.#", "###", "..#", "..#"},
+	'5':  {"###", "#..", "###", "..#", "###"},
+	'6':  {"###", "#..", "###", "#.#", "###"},
+	'7':  {"###", "..#", ".#.", ".#.", ".#."},
+	'8':  {"###", "#.#", "###", "#.#", "###"},
+	'9':  {"###", "#.#", "###", "..#", "###"},
+	'A':  {".#.", "#.#", "###", "#.#", "#.#"},
+	'B':  {"##.", "#.#", "##.", "#.#", "##."},
+	'C':  {".##", "#..", "#..", "#..", ".##"},
+	'D':  {"##.", "#.#", "#.#", "#.#", "##."},
+	'E':  {"###", "#..", "##.", "#..", "###"},
+	'F':  {"###", "#..", "##.", "#..", "#.."},
+	'G':  {".##", "#..", "#.#", "#.#", ".##"},
+	'H':  {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I':  {"###", ".#.", ".#.", ".#.", "###"},
+	'J':  {"..#", "..#", "..#", "#.#", ".#."},
+	'K':  {"#.#", "#.#", "##.", "#.#", "#.#"},
+	'L':  {"#..", "#..", "#..", "#..", "###"},
+	'M':  {"#.#", "###", "###", "#.#", "#.#"},
+	'N':  {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O':  {".#.", "#.#", "#.#", "#.#", ".#."},
+	'P':  {"##.", "#.#", "##.", "#..", "#.."},
+	'Q':  {".#.", "#.#", "#.#", "##.", ".##"},
+	'R':  {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S':  {".##", "#..", ".#.", "..#", "##."},
+	'T':  {"###", ".#.", ".#.", ".#.", ".#."},
+	'U':  {"#.#", "#.#", "#.#", "#.#", "###"},
+	'V':  {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W':  {"#.#", "#.#", "###", "###", "#.#"},
+	'X':  {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y':  {"#.#", "#.#", ".#.", ".#.", ".#."},
+	'Z':  {"###", "..#", ".#.", "#..", "###"},
+	'-':  {"...", "...", "###", "...", "..."},
+	'.':  {"...", "...", "...", "...", ".#."},
+	',':  {"...", "...", "...", ".#.", "#.."},
+	':':  {"...", ".#.", "...", ".#.", "..."},
+	'\'': {".#.", ".#.", "...", "...", "..."},
+	'!':  {".#.", ".#.", ".#.", "...", ".#."},
+	'?':  {"##.", "..#", ".#.", "...", ".#."},
+	'(':  {"..#", ".#.", ".#.", ".#.", "..#"},
+	')':  {"#..", ".#.", ".#.", ".#.", "#.."},
+	'/':  {"..#", "..#", ".#.", "#..", "#.."},
+	' ':  {"...", "...", "...", "...", "..."},
+}
+
+func glyph(r rune) [glyphHeight]string {
+	if g, ok := glyphs[unicode.ToUpper(r)]; ok {
+		return g
+	}
+
+	return glyphs['?']
+}
+
+// textWidth returns the width in pixels of s drawn at the given size.
+func textWidth(s string, size int) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+
+	return (n*(glyphWidth+1) - 1) * size
+}
+
+// drawText draws s with its top left corner at x, y, with each font pixel drawn as a size by size square. Text is drawn
+// in white with a dark shadow so it can be read over any map.
+func drawText(img *image.RGBA, x, y int, s string, size int) {
+	drawGlyphs(img, x+size, y+size, s, size, shadowColor)
+	drawGlyphs(img, x, y, s, size, textColor)
+}
+
+func drawGlyphs(img *image.RGBA, x, y int, s string, size int, c color.RGBA) {
+	for _, r := range s {
+		g := glyph(r)
+
+		for gy, row := range g {
+			for gx, p := range row {
+				if p != '#' {
+					continue
+				}
+
+				for py := 0; py < size; py++ {
+					for px := 0; px < size; px++ {
+						if pt := image.Pt(x+gx*size+px, y+gy*size+py); pt.In(img.Bounds()) {
+							img.SetRGBA(pt.X, pt.Y, c)
+						}
+					}
+				}
+			}
+		}
+
+		x += (glyphWidth + 1) * size
+	}
+}
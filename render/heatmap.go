@@ -0,0 +1,53 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Heatmap colors, from the fewest changed blocks to the most.
+var (
+	heatmapLow  = color.RGBA{0xff, 0xe0, 0x40, 0xff}
+	heatmapHigh = color.RGBA{0xe0, 0x10, 0x10, 0xff}
+)
+
+// heatmapColor returns the color and opacity of a chunk with n changed blocks, where most is the largest number of
+// changed blocks in any chunk. Counts are compared on a log scale so that small changes are still visible next to
+// large ones.
+func heatmapColor(n, most int) (color.RGBA, float64) {
+	f := 1.0
+	if most > 1 {
+		f = math.Log(float64(n)) / math.Log(float64(most))
+	}
+
+	return blend(heatmapLow, heatmapHigh, f), 0.35 + 0.45*f
+}
+
+// drawHeatmap shades each chunk of a map of the area, enlarged by the given scale, by its number of changed blocks.
+// Changes are keyed by chunk coordinates.
+func drawHeatmap(img *image.RGBA, a Area, scale int, changes map[[2]int]int) {
+	most := 0
+	for _, n := range changes {
+		if n > most {
+			most = n
+		}
+	}
+
+	for c, n := range changes {
+		if n <= 0 {
+			continue
+		}
+
+		x, z := c[0]*chunkSize-a.MinX, c[1]*chunkSize-a.MinZ
+		r := image.Rect(x*scale, z*scale, (x+chunkSize)*scale, (z+chunkSize)*scale).Intersect(img.Bounds())
+
+		col, alpha := heatmapColor(n, most)
+
+		for py := r.Min.Y; py < r.Max.Y; py++ {
+			for px := r.Min.X; px < r.Max.X; px++ {
+				img.SetRGBA(px, py, blend(img.RGBAAt(px, py), col, alpha))
+			}
+		}
+	}
+}
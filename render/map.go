@@ -0,0 +1,434 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/danhale-git/mine/world"
+)
+
+const chunkSize = 16
+
+// Area is a rectangle of columns in a dimension, in block coordinates. Min and Max are inclusive.
+type Area struct {
+	MinX, MinZ int
+	MaxX, MaxZ int
+	Dimension  int
+}
+
+// NewArea returns the area between two corners given in any order.
+func NewArea(x1, z1, x2, z2, dimension int) Area {
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+
+	if z1 > z2 {
+		z1, z2 = z2, z1
+	}
+
+	return Area{MinX: x1, MinZ: z1, MaxX: x2, MaxZ: z2, Dimension: dimension}
+}
+
+// Bounds returns the image rectangle of the area, one pixel per block with the minimum corner at 0, 0.
+func (a Area) Bounds() image.Rectangle {
+	return image.Rect(0, 0, a.MaxX-a.MinX+1, a.MaxZ-a.MinZ+1)
+}
+
+// Renderer draws top down maps of worlds. It keeps the image of each chunk it draws, keyed by the content of the
+// chunk's sub chunk records, so drawing the same area again, or drawing it from a later backup of the world, only
+// redraws the chunks which changed.
+type Renderer struct {
+	// Mode selects how columns are colored. The default colors each column by its surface block.
+	Mode Mode
+	// Palette replaces the colors of the blocks it holds. It must not be changed after drawing, as drawn chunks are
+	// reused.
+	Palette Palette
+	// ContourInterval draws elevation contour lines at every multiple of this many blocks. 0 draws no contours.
+	ContourInterval int
+	// Caves darkens each column by the fraction of air below its surface, showing where caves are.
+	Caves bool
+	// Surface changes how the surface of each column is found. If nil, the DefaultSurface of the dimension is used.
+	Surface *world.SurfaceOptions
+	// Order is the order in which the chunks of an area are read and drawn. The default draws columns of chunks from
+	// west to east.
+	Order world.ScanOrder
+	// Workers is the number of chunks read and drawn at once. Values below 1 draw one chunk at a time. The image is
+	// the same whatever the number of workers.
+	Workers int
+
+	tiles map[uint64]*chunkTile
+	mu    sync.Mutex // Guards tiles and the counters
+
+	// Drawn and Reused count the chunks drawn and the chunks taken from the cache since the renderer was created
+	Drawn, Reused int
+}
+
+// chunkTile is the image of a chunk and the data used to draw overlays on it.
+type chunkTile struct {
+	img     *image.RGBA
+	surface *[chunkSize][chunkSize]world.Block
+	caves   *[chunkSize][chunkSize]float64 // Only read when cave shading is first needed
+}
+
+// NewRenderer returns a Renderer with an empty chunk cache.
+func NewRenderer() *Renderer {
+	return &Renderer{tiles: make(map[uint64]*chunkTile)}
+}
+
+// voidColor is the color of columns with no saved blocks. In the End they are transparent, as the void is most of the
+// dimension.
+var voidColor = color.RGBA{A: 0xff}
+
+// Dimension IDs with their own rendering defaults.
+const (
+	netherDimension = int(world.Nether)
+	endDimension    = int(world.End)
+)
+
+// dimensionVoidColor returns the color of columns with no saved blocks in a dimension.
+func dimensionVoidColor(dimension int) color.RGBA {
+	if dimension == endDimension {
+		return color.RGBA{}
+	}
+
+	return voidColor
+}
+
+// DefaultSurface returns the surface options which make sensible maps of a dimension. In the Nether the surface is the
+// floor below the bedrock roof, as the roof is all that can be seen from above.
+func DefaultSurface(dimension int) world.SurfaceOptions {
+	return world.SurfaceOptions{BelowCeiling: dimension == netherDimension}
+}
+
+// surface returns the surface options used for a dimension.
+func (r *Renderer) surface(dimension int) world.SurfaceOptions {
+	if r.Surface != nil {
+		return *r.Surface
+	}
+
+	return DefaultSurface(dimension)
+}
+
+// contourColor is the color of contour lines, which are blended over the map.
+var contourColor = color.RGBA{0x3b, 0x24, 0x10, 0xff}
+
+// noHeight is the height of columns with no saved blocks.
+const noHeight = math.MinInt32
+
+// Map draws the area from above, one pixel per column, with north at the top. Each block is shaded by its height
+// relative to the block to its north, as on in-game maps, and the renderer's overlays are drawn over the result.
+func (r *Renderer) Map(w *world.World, a Area) (*image.RGBA, error) {
+	img := image.NewRGBA(a.Bounds())
+	draw.Draw(img, img.Bounds(), image.NewUniform(dimensionVoidColor(a.Dimension)), image.Point{}, draw.Src)
+
+	width, height := a.Bounds().Dx(), a.Bounds().Dy()
+
+	heights := make([]int, width*height)
+	for i := range heights {
+		heights[i] = noHeight
+	}
+
+	// Each chunk is drawn to its own pixels, so chunks can be drawn in parallel
+	region := world.Selection{Min: [3]int{a.MinX, 0, a.MinZ}, Max: [3]int{a.MaxX, 0, a.MaxZ}, Dimension: a.Dimension}
+	tiler := world.Tiler{Size: 1, Order: r.Order}
+
+	workers := r.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	err := tiler.Run(region, workers, func(t world.Tile) error {
+		c := t.Chunks[0]
+
+		tile, err := r.chunk(w, c.X, c.Z, a.Dimension)
+		if err != nil || tile == nil {
+			return err
+		}
+
+		at := image.Pt(c.X*chunkSize-a.MinX, c.Z*chunkSize-a.MinZ)
+		draw.Draw(img, tile.img.Bounds().Add(at), tile.img, image.Point{}, draw.Src)
+
+		// The part of the chunk inside the area, in chunk coordinates
+		x0, x1 := maxInt(-at.X, 0), minInt(width-at.X, chunkSize)
+		z0, z1 := maxInt(-at.Y, 0), minInt(height-at.Y, chunkSize)
+
+		for z := z0; z < z1; z++ {
+			pz := at.Y + z
+			row := img.Pix[pz*img.Stride : (pz+1)*img.Stride]
+
+			for x := x0; x < x1; x++ {
+				if tile.surface[x][z].ID == "" {
+					continue
+				}
+
+				px := at.X + x
+				heights[pz*width+px] = tile.surface[x][z].Y
+
+				if r.Caves {
+					p := row[4*px : 4*px+4]
+					c := shadeColor(color.RGBA{p[0], p[1], p[2], p[3]}, 1-0.7*tile.caves[x][z])
+					p[0], p[1], p[2], p[3] = c.R, c.G, c.B, c.A
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if r.ContourInterval > 0 {
+		drawContours(img, heights, r.ContourInterval)
+	}
+
+	return img, nil
+}
+
+// drawContours draws a line on the higher side of every boundary between columns in different elevation bands.
+func drawContours(img *image.RGBA, heights []int, interval int) {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+
+	band := func(x, z int) (int, bool) {
+		if x < 0 || z < 0 || x >= width || z >= height || heights[z*width+x] == noHeight {
+			return 0, false
+		}
+		return floorDiv(heights[z*width+x], interval), true
+	}
+
+	for z := 0; z < height; z++ {
+		for x := 0; x < width; x++ {
+			b, ok := band(x, z)
+			if !ok {
+				continue
+			}
+
+			for _, n := range [][2]int{{x - 1, z}, {x + 1, z}, {x, z - 1}, {x, z + 1}} {
+				if nb, ok := band(n[0], n[1]); ok && nb < b {
+					img.SetRGBA(x, z, blend(img.RGBAAt(x, z), contourColor, 0.6))
+					break
+				}
+			}
+		}
+	}
+}
+
+// surfaceKey returns a value distinguishing surface options in the tile cache key.
+func surfaceKey(opts world.SurfaceOptions) uint64 {
+	var k uint64
+	if opts.BelowCeiling {
+		k |= 1 << 8
+	}
+	if opts.MaxY != nil {
+		k |= 1<<9 | uint64(uint32(*opts.MaxY))<<16
+	}
+
+	return k * 0x94d049bb133111eb
+}
+
+// chunk returns the tile of a chunk, or nil if the chunk is not saved.
+func (r *Renderer) chunk(w *world.World, cx, cz, dimension int) (*chunkTile, error) {
+	digest, ok, err := w.ChunkDigest(cx, cz, dimension)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	opts := r.surface(dimension)
+
+	// The digest only covers chunk data, so the chunk position, dimension, render mode and surface options are mixed in
+	key := digest ^ uint64(uint32(cx))*0x9e3779b97f4a7c15 ^ uint64(uint32(cz))*0xc2b2ae3d27d4eb4f ^ uint64(dimension) ^
+		uint64(r.Mode)<<56 ^ surfaceKey(opts)
+
+	r.mu.Lock()
+	tile, ok := r.tiles[key]
+	if ok {
+		r.Reused++
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		surface, err := w.ChunkSurfaceWith(cx, cz, dimension, opts)
+		if err != nil {
+			return nil, fmt.Errorf("getting surface of chunk %d %d: %w", cx, cz, err)
+		}
+
+		var biomes *[chunkSize][chunkSize]int
+		if r.Mode != BlockMode {
+			if biomes, _, err = w.ChunkBiomes(cx, cz, dimension, surface); err != nil {
+				return nil, fmt.Errorf("getting biomes of chunk %d %d: %w", cx, cz, err)
+			}
+		}
+
+		img := drawSurface(surface, biomes, r.Mode, r.Palette, dimensionVoidColor(dimension))
+		tile = &chunkTile{img: img, surface: surface}
+
+		r.mu.Lock()
+		r.tiles[key] = tile
+		r.Drawn++
+		r.mu.Unlock()
+	}
+
+	if r.Caves && tile.caves == nil {
+		if tile.caves, err = w.ChunkCaveAir(cx, cz, dimension, tile.surface); err != nil {
+			return nil, fmt.Errorf("getting caves of chunk %d %d: %w", cx, cz, err)
+		}
+	}
+
+	return tile, nil
+}
+
+// Shades of a column's color, by the height of the column relative to the column to its north.
+const (
+	shadeLevel = iota
+	shadeRaised
+	shadeLowered
+)
+
+// columnShades are the factors columns are shaded by, indexed by shade.
+var columnShades = [...]float64{shadeLevel: 1, shadeRaised: 1.1, shadeLowered: 0.85}
+
+// surfaceColor is a color used by a chunk's surface in each of its shades.
+type surfaceColor struct {
+	id     string
+	biome  int
+	shades [len(columnShades)]color.RGBA
+}
+
+// surfacePalette is the distinct colors of a chunk's surface, so that each color is looked up and shaded once per
+// chunk rather than once per column. Chunks have few distinct surface blocks, so colors are found by a linear search
+// starting from the last color found.
+type surfacePalette struct {
+	colors []surfaceColor
+	last   int
+	blocks Palette // The colors replacing the built in block colors
+}
+
+// color returns the shades of a block in a biome, adding them to the palette if needed.
+func (p *surfacePalette) color(b world.Block, biomes *[chunkSize][chunkSize]int, x, z int, mode Mode) *surfaceColor {
+	biome := 0
+	if biomes != nil {
+		biome = biomes[x][z]
+	}
+
+	for i := range p.colors {
+		j := (p.last + i) % len(p.colors)
+		if c := &p.colors[j]; c.id == b.ID && c.biome == biome {
+			p.last = j
+			return c
+		}
+	}
+
+	c := p.blocks.Color(b.ID)
+	if biomes != nil {
+		c = columnColor(b, biome, mode, p.blocks)
+	}
+
+	sc := surfaceColor{id: b.ID, biome: biome}
+	for i, f := range columnShades {
+		sc.shades[i] = shadeColor(c, f)
+	}
+
+	p.colors = append(p.colors, sc)
+	p.last = len(p.colors) - 1
+
+	return &p.colors[p.last]
+}
+
+// drawSurface draws one chunk's surface. Only blocks within the chunk are used for shading, so that a chunk's image
+// doesn't depend on its neighbours and can be reused while they change. The northern row isn't shaded. Biomes may be
+// nil, in which case columns are colored by block whatever the mode. Columns with no surface are drawn in the void color.
+func drawSurface(s *[chunkSize][chunkSize]world.Block, biomes *[chunkSize][chunkSize]int, mode Mode, p Palette,
+	void color.RGBA) *image.RGBA {
+	tile := image.NewRGBA(image.Rect(0, 0, chunkSize, chunkSize))
+	palette := surfacePalette{colors: make([]surfaceColor, 0, 8), blocks: p}
+
+	// Pixels are written a row at a time, straight to the image's pixel data
+	for z := 0; z < chunkSize; z++ {
+		row := tile.Pix[z*tile.Stride : z*tile.Stride+4*chunkSize]
+
+		for x := 0; x < chunkSize; x++ {
+			c := void
+
+			if b := s[x][z]; b.ID != "" {
+				shade := shadeLevel
+				if z > 0 && s[x][z-1].ID != "" {
+					switch north := s[x][z-1].Y; {
+					case b.Y > north:
+						shade = shadeRaised
+					case b.Y < north:
+						shade = shadeLowered
+					}
+				}
+
+				c = palette.color(b, biomes, x, z, mode).shades[shade]
+			}
+
+			row[4*x], row[4*x+1], row[4*x+2], row[4*x+3] = c.R, c.G, c.B, c.A
+		}
+	}
+
+	return tile
+}
+
+// shadeColor multiplies each channel of c by f, clamping at 255.
+func shadeColor(c color.RGBA, f float64) color.RGBA {
+	scale := func(v uint8) uint8 {
+		return uint8(math.Min(255, math.Round(float64(v)*f)))
+	}
+
+	return color.RGBA{scale(c.R), scale(c.G), scale(c.B), c.A}
+}
+
+// floorDiv divides rounding towards negative infinity, to find the chunk containing a block coordinate.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+
+	return q
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Scale returns img enlarged by the given factor with each pixel drawn as a square block.
+func Scale(img *image.RGBA, factor int) *image.RGBA {
+	if factor <= 1 {
+		return img
+	}
+
+	b := img.Bounds()
+	scaled := image.NewRGBA(image.Rect(0, 0, b.Dx()*factor, b.Dy()*factor))
+
+	// Each source row is enlarged once and copied to the rows below it
+	for y := 0; y < b.Dy(); y++ {
+		row := scaled.Pix[y*factor*scaled.Stride : (y*factor+1)*scaled.Stride]
+		src := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):]
+
+		for x := 0; x < b.Dx()*factor; x++ {
+			copy(row[4*x:4*x+4], src[4*(x/factor):4*(x/factor)+4])
+		}
+
+		for i := 1; i < factor; i++ {
+			copy(scaled.Pix[(y*factor+i)*scaled.Stride:], row)
+		}
+	}
+
+	return scaled
+}
@@ -0,0 +1,257 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/world"
+)
+
+func TestMap(t *testing.T) {
+	w := world.NewFromDB(mock.ValidLevelDB())
+	r := NewRenderer()
+
+	a := NewArea(8, 8, -8, -8, 0)
+	if a.MinX != -8 || a.MaxZ != 8 || a.Bounds().Dx() != 17 {
+		t.Fatalf("unexpected area: %+v", a)
+	}
+
+	img, err := r.Map(w, a)
+	if err != nil {
+		t.Fatalf("unexpected error rendering map: %s", err)
+	}
+
+	if img.Bounds() != a.Bounds() {
+		t.Errorf("expected bounds %v: got %v", a.Bounds(), img.Bounds())
+	}
+
+	if img.RGBAAt(0, 0) == voidColor {
+		t.Errorf("expected saved chunks to be drawn")
+	}
+
+	// The area covers 4 chunks, which are identical in content but in different places
+	if r.Drawn != 4 || r.Reused != 0 {
+		t.Errorf("expected 4 chunks drawn: got %d drawn %d reused", r.Drawn, r.Reused)
+	}
+
+	if _, err := r.Map(w, a); err != nil {
+		t.Fatalf("unexpected error rendering map again: %s", err)
+	}
+
+	if r.Drawn != 4 || r.Reused != 4 {
+		t.Errorf("expected 4 chunks reused: got %d drawn %d reused", r.Drawn, r.Reused)
+	}
+
+	parallel := NewRenderer()
+	parallel.Workers = 4
+	parallel.Caves = true
+
+	r.Caves = true
+	want, _ := r.Map(w, a)
+
+	got, err := parallel.Map(w, a)
+	if err != nil {
+		t.Fatalf("unexpected error rendering map with 4 workers: %s", err)
+	}
+	if !bytes.Equal(got.Pix, want.Pix) {
+		t.Error("expected the same map with 4 workers")
+	}
+}
+
+func TestDrawSurface(t *testing.T) {
+	s := &[chunkSize][chunkSize]world.Block{}
+	s[0][0] = world.Block{ID: "minecraft:stone", Y: 64}
+	s[0][1] = world.Block{ID: "minecraft:stone", Y: 65}
+	s[0][2] = world.Block{ID: "minecraft:stone", Y: 60}
+
+	tile := drawSurface(s, nil, BlockMode, nil, voidColor)
+	stone := BlockColor("minecraft:stone")
+
+	for _, c := range []struct {
+		z    int
+		want color.RGBA
+	}{
+		{0, stone},
+		{1, shadeColor(stone, 1.1)},
+		{2, shadeColor(stone, 0.85)},
+		{3, voidColor},
+	} {
+		if got := tile.RGBAAt(0, c.z); got != c.want {
+			t.Errorf("z %d: expected %v: got %v", c.z, c.want, got)
+		}
+	}
+}
+
+func TestFloorDiv(t *testing.T) {
+	for _, c := range [][3]int{{0, 16, 0}, {15, 16, 0}, {16, 16, 1}, {-1, 16, -1}, {-16, 16, -1}, {-17, 16, -2}} {
+		if got := floorDiv(c[0], c[1]); got != c[2] {
+			t.Errorf("floorDiv(%d, %d): expected %d: got %d", c[0], c[1], c[2], got)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 3; y++ {
+			src.SetRGBA(x, y, color.RGBA{uint8(x), uint8(y), 0, 0xff})
+		}
+	}
+
+	// A sub image, which doesn't start at 0, 0
+	img := src.SubImage(image.Rect(1, 1, 4, 3)).(*image.RGBA)
+
+	scaled := Scale(img, 3)
+	if scaled.Bounds() != image.Rect(0, 0, 9, 6) {
+		t.Fatalf("expected bounds 9x6: got %v", scaled.Bounds())
+	}
+
+	for x := 0; x < 9; x++ {
+		for y := 0; y < 6; y++ {
+			if want, got := img.RGBAAt(1+x/3, 1+y/3), scaled.RGBAAt(x, y); got != want {
+				t.Errorf("pixel %d %d: expected %v: got %v", x, y, want, got)
+			}
+		}
+	}
+}
+
+func TestDrawContours(t *testing.T) {
+	// A 4x1 strip rising from 62 to 65, crossing the 64 contour between x 1 and x 2
+	img := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	draw.Draw(img, img.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+
+	drawContours(img, []int{62, 63, 64, 65}, 4)
+
+	for x, want := range []bool{false, false, true, false} {
+		if got := img.RGBAAt(x, 0) != white; got != want {
+			t.Errorf("x %d: expected contour %t: got %t", x, want, got)
+		}
+	}
+}
+
+func TestMapOverlays(t *testing.T) {
+	w := world.NewFromDB(mock.ValidLevelDB())
+	a := NewArea(0, 0, 15, 15, 0)
+
+	plain, err := NewRenderer().Map(w, a)
+	if err != nil {
+		t.Fatalf("unexpected error rendering map: %s", err)
+	}
+
+	r := NewRenderer()
+	r.Caves = true
+	r.ContourInterval = 8
+
+	shaded, err := r.Map(w, a)
+	if err != nil {
+		t.Fatalf("unexpected error rendering map with overlays: %s", err)
+	}
+
+	// Every sub chunk in the mock world is the same, so the columns have the same amount of air below the surface
+	darker := 0
+	for x := 0; x < 16; x++ {
+		p, s := plain.RGBAAt(x, 0), shaded.RGBAAt(x, 0)
+		if s.R > p.R || s.G > p.G || s.B > p.B {
+			t.Fatalf("expected cave shading to darken the map: %v is lighter than %v", s, p)
+		}
+		if s != p {
+			darker++
+		}
+	}
+
+	if darker == 0 {
+		t.Errorf("expected cave shading to darken columns with air below the surface")
+	}
+}
+
+func TestMapEndVoid(t *testing.T) {
+	w := world.NewFromDB(mock.NewLevelDB())
+
+	img, err := NewRenderer().Map(w, NewArea(0, 0, 15, 15, endDimension))
+	if err != nil {
+		t.Fatalf("unexpected error rendering map: %s", err)
+	}
+
+	if c := img.RGBAAt(0, 0); c.A != 0 {
+		t.Errorf("expected the End void to be transparent: got %v", c)
+	}
+
+	if !DefaultSurface(netherDimension).BelowCeiling || DefaultSurface(0).BelowCeiling {
+		t.Errorf("expected only the Nether to look below the ceiling by default")
+	}
+}
+
+func TestMapDeterministic(t *testing.T) {
+	w := world.NewFromDB(mock.ValidLevelDB())
+	a := NewArea(-20, -20, 20, 20, 0)
+
+	encode := func() []byte {
+		img, err := NewRenderer().Map(w, a)
+		if err != nil {
+			t.Fatalf("unexpected error rendering map: %s", err)
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatalf("unexpected error encoding map: %s", err)
+		}
+
+		return buf.Bytes()
+	}
+
+	if !bytes.Equal(encode(), encode()) {
+		t.Errorf("expected identical output rendering the same world twice")
+	}
+}
+
+// benchmarkSurface returns a chunk surface with a varied mix of blocks and heights.
+func benchmarkSurface() (*[chunkSize][chunkSize]world.Block, *[chunkSize][chunkSize]int) {
+	ids := []string{"minecraft:grass", "minecraft:water", "minecraft:oak_leaves", "minecraft:stone", "minecraft:sand"}
+	s := &[chunkSize][chunkSize]world.Block{}
+	biomes := &[chunkSize][chunkSize]int{}
+
+	for x := 0; x < chunkSize; x++ {
+		for z := 0; z < chunkSize; z++ {
+			s[x][z] = world.Block{ID: ids[(x/4+z/3)%len(ids)], Y: 60 + (x*z)%7}
+			biomes[x][z] = (x + z) % 3
+		}
+	}
+
+	return s, biomes
+}
+
+func BenchmarkDrawSurface(b *testing.B) {
+	s, biomes := benchmarkSurface()
+
+	b.Run("block", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			drawSurface(s, nil, BlockMode, nil, voidColor)
+		}
+	})
+
+	b.Run("blended", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			drawSurface(s, biomes, BlendedMode, nil, voidColor)
+		}
+	})
+}
+
+func BenchmarkMap(b *testing.B) {
+	w := world.NewFromDB(mock.ValidLevelDB())
+	a := NewArea(-64, -64, 63, 63, 0)
+
+	for i := 0; i < b.N; i++ {
+		// A new renderer each time, so every chunk is drawn rather than taken from the cache
+		r := NewRenderer()
+		r.Caves = true
+		if _, err := r.Map(w, a); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
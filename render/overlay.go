@@ -0,0 +1,220 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Overlay is the annotation drawn over a map by Draw: a heatmap of changes, chunk grid lines, coordinate labels and
+// markers.
+type Overlay struct {
+	Grid    bool // Draw a line on every chunk boundary
+	Labels  bool // Label chunk boundaries with their block coordinate along the top and left edges
+	Markers []Marker
+	// Changes shades each chunk by its number of changed blocks, keyed by chunk coordinates, as returned by
+	// world.ChunkChanges.
+	Changes map[[2]int]int
+}
+
+// Marker is a named point on a map.
+type Marker struct {
+	Name string `json:"name"`
+	X    int    `json:"x"`
+	Z    int    `json:"z"`
+	Icon string `json:"icon,omitempty"` // One of the names in markerIcons. The default is dot.
+}
+
+// ParseMarkers parses a JSON list of markers, e.g. [{"name": "Base", "x": 10, "z": -20, "icon": "house"}].
+func ParseMarkers(data []byte) ([]Marker, error) {
+	markers := make([]Marker, 0)
+	if err := json.Unmarshal(data, &markers); err != nil {
+		return nil, fmt.Errorf("parsing markers: %w", err)
+	}
+
+	for i, m := range markers {
+		if _, ok := markerIcons[m.icon()]; !ok {
+			return nil, fmt.Errorf("marker %d '%s' has invalid icon '%s': expected one of %s",
+				i, m.Name, m.Icon, strings.Join(markerIconNames(), ", "))
+		}
+	}
+
+	return markers, nil
+}
+
+// ReadMarkers reads a JSON file of markers. See ParseMarkers.
+func ReadMarkers(path string) ([]Marker, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return ParseMarkers(data)
+}
+
+func (m Marker) icon() string {
+	if m.Icon == "" {
+		return "dot"
+	}
+
+	return strings.ToLower(m.Icon)
+}
+
+// markerIcon returns whether a pixel at dx, dy from the center of an icon of the given radius is covered by the icon.
+type markerIcon func(dx, dy, r int) bool
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
+
+var markerIcons = map[string]markerIcon{
+	"dot":     func(dx, dy, r int) bool { return dx*dx+dy*dy <= r*r },
+	"square":  func(dx, dy, r int) bool { return abs(dx) <= r && abs(dy) <= r },
+	"diamond": func(dx, dy, r int) bool { return abs(dx)+abs(dy) <= r },
+	"cross":   func(dx, dy, r int) bool { return abs(dx) <= r && abs(dy) <= r && abs(abs(dx)-abs(dy)) <= r/3 },
+	"triangle": func(dx, dy, r int) bool {
+		return dy <= r && dy >= -r && 2*abs(dx) <= dy+r
+	},
+	"house": func(dx, dy, r int) bool {
+		if dy < 0 {
+			return abs(dx) <= r+dy && dy >= -r
+		}
+		return abs(dx) <= r*2/3 && dy <= r
+	},
+}
+
+func markerIconNames() []string {
+	names := make([]string, 0, len(markerIcons))
+	for n := range markerIcons {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Overlay colors.
+var (
+	gridColor   = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	textColor   = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	shadowColor = color.RGBA{A: 0xff}
+	markerColor = color.RGBA{0xe0, 0x20, 0x20, 0xff}
+)
+
+// Draw draws the overlay onto a map of the area which has been enlarged by the given scale.
+func (o Overlay) Draw(img *image.RGBA, a Area, scale int) {
+	if scale < 1 {
+		scale = 1
+	}
+
+	text := 1 + scale/4
+
+	if len(o.Changes) > 0 {
+		drawHeatmap(img, a, scale, o.Changes)
+	}
+
+	if o.Grid {
+		drawGrid(img, a, scale)
+	}
+
+	if o.Labels {
+		drawGridLabels(img, a, scale, text)
+	}
+
+	for _, m := range o.Markers {
+		drawMarker(img, a, scale, text, m)
+	}
+}
+
+// gridLines returns the image offsets of the chunk boundaries between min and max block coordinates inclusive, and
+// the block coordinate of each.
+func gridLines(min, max, scale int) (offsets, coords []int) {
+	for c := floorDiv(min+chunkSize-1, chunkSize) * chunkSize; c <= max; c += chunkSize {
+		offsets = append(offsets, (c-min)*scale)
+		coords = append(coords, c)
+	}
+
+	return
+}
+
+func drawGrid(img *image.RGBA, a Area, scale int) {
+	b := img.Bounds()
+
+	xs, _ := gridLines(a.MinX, a.MaxX, scale)
+	for _, x := range xs {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			img.SetRGBA(x, y, blend(img.RGBAAt(x, y), gridColor, 0.4))
+		}
+	}
+
+	zs, _ := gridLines(a.MinZ, a.MaxZ, scale)
+	for _, y := range zs {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.SetRGBA(x, y, blend(img.RGBAAt(x, y), gridColor, 0.4))
+		}
+	}
+}
+
+// drawGridLabels labels chunk boundaries with x coordinates along the top edge and z coordinates along the left edge.
+// Labels which would overlap the previous one are skipped.
+func drawGridLabels(img *image.RGBA, a Area, scale, size int) {
+	next := 0
+	xs, coords := gridLines(a.MinX, a.MaxX, scale)
+	for i, x := range xs {
+		if x+2 < next {
+			continue
+		}
+		s := strconv.Itoa(coords[i])
+		drawText(img, x+2, 2, s, size)
+		next = x + 2 + textWidth(s, size) + 2*size
+	}
+
+	next = 0
+	zs, coords := gridLines(a.MinZ, a.MaxZ, scale)
+	for i, y := range zs {
+		if y+2 < next {
+			continue
+		}
+		drawText(img, 2, y+2, strconv.Itoa(coords[i]), size)
+		next = y + 2 + glyphHeight*size + 2*size
+	}
+}
+
+func drawMarker(img *image.RGBA, a Area, scale, size int, m Marker) {
+	icon, ok := markerIcons[m.icon()]
+	if !ok {
+		return
+	}
+
+	cx, cy := (m.X-a.MinX)*scale+scale/2, (m.Z-a.MinZ)*scale+scale/2
+	r := 2 + size*2
+
+	for dy := -r - 1; dy <= r+1; dy++ {
+		for dx := -r - 1; dx <= r+1; dx++ {
+			p := image.Pt(cx+dx, cy+dy)
+			if !p.In(img.Bounds()) {
+				continue
+			}
+
+			switch {
+			case icon(dx, dy, r):
+				img.SetRGBA(p.X, p.Y, markerColor)
+			case icon(dx, dy, r+1):
+				img.SetRGBA(p.X, p.Y, shadowColor)
+			}
+		}
+	}
+
+	if m.Name != "" {
+		drawText(img, cx+r+3, cy-glyphHeight*size/2, m.Name, size)
+	}
+}
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"image"
+	"testing"
+)
+
+func TestParseMarkers(t *testing.T) {
+	markers, err := ParseMarkers([]byte(`[{"name": "Base", "x": 10, "z": -20, "icon": "house"}, {"name": "Portal", "x": 1, "z": 2}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(markers) != 2 || markers[0] != (Marker{Name: "Base", X: 10, Z: -20, Icon: "house"}) || markers[1].icon() != "dot" {
+		t.Errorf("unexpected markers: %+v", markers)
+	}
+
+	if _, err := ParseMarkers([]byte(`[{"name": "Base", "icon": "castle"}]`)); err == nil {
+		t.Errorf("expected error for unknown icon")
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	a := NewArea(-8, -8, 23, 23, 0)
+	scale := 4
+
+	blank := func() *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, a.Bounds().Dx()*scale, a.Bounds().Dy()*scale))
+		for i := range img.Pix {
+			img.Pix[i] = 0x40
+		}
+		return img
+	}
+
+	img := blank()
+	Overlay{Grid: true}.Draw(img, a, scale)
+
+	// Chunk boundaries at block 0 and 16 are 8 and 24 blocks from the minimum corner
+	for _, x := range []int{8 * scale, 24 * scale} {
+		if img.RGBAAt(x, 50) == blank().RGBAAt(x, 50) {
+			t.Errorf("expected grid line at x %d", x)
+		}
+	}
+
+	if img.RGBAAt(8*scale+1, 50) != blank().RGBAAt(0, 0) {
+		t.Errorf("expected no grid line beside the chunk boundary")
+	}
+
+	img = blank()
+	Overlay{Markers: []Marker{{Name: "Base", X: 0, Z: 0}}}.Draw(img, a, scale)
+
+	center := image.Pt(8*scale+scale/2, 8*scale+scale/2)
+	if img.RGBAAt(center.X, center.Y) != markerColor {
+		t.Errorf("expected marker at %v: got %v", center, img.RGBAAt(center.X, center.Y))
+	}
+
+	img = blank()
+	Overlay{Labels: true}.Draw(img, a, scale)
+
+	changed := false
+	for x := 8 * scale; x < 8*scale+textWidth("0", 2)+2; x++ {
+		for y := 0; y < 2+glyphHeight*2; y++ {
+			changed = changed || img.RGBAAt(x, y) == textColor
+		}
+	}
+
+	if !changed {
+		t.Errorf("expected a label at the chunk boundary")
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	if w := textWidth("-16", 2); w != (3*4-1)*2 {
+		t.Errorf("expected width 22: got %d", w)
+	}
+
+	if glyph('a') != glyphs['A'] || glyph('~') != glyphs['?'] {
+		t.Errorf("expected lower case to draw as upper case and unknown runes as ?")
+	}
+}
+
+func TestOverlayHeatmap(t *testing.T) {
+	a := NewArea(0, 0, 47, 15, 0)
+	img := image.NewRGBA(image.Rect(0, 0, 96, 32))
+
+	Overlay{Changes: map[[2]int]int{{0, 0}: 1, {1, 0}: 1000, {5, 5}: 10}}.Draw(img, a, 2)
+
+	low, high := img.RGBAAt(0, 0), img.RGBAAt(32, 0)
+	if low.R == 0 || high.R == 0 {
+		t.Fatalf("expected changed chunks to be shaded: got %v and %v", low, high)
+	}
+
+	if high.G >= low.G {
+		t.Errorf("expected the chunk with more changes to be redder: got %v and %v", low, high)
+	}
+
+	if c := img.RGBAAt(64, 0); c.R != 0 || c.G != 0 || c.B != 0 {
+		t.Errorf("expected unchanged chunk not to be shaded: got %v", c)
+	}
+}
@@ -0,0 +1,94 @@
+package render
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+)
+
+// Palette maps block IDs, with or without the minecraft: prefix, to the colors they are drawn in, replacing their
+// built in colors. A nil Palette is valid and draws every block in its built in color.
+type Palette map[string]color.RGBA
+
+// LoadPalette reads a palette file. Lines hold id=color pairs, where the color is given in hex as RRGGBB or RRGGBBAA
+// with an optional leading #, and ## starts a comment. For example:
+//
+//	## Show ores brightly
+//	diamond_ore=#00ffff
+//	minecraft:gold_ore=ffd700
+func LoadPalette(r io.Reader) (Palette, error) {
+	p := make(Palette)
+	s := bufio.NewScanner(r)
+
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(strings.TrimPrefix(s.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "##") {
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("line %d: expected id=color", n)
+		}
+
+		c, err := parseHexColor(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		p[strings.TrimPrefix(strings.TrimSpace(line[:i]), "minecraft:")] = c
+	}
+
+	return p, s.Err()
+}
+
+// LoadPaletteFile reads the palette file at the given path.
+func LoadPaletteFile(path string) (Palette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening palette file: %w", err)
+	}
+	defer f.Close()
+
+	p, err := LoadPalette(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading palette file '%s': %w", path, err)
+	}
+
+	return p, nil
+}
+
+// parseHexColor parses a color given as RRGGBB or RRGGBBAA with an optional leading #.
+func parseHexColor(s string) (color.RGBA, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "#"))
+	if err != nil || (len(b) != 3 && len(b) != 4) {
+		return color.RGBA{}, fmt.Errorf("invalid color '%s': expected RRGGBB or RRGGBBAA in hex", s)
+	}
+
+	c := color.RGBA{R: b[0], G: b[1], B: b[2], A: 0xff}
+	if len(b) == 4 {
+		c.A = b[3]
+	}
+
+	return c, nil
+}
+
+// lookup returns the palette's color for a block, if it has one.
+func (p Palette) lookup(id string) (color.RGBA, bool) {
+	c, ok := p[strings.TrimPrefix(id, "minecraft:")]
+	return c, ok
+}
+
+// Color returns the color of a block seen from above: the palette's color if it has one, otherwise the block's built
+// in color.
+func (p Palette) Color(id string) color.RGBA {
+	if c, ok := p.lookup(id); ok {
+		return c
+	}
+
+	return BlockColor(id)
+}
@@ -0,0 +1,42 @@
+package render
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/danhale-git/mine/world"
+)
+
+func TestLoadPalette(t *testing.T) {
+	file := "## Show ores brightly\n\ndiamond_ore=#00ffff\nminecraft:grass = 10203080\n"
+
+	p, err := LoadPalette(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if c := p.Color("minecraft:diamond_ore"); c != (color.RGBA{0x00, 0xff, 0xff, 0xff}) {
+		t.Errorf("expected diamond ore to be cyan: got %v", c)
+	}
+
+	if c := p.Color("grass"); c != (color.RGBA{0x10, 0x20, 0x30, 0x80}) {
+		t.Errorf("expected grass to take the palette's color with alpha: got %v", c)
+	}
+
+	if c := p.Color("minecraft:stone"); c != BlockColor("minecraft:stone") {
+		t.Errorf("expected a block not in the palette to keep its built in color: got %v", c)
+	}
+
+	// Blocks in the palette aren't tinted by biome
+	grass := world.Block{ID: "minecraft:grass"}
+	if c := columnColor(grass, 2, BlendedMode, p); c != p["grass"] {
+		t.Errorf("expected the palette's grass color in blended mode: got %v", c)
+	}
+
+	for _, bad := range []string{"stone", "=ffffff", "stone=fff", "stone=gggggg"} {
+		if _, err := LoadPalette(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected an error loading '%s'", bad)
+		}
+	}
+}
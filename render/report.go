@@ -0,0 +1,471 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danhale-git/mine/world"
+)
+
+// Report is the content of an HTML world report.
+type Report struct {
+	Info  *world.LevelInfo // Nil if the world has no readable level.dat
+	Stats world.Stats
+	// Thumbnails are maps of Stats.Builds in the same order. A nil thumbnail is left out of the report.
+	Thumbnails []*image.RGBA
+	// Palette replaces the colors of the blocks it holds in the chart of blocks, as it does in maps
+	Palette Palette
+}
+
+// Report limits, keeping the report readable.
+const (
+	reportTopBlocks   = 20
+	reportTopEntities = 15
+	reportTopBiomes   = 15
+
+	// maxThumbnailSize is the width and height in blocks of the largest build thumbnail.
+	maxThumbnailSize = 256
+	// dayTicks is the length of a Minecraft day in ticks.
+	dayTicks = 24000
+)
+
+// BuildArea returns the area of a build to draw as its thumbnail, at most maxThumbnailSize blocks across and centred on
+// the build.
+func BuildArea(b world.Build) Area {
+	a := NewArea(b.MinX, b.MinZ, b.MaxX, b.MaxZ, b.Dimension)
+
+	if w := a.MaxX - a.MinX + 1; w > maxThumbnailSize {
+		a.MinX += (w - maxThumbnailSize) / 2
+		a.MaxX = a.MinX + maxThumbnailSize - 1
+	}
+
+	if h := a.MaxZ - a.MinZ + 1; h > maxThumbnailSize {
+		a.MinZ += (h - maxThumbnailSize) / 2
+		a.MaxZ = a.MinZ + maxThumbnailSize - 1
+	}
+
+	return a
+}
+
+// bar is one bar of a horizontal bar chart.
+type bar struct {
+	Label   string
+	Value   int
+	Percent float64 // The bar's length as a percentage of the longest bar
+	Share   float64 // The value as a percentage of the total of all bars
+	Color   template.CSS
+}
+
+// column is one column of an ore distribution chart, in SVG user units.
+type column struct {
+	X, Y, Width, Height int
+	Title               string
+}
+
+// oreChart is the distribution of one ore by height.
+type oreChart struct {
+	Name    string
+	Total   int
+	Color   template.CSS
+	Width   int // The width of the chart in SVG user units
+	Columns []column
+	Axis    []column // Y coordinate labels, using X and Title
+}
+
+// reportBuild is a row of the builds table.
+type reportBuild struct {
+	world.Build
+	Rank      int
+	Dimension string
+	Size      string
+	Thumbnail template.URL
+}
+
+// reportPage is the data passed to the report template.
+type reportPage struct {
+	Title      string
+	Generated  string
+	Facts      [][2]string
+	Ores       []oreChart
+	Biomes     []bar
+	Blocks     []bar
+	Entities   []bar
+	Builds     []reportBuild
+	HasBiomes  bool
+	BlockTotal int
+}
+
+// WriteHTML writes the report as a single HTML file with no scripts or external resources, so it can be shared as it
+// is.
+func (r Report) WriteHTML(out io.Writer) error {
+	p, err := r.page()
+	if err != nil {
+		return err
+	}
+
+	if err := reportTemplate.Execute(out, p); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	return nil
+}
+
+// page prepares the report's charts and tables.
+func (r Report) page() (reportPage, error) {
+	s := r.Stats
+
+	p := reportPage{Title: "Minecraft world", Generated: time.Now().UTC().Format("2006-01-02 15:04 UTC")}
+
+	if r.Info != nil {
+		if r.Info.Name != "" {
+			p.Title = r.Info.Name
+		}
+
+		p.Facts = append(p.Facts,
+			[2]string{"Last played", time.Unix(r.Info.LastPlayed, 0).UTC().Format("2006-01-02 15:04 UTC")},
+			[2]string{"Days played", fmt.Sprint(r.Info.Time / dayTicks)},
+			[2]string{"Game mode", world.GameTypeName(r.Info.GameType)},
+			[2]string{"Seed", fmt.Sprint(r.Info.Seed)},
+		)
+	}
+
+	p.Facts = append(p.Facts, [2]string{"Players", fmt.Sprint(s.Players)})
+	for _, d := range sortedKeys(s.Chunks) {
+		p.Facts = append(p.Facts, [2]string{dimensionName(d) + " chunks", fmt.Sprint(s.Chunks[d])})
+	}
+
+	for _, n := range s.Blocks {
+		p.BlockTotal += n
+	}
+	p.Facts = append(p.Facts, [2]string{"Blocks", fmt.Sprint(p.BlockTotal)})
+
+	p.Ores = oreCharts(s.Ores)
+	p.Biomes = bars(s.Biomes, reportTopBiomes, biomeNameColor)
+	p.HasBiomes = len(p.Biomes) > 0
+	p.Blocks = bars(s.Blocks, reportTopBlocks, r.Palette.Color)
+	p.Entities = bars(s.Entities, reportTopEntities, derivedColor)
+
+	for i, b := range s.Builds {
+		rb := reportBuild{
+			Build:     b,
+			Rank:      i + 1,
+			Dimension: dimensionName(b.Dimension),
+			Size:      fmt.Sprintf("%d x %d", b.MaxX-b.MinX+1, b.MaxZ-b.MinZ+1),
+		}
+
+		if i < len(r.Thumbnails) && r.Thumbnails[i] != nil {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, r.Thumbnails[i]); err != nil {
+				return p, fmt.Errorf("encoding thumbnail of build %d: %w", i+1, err)
+			}
+
+			// Thumbnails are embedded so the report is a single file
+			rb.Thumbnail = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()))
+		}
+
+		p.Builds = append(p.Builds, rb)
+	}
+
+	return p, nil
+}
+
+// bars returns a bar for each of the n largest counts, largest first, with the remaining counts in a final bar.
+func bars(counts map[string]int, n int, colorOf func(string) color.RGBA) []bar {
+	names := make([]string, 0, len(counts))
+	total := 0
+	for name, c := range counts {
+		names = append(names, name)
+		total += c
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	largest := float64(counts[names[0]])
+	result := make([]bar, 0, n+1)
+	other := 0
+
+	for i, name := range names {
+		if i >= n {
+			other += counts[name]
+			continue
+		}
+
+		result = append(result, bar{
+			Label:   strings.TrimPrefix(name, "minecraft:"),
+			Value:   counts[name],
+			Percent: 100 * float64(counts[name]) / largest,
+			Share:   100 * float64(counts[name]) / float64(total),
+			Color:   cssColor(colorOf(name)),
+		})
+	}
+
+	if other > 0 {
+		result = append(result, bar{
+			Label:   fmt.Sprintf("%d others", len(names)-n),
+			Value:   other,
+			Percent: 100 * float64(other) / largest,
+			Share:   100 * float64(other) / float64(total),
+			Color:   cssColor(color.RGBA{0x99, 0x99, 0x99, 0xff}),
+		})
+	}
+
+	return result
+}
+
+// Ore chart dimensions in SVG user units.
+const (
+	oreColumnWidth  = 14
+	oreChartHeight  = 120
+	oreAxisInterval = 4 // Sub chunks between Y coordinate labels
+)
+
+// oreCharts returns a column chart for each ore of the number found in each sub chunk layer, most common ore first. All
+// charts share the same Y range so they can be compared.
+func oreCharts(ores map[string]map[int]int) []oreChart {
+	minY, maxY := 0, 0
+	first := true
+	totals := make(map[string]int)
+
+	for id, layers := range ores {
+		for y, n := range layers {
+			if first || y < minY {
+				minY = y
+			}
+			if first || y > maxY {
+				maxY = y
+			}
+			first = false
+			totals[id] += n
+		}
+	}
+
+	ids := make([]string, 0, len(totals))
+	for id := range totals {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if totals[ids[i]] != totals[ids[j]] {
+			return totals[ids[i]] > totals[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	charts := make([]oreChart, 0, len(ids))
+
+	for _, id := range ids {
+		largest := 0
+		for _, n := range ores[id] {
+			if n > largest {
+				largest = n
+			}
+		}
+
+		c := oreChart{
+			Name:  strings.TrimPrefix(id, "minecraft:"),
+			Total: totals[id],
+			Color: cssColor(oreColor(id)),
+			Width: (maxY-minY)/chunkSize*oreColumnWidth + oreColumnWidth,
+		}
+
+		for y := minY; y <= maxY; y += chunkSize {
+			x := (y - minY) / chunkSize * oreColumnWidth
+
+			if n := ores[id][y]; n > 0 {
+				h := oreChartHeight * n / largest
+				if h < 1 {
+					h = 1
+				}
+
+				c.Columns = append(c.Columns, column{
+					X: x, Y: oreChartHeight - h, Width: oreColumnWidth - 2, Height: h,
+					Title: fmt.Sprintf("Y %d to %d: %d", y, y+chunkSize-1, n),
+				})
+			}
+
+			if (y-minY)/chunkSize%oreAxisInterval == 0 {
+				c.Axis = append(c.Axis, column{X: x, Title: fmt.Sprint(y)})
+			}
+		}
+
+		charts = append(charts, c)
+	}
+
+	return charts
+}
+
+// oreColors are the colors of ores in charts, named by the mineral they hold.
+var oreColors = map[string]color.RGBA{
+	"coal":           {0x33, 0x33, 0x33, 0xff},
+	"iron":           {0xd8, 0xaf, 0x93, 0xff},
+	"copper":         {0xe0, 0x73, 0x4b, 0xff},
+	"gold":           {0xf5, 0xd0, 0x3b, 0xff},
+	"redstone":       {0xd0, 0x1b, 0x1b, 0xff},
+	"lapis":          {0x26, 0x4f, 0xbf, 0xff},
+	"diamond":        {0x4a, 0xe0, 0xd5, 0xff},
+	"emerald":        {0x17, 0xc5, 0x44, 0xff},
+	"quartz":         {0xe8, 0xe2, 0xd6, 0xff},
+	"ancient_debris": {0x65, 0x42, 0x3a, 0xff},
+}
+
+// oreColor returns the chart color of an ore ID, e.g. minecraft:deepslate_iron_ore.
+func oreColor(id string) color.RGBA {
+	for name, c := range oreColors {
+		if strings.Contains(id, name) {
+			return c
+		}
+	}
+
+	return derivedColor(id)
+}
+
+// cssColor returns a CSS color value.
+func cssColor(c color.RGBA) template.CSS {
+	return template.CSS(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))
+}
+
+// dimensionNames are the display names of dimension IDs.
+var dimensionNames = map[int]string{int(world.Overworld): "Overworld", netherDimension: "Nether", endDimension: "End"}
+
+// dimensionName returns the display name of a dimension ID.
+func dimensionName(d int) string {
+	if n, ok := dimensionNames[d]; ok {
+		return n
+	}
+
+	return fmt.Sprintf("Dimension %d", d)
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; color: #222; background: #fafafa; }
+h1 { margin-bottom: 0; }
+h2 { border-bottom: 1px solid #ccc; padding-bottom: .2em; margin-top: 2em; }
+.generated { color: #777; font-size: .9em; }
+table { border-collapse: collapse; }
+td, th { padding: .25em .75em; text-align: left; vertical-align: top; }
+.facts td:first-child { color: #555; }
+.bars td { padding: .1em .5em; }
+.bars td.label { white-space: nowrap; }
+.bars td.bar { width: 60%; }
+.bars .fill { height: 1em; min-width: 1px; border: 1px solid rgba(0,0,0,.25); }
+.num { text-align: right; font-variant-numeric: tabular-nums; }
+.ores { display: flex; flex-wrap: wrap; gap: 1.5em; }
+.ores figure { margin: 0; }
+.ores figcaption { font-size: .9em; margin-bottom: .3em; }
+.ores text { font-size: 9px; fill: #555; }
+.builds img { image-rendering: pixelated; max-width: 256px; border: 1px solid #ccc; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="generated">Report generated {{.Generated}}</p>
+
+<h2>Summary</h2>
+<table class="facts">
+{{- range .Facts}}
+<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Ore distribution</h2>
+{{- if .Ores}}
+<p>Ore blocks found in each 16 block layer, by the Y coordinate of the bottom of the layer.</p>
+<div class="ores">
+{{- range .Ores}}
+<figure>
+<figcaption><strong>{{.Name}}</strong> {{.Total}}</figcaption>
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="140" role="img" aria-label="{{.Name}} by height">
+<line x1="0" y1="120" x2="{{.Width}}" y2="120" stroke="#999"/>
+{{- $c := .Color}}
+{{- range .Columns}}
+<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="{{$c}}"><title>{{.Title}}</title></rect>
+{{- end}}
+{{- range .Axis}}
+<text x="{{.X}}" y="134">{{.Title}}</text>
+{{- end}}
+</svg>
+</figure>
+{{- end}}
+</div>
+{{- else}}
+<p>No ores were found.</p>
+{{- end}}
+
+<h2>Biomes</h2>
+{{- if .HasBiomes}}
+<p>Overworld columns by biome.</p>
+{{template "bars" .Biomes}}
+{{- else}}
+<p>No biome data was found.</p>
+{{- end}}
+
+<h2>Blocks</h2>
+{{template "bars" .Blocks}}
+
+<h2>Entities</h2>
+{{- if .Entities}}
+{{template "bars" .Entities}}
+{{- else}}
+<p>No entities were found.</p>
+{{- end}}
+
+<h2>Largest builds</h2>
+{{- if .Builds}}
+<p>Groups of chunks with many blocks usually placed by players, such as planks and glass. Villages and other generated
+structures may also be listed.</p>
+<table class="builds">
+<tr><th>#</th><th>Dimension</th><th>From</th><th>To</th><th>Size</th><th class="num">Crafted blocks</th><th></th></tr>
+{{- range .Builds}}
+<tr><td>{{.Rank}}</td><td>{{.Dimension}}</td><td>{{.MinX}} {{.MinZ}}</td><td>{{.MaxX}} {{.MaxZ}}</td><td>{{.Size}}</td>
+<td class="num">{{.Blocks}}</td>
+<td>{{if .Thumbnail}}<img src="{{.Thumbnail}}" alt="Map of build {{.Rank}}">{{end}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No builds were found.</p>
+{{- end}}
+</body>
+</html>
+{{define "bars"}}
+<table class="bars">
+{{- range .}}
+<tr><td class="label">{{.Label}}</td>
+<td class="bar"><div class="fill" style="width: {{printf "%.1f" .Percent}}%; background: {{.Color}}"></div></td>
+<td class="num">{{.Value}}</td><td class="num">{{printf "%.1f" .Share}}%</td></tr>
+{{- end}}
+</table>
+{{- end}}
+`))
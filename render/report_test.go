@@ -0,0 +1,84 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/danhale-git/mine/world"
+)
+
+func TestReport(t *testing.T) {
+	r := Report{
+		Info: &world.LevelInfo{Name: "Test <World>", Time: 48000, GameType: 1, Seed: 42},
+		Stats: world.Stats{
+			Chunks: map[int]int{0: 10, 1: 2},
+			Blocks: map[string]int{"minecraft:stone": 900, "minecraft:oak_planks": 100},
+			Ores: map[string]map[int]int{
+				"minecraft:diamond_ore": {-64: 5, -48: 1},
+				"minecraft:coal_ore":    {48: 40},
+			},
+			Biomes:   map[string]int{"plains": 3, "forest": 1},
+			Entities: map[string]int{"minecraft:cow": 2},
+			Builds:   []world.Build{{MinX: 0, MinZ: 0, MaxX: 31, MaxZ: 15, Chunks: 2, Blocks: 100}},
+		},
+		Thumbnails: []*image.RGBA{image.NewRGBA(image.Rect(0, 0, 32, 16))},
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteHTML(&buf); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	html := buf.String()
+
+	for _, want := range []string{
+		"<title>Test &lt;World&gt;</title>",
+		"<td>Days played</td><td>2</td>",
+		"<td>Game mode</td><td>creative</td>",
+		"<td>Nether chunks</td><td>2</td>",
+		"<strong>coal_ore</strong> 40",
+		"Y -64 to -49: 5",
+		"<td class=\"label\">plains</td>",
+		"<td class=\"num\">75.0%</td>",
+		"<td>32 x 16</td>",
+		`src="data:image/png;base64,`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected report to contain %q", want)
+		}
+	}
+
+	if strings.Contains(html, "<script") || strings.Contains(html, "<link") {
+		t.Errorf("expected a self contained report")
+	}
+
+	// Coal is more common so is charted first
+	if strings.Index(html, "coal_ore") > strings.Index(html, "diamond_ore") {
+		t.Errorf("expected ores to be ordered by count")
+	}
+}
+
+func TestBars(t *testing.T) {
+	b := bars(map[string]int{"a": 6, "b": 3, "c": 1}, 2, derivedColor)
+
+	if len(b) != 3 {
+		t.Fatalf("expected 2 bars and an others bar: got %d", len(b))
+	}
+
+	if b[0].Label != "a" || b[0].Percent != 100 || b[1].Percent != 50 || b[0].Share != 60 {
+		t.Errorf("unexpected bars: %+v", b[:2])
+	}
+
+	if b[2].Label != "1 others" || b[2].Value != 1 {
+		t.Errorf("unexpected others bar: %+v", b[2])
+	}
+}
+
+func TestBuildArea(t *testing.T) {
+	a := BuildArea(world.Build{MinX: 0, MinZ: 0, MaxX: 511, MaxZ: 15, Dimension: 1})
+
+	if want := (Area{MinX: 128, MinZ: 0, MaxX: 383, MaxZ: 15, Dimension: 1}); a != want {
+		t.Errorf("expected %+v: got %+v", want, a)
+	}
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// GIF assembles frames into an animated GIF which loops forever, showing each frame for delay hundredths of a second.
+// Frames are reduced to the web safe palette with dithering.
+func GIF(frames []*image.RGBA, delay int) *gif.GIF {
+	g := &gif.GIF{}
+
+	for _, f := range frames {
+		p := image.NewPaletted(f.Bounds(), palette.WebSafe)
+		draw.FloydSteinberg.Draw(p, f.Bounds(), f, f.Bounds().Min)
+
+		g.Image = append(g.Image, p)
+		g.Delay = append(g.Delay, delay)
+	}
+
+	return g
+}
+
+// WriteGIF writes frames to path as an animated GIF.
+func WriteGIF(path string, frames []*image.RGBA, delay int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+
+	if err := gif.EncodeAll(f, GIF(frames, delay)); err != nil {
+		f.Close()
+		return fmt.Errorf("encoding %s: %w", path, err)
+	}
+
+	return f.Close()
+}
+
+// WriteFrames writes each frame to dir as a numbered PNG file, frame_0000.png onwards, for assembly by other tools.
+func WriteFrames(dir string, frames []*image.RGBA) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	for i, frame := range frames {
+		path := filepath.Join(dir, fmt.Sprintf("frame_%04d.png", i))
+
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", path, err)
+		}
+
+		if err := png.Encode(f, frame); err != nil {
+			f.Close()
+			return fmt.Errorf("encoding %s: %w", path, err)
+		}
+
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("closing %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+// Package spill holds the intermediate results of operations over a whole world, such as the list of keys to visit,
+// in memory up to a budget and in a temporary file beyond it, so that huge worlds can be processed with little memory.
+package spill
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// recordOverhead is the approximate memory used by each record held in memory in addition to its bytes.
+const recordOverhead = 24
+
+// Buffer is an ordered list of records. Records are held in memory until their size exceeds the budget, after which
+// every record is written to a temporary file. A Buffer must be closed to remove the file.
+type Buffer struct {
+	budget int64
+	used   int64
+	n      int
+	mem    [][]byte
+
+	file *os.File
+	w    *bufio.Writer
+}
+
+// New returns an empty buffer which holds up to budget bytes in memory. A budget of 0 or less holds every record in
+// memory.
+func New(budget int64) *Buffer {
+	return &Buffer{budget: budget, mem: make([][]byte, 0)}
+}
+
+// Add appends a copy of the record to the buffer.
+func (b *Buffer) Add(record []byte) error {
+	b.n++
+
+	if b.file != nil {
+		return b.write(record)
+	}
+
+	b.mem = append(b.mem, append([]byte{}, record...))
+	b.used += int64(len(record)) + recordOverhead
+
+	if b.budget > 0 && b.used > b.budget {
+		return b.spill()
+	}
+
+	return nil
+}
+
+// spill moves the records held in memory to a new temporary file.
+func (b *Buffer) spill() error {
+	f, err := os.CreateTemp("", "mine-spill-*")
+	if err != nil {
+		return fmt.Errorf("creating spill file: %w", err)
+	}
+
+	b.file = f
+	b.w = bufio.NewWriter(f)
+
+	for _, r := range b.mem {
+		if err := b.write(r); err != nil {
+			return err
+		}
+	}
+
+	b.mem, b.used = nil, 0
+
+	return nil
+}
+
+// write appends a length prefixed record to the file.
+func (b *Buffer) write(record []byte) error {
+	prefix := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(prefix, uint64(len(record)))
+
+	if _, err := b.w.Write(prefix[:n]); err != nil {
+		return fmt.Errorf("writing spill file: %w", err)
+	}
+	if _, err := b.w.Write(record); err != nil {
+		return fmt.Errorf("writing spill file: %w", err)
+	}
+
+	return nil
+}
+
+// Len returns the number of records in the buffer.
+func (b *Buffer) Len() int {
+	return b.n
+}
+
+// Spilled returns true if the records have been moved to a temporary file.
+func (b *Buffer) Spilled() bool {
+	return b.file != nil
+}
+
+// Each calls f with every record in the order they were added, stopping at the first error. The record passed to f is
+// only valid until f returns. Records may not be added while Each is running.
+func (b *Buffer) Each(f func(record []byte) error) error {
+	if b.file == nil {
+		for _, r := range b.mem {
+			if err := f(r); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := b.w.Flush(); err != nil {
+		return fmt.Errorf("writing spill file: %w", err)
+	}
+
+	// The file is read through a separate reader so that the write offset is kept for later records
+	r := bufio.NewReader(io.NewSectionReader(b.file, 0, 1<<62))
+	record := make([]byte, 0)
+
+	for i := 0; i < b.n; i++ {
+		size, err := binary.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("reading spill file: %w", err)
+		}
+
+		if uint64(cap(record)) < size {
+			record = make([]byte, size)
+		}
+		record = record[:size]
+
+		if _, err := io.ReadFull(r, record); err != nil {
+			return fmt.Errorf("reading spill file: %w", err)
+		}
+
+		if err := f(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close releases the records and removes the temporary file, if any.
+func (b *Buffer) Close() error {
+	b.mem = nil
+
+	if b.file == nil {
+		return nil
+	}
+
+	name := b.file.Name()
+	err := b.file.Close()
+	b.file = nil
+
+	if rerr := os.Remove(name); rerr != nil && !errors.Is(rerr, os.ErrNotExist) && err == nil {
+		err = rerr
+	}
+
+	return err
+}
@@ -0,0 +1,96 @@
+package spill
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	for _, budget := range []int64{0, 1000, 1} {
+		b := New(budget)
+
+		want := make([][]byte, 0)
+		for i := 0; i < 500; i++ {
+			r := []byte(fmt.Sprintf("record %d", i))
+			if i == 7 {
+				r = []byte{}
+			}
+			want = append(want, r)
+
+			if err := b.Add(r); err != nil {
+				t.Fatalf("budget %d: unexpected error adding record: %s", budget, err)
+			}
+		}
+
+		if b.Len() != len(want) {
+			t.Errorf("budget %d: expected %d records: got %d", budget, len(want), b.Len())
+		}
+
+		if spilled := budget > 0; b.Spilled() != spilled {
+			t.Errorf("budget %d: expected spilled to be %t", budget, spilled)
+		}
+
+		// Each may be called more than once, and records may be added between calls
+		for pass := 0; pass < 2; pass++ {
+			i := 0
+			err := b.Each(func(r []byte) error {
+				if !bytes.Equal(r, want[i]) {
+					t.Errorf("budget %d: record %d: expected %q: got %q", budget, i, want[i], r)
+				}
+				i++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("budget %d: unexpected error reading records: %s", budget, err)
+			}
+
+			if i != len(want) {
+				t.Errorf("budget %d: expected %d records read: got %d", budget, len(want), i)
+			}
+
+			want = append(want, []byte("late"))
+			if err := b.Add([]byte("late")); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		name := ""
+		if b.file != nil {
+			name = b.file.Name()
+		}
+
+		if err := b.Close(); err != nil {
+			t.Errorf("budget %d: unexpected error closing: %s", budget, err)
+		}
+
+		if name != "" {
+			if _, err := os.Stat(name); !os.IsNotExist(err) {
+				t.Errorf("budget %d: expected spill file to be removed", budget)
+			}
+		}
+	}
+}
+
+func TestBufferEachError(t *testing.T) {
+	b := New(1)
+	defer b.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := b.Add([]byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stop := fmt.Errorf("stop")
+	n := 0
+	err := b.Each(func(r []byte) error {
+		n++
+		return stop
+	})
+
+	if err != stop || n != 1 {
+		t.Errorf("expected Each to stop at the first error: got %v after %d records", err, n)
+	}
+}
@@ -0,0 +1,224 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command wasm is a world inspector which runs in a browser. An .mcworld file chosen on the page is read entirely on
+// the client, so the world is never uploaded anywhere. The functions of the global mine object are called by
+// index.html:
+//
+//	mine.open(bytes)                     reads an .mcworld file given as a Uint8Array
+//	mine.block(x, y, z, dimension)       returns the block at a position
+//	mine.surface(x, z, dimension)        returns the highest block which is not air in a column
+//	mine.counts(cx, cz, dimension)       returns the number of each block in a chunk
+//	mine.map(x1, z1, x2, z2, dimension)  draws a map of an area as a PNG, returned as a Uint8Array
+//
+// Each returns an object with an error field if it fails. Build the inspector and copy the Go WebAssembly support
+// script next to it, from lib/wasm in Go 1.24 and later or misc/wasm before that, then serve the directory with any
+// static file server:
+//
+//	GOOS=js GOARCH=wasm go build -o wasm/mine.wasm ./wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" wasm/
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"syscall/js"
+
+	"github.com/danhale-git/mine/render"
+	"github.com/danhale-git/mine/world"
+)
+
+// maxMapBlocks is the largest area, in blocks, which may be drawn at once, so the page can't run out of memory.
+const maxMapBlocks = 1024 * 1024
+
+// errNoWorld is returned by functions called before a world is opened.
+var errNoWorld = errors.New("no world is open")
+
+var (
+	w        *world.World
+	renderer = render.NewRenderer()
+)
+
+func main() {
+	js.Global().Set("mine", js.ValueOf(map[string]interface{}{
+		"open":    function(open),
+		"block":   function(block),
+		"surface": function(surface),
+		"counts":  function(counts),
+		"map":     function(drawMap),
+	}))
+
+	// The functions are called by the page for as long as it is open
+	select {}
+}
+
+// function wraps f as a JavaScript function, returning its error as an object with an error field.
+func function(f func(args []js.Value) (interface{}, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		v, err := f(args)
+		if err != nil {
+			return map[string]interface{}{"error": err.Error()}
+		}
+
+		return v
+	})
+}
+
+// ints returns the first n arguments as integers.
+func ints(args []js.Value, n int) ([]int, error) {
+	if len(args) < n {
+		return nil, fmt.Errorf("expected %d arguments: got %d", n, len(args))
+	}
+
+	v := make([]int, n)
+	for i := range v {
+		if args[i].Type() != js.TypeNumber {
+			return nil, fmt.Errorf("argument %d is a %s: expected a number", i+1, args[i].Type())
+		}
+		v[i] = args[i].Int()
+	}
+
+	return v, nil
+}
+
+// open reads the .mcworld file in a Uint8Array, replacing any open world. It returns the world's name and the chunks
+// which have terrain saved, each as an array of x, z and dimension.
+func open(args []js.Value) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, errors.New("expected the content of an .mcworld file")
+	}
+
+	data := make([]byte, args[0].Get("length").Int())
+	js.CopyBytesToGo(data, args[0])
+
+	opened, err := world.ReadMCWorld(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, err := opened.Chunks()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := opened.Name()
+	if err != nil {
+		name = "unnamed world"
+	}
+
+	w, renderer = opened, render.NewRenderer()
+
+	positions := make([]interface{}, len(chunks))
+	for i, c := range chunks {
+		positions[i] = []interface{}{c.X, c.Z, c.Dimension}
+	}
+
+	return map[string]interface{}{"name": name, "chunks": positions}, nil
+}
+
+// blockValue returns the ID, position and block states of a block.
+func blockValue(b world.Block) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          b.ID,
+		"x":           b.X,
+		"y":           b.Y,
+		"z":           b.Z,
+		"states":      b.StatesString(),
+		"waterlogged": b.IsWaterlogged(),
+	}
+}
+
+func block(args []js.Value) (interface{}, error) {
+	if w == nil {
+		return nil, errNoWorld
+	}
+
+	v, err := ints(args, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := w.GetBlock(v[0], v[1], v[2], v[3])
+	if err != nil {
+		return nil, err
+	}
+
+	return blockValue(b), nil
+}
+
+func surface(args []js.Value) (interface{}, error) {
+	if w == nil {
+		return nil, errNoWorld
+	}
+
+	v, err := ints(args, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok, err := w.SurfaceBlock(v[0], v[1], v[2])
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no blocks are saved at %d %d", v[0], v[1])
+	}
+
+	return blockValue(b), nil
+}
+
+func counts(args []js.Value) (interface{}, error) {
+	if w == nil {
+		return nil, errNoWorld
+	}
+
+	v, err := ints(args, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := w.ChunkCounts(v[0], v[1], v[2])
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]interface{}, len(c))
+	for id, n := range c {
+		counts[id] = n
+	}
+
+	return counts, nil
+}
+
+func drawMap(args []js.Value) (interface{}, error) {
+	if w == nil {
+		return nil, errNoWorld
+	}
+
+	v, err := ints(args, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	a := render.NewArea(v[0], v[1], v[2], v[3], v[4])
+	if size := a.Bounds().Dx() * a.Bounds().Dy(); size > maxMapBlocks {
+		return nil, fmt.Errorf("area of %d blocks is too large: the most is %d", size, maxMapBlocks)
+	}
+
+	img, err := renderer.Map(w, a)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	dst := js.Global().Get("Uint8Array").New(buf.Len())
+	js.CopyBytesToJS(dst, buf.Bytes())
+
+	return dst, nil
+}
@@ -0,0 +1,127 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/danhale-git/mine/nbt"
+	"github.com/danhale-git/nbt2json"
+)
+
+// Annotation labels a span of bytes in a raw record.
+type Annotation struct {
+	Offset, Length int
+	Label          string
+}
+
+// AnnotateSubChunk walks raw sub chunk data and labels each structural element: the version byte, storage count,
+// bits per block, each index word and each palette NBT entry. If the data can't be fully parsed the annotations found
+// so far are returned along with the error, so the point where the format diverged is visible.
+func AnnotateSubChunk(data []byte) ([]Annotation, error) {
+	r := bytes.NewReader(data)
+	a := make([]Annotation, 0)
+
+	offset := func() int {
+		return int(r.Size()) - r.Len()
+	}
+
+	add := func(start int, format string, args ...interface{}) {
+		a = append(a, Annotation{
+			Offset: start,
+			Length: offset() - start,
+			Label:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	var version int8
+	if err := readLittleEndian(r, &version); err != nil {
+		return a, fmt.Errorf("reading version byte: %w", err)
+	}
+	add(0, "version %d", version)
+
+	var storageCount int8
+
+	switch version {
+	case 1:
+		storageCount = 1
+	case 8, 9:
+		start := offset()
+		if err := readLittleEndian(r, &storageCount); err != nil {
+			return a, fmt.Errorf("reading storage count: %w", err)
+		}
+		add(start, "storage count %d", storageCount)
+
+		if version == 9 {
+			start = offset()
+			var yIndex int8
+			if err := readLittleEndian(r, &yIndex); err != nil {
+				return a, fmt.Errorf("reading sub chunk index: %w", err)
+			}
+			add(start, "sub chunk index %d", yIndex)
+		}
+	default:
+		return a, &UnsupportedVersionError{Format: "sub chunk", Version: int(version)}
+	}
+
+	for s := 0; s < int(storageCount); s++ {
+		start := offset()
+		var bitsPerBlockAndVersion byte
+		if err := readLittleEndian(r, &bitsPerBlockAndVersion); err != nil {
+			return a, fmt.Errorf("reading storage %d bits per block: %w", s, err)
+		}
+
+		bitsPerBlock := int(bitsPerBlockAndVersion >> 1)
+		add(start, "storage %d: %d bits per block, storage version %d", s, bitsPerBlock, bitsPerBlockAndVersion&1)
+
+		// A storage of one block has no index words, as every block is the only palette entry
+		blocksPerWord, wordCount := 0, 0
+		if bitsPerBlock > 0 {
+			blocksPerWord = 32 / bitsPerBlock
+			wordCount = (subChunkBlockCount + blocksPerWord - 1) / blocksPerWord
+		}
+
+		for w := 0; w < wordCount; w++ {
+			start = offset()
+			var word int32
+			if err := readLittleEndian(r, &word); err != nil {
+				return a, fmt.Errorf("reading storage %d word %d: %w", s, w, err)
+			}
+			add(start, "storage %d: word %d (blocks %d-%d)",
+				s, w, w*blocksPerWord, minInt((w+1)*blocksPerWord, subChunkBlockCount)-1)
+		}
+
+		start = offset()
+		var paletteSize int32
+		if err := readLittleEndian(r, &paletteSize); err != nil {
+			return a, fmt.Errorf("reading storage %d palette size: %w", s, err)
+		}
+		add(start, "storage %d: palette size %d", s, paletteSize)
+
+		for p := 0; p < int(paletteSize); p++ {
+			start = offset()
+			j, err := nbt2json.ReadNbt2Json(r, "", 1)
+			if err != nil {
+				return a, fmt.Errorf("reading storage %d palette entry %d: %w", s, p, err)
+			}
+
+			nbtData := struct {
+				NBT []nbt.NBTTag
+			}{}
+			if err := json.Unmarshal(j, &nbtData); err != nil {
+				return a, fmt.Errorf("unmarshaling storage %d palette entry %d: %w", s, p, err)
+			}
+
+			add(start, "storage %d: palette %d NBT %s", s, p, nbtData.NBT[0].BlockID())
+		}
+	}
+
+	if r.Len() > 0 {
+		start := offset()
+		_, _ = r.Seek(0, io.SeekEnd)
+		add(start, "trailing bytes")
+	}
+
+	return a, nil
+}
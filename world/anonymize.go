@@ -0,0 +1,155 @@
+package world
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// AnonymizeReport counts what Anonymize removed from a world.
+type AnonymizeReport struct {
+	Players     int // Player records deleted
+	Signs       int // Signs whose text was cleared
+	Books       int // Books whose pages, title and author were removed
+	Names       int // Custom names removed from entities, block entities and items, such as those given by name tags
+	Identifiers int // Account and device identifiers removed from other records
+}
+
+func (r AnonymizeReport) String() string {
+	return fmt.Sprintf("%d player records deleted\n%d signs cleared\n%d books cleared\n%d custom names removed\n"+
+		"%d account and device identifiers removed", r.Players, r.Signs, r.Books, r.Names, r.Identifiers)
+}
+
+// signBlockEntities are the IDs of block entities which hold sign text.
+var signBlockEntities = map[string]bool{"Sign": true, "HangingSign": true}
+
+// signTextTags are the compounds of a sign holding the text on each side. Old signs hold their text at the top level.
+var signTextTags = []string{"FrontText", "BackText"}
+
+// bookItems are the IDs of items which hold pages written by players.
+var bookItems = map[string]bool{"minecraft:writable_book": true, "minecraft:written_book": true}
+
+// bookTags are the item tags of a book which hold its content and author.
+var bookTags = []string{"pages", "title", "author", "xuid", "generation"}
+
+// identifyingTags are tags which hold account or device identifiers, such as the Xbox user ID of the player who last
+// edited a sign.
+var identifyingTags = []string{"TextOwner", "MsaId", "PlatformOnlineId", "SelfSignedId", "ServerId", "xuid", "XUID"}
+
+// Anonymize removes data which could identify the players of a world, so that it can be shared publicly. Player records
+// are deleted, so everyone joining the shared world starts as a new player. Sign text, book content and custom names
+// are removed, along with any account or device identifiers found in other records.
+func (w *World) Anonymize() (AnonymizeReport, error) {
+	report := AnonymizeReport{}
+
+	keys, err := w.playerKeys(w.startBudget())
+	if err != nil {
+		return report, err
+	}
+
+	for _, key := range keys {
+		if err := w.delete([]byte(key)); err != nil {
+			return report, fmt.Errorf("deleting player record '%s': %w", key, err)
+		}
+		report.Players++
+	}
+
+	err = w.forEachNBTRecord(func(key []byte, tags []nbt.NBTTag) ([]nbt.NBTTag, bool, error) {
+		before := report
+
+		for i := range tags {
+			tags[i] = nbt.Rewrite(tags[i], func(t nbt.NBTTag) nbt.NBTTag {
+				anonymizeTag(&t, &report)
+				return t
+			})
+		}
+
+		return tags, report != before, nil
+	})
+
+	return report, err
+}
+
+// anonymizeTag removes identifying data held directly by a compound tag. Nested compounds are handled separately.
+func anonymizeTag(t *nbt.NBTTag, report *AnonymizeReport) {
+	if t.Type != nbt.TagCompound {
+		return
+	}
+
+	if id := childString(t, "id"); signBlockEntities[id] {
+		if clearSignText(t) {
+			report.Signs++
+		}
+	}
+
+	if name := childString(t, "Name"); bookItems[name] {
+		if tag, ok := t.Child("tag"); ok {
+			removed := false
+			for _, n := range bookTags {
+				removed = tag.RemoveChild(n) || removed
+			}
+			if removed {
+				_ = t.SetChild(tag)
+				report.Books++
+			}
+		}
+	}
+
+	// Entities and block entities have a CustomName and items have a Name in their display compound
+	if t.RemoveChild("CustomName") {
+		t.RemoveChild("CustomNameVisible")
+		report.Names++
+	}
+	if strings.EqualFold(t.Name, "display") && t.RemoveChild("Name") {
+		report.Names++
+	}
+
+	for _, n := range identifyingTags {
+		if t.RemoveChild(n) {
+			report.Identifiers++
+		}
+	}
+}
+
+// clearSignText sets the text of every side of a sign to an empty string. It returns false if the sign had no text.
+func clearSignText(t *nbt.NBTTag) bool {
+	cleared := clearText(t)
+
+	for _, side := range signTextTags {
+		s, ok := t.Child(side)
+		if !ok {
+			continue
+		}
+
+		if clearText(&s) {
+			_ = t.SetChild(s)
+			cleared = true
+		}
+	}
+
+	return cleared
+}
+
+// clearText empties the Text child of a compound. It returns false if there is no text to clear.
+func clearText(t *nbt.NBTTag) bool {
+	if text := childString(t, "Text"); text == "" {
+		return false
+	}
+
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagString, Name: "Text", Value: ""})
+
+	return true
+}
+
+// childString returns the value of a string child of a compound, or an empty string if there is none.
+func childString(t *nbt.NBTTag, name string) string {
+	c, ok := t.Child(name)
+	if !ok {
+		return ""
+	}
+
+	s, _ := c.StringValue()
+
+	return s
+}
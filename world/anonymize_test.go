@@ -0,0 +1,108 @@
+package world
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func testString(name, value string) nbt.NBTTag {
+	return nbt.NBTTag{Type: nbt.TagString, Name: name, Value: value}
+}
+
+func TestAnonymize(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	player := testCompound("", testString("MsaId", "abc"), testString("PlatformOnlineId", "123"))
+	for _, key := range []string{"~local_player", "player_server_1234"} {
+		value, _ := nbt.Encode([]nbt.NBTTag{player})
+		_ = db.Put([]byte(key), value)
+	}
+
+	sign := testBlockEntity("Sign", 0, 64, 0)
+	_ = sign.SetChild(testCompound("FrontText", testString("Text", "Alice's house"), testString("TextOwner", "2535")))
+	_ = sign.SetChild(testCompound("BackText", testString("Text", "")))
+
+	book := testCompound("",
+		testString("Name", "minecraft:written_book"),
+		testCompound("tag",
+			testList("pages", nbt.TagCompound, testCompound("", testString("text", "Dear diary"))),
+			testString("author", "Alice"),
+			testString("title", "Secrets"),
+			testCompound("display", testString("Name", "My book")),
+		),
+	)
+
+	chest := testBlockEntity("Chest", 1, 64, 0)
+	_ = chest.SetChild(testString("CustomName", "Alice's stuff"))
+	_ = chest.SetChild(testList("Items", nbt.TagCompound, book))
+
+	blockEntities, _ := nbt.Encode([]nbt.NBTTag{sign, chest, testBlockEntity("Furnace", 2, 64, 0)})
+	beKey := leveldb.ChunkKey{Tag: leveldb.BlockEntity}.Bytes()
+	_ = db.Put(beKey, blockEntities)
+
+	wolf := testEntity("minecraft:wolf", 5, 1, 64, 1)
+	_ = wolf.SetChild(testString("CustomName", "Rex"))
+	_ = wolf.SetChild(nbt.NBTTag{Type: nbt.TagByte, Name: "CustomNameVisible", Value: 1})
+	entities, _ := nbt.Encode([]nbt.NBTTag{wolf})
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.Entity}.Bytes(), entities)
+
+	report, err := w.Anonymize()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := AnonymizeReport{Players: 2, Signs: 1, Books: 1, Names: 3, Identifiers: 1}
+	if report != want {
+		t.Errorf("expected report %+v: got %+v", want, report)
+	}
+
+	for _, key := range []string{"~local_player", "player_server_1234"} {
+		if _, err := db.Get([]byte(key)); !errors.Is(err, leveldb.ErrNotFound) {
+			t.Errorf("expected %s to be deleted: got %v", key, err)
+		}
+	}
+
+	value, _ := db.Get(beKey)
+	tags, err := nbt.Decode(value)
+	if err != nil || len(tags) != 3 {
+		t.Fatalf("expected 3 block entities: got %d, %v", len(tags), err)
+	}
+
+	if text, _ := tags[0].Path("FrontText", "Text"); text.Value != "" {
+		t.Errorf("expected sign text to be cleared: got %v", text.Value)
+	}
+
+	if _, ok := tags[0].Path("FrontText", "TextOwner"); ok {
+		t.Errorf("expected sign owner to be removed")
+	}
+
+	if _, ok := tags[1].Child("CustomName"); ok {
+		t.Errorf("expected chest name to be removed")
+	}
+
+	items, _ := tags[1].Child("Items")
+	item := items.List()[0]
+	for _, n := range []string{"pages", "author", "title"} {
+		if _, ok := item.Path("tag", n); ok {
+			t.Errorf("expected book %s to be removed", n)
+		}
+	}
+
+	if _, ok := item.Path("tag", "display", "Name"); ok {
+		t.Errorf("expected item name to be removed")
+	}
+
+	if name, _ := item.Child("Name"); name.Value != "minecraft:written_book" {
+		t.Errorf("expected book item to be kept: got %v", name.Value)
+	}
+
+	// Running again finds nothing left to remove
+	if report, err := w.Anonymize(); err != nil || report != (AnonymizeReport{}) {
+		t.Errorf("expected nothing removed the second time: got %+v, %v", report, err)
+	}
+}
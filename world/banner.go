@@ -0,0 +1,162 @@
+package world
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// DyeColor is one of the 16 dye colors, numbered as in Bedrock Edition where 0 is black and 15 is white.
+type DyeColor int
+
+var dyeColors = []struct {
+	name string
+	rgb  color.RGBA
+}{
+	{"black", color.RGBA{0x1d, 0x1d, 0x21, 0xff}},
+	{"red", color.RGBA{0xb0, 0x2e, 0x26, 0xff}},
+	{"green", color.RGBA{0x5e, 0x7c, 0x16, 0xff}},
+	{"brown", color.RGBA{0x83, 0x54, 0x32, 0xff}},
+	{"blue", color.RGBA{0x3c, 0x44, 0xaa, 0xff}},
+	{"purple", color.RGBA{0x89, 0x32, 0xb8, 0xff}},
+	{"cyan", color.RGBA{0x16, 0x9c, 0x9c, 0xff}},
+	{"light gray", color.RGBA{0x9d, 0x9d, 0x97, 0xff}},
+	{"gray", color.RGBA{0x47, 0x4f, 0x52, 0xff}},
+	{"pink", color.RGBA{0xf3, 0x8b, 0xaa, 0xff}},
+	{"lime", color.RGBA{0x80, 0xc7, 0x1f, 0xff}},
+	{"yellow", color.RGBA{0xfe, 0xd8, 0x3d, 0xff}},
+	{"light blue", color.RGBA{0x3a, 0xb3, 0xda, 0xff}},
+	{"magenta", color.RGBA{0xc7, 0x4e, 0xbd, 0xff}},
+	{"orange", color.RGBA{0xf9, 0x80, 0x1d, 0xff}},
+	{"white", color.RGBA{0xf9, 0xff, 0xfe, 0xff}},
+}
+
+func (c DyeColor) String() string {
+	if c < 0 || int(c) >= len(dyeColors) {
+		return fmt.Sprintf("DyeColor(%d)", int(c))
+	}
+
+	return dyeColors[c].name
+}
+
+// RGBA returns the color of the dye as shown on banners, or opaque black if the dye color is invalid.
+func (c DyeColor) RGBA() color.RGBA {
+	if c < 0 || int(c) >= len(dyeColors) {
+		return color.RGBA{A: 0xff}
+	}
+
+	return dyeColors[c].rgb
+}
+
+// bannerPatternNames maps banner pattern codes to the pattern names shown in game.
+var bannerPatternNames = map[string]string{
+	"bs":  "Base",
+	"ts":  "Chief",
+	"ls":  "Pale Dexter",
+	"rs":  "Pale Sinister",
+	"cs":  "Pale",
+	"ms":  "Fess",
+	"drs": "Bend",
+	"dls": "Bend Sinister",
+	"ss":  "Paly",
+	"cr":  "Saltire",
+	"sc":  "Cross",
+	"ld":  "Per Bend Sinister",
+	"rud": "Per Bend",
+	"lud": "Per Bend Inverted",
+	"rd":  "Per Bend Sinister Inverted",
+	"vh":  "Per Pale",
+	"vhr": "Per Pale Inverted",
+	"hh":  "Per Fess",
+	"hhb": "Per Fess Inverted",
+	"bl":  "Base Dexter Canton",
+	"br":  "Base Sinister Canton",
+	"tl":  "Chief Dexter Canton",
+	"tr":  "Chief Sinister Canton",
+	"bt":  "Chevron",
+	"tt":  "Inverted Chevron",
+	"bts": "Base Indented",
+	"tts": "Chief Indented",
+	"mc":  "Roundel",
+	"mr":  "Lozenge",
+	"bo":  "Bordure",
+	"cbo": "Bordure Indented",
+	"bri": "Field Masoned",
+	"gra": "Gradient",
+	"gru": "Base Gradient",
+	"cre": "Creeper Charge",
+	"sku": "Skull Charge",
+	"flo": "Flower Charge",
+	"moj": "Thing",
+	"glb": "Globe",
+	"pig": "Snout",
+	"flw": "Flow",
+	"gus": "Guster",
+}
+
+// Banner is a banner or shield design.
+type Banner struct {
+	X, Y, Z  int
+	Base     DyeColor
+	Patterns []BannerPattern // Patterns in the order they are layered, bottom first
+	Ominous  bool            // The banner is an ominous (illager) banner
+}
+
+// BannerPattern is one layer of a banner design.
+type BannerPattern struct {
+	Code  string // The pattern code e.g. cr
+	Color DyeColor
+}
+
+// Name returns the name of the pattern shown in game, or the pattern code if it is not known.
+func (p BannerPattern) Name() string {
+	if n, ok := bannerPatternNames[p.Code]; ok {
+		return n
+	}
+
+	return p.Code
+}
+
+// ParseBanner returns the design held by a Banner block entity, or by the tag of a banner or shield item.
+func ParseBanner(e BlockEntity) Banner {
+	b := Banner{
+		X: e.X, Y: e.Y, Z: e.Z,
+		Base:    DyeColor(childInt(e.NBT, "Base")),
+		Ominous: childInt(e.NBT, "Type") == 1,
+	}
+
+	patterns, ok := e.NBT.Child("Patterns")
+	if !ok {
+		return b
+	}
+
+	for _, p := range patterns.List() {
+		code := ""
+		if c, ok := p.Child("Pattern"); ok {
+			code, _ = c.StringValue()
+		}
+
+		b.Patterns = append(b.Patterns, BannerPattern{Code: code, Color: DyeColor(childInt(p, "Color"))})
+	}
+
+	return b
+}
+
+// Banners returns every banner placed in the world.
+func (w *World) Banners() ([]Banner, error) {
+	records, err := w.blockEntityRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	banners := make([]Banner, 0)
+
+	for _, r := range records {
+		for _, e := range r.entities {
+			if e.ID == "Banner" {
+				banners = append(banners, ParseBanner(e))
+			}
+		}
+	}
+
+	return banners, nil
+}
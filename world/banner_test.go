@@ -0,0 +1,55 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestBanners(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := &World{db: db, subChunks: make(map[struct{ x, y, z, d int }]*subChunkData)}
+
+	pattern := func(code string, color int) nbt.NBTTag {
+		return testCompound("",
+			nbt.NBTTag{Type: nbt.TagString, Name: "Pattern", Value: code},
+			nbt.NBTTag{Type: nbt.TagInt, Name: "Color", Value: color},
+		)
+	}
+
+	banner := testBlockEntity("Banner", 1, 64, 2)
+	_ = banner.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: "Base", Value: 15})
+	_ = banner.SetChild(testList("Patterns", nbt.TagCompound, pattern("cr", 1), pattern("bo", 0)))
+
+	value, err := nbt.Encode([]nbt.NBTTag{banner, testBlockEntity("Chest", 0, 64, 0)})
+	if err != nil {
+		t.Fatalf("unexpected error encoding block entities: %s", err)
+	}
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.BlockEntity}.Bytes(), value)
+
+	banners, err := w.Banners()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []Banner{{
+		X: 1, Y: 64, Z: 2,
+		Base:     15,
+		Patterns: []BannerPattern{{Code: "cr", Color: 1}, {Code: "bo", Color: 0}},
+	}}
+
+	if !reflect.DeepEqual(banners, want) {
+		t.Fatalf("expected %+v: got %+v", want, banners)
+	}
+
+	if n := banners[0].Patterns[0].Name(); n != "Saltire" {
+		t.Errorf("expected Saltire: got %s", n)
+	}
+
+	if c := banners[0].Base.String(); c != "white" {
+		t.Errorf("expected white: got %s", c)
+	}
+}
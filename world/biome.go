@@ -0,0 +1,201 @@
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+//go:generate go run gen_ids.go
+
+// Biome is a biome ID, such as those returned by ChunkBiomes.
+type Biome int
+
+func (b Biome) String() string {
+	return BiomeName(int(b))
+}
+
+// BiomeName returns the name of a biome ID, e.g. plains, or the ID as a number if it is not known.
+func BiomeName(id int) string {
+	if n, ok := biomeNames[id]; ok {
+		return n
+	}
+
+	return fmt.Sprintf("biome %d", id)
+}
+
+// biomeRunCopyLast is the bits per block value of a Data3D biome storage which repeats the previous sub chunk's biomes.
+const biomeRunCopyLast = 0x7f
+
+// ChunkBiomes returns the biome ID of each column of the chunk with the given chunk coordinates, indexed [x][z] from
+// the lowest corner of the chunk, at the height of the given surface block. Columns with no surface block take the biome
+// at the top of the column. The returned bool is false if the chunk has no biome data.
+func (w *World) ChunkBiomes(cx, cz, dimension int, surface *[chunkSize][chunkSize]Block) (*[chunkSize][chunkSize]int, bool, error) {
+	r, ok := subChunkRanges[dimension]
+	if !ok {
+		return nil, false, fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension), Tag: leveldb.Data3D}
+
+	value, err := w.db.Get(k.Bytes())
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return w.chunkBiomes2D(cx, cz, dimension)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("getting key '%x': %w", k.Bytes(), err)
+	}
+
+	storages, err := parseData3DBiomes(value)
+	if err != nil {
+		return nil, false, fmt.Errorf("parsing biomes of chunk %d %d: %w", cx, cz, err)
+	}
+
+	biomes := &[chunkSize][chunkSize]int{}
+
+	for x := 0; x < chunkSize; x++ {
+		for z := 0; z < chunkSize; z++ {
+			y := int(r[1])*chunkSize + chunkSize - 1
+			if surface != nil && surface[x][z].ID != "" {
+				y = surface[x][z].Y
+			}
+
+			// Data3D holds a storage for each sub chunk from the bottom of the world, but may end early
+			i := floorDiv(y, chunkSize) - int(r[0])
+			if i >= len(storages) {
+				i = len(storages) - 1
+			}
+			if i < 0 {
+				i = 0
+			}
+
+			biomes[x][z] = storages[i][subChunkVoxelToIndex(x, y-floorDiv(y, chunkSize)*chunkSize, z)]
+		}
+	}
+
+	return biomes, true, nil
+}
+
+// chunkBiomes2D reads the biomes of a chunk saved before 1.18 from its Data2D record, which has one biome per column.
+func (w *World) chunkBiomes2D(cx, cz, dimension int) (*[chunkSize][chunkSize]int, bool, error) {
+	k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension), Tag: leveldb.Data2D}
+
+	value, err := w.db.Get(k.Bytes())
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("getting key '%x': %w", k.Bytes(), err)
+	}
+
+	// A 256 entry int16 height map followed by one biome byte per column, indexed by z * 16 + x
+	if len(value) < 512+256 {
+		return nil, false, fmt.Errorf("Data2D record of chunk %d %d is %d bytes long: expected 768", cx, cz, len(value))
+	}
+
+	biomes := &[chunkSize][chunkSize]int{}
+	for x := 0; x < chunkSize; x++ {
+		for z := 0; z < chunkSize; z++ {
+			biomes[x][z] = int(value[512+z*chunkSize+x])
+		}
+	}
+
+	return biomes, true, nil
+}
+
+// parseData3DBiomes parses the biome storages of a Data3D record, one for each sub chunk from the bottom of the world,
+// each with a biome ID for every block in the sub chunk's storage order.
+func parseData3DBiomes(data []byte) ([][]int, error) {
+	r := bytes.NewReader(data)
+
+	// Skip the 256 entry int16 height map
+	if _, err := r.Seek(512, 0); err != nil || r.Len() == 0 {
+		return nil, fmt.Errorf("record is %d bytes long: too short for height map", len(data))
+	}
+
+	storages := make([][]int, 0)
+
+	for r.Len() > 0 {
+		var header byte
+		if err := readLittleEndian(r, &header); err != nil {
+			return nil, fmt.Errorf("reading storage %d header: %w", len(storages), err)
+		}
+
+		bitsPerBlock := int(header >> 1)
+
+		if bitsPerBlock == biomeRunCopyLast {
+			if len(storages) == 0 {
+				return nil, fmt.Errorf("first storage repeats a previous storage")
+			}
+			storages = append(storages, storages[len(storages)-1])
+			continue
+		}
+
+		indices := make([]int, subChunkBlockCount)
+
+		if bitsPerBlock > 0 {
+			if bitsPerBlock > 16 {
+				return nil, fmt.Errorf("storage %d has invalid bits per block %d", len(storages), bitsPerBlock)
+			}
+
+			blocksPerWord := 32 / bitsPerBlock
+			wordCount := int(math.Ceil(subChunkBlockCount / float64(blocksPerWord)))
+
+			for wi := 0; wi < wordCount; wi++ {
+				var word uint32
+				if err := binary.Read(r, binary.LittleEndian, &word); err != nil {
+					return nil, fmt.Errorf("reading storage %d word %d: %w", len(storages), wi, err)
+				}
+
+				for b := 0; b < blocksPerWord; b++ {
+					if i := wi*blocksPerWord + b; i < subChunkBlockCount {
+						indices[i] = int(word>>(b*bitsPerBlock)) & (1<<bitsPerBlock - 1)
+					}
+				}
+			}
+		}
+
+		// A storage with no index bits has a single palette entry and no palette size
+		paletteSize := int32(1)
+		if bitsPerBlock > 0 {
+			if err := readLittleEndian(r, &paletteSize); err != nil {
+				return nil, fmt.Errorf("reading storage %d palette size: %w", len(storages), err)
+			}
+		}
+
+		palette := make([]int32, paletteSize)
+		if err := readLittleEndian(r, palette); err != nil {
+			return nil, fmt.Errorf("reading storage %d palette: %w", len(storages), err)
+		}
+
+		biomes := make([]int, subChunkBlockCount)
+		for i, p := range indices {
+			if p >= len(palette) {
+				return nil, fmt.Errorf("storage %d index %d out of range of palette with length %d", len(storages), p, len(palette))
+			}
+			biomes[i] = int(palette[p])
+		}
+
+		storages = append(storages, biomes)
+	}
+
+	if len(storages) == 0 {
+		return nil, fmt.Errorf("record has no biome storages")
+	}
+
+	return storages, nil
+}
+
+// floorDiv divides rounding towards negative infinity.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+
+	return q
+}
@@ -0,0 +1,65 @@
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestChunkBiomes(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	// Sub chunk -4 is all plains and -3 repeats it. Sub chunk -2 is all forest, using a one bit index.
+	b := &bytes.Buffer{}
+	b.Write(make([]byte, 512))
+	b.WriteByte(0)
+	_ = binary.Write(b, binary.LittleEndian, int32(1))
+	b.WriteByte(biomeRunCopyLast<<1 | 1)
+	b.WriteByte(1 << 1)
+	for i := 0; i < subChunkBlockCount/32; i++ {
+		_ = binary.Write(b, binary.LittleEndian, uint32(0xffffffff))
+	}
+	_ = binary.Write(b, binary.LittleEndian, []int32{2, 1, 4})
+
+	_ = db.Put(leveldb.ChunkKey{X: 0, Z: 0, Tag: leveldb.Data3D}.Bytes(), b.Bytes())
+
+	surface := &[chunkSize][chunkSize]Block{}
+	surface[0][0] = Block{ID: "minecraft:stone", Y: -40}
+
+	biomes, ok, err := w.ChunkBiomes(0, 0, 0, surface)
+	if err != nil || !ok {
+		t.Fatalf("expected biomes: got %t, %v", ok, err)
+	}
+
+	// Column 0 0 is at sub chunk -3 and the others take the top storage, which is the last one saved
+	if BiomeName(biomes[0][0]) != "plains" || BiomeName(biomes[1][0]) != "forest" {
+		t.Errorf("expected plains and forest: got %s and %s", BiomeName(biomes[0][0]), BiomeName(biomes[1][0]))
+	}
+
+	// A chunk saved before 1.18 with one desert column
+	legacy := make([]byte, 512+256)
+	legacy[512+3*chunkSize+2] = 2
+	_ = db.Put(leveldb.ChunkKey{X: 1, Z: 0, Tag: leveldb.Data2D}.Bytes(), legacy)
+
+	biomes, ok, err = w.ChunkBiomes(1, 0, 0, nil)
+	if err != nil || !ok {
+		t.Fatalf("expected legacy biomes: got %t, %v", ok, err)
+	}
+
+	if BiomeName(biomes[2][3]) != "desert" || BiomeName(biomes[0][0]) != "ocean" {
+		t.Errorf("expected desert at 2 3 and ocean at 0 0: got %s and %s",
+			BiomeName(biomes[2][3]), BiomeName(biomes[0][0]))
+	}
+
+	if _, ok, err := w.ChunkBiomes(5, 5, 0, nil); ok || err != nil {
+		t.Errorf("expected no biomes for an unsaved chunk: got %t, %v", ok, err)
+	}
+
+	if _, err := parseData3DBiomes(make([]byte, 100)); err == nil {
+		t.Errorf("expected error parsing a short record")
+	}
+}
@@ -1,7 +1,205 @@
 package world
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// Block is a block in the world. Blocks read from the world hold a copy of their palette entry, from which their block
+// states and version are read, so blocks with the same ID, position and states are equal. Blocks created by callers,
+// or found by functions which only read block IDs, have no states.
 type Block struct {
 	ID          string
 	X, Y, Z     int
 	waterLogged bool
+	entry       string // The palette entry of the block encoded as NBT, or empty
+}
+
+// NewBlock returns a block with the name, states and version of a palette entry, a compound tag with name, states and
+// version children.
+func NewBlock(entry nbt.NBTTag) Block {
+	return Block{ID: entry.BlockID(), entry: encodeEntry(entry)}
+}
+
+// block returns the block at index i of the sub chunk, with the given world coordinates.
+func (s *subChunkData) block(i, x, y, z int) Block {
+	p := s.Blocks.Indices[i]
+
+	return Block{
+		ID:          s.Blocks.Palette[p].BlockID(),
+		X:           x,
+		Y:           y,
+		Z:           z,
+		waterLogged: s.waterLogged(i),
+		entry:       s.Blocks.encodedEntry(p),
+	}
+}
+
+// encodedEntry returns palette entry p encoded as NBT: the bytes it was read from if it was read from the world.
+func (s *blockStorage) encodedEntry(p int) string {
+	if p < len(s.encoded) {
+		return s.encoded[p]
+	}
+
+	return encodeEntry(s.Palette[p])
+}
+
+// encodeEntry returns a palette entry encoded as NBT, or an empty string if it can't be encoded.
+func encodeEntry(entry nbt.NBTTag) string {
+	data, err := nbt.Encode([]nbt.NBTTag{entry})
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// Name returns the ID of the block, for example minecraft:stone.
+func (b Block) Name() string {
+	return b.ID
+}
+
+// States returns the block states by name, for example facing_direction or open_bit. String states are string, int
+// states are int32 and byte states, which the game uses for flags, are bool. States of other types have the value
+// held by their tag. The map is empty if the block has no states.
+func (b Block) States() map[string]interface{} {
+	states := make(map[string]interface{})
+
+	for _, t := range b.stateTags() {
+		states[t.Name] = stateValue(t)
+	}
+
+	return states
+}
+
+// State returns the value of the block state with the given name, as returned by States. The returned bool is false if
+// the block has no such state.
+func (b Block) State(name string) (interface{}, bool) {
+	for _, t := range b.stateTags() {
+		if t.Name == name {
+			return stateValue(t), true
+		}
+	}
+
+	return nil, false
+}
+
+// IsWaterlogged returns true if the block is water logged, sharing its position with water.
+func (b Block) IsWaterlogged() bool {
+	return b.waterLogged
+}
+
+// Version returns the block state version of the block's palette entry, which the game updates as block states change
+// between releases, or 0 if the block has no palette entry.
+func (b Block) Version() int32 {
+	e, ok := b.decodeEntry()
+	if !ok {
+		return 0
+	}
+
+	return int32(childInt(e, "version"))
+}
+
+// String returns the ID of the block followed by its states in brackets, as the game's commands give them, and its
+// coordinates, for example minecraft:chest["facing_direction"=3] at 10 64 -20.
+func (b Block) String() string {
+	s := fmt.Sprintf("%s%s at %d %d %d", b.ID, b.StatesString(), b.X, b.Y, b.Z)
+	if b.waterLogged {
+		s += " (water logged)"
+	}
+
+	return s
+}
+
+// StatesString returns the block states in brackets sorted by name, as the game's commands give them, for example
+// ["facing_direction"=3,"open_bit"=true], or an empty string if the block has no states.
+func (b Block) StatesString() string {
+	states := b.States()
+	if len(states) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := strings.Builder{}
+
+	for i, name := range names {
+		v := states[name]
+		if str, ok := v.(string); ok {
+			v = fmt.Sprintf("%q", str)
+		}
+
+		sep := ","
+		if i == 0 {
+			sep = "["
+		}
+		fmt.Fprintf(&s, "%s%q=%v", sep, name, v)
+	}
+	s.WriteString("]")
+
+	return s.String()
+}
+
+// decodeEntry returns the block's palette entry, if it has one.
+func (b Block) decodeEntry() (nbt.NBTTag, bool) {
+	if b.entry == "" {
+		return nbt.NBTTag{}, false
+	}
+
+	tags, err := nbt.Decode([]byte(b.entry))
+	if err != nil || len(tags) != 1 {
+		return nbt.NBTTag{}, false
+	}
+
+	return tags[0], true
+}
+
+// ownEntry returns the block's palette entry if it has one naming the block's ID. A block read from the world may
+// have had its ID changed, leaving an entry which describes the block it replaced.
+func (b Block) ownEntry() (nbt.NBTTag, bool) {
+	e, ok := b.decodeEntry()
+	if !ok || e.BlockID() != b.ID {
+		return nbt.NBTTag{}, false
+	}
+
+	return e, true
+}
+
+// stateTags returns the tags of the block states.
+func (b Block) stateTags() []nbt.NBTTag {
+	e, ok := b.decodeEntry()
+	if !ok {
+		return nil
+	}
+
+	states, ok := e.Child("states")
+	if !ok {
+		return nil
+	}
+
+	return states.Tags()
+}
+
+// stateValue returns the value of a block state tag, as described by Block.States.
+func stateValue(t nbt.NBTTag) interface{} {
+	switch t.Type {
+	case nbt.TagString:
+		s, _ := t.StringValue()
+		return s
+	case nbt.TagInt:
+		i, _ := t.Int()
+		return int32(i)
+	case nbt.TagByte:
+		i, _ := t.Int()
+		return i != 0
+	}
+
+	return t.Value
 }
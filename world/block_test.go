@@ -0,0 +1,76 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// testBlock returns a block at the given world coordinates with the palette entry testSubChunkValue gives its ID.
+func testBlock(id string, x, y, z int) Block {
+	b := NewBlock(testPaletteEntry(id))
+	b.X, b.Y, b.Z = x, y, z
+
+	return b
+}
+
+func TestBlockStates(t *testing.T) {
+	entry := testPaletteEntry("minecraft:chest")
+	_ = entry.SetChild(testCompound("states",
+		nbt.NBTTag{Type: nbt.TagInt, Name: "facing_direction", Value: 3},
+		testString("wood_type", "oak"),
+		nbt.NBTTag{Type: nbt.TagByte, Name: "open_bit", Value: 1},
+	))
+	_ = entry.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: "version", Value: 18090528})
+
+	w, _ := testGeneratedWorld()
+	s := NewSubChunk(0, 4, 0, 0, BlockAir)
+	s.setEntry(subChunkVoxelToIndex(1, 2, 3), entry)
+	s.setWaterLogged(subChunkVoxelToIndex(1, 2, 3), true)
+	if err := w.SetSubChunk(s); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := w.GetBlock(1, 66, 3, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if b.Name() != "minecraft:chest" {
+		t.Errorf("expected minecraft:chest: got %s", b.Name())
+	}
+
+	want := map[string]interface{}{"facing_direction": int32(3), "wood_type": "oak", "open_bit": true}
+	if got := b.States(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected states %v: got %v", want, got)
+	}
+
+	if v, ok := b.State("facing_direction"); !ok || v != int32(3) {
+		t.Errorf("expected facing_direction 3: got %v, %t", v, ok)
+	}
+	if _, ok := b.State("age"); ok {
+		t.Error("expected no age state")
+	}
+
+	if !b.IsWaterlogged() {
+		t.Error("expected the block to be water logged")
+	}
+	if b.Version() != 18090528 {
+		t.Errorf("expected version 18090528: got %d", b.Version())
+	}
+
+	wantString := `minecraft:chest["facing_direction"=3,"open_bit"=true,"wood_type"="oak"] at 1 66 3 (water logged)`
+	if got := b.String(); got != wantString {
+		t.Errorf("expected %s: got %s", wantString, got)
+	}
+
+	if n := NewBlock(entry); n.Name() != "minecraft:chest" || len(n.States()) != 3 {
+		t.Errorf("expected a chest with 3 states from the palette entry: got %s", n)
+	}
+
+	plain := Block{ID: BlockStone}
+	if len(plain.States()) != 0 || plain.Version() != 0 || plain.String() != "minecraft:stone at 0 0 0" {
+		t.Errorf("expected a block without a palette entry to have no states: got %s", plain)
+	}
+}
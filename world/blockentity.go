@@ -0,0 +1,332 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// BlockEntity is a block entity (tile entity) such as a chest, sign or furnace. Block entities hold data for a block
+// which can't be stored in its block states.
+type BlockEntity struct {
+	ID      string
+	X, Y, Z int
+	NBT     nbt.NBTTag // The full compound tag for the block entity
+}
+
+// blockEntityBlocks maps block entity IDs to the block ID substrings which may hold them. Block entities with IDs which
+// are not listed here are not checked for a matching block.
+var blockEntityBlocks = map[string][]string{
+	"Chest":             {"chest"},
+	"EnderChest":        {"ender_chest"},
+	"ShulkerBox":        {"shulker_box"},
+	"Barrel":            {"barrel"},
+	"Furnace":           {"furnace"},
+	"BlastFurnace":      {"blast_furnace"},
+	"Smoker":            {"smoker"},
+	"Hopper":            {"hopper"},
+	"Dispenser":         {"dispenser"},
+	"Dropper":           {"dropper"},
+	"BrewingStand":      {"brewing_stand"},
+	"Sign":              {"sign"},
+	"HangingSign":       {"hanging_sign"},
+	"Bed":               {"bed"},
+	"Banner":            {"banner"},
+	"Skull":             {"skull"},
+	"FlowerPot":         {"flower_pot"},
+	"Jukebox":           {"jukebox"},
+	"Lectern":           {"lectern"},
+	"Beacon":            {"beacon"},
+	"Campfire":          {"campfire"},
+	"CommandBlock":      {"command_block"},
+	"EnchantTable":      {"enchanting_table"},
+	"MobSpawner":        {"mob_spawner"},
+	"Beehive":           {"beehive", "bee_nest"},
+	"Cauldron":          {"cauldron"},
+	"Comparator":        {"comparator"},
+	"DaylightDetector":  {"daylight_detector"},
+	"NoteBlock":         {"noteblock"},
+	"PistonArm":         {"piston"},
+	"EndPortal":         {"end_portal"},
+	"EndGateway":        {"end_gateway"},
+	"Conduit":           {"conduit"},
+	"Bell":              {"bell"},
+	"StructureBlock":    {"structure_block"},
+	"ChiseledBookshelf": {"chiseled_bookshelf"},
+}
+
+// GhostBlockEntity is a block entity whose coordinates no longer contain a block which can hold it, for example chest
+// data where the block is now air. These are usually left behind by external edits and cause phantom container bugs.
+type GhostBlockEntity struct {
+	BlockEntity
+	Block Block // The block currently at the block entity's coordinates
+	key   []byte
+}
+
+// blockEntityRecord is the block entities stored in one chunk's BlockEntity record.
+type blockEntityRecord struct {
+	key      []byte
+	chunk    leveldb.ChunkKey
+	entities []BlockEntity
+}
+
+// blockEntityRecords returns every BlockEntity record in the world.
+func (w *World) blockEntityRecords() ([]blockEntityRecord, error) {
+	keys, err := w.db.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("listing keys: %w", err)
+	}
+
+	records := make([]blockEntityRecord, 0)
+
+	for _, key := range keys {
+		k, ok := leveldb.ParseChunkKey(key)
+		if !ok || k.Tag != leveldb.BlockEntity {
+			continue
+		}
+
+		value, err := w.db.Get(key)
+		if err != nil {
+			return nil, fmt.Errorf("getting block entities with key '%x': %w", key, err)
+		}
+
+		entities, err := parseBlockEntities(nil, value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing block entities with key '%x': %w", key, err)
+		}
+
+		records = append(records, blockEntityRecord{key: key, chunk: k, entities: entities})
+	}
+
+	return records, nil
+}
+
+// parseBlockEntities parses the concatenated compound tags in a BlockEntity record, decoded by d.
+func parseBlockEntities(d *nbt.Decoder, data []byte) ([]BlockEntity, error) {
+	tags, err := d.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]BlockEntity, len(tags))
+
+	for i, t := range tags {
+		e := BlockEntity{NBT: t}
+
+		if id, ok := t.Child("id"); ok {
+			e.ID, _ = id.StringValue()
+		}
+
+		for name, c := range map[string]*int{"x": &e.X, "y": &e.Y, "z": &e.Z} {
+			tag, ok := t.Child(name)
+			if !ok {
+				return nil, fmt.Errorf("block entity %d has no '%s' coordinate", i, name)
+			}
+
+			v, _ := tag.Int()
+			*c = int(v)
+		}
+
+		entities[i] = e
+	}
+
+	return entities, nil
+}
+
+// BlockEntityAt returns the block entity at the given coordinates, which hold the data of the block there. The returned
+// bool is false if there is none.
+func (w *World) BlockEntityAt(x, y, z, dimension int) (BlockEntity, bool, error) {
+	if err := checkHeight(y, dimension); err != nil {
+		return BlockEntity{}, false, err
+	}
+
+	entities, err := w.chunkBlockEntities(floorDiv(x, chunkSize), floorDiv(z, chunkSize), dimension)
+	if err != nil {
+		return BlockEntity{}, false, err
+	}
+
+	for _, e := range entities {
+		if e.X == x && e.Y == y && e.Z == z {
+			return e, true, nil
+		}
+	}
+
+	return BlockEntity{}, false, nil
+}
+
+// encodeBlockEntities serializes block entities as a BlockEntity record.
+func encodeBlockEntities(entities []BlockEntity) ([]byte, error) {
+	tags := make([]nbt.NBTTag, len(entities))
+	for i, e := range entities {
+		tags[i] = e.NBT
+	}
+
+	return nbt.Encode(tags)
+}
+
+// chunkBlockEntities returns the block entities in the chunk at the given chunk coordinates.
+func (w *World) chunkBlockEntities(cx, cz, dimension int) ([]BlockEntity, error) {
+	k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension), Tag: leveldb.BlockEntity}
+
+	value, err := w.db.Get(k.Bytes())
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting block entities with key '%x': %w", k.Bytes(), err)
+	}
+
+	entities, err := parseBlockEntities(nil, value)
+	if err != nil {
+		return nil, fmt.Errorf("parsing block entities with key '%x': %w", k.Bytes(), err)
+	}
+
+	return entities, nil
+}
+
+// replaceBlockEntities removes the block entities for which replaced returns true from the BlockEntity record of the
+// chunk at the given chunk coordinates, and adds the given block entity tags. The record is deleted if it is left empty
+// and isn't written if nothing changes.
+func (w *World) replaceBlockEntities(cx, cz, dimension int, replaced func(x, y, z int) bool, added []nbt.NBTTag) error {
+	existing, err := w.chunkBlockEntities(cx, cz, dimension)
+	if err != nil {
+		return err
+	}
+
+	tags := make([]nbt.NBTTag, 0, len(existing)+len(added))
+	removed := make([]BlockEntity, 0)
+	for _, e := range existing {
+		if replaced(e.X, e.Y, e.Z) {
+			removed = append(removed, e)
+			continue
+		}
+		tags = append(tags, e.NBT)
+	}
+
+	if len(removed) == 0 && len(added) == 0 {
+		return nil
+	}
+
+	k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension), Tag: leveldb.BlockEntity}
+	if err := w.putTags(k.Bytes(), append(tags, added...)); err != nil {
+		return fmt.Errorf("putting block entities: %w", err)
+	}
+	w.notifyBlockEntitiesRemoved(removed)
+
+	return nil
+}
+
+// moveBlockEntity sets the coordinates of a block entity tag, returning the tag.
+func moveBlockEntity(t nbt.NBTTag, x, y, z int) nbt.NBTTag {
+	for name, v := range map[string]int{"x": x, "y": y, "z": z} {
+		_ = t.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: name, Value: float64(v)})
+	}
+
+	return t
+}
+
+// GhostBlockEntities returns every block entity in the world whose coordinates don't contain a matching block. Block
+// entities in sub chunks which are not saved are also returned, as their block is effectively air.
+func (w *World) GhostBlockEntities() ([]GhostBlockEntity, error) {
+	records, err := w.blockEntityRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	ghosts := make([]GhostBlockEntity, 0)
+
+	for _, r := range records {
+		for _, e := range r.entities {
+			allowed, ok := blockEntityBlocks[e.ID]
+			if !ok {
+				continue
+			}
+
+			b, err := w.GetBlock(e.X, e.Y, e.Z, int(r.chunk.Dimension))
+			if err != nil && !errors.Is(err, &SubChunkNotSavedError{}) && !errors.Is(err, ErrOutsideHeight) {
+				return nil, fmt.Errorf("getting block for %s at %d %d %d: %w", e.ID, e.X, e.Y, e.Z, err)
+			}
+
+			if matchesAny(b.ID, allowed) {
+				continue
+			}
+
+			ghosts = append(ghosts, GhostBlockEntity{BlockEntity: e, Block: b, key: r.key})
+		}
+	}
+
+	return ghosts, nil
+}
+
+// RemoveGhostBlockEntities removes the given block entities from their BlockEntity records. Records left empty are
+// deleted.
+func (w *World) RemoveGhostBlockEntities(ghosts []GhostBlockEntity) error {
+	remove := make(map[string]map[[3]int]bool)
+	for _, g := range ghosts {
+		if remove[string(g.key)] == nil {
+			remove[string(g.key)] = make(map[[3]int]bool)
+		}
+		remove[string(g.key)][[3]int{g.X, g.Y, g.Z}] = true
+	}
+
+	for key, positions := range remove {
+		value, err := w.db.Get([]byte(key))
+		if err != nil {
+			return fmt.Errorf("getting block entities with key '%x': %w", key, err)
+		}
+
+		entities, err := parseBlockEntities(nil, value)
+		if err != nil {
+			return fmt.Errorf("parsing block entities with key '%x': %w", key, err)
+		}
+
+		kept := make([]BlockEntity, 0, len(entities))
+		removed := make([]BlockEntity, 0, len(positions))
+		for _, e := range entities {
+			if positions[[3]int{e.X, e.Y, e.Z}] {
+				removed = append(removed, e)
+				continue
+			}
+			kept = append(kept, e)
+		}
+
+		if len(kept) == 0 {
+			if err := w.delete([]byte(key)); err != nil {
+				return fmt.Errorf("deleting block entities with key '%x': %w", key, err)
+			}
+			w.notifyBlockEntitiesRemoved(removed)
+			continue
+		}
+
+		data, err := encodeBlockEntities(kept)
+		if err != nil {
+			return fmt.Errorf("encoding block entities with key '%x': %w", key, err)
+		}
+
+		if err := w.put([]byte(key), data); err != nil {
+			return fmt.Errorf("putting block entities with key '%x': %w", key, err)
+		}
+		w.notifyBlockEntitiesRemoved(removed)
+	}
+
+	return nil
+}
+
+func (w *World) notifyBlockEntitiesRemoved(removed []BlockEntity) {
+	for i := range removed {
+		w.notify(ChangeEvent{Kind: BlockEntityRemoved, BlockEntity: &removed[i]})
+	}
+}
+
+func matchesAny(id string, substrings []string) bool {
+	for _, s := range substrings {
+		if strings.Contains(id, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func testBlockEntity(id string, x, y, z int) nbt.NBTTag {
+	t := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagString, Name: "id", Value: id})
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: "x", Value: x})
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: "y", Value: y})
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: "z", Value: z})
+
+	return t
+}
+
+func TestGhostBlockEntities(t *testing.T) {
+	db := mock.ValidLevelDB()
+	w := World{
+		db:        db,
+		subChunks: make(map[struct{ x, y, z, d int }]*subChunkData),
+	}
+
+	value, err := nbt.Encode([]nbt.NBTTag{
+		testBlockEntity("Chest", 0, 0, 0),
+		testBlockEntity("Unknown", 0, 1, 0),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error encoding block entities: %s", err)
+	}
+
+	key := leveldb.ChunkKey{Tag: leveldb.BlockEntity}.Bytes()
+	_ = db.Put(key, value)
+
+	ghosts, err := w.GhostBlockEntities()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(ghosts) != 1 {
+		t.Fatalf("expected 1 ghost block entity: got %d", len(ghosts))
+	}
+
+	if ghosts[0].ID != "Chest" || ghosts[0].Block.ID != "minecraft:crimson_planks" {
+		t.Errorf("unexpected ghost block entity: %+v", ghosts[0])
+	}
+
+	if err := w.RemoveGhostBlockEntities(ghosts); err != nil {
+		t.Fatalf("unexpected error removing ghosts: %s", err)
+	}
+
+	value, _ = db.Get(key)
+	remaining, err := parseBlockEntities(nil, value)
+	if err != nil {
+		t.Fatalf("unexpected error parsing block entities: %s", err)
+	}
+
+	if len(remaining) != 1 || remaining[0].ID != "Unknown" {
+		t.Errorf("unexpected block entities remaining: %+v", remaining)
+	}
+}
+
+func TestBlockEntityAt(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	value, err := nbt.Encode([]nbt.NBTTag{
+		testBlockEntity("Chest", -3, 64, 5),
+		testBlockEntity("Sign", -4, 64, 5),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error encoding block entities: %s", err)
+	}
+	_ = db.Put(leveldb.ChunkKey{X: -1, Tag: leveldb.BlockEntity}.Bytes(), value)
+
+	e, ok, err := w.BlockEntityAt(-4, 64, 5, 0)
+	if err != nil || !ok || e.ID != "Sign" {
+		t.Errorf("expected a sign: got %+v, %t, %v", e, ok, err)
+	}
+
+	if e, ok, err := w.BlockEntityAt(-4, 65, 5, 0); err != nil || ok {
+		t.Errorf("expected no block entity: got %+v, %v", e, err)
+	}
+
+	if _, _, err := w.BlockEntityAt(0, 0, 0, 7); err == nil {
+		t.Error("expected an error for an invalid dimension")
+	}
+
+	c, err := w.GetChunk(-1, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error getting chunk: %s", err)
+	}
+
+	entities, err := c.BlockEntities()
+	if err != nil || len(entities) != 2 || entities[0].ID != "Chest" {
+		t.Errorf("expected a chest and a sign: got %+v, %v", entities, err)
+	}
+
+	c, _ = w.GetChunk(0, 0, 0)
+	if entities, err := c.BlockEntities(); err != nil || len(entities) != 0 {
+		t.Errorf("expected no block entities: got %+v, %v", entities, err)
+	}
+}
@@ -0,0 +1,168 @@
+package world
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// BlockFormat is the encoding of blocks written by ExportBlocks.
+type BlockFormat int
+
+const (
+	// JSONBlocks is a JSON array with one object per line, holding a block's coordinates, name and states.
+	JSONBlocks BlockFormat = iota
+	// CSVBlocks is a header row followed by one row per block with the columns x, y, z, name and states. States are
+	// given as Block.StatesString gives them.
+	CSVBlocks
+)
+
+var blockFormatNames = []string{"json", "csv"}
+
+func (f BlockFormat) String() string {
+	return enumName(blockFormatNames, int(f), "BlockFormat")
+}
+
+// ParseBlockFormat returns the block format with the given name: json or csv.
+func ParseBlockFormat(s string) (BlockFormat, error) {
+	i, err := parseEnum(blockFormatNames, s, "block format")
+	return BlockFormat(i), err
+}
+
+// exportedBlock is a block in the JSON format.
+type exportedBlock struct {
+	X      int                    `json:"x"`
+	Y      int                    `json:"y"`
+	Z      int                    `json:"z"`
+	Name   string                 `json:"name"`
+	States map[string]interface{} `json:"states"`
+}
+
+// ExportBlocks writes the saved blocks in the region for which match returns true, or every saved block if match is
+// nil, to dst in the given format and returns the number written. The region is read in tiles of one chunk by the
+// given number of workers, or one per CPU if workers is less than 1, and the tiles are written in order so the output
+// is the same whatever the number of workers.
+func (w *World) ExportBlocks(dst io.Writer, region Selection, f BlockFormat, match BlockMatcher,
+	workers int) (int, error) {
+	if f != JSONBlocks && f != CSVBlocks {
+		return 0, fmt.Errorf("unknown block format %s", f)
+	}
+
+	header := "x,y,z,name,states\n"
+	if f == JSONBlocks {
+		header = "["
+	}
+	if _, err := io.WriteString(dst, header); err != nil {
+		return 0, err
+	}
+
+	tiler := Tiler{Size: 1}
+
+	// Tiles finish in any order, so each is held until the tiles before it are written
+	var mu sync.Mutex
+	done := make(map[int]*bytes.Buffer)
+	next, count := 0, 0
+	written := false // Whether any JSON object has been written
+
+	err := tiler.Run(region, workers, func(t Tile) error {
+		buf := &bytes.Buffer{}
+
+		n, err := w.encodeTileBlocks(buf, t.Core, f, match)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		done[t.Index] = buf
+		count += n
+
+		for b, ok := done[next]; ok; b, ok = done[next] {
+			delete(done, next)
+			next++
+
+			// JSON objects after the first are separated by commas
+			if f == JSONBlocks && b.Len() > 0 {
+				if written {
+					if _, err := io.WriteString(dst, ","); err != nil {
+						return err
+					}
+				}
+				written = true
+			}
+
+			if _, err := b.WriteTo(dst); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if f == JSONBlocks {
+		if _, err := io.WriteString(dst, "\n]\n"); err != nil {
+			return 0, err
+		}
+	}
+
+	return count, nil
+}
+
+// encodeTileBlocks writes the saved blocks in the region matching match to buf in the given format, with a newline
+// before each JSON object, and returns the number written.
+func (w *World) encodeTileBlocks(buf *bytes.Buffer, region Selection, f BlockFormat, match BlockMatcher) (int, error) {
+	it, err := w.GetBlocks(region)
+	if err != nil {
+		return 0, err
+	}
+
+	c := csv.NewWriter(buf)
+	n := 0
+
+	for it.Next() {
+		b := it.Block()
+
+		if match != nil {
+			ok, err := match(b, region.Dimension)
+			if err != nil {
+				return 0, err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		if f == CSVBlocks {
+			err = c.Write([]string{strconv.Itoa(b.X), strconv.Itoa(b.Y), strconv.Itoa(b.Z), b.ID, b.StatesString()})
+		} else {
+			if n > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+
+			var data []byte
+			data, err = json.Marshal(exportedBlock{X: b.X, Y: b.Y, Z: b.Z, Name: b.ID, States: b.States()})
+			buf.Write(data)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		n++
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+
+	c.Flush()
+
+	return n, c.Error()
+}
@@ -0,0 +1,68 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestExportBlocks(t *testing.T) {
+	w, _ := testGeneratedWorld()
+
+	chest := testPaletteEntry("minecraft:chest")
+	_ = chest.SetChild(testCompound("states", nbt.NBTTag{Type: nbt.TagInt, Name: "facing_direction", Value: 3}))
+
+	// Blocks in three chunks, so the region is split into tiles
+	a := NewSubChunk(0, 4, 0, 0, BlockAir)
+	a.setAt(1, 0, 1, Block{ID: BlockStone})
+	a.setEntry(subChunkVoxelToIndex(2, 0, 1), chest)
+	b := NewSubChunk(1, 4, 0, 0, BlockAir)
+	b.setAt(0, 0, 0, Block{ID: BlockDirt})
+	c := NewSubChunk(0, 4, 1, 0, BlockAir)
+	c.setAt(0, 1, 0, Block{ID: BlockGrass})
+	for _, s := range []*SubChunk{a, b, c} {
+		if err := w.SetSubChunk(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	region := NewSelection(0, 64, 0, 20, 65, 20, 0)
+	notAir := func(b Block, _ int) (bool, error) { return b.ID != BlockAir, nil }
+
+	var csv bytes.Buffer
+	n, err := w.ExportBlocks(&csv, region, CSVBlocks, notAir, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := `x,y,z,name,states
+1,64,1,minecraft:stone,
+2,64,1,minecraft:chest,"[""facing_direction""=3]"
+0,65,16,minecraft:grass,
+16,64,0,minecraft:dirt,
+`
+	if n != 4 || csv.String() != want {
+		t.Errorf("expected 4 blocks:\n%s\ngot %d:\n%s", want, n, csv.String())
+	}
+
+	var js bytes.Buffer
+	if _, err := w.ExportBlocks(&js, region, JSONBlocks, notAir, 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var blocks []exportedBlock
+	if err := json.Unmarshal(js.Bytes(), &blocks); err != nil {
+		t.Fatalf("expected a JSON array: %s\n%s", err, js.String())
+	}
+	if len(blocks) != 4 || blocks[1].Name != "minecraft:chest" || blocks[1].States["facing_direction"] != 3.0 {
+		t.Errorf("expected the chest with its states second: got %+v", blocks)
+	}
+
+	var empty bytes.Buffer
+	none := func(Block, int) (bool, error) { return false, nil }
+	if _, err := w.ExportBlocks(&empty, region, JSONBlocks, none, 0); err != nil || empty.String() != "[\n]\n" {
+		t.Errorf("expected an empty array: got %q, %v", empty.String(), err)
+	}
+}
@@ -0,0 +1,84 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeBudget is wrapped by the *PartialResultError returned when a scan of the world runs out of time.
+var ErrTimeBudget = errors.New("time budget exceeded")
+
+// PartialResultError is returned by a scan of the world which stopped because its time budget or deadline passed.
+// Operations which gather results return those found so far along with it, so interactive callers such as a viewer
+// can show them as partial.
+type PartialResultError struct {
+	Done, Total int    // The number of items visited and the number there were to visit
+	Unit        string // What was visited, e.g. chunks
+}
+
+func (e *PartialResultError) Error() string {
+	return fmt.Sprintf("%s: searched %.0f%% of %s", ErrTimeBudget, 100*e.Fraction(), e.Unit)
+}
+
+func (e *PartialResultError) Unwrap() error { return ErrTimeBudget }
+
+// Is implements Is(error) to support errors.Is()
+func (e *PartialResultError) Is(tgt error) bool {
+	_, ok := tgt.(*PartialResultError)
+	return ok
+}
+
+// Fraction returns the fraction of items visited before the scan stopped, from 0 to 1.
+func (e *PartialResultError) Fraction() float64 {
+	if e.Total == 0 {
+		return 1
+	}
+
+	return float64(e.Done) / float64(e.Total)
+}
+
+// SetTimeBudget limits how long each operation which scans the whole world, such as FindBlocks or Stats, may run.
+// When the budget runs out the operation stops early and returns a *PartialResultError. FindBlocks, ForEachBlock,
+// SearchText and Stats return or have passed the results gathered so far; other operations return only the error. A
+// budget of 0 means no limit.
+func (w *World) SetTimeBudget(d time.Duration) {
+	w.timeBudget = d
+}
+
+// SetDeadline stops operations which scan the whole world and are running at the given time, as SetTimeBudget does.
+// The zero time means no deadline.
+func (w *World) SetDeadline(t time.Time) {
+	w.deadline = t
+}
+
+// scanBudget is the time budget of one operation which scans the whole world. Every scan the operation makes is passed
+// the same budget, so together they stop when it runs out. A nil *scanBudget has no limit.
+type scanBudget struct {
+	stopAt time.Time
+}
+
+// startBudget starts the time budget of an operation, returning nil if the world has no time budget or deadline.
+func (w *World) startBudget() *scanBudget {
+	if w.timeBudget == 0 && w.deadline.IsZero() {
+		return nil
+	}
+
+	b := &scanBudget{stopAt: w.deadline}
+	if w.timeBudget > 0 {
+		if t := time.Now().Add(w.timeBudget); b.stopAt.IsZero() || t.Before(b.stopAt) {
+			b.stopAt = t
+		}
+	}
+
+	return b
+}
+
+// check returns a *PartialResultError if the budget has run out, having visited done of total items.
+func (b *scanBudget) check(done, total int, unit string) error {
+	if b == nil || time.Now().Before(b.stopAt) {
+		return nil
+	}
+
+	return &PartialResultError{Done: done, Total: total, Unit: unit}
+}
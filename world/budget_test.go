@@ -0,0 +1,93 @@
+package world
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestTimeBudget(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	for x := int32(0); x < 4; x++ {
+		_ = db.Put(leveldb.ChunkKey{X: x, Tag: leveldb.SubChunkPrefix}.Bytes(),
+			testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: BlockStone}))
+	}
+
+	stone := func(b Block, _ int) (bool, error) { return b.ID == BlockStone, nil }
+
+	// The budget runs out while the first chunk is searched
+	w.SetScanOrder(ScanOrder{Order: RowMajor})
+	w.SetTimeBudget(50 * time.Millisecond)
+	slept := false
+	found, err := w.FindBlocks(func(b Block, d int) (bool, error) {
+		if !slept {
+			time.Sleep(100 * time.Millisecond)
+			slept = true
+		}
+		return stone(b, d)
+	})
+
+	var partial *PartialResultError
+	if !errors.As(err, &partial) || !errors.Is(err, ErrTimeBudget) {
+		t.Fatalf("expected a *PartialResultError: got %v", err)
+	}
+	if len(found) != 1 || partial.Done != 1 || partial.Total != 4 || partial.Unit != "chunks" {
+		t.Errorf("expected 1 block after 1 of 4 chunks: got %d blocks after %+v", len(found), partial)
+	}
+	if want := "time budget exceeded: searched 25% of chunks"; err.Error() != want {
+		t.Errorf("expected error %q: got %q", want, err)
+	}
+
+	// The budget of the next scan starts again
+	if found, err := w.FindBlocks(stone); err != nil || len(found) != 4 {
+		t.Errorf("expected 4 blocks within the budget: got %d, %v", len(found), err)
+	}
+
+	w.SetScanOrder(ScanOrder{})
+	w.SetDeadline(time.Now().Add(-time.Second))
+	if found, err := w.FindBlocks(stone); !errors.As(err, &partial) || len(found) != 0 || partial.Unit != "records" {
+		t.Errorf("expected no blocks after the deadline: got %d, %v", len(found), err)
+	}
+
+	// Stats returns what it gathered before the budget ran out
+	w.SetDeadline(time.Time{})
+	w.SetTimeBudget(time.Hour)
+	if _, err := w.Stats(); err != nil {
+		t.Fatalf("expected stats within the budget: %v", err)
+	}
+
+	w.SetDeadline(time.Now().Add(-time.Second))
+	s, err := w.Stats()
+	if !errors.As(err, &partial) || s.Chunks == nil || s.Blocks == nil {
+		t.Errorf("expected empty stats with a *PartialResultError: got %+v, %v", s, err)
+	}
+}
+
+func TestScanBudgetIsPerOperation(t *testing.T) {
+	w := NewFromDB(mock.NewLevelDB())
+	w.SetTimeBudget(time.Hour)
+
+	// Each operation starts its own budget, which every scan it makes shares
+	a, b := w.startBudget(), w.startBudget()
+	if a == nil || a == b {
+		t.Fatalf("expected a new budget for each operation")
+	}
+
+	a.stopAt = time.Now().Add(-time.Second)
+	if err := a.check(1, 2, "chunks"); err == nil {
+		t.Errorf("expected the spent budget to stop its scans")
+	}
+	if err := b.check(1, 2, "chunks"); err != nil {
+		t.Errorf("expected other operations to keep their budget: got %v", err)
+	}
+
+	var none *scanBudget
+	if err := none.check(1, 2, "chunks"); err != nil {
+		t.Errorf("expected no limit without a budget: got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// ChangeKind is the kind of modification described by a ChangeEvent.
+type ChangeKind int
+
+const (
+	RecordPut          ChangeKind = iota // A database record was written
+	RecordDeleted                        // A database record was deleted
+	EntityAdded                          // An entity was added, or moved to a new position
+	EntityRemoved                        // An entity was removed, or moved from its old position
+	BlockEntityRemoved                   // A block entity was removed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case RecordPut:
+		return "record put"
+	case RecordDeleted:
+		return "record deleted"
+	case EntityAdded:
+		return "entity added"
+	case EntityRemoved:
+		return "entity removed"
+	case BlockEntityRemoved:
+		return "block entity removed"
+	}
+
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// ChangeEvent describes a modification made to the world through this package. A single API call may produce several
+// events, for example moving an entity produces record events for each record written followed by an EntityRemoved
+// and an EntityAdded event.
+type ChangeEvent struct {
+	Kind        ChangeKind
+	Key         []byte       // The record key, for record events
+	Entity      *Entity      // The entity, for entity events
+	BlockEntity *BlockEntity // The block entity, for block entity events
+}
+
+// OnChange registers f to be called after every modification made to the world, so that applications embedding the
+// package can react to edits e.g. to refresh a preview or write an audit log. Callbacks are called synchronously in the
+// order they were registered and must not modify the world.
+func (w *World) OnChange(f func(ChangeEvent)) {
+	w.observers = append(w.observers, f)
+}
+
+func (w *World) notify(e ChangeEvent) {
+	for _, f := range w.observers {
+		f(e)
+	}
+}
+
+// put writes a record and notifies observers.
+func (w *World) put(key, value []byte) error {
+	if err := w.beforeChange(key); err != nil {
+		return err
+	}
+
+	if err := w.db.Put(key, value); err != nil {
+		return err
+	}
+
+	w.notify(ChangeEvent{Kind: RecordPut, Key: key})
+
+	return nil
+}
+
+// delete deletes a record and notifies observers.
+func (w *World) delete(key []byte) error {
+	if err := w.beforeChange(key); err != nil {
+		return err
+	}
+
+	if err := w.db.Delete(key); err != nil {
+		return err
+	}
+
+	w.notify(ChangeEvent{Kind: RecordDeleted, Key: key})
+
+	return nil
+}
+
+// beforeChange records the current value of a record in the journal, if there is one, and drops any cached copy of
+// the record.
+func (w *World) beforeChange(key []byte) error {
+	if k, ok := leveldb.ParseChunkKey(key); ok && k.Tag == leveldb.SubChunkPrefix {
+		delete(w.subChunks, struct{ x, y, z, d int }{int(k.X), int(k.SubChunkY), int(k.Z), int(k.Dimension)})
+	}
+
+	if w.journal == nil {
+		return nil
+	}
+
+	value, err := w.db.Get(key)
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		return fmt.Errorf("getting key '%x' for the undo journal: %w", key, err)
+	}
+
+	*w.journal = append(*w.journal, journalEntry{key: key, value: value, existed: err == nil})
+
+	return nil
+}
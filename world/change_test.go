@@ -0,0 +1,47 @@
+package world
+
+import "testing"
+
+func TestOnChange(t *testing.T) {
+	w, _ := testEntityWorld(t)
+
+	events := make([]ChangeEvent, 0)
+	w.OnChange(func(e ChangeEvent) { events = append(events, e) })
+
+	entities, err := w.Entities()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var wolf Entity
+	for _, e := range entities {
+		if e.Identifier == "minecraft:wolf" {
+			wolf = e
+		}
+	}
+
+	if _, err := w.MoveEntity(wolf, 40, 64, 8, 0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	kinds := make([]ChangeKind, 0)
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+
+	// The actor and emptied digest are deleted, then the actor and the new digest are written
+	want := []ChangeKind{RecordDeleted, RecordDeleted, EntityRemoved, RecordPut, RecordPut, EntityAdded}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected events %v: got %v", want, kinds)
+	}
+
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("expected events %v: got %v", want, kinds)
+		}
+	}
+
+	if added := events[len(events)-1].Entity; added == nil || added.X != 40 || added.UniqueID != 3 {
+		t.Errorf("expected added entity to be the moved wolf: got %+v", added)
+	}
+}
@@ -0,0 +1,155 @@
+package world
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// ChunkChanges returns the number of blocks which are different in w and before in each chunk of a dimension, keyed by
+// chunk coordinates, such as between a world and an earlier backup of it. A block is changed if its ID or states are
+// different. Sub chunks saved in only one of the worlds are compared with air. Chunks with no changes are left out.
+func (w *World) ChunkChanges(before *World, dimension int) (map[[2]int]int, error) {
+	changes := make(map[[2]int]int)
+	b := w.startBudget()
+
+	// Sub chunks in w are compared with before, then sub chunks only in before are compared with air
+	err := w.eachKey(b, func(key []byte) error {
+		k, ok := leveldb.ParseChunkKey(key)
+		if !ok || k.Tag != leveldb.SubChunkPrefix || int(k.Dimension) != dimension {
+			return nil
+		}
+
+		a, err := getOptional(before, key)
+		if err != nil {
+			return err
+		}
+
+		b, err := getOptional(w, key)
+		if err != nil {
+			return err
+		}
+
+		return addChanges(changes, k, a, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = before.eachKey(b, func(key []byte) error {
+		k, ok := leveldb.ParseChunkKey(key)
+		if !ok || k.Tag != leveldb.SubChunkPrefix || int(k.Dimension) != dimension {
+			return nil
+		}
+
+		b, err := getOptional(w, key)
+		if err != nil || b != nil {
+			return err
+		}
+
+		a, err := getOptional(before, key)
+		if err != nil {
+			return err
+		}
+
+		return addChanges(changes, k, a, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// addChanges adds the number of blocks which are different in two values of the sub chunk with the given key to the
+// count for its chunk.
+func addChanges(changes map[[2]int]int, k leveldb.ChunkKey, a, b []byte) error {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+
+	n, err := changedBlocks(a, b)
+	if err != nil {
+		return fmt.Errorf("comparing sub chunk with key '%x': %w", k.Bytes(), err)
+	}
+
+	if n > 0 {
+		changes[[2]int{int(k.X), int(k.Z)}] += n
+	}
+
+	return nil
+}
+
+// getOptional returns the value of a key, or nil if the key is not in the world.
+func getOptional(w *World, key []byte) ([]byte, error) {
+	value, err := w.db.Get(key)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting key '%x': %w", key, err)
+	}
+
+	return value, nil
+}
+
+// changedBlocks returns the number of blocks which are different in two sub chunk values. A nil value is all air.
+func changedBlocks(a, b []byte) (int, error) {
+	before, err := subChunkStates(a)
+	if err != nil {
+		return 0, fmt.Errorf("parsing first value: %w", err)
+	}
+
+	after, err := subChunkStates(b)
+	if err != nil {
+		return 0, fmt.Errorf("parsing second value: %w", err)
+	}
+
+	n := 0
+	for i := range before {
+		if before[i] != after[i] {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// subChunkStates returns the block ID and states of every block in a sub chunk value, in storage order. A nil value
+// is all air.
+func subChunkStates(value []byte) ([]string, error) {
+	states := make([]string, subChunkBlockCount)
+
+	if value == nil {
+		for i := range states {
+			states[i] = BlockAir
+		}
+		return states, nil
+	}
+
+	s, err := parseSubChunk(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Format each palette entry once, leaving out the version which changes when the game is updated
+	palette := make([]string, len(s.Blocks.Palette))
+	for i, p := range s.Blocks.Palette {
+		palette[i] = p.BlockID()
+		if st, ok := p.Child("states"); ok && len(st.Tags()) > 0 {
+			palette[i] += nbt.Format(st, false)
+		}
+	}
+
+	for i, p := range s.Blocks.Indices {
+		if p >= len(palette) {
+			return nil, fmt.Errorf("index %d out of range of palette with length %d", p, len(palette))
+		}
+		states[i] = palette[p]
+	}
+
+	return states, nil
+}
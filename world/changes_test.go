@@ -0,0 +1,74 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestChunkChanges(t *testing.T) {
+	beforeDB, afterDB := mock.NewLevelDB(), mock.NewLevelDB()
+	before, after := &World{db: beforeDB}, &World{db: afterDB}
+
+	key := func(x, z, d int32) []byte {
+		return leveldb.ChunkKey{X: x, Z: z, Dimension: d, Tag: leveldb.SubChunkPrefix, SubChunkY: 4}.Bytes()
+	}
+
+	same := testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:stone"})
+
+	// Chunk 0 0 is unchanged
+	_ = beforeDB.Put(key(0, 0, 0), same)
+	_ = afterDB.Put(key(0, 0, 0), same)
+
+	// Chunk 1 0 has one block replaced and one added
+	_ = beforeDB.Put(key(1, 0, 0), testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:stone"}))
+	_ = afterDB.Put(key(1, 0, 0), testSubChunkValue(t, map[[3]int]string{
+		{0, 0, 0}: "minecraft:oak_planks",
+		{1, 0, 0}: "minecraft:oak_planks",
+	}))
+
+	// Chunk -1 2 is new, with 3 blocks other than air
+	_ = afterDB.Put(key(-1, 2, 0), testSubChunkValue(t, map[[3]int]string{
+		{0, 0, 0}: "minecraft:dirt", {0, 1, 0}: "minecraft:dirt", {0, 2, 0}: "minecraft:grass",
+	}))
+
+	// Changes in another dimension are ignored
+	_ = afterDB.Put(key(1, 0, 1), same)
+
+	changes, err := after.ChunkChanges(before, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := map[[2]int]int{{1, 0}: 2, {-1, 2}: 3}
+
+	if len(changes) != len(want) {
+		t.Errorf("expected changes %v: got %v", want, changes)
+	}
+
+	for c, n := range want {
+		if changes[c] != n {
+			t.Errorf("chunk %d %d: expected %d changed blocks: got %d", c[0], c[1], n, changes[c])
+		}
+	}
+}
+
+func TestDiffSubChunk(t *testing.T) {
+	k := leveldb.ChunkKey{Tag: leveldb.SubChunkPrefix}.Bytes()
+	a := testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:stone"})
+	b := testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:dirt", {5, 5, 5}: "minecraft:dirt"})
+
+	diff, err := DiffRecord(k, a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(diff) != 1 || diff[0] != "2 blocks changed" {
+		t.Errorf("expected 2 blocks changed: got %v", diff)
+	}
+
+	if diff, _ := DiffRecord(k, a, a); len(diff) != 0 {
+		t.Errorf("expected no differences: got %v", diff)
+	}
+}
@@ -0,0 +1,81 @@
+package world
+
+import (
+	"errors"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// creativeGameType is the GameType value of creative mode, which disables achievements.
+const creativeGameType = 1
+
+// ErrAchievementsBlocked is returned by ReenableAchievements when commands are enabled or the world game mode is
+// creative, either of which would disable achievements again as soon as the world is loaded.
+var ErrAchievementsBlocked = errors.New("commands are enabled or the game mode is creative")
+
+// Cheats are the level.dat flags controlling commands and achievements.
+type Cheats struct {
+	CommandsEnabled bool // Cheats are on, so players with permission may run commands
+	// Achievements are disabled. The game sets this permanently when cheats are turned on or the world is played in
+	// creative mode.
+	AchievementsDisabled bool
+	Creative             bool // The world game mode is creative
+}
+
+// fields returns the level.dat tags holding each flag. The game type is read only, and not written by SetCheats.
+func (c *Cheats) fields() []levelDatField {
+	return []levelDatField{
+		{"commandsEnabled", nbt.TagByte, &c.CommandsEnabled},
+		{"hasBeenLoadedInCreative", nbt.TagByte, &c.AchievementsDisabled},
+	}
+}
+
+// Cheats reads the commands and achievement flags from level.dat.
+func (w *World) Cheats() (Cheats, error) {
+	c := Cheats{}
+
+	gameType := 0
+	fields := append(c.fields(), levelDatField{"GameType", nbt.TagInt, &gameType})
+
+	if err := w.readLevelDatFields(fields); err != nil {
+		return Cheats{}, err
+	}
+
+	c.Creative = gameType == creativeGameType
+
+	return c, nil
+}
+
+// SetCommandsEnabled turns cheats on or off. As in game, turning cheats on also disables achievements, and turning them
+// off does not enable achievements again.
+func (w *World) SetCommandsEnabled(enabled bool) error {
+	c, err := w.Cheats()
+	if err != nil {
+		return err
+	}
+
+	c.CommandsEnabled = enabled
+	if enabled {
+		c.AchievementsDisabled = true
+	}
+
+	return w.setLevelDatFields(c.fields())
+}
+
+// ReenableAchievements clears the flag which permanently disables achievements once a world has used cheats or
+// creative mode. This is not possible in game. It returns ErrAchievementsBlocked if commands are enabled or the world
+// is in creative mode, so turn commands off and set the game mode first.
+func (w *World) ReenableAchievements() error {
+	c, err := w.Cheats()
+	if err != nil {
+		return err
+	}
+
+	if c.CommandsEnabled || c.Creative {
+		return ErrAchievementsBlocked
+	}
+
+	c.AchievementsDisabled = false
+
+	return w.setLevelDatFields(c.fields())
+}
@@ -0,0 +1,49 @@
+package world
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestCheats(t *testing.T) {
+	w := testLevelDat(t,
+		nbt.NBTTag{Name: "commandsEnabled", Type: nbt.TagByte, Value: 0.0},
+		nbt.NBTTag{Name: "hasBeenLoadedInCreative", Type: nbt.TagByte, Value: 0.0},
+		nbt.NBTTag{Name: "GameType", Type: nbt.TagInt, Value: 0.0},
+	)
+
+	if err := w.SetCommandsEnabled(true); err != nil {
+		t.Fatalf("unexpected error enabling commands: %s", err)
+	}
+
+	c, err := w.Cheats()
+	if err != nil {
+		t.Fatalf("unexpected error reading cheats: %s", err)
+	}
+
+	if !c.CommandsEnabled || !c.AchievementsDisabled || c.Creative {
+		t.Errorf("expected commands enabled and achievements disabled: got %+v", c)
+	}
+
+	if err := w.ReenableAchievements(); !errors.Is(err, ErrAchievementsBlocked) {
+		t.Errorf("expected ErrAchievementsBlocked while commands are enabled: got %v", err)
+	}
+
+	if err := w.SetCommandsEnabled(false); err != nil {
+		t.Fatalf("unexpected error disabling commands: %s", err)
+	}
+
+	if c, _ := w.Cheats(); !c.AchievementsDisabled {
+		t.Errorf("expected achievements to stay disabled after disabling commands")
+	}
+
+	if err := w.ReenableAchievements(); err != nil {
+		t.Fatalf("unexpected error enabling achievements: %s", err)
+	}
+
+	if c, _ := w.Cheats(); c.AchievementsDisabled || c.CommandsEnabled {
+		t.Errorf("expected commands and achievements flags to be clear: got %+v", c)
+	}
+}
@@ -0,0 +1,143 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// Chunk is a column of sub chunks, read from the world at once so that renderers and analyzers can work a column at
+// a time. Columns are addressed by their coordinates within the chunk, each from 0 to 15, and blocks by world Y.
+type Chunk struct {
+	X, Z      int // The position of the chunk in chunk coordinates
+	Dimension int
+
+	subChunks     map[int]*SubChunk // Saved sub chunks by index
+	heights       *[chunkSize][chunkSize]int
+	blockEntities []byte // The BlockEntity record, parsed by BlockEntities
+}
+
+// GetChunk reads every saved sub chunk of the chunk with the given chunk coordinates, its height map from the Data3D
+// or Data2D record and its BlockEntity record.
+func (w *World) GetChunk(cx, cz, dimension int) (*Chunk, error) {
+	r, ok := subChunkRanges[dimension]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	c := &Chunk{X: cx, Z: cz, Dimension: dimension, subChunks: make(map[int]*SubChunk)}
+
+	for sy := int(r[0]); sy <= int(r[1]); sy++ {
+		k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension),
+			Tag: leveldb.SubChunkPrefix, SubChunkY: int8(sy)}
+
+		value, err := w.db.Get(k.Bytes())
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting sub chunk with key '%x': %w", k.Bytes(), err)
+		}
+
+		s, err := parseSubChunk(value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing sub chunk with key '%x': %w", k.Bytes(), err)
+		}
+
+		c.subChunks[sy] = &SubChunk{X: cx, Y: sy, Z: cz, Dimension: dimension, data: s}
+	}
+
+	heights, err := w.savedHeightMap(cx, cz, dimension)
+	if err != nil {
+		return nil, err
+	}
+	c.heights = heights
+
+	k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension), Tag: leveldb.BlockEntity}
+
+	value, err := w.db.Get(k.Bytes())
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		return nil, fmt.Errorf("getting block entities with key '%x': %w", k.Bytes(), err)
+	}
+	c.blockEntities = value
+
+	return c, nil
+}
+
+// SubChunks returns the saved sub chunks of the chunk from the bottom up.
+func (c *Chunk) SubChunks() []*SubChunk {
+	subChunks := make([]*SubChunk, 0, len(c.subChunks))
+	for _, s := range c.subChunks {
+		subChunks = append(subChunks, s)
+	}
+
+	sort.Slice(subChunks, func(i, j int) bool { return subChunks[i].Y < subChunks[j].Y })
+
+	return subChunks
+}
+
+// BlockAt returns the block at the given column of the chunk and world Y. The block has world coordinates. If the sub
+// chunk holding it is not saved a *SubChunkNotSavedError is returned.
+func (c *Chunk) BlockAt(x, y, z int) (Block, error) {
+	if err := outOfChunk("chunk", x, 0, z); err != nil {
+		return Block{}, err
+	}
+
+	if err := checkHeight(y, c.Dimension); err != nil {
+		return Block{}, err
+	}
+
+	s, ok := c.subChunks[floorDiv(y, chunkSize)]
+	if !ok {
+		return Block{}, &SubChunkNotSavedError{
+			subChunkOrigin(c.X*chunkSize+x, y, c.Z*chunkSize+z, c.Dimension),
+		}
+	}
+
+	return s.at(x, y-s.Y*chunkSize, z), nil
+}
+
+// HighestBlock returns the highest saved block which is not air in the given column of the chunk. The returned bool
+// is false if the column has no saved blocks other than air.
+func (c *Chunk) HighestBlock(x, z int) (Block, bool) {
+	if x < 0 || x >= chunkSize || z < 0 || z >= chunkSize {
+		return Block{}, false
+	}
+
+	subChunks := c.SubChunks()
+
+	for i := len(subChunks) - 1; i >= 0; i-- {
+		s := subChunks[i]
+
+		for y := chunkSize - 1; y >= 0; y-- {
+			if b := s.at(x, y, z); b.ID != BlockAir {
+				return b, true
+			}
+		}
+	}
+
+	return Block{}, false
+}
+
+// HeightMap returns the height map saved by the game for each column of the chunk, indexed [x][z], converted to world
+// Y. The returned bool is false if the chunk has no Data3D or Data2D record.
+func (c *Chunk) HeightMap() (*[chunkSize][chunkSize]int, bool) {
+	return c.heights, c.heights != nil
+}
+
+// BlockEntities returns the block entities of the chunk, such as chests, signs and furnaces, with their full NBT. Use
+// World.BlockEntityAt to get the block entity of one block.
+func (c *Chunk) BlockEntities() ([]BlockEntity, error) {
+	if len(c.blockEntities) == 0 {
+		return []BlockEntity{}, nil
+	}
+
+	entities, err := parseBlockEntities(nil, c.blockEntities)
+	if err != nil {
+		return nil, fmt.Errorf("parsing block entities of chunk %d %d: %w", c.X, c.Z, err)
+	}
+
+	return entities, nil
+}
@@ -0,0 +1,64 @@
+package world
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestGetChunk(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	_ = db.Put(leveldb.ChunkKey{X: 1, Tag: leveldb.SubChunkPrefix, SubChunkY: -1}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{2, 3, 4}: BlockStone}))
+	_ = db.Put(leveldb.ChunkKey{X: 1, Tag: leveldb.SubChunkPrefix, SubChunkY: 2}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{2, 5, 4}: BlockDirt}))
+
+	data3D := make([]byte, 512+1)
+	binary.LittleEndian.PutUint16(data3D[2*(4*chunkSize+2):], 102)
+	_ = db.Put(leveldb.ChunkKey{X: 1, Tag: leveldb.Data3D}.Bytes(), data3D)
+
+	c, err := w.GetChunk(1, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	subChunks := c.SubChunks()
+	if len(subChunks) != 2 || subChunks[0].Y != -1 || subChunks[1].Y != 2 {
+		t.Fatalf("expected sub chunks -1 and 2: got %+v", subChunks)
+	}
+
+	b, err := c.BlockAt(2, -13, 4)
+	if err != nil {
+		t.Fatalf("unexpected error getting block: %s", err)
+	}
+	if b.ID != BlockStone || b.X != 18 || b.Y != -13 || b.Z != 4 {
+		t.Errorf("expected stone at 18 -13 4: got %+v", b)
+	}
+
+	if _, err := c.BlockAt(2, 0, 4); !errors.Is(err, &SubChunkNotSavedError{}) {
+		t.Errorf("expected SubChunkNotSavedError: got %v", err)
+	}
+	if _, err := c.BlockAt(2, 400, 4); !errors.Is(err, ErrOutsideHeight) {
+		t.Errorf("expected ErrOutsideHeight: got %v", err)
+	}
+	if _, err := c.BlockAt(16, 0, 4); err == nil {
+		t.Error("expected an error for a column outside the chunk")
+	}
+
+	if b, ok := c.HighestBlock(2, 4); !ok || b.ID != BlockDirt || b.Y != 37 {
+		t.Errorf("expected dirt at y 37: got %+v, %t", b, ok)
+	}
+	if b, ok := c.HighestBlock(0, 0); ok {
+		t.Errorf("expected no block in an empty column: got %+v", b)
+	}
+
+	heights, ok := c.HeightMap()
+	if !ok || heights[2][4] != 38 || heights[0][0] != -64 {
+		t.Errorf("expected heights 38 and -64: got %v, %t", heights, ok)
+	}
+}
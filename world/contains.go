@@ -0,0 +1,110 @@
+package world
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// ContainsBlock returns true if any saved block in the region has the given ID, e.g. to check for bedrock above Y=0.
+//
+// Only the palettes of the sub chunks are read, which is much faster than reading blocks with GetBlock. The block
+// indices of a sub chunk are only unpacked if its palette has the block and it is partly outside the region.
+func (w *World) ContainsBlock(region Selection, id string) (bool, error) {
+	r, ok := subChunkRanges[region.Dimension]
+	if !ok {
+		return false, fmt.Errorf("%w %d", ErrInvalidDimension, region.Dimension)
+	}
+
+	minY := maxInt(floorDiv(region.Min[1], chunkSize), int(r[0]))
+	maxY := minInt(floorDiv(region.Max[1], chunkSize), int(r[1]))
+	idBytes := []byte(id)
+
+	for cx := floorDiv(region.Min[0], chunkSize); cx <= floorDiv(region.Max[0], chunkSize); cx++ {
+		for cz := floorDiv(region.Min[2], chunkSize); cz <= floorDiv(region.Max[2], chunkSize); cz++ {
+			for sy := minY; sy <= maxY; sy++ {
+				k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(region.Dimension),
+					Tag: leveldb.SubChunkPrefix, SubChunkY: int8(sy)}
+
+				value, err := w.db.Get(k.Bytes())
+				if errors.Is(err, leveldb.ErrNotFound) {
+					continue
+				}
+				if err != nil {
+					return false, fmt.Errorf("getting sub chunk with key '%x': %w", k.Bytes(), err)
+				}
+
+				// Block IDs are stored as plain strings in the palette, so a sub chunk without the bytes of the ID
+				// can't have the block
+				if !bytes.Contains(value, idBytes) {
+					continue
+				}
+
+				palette, err := parseSubChunkPalette(value)
+				if err != nil {
+					return false, fmt.Errorf("parsing sub chunk with key '%x': %w", k.Bytes(), err)
+				}
+
+				if !(&blockStorage{Palette: palette}).paletteContains(id) {
+					continue
+				}
+
+				ox, oy, oz := subChunkKeyOrigin(k)
+				// Every block of a sub chunk with only this block in its palette is the block
+				if len(palette) == 1 || region.Contains(ox, oy, oz, region.Dimension) &&
+					region.Contains(ox+chunkSize-1, oy+chunkSize-1, oz+chunkSize-1, region.Dimension) {
+					return true, nil
+				}
+
+				s, err := parseSubChunk(value)
+				if err != nil {
+					return false, fmt.Errorf("parsing sub chunk with key '%x': %w", k.Bytes(), err)
+				}
+
+				for i, p := range s.Blocks.Indices {
+					bx, by, bz := subChunkIndexToVoxel(i)
+					if s.Blocks.Palette[p].BlockID() == id && region.Contains(ox+bx, oy+by, oz+bz, region.Dimension) {
+						return true, nil
+					}
+				}
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// parseSubChunkPalette returns the palette of the first block storage of a sub chunk value, skipping over the
+// block indices without unpacking them.
+func parseSubChunkPalette(data []byte) ([]nbt.NBTTag, error) {
+	r := bytes.NewReader(data)
+
+	if _, _, _, err := readSubChunkHeader(r); err != nil {
+		return nil, err
+	}
+
+	var bitsPerBlockAndVersion byte
+	if err := readLittleEndian(r, &bitsPerBlockAndVersion); err != nil {
+		return nil, &CorruptPaletteError{Err: fmt.Errorf("reading bits per block: %w", err)}
+	}
+
+	if bitsPerBlock := int(bitsPerBlockAndVersion >> 1); bitsPerBlock > 0 {
+		blocksPerWord := 32 / bitsPerBlock
+		wordCount := (subChunkBlockCount + blocksPerWord - 1) / blocksPerWord
+
+		if _, err := r.Seek(int64(wordCount)*4, io.SeekCurrent); err != nil {
+			return nil, &CorruptPaletteError{Err: fmt.Errorf("skipping block indices: %w", err)}
+		}
+	}
+
+	palette, _, err := statePalette(r)
+	if err != nil {
+		return nil, &CorruptPaletteError{Err: fmt.Errorf("parsing nbt data: %w", err)}
+	}
+
+	return palette, nil
+}
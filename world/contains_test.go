@@ -0,0 +1,62 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestContainsBlock(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	value := testSubChunkValue(t, map[[3]int]string{{3, 5, 7}: BlockBedrock, {0, 0, 0}: BlockStone})
+	_ = db.Put(leveldb.ChunkKey{X: 1, Z: -1, Tag: leveldb.SubChunkPrefix, SubChunkY: 2}.Bytes(), value)
+
+	// The bedrock is at 19 37 -9
+	cases := []struct {
+		name   string
+		region Selection
+		id     string
+		want   bool
+	}{
+		{"whole sub chunk", NewSelection(0, 0, -32, 47, 63, 0, 0), BlockBedrock, true},
+		{"part of the sub chunk with the block", NewSelection(19, 37, -9, 20, 40, -5, 0), BlockBedrock, true},
+		{"part of the sub chunk without the block", NewSelection(20, 32, -16, 31, 47, -1, 0), BlockBedrock, false},
+		{"block not in the palette", NewSelection(0, 0, -32, 47, 63, 0, 0), BlockDiamondOre, false},
+		{"unsaved sub chunks", NewSelection(-100, -64, 0, -50, 300, 50, 0), BlockBedrock, false},
+		{"other dimension", NewSelection(0, 0, -32, 47, 63, 0, int(Nether)), BlockBedrock, false},
+	}
+
+	for _, c := range cases {
+		got, err := w.ContainsBlock(c.region, c.id)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", c.name, err)
+		}
+
+		if got != c.want {
+			t.Errorf("%s: expected %t: got %t", c.name, c.want, got)
+		}
+	}
+
+	palette, err := parseSubChunkPalette(value)
+	if err != nil {
+		t.Fatalf("unexpected error parsing palette: %s", err)
+	}
+
+	s, err := parseSubChunk(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(palette) != len(s.Blocks.Palette) {
+		t.Fatalf("expected %d palette entries: got %d", len(s.Blocks.Palette), len(palette))
+	}
+
+	for i := range palette {
+		if palette[i].BlockID() != s.Blocks.Palette[i].BlockID() {
+			t.Errorf("palette entry %d: expected %s: got %s", i, s.Blocks.Palette[i].BlockID(), palette[i].BlockID())
+		}
+	}
+}
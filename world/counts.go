@@ -0,0 +1,95 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// BlockCounts is the number of blocks with each block ID.
+type BlockCounts map[string]int
+
+// Dominant returns the most common block ID and its count, ignoring the given IDs such as BlockAir. Ties go to the
+// first ID in alphabetical order. The ID is empty if there are no blocks other than those ignored.
+func (c BlockCounts) Dominant(ignore ...string) (id string, n int) {
+	skip := make(map[string]bool, len(ignore))
+	for _, b := range ignore {
+		skip[b] = true
+	}
+
+	for b, count := range c {
+		if skip[b] {
+			continue
+		}
+
+		if count > n || (count == n && count > 0 && b < id) {
+			id, n = b, count
+		}
+	}
+
+	return id, n
+}
+
+// add adds the counts of o to c.
+func (c BlockCounts) add(o BlockCounts) {
+	for id, n := range o {
+		c[id] += n
+	}
+}
+
+// counts returns the number of blocks in the storage with each block ID, counting palette indices without reading
+// any block.
+func (s *blockStorage) counts() BlockCounts {
+	if s.uniform() {
+		return BlockCounts{s.Palette[0].BlockID(): subChunkBlockCount}
+	}
+
+	perEntry := make([]int, len(s.Palette))
+	for _, p := range s.Indices {
+		if p < len(perEntry) {
+			perEntry[p]++
+		}
+	}
+
+	counts := make(BlockCounts)
+	for p, n := range perEntry {
+		if n > 0 {
+			counts[s.Palette[p].BlockID()] += n
+		}
+	}
+
+	return counts
+}
+
+// ChunkCounts returns the number of saved blocks with each block ID in the chunk with the given chunk coordinates.
+func (w *World) ChunkCounts(cx, cz, dimension int) (BlockCounts, error) {
+	r, ok := subChunkRanges[dimension]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	counts := make(BlockCounts)
+
+	for sy := int(r[0]); sy <= int(r[1]); sy++ {
+		k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension),
+			Tag: leveldb.SubChunkPrefix, SubChunkY: int8(sy)}
+
+		value, err := w.db.Get(k.Bytes())
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting sub chunk with key '%x': %w", k.Bytes(), err)
+		}
+
+		s, err := parseSubChunk(value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing sub chunk with key '%x': %w", k.Bytes(), err)
+		}
+
+		counts.add(s.Blocks.counts())
+	}
+
+	return counts, nil
+}
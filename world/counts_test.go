@@ -0,0 +1,49 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestChunkCounts(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	put := func(sy int8, blocks map[[3]int]string) {
+		_ = db.Put(leveldb.ChunkKey{X: 3, Z: 4, Tag: leveldb.SubChunkPrefix, SubChunkY: sy}.Bytes(),
+			testSubChunkValue(t, blocks))
+	}
+
+	put(-4, map[[3]int]string{{0, 0, 0}: BlockStone, {1, 0, 0}: BlockStone, {2, 0, 0}: BlockDirt})
+	put(5, map[[3]int]string{{0, 0, 0}: BlockDirt})
+
+	got, err := w.ChunkCounts(3, 4, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := BlockCounts{BlockAir: 2*subChunkBlockCount - 4, BlockStone: 2, BlockDirt: 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v: got %v", want, got)
+	}
+
+	if id, n := got.Dominant(); id != BlockAir || n != want[BlockAir] {
+		t.Errorf("expected air to be dominant: got %s %d", id, n)
+	}
+
+	// Ties go to the first ID alphabetically
+	if id, n := got.Dominant(BlockAir); id != BlockDirt || n != 2 {
+		t.Errorf("expected dirt to be dominant ignoring air: got %s %d", id, n)
+	}
+
+	if id, n := (BlockCounts{BlockAir: 5}).Dominant(BlockAir); id != "" || n != 0 {
+		t.Errorf("expected no dominant block: got %s %d", id, n)
+	}
+
+	if _, err := w.ChunkCounts(0, 0, 9); err == nil {
+		t.Error("expected an error for an unknown dimension")
+	}
+}
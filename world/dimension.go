@@ -0,0 +1,91 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Dimension is a dimension of a world. Functions taking a dimension as an int accept int(Dimension).
+type Dimension int
+
+// Dimensions.
+const (
+	Overworld Dimension = iota
+	Nether
+	End
+)
+
+var dimensionNames = []string{"overworld", "nether", "end"}
+
+// ErrInvalidDimension is returned for dimension numbers other than those of the overworld, nether and end.
+var ErrInvalidDimension = errors.New("invalid dimension")
+
+// ErrOutsideHeight is returned for a Y coordinate above or below the blocks of a dimension.
+var ErrOutsideHeight = errors.New("y is outside the height of the dimension")
+
+func (d Dimension) String() string {
+	return enumName(dimensionNames, int(d), "Dimension")
+}
+
+// ParseDimension returns the dimension with the given name, e.g. nether, or number, e.g. 1.
+func ParseDimension(s string) (Dimension, error) {
+	if i, err := strconv.Atoi(s); err == nil {
+		if !Dimension(i).Valid() {
+			return 0, fmt.Errorf("%w %d: expected 0 to %d", ErrInvalidDimension, i, len(dimensionNames)-1)
+		}
+		return Dimension(i), nil
+	}
+
+	i, err := parseEnum(dimensionNames, s, "dimension")
+	return Dimension(i), err
+}
+
+// Valid returns true if d is the overworld, nether or end.
+func (d Dimension) Valid() bool {
+	_, ok := subChunkRanges[int(d)]
+	return ok
+}
+
+// HeightRange returns the lowest and highest Y of blocks in the dimension: -64 to 319 in the overworld, 0 to 127 in
+// the nether and 0 to 255 in the end. Both are 0 for an invalid dimension.
+func (d Dimension) HeightRange() (minY, maxY int) {
+	r, ok := subChunkRanges[int(d)]
+	if !ok {
+		return 0, 0
+	}
+
+	return int(r[0]) * chunkSize, int(r[1])*chunkSize + chunkSize - 1
+}
+
+// checkHeight returns an error wrapping ErrInvalidDimension if the dimension is not valid, or an *OutOfBoundsError
+// wrapping ErrOutsideHeight if y is outside its height.
+func checkHeight(y, dimension int) error {
+	d := Dimension(dimension)
+	if !d.Valid() {
+		return fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	if minY, maxY := d.HeightRange(); y < minY || y > maxY {
+		return &OutOfBoundsError{Axis: "y", Value: y, Min: minY, Max: maxY, Bounds: d.String(), Err: ErrOutsideHeight}
+	}
+
+	return nil
+}
+
+// MarshalText encodes the dimension as its name.
+func (d Dimension) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText decodes a dimension name or number, so config files may give either.
+func (d *Dimension) UnmarshalText(text []byte) error {
+	v, err := ParseDimension(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = v
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package world
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestParseDimension(t *testing.T) {
+	cases := map[string]Dimension{"overworld": Overworld, "Nether": Nether, "END": End, "0": Overworld, "2": End}
+
+	for s, want := range cases {
+		got, err := ParseDimension(s)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", s, err)
+			continue
+		}
+
+		if got != want {
+			t.Errorf("%q: expected %s: got %s", s, want, got)
+		}
+	}
+
+	for _, s := range []string{"", "hell", "3", "-1"} {
+		if _, err := ParseDimension(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+
+	var d Dimension
+	if err := d.UnmarshalText([]byte("nether")); err != nil || d != Nether {
+		t.Errorf("expected nether to unmarshal to %s: got %s, %v", Nether, d, err)
+	}
+
+	if text, _ := End.MarshalText(); string(text) != "end" {
+		t.Errorf("expected End to marshal to end: got %s", text)
+	}
+
+	if s := Dimension(7).String(); s != "Dimension(7)" {
+		t.Errorf("expected an unknown dimension to be shown as Dimension(7): got %s", s)
+	}
+}
+
+func TestIDConstants(t *testing.T) {
+	if BiomeCherryGrove.String() != "cherry_grove" || BiomeTheEnd != 9 {
+		t.Errorf("expected biome constants to match the biome table: got %s, %d", BiomeCherryGrove, BiomeTheEnd)
+	}
+
+	if s := Biome(500).String(); s != "biome 500" {
+		t.Errorf("expected an unknown biome to be shown as its ID: got %s", s)
+	}
+
+	if BlockDiamondOre != "minecraft:diamond_ore" {
+		t.Errorf("unexpected block ID %s", BlockDiamondOre)
+	}
+}
+
+func TestDimensionHeight(t *testing.T) {
+	for d, want := range map[Dimension][2]int{Overworld: {-64, 319}, Nether: {0, 127}, End: {0, 255}} {
+		if minY, maxY := d.HeightRange(); minY != want[0] || maxY != want[1] {
+			t.Errorf("%s: expected %d to %d: got %d to %d", d, want[0], want[1], minY, maxY)
+		}
+	}
+
+	if Dimension(3).Valid() {
+		t.Error("expected dimension 3 to be invalid")
+	}
+
+	w := NewFromDB(mock.NewLevelDB())
+
+	for _, c := range []struct {
+		y, dimension int
+		want         error
+	}{
+		{-64, int(Overworld), &SubChunkNotSavedError{}},
+		{-1, int(Nether), ErrOutsideHeight},
+		{128, int(Nether), ErrOutsideHeight},
+		{200, int(End), &SubChunkNotSavedError{}},
+		{0, 3, ErrInvalidDimension},
+	} {
+		if _, err := w.GetBlock(0, c.y, 0, c.dimension); !errors.Is(err, c.want) {
+			t.Errorf("y %d in dimension %d: expected %v: got %v", c.y, c.dimension, c.want, err)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+)
+
+// EditorSession bundles the state needed by an interactive editor: a selection, a mask restricting which blocks are
+// affected, a clipboard and an undo journal. Frontends make every change through Do so that it can be undone.
+//
+//	s := world.NewEditorSession(w)
+//	s.Select(world.NewSelection(0, 0, 0, 15, 63, 15, 0))
+//	s.Mask = func(b world.Block, _ int) (bool, error) { return b.ID != "minecraft:air", nil }
+//	err := s.Copy()
+//	err = s.Do(func(w *world.World) error { return w.RemoveEntity(e) })
+//	err = s.Undo()
+type EditorSession struct {
+	World     *World
+	Selection Selection
+	Mask      BlockMatcher // Blocks for which the mask returns false are ignored. A nil mask matches every block.
+	Clipboard *Clipboard
+
+	// If PasteReset is not nil, Paste resets the volatile states of the pasted blocks as part of the same step
+	PasteReset *StateReset
+	// If PasteSettle is true, Paste drops unsupported gravity blocks in the pasted region as part of the same step
+	PasteSettle bool
+
+	undo, redo [][]journalEntry
+}
+
+// journalEntry is the value of a record before it was changed.
+type journalEntry struct {
+	key     []byte
+	value   []byte
+	existed bool
+}
+
+// ErrNothingToUndo is returned by Undo and Redo when there is no step to undo or redo.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// NewEditorSession returns a session editing the given world.
+func NewEditorSession(w *World) *EditorSession {
+	return &EditorSession{World: w}
+}
+
+// Selection is a box of blocks in one dimension. Min and Max are inclusive.
+type Selection struct {
+	Min, Max  [3]int
+	Dimension int
+}
+
+// NewSelection returns the selection spanning the two given corners.
+func NewSelection(x1, y1, z1, x2, y2, z2, dimension int) Selection {
+	s := Selection{Min: [3]int{x1, y1, z1}, Max: [3]int{x2, y2, z2}, Dimension: dimension}
+
+	for i := 0; i < 3; i++ {
+		if s.Min[i] > s.Max[i] {
+			s.Min[i], s.Max[i] = s.Max[i], s.Min[i]
+		}
+	}
+
+	return s
+}
+
+// Size returns the number of blocks along each axis of the selection.
+func (s Selection) Size() (x, y, z int) {
+	return s.Max[0] - s.Min[0] + 1, s.Max[1] - s.Min[1] + 1, s.Max[2] - s.Min[2] + 1
+}
+
+// Contains returns true if the given coordinates are inside the selection.
+func (s Selection) Contains(x, y, z, dimension int) bool {
+	return dimension == s.Dimension &&
+		x >= s.Min[0] && x <= s.Max[0] &&
+		y >= s.Min[1] && y <= s.Max[1] &&
+		z >= s.Min[2] && z <= s.Max[2]
+}
+
+// Clipboard holds blocks copied from a selection. Block coordinates are relative to the lowest corner of the selection.
+type Clipboard struct {
+	SizeX, SizeY, SizeZ int
+	Blocks              []Block
+}
+
+// Select replaces the session's selection.
+func (s *EditorSession) Select(sel Selection) {
+	s.Selection = sel
+}
+
+// SelectedBlocks returns every saved block inside the selection which matches the mask.
+func (s *EditorSession) SelectedBlocks() ([]Block, error) {
+	sel := s.Selection
+	blocks := make([]Block, 0)
+
+	it, err := s.World.GetBlocks(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	for it.Next() {
+		b := it.Block()
+
+		if s.Mask != nil {
+			ok, err := s.Mask(b, sel.Dimension)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		blocks = append(blocks, b)
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("getting selected blocks: %w", err)
+	}
+
+	return blocks, nil
+}
+
+// Copy replaces the clipboard with the selected blocks which match the mask.
+func (s *EditorSession) Copy() error {
+	blocks, err := s.SelectedBlocks()
+	if err != nil {
+		return err
+	}
+
+	c := &Clipboard{Blocks: blocks}
+	c.SizeX, c.SizeY, c.SizeZ = s.Selection.Size()
+
+	for i := range c.Blocks {
+		c.Blocks[i].X -= s.Selection.Min[0]
+		c.Blocks[i].Y -= s.Selection.Min[1]
+		c.Blocks[i].Z -= s.Selection.Min[2]
+	}
+
+	s.Clipboard = c
+
+	return nil
+}
+
+// ErrEmptyClipboard is returned by Paste when nothing has been copied.
+var ErrEmptyClipboard = errors.New("clipboard is empty")
+
+// Paste sets the blocks in the clipboard with the lowest corner of the copied selection at the given coordinates, as
+// one undoable step. Positions which were not saved or didn't match the mask when copied are left unchanged. If the
+// session has a PasteReset, the volatile states of blocks in the pasted region are then reset, and if PasteSettle is
+// true its unsupported gravity blocks are dropped.
+func (s *EditorSession) Paste(x, y, z, dimension int) error {
+	c := s.Clipboard
+	if c == nil {
+		return ErrEmptyClipboard
+	}
+
+	blocks := make([]Block, len(c.Blocks))
+	for i, b := range c.Blocks {
+		b.X, b.Y, b.Z = b.X+x, b.Y+y, b.Z+z
+		blocks[i] = b
+	}
+
+	return s.Do(func(w *World) error {
+		if err := w.SetBlocks(blocks, dimension); err != nil {
+			return err
+		}
+
+		region := NewSelection(x, y, z, x+c.SizeX-1, y+c.SizeY-1, z+c.SizeZ-1, dimension)
+
+		if s.PasteReset != nil {
+			if _, err := w.ResetStates(region, *s.PasteReset); err != nil {
+				return fmt.Errorf("resetting pasted block states: %w", err)
+			}
+		}
+
+		if s.PasteSettle {
+			if _, err := w.SettleBlocks(region); err != nil {
+				return fmt.Errorf("settling pasted blocks: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Do calls edit and records every change it makes to the world as one undoable step. If edit returns an error the
+// changes it made are rolled back.
+func (s *EditorSession) Do(edit func(w *World) error) error {
+	step, err := s.record(func() error { return edit(s.World) })
+	if err != nil {
+		if _, rollbackErr := s.record(func() error { return s.World.restore(step) }); rollbackErr != nil {
+			return fmt.Errorf("%w (rolling back: %s)", err, rollbackErr)
+		}
+		return err
+	}
+
+	if len(step) == 0 {
+		return nil
+	}
+
+	s.undo = append(s.undo, step)
+	s.redo = nil
+
+	return nil
+}
+
+// Undo reverts the most recent step.
+func (s *EditorSession) Undo() error {
+	return s.replay(&s.undo, &s.redo)
+}
+
+// Redo reapplies the most recently undone step.
+func (s *EditorSession) Redo() error {
+	return s.replay(&s.redo, &s.undo)
+}
+
+// CanUndo returns true if there is a step to undo.
+func (s *EditorSession) CanUndo() bool {
+	return len(s.undo) > 0
+}
+
+// CanRedo returns true if there is an undone step to redo.
+func (s *EditorSession) CanRedo() bool {
+	return len(s.redo) > 0
+}
+
+// replay restores the last step in from and pushes the changes it made onto to.
+func (s *EditorSession) replay(from, to *[][]journalEntry) error {
+	if len(*from) == 0 {
+		return ErrNothingToUndo
+	}
+
+	step := (*from)[len(*from)-1]
+
+	reverse, err := s.record(func() error { return s.World.restore(step) })
+	if err != nil {
+		return err
+	}
+
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, reverse)
+
+	return nil
+}
+
+// record calls f with journaling enabled and returns the previous values of the records it changed.
+func (s *EditorSession) record(f func() error) ([]journalEntry, error) {
+	step := make([]journalEntry, 0)
+	s.World.journal = &step
+	defer func() { s.World.journal = nil }()
+
+	err := f()
+
+	return step, err
+}
+
+// restore writes back the journaled values of records in reverse order.
+func (w *World) restore(step []journalEntry) error {
+	for i := len(step) - 1; i >= 0; i-- {
+		e := step[i]
+
+		var err error
+		if e.existed {
+			err = w.put(e.key, e.value)
+		} else {
+			err = w.delete(e.key)
+		}
+
+		if err != nil {
+			return fmt.Errorf("restoring key '%x': %w", e.key, err)
+		}
+	}
+
+	return nil
+}
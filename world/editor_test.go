@@ -0,0 +1,110 @@
+package world
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+func TestEditorSessionUndo(t *testing.T) {
+	w, db := testEntityWorld(t)
+	snapshot := func() map[string]string {
+		keys, _ := db.Keys()
+		m := make(map[string]string)
+		for _, k := range keys {
+			v, _ := db.Get(k)
+			m[string(k)] = string(v)
+		}
+		return m
+	}
+
+	original := snapshot()
+	s := NewEditorSession(w)
+
+	err := s.Do(func(w *World) error {
+		entities, err := w.Entities()
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entities {
+			if _, err := w.MoveEntity(e, 40, 64, 40, 0); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	moved := snapshot()
+	if reflect.DeepEqual(moved, original) {
+		t.Fatalf("expected the edit to change the world")
+	}
+
+	if err := s.Undo(); err != nil {
+		t.Fatalf("unexpected error undoing: %s", err)
+	}
+
+	if !reflect.DeepEqual(snapshot(), original) {
+		t.Fatalf("expected undo to restore the original records")
+	}
+
+	if err := s.Redo(); err != nil {
+		t.Fatalf("unexpected error redoing: %s", err)
+	}
+
+	if !reflect.DeepEqual(snapshot(), moved) {
+		t.Fatalf("expected redo to restore the edited records")
+	}
+
+	if err := s.Redo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("expected ErrNothingToUndo: got %v", err)
+	}
+
+	// A failed edit is rolled back and not added to the journal
+	err = s.Do(func(w *World) error {
+		_ = w.delete(leveldb.DigestKey(2, 2, 0))
+		_ = w.put([]byte("partial"), []byte{1})
+		return errors.New("failed")
+	})
+	if err == nil {
+		t.Fatalf("expected error from failed edit")
+	}
+
+	if !reflect.DeepEqual(snapshot(), moved) {
+		t.Fatalf("expected failed edit to be rolled back")
+	}
+
+	if err := s.Undo(); err != nil || !reflect.DeepEqual(snapshot(), original) {
+		t.Fatalf("expected undo after a failed edit to undo the move: got error %v", err)
+	}
+}
+
+func TestEditorSessionCopy(t *testing.T) {
+	w, db := testEntityWorld(t)
+
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{1, 2, 3}: "minecraft:chest", {2, 2, 3}: "minecraft:stone"}))
+
+	s := NewEditorSession(w)
+	s.Select(NewSelection(2, 4, 4, 1, 2, 2, 0))
+	s.Mask = func(b Block, _ int) (bool, error) { return b.ID != "minecraft:air", nil }
+
+	if err := s.Copy(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := &Clipboard{
+		SizeX: 2, SizeY: 3, SizeZ: 3,
+		Blocks: []Block{testBlock("minecraft:chest", 0, 0, 1), testBlock("minecraft:stone", 1, 0, 1)},
+	}
+
+	if !reflect.DeepEqual(s.Clipboard, want) {
+		t.Fatalf("expected clipboard %+v: got %+v", want, s.Clipboard)
+	}
+}
@@ -0,0 +1,202 @@
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// validBitsPerBlock are the index sizes supported by the Bedrock block storage format.
+var validBitsPerBlock = []int{1, 2, 3, 4, 5, 6, 8, 16}
+
+// ReEncodeSubChunk decodes raw sub chunk data and encodes it again. If normalize is true the palette is normalized as
+// described by encodeSubChunk, otherwise well-formed data is returned byte-for-byte identical.
+func ReEncodeSubChunk(data []byte, normalize bool) ([]byte, error) {
+	s, err := parseSubChunk(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sub chunk: %w", err)
+	}
+
+	return encodeSubChunk(s, normalize)
+}
+
+// encodeSubChunk serializes sub chunk data in the format read by parseSubChunk. If normalize is true, duplicate palette
+// entries are merged, unreferenced entries are dropped and the smallest valid bitsPerBlock for the palette size is
+// used, usually producing a smaller record than the game wrote. If normalize is false the stored bitsPerBlock and
+// palette are written as they are, for byte-exact round tripping.
+func encodeSubChunk(s *subChunkData, normalize bool) ([]byte, error) {
+	storages := append([]blockStorage{s.Blocks}, s.Extra...)
+
+	buf := bytes.Buffer{}
+
+	switch s.Version {
+	case 1:
+		if len(storages) > 1 {
+			return nil, fmt.Errorf("version 1 sub chunks may only have one block storage: got %d", len(storages))
+		}
+		buf.WriteByte(1)
+	case 8:
+		buf.WriteByte(8)
+		buf.WriteByte(byte(len(storages)))
+	case 9:
+		buf.WriteByte(9)
+		buf.WriteByte(byte(len(storages)))
+		buf.WriteByte(byte(s.YIndex))
+	default:
+		return nil, &UnsupportedVersionError{Format: "sub chunk", Version: int(s.Version)}
+	}
+
+	for i, storage := range storages {
+		if normalize {
+			var err error
+			if storage, err = normalizeBlockStorage(storage); err != nil {
+				return nil, fmt.Errorf("normalizing storage %d: %w", i, err)
+			}
+		}
+
+		if err := writeBlockStorage(&buf, storage); err != nil {
+			return nil, fmt.Errorf("writing storage %d: %w", i, err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// normalizeBlockStorage returns a copy of s with duplicate palette entries merged, unreferenced palette entries removed
+// and the minimal bitsPerBlock for the resulting palette. Palette order is otherwise preserved.
+func normalizeBlockStorage(s blockStorage) (blockStorage, error) {
+	remap := make([]int, len(s.Palette))
+	seen := make(map[string]int)
+	palette := make([]nbt.NBTTag, 0, len(s.Palette))
+
+	used := make([]bool, len(s.Palette))
+	for _, i := range s.Indices {
+		if i >= len(s.Palette) {
+			return blockStorage{}, fmt.Errorf("index %d out of range of palette with length %d", i, len(s.Palette))
+		}
+		used[i] = true
+	}
+
+	for i, tag := range s.Palette {
+		if !used[i] {
+			remap[i] = -1
+			continue
+		}
+
+		b, err := nbt.Encode([]nbt.NBTTag{tag})
+		if err != nil {
+			return blockStorage{}, fmt.Errorf("encoding palette entry %d: %w", i, err)
+		}
+
+		if j, ok := seen[string(b)]; ok {
+			remap[i] = j
+			continue
+		}
+
+		seen[string(b)] = len(palette)
+		remap[i] = len(palette)
+		palette = append(palette, tag)
+	}
+
+	indices := make([]int, len(s.Indices))
+	for i, p := range s.Indices {
+		indices[i] = remap[p]
+	}
+
+	return blockStorage{
+		BitsPerBlock: minimalBitsPerBlock(len(palette)),
+		Indices:      indices,
+		Palette:      palette,
+	}, nil
+}
+
+// minimalBitsPerBlock returns the smallest supported bitsPerBlock able to index a palette of the given size.
+func minimalBitsPerBlock(paletteSize int) int {
+	required := int(math.Ceil(math.Log2(float64(paletteSize))))
+
+	for _, b := range validBitsPerBlock {
+		if b >= required {
+			return b
+		}
+	}
+
+	return validBitsPerBlock[len(validBitsPerBlock)-1]
+}
+
+// writeBlockStorage writes a single block storage record: the bitsPerBlock/version byte, the packed index words, the
+// palette size and the palette NBT.
+func writeBlockStorage(buf *bytes.Buffer, s blockStorage) error {
+	if (s.BitsPerBlock != 0 && !validBitsPerBlockValue(s.BitsPerBlock)) || (s.BitsPerBlock == 0 && len(s.Palette) != 1) {
+		return fmt.Errorf("invalid bits per block %d for palette of %d entries", s.BitsPerBlock, len(s.Palette))
+	}
+
+	if len(s.Indices) != subChunkBlockCount {
+		return fmt.Errorf("expected %d indices: got %d", subChunkBlockCount, len(s.Indices))
+	}
+
+	// The lowest bit is the storage version, which is always 0 for save files
+	buf.WriteByte(byte(s.BitsPerBlock << 1))
+
+	wordCount := 0
+	if s.BitsPerBlock > 0 {
+		wordCount = int(math.Ceil(subChunkBlockCount / float64(32/s.BitsPerBlock)))
+	}
+
+	if s.uniform() {
+		// Every index of a one block storage is 0, so the words are all 0
+		for _, i := range s.Indices {
+			if i != 0 {
+				return fmt.Errorf("index %d out of range of palette with length 1", i)
+			}
+		}
+
+		buf.Write(make([]byte, wordCount*4))
+	} else if err := writeIndices(buf, s, wordCount); err != nil {
+		return err
+	}
+
+	if err := binary.Write(buf, binary.LittleEndian, int32(len(s.Palette))); err != nil {
+		return fmt.Errorf("writing palette size: %w", err)
+	}
+
+	palette, err := nbt.Encode(s.Palette)
+	if err != nil {
+		return fmt.Errorf("encoding palette: %w", err)
+	}
+
+	buf.Write(palette)
+
+	return nil
+}
+
+// writeIndices writes the packed index words of a block storage.
+func writeIndices(buf *bytes.Buffer, s blockStorage, wordCount int) error {
+	blocksPerWord := 32 / s.BitsPerBlock
+	mask := uint32(1<<s.BitsPerBlock) - 1
+
+	for w := 0; w < wordCount; w++ {
+		var word uint32
+
+		for b := 0; b < blocksPerWord; b++ {
+			i := w*blocksPerWord + b
+			if i >= subChunkBlockCount {
+				break
+			}
+
+			if uint32(s.Indices[i]) > mask {
+				return fmt.Errorf("index %d does not fit in %d bits", s.Indices[i], s.BitsPerBlock)
+			}
+
+			word |= uint32(s.Indices[i]) << (b * s.BitsPerBlock)
+		}
+
+		if err := binary.Write(buf, binary.LittleEndian, word); err != nil {
+			return fmt.Errorf("writing word %d: %w", w, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package world
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestEncodeSubChunkRoundTrip(t *testing.T) {
+	b, err := ReEncodeSubChunk(mock.SubChunkValue, false)
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	if !bytes.Equal(b, mock.SubChunkValue) {
+		t.Errorf("re-encoded sub chunk is not identical to the original: got %d bytes, expected %d",
+			len(b), len(mock.SubChunkValue))
+	}
+}
+
+func TestEncodeSubChunkNormalize(t *testing.T) {
+	original, err := parseSubChunk(mock.SubChunkValue)
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	b, err := ReEncodeSubChunk(mock.SubChunkValue, true)
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	normalized, err := parseSubChunk(b)
+	if err != nil {
+		t.Fatalf("unexpected error parsing normalized sub chunk: %s", err)
+	}
+
+	for i := range original.Blocks.Indices {
+		want := original.Blocks.Palette[original.Blocks.Indices[i]].BlockID()
+		got := normalized.Blocks.Palette[normalized.Blocks.Indices[i]].BlockID()
+
+		if want != got {
+			t.Fatalf("block %d changed from '%s' to '%s' when normalized", i, want, got)
+		}
+	}
+}
+
+func TestNormalizeBlockStorage(t *testing.T) {
+	air := nbt.NBTTag{Type: 10, Value: []interface{}{
+		map[string]interface{}{"tagType": 8, "name": "name", "value": "minecraft:air"},
+	}}
+	stone := nbt.NBTTag{Type: 10, Value: []interface{}{
+		map[string]interface{}{"tagType": 8, "name": "name", "value": "minecraft:stone"},
+	}}
+
+	s := blockStorage{
+		BitsPerBlock: 4,
+		Indices:      make([]int, subChunkBlockCount),
+		Palette:      []nbt.NBTTag{air, stone, air, stone},
+	}
+
+	s.Indices[1] = 2
+	s.Indices[2] = 3
+
+	n, err := normalizeBlockStorage(s)
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	if len(n.Palette) != 2 {
+		t.Fatalf("expected 2 palette entries: got %d", len(n.Palette))
+	}
+
+	if n.BitsPerBlock != 1 {
+		t.Errorf("expected 1 bit per block: got %d", n.BitsPerBlock)
+	}
+
+	if n.Indices[0] != 0 || n.Indices[1] != 0 || n.Indices[2] != 1 {
+		t.Errorf("unexpected indices after normalizing: %v", n.Indices[:3])
+	}
+
+	buf := bytes.Buffer{}
+	if err := writeBlockStorage(&buf, n); err != nil {
+		t.Fatalf("unexpected error writing storage: %s", err)
+	}
+
+	read, err := parseBlockStorage(bytes.NewReader(buf.Bytes()), 0)
+	if err != nil {
+		t.Fatalf("unexpected error reading storage: %s", err)
+	}
+
+	for i := range n.Indices {
+		if read.Indices[i] != n.Indices[i] {
+			t.Fatalf("index %d was %d after encoding: expected %d", i, read.Indices[i], n.Indices[i])
+		}
+	}
+}
+
+func TestEncodeUniformSubChunk(t *testing.T) {
+	stone := paletteEntry(BlockStone)
+
+	for _, bits := range []int{0, 1, 4} {
+		s := &subChunkData{Version: 8, Blocks: blockStorage{
+			BitsPerBlock: bits,
+			Indices:      make([]int, subChunkBlockCount),
+			Palette:      []nbt.NBTTag{stone},
+		}}
+
+		b, err := encodeSubChunk(s, false)
+		if err != nil {
+			t.Fatalf("%d bits: unexpected error encoding: %s", bits, err)
+		}
+
+		parsed, err := parseSubChunk(b)
+		if err != nil {
+			t.Fatalf("%d bits: unexpected error parsing: %s", bits, err)
+		}
+
+		if parsed.Blocks.BitsPerBlock != bits || !parsed.Blocks.uniform() || parsed.Blocks.Indices[4095] != 0 {
+			t.Errorf("%d bits: expected a uniform storage with the same bits per block: got %d bits, %d entries",
+				bits, parsed.Blocks.BitsPerBlock, len(parsed.Blocks.Palette))
+		}
+
+		if again, _ := encodeSubChunk(parsed, false); !bytes.Equal(again, b) {
+			t.Errorf("%d bits: re-encoded uniform sub chunk is not identical", bits)
+		}
+	}
+
+	// An unused palette entry is dropped, leaving the minimal layout of one entry and one bit per block
+	s := &subChunkData{Version: 8, Blocks: blockStorage{
+		BitsPerBlock: 4,
+		Indices:      make([]int, subChunkBlockCount),
+		Palette:      []nbt.NBTTag{stone, paletteEntry(BlockDirt)},
+	}}
+
+	b, err := encodeSubChunk(s, true)
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+
+	// Version, storage count, bits per block, 128 words, palette size and the palette
+	palette, _ := nbt.Encode([]nbt.NBTTag{stone})
+	if want := 3 + 128*4 + 4 + len(palette); len(b) != want {
+		t.Errorf("expected %d bytes: got %d", want, len(b))
+	}
+
+	if _, err := encodeSubChunk(&subChunkData{Version: 8, Blocks: blockStorage{
+		Indices: make([]int, subChunkBlockCount),
+		Palette: []nbt.NBTTag{stone, stone},
+	}}, false); err == nil {
+		t.Error("expected an error encoding 0 bits per block with more than one palette entry")
+	}
+}
@@ -0,0 +1,375 @@
+package world
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// Entity is a mob, item, projectile or other actor stored in the world.
+type Entity struct {
+	Identifier string // The entity type e.g. minecraft:cow
+	UniqueID   int64
+	X, Y, Z    float64
+	Dimension  int
+	NBT        nbt.NBTTag // The full compound tag for the entity
+
+	// The chunk the entity is stored in, which is not always the chunk containing its position
+	chunkX, chunkZ int32
+	// The actor storage ID, which is nil for entities stored in legacy per chunk Entity records
+	storageID []byte
+}
+
+// Entities returns every entity in the world, read from both the legacy per chunk Entity records and the actor digest
+// format used since 1.18.30.
+func (w *World) Entities() ([]Entity, error) {
+	return w.entities(w.startBudget())
+}
+
+// entities is Entities for operations which scan the world more than once within the time budget b.
+func (w *World) entities(b *scanBudget) ([]Entity, error) {
+	entities := make([]Entity, 0)
+
+	err := w.forEachEntity(b, nil, func(e Entity) error {
+		entities = append(entities, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entities, nil
+}
+
+// ForEachEntity calls f with every entity in the world, as returned by Entities, without holding them all in memory.
+// Iteration stops if f returns an error.
+func (w *World) ForEachEntity(f func(e Entity) error) error {
+	return w.forEachEntity(w.startBudget(), nil, f)
+}
+
+// scanEntities is ForEachEntity for scans which only read the entities passed to f. Entities are decoded into pooled
+// memory which is reused once f has seen every entity in a record, so f must not keep them or their NBT.
+func (w *World) scanEntities(b *scanBudget, f func(e Entity) error) error {
+	d := nbt.NewDecoder()
+	defer d.Release()
+
+	return w.forEachEntity(b, d, f)
+}
+
+// forEachEntity calls f with every entity in the world, decoded by d, releasing d after each record. Iteration stops
+// if f returns an error or the time budget b runs out.
+func (w *World) forEachEntity(b *scanBudget, d *nbt.Decoder, f func(e Entity) error) error {
+	return w.eachKey(b, func(key []byte) error {
+		var entities []Entity
+		var err error
+
+		defer d.Release()
+
+		if k, ok := leveldb.ParseChunkKey(key); ok && k.Tag == leveldb.Entity {
+			entities, err = w.legacyEntities(d, k)
+		} else if x, z, dimension, ok := leveldb.ParseDigestKey(key); ok {
+			entities, err = w.digestEntities(d, x, z, dimension)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entities {
+			if err := f(e); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ChunkEntities returns the entities stored in the chunk with the given chunk coordinates, from its legacy Entity
+// record and its actor digest. Entities are stored in the chunk they were in when it was last saved, so some may have
+// positions outside it.
+func (w *World) ChunkEntities(cx, cz, dimension int) ([]Entity, error) {
+	if !Dimension(dimension).Valid() {
+		return nil, fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension), Tag: leveldb.Entity}
+
+	entities, err := w.legacyEntities(nil, k)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		entities = make([]Entity, 0)
+	} else if err != nil {
+		return nil, err
+	}
+
+	actors, err := w.digestEntities(nil, k.X, k.Z, k.Dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(entities, actors...), nil
+}
+
+// legacyEntities returns the entities stored in the Entity record with the given key, decoded by d.
+func (w *World) legacyEntities(d *nbt.Decoder, k leveldb.ChunkKey) ([]Entity, error) {
+	value, err := w.db.Get(k.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("getting entities with key '%x': %w", k.Bytes(), err)
+	}
+
+	tags, err := d.Decode(value)
+	if err != nil {
+		return nil, fmt.Errorf("decoding entities with key '%x': %w", k.Bytes(), err)
+	}
+
+	entities := make([]Entity, len(tags))
+	for i, t := range tags {
+		entities[i] = parseEntity(t)
+		entities[i].Dimension = int(k.Dimension)
+		entities[i].chunkX, entities[i].chunkZ = k.X, k.Z
+	}
+
+	return entities, nil
+}
+
+// digestEntities returns the entities listed in the actor digest for the given chunk, decoded by d.
+func (w *World) digestEntities(d *nbt.Decoder, x, z, dimension int32) ([]Entity, error) {
+	ids, err := w.digest(x, z, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]Entity, 0, len(ids))
+
+	for _, id := range ids {
+		value, err := w.db.Get(leveldb.ActorKey(id))
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting actor '%x': %w", id, err)
+		}
+
+		tags, err := d.Decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("decoding actor '%x': %w", id, err)
+		}
+		if len(tags) != 1 {
+			return nil, fmt.Errorf("decoding actor '%x': expected one tag: got %d tags", id, len(tags))
+		}
+
+		e := parseEntity(tags[0])
+		e.Dimension = int(dimension)
+		e.chunkX, e.chunkZ = x, z
+		e.storageID = id
+		entities = append(entities, e)
+	}
+
+	return entities, nil
+}
+
+// digest returns the actor storage IDs listed in the digest record for the given chunk.
+func (w *World) digest(x, z, dimension int32) ([][]byte, error) {
+	key := leveldb.DigestKey(x, z, dimension)
+
+	value, err := w.db.Get(key)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting actor digest with key '%x': %w", key, err)
+	}
+
+	if len(value)%8 != 0 {
+		return nil, fmt.Errorf("actor digest with key '%x' has length %d: expected a multiple of 8", key, len(value))
+	}
+
+	ids := make([][]byte, len(value)/8)
+	for i := range ids {
+		ids[i] = value[i*8 : i*8+8]
+	}
+
+	return ids, nil
+}
+
+func parseEntity(t nbt.NBTTag) Entity {
+	e := Entity{NBT: t}
+
+	if id, ok := t.Child("identifier"); ok {
+		e.Identifier, _ = id.StringValue()
+	}
+
+	if id, ok := t.Child("UniqueID"); ok {
+		e.UniqueID, _ = id.Int()
+	}
+
+	if pos, ok := t.Child("Pos"); ok {
+		if p := pos.List(); len(p) == 3 {
+			e.X, _ = p[0].Float()
+			e.Y, _ = p[1].Float()
+			e.Z, _ = p[2].Float()
+		}
+	}
+
+	return e
+}
+
+// RemoveEntity deletes the given entity from the world.
+func (w *World) RemoveEntity(e Entity) error {
+	if err := w.removeEntity(e); err != nil {
+		return err
+	}
+
+	w.notify(ChangeEvent{Kind: EntityRemoved, Entity: &e})
+
+	return nil
+}
+
+func (w *World) removeEntity(e Entity) error {
+	if e.storageID != nil {
+		if err := w.delete(leveldb.ActorKey(e.storageID)); err != nil {
+			return fmt.Errorf("deleting actor '%x': %w", e.storageID, err)
+		}
+
+		return w.updateDigest(e.chunkX, e.chunkZ, int32(e.Dimension), func(ids [][]byte) [][]byte {
+			kept := make([][]byte, 0, len(ids))
+			for _, id := range ids {
+				if !bytes.Equal(id, e.storageID) {
+					kept = append(kept, id)
+				}
+			}
+			return kept
+		})
+	}
+
+	k := leveldb.ChunkKey{X: e.chunkX, Z: e.chunkZ, Dimension: int32(e.Dimension), Tag: leveldb.Entity}
+
+	entities, err := w.legacyEntities(nil, k)
+	if err != nil {
+		return err
+	}
+
+	tags := make([]nbt.NBTTag, 0, len(entities))
+	for _, other := range entities {
+		if other.UniqueID != e.UniqueID {
+			tags = append(tags, other.NBT)
+		}
+	}
+
+	return w.putTags(k.Bytes(), tags)
+}
+
+// MoveEntity changes the position of the given entity, moving its record to the chunk containing the new position in
+// the given dimension. The updated entity is returned.
+func (w *World) MoveEntity(e Entity, x, y, z float64, dimension int) (Entity, error) {
+	if err := w.RemoveEntity(e); err != nil {
+		return Entity{}, fmt.Errorf("removing entity from original chunk: %w", err)
+	}
+
+	pos, ok := e.NBT.Child("Pos")
+	if !ok {
+		pos = nbt.NBTTag{Type: nbt.TagList, Name: "Pos", Value: map[string]interface{}{"tagListType": nbt.TagFloat}}
+	}
+
+	if err := pos.SetList([]nbt.NBTTag{
+		{Type: nbt.TagFloat, Value: x},
+		{Type: nbt.TagFloat, Value: y},
+		{Type: nbt.TagFloat, Value: z},
+	}); err != nil {
+		return Entity{}, fmt.Errorf("setting position: %w", err)
+	}
+
+	if err := e.NBT.SetChild(pos); err != nil {
+		return Entity{}, fmt.Errorf("setting position: %w", err)
+	}
+
+	e.X, e.Y, e.Z = x, y, z
+	e.Dimension = dimension
+	e.chunkX = int32(math.Floor(x / chunkSize))
+	e.chunkZ = int32(math.Floor(z / chunkSize))
+
+	if err := w.putEntity(e); err != nil {
+		return Entity{}, fmt.Errorf("writing entity to new chunk: %w", err)
+	}
+
+	w.notify(ChangeEvent{Kind: EntityAdded, Entity: &e})
+
+	return e, nil
+}
+
+// putEntity adds an entity to the records of the chunk it is stored in, using the storage format it was read from.
+func (w *World) putEntity(e Entity) error {
+	if e.storageID != nil {
+		data, err := nbt.Encode([]nbt.NBTTag{e.NBT})
+		if err != nil {
+			return fmt.Errorf("encoding actor: %w", err)
+		}
+
+		if err := w.put(leveldb.ActorKey(e.storageID), data); err != nil {
+			return fmt.Errorf("putting actor '%x': %w", e.storageID, err)
+		}
+
+		return w.updateDigest(e.chunkX, e.chunkZ, int32(e.Dimension), func(ids [][]byte) [][]byte {
+			return append(ids, e.storageID)
+		})
+	}
+
+	k := leveldb.ChunkKey{X: e.chunkX, Z: e.chunkZ, Dimension: int32(e.Dimension), Tag: leveldb.Entity}
+
+	entities, err := w.legacyEntities(nil, k)
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		return err
+	}
+
+	tags := make([]nbt.NBTTag, 0, len(entities)+1)
+	for _, other := range entities {
+		tags = append(tags, other.NBT)
+	}
+
+	return w.putTags(k.Bytes(), append(tags, e.NBT))
+}
+
+// updateDigest replaces the IDs in a chunk's actor digest with the result of update. An empty digest is deleted.
+func (w *World) updateDigest(x, z, dimension int32, update func([][]byte) [][]byte) error {
+	ids, err := w.digest(x, z, dimension)
+	if err != nil {
+		return err
+	}
+
+	key := leveldb.DigestKey(x, z, dimension)
+	ids = update(ids)
+
+	if len(ids) == 0 {
+		return w.delete(key)
+	}
+
+	return w.put(key, bytes.Join(ids, nil))
+}
+
+// updateEntity replaces the saved NBT of an entity with e.NBT, leaving it in the chunk it is stored in.
+func (w *World) updateEntity(e Entity) error {
+	if e.storageID != nil {
+		return w.putTags(leveldb.ActorKey(e.storageID), []nbt.NBTTag{e.NBT})
+	}
+
+	k := leveldb.ChunkKey{X: e.chunkX, Z: e.chunkZ, Dimension: int32(e.Dimension), Tag: leveldb.Entity}
+
+	entities, err := w.legacyEntities(nil, k)
+	if err != nil {
+		return err
+	}
+
+	tags := make([]nbt.NBTTag, len(entities))
+	for i, other := range entities {
+		if other.UniqueID == e.UniqueID {
+			tags[i] = e.NBT
+		} else {
+			tags[i] = other.NBT
+		}
+	}
+
+	return w.putTags(k.Bytes(), tags)
+}
@@ -0,0 +1,180 @@
+package world
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func testEntity(identifier string, id int64, x, y, z float64) nbt.NBTTag {
+	t := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagString, Name: "identifier", Value: identifier})
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagLong, Name: "UniqueID", Value: nbt.Long(id)})
+
+	pos := nbt.NBTTag{Type: nbt.TagList, Name: "Pos", Value: map[string]interface{}{"tagListType": nbt.TagFloat}}
+	_ = pos.SetList([]nbt.NBTTag{
+		{Type: nbt.TagFloat, Value: x},
+		{Type: nbt.TagFloat, Value: y},
+		{Type: nbt.TagFloat, Value: z},
+	})
+	_ = t.SetChild(pos)
+
+	return t
+}
+
+func testEntityWorld(t *testing.T) (*World, *mock.LevelDB) {
+	db := mock.NewLevelDB()
+	w := &World{
+		db:        db,
+		subChunks: make(map[struct{ x, y, z, d int }]*subChunkData),
+	}
+
+	// Chunk 0 0 is generated, with a legacy entity record
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.Version}.Bytes(), []byte{40})
+
+	legacy, err := nbt.Encode([]nbt.NBTTag{
+		testEntity("minecraft:cow", 1, 8, 64, 8),
+		testEntity("minecraft:pig", 2, 8, -200, 8),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error encoding entities: %s", err)
+	}
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.Entity}.Bytes(), legacy)
+
+	// An actor stored in chunk 0 0 with a position in chunk 100 100 which was never generated
+	id := []byte{0, 0, 0, 1, 0, 0, 0, 3}
+	actor, err := nbt.Encode([]nbt.NBTTag{testEntity("minecraft:wolf", 3, 1600, 64, 1600)})
+	if err != nil {
+		t.Fatalf("unexpected error encoding actor: %s", err)
+	}
+	_ = db.Put(leveldb.ActorKey(id), actor)
+	_ = db.Put(leveldb.DigestKey(0, 0, 0), id)
+
+	return w, db
+}
+
+func TestEntities(t *testing.T) {
+	w, _ := testEntityWorld(t)
+
+	entities, err := w.Entities()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(entities) != 3 {
+		t.Fatalf("expected 3 entities: got %d", len(entities))
+	}
+
+	found := make(map[string]Entity)
+	for _, e := range entities {
+		found[e.Identifier] = e
+	}
+
+	if e := found["minecraft:wolf"]; e.UniqueID != 3 || e.X != 1600 || e.storageID == nil {
+		t.Errorf("unexpected actor read from digest: %+v", e)
+	}
+
+	if e := found["minecraft:cow"]; e.UniqueID != 1 || e.Y != 64 || e.storageID != nil {
+		t.Errorf("unexpected entity read from legacy record: %+v", e)
+	}
+}
+
+func TestEntitiesCorruptActor(t *testing.T) {
+	w, db := testEntityWorld(t)
+
+	// A truncated actor fails with an error which can still be matched
+	id := []byte{0, 0, 0, 1, 0, 0, 0, 3}
+	_ = db.Put(leveldb.ActorKey(id), []byte{nbt.TagCompound, 0})
+
+	if _, err := w.Entities(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected an error wrapping io.ErrUnexpectedEOF: got %v", err)
+	}
+
+	// An actor record must hold exactly one tag
+	_ = db.Put(leveldb.ActorKey(id), nil)
+
+	if _, err := w.Entities(); err == nil || strings.Contains(err.Error(), "<nil>") {
+		t.Errorf("expected an error for an actor with no tags: got %v", err)
+	}
+}
+
+func TestStrayEntities(t *testing.T) {
+	w, db := testEntityWorld(t)
+
+	strays, err := w.StrayEntities()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	reasons := make(map[string]string)
+	for _, s := range strays {
+		reasons[s.Identifier] = s.Reason
+	}
+
+	if len(strays) != 2 || reasons["minecraft:pig"] != "below the void" ||
+		reasons["minecraft:wolf"] != "outside generated chunks" {
+		t.Fatalf("unexpected stray entities: %v", reasons)
+	}
+
+	for _, s := range strays {
+		if s.Identifier == "minecraft:pig" {
+			if err := w.RemoveEntity(s.Entity); err != nil {
+				t.Fatalf("unexpected error removing entity: %s", err)
+			}
+			continue
+		}
+
+		moved, err := w.MoveEntity(s.Entity, 4, 70, 4, 0)
+		if err != nil {
+			t.Fatalf("unexpected error moving entity: %s", err)
+		}
+
+		if moved.X != 4 || moved.Y != 70 || moved.chunkX != 0 {
+			t.Errorf("unexpected entity after moving: %+v", moved)
+		}
+	}
+
+	strays, err = w.StrayEntities()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(strays) != 0 {
+		t.Errorf("expected no stray entities after repair: got %+v", strays)
+	}
+
+	if ids, _ := db.Get(leveldb.DigestKey(0, 0, 0)); len(ids) != 8 {
+		t.Errorf("expected moved actor to be listed once in the digest: got %x", ids)
+	}
+}
+
+func TestChunkEntities(t *testing.T) {
+	w, _ := testEntityWorld(t)
+
+	entities, err := w.ChunkEntities(0, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ids := make([]string, len(entities))
+	for i, e := range entities {
+		ids[i] = e.Identifier
+	}
+	if want := []string{"minecraft:cow", "minecraft:pig", "minecraft:wolf"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("expected entities %v: got %v", want, ids)
+	}
+
+	if entities, err := w.ChunkEntities(1, 0, 0); err != nil || len(entities) != 0 {
+		t.Errorf("expected no entities in chunk 1 0: got %+v, %v", entities, err)
+	}
+
+	if _, err := w.ChunkEntities(0, 0, 5); !errors.Is(err, ErrInvalidDimension) {
+		t.Errorf("expected ErrInvalidDimension: got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package world
+
+import "fmt"
+
+// The package returns errors of the types below, and the sentinel errors declared alongside the functions which return
+// them, instead of ending the process, so that applications can recover from damaged or unexpected data. Use
+// errors.Is with a zero value of an error type, e.g. &CorruptPaletteError{}, to test for any error of that type, or
+// errors.As to read its fields.
+
+// SubChunkNotSavedError is returned if a requested sub chunk is not present in the world database.
+type SubChunkNotSavedError struct {
+	origin struct{ x, y, z, d int }
+}
+
+func (e *SubChunkNotSavedError) Error() string {
+	return fmt.Sprintf("chunk with origin %d %d %d in the %s is not stored in this world database",
+		e.origin.x, e.origin.y, e.origin.z, Dimension(e.origin.d))
+}
+
+// Is implements Is(error) to support errors.Is()
+func (e *SubChunkNotSavedError) Is(tgt error) bool {
+	_, ok := tgt.(*SubChunkNotSavedError)
+	return ok
+}
+
+// UnsupportedVersionError is returned when reading or writing data in a format version the package doesn't support,
+// such as a sub chunk saved by a newer version of the game.
+type UnsupportedVersionError struct {
+	Format  string // The versioned data, e.g. sub chunk
+	Version int
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("unsupported %s version %d", e.Format, e.Version)
+}
+
+// Is implements Is(error) to support errors.Is()
+func (e *UnsupportedVersionError) Is(tgt error) bool {
+	_, ok := tgt.(*UnsupportedVersionError)
+	return ok
+}
+
+// CorruptPaletteError is returned when a block storage of a sub chunk can't be read, because its indices or palette
+// are malformed or an index is out of range of the palette.
+type CorruptPaletteError struct {
+	Storage int // The index of the block storage in the sub chunk, 0 being the blocks
+	Err     error
+}
+
+func (e *CorruptPaletteError) Error() string {
+	return fmt.Sprintf("corrupt block storage %d: %s", e.Storage, e.Err)
+}
+
+func (e *CorruptPaletteError) Unwrap() error { return e.Err }
+
+// Is implements Is(error) to support errors.Is()
+func (e *CorruptPaletteError) Is(tgt error) bool {
+	_, ok := tgt.(*CorruptPaletteError)
+	return ok
+}
+
+// OutOfBoundsError is returned for a coordinate outside the blocks of a dimension, chunk or sub chunk. Errors for a Y
+// coordinate outside the height of a dimension wrap ErrOutsideHeight.
+type OutOfBoundsError struct {
+	Axis     string // x, y or z
+	Value    int
+	Min, Max int    // The inclusive range of valid values
+	Bounds   string // What the coordinate is outside, e.g. the overworld or the chunk
+	Err      error
+}
+
+func (e *OutOfBoundsError) Error() string {
+	return fmt.Sprintf("%s %d is not between %d and %d in the %s", e.Axis, e.Value, e.Min, e.Max, e.Bounds)
+}
+
+func (e *OutOfBoundsError) Unwrap() error { return e.Err }
+
+// Is implements Is(error) to support errors.Is()
+func (e *OutOfBoundsError) Is(tgt error) bool {
+	_, ok := tgt.(*OutOfBoundsError)
+	return ok
+}
+
+// outOfChunk returns an *OutOfBoundsError for the first coordinate within a chunk or sub chunk which is outside 0 to
+// 15, or nil if there is none.
+func outOfChunk(bounds string, x, y, z int) error {
+	for i, v := range []int{x, y, z} {
+		if v < 0 || v >= chunkSize {
+			return &OutOfBoundsError{Axis: []string{"x", "y", "z"}[i], Value: v, Min: 0, Max: chunkSize - 1, Bounds: bounds}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package world
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestErrorTypes(t *testing.T) {
+	var version *UnsupportedVersionError
+	if _, err := parseSubChunk([]byte{42, 1}); !errors.As(err, &version) || version.Version != 42 {
+		t.Errorf("expected an UnsupportedVersionError for version 42: got %v", err)
+	}
+
+	// The lowest bit of the first storage header is the storage version, 1 being used only by network packets
+	if _, err := parseSubChunk([]byte{8, 1, 1}); !errors.As(err, &version) || version.Format != "block storage" {
+		t.Errorf("expected an UnsupportedVersionError for block storage version 1: got %v", err)
+	}
+
+	storage := blockStorage{BitsPerBlock: 4, Indices: make([]int, subChunkBlockCount),
+		Palette: []nbt.NBTTag{paletteEntry(BlockAir), paletteEntry(BlockStone)}}
+	storage.Indices[10] = 5
+	outOfRange, err := encodeSubChunk(&subChunkData{Version: 8, Blocks: storage}, false)
+	if err != nil {
+		t.Fatalf("unexpected error encoding sub chunk: %s", err)
+	}
+
+	var corrupt *CorruptPaletteError
+	for _, c := range []struct {
+		name    string
+		value   []byte
+		storage int
+	}{
+		{"an index out of range", outOfRange, 0},
+		{"a truncated water logging palette", mock.SubChunkValue[:len(mock.SubChunkValue)-10], 1},
+	} {
+		if _, err := parseSubChunk(c.value); !errors.As(err, &corrupt) || corrupt.Storage != c.storage {
+			t.Errorf("expected a CorruptPaletteError for storage %d with %s: got %v", c.storage, c.name, err)
+		}
+	}
+
+	// Errors are returned through the world API
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.SubChunkPrefix}.Bytes(), outOfRange)
+	if _, err := w.GetBlock(0, 0, 0, 0); !errors.Is(err, &CorruptPaletteError{}) {
+		t.Errorf("expected GetBlock to return a CorruptPaletteError: got %v", err)
+	}
+
+	var bounds *OutOfBoundsError
+	if _, err := w.GetBlock(0, 400, 0, 0); !errors.As(err, &bounds) || bounds.Axis != "y" ||
+		!errors.Is(err, ErrOutsideHeight) {
+		t.Errorf("expected an OutOfBoundsError wrapping ErrOutsideHeight: got %v", err)
+	}
+
+	c := &Chunk{Dimension: 0}
+	if _, err := c.BlockAt(0, 0, 16); !errors.As(err, &bounds) || bounds.Axis != "z" || bounds.Value != 16 {
+		t.Errorf("expected an OutOfBoundsError for z 16: got %v", err)
+	}
+
+	s := NewSubChunk(0, 0, 0, 0, BlockAir)
+	if _, err := s.At(-1, 0, 0); !errors.As(err, &bounds) || bounds.Axis != "x" || bounds.Value != -1 {
+		t.Errorf("expected an OutOfBoundsError for x -1: got %v", err)
+	}
+	if err := s.SetAt(0, 16, 0, Block{ID: BlockStone}); !errors.As(err, &bounds) || bounds.Axis != "y" {
+		t.Errorf("expected an OutOfBoundsError for y 16: got %v", err)
+	}
+	if _, err := SubChunkIndex(0, 0, 100); !errors.As(err, &bounds) || bounds.Bounds != "sub chunk" {
+		t.Errorf("expected an OutOfBoundsError for z 100: got %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+const (
+	mobEventsKey       = "mobevents"
+	traderSchedulerKey = "schedulerWT"
+	villageRaidSuffix  = "_RAID"
+)
+
+// badOmenEffectID is the status effect given to players who kill a raid captain, which starts a raid when they enter
+// a village.
+const badOmenEffectID = 28
+
+// MobEvents are the toggles controlling events which spawn mobs, by tag name e.g. minecraft:wandering_trader_event.
+// The events_enabled entry turns all of them on or off.
+type MobEvents map[string]bool
+
+// TraderSchedule is the wandering trader spawn timer from the schedulerWT record.
+type TraderSchedule struct {
+	DaysSinceLastSpawn int
+	Spawning           bool  // A wandering trader is currently being spawned
+	NextSpawnCheckTick int64 // The game tick at which the next spawn attempt is made
+}
+
+// Raid is a raid in progress, as stored in a village's VILLAGE_<id>_RAID record.
+type Raid struct {
+	VillageID string
+	Status    int   // The raid state, which is 0 while the raid is ongoing
+	GroupNum  int   // The number of waves which have spawned
+	NumGroups int   // The total number of waves
+	GameTick  int64 // The game tick at which the raid started
+	NBT       nbt.NBTTag
+}
+
+// BadOmen is a player with the bad omen effect.
+type BadOmen struct {
+	PlayerKey string // The key of the player's record
+	Effect    Effect
+}
+
+// MobEvents returns the mob event toggles, or nil if the world has no mobevents record.
+func (w *World) MobEvents() (MobEvents, error) {
+	root, err := w.singleTagRecord([]byte(mobEventsKey))
+	if err != nil || root == nil {
+		return nil, err
+	}
+
+	events := make(MobEvents)
+
+	for _, t := range root.Tags() {
+		i, _ := t.Int()
+		events[t.Name] = i != 0
+	}
+
+	return events, nil
+}
+
+// SetMobEvent turns a mob event on or off, creating the mobevents record if there is none.
+func (w *World) SetMobEvent(name string, enabled bool) error {
+	root, err := w.singleTagRecord([]byte(mobEventsKey))
+	if err != nil {
+		return err
+	}
+
+	if root == nil {
+		root = &nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	}
+
+	value := 0.0
+	if enabled {
+		value = 1
+	}
+
+	if err := root.SetChild(nbt.NBTTag{Name: name, Type: nbt.TagByte, Value: value}); err != nil {
+		return fmt.Errorf("setting %s: %w", name, err)
+	}
+
+	return w.putTags([]byte(mobEventsKey), []nbt.NBTTag{*root})
+}
+
+// TraderSchedule returns the wandering trader spawn timer. The returned bool is false if the world has no
+// schedulerWT record, which is created the first time the game checks for a trader spawn.
+func (w *World) TraderSchedule() (TraderSchedule, bool, error) {
+	root, err := w.singleTagRecord([]byte(traderSchedulerKey))
+	if err != nil || root == nil {
+		return TraderSchedule{}, false, err
+	}
+
+	return TraderSchedule{
+		DaysSinceLastSpawn: int(childInt(*root, "daysSinceLastWTSpawn")),
+		Spawning:           childInt(*root, "isSpawningWT") != 0,
+		NextSpawnCheckTick: childInt(*root, "nextWTSpawnCheckTick"),
+	}, true, nil
+}
+
+// SetTraderSchedule writes the wandering trader spawn timer. Other tags in the record are kept.
+func (w *World) SetTraderSchedule(s TraderSchedule) error {
+	root, err := w.singleTagRecord([]byte(traderSchedulerKey))
+	if err != nil {
+		return err
+	}
+
+	if root == nil {
+		root = &nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	}
+
+	spawning := 0.0
+	if s.Spawning {
+		spawning = 1
+	}
+
+	for _, t := range []nbt.NBTTag{
+		{Name: "daysSinceLastWTSpawn", Type: nbt.TagInt, Value: float64(s.DaysSinceLastSpawn)},
+		{Name: "isSpawningWT", Type: nbt.TagByte, Value: spawning},
+		{Name: "nextWTSpawnCheckTick", Type: nbt.TagLong, Value: nbt.Long(s.NextSpawnCheckTick)},
+	} {
+		if err := root.SetChild(t); err != nil {
+			return fmt.Errorf("setting %s: %w", t.Name, err)
+		}
+	}
+
+	return w.putTags([]byte(traderSchedulerKey), []nbt.NBTTag{*root})
+}
+
+// Raids returns every raid in progress.
+func (w *World) Raids() ([]Raid, error) {
+	ids, err := w.villageIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	raids := make([]Raid, 0)
+
+	for _, id := range ids {
+		root, err := w.singleTagRecord([]byte(villagePrefix + id + villageRaidSuffix))
+		if err != nil {
+			return nil, err
+		}
+		if root == nil {
+			continue
+		}
+
+		// The raid fields are held in a Raid compound inside the root tag
+		raid := *root
+		if t, ok := root.Child("Raid"); ok {
+			raid = t
+		}
+
+		raids = append(raids, Raid{
+			VillageID: id,
+			Status:    int(childInt(raid, "Status")),
+			GroupNum:  int(childInt(raid, "GroupNum")),
+			NumGroups: int(childInt(raid, "NumGroups")),
+			GameTick:  childInt(raid, "GameTick"),
+			NBT:       raid,
+		})
+	}
+
+	return raids, nil
+}
+
+// RemoveRaid deletes the raid record of a village, ending a raid which is stuck. Raiders which have already spawned are
+// not removed.
+func (w *World) RemoveRaid(villageID string) error {
+	return w.putTags([]byte(villagePrefix+villageID+villageRaidSuffix), nil)
+}
+
+// BadOmens returns every player with the bad omen effect.
+func (w *World) BadOmens() ([]BadOmen, error) {
+	keys, err := w.playerKeys(w.startBudget())
+	if err != nil {
+		return nil, err
+	}
+
+	omens := make([]BadOmen, 0)
+
+	for _, key := range keys {
+		root, err := w.singleTagRecord([]byte(key))
+		if err != nil {
+			return nil, err
+		}
+		if root == nil {
+			continue
+		}
+
+		for _, e := range parseEntity(*root).Effects() {
+			if e.ID == badOmenEffectID {
+				omens = append(omens, BadOmen{PlayerKey: key, Effect: e})
+			}
+		}
+	}
+
+	return omens, nil
+}
+
+// ClearBadOmen removes the bad omen effect from the player with the given record key.
+func (w *World) ClearBadOmen(playerKey string) error {
+	root, err := w.singleTagRecord([]byte(playerKey))
+	if err != nil {
+		return err
+	}
+	if root == nil {
+		return fmt.Errorf("player record '%s' not found", playerKey)
+	}
+
+	effects, ok := root.Child("ActiveEffects")
+	if !ok {
+		return nil
+	}
+
+	kept := make([]nbt.NBTTag, 0)
+	for _, e := range effects.List() {
+		if childInt(e, "Id") != badOmenEffectID {
+			kept = append(kept, e)
+		}
+	}
+
+	if err := effects.SetList(kept); err != nil {
+		return fmt.Errorf("setting effects: %w", err)
+	}
+
+	if err := root.SetChild(effects); err != nil {
+		return fmt.Errorf("setting effects: %w", err)
+	}
+
+	return w.putTags([]byte(playerKey), []nbt.NBTTag{*root})
+}
+
+// playerKeys returns the keys of the local player record and every server player record, sorted, within the time
+// budget b.
+func (w *World) playerKeys(b *scanBudget) ([]string, error) {
+	players := make([]string, 0)
+
+	err := w.eachKey(b, func(key []byte) error {
+		k := string(key)
+		if k == localPlayerKey || strings.HasPrefix(k, "player_") {
+			players = append(players, k)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(players)
+
+	return players, nil
+}
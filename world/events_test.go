@@ -0,0 +1,117 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestMobEventsAndTraderSchedule(t *testing.T) {
+	w := &World{db: mock.NewLevelDB()}
+
+	if events, err := w.MobEvents(); err != nil || events != nil {
+		t.Fatalf("expected no mob events and no error: got %v, %v", events, err)
+	}
+
+	if err := w.SetMobEvent("minecraft:wandering_trader_event", false); err != nil {
+		t.Fatalf("unexpected error setting mob event: %s", err)
+	}
+	if err := w.SetMobEvent("events_enabled", true); err != nil {
+		t.Fatalf("unexpected error setting mob event: %s", err)
+	}
+
+	events, err := w.MobEvents()
+	if err != nil {
+		t.Fatalf("unexpected error reading mob events: %s", err)
+	}
+
+	if len(events) != 2 || !events["events_enabled"] || events["minecraft:wandering_trader_event"] {
+		t.Errorf("unexpected mob events: %v", events)
+	}
+
+	if _, ok, err := w.TraderSchedule(); ok || err != nil {
+		t.Fatalf("expected no trader schedule and no error: got %t, %v", ok, err)
+	}
+
+	want := TraderSchedule{DaysSinceLastSpawn: 3, Spawning: true, NextSpawnCheckTick: 1 << 33}
+	if err := w.SetTraderSchedule(want); err != nil {
+		t.Fatalf("unexpected error setting trader schedule: %s", err)
+	}
+
+	got, ok, err := w.TraderSchedule()
+	if err != nil || !ok {
+		t.Fatalf("expected trader schedule: got %t, %v", ok, err)
+	}
+
+	if got != want {
+		t.Errorf("expected trader schedule %+v: got %+v", want, got)
+	}
+}
+
+func TestRaidsAndBadOmen(t *testing.T) {
+	w, _ := testEntityWorld(t)
+
+	raid := testCompound("", testCompound("Raid",
+		nbt.NBTTag{Name: "GameTick", Type: nbt.TagLong, Value: nbt.Long(1000)},
+		nbt.NBTTag{Name: "GroupNum", Type: nbt.TagByte, Value: 2.0},
+		nbt.NBTTag{Name: "NumGroups", Type: nbt.TagByte, Value: 5.0},
+	))
+	if err := w.putTags([]byte("VILLAGE_abc_RAID"), []nbt.NBTTag{raid}); err != nil {
+		t.Fatalf("unexpected error writing raid: %s", err)
+	}
+
+	raids, err := w.Raids()
+	if err != nil {
+		t.Fatalf("unexpected error reading raids: %s", err)
+	}
+
+	if len(raids) != 1 || raids[0].VillageID != "abc" || raids[0].GroupNum != 2 || raids[0].NumGroups != 5 ||
+		raids[0].GameTick != 1000 {
+		t.Fatalf("unexpected raids: %+v", raids)
+	}
+
+	if err := w.RemoveRaid("abc"); err != nil {
+		t.Fatalf("unexpected error removing raid: %s", err)
+	}
+
+	if raids, _ := w.Raids(); len(raids) != 0 {
+		t.Errorf("expected raid to be removed: got %+v", raids)
+	}
+
+	effect := func(id int) nbt.NBTTag {
+		return testCompound("",
+			nbt.NBTTag{Name: "Id", Type: nbt.TagByte, Value: float64(id)},
+			nbt.NBTTag{Name: "Amplifier", Type: nbt.TagByte, Value: 0.0},
+			nbt.NBTTag{Name: "Duration", Type: nbt.TagInt, Value: 2400.0},
+		)
+	}
+
+	player := testEntity("minecraft:player", 1, 0, 64, 0)
+	_ = player.SetChild(testList("ActiveEffects", nbt.TagCompound, effect(1), effect(badOmenEffectID)))
+	if err := w.putTags([]byte("~local_player"), []nbt.NBTTag{player}); err != nil {
+		t.Fatalf("unexpected error writing player: %s", err)
+	}
+
+	omens, err := w.BadOmens()
+	if err != nil {
+		t.Fatalf("unexpected error reading bad omens: %s", err)
+	}
+
+	if len(omens) != 1 || omens[0].PlayerKey != "~local_player" || omens[0].Effect.Name != "Bad Omen" {
+		t.Fatalf("unexpected bad omens: %+v", omens)
+	}
+
+	if err := w.ClearBadOmen("~local_player"); err != nil {
+		t.Fatalf("unexpected error clearing bad omen: %s", err)
+	}
+
+	if omens, _ := w.BadOmens(); len(omens) != 0 {
+		t.Errorf("expected bad omen to be cleared: got %+v", omens)
+	}
+
+	root, _ := w.singleTagRecord([]byte("~local_player"))
+	if effects, _ := root.Child("ActiveEffects"); len(effects.List()) != 1 {
+		t.Errorf("expected other effects to be kept: got %d", len(effects.List()))
+	}
+}
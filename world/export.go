@@ -0,0 +1,284 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ExportIssue is a problem which would stop a world directory being exported as an .mcworld or .mctemplate file, or
+// being accepted as a marketplace or Realms world template.
+type ExportIssue struct {
+	File    string // The file with the problem, relative to the world directory
+	Problem string
+	Fix     string // What to do about it
+}
+
+func (i ExportIssue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.File, i.Problem, i.Fix)
+}
+
+const worldIconFileName = "world_icon.jpeg"
+
+// packReference is an entry in world_behavior_packs.json or world_resource_packs.json.
+type packReference struct {
+	PackID  string `json:"pack_id"`
+	Version []int  `json:"version"`
+}
+
+// packManifest is the part of a pack or template manifest.json checked by CheckExport.
+type packManifest struct {
+	Header struct {
+		Name    string `json:"name"`
+		UUID    string `json:"uuid"`
+		Version []int  `json:"version"`
+	} `json:"header"`
+	Modules []struct {
+		Type string `json:"type"`
+	} `json:"modules"`
+}
+
+// absolutePath matches strings which are absolute unix or windows paths.
+var absolutePath = regexp.MustCompile(`^(/[^/]|[A-Za-z]:[\\/]|\\\\)`)
+
+// CheckExport checks the world directory at path for problems which would break an exported world on another device:
+// missing files, symbolic links and absolute paths, and pack references which don't match a pack included with the
+// world. If template is true the world is also checked against the world template requirements, which include a
+// manifest.json with a world_template module.
+func CheckExport(path string, template bool) ([]ExportIssue, error) {
+	issues := make([]ExportIssue, 0)
+	add := func(file, problem, fix string) {
+		issues = append(issues, ExportIssue{File: file, Problem: problem, Fix: fix})
+	}
+
+	for _, f := range []struct{ name, fix string }{
+		{levelDatFileName, "the world is incomplete or this is not a world directory"},
+		{"db", "the world is incomplete or this is not a world directory"},
+		{"levelname.txt", "create levelname.txt containing the world name"},
+	} {
+		if _, err := os.Stat(filepath.Join(path, f.name)); os.IsNotExist(err) {
+			add(f.name, "missing", f.fix)
+		} else if err != nil {
+			return nil, fmt.Errorf("checking %s: %w", f.name, err)
+		}
+	}
+
+	if name, err := ioutil.ReadFile(filepath.Join(path, "levelname.txt")); err == nil && len(bytes.TrimSpace(name)) == 0 {
+		add("levelname.txt", "empty", "write the world name to levelname.txt")
+	}
+
+	icon, err := ioutil.ReadFile(filepath.Join(path, worldIconFileName))
+	switch {
+	case os.IsNotExist(err):
+		add(worldIconFileName, "missing", "add a JPEG world icon, which the world list and marketplace show")
+	case err != nil:
+		return nil, fmt.Errorf("reading %s: %w", worldIconFileName, err)
+	case !bytes.HasPrefix(icon, []byte{0xff, 0xd8}):
+		add(worldIconFileName, "not a JPEG image", "convert the icon to JPEG")
+	}
+
+	if err := checkExportFiles(path, add); err != nil {
+		return nil, err
+	}
+
+	for _, packs := range []struct{ references, dir string }{
+		{"world_behavior_packs.json", "behavior_packs"},
+		{"world_resource_packs.json", "resource_packs"},
+	} {
+		if err := checkPackReferences(path, packs.references, packs.dir, add); err != nil {
+			return nil, err
+		}
+	}
+
+	if template {
+		if err := checkTemplateManifest(path, add); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].File < issues[j].File })
+
+	return issues, nil
+}
+
+// checkExportFiles reports symbolic links, which are not followed when a world is zipped, and absolute paths in JSON
+// files, which won't exist on another device.
+func checkExportFiles(root string, add func(file, problem, fix string)) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(root, path)
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			add(rel, "symbolic link", "replace the link with a copy of the file")
+			return nil
+		}
+
+		if info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", rel, err)
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			add(rel, fmt.Sprintf("invalid JSON: %s", err), "fix the JSON syntax")
+			return nil
+		}
+
+		for _, s := range jsonStrings(v) {
+			if absolutePath.MatchString(s) {
+				add(rel, fmt.Sprintf("absolute path '%s'", s), "use a path relative to the pack or world")
+			}
+		}
+
+		return nil
+	})
+}
+
+// jsonStrings returns every string value in a decoded JSON value.
+func jsonStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		s := make([]string, 0)
+		for _, e := range v {
+			s = append(s, jsonStrings(e)...)
+		}
+		return s
+	case map[string]interface{}:
+		s := make([]string, 0)
+		for _, e := range v {
+			s = append(s, jsonStrings(e)...)
+		}
+		sort.Strings(s)
+		return s
+	}
+
+	return nil
+}
+
+// checkPackReferences reports entries in the given pack references file which don't match the UUID and version of a
+// pack in the given directory.
+func checkPackReferences(root, references, dir string, add func(file, problem, fix string)) error {
+	data, err := ioutil.ReadFile(filepath.Join(root, references))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", references, err)
+	}
+
+	refs := make([]packReference, 0)
+	if err := json.Unmarshal(data, &refs); err != nil {
+		// Invalid JSON is reported by checkExportFiles
+		return nil
+	}
+
+	packs, err := packVersions(filepath.Join(root, dir))
+	if err != nil {
+		return err
+	}
+
+	for _, r := range refs {
+		version, ok := packs[strings.ToLower(r.PackID)]
+		switch {
+		case !ok:
+			add(references, fmt.Sprintf("pack %s is not in %s", r.PackID, dir),
+				"copy the pack into the world or remove the reference")
+		case formatVersion(version) != formatVersion(r.Version):
+			add(references, fmt.Sprintf("pack %s is referenced as version %s: %s has version %s",
+				r.PackID, formatVersion(r.Version), dir, formatVersion(version)),
+				"update the version in the reference to match the pack manifest")
+		}
+	}
+
+	return nil
+}
+
+// packVersions returns the header version of each pack in dir by lower case UUID.
+func packVersions(dir string) (map[string][]int, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return map[string][]int{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", dir, err)
+	}
+
+	versions := make(map[string][]int)
+
+	for _, e := range entries {
+		data, err := ioutil.ReadFile(filepath.Join(dir, e.Name(), "manifest.json"))
+		if err != nil {
+			continue
+		}
+
+		m := packManifest{}
+		if err := json.Unmarshal(data, &m); err == nil {
+			versions[strings.ToLower(m.Header.UUID)] = m.Header.Version
+		}
+	}
+
+	return versions, nil
+}
+
+// checkTemplateManifest reports problems with the manifest.json which makes a world directory a world template.
+func checkTemplateManifest(root string, add func(file, problem, fix string)) error {
+	const name = "manifest.json"
+
+	data, err := ioutil.ReadFile(filepath.Join(root, name))
+	if os.IsNotExist(err) {
+		add(name, "missing", "add a manifest.json with a header and a world_template module")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	m := packManifest{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		// Invalid JSON is reported by checkExportFiles
+		return nil
+	}
+
+	if m.Header.UUID == "" {
+		add(name, "header has no uuid", "generate a new UUID for the template")
+	}
+
+	if len(m.Header.Version) != 3 {
+		add(name, "header version is not three numbers", "set the version, e.g. [1, 0, 0]")
+	}
+
+	for _, module := range m.Modules {
+		if module.Type == "world_template" {
+			return nil
+		}
+	}
+
+	add(name, "no world_template module", "add a module with type world_template")
+
+	return nil
+}
+
+// formatVersion returns a pack version as dotted numbers.
+func formatVersion(v []int) string {
+	s := make([]string, len(v))
+	for i, n := range v {
+		s[i] = fmt.Sprint(n)
+	}
+
+	return strings.Join(s, ".")
+}
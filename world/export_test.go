@@ -0,0 +1,74 @@
+package world
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckExport(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unexpected error creating directory: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error writing %s: %s", name, err)
+		}
+	}
+
+	write("level.dat", "")
+	write("db/CURRENT", "")
+	write("levelname.txt", "test")
+	write(worldIconFileName, "\xff\xd8")
+	write("resource_packs/a/manifest.json", `{"header": {"uuid": "AAAA", "version": [1, 0, 0]}}`)
+	write("resource_packs/b/manifest.json", `{"header": {"uuid": "bbbb", "version": [1, 2, 0]}}`)
+	write("world_resource_packs.json",
+		`[{"pack_id": "aaaa", "version": [1, 0, 0]}, {"pack_id": "bbbb", "version": [1, 0, 0]}, `+
+			`{"pack_id": "cccc", "version": [1, 0, 0]}]`)
+	write("behavior_packs/c/manifest.json", `{"header": {"uuid": "dddd", "icon": "C:\\Users\\me\\icon.png"}}`)
+
+	issues, err := CheckExport(dir, false)
+	if err != nil {
+		t.Fatalf("unexpected error checking export: %s", err)
+	}
+
+	want := map[string]bool{
+		"behavior_packs/c/manifest.json": true, // Absolute path
+		"world_resource_packs.json":      true, // Wrong version of bbbb and missing cccc
+	}
+
+	if len(issues) != 3 {
+		t.Errorf("expected 3 issues: got %d: %v", len(issues), issues)
+	}
+
+	for _, i := range issues {
+		if !want[filepath.ToSlash(i.File)] {
+			t.Errorf("unexpected issue: %s", i)
+		}
+	}
+
+	issues, err = CheckExport(dir, true)
+	if err != nil {
+		t.Fatalf("unexpected error checking template export: %s", err)
+	}
+
+	if len(issues) != 4 || issues[1].File != "manifest.json" {
+		t.Errorf("expected missing manifest.json to be reported for a template: got %v", issues)
+	}
+
+	write("manifest.json", `{"header": {"uuid": "eeee", "version": [1, 0, 0]}, "modules": [{"type": "world_template"}]}`)
+	_ = os.Remove(filepath.Join(dir, worldIconFileName))
+
+	issues, err = CheckExport(dir, true)
+	if err != nil {
+		t.Fatalf("unexpected error checking template export: %s", err)
+	}
+
+	if len(issues) != 4 || issues[1].File != worldIconFileName {
+		t.Errorf("expected valid manifest and missing icon: got %v", issues)
+	}
+}
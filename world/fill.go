@@ -0,0 +1,308 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// checkRegion returns an error if the region's dimension is invalid or it extends outside the dimension's height.
+func checkRegion(region Selection) error {
+	for _, y := range []int{region.Min[1], region.Max[1]} {
+		if err := checkHeight(y, region.Dimension); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// editRegion calls edit with each sub chunk which overlaps the region, and the lowest and highest corners of the
+// overlap in sub chunk coordinates, then writes the sub chunk. Sub chunks which aren't saved are added to generated
+// chunks if create is true and skipped otherwise. If create is true and a chunk isn't generated, ErrChunkNotGenerated
+// is returned.
+func (w *World) editRegion(region Selection, create bool, edit func(s *SubChunk, lo, hi [3]int)) error {
+	for cx := floorDiv(region.Min[0], chunkSize); cx <= floorDiv(region.Max[0], chunkSize); cx++ {
+		for cz := floorDiv(region.Min[2], chunkSize); cz <= floorDiv(region.Max[2], chunkSize); cz++ {
+			for sy := floorDiv(region.Min[1], chunkSize); sy <= floorDiv(region.Max[1], chunkSize); sy++ {
+				s, err := w.SubChunk(cx, sy, cz, region.Dimension)
+				if errors.Is(err, &SubChunkNotSavedError{}) {
+					if !create {
+						continue
+					}
+
+					generated, err := w.chunkGenerated(cx, cz, region.Dimension)
+					if err != nil {
+						return err
+					}
+					if !generated {
+						return fmt.Errorf("editing chunk %d %d: %w", cx, cz, ErrChunkNotGenerated)
+					}
+
+					s = NewSubChunk(cx, sy, cz, region.Dimension, BlockAir)
+				} else if err != nil {
+					return err
+				}
+
+				var lo, hi [3]int
+				for a, c := range [3]int{cx, sy, cz} {
+					lo[a] = maxInt(region.Min[a]-c*chunkSize, 0)
+					hi[a] = minInt(region.Max[a]-c*chunkSize, chunkSize-1)
+				}
+
+				edit(s, lo, hi)
+
+				if err := w.SetSubChunk(s); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// wholeSubChunk returns true if the corners given in sub chunk coordinates cover the whole sub chunk.
+func wholeSubChunk(lo, hi [3]int) bool {
+	return lo == [3]int{} && hi == [3]int{chunkSize - 1, chunkSize - 1, chunkSize - 1}
+}
+
+// volume returns the number of blocks in the region.
+func (s Selection) volume() int {
+	x, y, z := s.Size()
+	return x * y * z
+}
+
+// removeRegionBlockEntities removes the block entities inside the region.
+func (w *World) removeRegionBlockEntities(region Selection) error {
+	for cx := floorDiv(region.Min[0], chunkSize); cx <= floorDiv(region.Max[0], chunkSize); cx++ {
+		for cz := floorDiv(region.Min[2], chunkSize); cz <= floorDiv(region.Max[2], chunkSize); cz++ {
+			err := w.replaceBlockEntities(cx, cz, region.Dimension, func(x, y, z int) bool {
+				return region.Contains(x, y, z, region.Dimension)
+			}, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Fill sets every block in the region to b and removes the block entities in it. Sub chunks covered by the region are
+// replaced with a single palette entry, and the others have one palette entry added at most, so this is much faster
+// than setting each block. Sub chunks which aren't saved are added to generated chunks, unless b is air. The number of
+// blocks set is returned.
+func (w *World) Fill(region Selection, b Block) (int, error) {
+	if err := checkRegion(region); err != nil {
+		return 0, err
+	}
+
+	err := w.editRegion(region, b.ID != BlockAir || b.waterLogged, func(s *SubChunk, lo, hi [3]int) {
+		if wholeSubChunk(lo, hi) {
+			s.data.Blocks = uniformStorage(b.ID)
+			s.data.clearWaterLogged()
+			if b.waterLogged {
+				for i := 0; i < subChunkBlockCount; i++ {
+					s.setWaterLogged(i, true)
+				}
+			}
+			return
+		}
+
+		p := -1
+		for x := lo[0]; x <= hi[0]; x++ {
+			for y := lo[1]; y <= hi[1]; y++ {
+				for z := lo[2]; z <= hi[2]; z++ {
+					i := subChunkVoxelToIndex(x, y, z)
+
+					// The palette is searched for the block only once
+					if p < 0 {
+						s.setAt(x, y, z, b)
+						p = s.data.Blocks.Indices[i]
+						continue
+					}
+
+					s.data.Blocks.Indices[i] = p
+					s.setWaterLogged(i, b.waterLogged)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := w.removeRegionBlockEntities(region); err != nil {
+		return 0, err
+	}
+
+	return region.volume(), nil
+}
+
+// Clone copies every block in src, with its block states and water logging, to the region of the same size in the same
+// dimension whose lowest corner is dst. Block entities in src are copied and those already in the destination are
+// removed. Positions in src whose sub chunk isn't saved are copied as air. The source is read before anything is
+// written, so the regions may overlap. The number of blocks copied is returned.
+//
+// Sub chunks are copied whole where the destination covers them and is offset from src by a multiple of 16 blocks on
+// every axis, otherwise each destination sub chunk's palette is extended with the source palette entries it needs.
+func (w *World) Clone(src Selection, dst [3]int) (int, error) {
+	sx, sy, sz := src.Size()
+	to := Selection{Min: dst, Max: [3]int{dst[0] + sx - 1, dst[1] + sy - 1, dst[2] + sz - 1}, Dimension: src.Dimension}
+
+	for _, r := range []Selection{src, to} {
+		if err := checkRegion(r); err != nil {
+			return 0, err
+		}
+	}
+
+	var offset [3]int
+	aligned := true
+	for a := range offset {
+		offset[a] = src.Min[a] - dst[a]
+		aligned = aligned && offset[a]%chunkSize == 0
+	}
+
+	// Source sub chunks which aren't saved are nil
+	sources := make(map[[3]int]*subChunkData)
+	for cx := floorDiv(src.Min[0], chunkSize); cx <= floorDiv(src.Max[0], chunkSize); cx++ {
+		for cz := floorDiv(src.Min[2], chunkSize); cz <= floorDiv(src.Max[2], chunkSize); cz++ {
+			for cy := floorDiv(src.Min[1], chunkSize); cy <= floorDiv(src.Max[1], chunkSize); cy++ {
+				s, err := w.SubChunk(cx, cy, cz, src.Dimension)
+				if errors.Is(err, &SubChunkNotSavedError{}) {
+					sources[[3]int{cx, cy, cz}] = nil
+					continue
+				}
+				if err != nil {
+					return 0, err
+				}
+				sources[[3]int{cx, cy, cz}] = s.data
+			}
+		}
+	}
+
+	blockEntities, err := w.regionBlockEntities(src)
+	if err != nil {
+		return 0, err
+	}
+
+	// Destination palette indices of source palette entries, for each destination sub chunk
+	type sourceEntry struct {
+		s *subChunkData
+		p int
+	}
+	air := &subChunkData{Blocks: uniformStorage(BlockAir)}
+
+	err = w.editRegion(to, true, func(s *SubChunk, lo, hi [3]int) {
+		origin := [3]int{s.X * chunkSize, s.Y * chunkSize, s.Z * chunkSize}
+
+		if aligned && wholeSubChunk(lo, hi) {
+			source := sources[[3]int{s.X + offset[0]/chunkSize, s.Y + offset[1]/chunkSize, s.Z + offset[2]/chunkSize}]
+			if source == nil {
+				source = air
+			}
+
+			s.data.Blocks = copyStorage(source.Blocks)
+			s.data.clearWaterLogged()
+			if len(source.Extra) > 0 {
+				if len(s.data.Extra) == 0 {
+					s.data.Extra = make([]blockStorage, 1)
+				}
+				s.data.Extra[0] = copyStorage(source.Extra[0])
+				if s.data.Version == 1 {
+					s.data.Version = 8
+				}
+			}
+			return
+		}
+
+		entries := make(map[sourceEntry]int)
+		for x := lo[0]; x <= hi[0]; x++ {
+			for y := lo[1]; y <= hi[1]; y++ {
+				for z := lo[2]; z <= hi[2]; z++ {
+					wx, wy, wz := origin[0]+x+offset[0], origin[1]+y+offset[1], origin[2]+z+offset[2]
+
+					source := sources[[3]int{floorDiv(wx, chunkSize), floorDiv(wy, chunkSize), floorDiv(wz, chunkSize)}]
+					if source == nil {
+						source = air
+					}
+
+					from := subChunkVoxelToIndex(wx&15, wy&15, wz&15)
+					i := subChunkVoxelToIndex(x, y, z)
+
+					e := sourceEntry{source, source.Blocks.Indices[from]}
+					if p, ok := entries[e]; ok {
+						s.data.Blocks.Indices[i] = p
+					} else {
+						s.setEntry(i, source.Blocks.Palette[e.p])
+						entries[e] = s.data.Blocks.Indices[i]
+					}
+
+					s.setWaterLogged(i, source.waterLogged(from))
+				}
+			}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	added := make(map[[2]int][]nbt.NBTTag)
+	for _, e := range blockEntities {
+		x, y, z := e.X-offset[0], e.Y-offset[1], e.Z-offset[2]
+		c := [2]int{floorDiv(x, chunkSize), floorDiv(z, chunkSize)}
+		added[c] = append(added[c], moveBlockEntity(e.NBT, x, y, z))
+	}
+
+	for cx := floorDiv(to.Min[0], chunkSize); cx <= floorDiv(to.Max[0], chunkSize); cx++ {
+		for cz := floorDiv(to.Min[2], chunkSize); cz <= floorDiv(to.Max[2], chunkSize); cz++ {
+			err := w.replaceBlockEntities(cx, cz, to.Dimension, func(x, y, z int) bool {
+				return to.Contains(x, y, z, to.Dimension)
+			}, added[[2]int{cx, cz}])
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return to.volume(), nil
+}
+
+// copyStorage returns a copy of a block storage which shares no indices with it. Palette entries are shared, as they
+// are never modified in place.
+func copyStorage(s blockStorage) blockStorage {
+	c := blockStorage{BitsPerBlock: s.BitsPerBlock}
+	if s.Indices != nil {
+		c.Indices = append([]int(nil), s.Indices...)
+	}
+	if s.Palette != nil {
+		c.Palette = append([]nbt.NBTTag(nil), s.Palette...)
+		c.encoded = s.encoded[:len(s.encoded):len(s.encoded)]
+	}
+
+	return c
+}
+
+// regionBlockEntities returns the block entities inside the region.
+func (w *World) regionBlockEntities(region Selection) ([]BlockEntity, error) {
+	entities := make([]BlockEntity, 0)
+
+	for cx := floorDiv(region.Min[0], chunkSize); cx <= floorDiv(region.Max[0], chunkSize); cx++ {
+		for cz := floorDiv(region.Min[2], chunkSize); cz <= floorDiv(region.Max[2], chunkSize); cz++ {
+			chunk, err := w.chunkBlockEntities(cx, cz, region.Dimension)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, e := range chunk {
+				if region.Contains(e.X, e.Y, e.Z, region.Dimension) {
+					entities = append(entities, e)
+				}
+			}
+		}
+	}
+
+	return entities, nil
+}
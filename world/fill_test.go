@@ -0,0 +1,174 @@
+package world
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// testGeneratedWorld returns a world where the chunks from -1 -1 to 2 2 are generated with no saved sub chunks.
+func testGeneratedWorld() (*World, *mock.LevelDB) {
+	db := mock.NewLevelDB()
+	for cx := int32(-1); cx <= 2; cx++ {
+		for cz := int32(-1); cz <= 2; cz++ {
+			_ = db.Put(leveldb.ChunkKey{X: cx, Z: cz, Tag: leveldb.Version}.Bytes(), []byte{40})
+		}
+	}
+
+	return NewFromDB(db), db
+}
+
+func checkBlocks(t *testing.T, w *World, want map[[3]int]Block) {
+	t.Helper()
+
+	for p, b := range want {
+		got, err := w.GetBlock(p[0], p[1], p[2], 0)
+		if errors.Is(err, &SubChunkNotSavedError{}) {
+			got = Block{ID: BlockAir}
+		} else if err != nil {
+			t.Fatalf("unexpected error getting block at %v: %s", p, err)
+		}
+
+		if got.ID != b.ID || got.waterLogged != b.waterLogged {
+			t.Errorf("expected %s (water logged %t) at %v: got %s (water logged %t)",
+				b.ID, b.waterLogged, p, got.ID, got.waterLogged)
+		}
+	}
+}
+
+func TestFill(t *testing.T) {
+	w, db := testGeneratedWorld()
+
+	value, _ := nbt.Encode([]nbt.NBTTag{testBlockEntity("Chest", 3, 65, 3), testBlockEntity("Chest", 3, 90, 3)})
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.BlockEntity}.Bytes(), value)
+
+	// Sub chunk 0 4 0 is covered, the others are partly filled
+	n, err := w.Fill(NewSelection(0, 64, 0, 17, 80, 15, 0), Block{ID: BlockStone})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := 18 * 17 * 16; n != want {
+		t.Errorf("expected %d blocks filled: got %d", want, n)
+	}
+
+	seagrass := Block{ID: "minecraft:seagrass", waterLogged: true}
+	if _, err := w.Fill(NewSelection(2, 70, 2, 2, 70, 2, 0), seagrass); err != nil {
+		t.Fatalf("unexpected error filling with a water logged block: %s", err)
+	}
+
+	checkBlocks(t, w, map[[3]int]Block{
+		{0, 64, 0}:   {ID: BlockStone},
+		{15, 79, 15}: {ID: BlockStone},
+		{17, 80, 15}: {ID: BlockStone},
+		{2, 70, 2}:   {ID: "minecraft:seagrass", waterLogged: true},
+		{18, 64, 0}:  {ID: BlockAir},
+		{0, 81, 0}:   {ID: BlockAir},
+		{0, 63, 0}:   {ID: BlockAir},
+		{0, 64, 16}:  {ID: BlockAir},
+	})
+
+	s, err := w.SubChunk(0, 4, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocks := s.PaletteBlocks(); len(blocks) != 2 {
+		t.Errorf("expected the stone and seagrass in the palette of the covered sub chunk: got %v", blocks)
+	}
+
+	if _, ok, _ := w.BlockEntityAt(3, 65, 3, 0); ok {
+		t.Error("expected the block entity in the region to be removed")
+	}
+	if _, ok, _ := w.BlockEntityAt(3, 90, 3, 0); !ok {
+		t.Error("expected the block entity outside the region to be kept")
+	}
+
+	// Air needs no sub chunks, so chunks which aren't generated are skipped
+	ungenerated := NewSelection(100, 0, 100, 110, 10, 110, 0)
+	if _, err := w.Fill(ungenerated, Block{ID: BlockAir}); err != nil {
+		t.Errorf("unexpected error filling an ungenerated chunk with air: %s", err)
+	}
+	if _, err := w.Fill(ungenerated, Block{ID: BlockStone}); !errors.Is(err, ErrChunkNotGenerated) {
+		t.Errorf("expected ErrChunkNotGenerated: got %v", err)
+	}
+	_, err = w.Fill(NewSelection(0, 300, 0, 1, 400, 1, 0), Block{ID: BlockStone})
+	if !errors.Is(err, ErrOutsideHeight) {
+		t.Errorf("expected ErrOutsideHeight: got %v", err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	w, db := testGeneratedWorld()
+
+	row := []string{BlockStone, BlockDirt, BlockGlass, BlockGrass}
+	blocks := make([]Block, 0)
+	for x, id := range row {
+		blocks = append(blocks, Block{ID: id, X: x, Y: 64})
+	}
+	blocks = append(blocks, Block{ID: BlockDirt, X: 0, Y: 65, Z: 1, waterLogged: true})
+	if err := w.SetBlocks(blocks, 0); err != nil {
+		t.Fatalf("unexpected error setting blocks: %s", err)
+	}
+
+	// A chest with a block state and a block entity
+	s, _ := w.SubChunk(0, 4, 0, 0)
+	chest := testPaletteEntry("minecraft:chest")
+	_ = chest.SetChild(testCompound("states", nbt.NBTTag{Type: nbt.TagInt, Name: "facing_direction", Value: 3}))
+	s.setEntry(subChunkVoxelToIndex(1, 1, 1), chest)
+	_ = w.SetSubChunk(s)
+	value, _ := nbt.Encode([]nbt.NBTTag{testBlockEntity("Chest", 1, 65, 1)})
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.BlockEntity}.Bytes(), value)
+
+	n, err := w.Clone(NewSelection(0, 64, 0, 3, 65, 1, 0), [3]int{14, 70, -1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n != 16 {
+		t.Errorf("expected 16 blocks cloned: got %d", n)
+	}
+
+	// Overlapping regions are copied from the original source
+	if _, err := w.Clone(NewSelection(0, 64, 0, 3, 64, 0, 0), [3]int{1, 64, 0}); err != nil {
+		t.Fatalf("unexpected error cloning an overlapping region: %s", err)
+	}
+
+	// Whole sub chunks are copied when the offset is aligned
+	if _, err := w.Clone(NewSelection(0, 64, 0, 15, 79, 15, 0), [3]int{16, 96, 16}); err != nil {
+		t.Fatalf("unexpected error cloning whole sub chunks: %s", err)
+	}
+
+	checkBlocks(t, w, map[[3]int]Block{
+		{14, 70, -1}: {ID: BlockStone},
+		{17, 70, -1}: {ID: BlockGrass},
+		{14, 71, 0}:  {ID: BlockDirt, waterLogged: true},
+		{15, 71, 0}:  {ID: "minecraft:chest"},
+		{0, 64, 0}:   {ID: BlockStone},
+		{1, 64, 0}:   {ID: BlockStone},
+		{2, 64, 0}:   {ID: BlockDirt},
+		{4, 64, 0}:   {ID: BlockGrass},
+		{16, 96, 16}: {ID: BlockStone},
+		{16, 97, 17}: {ID: BlockDirt, waterLogged: true},
+	})
+
+	moved, err := w.SubChunk(0, 4, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := moved.data.Blocks.Palette[moved.data.Blocks.Indices[subChunkVoxelToIndex(15, 7, 0)]]
+	if facing, _ := entry.Path("states", "facing_direction"); facing.Value != 3.0 {
+		t.Errorf("expected the cloned chest's facing_direction state to be kept: got %v", facing.Value)
+	}
+
+	for _, p := range [][3]int{{1, 65, 1}, {15, 71, 0}, {17, 97, 17}} {
+		if e, ok, err := w.BlockEntityAt(p[0], p[1], p[2], 0); err != nil || !ok || e.ID != "Chest" {
+			t.Errorf("expected a chest block entity at %v: got '%s' %t %v", p, e.ID, ok, err)
+		}
+	}
+
+	_, err = w.Clone(NewSelection(0, 64, 0, 1, 64, 1, 0), [3]int{100, 64, 100})
+	if !errors.Is(err, ErrChunkNotGenerated) {
+		t.Errorf("expected ErrChunkNotGenerated: got %v", err)
+	}
+}
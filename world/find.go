@@ -0,0 +1,55 @@
+package world
+
+import (
+	"errors"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// BlockMatcher reports whether a block in the given dimension should be returned by FindBlocks.
+type BlockMatcher func(b Block, dimension int) (bool, error)
+
+// FindBlocks returns every saved block for which match returns true. Blocks in sub chunks which are not saved are not
+// checked. If the time budget runs out the blocks found so far are returned with a *PartialResultError.
+func (w *World) FindBlocks(match BlockMatcher) ([]Block, error) {
+	found := make([]Block, 0)
+
+	err := w.ForEachBlock(match, func(b Block) error {
+		found = append(found, b)
+		return nil
+	})
+	if errors.Is(err, ErrTimeBudget) {
+		return found, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// ForEachBlock calls f with every saved block for which match returns true, as returned by FindBlocks, without holding
+// them all in memory. Iteration stops if f returns an error.
+func (w *World) ForEachBlock(match BlockMatcher, f func(b Block) error) error {
+	return w.forEachSubChunk(w.startBudget(), func(k leveldb.ChunkKey, s *subChunkData) error {
+		ox, oy, oz := subChunkKeyOrigin(k)
+
+		for i := range s.Blocks.Indices {
+			x, y, z := subChunkIndexToVoxel(i)
+			b := s.block(i, ox+x, oy+y, oz+z)
+
+			ok, err := match(b, int(k.Dimension))
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				if err := f(b); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
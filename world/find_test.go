@@ -0,0 +1,31 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestFindBlocks(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := &World{
+		db:        db,
+		subChunks: make(map[struct{ x, y, z, d int }]*subChunkData),
+	}
+
+	_ = db.Put(leveldb.ChunkKey{X: -1, Z: 2, Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{1, 2, 3}: "minecraft:chest", {4, 5, 6}: "minecraft:stone"}))
+
+	blocks, err := w.FindBlocks(func(b Block, dimension int) (bool, error) {
+		return b.ID == "minecraft:chest" && dimension == 0, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := testBlock("minecraft:chest", -15, 2, 35)
+	if len(blocks) != 1 || blocks[0] != want {
+		t.Fatalf("expected %+v: got %+v", want, blocks)
+	}
+}
@@ -0,0 +1,165 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+const flatWorldLayersTag = "FlatWorldLayers"
+
+// FlatLayer is one layer of a superflat world preset.
+type FlatLayer struct {
+	Block string `json:"block_name"` // The block ID e.g. minecraft:dirt
+	Count int    `json:"count"`      // The number of blocks in the layer
+}
+
+// FlatPreset is the superflat world preset stored as JSON in the FlatWorldLayers tag of level.dat. It is only used
+// when new chunks are generated, so editing it changes new terrain without changing existing chunks.
+type FlatPreset struct {
+	BiomeID int
+	Layers  []FlatLayer // Layers from the bottom of the world up
+
+	// The other preset properties, such as encoding_version and world_version, which are written back unchanged
+	other map[string]json.RawMessage
+}
+
+// ParseFlatPreset parses the JSON held in the FlatWorldLayers tag.
+func ParseFlatPreset(s string) (FlatPreset, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal([]byte(s), &fields); err != nil {
+		return FlatPreset{}, fmt.Errorf("unmarshaling preset: %w", err)
+	}
+
+	p := FlatPreset{other: fields}
+
+	if b, ok := fields["biome_id"]; ok {
+		if err := json.Unmarshal(b, &p.BiomeID); err != nil {
+			return FlatPreset{}, fmt.Errorf("unmarshaling biome_id: %w", err)
+		}
+	}
+
+	if b, ok := fields["block_layers"]; ok {
+		if err := json.Unmarshal(b, &p.Layers); err != nil {
+			return FlatPreset{}, fmt.Errorf("unmarshaling block_layers: %w", err)
+		}
+	}
+
+	delete(fields, "biome_id")
+	delete(fields, "block_layers")
+
+	return p, p.validate()
+}
+
+// ParseFlatLayers parses layers given as comma separated block IDs from the bottom up, each optionally preceded by a
+// count and *, for example "minecraft:bedrock,2*minecraft:dirt,minecraft:grass".
+func ParseFlatLayers(s string) ([]FlatLayer, error) {
+	layers := make([]FlatLayer, 0)
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		l := FlatLayer{Block: part, Count: 1}
+
+		if i := strings.Index(part, "*"); i >= 0 {
+			n, err := strconv.Atoi(part[:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid layer count in '%s': %w", part, err)
+			}
+
+			l = FlatLayer{Block: part[i+1:], Count: n}
+		}
+
+		if !strings.Contains(l.Block, ":") {
+			l.Block = "minecraft:" + l.Block
+		}
+
+		layers = append(layers, l)
+	}
+
+	return layers, FlatPreset{Layers: layers}.validate()
+}
+
+// Height returns the total thickness of the layers.
+func (p FlatPreset) Height() int {
+	h := 0
+	for _, l := range p.Layers {
+		h += l.Count
+	}
+
+	return h
+}
+
+// String returns the layers in the format read by ParseFlatLayers.
+func (p FlatPreset) String() string {
+	parts := make([]string, len(p.Layers))
+	for i, l := range p.Layers {
+		parts[i] = fmt.Sprintf("%d*%s", l.Count, l.Block)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// validate returns an error if any layer is empty or unnamed.
+func (p FlatPreset) validate() error {
+	for i, l := range p.Layers {
+		if l.Block == "" || l.Block == "minecraft:" {
+			return fmt.Errorf("layer %d has no block", i)
+		}
+
+		if l.Count < 1 {
+			return fmt.Errorf("layer %d (%s) has count %d: must be at least 1", i, l.Block, l.Count)
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the preset in the FlatWorldLayers format, keeping any properties read by ParseFlatPreset.
+func (p FlatPreset) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(p.other)+2)
+	for k, v := range p.other {
+		fields[k] = v
+	}
+
+	layers := p.Layers
+	if layers == nil {
+		layers = []FlatLayer{}
+	}
+
+	fields["biome_id"] = p.BiomeID
+	fields["block_layers"] = layers
+
+	return json.Marshal(fields)
+}
+
+// FlatPreset reads the superflat preset from level.dat. The preset is present in every world, but only used by worlds
+// created as superflat.
+func (w *World) FlatPreset() (FlatPreset, error) {
+	var s string
+	if err := w.readLevelDatFields([]levelDatField{{flatWorldLayersTag, nbt.TagString, &s}}); err != nil {
+		return FlatPreset{}, err
+	}
+
+	return ParseFlatPreset(s)
+}
+
+// SetFlatPreset writes the superflat preset to level.dat. The game overwrites level.dat when the world is closed, so
+// this must not be called while the world is open in the game or a server.
+func (w *World) SetFlatPreset(p FlatPreset) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshaling preset: %w", err)
+	}
+
+	// The game writes the preset with a trailing newline
+	s := string(b) + "\n"
+
+	return w.setLevelDatFields([]levelDatField{{flatWorldLayersTag, nbt.TagString, &s}})
+}
@@ -0,0 +1,54 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+const testFlatPreset = `{"biome_id":1,"block_layers":[{"block_name":"minecraft:bedrock","count":1},` +
+	`{"block_name":"minecraft:dirt","count":2},{"block_name":"minecraft:grass","count":1}],"encoding_version":6,` +
+	`"structure_options":null,"world_version":"version.post_1_18"}` + "\n"
+
+func TestFlatPreset(t *testing.T) {
+	w := testLevelDat(t, nbt.NBTTag{Name: "FlatWorldLayers", Type: nbt.TagString, Value: testFlatPreset})
+
+	p, err := w.FlatPreset()
+	if err != nil {
+		t.Fatalf("unexpected error reading preset: %s", err)
+	}
+
+	if p.BiomeID != 1 || p.Height() != 4 || p.String() != "1*minecraft:bedrock,2*minecraft:dirt,1*minecraft:grass" {
+		t.Errorf("unexpected preset: biome %d height %d layers %s", p.BiomeID, p.Height(), p)
+	}
+
+	p.Layers, err = ParseFlatLayers("minecraft:bedrock, 3*stone,sandstone")
+	if err != nil {
+		t.Fatalf("unexpected error parsing layers: %s", err)
+	}
+
+	if err := w.SetFlatPreset(p); err != nil {
+		t.Fatalf("unexpected error writing preset: %s", err)
+	}
+
+	got, err := w.FlatPreset()
+	if err != nil {
+		t.Fatalf("unexpected error reading preset after writing: %s", err)
+	}
+
+	want := []FlatLayer{{"minecraft:bedrock", 1}, {"minecraft:stone", 3}, {"minecraft:sandstone", 1}}
+	if !reflect.DeepEqual(got.Layers, want) {
+		t.Errorf("expected layers %v: got %v", want, got.Layers)
+	}
+
+	if string(got.other["world_version"]) != `"version.post_1_18"` || string(got.other["encoding_version"]) != "6" {
+		t.Errorf("expected other preset properties to be kept: got %v", got.other)
+	}
+
+	for _, invalid := range []string{"0*minecraft:dirt", "x*minecraft:dirt", "minecraft:dirt,"} {
+		if _, err := ParseFlatLayers(invalid); err == nil {
+			t.Errorf("expected error parsing '%s'", invalid)
+		}
+	}
+}
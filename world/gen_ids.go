@@ -0,0 +1,100 @@
+//go:build ignore
+// +build ignore
+
+// gen_ids writes ids.go, the constants of vanilla biome IDs and common block IDs. Run it with go generate after
+// changing the tables below.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+)
+
+// biomes are the Bedrock Edition biome IDs and names.
+var biomes = map[int]string{
+	0: "ocean", 1: "plains", 2: "desert", 3: "extreme_hills", 4: "forest", 5: "taiga", 6: "swampland", 7: "river",
+	8: "hell", 9: "the_end", 10: "legacy_frozen_ocean", 11: "frozen_river", 12: "ice_plains", 13: "ice_mountains",
+	14: "mushroom_island", 15: "mushroom_island_shore", 16: "beach", 17: "desert_hills", 18: "forest_hills",
+	19: "taiga_hills", 20: "extreme_hills_edge", 21: "jungle", 22: "jungle_hills", 23: "jungle_edge",
+	24: "deep_ocean", 25: "stone_beach", 26: "cold_beach", 27: "birch_forest", 28: "birch_forest_hills",
+	29: "roofed_forest", 30: "cold_taiga", 31: "cold_taiga_hills", 32: "mega_taiga", 33: "mega_taiga_hills",
+	34: "extreme_hills_plus_trees", 35: "savanna", 36: "savanna_plateau", 37: "mesa", 38: "mesa_plateau_stone",
+	39: "mesa_plateau", 40: "warm_ocean", 41: "deep_warm_ocean", 42: "lukewarm_ocean", 43: "deep_lukewarm_ocean",
+	44: "cold_ocean", 45: "deep_cold_ocean", 46: "frozen_ocean", 47: "deep_frozen_ocean", 48: "bamboo_jungle",
+	49: "bamboo_jungle_hills", 129: "sunflower_plains", 130: "desert_mutated", 131: "extreme_hills_mutated",
+	132: "flower_forest", 133: "taiga_mutated", 134: "swampland_mutated", 140: "ice_plains_spikes",
+	149: "jungle_mutated", 151: "jungle_edge_mutated", 155: "birch_forest_mutated", 156: "birch_forest_hills_mutated",
+	157: "roofed_forest_mutated", 158: "cold_taiga_mutated", 160: "redwood_taiga_mutated",
+	161: "redwood_taiga_hills_mutated", 162: "extreme_hills_plus_trees_mutated", 163: "savanna_mutated",
+	164: "savanna_plateau_mutated", 165: "mesa_bryce", 166: "mesa_plateau_stone_mutated", 167: "mesa_plateau_mutated",
+	178: "soulsand_valley", 179: "crimson_forest", 180: "warped_forest", 181: "basalt_deltas", 182: "jagged_peaks",
+	183: "frozen_peaks", 184: "snowy_slopes", 185: "grove", 186: "meadow", 187: "lush_caves", 188: "dripstone_caves",
+	189: "stony_peaks", 190: "deep_dark", 191: "mangrove_swamp", 192: "cherry_grove",
+}
+
+// blocks are the names of the block IDs given constants, without the minecraft: namespace.
+var blocks = []string{
+	"air", "stone", "grass", "dirt", "cobblestone", "bedrock", "sand", "gravel", "clay", "snow", "ice", "glass",
+	"sandstone", "deepslate", "netherrack", "soul_sand", "glowstone", "obsidian", "end_stone",
+	"water", "flowing_water", "lava", "flowing_lava",
+	"oak_log", "oak_planks", "oak_leaves",
+	"coal_ore", "iron_ore", "copper_ore", "gold_ore", "redstone_ore", "lapis_ore", "diamond_ore", "emerald_ore",
+	"ancient_debris",
+	"iron_block", "gold_block", "diamond_block", "emerald_block", "netherite_block",
+	"portal", "end_portal", "end_portal_frame",
+	"chest", "barrel", "crafting_table", "furnace", "bookshelf", "bed", "torch", "tnt", "mob_spawner",
+}
+
+func main() {
+	var b bytes.Buffer
+
+	b.WriteString("// Code generated by gen_ids.go; DO NOT EDIT.\n\npackage world\n\n")
+
+	ids := make([]int, 0, len(biomes))
+	for id := range biomes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	b.WriteString("// Vanilla biomes.\nconst (\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "\tBiome%s Biome = %d\n", camelCase(biomes[id]), id)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// biomeNames are the Bedrock Edition biome IDs and names.\nvar biomeNames = map[int]string{\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "\t%d: %q,\n", id, biomes[id])
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// Common vanilla block IDs.\nconst (\n")
+	for _, n := range blocks {
+		fmt.Fprintf(&b, "\tBlock%s = %q\n", camelCase(n), "minecraft:"+n)
+	}
+	b.WriteString(")\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile("ids.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// camelCase converts a snake case name to an exported Go name, e.g. the_end to TheEnd.
+func camelCase(s string) string {
+	parts := strings.Split(s, "_")
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,174 @@
+package world
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// gravityBlocks are the blocks which fall when there is nothing below them. Concrete powder and anvils are matched by
+// suffix, as newer versions of the game give each colour and damage level its own ID.
+var gravityBlocks = map[string]bool{
+	"minecraft:sand":              true,
+	"minecraft:red_sand":          true,
+	"minecraft:gravel":            true,
+	"minecraft:suspicious_sand":   true,
+	"minecraft:suspicious_gravel": true,
+	"minecraft:dragon_egg":        true,
+}
+
+// IsGravityBlock returns true if blocks with the given ID fall when there is nothing below them.
+func IsGravityBlock(id string) bool {
+	return gravityBlocks[id] || strings.HasSuffix(id, "concrete_powder") || strings.HasSuffix(id, "anvil")
+}
+
+// fallThroughBlocks are the blocks which a falling block replaces.
+var fallThroughBlocks = map[string]bool{
+	BlockAir:                  true,
+	BlockWater:                true,
+	"minecraft:flowing_water": true,
+	"minecraft:lava":          true,
+	"minecraft:flowing_lava":  true,
+}
+
+// SettleBlocks drops the unsupported gravity blocks in the region, such as sand, gravel and concrete powder, to where
+// the game would leave them after they fell, returning the number of blocks moved. Blocks fall through air, water and
+// lava, which they replace, and may land below the region. Unsaved sub chunks count as air. Blocks keep their states.
+//
+// The bottom of the dimension supports blocks, where the game would let them fall into the void.
+func (w *World) SettleBlocks(region Selection) (int, error) {
+	r, ok := subChunkRanges[region.Dimension]
+	if !ok {
+		return 0, fmt.Errorf("%w %d", ErrInvalidDimension, region.Dimension)
+	}
+
+	bottom := int(r[0]) * chunkSize
+	minY := maxInt(region.Min[1], bottom)
+	maxY := minInt(region.Max[1], int(r[1])*chunkSize+chunkSize-1)
+
+	moved := 0
+
+	for cx := floorDiv(region.Min[0], chunkSize); cx <= floorDiv(region.Max[0], chunkSize); cx++ {
+		for cz := floorDiv(region.Min[2], chunkSize); cz <= floorDiv(region.Max[2], chunkSize); cz++ {
+			c, err := w.GetChunk(cx, cz, region.Dimension)
+			if err != nil {
+				return moved, err
+			}
+
+			// A chunk with no saved sub chunks has no blocks to fall
+			if len(c.subChunks) == 0 {
+				continue
+			}
+
+			changed := make(map[int]*SubChunk)
+			ox, oz := cx*chunkSize, cz*chunkSize
+
+			for x := maxInt(region.Min[0]-ox, 0); x <= minInt(region.Max[0]-ox, chunkSize-1); x++ {
+				for z := maxInt(region.Min[2]-oz, 0); z <= minInt(region.Max[2]-oz, chunkSize-1); z++ {
+					moved += c.settleColumn(x, z, minY, maxY, bottom, changed)
+				}
+			}
+
+			for _, s := range changed {
+				if err := w.SetSubChunk(s); err != nil {
+					return moved, err
+				}
+			}
+		}
+	}
+
+	return moved, nil
+}
+
+// settleColumn drops the gravity blocks in one column of the chunk between minY and maxY, adding the sub chunks it
+// changes to changed. It returns the number of blocks moved.
+func (c *Chunk) settleColumn(x, z, minY, maxY, bottom int, changed map[int]*SubChunk) int {
+	// The lowest position a falling block would land in, or nil if the block below is a support
+	var landing *int
+	for y := minY - 1; y >= bottom && c.fallThrough(x, y, z); y-- {
+		l := y
+		landing = &l
+	}
+
+	moved := 0
+
+	for y := minY; y <= maxY; y++ {
+		if c.fallThrough(x, y, z) {
+			if landing == nil {
+				l := y
+				landing = &l
+			}
+			continue
+		}
+
+		s, i := c.subChunkAt(x, y, z, false)
+		entry := s.data.Blocks.Palette[s.data.Blocks.Indices[i]]
+
+		if landing == nil || !IsGravityBlock(entry.BlockID()) {
+			landing = nil
+			continue
+		}
+
+		// Every position from landing up to y falls through, so the next block lands on this one
+		to, j := c.subChunkAt(x, *landing, z, true)
+		to.setEntry(j, entry)
+		to.setWaterLogged(j, false)
+		changed[to.Y] = to
+
+		s.setAt(x, y-s.Y*chunkSize, z, Block{ID: BlockAir})
+		changed[s.Y] = s
+
+		*landing++
+		moved++
+	}
+
+	return moved
+}
+
+// fallThrough returns true if a falling block would pass through the block at the given column of the chunk and world
+// Y.
+func (c *Chunk) fallThrough(x, y, z int) bool {
+	s, i := c.subChunkAt(x, y, z, false)
+	if s == nil {
+		return true
+	}
+
+	return fallThroughBlocks[s.data.Blocks.Palette[s.data.Blocks.Indices[i]].BlockID()]
+}
+
+// subChunkAt returns the sub chunk holding the given column of the chunk and world Y, and the index of the block in
+// it. If the sub chunk is not saved it is nil, unless create is true, in which case a sub chunk of air is added.
+func (c *Chunk) subChunkAt(x, y, z int, create bool) (*SubChunk, int) {
+	sy := floorDiv(y, chunkSize)
+
+	s, ok := c.subChunks[sy]
+	if !ok {
+		if !create {
+			return nil, 0
+		}
+
+		s = NewSubChunk(c.X, sy, c.Z, c.Dimension, BlockAir)
+		c.subChunks[sy] = s
+	}
+
+	return s, subChunkVoxelToIndex(x, y-sy*chunkSize, z)
+}
+
+// setEntry sets the block at index i to the given palette entry, keeping its states, adding it to the palette if
+// there is no identical entry.
+func (s *SubChunk) setEntry(i int, entry nbt.NBTTag) {
+	blocks := &s.data.Blocks
+
+	for j, e := range blocks.Palette {
+		if reflect.DeepEqual(e, entry) {
+			blocks.Indices[i] = j
+			return
+		}
+	}
+
+	blocks.Palette = append(blocks.Palette, entry)
+	blocks.BitsPerBlock = maxInt(blocks.BitsPerBlock, minimalBitsPerBlock(len(blocks.Palette)))
+	blocks.Indices[i] = len(blocks.Palette) - 1
+}
@@ -0,0 +1,53 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestSettleBlocks(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{
+			// A column with a gap below two sand blocks and gravel resting on stone
+			{1, 0, 1}: BlockStone, {1, 4, 1}: "minecraft:sand", {1, 5, 1}: "minecraft:sand",
+			{1, 7, 1}: BlockStone, {1, 8, 1}: "minecraft:gravel",
+			// Concrete powder which falls below the region
+			{2, 2, 2}: BlockStone, {2, 12, 2}: "minecraft:white_concrete_powder",
+			// Sand outside the region
+			{3, 10, 3}: "minecraft:sand",
+		}))
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.SubChunkPrefix, SubChunkY: 1}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{1, 0, 1}: "minecraft:sand"})) // Lands on the gravel
+
+	n, err := w.SettleBlocks(NewSelection(0, 4, 0, 2, 20, 2, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n != 4 {
+		t.Errorf("expected 4 blocks moved: got %d", n)
+	}
+
+	for pos, want := range map[[3]int]string{
+		{1, 1, 1}: "minecraft:sand", {1, 2, 1}: "minecraft:sand", {1, 4, 1}: BlockAir, {1, 5, 1}: BlockAir,
+		{1, 8, 1}: "minecraft:gravel", {1, 9, 1}: "minecraft:sand", {1, 16, 1}: BlockAir,
+		{2, 3, 2}: "minecraft:white_concrete_powder", {2, 12, 2}: BlockAir,
+		{3, 10, 3}: "minecraft:sand",
+	} {
+		b, err := w.GetBlock(pos[0], pos[1], pos[2], 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.ID != want {
+			t.Errorf("expected %s at %v: got %s", want, pos, b.ID)
+		}
+	}
+
+	if n, err := w.SettleBlocks(NewSelection(0, 4, 0, 2, 20, 2, 0)); err != nil || n != 0 {
+		t.Errorf("expected nothing to settle a second time: got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,150 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// TextMatch is a string tag whose value matched a text search.
+type TextMatch struct {
+	Key   []byte   // The key of the record holding the tag
+	Path  []string // The index of the root tag in the record, then the compound names and list indices to the tag
+	Value string   // The value of the tag
+
+	// The position of the nearest compound enclosing the tag which has one, such as a block entity or an entity. If
+	// HasPosition is false the dimension is still set for records which belong to a chunk or a player.
+	HasPosition bool
+	X, Y, Z     int
+	Dimension   int
+}
+
+// SearchText calls f with every string tag in every record holding NBT data whose value matches re, including sign
+// text, book pages, custom names and command block commands. Records are read in key order and not modified. Iteration
+// stops if f returns an error, which is returned.
+func (w *World) SearchText(re *regexp.Regexp, f func(m TextMatch) error) error {
+	d := nbt.NewDecoder()
+
+	return w.eachKey(w.startBudget(), func(key []byte) error {
+		if !isNBTRecord(key) {
+			return nil
+		}
+
+		defer d.Release()
+
+		value, err := w.db.Get(key)
+		if err != nil {
+			return fmt.Errorf("getting key '%x': %w", key, err)
+		}
+
+		tags, err := d.Decode(value)
+		if err != nil {
+			return fmt.Errorf("decoding key '%x': %w", key, err)
+		}
+
+		// The key is only valid until the callback returns, so it is copied once for every match in the record
+		var saved []byte
+
+		for i, t := range tags {
+			m := TextMatch{Dimension: recordDimension(key, t)}
+
+			err := searchTag(re, t, []string{strconv.Itoa(i)}, m, func(m TextMatch) error {
+				if saved == nil {
+					saved = append([]byte(nil), key...)
+				}
+				m.Key = saved
+
+				return f(m)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// searchTag calls f with each string tag in t, or t itself, whose value matches re. The match is passed with the
+// position of the nearest enclosing compound which has one, or that of m.
+func searchTag(re *regexp.Regexp, t nbt.NBTTag, path []string, m TextMatch, f func(m TextMatch) error) error {
+	switch t.Type {
+	case nbt.TagString:
+		s, _ := t.StringValue()
+		if !re.MatchString(s) {
+			return nil
+		}
+
+		m.Path = append([]string(nil), path...)
+		m.Value = s
+
+		return f(m)
+	case nbt.TagCompound:
+		if x, y, z, ok := tagPosition(t); ok {
+			m.X, m.Y, m.Z, m.HasPosition = x, y, z, true
+		}
+
+		for _, c := range t.Tags() {
+			if err := searchTag(re, c, append(path[:len(path):len(path)], c.Name), m, f); err != nil {
+				return err
+			}
+		}
+	case nbt.TagList:
+		for i, e := range t.List() {
+			if err := searchTag(re, e, append(path[:len(path):len(path)], strconv.Itoa(i)), m, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// tagPosition returns the block position of a compound with integer x, y and z children, such as a block entity, or
+// with a Pos list of three numbers, such as an entity.
+func tagPosition(t nbt.NBTTag) (x, y, z int, ok bool) {
+	if pos, found := t.Child("Pos"); found {
+		if p := pos.List(); len(p) == 3 {
+			var c [3]int
+			for i := range p {
+				f, isNumber := p[i].Float()
+				if !isNumber {
+					return 0, 0, 0, false
+				}
+				c[i] = int(math.Floor(f))
+			}
+
+			return c[0], c[1], c[2], true
+		}
+	}
+
+	var c [3]int
+	for i, name := range []string{"x", "y", "z"} {
+		tag, found := t.Child(name)
+		if !found {
+			return 0, 0, 0, false
+		}
+
+		v, isInt := tag.Int()
+		if !isInt {
+			return 0, 0, 0, false
+		}
+		c[i] = int(v)
+	}
+
+	return c[0], c[1], c[2], true
+}
+
+// recordDimension returns the dimension of a root tag in the record with the given key: the dimension of the chunk
+// for chunk records, otherwise the tag's DimensionId, which entities and players have, or 0.
+func recordDimension(key []byte, t nbt.NBTTag) int {
+	if k, ok := leveldb.ParseChunkKey(key); ok {
+		return int(k.Dimension)
+	}
+
+	return int(childInt(t, "DimensionId"))
+}
@@ -0,0 +1,78 @@
+package world
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestSearchText(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	sign := testBlockEntity("Sign", 0, 64, 0)
+	_ = sign.SetChild(testCompound("FrontText", testString("Text", "Alice's house")))
+
+	book := testCompound("",
+		testString("Name", "minecraft:written_book"),
+		testCompound("tag", testList("pages", nbt.TagCompound, testCompound("", testString("text", "Dear alice")))),
+	)
+	chest := testBlockEntity("Chest", 1, 64, 0)
+	_ = chest.SetChild(testList("Items", nbt.TagCompound, book))
+
+	blockEntities, _ := nbt.Encode([]nbt.NBTTag{sign, chest})
+	_ = db.Put(leveldb.ChunkKey{Dimension: 1, Tag: leveldb.BlockEntity}.Bytes(), blockEntities)
+
+	wolf := testEntity("minecraft:wolf", 5, 1.5, 64, -0.5)
+	_ = wolf.SetChild(testString("CustomName", "Alice's wolf"))
+	actor, _ := nbt.Encode([]nbt.NBTTag{wolf})
+	_ = db.Put(leveldb.ActorKey([]byte{0, 0, 0, 0, 0, 0, 0, 5}), actor)
+
+	player := testCompound("", testString("PlatformOnlineId", "alice"),
+		nbt.NBTTag{Type: nbt.TagInt, Name: "DimensionId", Value: 2})
+	value, _ := nbt.Encode([]nbt.NBTTag{player})
+	_ = db.Put([]byte("~local_player"), value)
+
+	// Text in other records isn't searched
+	_ = db.Put([]byte("mVillage"), []byte("alice"))
+
+	matches := make(map[string]TextMatch)
+	err := w.SearchText(regexp.MustCompile("(?i)alice"), func(m TextMatch) error {
+		matches[strings.Join(m.Path, "/")] = m
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := map[string]TextMatch{
+		"0/FrontText/Text":           {Value: "Alice's house", HasPosition: true, X: 0, Y: 64, Z: 0, Dimension: 1},
+		"1/Items/0/tag/pages/0/text": {Value: "Dear alice", HasPosition: true, X: 1, Y: 64, Z: 0, Dimension: 1},
+		"0/CustomName":               {Value: "Alice's wolf", HasPosition: true, X: 1, Y: 64, Z: -1},
+		"0/PlatformOnlineId":         {Value: "alice", Dimension: 2},
+	}
+
+	if len(matches) != len(want) {
+		t.Errorf("expected %d matches: got %d: %v", len(want), len(matches), matches)
+	}
+
+	for path, m := range want {
+		got, ok := matches[path]
+		if !ok {
+			t.Errorf("expected a match at %s", path)
+			continue
+		}
+		if len(got.Key) == 0 {
+			t.Errorf("expected the key of the match at %s", path)
+		}
+
+		if got.Value != m.Value || got.HasPosition != m.HasPosition || got.X != m.X || got.Y != m.Y || got.Z != m.Z ||
+			got.Dimension != m.Dimension {
+			t.Errorf("expected %+v at %s: got %+v", m, path, got)
+		}
+	}
+}
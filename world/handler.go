@@ -0,0 +1,287 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// RecordHandler teaches the package about a type of LevelDB record, such as a record added by a newer version of the
+// game or by an add-on. Handlers may also implement RecordValidator, RecordDiffer and RecordMigrator.
+type RecordHandler interface {
+	// Name identifies the record type, for example "nbt" or "subchunk".
+	Name() string
+	// Match returns true if the handler understands records with the given key.
+	Match(key []byte) bool
+	// Decode returns a representation of the record value suitable for inspection or JSON encoding.
+	Decode(key, value []byte) (interface{}, error)
+}
+
+// RecordValidator is implemented by record handlers which can check a record for corruption.
+type RecordValidator interface {
+	Validate(key, value []byte) error
+}
+
+// RecordDiffer is implemented by record handlers which can describe the differences between two values of a record.
+type RecordDiffer interface {
+	Diff(key, a, b []byte) ([]string, error)
+}
+
+// RecordMigrator is implemented by record handlers which can upgrade records to the current format. Migrate returns
+// the new value and true if the value was changed.
+type RecordMigrator interface {
+	Migrate(key, value []byte) ([]byte, bool, error)
+}
+
+var handlers = struct {
+	sync.RWMutex
+	list []RecordHandler
+}{
+	list: []RecordHandler{nbtHandler{}, subChunkHandler{}},
+}
+
+// RegisterRecordHandler adds a record handler. Handlers registered later take precedence over earlier handlers and
+// the built in handlers, so a handler may replace the built in handling of a record type.
+func RegisterRecordHandler(h RecordHandler) {
+	handlers.Lock()
+	defer handlers.Unlock()
+
+	handlers.list = append(handlers.list, h)
+}
+
+// RecordHandlerFor returns the handler for records with the given key, or false if no handler matches.
+func RecordHandlerFor(key []byte) (RecordHandler, bool) {
+	handlers.RLock()
+	defer handlers.RUnlock()
+
+	for i := len(handlers.list) - 1; i >= 0; i-- {
+		if handlers.list[i].Match(key) {
+			return handlers.list[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// RecordProblem is a record which failed validation by its handler.
+type RecordProblem struct {
+	Key     []byte
+	Handler string
+	Err     error
+}
+
+// DecodeRecord returns the value of the record with the given key, decoded by its handler, and the handler name.
+func (w *World) DecodeRecord(key []byte) (string, interface{}, error) {
+	h, ok := RecordHandlerFor(key)
+	if !ok {
+		return "", nil, fmt.Errorf("no handler for key '%x'", key)
+	}
+
+	value, err := w.db.Get(key)
+	if err != nil {
+		return "", nil, fmt.Errorf("getting key '%x': %w", key, err)
+	}
+
+	v, err := h.Decode(key, value)
+	if err != nil {
+		return "", nil, fmt.Errorf("decoding key '%x' as %s: %w", key, h.Name(), err)
+	}
+
+	return h.Name(), v, nil
+}
+
+// ValidateRecords checks every record whose handler implements RecordValidator and returns those which fail.
+func (w *World) ValidateRecords() ([]RecordProblem, error) {
+	problems := make([]RecordProblem, 0)
+
+	err := w.forEachHandledRecord(func(key, value []byte, h RecordHandler) error {
+		v, ok := h.(RecordValidator)
+		if !ok {
+			return nil
+		}
+
+		if err := v.Validate(key, value); err != nil {
+			problems = append(problems, RecordProblem{Key: key, Handler: h.Name(), Err: err})
+		}
+
+		return nil
+	})
+
+	return problems, err
+}
+
+// MigrateRecords upgrades every record whose handler implements RecordMigrator and returns the number of records
+// which were changed.
+func (w *World) MigrateRecords() (int, error) {
+	changed := 0
+
+	err := w.forEachHandledRecord(func(key, value []byte, h RecordHandler) error {
+		m, ok := h.(RecordMigrator)
+		if !ok {
+			return nil
+		}
+
+		migrated, ok, err := m.Migrate(key, value)
+		if err != nil {
+			return fmt.Errorf("migrating key '%x' as %s: %w", key, h.Name(), err)
+		}
+
+		if !ok {
+			return nil
+		}
+
+		if err := w.put(key, migrated); err != nil {
+			return fmt.Errorf("putting key '%x': %w", key, err)
+		}
+		changed++
+
+		return nil
+	})
+
+	return changed, err
+}
+
+// DiffRecord describes the differences between two values of the record with the given key, using its handler. If the
+// handler doesn't implement RecordDiffer, the values are only compared for equality.
+func DiffRecord(key, a, b []byte) ([]string, error) {
+	h, ok := RecordHandlerFor(key)
+	if d, isDiffer := h.(RecordDiffer); ok && isDiffer {
+		return d.Diff(key, a, b)
+	}
+
+	if string(a) == string(b) {
+		return nil, nil
+	}
+
+	return []string{fmt.Sprintf("value changed from %d to %d bytes", len(a), len(b))}, nil
+}
+
+// forEachHandledRecord calls f with every record which has a handler. Iteration stops if f returns an error.
+func (w *World) forEachHandledRecord(f func(key, value []byte, h RecordHandler) error) error {
+	keys, err := w.db.Keys()
+	if err != nil {
+		return fmt.Errorf("listing keys: %w", err)
+	}
+
+	for _, key := range keys {
+		h, ok := RecordHandlerFor(key)
+		if !ok {
+			continue
+		}
+
+		value, err := w.db.Get(key)
+		if err != nil {
+			return fmt.Errorf("getting key '%x': %w", key, err)
+		}
+
+		if err := f(key, value, h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nbtHandler handles records which hold only NBT data.
+type nbtHandler struct{}
+
+func (nbtHandler) Name() string { return "nbt" }
+
+func (nbtHandler) Match(key []byte) bool { return isNBTRecord(key) }
+
+func (nbtHandler) Decode(_, value []byte) (interface{}, error) { return nbt.Decode(value) }
+
+func (nbtHandler) Validate(_, value []byte) error {
+	_, err := nbt.Decode(value)
+	return err
+}
+
+// Diff lists each value which was added, removed or changed, by its path from the root tags.
+func (nbtHandler) Diff(_, a, b []byte) ([]string, error) {
+	before, err := nbtLeaves(a)
+	if err != nil {
+		return nil, fmt.Errorf("decoding first value: %w", err)
+	}
+
+	after, err := nbtLeaves(b)
+	if err != nil {
+		return nil, fmt.Errorf("decoding second value: %w", err)
+	}
+
+	diff := make([]string, 0)
+
+	for path, v := range before {
+		w, ok := after[path]
+		switch {
+		case !ok:
+			diff = append(diff, fmt.Sprintf("removed %s: %s", path, v))
+		case v != w:
+			diff = append(diff, fmt.Sprintf("changed %s: %s to %s", path, v, w))
+		}
+	}
+
+	for path, v := range after {
+		if _, ok := before[path]; !ok {
+			diff = append(diff, fmt.Sprintf("added %s: %s", path, v))
+		}
+	}
+
+	sort.Strings(diff)
+
+	return diff, nil
+}
+
+// nbtLeaves returns the formatted value of every tag which is not a compound or list, keyed by its path.
+func nbtLeaves(data []byte) (map[string]string, error) {
+	tags, err := nbt.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	leaves := make(map[string]string)
+
+	for i, t := range tags {
+		nbt.Walk(t, func(path []string, t nbt.NBTTag) {
+			if t.Type == nbt.TagCompound || t.Type == nbt.TagList {
+				return
+			}
+
+			leaves[strconv.Itoa(i)+"/"+strings.Join(path, "/")] = fmt.Sprintf("%v", t.Value)
+		})
+	}
+
+	return leaves, nil
+}
+
+// subChunkHandler handles sub chunk block data records.
+type subChunkHandler struct{}
+
+func (subChunkHandler) Name() string { return "subchunk" }
+
+func (subChunkHandler) Match(key []byte) bool {
+	k, ok := leveldb.ParseChunkKey(key)
+	return ok && k.Tag == leveldb.SubChunkPrefix
+}
+
+// Decode returns the annotated layout of the sub chunk.
+func (subChunkHandler) Decode(_, value []byte) (interface{}, error) { return AnnotateSubChunk(value) }
+
+func (subChunkHandler) Validate(_, value []byte) error {
+	_, err := AnnotateSubChunk(value)
+	return err
+}
+
+// Diff reports the number of blocks whose ID or states changed.
+func (subChunkHandler) Diff(_, a, b []byte) ([]string, error) {
+	n, err := changedBlocks(a, b)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+
+	return []string{fmt.Sprintf("%d blocks changed", n)}, nil
+}
@@ -0,0 +1,91 @@
+package world
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// testHandler handles records prefixed with "test_handler_" whose values are "v1" or "v2".
+type testHandler struct{}
+
+func (testHandler) Name() string { return "test" }
+
+func (testHandler) Match(key []byte) bool { return bytes.HasPrefix(key, []byte("test_handler_")) }
+
+func (testHandler) Decode(_, value []byte) (interface{}, error) { return string(value), nil }
+
+func (testHandler) Validate(_, value []byte) error {
+	if string(value) != "v1" && string(value) != "v2" {
+		return errors.New("unknown version")
+	}
+	return nil
+}
+
+func (testHandler) Migrate(_, value []byte) ([]byte, bool, error) {
+	if string(value) == "v1" {
+		return []byte("v2"), true, nil
+	}
+	return value, false, nil
+}
+
+func TestRecordHandlers(t *testing.T) {
+	RegisterRecordHandler(testHandler{})
+
+	db := mock.NewLevelDB()
+	w := &World{db: db, subChunks: make(map[struct{ x, y, z, d int }]*subChunkData)}
+
+	_ = db.Put([]byte("test_handler_a"), []byte("v1"))
+	_ = db.Put([]byte("test_handler_b"), []byte("bad"))
+	_ = db.Put([]byte("unhandled"), []byte("anything"))
+
+	name, v, err := w.DecodeRecord([]byte("test_handler_a"))
+	if err != nil || name != "test" || v != "v1" {
+		t.Fatalf("expected test handler to decode v1: got %s %v %v", name, v, err)
+	}
+
+	if _, _, err := w.DecodeRecord([]byte("unhandled")); err == nil {
+		t.Fatalf("expected error decoding unhandled record")
+	}
+
+	problems, err := w.ValidateRecords()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(problems) != 1 || string(problems[0].Key) != "test_handler_b" || problems[0].Handler != "test" {
+		t.Fatalf("expected one problem with test_handler_b: got %+v", problems)
+	}
+
+	n, err := w.MigrateRecords()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if value, _ := db.Get([]byte("test_handler_a")); n != 1 || string(value) != "v2" {
+		t.Fatalf("expected test_handler_a to be migrated to v2: got %d records migrated and value %s", n, value)
+	}
+}
+
+func TestDiffRecord(t *testing.T) {
+	a := testEntity("minecraft:cow", 1, 0, 64, 0)
+	b := testEntity("minecraft:cow", 1, 0, 70, 0)
+	_ = b.SetChild(nbt.NBTTag{Type: nbt.TagString, Name: "CustomName", Value: "Daisy"})
+
+	before, _ := nbt.Encode([]nbt.NBTTag{a})
+	after, _ := nbt.Encode([]nbt.NBTTag{b})
+
+	diff, err := DiffRecord([]byte("actorprefix\x00\x00\x00\x00\x00\x00\x00\x01"), before, after)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{"added 0/CustomName: Daisy", "changed 0/Pos/1: 64 to 70"}
+	if !reflect.DeepEqual(diff, want) {
+		t.Fatalf("expected %q: got %q", want, diff)
+	}
+}
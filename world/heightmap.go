@@ -0,0 +1,103 @@
+package world
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// HeightMap returns the height of each column of the chunk with the given chunk coordinates, indexed [x][z]. A height
+// is the world Y above the highest block in the column, as the game saves it, or the lowest Y of the dimension if the
+// column is empty. The height map saved in the chunk's Data3D or Data2D record is used if there is one, otherwise the
+// sub chunks are scanned from the top down. The game's saved heights pass through some blocks, such as glass, which a
+// scan doesn't.
+func (w *World) HeightMap(cx, cz, dimension int) ([chunkSize][chunkSize]int, error) {
+	heights, err := w.savedHeightMap(cx, cz, dimension)
+	if err != nil {
+		return [chunkSize][chunkSize]int{}, err
+	}
+	if heights != nil {
+		return *heights, nil
+	}
+
+	surface, err := w.ChunkSurface(cx, cz, dimension)
+	if err != nil {
+		return [chunkSize][chunkSize]int{}, err
+	}
+
+	bottom := int(subChunkRanges[dimension][0]) * chunkSize
+
+	var scanned [chunkSize][chunkSize]int
+	for x := 0; x < chunkSize; x++ {
+		for z := 0; z < chunkSize; z++ {
+			scanned[x][z] = bottom
+			if b := surface[x][z]; b.ID != "" {
+				scanned[x][z] = b.Y + 1
+			}
+		}
+	}
+
+	return scanned, nil
+}
+
+// SurfaceBlock returns the highest saved block which is not air at the given world X and Z. The returned bool is false
+// if the column has no saved blocks other than air.
+func (w *World) SurfaceBlock(x, z, dimension int) (Block, bool, error) {
+	cx, cz := floorDiv(x, chunkSize), floorDiv(z, chunkSize)
+
+	surface, err := w.ChunkSurface(cx, cz, dimension)
+	if err != nil {
+		return Block{}, false, err
+	}
+
+	b := surface[x-cx*chunkSize][z-cz*chunkSize]
+
+	return b, b.ID != "", nil
+}
+
+// savedHeightMap reads the height map saved by the game in the Data3D or Data2D record of a chunk, converted to world
+// Y. It returns nil if the chunk has neither record.
+func (w *World) savedHeightMap(cx, cz, dimension int) (*[chunkSize][chunkSize]int, error) {
+	r, ok := subChunkRanges[dimension]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	// Both records start with a height map of 256 little endian int16s indexed by z * 16 + x. Data3D heights are
+	// relative to the bottom of the dimension.
+	for _, record := range []struct {
+		tag    byte
+		bottom int
+	}{
+		{leveldb.Data3D, int(r[0]) * chunkSize},
+		{leveldb.Data2D, 0},
+	} {
+		k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension), Tag: record.tag}
+
+		value, err := w.db.Get(k.Bytes())
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting key '%x': %w", k.Bytes(), err)
+		}
+		if len(value) < 2*chunkSize*chunkSize {
+			return nil, fmt.Errorf("height map of chunk %d %d is %d bytes long: expected at least 512",
+				cx, cz, len(value))
+		}
+
+		heights := &[chunkSize][chunkSize]int{}
+		for x := 0; x < chunkSize; x++ {
+			for z := 0; z < chunkSize; z++ {
+				i := 2 * (z*chunkSize + x)
+				heights[x][z] = record.bottom + int(int16(binary.LittleEndian.Uint16(value[i:])))
+			}
+		}
+
+		return heights, nil
+	}
+
+	return nil, nil
+}
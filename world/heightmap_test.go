@@ -0,0 +1,59 @@
+package world
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestHeightMap(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	// A chunk with no saved height map, with grass at -30 64 5 and stone at -29 -60 5
+	_ = db.Put(leveldb.ChunkKey{X: -2, Tag: leveldb.SubChunkPrefix, SubChunkY: 4}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{2, 0, 5}: BlockGrass}))
+	_ = db.Put(leveldb.ChunkKey{X: -2, Tag: leveldb.SubChunkPrefix, SubChunkY: -4}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{3, 4, 5}: BlockStone}))
+
+	heights, err := w.HeightMap(-2, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if heights[2][5] != 65 || heights[3][5] != -59 || heights[0][0] != -64 {
+		t.Errorf("expected scanned heights 65, -59 and -64: got %d, %d and %d",
+			heights[2][5], heights[3][5], heights[0][0])
+	}
+
+	// A saved height map is used instead of scanning
+	data3D := make([]byte, 512+1)
+	binary.LittleEndian.PutUint16(data3D[2*(5*chunkSize+2):], 200)
+	_ = db.Put(leveldb.ChunkKey{X: -2, Tag: leveldb.Data3D}.Bytes(), data3D)
+
+	heights, err = w.HeightMap(-2, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if heights[2][5] != 136 || heights[3][5] != -64 {
+		t.Errorf("expected saved heights 136 and -64: got %d and %d", heights[2][5], heights[3][5])
+	}
+
+	b, ok, err := w.SurfaceBlock(-30, 5, 0)
+	if err != nil || !ok {
+		t.Fatalf("expected a surface block: got %t, %v", ok, err)
+	}
+	if b.ID != BlockGrass || b.X != -30 || b.Y != 64 || b.Z != 5 {
+		t.Errorf("expected grass at -30 64 5: got %+v", b)
+	}
+
+	if b, ok, err := w.SurfaceBlock(-32, 0, 0); ok || err != nil {
+		t.Errorf("expected no surface block in an empty column: got %+v, %t, %v", b, ok, err)
+	}
+
+	if _, err := w.HeightMap(0, 0, 9); !errors.Is(err, ErrInvalidDimension) {
+		t.Errorf("expected ErrInvalidDimension: got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+// Code generated by gen_ids.go; DO NOT EDIT.
+
+package world
+
+// Vanilla biomes.
+const (
+	BiomeOcean                        Biome = 0
+	BiomePlains                       Biome = 1
+	BiomeDesert                       Biome = 2
+	BiomeExtremeHills                 Biome = 3
+	BiomeForest                       Biome = 4
+	BiomeTaiga                        Biome = 5
+	BiomeSwampland                    Biome = 6
+	BiomeRiver                        Biome = 7
+	BiomeHell                         Biome = 8
+	BiomeTheEnd                       Biome = 9
+	BiomeLegacyFrozenOcean            Biome = 10
+	BiomeFrozenRiver                  Biome = 11
+	BiomeIcePlains                    Biome = 12
+	BiomeIceMountains                 Biome = 13
+	BiomeMushroomIsland               Biome = 14
+	BiomeMushroomIslandShore          Biome = 15
+	BiomeBeach                        Biome = 16
+	BiomeDesertHills                  Biome = 17
+	BiomeForestHills                  Biome = 18
+	BiomeTaigaHills                   Biome = 19
+	BiomeExtremeHillsEdge             Biome = 20
+	BiomeJungle                       Biome = 21
+	BiomeJungleHills                  Biome = 22
+	BiomeJungleEdge                   Biome = 23
+	BiomeDeepOcean                    Biome = 24
+	BiomeStoneBeach                   Biome = 25
+	BiomeColdBeach                    Biome = 26
+	BiomeBirchForest                  Biome = 27
+	BiomeBirchForestHills             Biome = 28
+	BiomeRoofedForest                 Biome = 29
+	BiomeColdTaiga                    Biome = 30
+	BiomeColdTaigaHills               Biome = 31
+	BiomeMegaTaiga                    Biome = 32
+	BiomeMegaTaigaHills               Biome = 33
+	BiomeExtremeHillsPlusTrees        Biome = 34
+	BiomeSavanna                      Biome = 35
+	BiomeSavannaPlateau               Biome = 36
+	BiomeMesa                         Biome = 37
+	BiomeMesaPlateauStone             Biome = 38
+	BiomeMesaPlateau                  Biome = 39
+	BiomeWarmOcean                    Biome = 40
+	BiomeDeepWarmOcean                Biome = 41
+	BiomeLukewarmOcean                Biome = 42
+	BiomeDeepLukewarmOcean            Biome = 43
+	BiomeColdOcean                    Biome = 44
+	BiomeDeepColdOcean                Biome = 45
+	BiomeFrozenOcean                  Biome = 46
+	BiomeDeepFrozenOcean              Biome = 47
+	BiomeBambooJungle                 Biome = 48
+	BiomeBambooJungleHills            Biome = 49
+	BiomeSunflowerPlains              Biome = 129
+	BiomeDesertMutated                Biome = 130
+	BiomeExtremeHillsMutated          Biome = 131
+	BiomeFlowerForest                 Biome = 132
+	BiomeTaigaMutated                 Biome = 133
+	BiomeSwamplandMutated             Biome = 134
+	BiomeIcePlainsSpikes              Biome = 140
+	BiomeJungleMutated                Biome = 149
+	BiomeJungleEdgeMutated            Biome = 151
+	BiomeBirchForestMutated           Biome = 155
+	BiomeBirchForestHillsMutated      Biome = 156
+	BiomeRoofedForestMutated          Biome = 157
+	BiomeColdTaigaMutated             Biome = 158
+	BiomeRedwoodTaigaMutated          Biome = 160
+	BiomeRedwoodTaigaHillsMutated     Biome = 161
+	BiomeExtremeHillsPlusTreesMutated Biome = 162
+	BiomeSavannaMutated               Biome = 163
+	BiomeSavannaPlateauMutated        Biome = 164
+	BiomeMesaBryce                    Biome = 165
+	BiomeMesaPlateauStoneMutated      Biome = 166
+	BiomeMesaPlateauMutated           Biome = 167
+	BiomeSoulsandValley               Biome = 178
+	BiomeCrimsonForest                Biome = 179
+	BiomeWarpedForest                 Biome = 180
+	BiomeBasaltDeltas                 Biome = 181
+	BiomeJaggedPeaks                  Biome = 182
+	BiomeFrozenPeaks                  Biome = 183
+	BiomeSnowySlopes                  Biome = 184
+	BiomeGrove                        Biome = 185
+	BiomeMeadow                       Biome = 186
+	BiomeLushCaves                    Biome = 187
+	BiomeDripstoneCaves               Biome = 188
+	BiomeStonyPeaks                   Biome = 189
+	BiomeDeepDark                     Biome = 190
+	BiomeMangroveSwamp                Biome = 191
+	BiomeCherryGrove                  Biome = 192
+)
+
+// biomeNames are the Bedrock Edition biome IDs and names.
+var biomeNames = map[int]string{
+	0:   "ocean",
+	1:   "plains",
+	2:   "desert",
+	3:   "extreme_hills",
+	4:   "forest",
+	5:   "taiga",
+	6:   "swampland",
+	7:   "river",
+	8:   "hell",
+	9:   "the_end",
+	10:  "legacy_frozen_ocean",
+	11:  "frozen_river",
+	12:  "ice_plains",
+	13:  "ice_mountains",
+	14:  "mushroom_island",
+	15:  "mushroom_island_shore",
+	16:  "beach",
+	17:  "desert_hills",
+	18:  "forest_hills",
+	19:  "taiga_hills",
+	20:  "extreme_hills_edge",
+	21:  "jungle",
+	22:  "jungle_hills",
+	23:  "jungle_edge",
+	24:  "deep_ocean",
+	25:  "stone_beach",
+	26:  "cold_beach",
+	27:  "birch_forest",
+	28:  "birch_forest_hills",
+	29:  "roofed_forest",
+	30:  "cold_taiga",
+	31:  "cold_taiga_hills",
+	32:  "mega_taiga",
+	33:  "mega_taiga_hills",
+	34:  "extreme_hills_plus_trees",
+	35:  "savanna",
+	36:  "savanna_plateau",
+	37:  "mesa",
+	38:  "mesa_plateau_stone",
+	39:  "mesa_plateau",
+	40:  "warm_ocean",
+	41:  "deep_warm_ocean",
+	42:  "lukewarm_ocean",
+	43:  "deep_lukewarm_ocean",
+	44:  "cold_ocean",
+	45:  "deep_cold_ocean",
+	46:  "frozen_ocean",
+	47:  "deep_frozen_ocean",
+	48:  "bamboo_jungle",
+	49:  "bamboo_jungle_hills",
+	129: "sunflower_plains",
+	130: "desert_mutated",
+	131: "extreme_hills_mutated",
+	132: "flower_forest",
+	133: "taiga_mutated",
+	134: "swampland_mutated",
+	140: "ice_plains_spikes",
+	149: "jungle_mutated",
+	151: "jungle_edge_mutated",
+	155: "birch_forest_mutated",
+	156: "birch_forest_hills_mutated",
+	157: "roofed_forest_mutated",
+	158: "cold_taiga_mutated",
+	160: "redwood_taiga_mutated",
+	161: "redwood_taiga_hills_mutated",
+	162: "extreme_hills_plus_trees_mutated",
+	163: "savanna_mutated",
+	164: "savanna_plateau_mutated",
+	165: "mesa_bryce",
+	166: "mesa_plateau_stone_mutated",
+	167: "mesa_plateau_mutated",
+	178: "soulsand_valley",
+	179: "crimson_forest",
+	180: "warped_forest",
+	181: "basalt_deltas",
+	182: "jagged_peaks",
+	183: "frozen_peaks",
+	184: "snowy_slopes",
+	185: "grove",
+	186: "meadow",
+	187: "lush_caves",
+	188: "dripstone_caves",
+	189: "stony_peaks",
+	190: "deep_dark",
+	191: "mangrove_swamp",
+	192: "cherry_grove",
+}
+
+// Common vanilla block IDs.
+const (
+	BlockAir            = "minecraft:air"
+	BlockStone          = "minecraft:stone"
+	BlockGrass          = "minecraft:grass"
+	BlockDirt           = "minecraft:dirt"
+	BlockCobblestone    = "minecraft:cobblestone"
+	BlockBedrock        = "minecraft:bedrock"
+	BlockSand           = "minecraft:sand"
+	BlockGravel         = "minecraft:gravel"
+	BlockClay           = "minecraft:clay"
+	BlockSnow           = "minecraft:snow"
+	BlockIce            = "minecraft:ice"
+	BlockGlass          = "minecraft:glass"
+	BlockSandstone      = "minecraft:sandstone"
+	BlockDeepslate      = "minecraft:deepslate"
+	BlockNetherrack     = "minecraft:netherrack"
+	BlockSoulSand       = "minecraft:soul_sand"
+	BlockGlowstone      = "minecraft:glowstone"
+	BlockObsidian       = "minecraft:obsidian"
+	BlockEndStone       = "minecraft:end_stone"
+	BlockWater          = "minecraft:water"
+	BlockFlowingWater   = "minecraft:flowing_water"
+	BlockLava           = "minecraft:lava"
+	BlockFlowingLava    = "minecraft:flowing_lava"
+	BlockOakLog         = "minecraft:oak_log"
+	BlockOakPlanks      = "minecraft:oak_planks"
+	BlockOakLeaves      = "minecraft:oak_leaves"
+	BlockCoalOre        = "minecraft:coal_ore"
+	BlockIronOre        = "minecraft:iron_ore"
+	BlockCopperOre      = "minecraft:copper_ore"
+	BlockGoldOre        = "minecraft:gold_ore"
+	BlockRedstoneOre    = "minecraft:redstone_ore"
+	BlockLapisOre       = "minecraft:lapis_ore"
+	BlockDiamondOre     = "minecraft:diamond_ore"
+	BlockEmeraldOre     = "minecraft:emerald_ore"
+	BlockAncientDebris  = "minecraft:ancient_debris"
+	BlockIronBlock      = "minecraft:iron_block"
+	BlockGoldBlock      = "minecraft:gold_block"
+	BlockDiamondBlock   = "minecraft:diamond_block"
+	BlockEmeraldBlock   = "minecraft:emerald_block"
+	BlockNetheriteBlock = "minecraft:netherite_block"
+	BlockPortal         = "minecraft:portal"
+	BlockEndPortal      = "minecraft:end_portal"
+	BlockEndPortalFrame = "minecraft:end_portal_frame"
+	BlockChest          = "minecraft:chest"
+	BlockBarrel         = "minecraft:barrel"
+	BlockCraftingTable  = "minecraft:crafting_table"
+	BlockFurnace        = "minecraft:furnace"
+	BlockBookshelf      = "minecraft:bookshelf"
+	BlockBed            = "minecraft:bed"
+	BlockTorch          = "minecraft:torch"
+	BlockTnt            = "minecraft:tnt"
+	BlockMobSpawner     = "minecraft:mob_spawner"
+)
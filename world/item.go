@@ -0,0 +1,215 @@
+package world
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// Item is an item stack held in an inventory, container or entity equipment slot.
+type Item struct {
+	Name         string // The item ID e.g. minecraft:diamond_sword
+	Count        int
+	Damage       int // The auxiliary value, which selects the variant of some items such as potions
+	Slot         int // The inventory slot, for items in slotted containers
+	CustomName   string
+	Durability   int // The damage taken by a tool or armor item
+	Enchantments []Enchantment
+	NBT          nbt.NBTTag // The full compound tag for the item
+}
+
+// Enchantment is an enchantment on an item.
+type Enchantment struct {
+	ID    int
+	Name  string
+	Level int
+}
+
+func (e Enchantment) String() string {
+	return e.Name + " " + romanNumeral(e.Level)
+}
+
+// Effect is a status effect active on an entity.
+type Effect struct {
+	ID        int
+	Name      string
+	Amplifier int // The effect level minus one
+	Duration  int // The remaining duration in ticks
+	Ambient   bool
+}
+
+func (e Effect) String() string {
+	seconds := e.Duration / 20
+	return fmt.Sprintf("%s %s (%d:%02d)", e.Name, romanNumeral(e.Amplifier+1), seconds/60, seconds%60)
+}
+
+// enchantmentNames are the names of enchantments by their numeric Bedrock Edition ID.
+var enchantmentNames = []string{
+	"Protection", "Fire Protection", "Feather Falling", "Blast Protection", "Projectile Protection", "Thorns",
+	"Respiration", "Depth Strider", "Aqua Affinity", "Sharpness", "Smite", "Bane of Arthropods", "Knockback",
+	"Fire Aspect", "Looting", "Efficiency", "Silk Touch", "Unbreaking", "Fortune", "Power", "Punch", "Flame",
+	"Infinity", "Luck of the Sea", "Lure", "Frost Walker", "Mending", "Curse of Binding", "Curse of Vanishing",
+	"Impaling", "Riptide", "Loyalty", "Channeling", "Multishot", "Piercing", "Quick Charge", "Soul Speed",
+	"Swift Sneak",
+}
+
+// effectNames are the names of status effects by their numeric Bedrock Edition ID, which starts at 1.
+var effectNames = []string{
+	"", "Speed", "Slowness", "Haste", "Mining Fatigue", "Strength", "Instant Health", "Instant Damage",
+	"Jump Boost", "Nausea", "Regeneration", "Resistance", "Fire Resistance", "Water Breathing", "Invisibility",
+	"Blindness", "Night Vision", "Hunger", "Weakness", "Poison", "Wither", "Health Boost", "Absorption",
+	"Saturation", "Levitation", "Fatal Poison", "Conduit Power", "Slow Falling", "Bad Omen", "Hero of the Village",
+	"Darkness",
+}
+
+// potionNames are the names of potions by the auxiliary value of potion, splash potion, lingering potion and tipped
+// arrow items. Tipped arrows are offset by one, as arrow 0 is a plain arrow.
+var potionNames = []string{
+	"Water Bottle", "Mundane Potion", "Long Mundane Potion", "Thick Potion", "Awkward Potion",
+	"Potion of Night Vision", "Potion of Night Vision (long)", "Potion of Invisibility",
+	"Potion of Invisibility (long)", "Potion of Leaping", "Potion of Leaping (long)", "Potion of Leaping II",
+	"Potion of Fire Resistance", "Potion of Fire Resistance (long)", "Potion of Swiftness",
+	"Potion of Swiftness (long)", "Potion of Swiftness II", "Potion of Slowness", "Potion of Slowness (long)",
+	"Potion of Water Breathing", "Potion of Water Breathing (long)", "Potion of Healing", "Potion of Healing II",
+	"Potion of Harming", "Potion of Harming II", "Potion of Poison", "Potion of Poison (long)", "Potion of Poison II",
+	"Potion of Regeneration", "Potion of Regeneration (long)", "Potion of Regeneration II", "Potion of Strength",
+	"Potion of Strength (long)", "Potion of Strength II", "Potion of Weakness", "Potion of Weakness (long)",
+	"Potion of Decay", "Potion of the Turtle Master", "Potion of the Turtle Master (long)",
+	"Potion of the Turtle Master II", "Potion of Slow Falling", "Potion of Slow Falling (long)",
+	"Potion of Slowness IV",
+}
+
+// entityItemLists are the names of the list tags holding an entity's items.
+var entityItemLists = []string{"Mainhand", "Offhand", "Armor", "Inventory", "ChestItems", "EnderChestInventory"}
+
+// ParseItem returns the item held in an item compound tag.
+func ParseItem(t nbt.NBTTag) Item {
+	i := Item{
+		Count:  int(childInt(t, "Count")),
+		Damage: int(childInt(t, "Damage")),
+		Slot:   int(childInt(t, "Slot")),
+		NBT:    t,
+	}
+
+	if n, ok := t.Child("Name"); ok {
+		i.Name, _ = n.StringValue()
+	}
+
+	tag, ok := t.Child("tag")
+	if !ok {
+		return i
+	}
+
+	i.Durability = int(childInt(tag, "Damage"))
+
+	if n, ok := tag.Path("display", "Name"); ok {
+		i.CustomName, _ = n.StringValue()
+	}
+
+	if ench, ok := tag.Child("ench"); ok {
+		for _, e := range ench.List() {
+			id := int(childInt(e, "id"))
+			i.Enchantments = append(i.Enchantments, Enchantment{
+				ID:    id,
+				Name:  tableName(enchantmentNames, id, "Enchantment"),
+				Level: int(childInt(e, "lvl")),
+			})
+		}
+	}
+
+	return i
+}
+
+// Potion returns the name of the potion held by a potion item or tipped arrow.
+func (i Item) Potion() (string, bool) {
+	switch strings.TrimPrefix(i.Name, "minecraft:") {
+	case "potion", "splash_potion", "lingering_potion":
+		return tableName(potionNames, i.Damage, "Potion"), true
+	case "arrow":
+		if i.Damage == 0 {
+			return "", false
+		}
+		return tableName(potionNames, i.Damage-1, "Potion"), true
+	}
+
+	return "", false
+}
+
+// Items returns the items held, worn or carried by the entity. Empty slots are not returned.
+func (e Entity) Items() []Item {
+	items := make([]Item, 0)
+
+	for _, name := range entityItemLists {
+		list, ok := e.NBT.Child(name)
+		if !ok {
+			continue
+		}
+
+		for _, t := range list.List() {
+			if i := ParseItem(t); i.Name != "" && i.Count > 0 {
+				items = append(items, i)
+			}
+		}
+	}
+
+	return items
+}
+
+// Effects returns the status effects active on the entity.
+func (e Entity) Effects() []Effect {
+	list, ok := e.NBT.Child("ActiveEffects")
+	if !ok {
+		return nil
+	}
+
+	effects := make([]Effect, 0)
+
+	for _, t := range list.List() {
+		id := int(childInt(t, "Id"))
+		effects = append(effects, Effect{
+			ID:        id,
+			Name:      tableName(effectNames, id, "Effect"),
+			Amplifier: int(childInt(t, "Amplifier")),
+			Duration:  int(childInt(t, "Duration")),
+			Ambient:   childInt(t, "Ambient") != 0,
+		})
+	}
+
+	return effects
+}
+
+// tableName returns the name at index i of a table of names, or the kind and number if there is none.
+func tableName(table []string, i int, kind string) string {
+	if i >= 0 && i < len(table) && table[i] != "" {
+		return table[i]
+	}
+
+	return fmt.Sprintf("%s %d", kind, i)
+}
+
+// romanNumeral returns n as a Roman numeral, as used for enchantment and effect levels. Numbers outside 1-3999 are
+// returned as decimal.
+func romanNumeral(n int) string {
+	if n < 1 || n > 3999 {
+		return fmt.Sprint(n)
+	}
+
+	numerals := []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+		{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	}
+
+	b := &strings.Builder{}
+	for _, r := range numerals {
+		for n >= r.value {
+			b.WriteString(r.symbol)
+			n -= r.value
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestEntityItemsAndEffects(t *testing.T) {
+	short := func(name string, v int) nbt.NBTTag { return nbt.NBTTag{Type: nbt.TagShort, Name: name, Value: v} }
+	byteTag := func(name string, v int) nbt.NBTTag { return nbt.NBTTag{Type: nbt.TagByte, Name: name, Value: v} }
+	str := func(name, v string) nbt.NBTTag { return nbt.NBTTag{Type: nbt.TagString, Name: name, Value: v} }
+
+	sword := testCompound("",
+		str("Name", "minecraft:diamond_sword"), byteTag("Count", 1), short("Damage", 0),
+		testCompound("tag",
+			nbt.NBTTag{Type: nbt.TagInt, Name: "Damage", Value: 12},
+			testCompound("display", str("Name", "Stabby")),
+			testList("ench", nbt.TagCompound,
+				testCompound("", short("id", 9), short("lvl", 5)),
+				testCompound("", short("id", 99), short("lvl", 1)),
+			),
+		),
+	)
+	empty := testCompound("", str("Name", ""), byteTag("Count", 0))
+	potion := testCompound("", str("Name", "minecraft:splash_potion"), byteTag("Count", 1), short("Damage", 22))
+
+	e := testEntity("minecraft:zombie", 1, 0, 64, 0)
+	_ = e.SetChild(testList("Mainhand", nbt.TagCompound, sword))
+	_ = e.SetChild(testList("Offhand", nbt.TagCompound, empty))
+	_ = e.SetChild(testList("Armor", nbt.TagCompound, potion))
+	_ = e.SetChild(testList("ActiveEffects", nbt.TagCompound, testCompound("",
+		byteTag("Id", 1), byteTag("Amplifier", 1), nbt.NBTTag{Type: nbt.TagInt, Name: "Duration", Value: 1800},
+		byteTag("Ambient", 0),
+	)))
+
+	// Round trip through the encoder so values have the types produced by decoding a world
+	data, err := nbt.Encode([]nbt.NBTTag{e})
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+	tags, err := nbt.Decode(data)
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	entity := parseEntity(tags[0])
+
+	items := entity.Items()
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items: got %+v", items)
+	}
+
+	s := items[0]
+	if s.Name != "minecraft:diamond_sword" || s.CustomName != "Stabby" || s.Durability != 12 {
+		t.Errorf("unexpected sword %+v", s)
+	}
+
+	wantEnchantments := []Enchantment{{ID: 9, Name: "Sharpness", Level: 5}, {ID: 99, Name: "Enchantment 99", Level: 1}}
+	if !reflect.DeepEqual(s.Enchantments, wantEnchantments) {
+		t.Errorf("expected enchantments %+v: got %+v", wantEnchantments, s.Enchantments)
+	}
+
+	if got := s.Enchantments[0].String(); got != "Sharpness V" {
+		t.Errorf("expected Sharpness V: got %s", got)
+	}
+
+	if name, ok := items[1].Potion(); !ok || name != "Potion of Healing II" {
+		t.Errorf("expected Potion of Healing II: got %q", name)
+	}
+
+	effects := entity.Effects()
+	if len(effects) != 1 || effects[0].String() != "Speed II (1:30)" {
+		t.Errorf("expected Speed II (1:30): got %+v", effects)
+	}
+}
@@ -0,0 +1,145 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// BlockIterator streams the saved blocks in a region, parsing each sub chunk once. Call Next before each block:
+//
+//	it, err := w.GetBlocks(region)
+//	for it.Next() {
+//		b := it.Block()
+//	}
+//	err = it.Err()
+type BlockIterator struct {
+	w         *World
+	dimension int
+
+	// The sub chunks left to visit, in chunk coordinates with the sub chunk index as Y
+	subChunks [][3]int
+
+	// The current sub chunk and its origin, the corners of the region in world coordinates and the position of the
+	// next block relative to the origin
+	current *subChunkData
+	origin  [3]int
+	min     [3]int
+	max     [3]int
+	next    [3]int
+
+	block Block
+	err   error
+}
+
+// GetBlocks returns an iterator over the saved blocks in the region. Blocks are visited one sub chunk at a time,
+// sub chunks in x, z then y order and blocks in each sub chunk in the same order. Positions in sub chunks which are not
+// saved are skipped.
+func (w *World) GetBlocks(region Selection) (*BlockIterator, error) {
+	r, ok := subChunkRanges[region.Dimension]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrInvalidDimension, region.Dimension)
+	}
+
+	it := &BlockIterator{w: w, dimension: region.Dimension}
+
+	minY := maxInt(floorDiv(region.Min[1], chunkSize), int(r[0]))
+	maxY := minInt(floorDiv(region.Max[1], chunkSize), int(r[1]))
+
+	for cx := floorDiv(region.Min[0], chunkSize); cx <= floorDiv(region.Max[0], chunkSize); cx++ {
+		for cz := floorDiv(region.Min[2], chunkSize); cz <= floorDiv(region.Max[2], chunkSize); cz++ {
+			for sy := minY; sy <= maxY; sy++ {
+				it.subChunks = append(it.subChunks, [3]int{cx, sy, cz})
+			}
+		}
+	}
+
+	it.min, it.max = region.Min, region.Max
+
+	return it, nil
+}
+
+// Next advances to the next block, returning false when there are no more blocks or an error stopped the iteration.
+func (it *BlockIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for it.current == nil || it.next[0] > minInt(it.max[0]-it.origin[0], chunkSize-1) {
+		if !it.nextSubChunk() {
+			return false
+		}
+	}
+
+	x, y, z := it.next[0], it.next[1], it.next[2]
+	it.block = it.current.block(subChunkVoxelToIndex(x, y, z), it.origin[0]+x, it.origin[1]+y, it.origin[2]+z)
+
+	// Advance y, then z, then x within the part of the sub chunk in the region
+	lo, hi := it.localBounds()
+	if it.next[1]++; it.next[1] > hi[1] {
+		it.next[1] = lo[1]
+		if it.next[2]++; it.next[2] > hi[2] {
+			it.next[2] = lo[2]
+			it.next[0]++
+		}
+	}
+
+	return true
+}
+
+// nextSubChunk reads the next saved sub chunk, returning false if there are none left or an error occurred.
+func (it *BlockIterator) nextSubChunk() bool {
+	for len(it.subChunks) > 0 {
+		c := it.subChunks[0]
+		it.subChunks = it.subChunks[1:]
+
+		k := leveldb.ChunkKey{X: int32(c[0]), Z: int32(c[2]), Dimension: int32(it.dimension),
+			Tag: leveldb.SubChunkPrefix, SubChunkY: int8(c[1])}
+
+		value, err := it.w.db.Get(k.Bytes())
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			it.err = fmt.Errorf("getting sub chunk with key '%x': %w", k.Bytes(), err)
+			return false
+		}
+
+		s, err := parseSubChunk(value)
+		if err != nil {
+			it.err = fmt.Errorf("parsing sub chunk with key '%x': %w", k.Bytes(), err)
+			return false
+		}
+
+		it.current = s
+		it.origin = [3]int{c[0] * chunkSize, c[1] * chunkSize, c[2] * chunkSize}
+		it.next, _ = it.localBounds()
+
+		return true
+	}
+
+	it.current = nil
+
+	return false
+}
+
+// localBounds returns the corners of the part of the current sub chunk in the region, relative to its origin.
+func (it *BlockIterator) localBounds() (lo, hi [3]int) {
+	for i := 0; i < 3; i++ {
+		lo[i] = maxInt(it.min[i]-it.origin[i], 0)
+		hi[i] = minInt(it.max[i]-it.origin[i], chunkSize-1)
+	}
+
+	return lo, hi
+}
+
+// Block returns the block Next advanced to.
+func (it *BlockIterator) Block() Block {
+	return it.block
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *BlockIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,62 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestGetBlocks(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	// Two saved sub chunks either side of x=0, the sub chunk at 1 0 -1 is not saved
+	_ = db.Put(leveldb.ChunkKey{X: -1, Z: -1, Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{15, 0, 15}: BlockStone, {14, 1, 15}: BlockDirt}))
+	_ = db.Put(leveldb.ChunkKey{X: 0, Z: -1, Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{0, 0, 15}: BlockGlass}))
+
+	it, err := w.GetBlocks(NewSelection(-2, 0, -1, 17, 1, -1, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := make(map[[3]int]string)
+	for it.Next() {
+		b := it.Block()
+		p := [3]int{b.X, b.Y, b.Z}
+		if _, ok := got[p]; ok {
+			t.Errorf("block at %v visited twice", p)
+		}
+		got[p] = b.ID
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// 2 blocks along x in chunk -1 and 16 in chunk 0, 2 high
+	if len(got) != 36 {
+		t.Errorf("expected 36 blocks: got %d", len(got))
+	}
+
+	for p, want := range map[[3]int]string{
+		{-1, 0, -1}: BlockStone,
+		{-2, 1, -1}: BlockDirt,
+		{0, 0, -1}:  BlockGlass,
+		{15, 1, -1}: BlockAir,
+	} {
+		if got[p] != want {
+			t.Errorf("expected %s at %v: got %q", want, p, got[p])
+		}
+	}
+
+	if _, ok := got[[3]int{16, 0, -1}]; ok {
+		t.Error("expected blocks in unsaved sub chunks to be skipped")
+	}
+
+	if _, err := w.GetBlocks(NewSelection(0, 0, 0, 1, 1, 1, 7)); err == nil {
+		t.Error("expected an error for an unknown dimension")
+	}
+}
@@ -0,0 +1,220 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// LagScore estimates how much work a chunk gives the game each tick from what is saved in it.
+type LagScore struct {
+	ChunkPos
+	Entities      int // Entities positioned in the chunk
+	BlockEntities int // Block entities such as hoppers and furnaces, which are ticked while the chunk is loaded
+	Redstone      int // Redstone components, which cause block updates when they change
+	PendingTicks  int // Block updates scheduled for a later tick when the chunk was saved
+	Score         float64
+}
+
+// The weight of each count in a lag score, roughly the relative cost per tick of one of each.
+const (
+	entityLagWeight      = 1
+	blockEntityLagWeight = 0.5
+	hopperLagWeight      = 2 // Hoppers search for items every tick, so cost much more than other block entities
+	redstoneLagWeight    = 0.25
+	pendingTickLagWeight = 0.25
+)
+
+// redstoneComponents are substrings of the IDs of blocks which are redstone components.
+var redstoneComponents = []string{
+	"redstone_wire", "redstone_torch", "repeater", "comparator", "observer", "piston", "lever", "button",
+	"pressure_plate", "redstone_lamp", "daylight_detector", "tripwire", "dispenser", "dropper", "hopper", "target",
+	"sculk_sensor", "redstone_block",
+}
+
+// isRedstoneComponent returns true if the block ID is a redstone component.
+func isRedstoneComponent(id string) bool {
+	for _, c := range redstoneComponents {
+		if strings.Contains(id, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LagScores returns the lag score of every chunk with any entities, block entities, redstone components or pending
+// ticks, highest score first. Scores are estimates for finding problem areas and are only comparable to each other.
+func (w *World) LagScores() ([]LagScore, error) {
+	scores := make(map[ChunkPos]*LagScore)
+
+	score := func(c ChunkPos) *LagScore {
+		if scores[c] == nil {
+			scores[c] = &LagScore{ChunkPos: c}
+		}
+		return scores[c]
+	}
+
+	b := w.startBudget()
+
+	err := w.scanEntities(b, func(e Entity) error {
+		c := ChunkPos{
+			X:         floorDiv(int(math.Floor(e.X)), chunkSize),
+			Z:         floorDiv(int(math.Floor(e.Z)), chunkSize),
+			Dimension: e.Dimension,
+		}
+		score(c).Entities++
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Block entities are only counted, so their values are reused for each record
+	d := nbt.NewDecoder()
+
+	err = w.eachKey(b, func(key []byte) error {
+		k, ok := leveldb.ParseChunkKey(key)
+		if !ok {
+			return nil
+		}
+
+		c := ChunkPos{X: int(k.X), Z: int(k.Z), Dimension: int(k.Dimension)}
+
+		switch k.Tag {
+		case leveldb.BlockEntity:
+			value, err := w.db.Get(key)
+			if err != nil {
+				return fmt.Errorf("getting block entities with key '%x': %w", key, err)
+			}
+
+			entities, err := parseBlockEntities(d, value)
+			if err != nil {
+				return fmt.Errorf("parsing block entities with key '%x': %w", key, err)
+			}
+
+			s := score(c)
+			for _, e := range entities {
+				s.BlockEntities++
+				if e.ID == "Hopper" {
+					s.Score += hopperLagWeight
+				} else {
+					s.Score += blockEntityLagWeight
+				}
+			}
+
+			d.Release()
+
+		case leveldb.PendingTicks:
+			n, err := w.pendingTicks(key)
+			if err != nil {
+				return err
+			}
+			score(c).PendingTicks += n
+
+		case leveldb.SubChunkPrefix:
+			n, err := w.redstoneComponents(key)
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				score(c).Redstone += n
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]LagScore, 0, len(scores))
+	for _, s := range scores {
+		s.Score += float64(s.Entities)*entityLagWeight + float64(s.Redstone)*redstoneLagWeight +
+			float64(s.PendingTicks)*pendingTickLagWeight
+		list = append(list, *s)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		return a.Z < b.Z
+	})
+
+	return list, nil
+}
+
+// pendingTicks returns the number of scheduled block updates in the PendingTicks record with the given key.
+func (w *World) pendingTicks(key []byte) (int, error) {
+	value, err := w.db.Get(key)
+	if err != nil {
+		return 0, fmt.Errorf("getting pending ticks with key '%x': %w", key, err)
+	}
+
+	tags, err := nbt.Decode(value)
+	if err != nil {
+		return 0, fmt.Errorf("parsing pending ticks with key '%x': %w", key, err)
+	}
+
+	n := 0
+	for _, t := range tags {
+		if list, ok := t.Child("tickList"); ok {
+			n += len(list.List())
+		}
+	}
+
+	return n, nil
+}
+
+// redstoneComponents returns the number of redstone components in the sub chunk with the given key. Only the palette
+// is read unless it has a redstone component.
+func (w *World) redstoneComponents(key []byte) (int, error) {
+	value, err := w.db.Get(key)
+	if err != nil {
+		return 0, fmt.Errorf("getting sub chunk with key '%x': %w", key, err)
+	}
+
+	palette, err := parseSubChunkPalette(value)
+	if err != nil {
+		return 0, fmt.Errorf("parsing sub chunk with key '%x': %w", key, err)
+	}
+
+	found := false
+	for _, p := range palette {
+		if isRedstoneComponent(p.BlockID()) {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return 0, nil
+	}
+
+	s, err := parseSubChunk(value)
+	if err != nil {
+		return 0, fmt.Errorf("parsing sub chunk with key '%x': %w", key, err)
+	}
+
+	n := 0
+	for id, count := range s.Blocks.counts() {
+		if isRedstoneComponent(id) {
+			n += count
+		}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,66 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestLagScores(t *testing.T) {
+	w, db := testEntityWorld(t)
+
+	// Chunk 0 0 has the cow and pig, a hopper and a chest
+	blockEntities, err := nbt.Encode([]nbt.NBTTag{
+		testBlockEntity("Hopper", 1, 64, 1),
+		testBlockEntity("Chest", 2, 64, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.BlockEntity}.Bytes(), blockEntities)
+
+	// Chunk 2 -3 has 3 pending ticks
+	ticks := nbt.NBTTag{Type: nbt.TagList, Name: "tickList", Value: map[string]interface{}{
+		"tagListType": float64(nbt.TagCompound), "list": []interface{}{}}}
+	tick := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	if err := ticks.SetList([]nbt.NBTTag{tick, tick, tick}); err != nil {
+		t.Fatal(err)
+	}
+	root := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	_ = root.SetChild(ticks)
+
+	pending, err := nbt.Encode([]nbt.NBTTag{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Put(leveldb.ChunkKey{X: 2, Z: -3, Tag: leveldb.PendingTicks}.Bytes(), pending)
+
+	// Chunk 5 5 has 2 redstone components, and chunk 6 6 has none
+	_ = db.Put(leveldb.ChunkKey{X: 5, Z: 5, Tag: leveldb.SubChunkPrefix, SubChunkY: 4}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:redstone_wire", {1, 0, 0}: "minecraft:repeater"}))
+	_ = db.Put(leveldb.ChunkKey{X: 6, Z: 6, Tag: leveldb.SubChunkPrefix, SubChunkY: 4}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: BlockStone}))
+
+	scores, err := w.LagScores()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []LagScore{
+		{ChunkPos: ChunkPos{X: 0, Z: 0}, Entities: 2, BlockEntities: 2, Score: 2 + hopperLagWeight + blockEntityLagWeight},
+		{ChunkPos: ChunkPos{X: 100, Z: 100}, Entities: 1, Score: 1},
+		{ChunkPos: ChunkPos{X: 2, Z: -3}, PendingTicks: 3, Score: 3 * pendingTickLagWeight},
+		{ChunkPos: ChunkPos{X: 5, Z: 5}, Redstone: 2, Score: 2 * redstoneLagWeight},
+	}
+
+	if len(scores) != len(want) {
+		t.Fatalf("expected %d scores: got %d: %+v", len(want), len(scores), scores)
+	}
+
+	for i := range want {
+		if scores[i] != want[i] {
+			t.Errorf("score %d: expected %+v: got %+v", i, want[i], scores[i])
+		}
+	}
+}
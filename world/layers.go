@@ -0,0 +1,116 @@
+package world
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// StorageLayer is one of the block storages of a sub chunk. Layer 0 holds the blocks. Layer 1, if there is one, marks
+// water logged blocks with water, see WaterLogged. Further layers are written by some add-ons and are not interpreted
+// by the package.
+type StorageLayer struct {
+	Palette []nbt.NBTTag // A palette of block types and states
+	Indices []int        // An index into the palette for each position, in the order of SubChunkIndex
+}
+
+// SubChunkIndex returns the index in a storage layer's Indices of the given coordinates within a sub chunk. If a
+// coordinate is outside 0 to 15 an *OutOfBoundsError is returned.
+func SubChunkIndex(x, y, z int) (int, error) {
+	if err := outOfChunk("sub chunk", x, y, z); err != nil {
+		return 0, err
+	}
+
+	return subChunkVoxelToIndex(x, y, z), nil
+}
+
+// At returns the palette entry at the given coordinates within the sub chunk. An error is returned if a coordinate is
+// outside 0 to 15 or the layer has no valid palette index for the position.
+func (l StorageLayer) At(x, y, z int) (nbt.NBTTag, error) {
+	i, err := SubChunkIndex(x, y, z)
+	if err != nil {
+		return nbt.NBTTag{}, err
+	}
+
+	if i >= len(l.Indices) {
+		return nbt.NBTTag{}, fmt.Errorf("layer has %d indices, not %d", len(l.Indices), subChunkBlockCount)
+	}
+	if p := l.Indices[i]; p < 0 || p >= len(l.Palette) {
+		return nbt.NBTTag{}, fmt.Errorf("index %d is %d, out of range of a palette with length %d",
+			i, p, len(l.Palette))
+	}
+
+	return l.Palette[l.Indices[i]], nil
+}
+
+// WaterLogged returns true if the layers mark the block at the given coordinates within the sub chunk as water logged,
+// with water at the position in layer 1. An error is returned as by StorageLayer.At.
+func WaterLogged(layers []StorageLayer, x, y, z int) (bool, error) {
+	if len(layers) < 2 {
+		return false, outOfChunk("sub chunk", x, y, z)
+	}
+
+	entry, err := layers[1].At(x, y, z)
+	if err != nil {
+		return false, err
+	}
+
+	return entry.BlockID() == BlockWater, nil
+}
+
+// Layers returns a copy of every block storage layer of the sub chunk, which may be modified and written back with
+// SetLayers.
+func (s *SubChunk) Layers() []StorageLayer {
+	storages := append([]blockStorage{s.data.Blocks}, s.data.Extra...)
+
+	layers := make([]StorageLayer, len(storages))
+	for i, storage := range storages {
+		c := copyStorage(storage)
+		layers[i] = StorageLayer{Palette: c.Palette, Indices: c.Indices}
+	}
+
+	return layers
+}
+
+// SetLayers replaces the block storage layers of the sub chunk. There must be at least one layer, and every layer
+// must have an index for each position which is in range of its palette.
+func (s *SubChunk) SetLayers(layers []StorageLayer) error {
+	if len(layers) == 0 {
+		return fmt.Errorf("a sub chunk must have at least one storage layer")
+	}
+	if len(layers) > 127 {
+		return fmt.Errorf("a sub chunk may have at most 127 storage layers: got %d", len(layers))
+	}
+
+	storages := make([]blockStorage, len(layers))
+	for i, l := range layers {
+		if len(l.Indices) != subChunkBlockCount {
+			return fmt.Errorf("layer %d has %d indices, not %d", i, len(l.Indices), subChunkBlockCount)
+		}
+		if len(l.Palette) == 0 {
+			return fmt.Errorf("layer %d has no palette", i)
+		}
+		for j, p := range l.Indices {
+			if p < 0 || p >= len(l.Palette) {
+				return fmt.Errorf("layer %d index %d is %d, out of range of a palette with length %d",
+					i, j, p, len(l.Palette))
+			}
+		}
+
+		storages[i] = copyStorage(blockStorage{Palette: l.Palette, Indices: l.Indices})
+		storages[i].BitsPerBlock = minimalBitsPerBlock(len(l.Palette))
+	}
+
+	s.data.Blocks = storages[0]
+	s.data.Extra = storages[1:]
+	if len(s.data.Extra) == 0 {
+		s.data.Extra = nil
+	}
+
+	// Version 1 sub chunks have only one storage
+	if s.data.Version == 1 && len(layers) > 1 {
+		s.data.Version = 8
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestLayers(t *testing.T) {
+	w := NewFromDB(mock.NewLevelDB())
+
+	s := NewSubChunk(0, 4, 0, 0, BlockAir)
+	if err := s.SetAt(1, 2, 3, Block{ID: "minecraft:seagrass", waterLogged: true}); err != nil {
+		t.Fatalf("unexpected error setting a block: %s", err)
+	}
+
+	layers := s.Layers()
+	if len(layers) != 2 {
+		t.Fatalf("expected 2 layers: got %d", len(layers))
+	}
+	if e, err := layers[0].At(1, 2, 3); err != nil || e.BlockID() != "minecraft:seagrass" {
+		t.Errorf("expected seagrass in layer 0: got %s, %v", e.BlockID(), err)
+	}
+	seagrass, _ := WaterLogged(layers, 1, 2, 3)
+	air, _ := WaterLogged(layers, 0, 0, 0)
+	if !seagrass || air {
+		t.Error("expected only the seagrass to be water logged")
+	}
+
+	// Layers are copies
+	layers[0].Indices[0] = 1
+	if b, _ := s.At(0, 0, 0); b.ID != BlockAir {
+		t.Errorf("expected modifying a layer to leave the sub chunk unchanged: got %s", b.ID)
+	}
+
+	// An add-on layer is written and read back
+	extra := StorageLayer{Palette: []nbt.NBTTag{paletteEntry(BlockAir), paletteEntry("addon:glow")},
+		Indices: make([]int, subChunkBlockCount)}
+	i, _ := SubChunkIndex(5, 5, 5)
+	extra.Indices[i] = 1
+	if err := s.SetLayers(append(layers, extra)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := w.SetSubChunk(s); err != nil {
+		t.Fatalf("unexpected error writing the sub chunk: %s", err)
+	}
+
+	read, err := w.SubChunk(0, 4, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers = read.Layers()
+	if len(layers) != 3 {
+		t.Fatalf("expected 3 layers: got %d", len(layers))
+	}
+	if e, _ := layers[2].At(5, 5, 5); e.BlockID() != "addon:glow" {
+		t.Errorf("expected the add-on block in layer 2: got %s", e.BlockID())
+	}
+	if b, _ := read.At(1, 2, 3); !b.waterLogged {
+		t.Error("expected the seagrass to still be water logged")
+	}
+
+	for name, invalid := range map[string][]StorageLayer{
+		"no layers":      nil,
+		"short indices":  {{Palette: extra.Palette, Indices: make([]int, 10)}},
+		"no palette":     {{Indices: make([]int, subChunkBlockCount)}},
+		"index too high": {{Palette: extra.Palette[:1], Indices: extra.Indices}},
+	} {
+		if err := s.SetLayers(invalid); err == nil {
+			t.Errorf("expected an error setting layers with %s", name)
+		}
+	}
+
+	short := StorageLayer{Palette: extra.Palette, Indices: make([]int, 10)}
+	if _, err := short.At(15, 15, 15); err == nil {
+		t.Error("expected an error getting a block from a layer with too few indices")
+	}
+}
@@ -0,0 +1,351 @@
+package world
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+const levelDatFileName = "level.dat"
+
+// levelDatHeaderSize is the length of the storage version and NBT length header at the start of level.dat.
+const levelDatHeaderSize = 8
+
+// levelDat reads the root compound tag from the world's level.dat file.
+func (w *World) levelDat() (nbt.NBTTag, error) {
+	data, err := ioutil.ReadFile(filepath.Join(w.path, levelDatFileName))
+	if err != nil {
+		return nbt.NBTTag{}, fmt.Errorf("reading %s: %w", levelDatFileName, err)
+	}
+
+	_, root, err := parseLevelDat(data)
+
+	return root, err
+}
+
+// parseLevelDat returns the storage version from the header of a level.dat file and its root compound tag.
+func parseLevelDat(data []byte) (int, nbt.NBTTag, error) {
+	if len(data) < levelDatHeaderSize {
+		return 0, nbt.NBTTag{}, fmt.Errorf("%s is %d bytes long: too short for header", levelDatFileName, len(data))
+	}
+
+	length := int(binary.LittleEndian.Uint32(data[4:8]))
+	if length != len(data)-levelDatHeaderSize {
+		return 0, nbt.NBTTag{}, fmt.Errorf("%s header gives NBT length %d: found %d bytes",
+			levelDatFileName, length, len(data)-levelDatHeaderSize)
+	}
+
+	tags, err := nbt.Decode(data[levelDatHeaderSize:])
+	if err != nil {
+		return 0, nbt.NBTTag{}, fmt.Errorf("decoding %s: %w", levelDatFileName, err)
+	}
+
+	if len(tags) != 1 || tags[0].Type != nbt.TagCompound {
+		return 0, nbt.NBTTag{}, fmt.Errorf("%s does not contain a single compound tag", levelDatFileName)
+	}
+
+	return int(binary.LittleEndian.Uint32(data[0:4])), tags[0], nil
+}
+
+// loadLevelDat reads level.dat into the world's metadata. A world directory without a level.dat has no metadata.
+func (w *World) loadLevelDat() error {
+	data, err := ioutil.ReadFile(filepath.Join(w.path, levelDatFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", levelDatFileName, err)
+	}
+
+	version, root, err := parseLevelDat(data)
+	if err != nil {
+		return err
+	}
+
+	w.level = &levelMetadata{storageVersion: version, root: root}
+
+	return nil
+}
+
+// levelMetadata is the content of level.dat as read when the world was opened or last written.
+type levelMetadata struct {
+	storageVersion int
+	root           nbt.NBTTag
+}
+
+// levelTag returns the tag with the given name from the level.dat metadata read when the world was opened.
+func (w *World) levelTag(name string) (nbt.NBTTag, error) {
+	if w.level == nil {
+		return nbt.NBTTag{}, fmt.Errorf("world has no %s", levelDatFileName)
+	}
+
+	t, ok := w.level.root.Child(name)
+	if !ok {
+		return nbt.NBTTag{}, fmt.Errorf("%s has no %s tag", levelDatFileName, name)
+	}
+
+	return t, nil
+}
+
+// Name returns the name of the world shown in the game's world list.
+func (w *World) Name() (string, error) {
+	t, err := w.levelTag("LevelName")
+	if err != nil {
+		return "", err
+	}
+
+	s, _ := t.StringValue()
+
+	return s, nil
+}
+
+// LevelName returns the name shown in the game's world list of the world in the directory at path, read from its
+// level.dat without opening the world database, so it works while the world is open in the game.
+func LevelName(path string) (string, error) {
+	w := World{path: path}
+
+	root, err := w.levelDat()
+	if err != nil {
+		return "", err
+	}
+
+	t, ok := root.Child("LevelName")
+	if !ok {
+		return "", fmt.Errorf("%s has no LevelName tag", levelDatFileName)
+	}
+
+	s, _ := t.StringValue()
+
+	return s, nil
+}
+
+// Seed returns the seed the world was generated from.
+func (w *World) Seed() (int64, error) {
+	t, err := w.levelTag("RandomSeed")
+	if err != nil {
+		return 0, err
+	}
+
+	i, _ := t.Int()
+
+	return i, nil
+}
+
+// LastPlayed returns the time the world was last saved by the game.
+func (w *World) LastPlayed() (time.Time, error) {
+	t, err := w.levelTag("LastPlayed")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	i, _ := t.Int()
+
+	return time.Unix(i, 0), nil
+}
+
+// StorageVersion returns the storage format version from the header of level.dat.
+func (w *World) StorageVersion() (int, error) {
+	if w.level == nil {
+		return 0, fmt.Errorf("world has no %s", levelDatFileName)
+	}
+
+	return w.level.storageVersion, nil
+}
+
+// SpawnPoint returns the world spawn coordinates from level.dat.
+func (w *World) SpawnPoint() (x, y, z int, err error) {
+	for name, c := range map[string]*int{"SpawnX": &x, "SpawnY": &y, "SpawnZ": &z} {
+		t, err := w.levelTag(name)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		v, _ := t.Int()
+		*c = int(v)
+	}
+
+	return x, y, z, nil
+}
+
+// GameRules are the game rules of a world, such as keepinventory and randomtickspeed, by their level.dat tag name.
+// Rules the game has not saved, such as those added by versions newer than the one which last saved the world, are
+// missing.
+type GameRules struct {
+	Bools map[string]bool
+	Ints  map[string]int
+}
+
+// boolGameRules and intGameRules are the names of the game rules held in level.dat.
+var (
+	boolGameRules = []string{
+		"commandblockoutput", "commandblocksenabled", "dodaylightcycle", "doentitydrops", "dofiretick",
+		"doimmediaterespawn", "doinsomnia", "domobloot", "domobspawning", "dotiledrops", "doweathercycle",
+		"drowningdamage", "falldamage", "firedamage", "freezedamage", "keepinventory", "mobgriefing",
+		"naturalregeneration", "pvp", "recipesunlock", "respawnblocksexplode", "sendcommandfeedback",
+		"showbordereffect", "showcoordinates", "showdeathmessages", "showtags", "tntexplodes",
+	}
+	intGameRules = []string{
+		"functioncommandlimit", "maxcommandchainlength", "playerssleepingpercentage", "randomtickspeed",
+		"spawnradius",
+	}
+)
+
+// GameRules returns the game rules saved in level.dat.
+func (w *World) GameRules() (GameRules, error) {
+	if w.level == nil {
+		return GameRules{}, fmt.Errorf("world has no %s", levelDatFileName)
+	}
+
+	rules := GameRules{Bools: make(map[string]bool), Ints: make(map[string]int)}
+
+	for _, name := range boolGameRules {
+		if t, ok := w.level.root.Child(name); ok {
+			i, _ := t.Int()
+			rules.Bools[name] = i != 0
+		}
+	}
+
+	for _, name := range intGameRules {
+		if t, ok := w.level.root.Child(name); ok {
+			i, _ := t.Int()
+			rules.Ints[name] = int(i)
+		}
+	}
+
+	return rules, nil
+}
+
+// setLevelDat replaces the root compound tag in the world's level.dat file, keeping the storage version from the
+// existing file. The new file is written alongside the old one and renamed over it, so a failed write leaves the
+// original intact.
+func (w *World) setLevelDat(t nbt.NBTTag) error {
+	path := filepath.Join(w.path, levelDatFileName)
+
+	old, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", levelDatFileName, err)
+	}
+
+	if len(old) < levelDatHeaderSize {
+		return fmt.Errorf("%s is %d bytes long: too short for header", levelDatFileName, len(old))
+	}
+
+	body, err := nbt.Encode([]nbt.NBTTag{t})
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", levelDatFileName, err)
+	}
+
+	data := make([]byte, levelDatHeaderSize, levelDatHeaderSize+len(body))
+	copy(data, old[:4])
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(body)))
+	data = append(data, body...)
+
+	f, err := ioutil.TempFile(w.path, levelDatFileName+".*")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("writing %s: %w", f.Name(), err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("closing %s: %w", f.Name(), err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("replacing %s: %w", levelDatFileName, err)
+	}
+
+	w.level = &levelMetadata{storageVersion: int(binary.LittleEndian.Uint32(old[0:4])), root: t}
+
+	return nil
+}
+
+// levelDatField is a level.dat tag and the Go value it is read into. The value must be a pointer to an int64, int,
+// float64, bool or string.
+type levelDatField struct {
+	name    string
+	tagType byte
+	value   interface{}
+}
+
+// readLevelDatFields reads each field from its level.dat tag.
+func (w *World) readLevelDatFields(fields []levelDatField) error {
+	l, err := w.levelDat()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		t, ok := l.Child(f.name)
+		if !ok {
+			return fmt.Errorf("%s has no %s tag", levelDatFileName, f.name)
+		}
+
+		switch v := f.value.(type) {
+		case *int64:
+			*v, _ = t.Int()
+		case *int:
+			i, _ := t.Int()
+			*v = int(i)
+		case *float64:
+			*v, _ = t.Float()
+		case *bool:
+			i, _ := t.Int()
+			*v = i != 0
+		case *string:
+			*v, _ = t.StringValue()
+		default:
+			return fmt.Errorf("unhandled field type %T for %s", f.value, f.name)
+		}
+	}
+
+	return nil
+}
+
+// setLevelDatFields writes each field to its level.dat tag, keeping all other tags.
+func (w *World) setLevelDatFields(fields []levelDatField) error {
+	l, err := w.levelDat()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		var value interface{}
+
+		switch v := f.value.(type) {
+		case *int64:
+			value = nbt.Long(*v)
+		case *int:
+			value = float64(*v)
+		case *float64:
+			value = *v
+		case *bool:
+			value = 0.0
+			if *v {
+				value = 1.0
+			}
+		case *string:
+			value = *v
+		default:
+			return fmt.Errorf("unhandled field type %T for %s", f.value, f.name)
+		}
+
+		if err := l.SetChild(nbt.NBTTag{Name: f.name, Type: f.tagType, Value: value}); err != nil {
+			return fmt.Errorf("setting %s: %w", f.name, err)
+		}
+	}
+
+	return w.setLevelDat(l)
+}
@@ -0,0 +1,84 @@
+package world
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestLevelMetadata(t *testing.T) {
+	w := testLevelDat(t,
+		nbt.NBTTag{Name: "LevelName", Type: nbt.TagString, Value: "test world"},
+		nbt.NBTTag{Name: "RandomSeed", Type: nbt.TagLong, Value: nbt.Long(-1234567890123)},
+		nbt.NBTTag{Name: "LastPlayed", Type: nbt.TagLong, Value: nbt.Long(1600000000)},
+		nbt.NBTTag{Name: "SpawnX", Type: nbt.TagInt, Value: float64(-20)},
+		nbt.NBTTag{Name: "SpawnY", Type: nbt.TagInt, Value: float64(70)},
+		nbt.NBTTag{Name: "SpawnZ", Type: nbt.TagInt, Value: float64(300)},
+		nbt.NBTTag{Name: "keepinventory", Type: nbt.TagByte, Value: float64(1)},
+		nbt.NBTTag{Name: "mobgriefing", Type: nbt.TagByte, Value: float64(0)},
+		nbt.NBTTag{Name: "randomtickspeed", Type: nbt.TagInt, Value: float64(3)},
+	)
+
+	if name, err := w.Name(); err != nil || name != "test world" {
+		t.Errorf("expected name 'test world': got '%s' with error %v", name, err)
+	}
+
+	if name, err := LevelName(w.path); err != nil || name != "test world" {
+		t.Errorf("expected level name 'test world' from the directory: got '%s' with error %v", name, err)
+	}
+
+	if seed, err := w.Seed(); err != nil || seed != -1234567890123 {
+		t.Errorf("expected seed -1234567890123: got %d with error %v", seed, err)
+	}
+
+	if last, err := w.LastPlayed(); err != nil || !last.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("expected last played %s: got %s with error %v", time.Unix(1600000000, 0), last, err)
+	}
+
+	if v, err := w.StorageVersion(); err != nil || v != 9 {
+		t.Errorf("expected storage version 9: got %d with error %v", v, err)
+	}
+
+	x, y, z, err := w.SpawnPoint()
+	if err != nil {
+		t.Fatalf("unexpected error getting spawn point: %s", err)
+	}
+	if x != -20 || y != 70 || z != 300 {
+		t.Errorf("expected spawn point -20 70 300: got %d %d %d", x, y, z)
+	}
+
+	rules, err := w.GameRules()
+	if err != nil {
+		t.Fatalf("unexpected error getting game rules: %s", err)
+	}
+
+	if len(rules.Bools) != 2 || !rules.Bools["keepinventory"] || rules.Bools["mobgriefing"] {
+		t.Errorf("expected keepinventory true and mobgriefing false: got %v", rules.Bools)
+	}
+	if len(rules.Ints) != 1 || rules.Ints["randomtickspeed"] != 3 {
+		t.Errorf("expected randomtickspeed 3: got %v", rules.Ints)
+	}
+
+	// Writing level.dat updates the metadata
+	renamed := "renamed"
+	if err := w.setLevelDatFields([]levelDatField{{"LevelName", nbt.TagString, &renamed}}); err != nil {
+		t.Fatalf("unexpected error writing level.dat: %s", err)
+	}
+
+	if name, err := w.Name(); err != nil || name != "renamed" {
+		t.Errorf("expected name 'renamed' after writing: got '%s' with error %v", name, err)
+	}
+
+	if v, err := w.StorageVersion(); err != nil || v != 9 {
+		t.Errorf("expected storage version 9 after writing: got %d with error %v", v, err)
+	}
+
+	if _, err := w.Seed(); err != nil {
+		t.Errorf("unexpected error getting seed after writing: %s", err)
+	}
+
+	if _, err := NewFromDB(nil).Name(); err == nil {
+		t.Errorf("expected error getting the name of a world without level.dat")
+	}
+}
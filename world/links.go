@@ -0,0 +1,295 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// LinkKind is a kind of relationship between two entities, or an entity and a player.
+type LinkKind int
+
+// Kinds of link.
+const (
+	// Rides links a passenger to the vehicle or mount it is riding.
+	Rides LinkKind = iota
+	// LeashedTo links a leashed animal to what holds the leash, usually a leash knot on a fence or a player.
+	LeashedTo
+	// OwnedBy links a tamed animal to its owner.
+	OwnedBy
+)
+
+var linkKindNames = []string{"rides", "leashed to", "owned by"}
+
+func (k LinkKind) String() string {
+	return enumName(linkKindNames, int(k), "LinkKind")
+}
+
+// EntityLink is a relationship between two unique IDs: From rides, is leashed to or is owned by To.
+type EntityLink struct {
+	Kind     LinkKind
+	From, To int64
+}
+
+// EntityResolver resolves the unique IDs saved in entity and player records to the entities and players they refer
+// to. Unique IDs are the only IDs saved: runtime IDs are assigned by the game each time entities are loaded.
+type EntityResolver struct {
+	entities map[int64]Entity
+	players  map[int64]string // The key of each player record by the player's unique ID
+	links    []EntityLink
+}
+
+// EntityResolver reads every entity and player in the world and the links between them.
+func (w *World) EntityResolver() (*EntityResolver, error) {
+	r := &EntityResolver{
+		entities: make(map[int64]Entity),
+		players:  make(map[int64]string),
+		links:    make([]EntityLink, 0),
+	}
+
+	b := w.startBudget()
+
+	err := w.forEachEntity(b, nil, func(e Entity) error {
+		r.entities[e.UniqueID] = e
+		r.links = append(r.links, entityLinks(e.UniqueID, e.NBT)...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := w.playerKeys(b)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys {
+		root, err := w.singleTagRecord([]byte(k))
+		if err != nil {
+			return nil, err
+		}
+		if root == nil {
+			continue
+		}
+
+		if id, ok := root.Child("UniqueID"); ok {
+			uid, _ := id.Int()
+			r.players[uid] = k
+			r.links = append(r.links, entityLinks(uid, *root)...)
+		}
+	}
+
+	sort.SliceStable(r.links, func(i, j int) bool {
+		a, b := r.links[i], r.links[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.Kind < b.Kind
+	})
+
+	return r, nil
+}
+
+// entityLinks returns the links saved in the compound tag of the entity or player with the given unique ID.
+func entityLinks(id int64, t nbt.NBTTag) []EntityLink {
+	links := make([]EntityLink, 0)
+
+	// The vehicle lists its riders
+	if riders, ok := t.Child("LinksTag"); ok {
+		for _, l := range riders.List() {
+			if rider, ok := l.Child("entityID"); ok {
+				rid, _ := rider.Int()
+				links = append(links, EntityLink{Kind: Rides, From: rid, To: id})
+			}
+		}
+	}
+
+	// IDs of -1 mean no leash holder or owner
+	for name, kind := range map[string]LinkKind{"LeasherID": LeashedTo, "OwnerNew": OwnedBy} {
+		if tag, ok := t.Child(name); ok {
+			if to, _ := tag.Int(); to != -1 && to != 0 {
+				links = append(links, EntityLink{Kind: kind, From: id, To: to})
+			}
+		}
+	}
+
+	return links
+}
+
+// Links returns every link between entities and players, ordered by the unique ID of the entity they are from.
+func (r *EntityResolver) Links() []EntityLink {
+	return r.links
+}
+
+// LinksOf returns the links from and to the entity or player with the given unique ID.
+func (r *EntityResolver) LinksOf(id int64) []EntityLink {
+	links := make([]EntityLink, 0)
+
+	for _, l := range r.links {
+		if l.From == id || l.To == id {
+			links = append(links, l)
+		}
+	}
+
+	return links
+}
+
+// Entity returns the entity with the given unique ID.
+func (r *EntityResolver) Entity(id int64) (Entity, bool) {
+	e, ok := r.entities[id]
+	return e, ok
+}
+
+// Player returns the key of the record of the player with the given unique ID, e.g. ~local_player.
+func (r *EntityResolver) Player(id int64) (string, bool) {
+	k, ok := r.players[id]
+	return k, ok
+}
+
+// ErrUnresolved is returned by Describe for IDs which are not the ID of any saved entity or player.
+var ErrUnresolved = errors.New("no entity or player has the ID")
+
+// Describe returns a short description of the entity or player with the given unique ID, such as
+// "minecraft:horse 123 at 10 64 -20", or an error wrapping ErrUnresolved.
+func (r *EntityResolver) Describe(id int64) (string, error) {
+	if e, ok := r.entities[id]; ok {
+		return fmt.Sprintf("%s %d at %.0f %.0f %.0f", e.Identifier, e.UniqueID, e.X, e.Y, e.Z), nil
+	}
+
+	if k, ok := r.players[id]; ok {
+		return fmt.Sprintf("player %s", k), nil
+	}
+
+	return "", fmt.Errorf("%w %d", ErrUnresolved, id)
+}
+
+// Broken returns the links to or from an ID which is not the ID of any saved entity or player, such as riders left
+// behind when a vehicle was removed.
+func (r *EntityResolver) Broken() []EntityLink {
+	broken := make([]EntityLink, 0)
+
+	for _, l := range r.links {
+		if !r.known(l.From) || !r.known(l.To) {
+			broken = append(broken, l)
+		}
+	}
+
+	return broken
+}
+
+func (r *EntityResolver) known(id int64) bool {
+	_, entity := r.entities[id]
+	_, player := r.players[id]
+
+	return entity || player
+}
+
+// BrokenRides returns the links from riders which are not saved to the vehicles listing them. These are left behind
+// when a rider is deleted by an external edit and can cause invisible mobs or crashes when the vehicle is loaded.
+func (w *World) BrokenRides() ([]EntityLink, error) {
+	r, err := w.EntityResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	broken := make([]EntityLink, 0)
+	for _, l := range r.Broken() {
+		if l.Kind == Rides {
+			broken = append(broken, l)
+		}
+	}
+
+	return broken, nil
+}
+
+// RemoveRides removes the given rider links from the vehicles which list them. Links of other kinds are ignored.
+func (w *World) RemoveRides(links []EntityLink) error {
+	riders := make(map[int64]map[int64]bool)
+	for _, l := range links {
+		if l.Kind != Rides {
+			continue
+		}
+		if riders[l.To] == nil {
+			riders[l.To] = make(map[int64]bool)
+		}
+		riders[l.To][l.From] = true
+	}
+
+	// Vehicles are collected before any are rewritten, as rewriting changes the records being iterated over
+	vehicles := make([]Entity, 0)
+	b := w.startBudget()
+
+	err := w.forEachEntity(b, nil, func(e Entity) error {
+		if riders[e.UniqueID] != nil {
+			vehicles = append(vehicles, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range vehicles {
+		if err := removeRiders(&e.NBT, riders[e.UniqueID]); err != nil {
+			return fmt.Errorf("removing riders of %s %d: %w", e.Identifier, e.UniqueID, err)
+		}
+
+		if err := w.updateEntity(e); err != nil {
+			return err
+		}
+	}
+
+	keys, err := w.playerKeys(b)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		root, err := w.singleTagRecord([]byte(k))
+		if err != nil {
+			return err
+		}
+		if root == nil {
+			continue
+		}
+
+		remove := riders[childInt(*root, "UniqueID")]
+		if remove == nil {
+			continue
+		}
+
+		if err := removeRiders(root, remove); err != nil {
+			return fmt.Errorf("removing riders of player %s: %w", k, err)
+		}
+
+		if err := w.putTags([]byte(k), []nbt.NBTTag{*root}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeRiders removes the riders with the given unique IDs from the LinksTag list of a vehicle's compound tag.
+func removeRiders(vehicle *nbt.NBTTag, remove map[int64]bool) error {
+	riders, ok := vehicle.Child("LinksTag")
+	if !ok {
+		return nil
+	}
+
+	kept := make([]nbt.NBTTag, 0)
+	for _, l := range riders.List() {
+		if !remove[childInt(l, "entityID")] {
+			kept = append(kept, l)
+		}
+	}
+
+	if err := riders.SetList(kept); err != nil {
+		return err
+	}
+
+	return vehicle.SetChild(riders)
+}
@@ -0,0 +1,142 @@
+package world
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestEntityResolver(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	long := func(name string, v int64) nbt.NBTTag {
+		return nbt.NBTTag{Type: nbt.TagLong, Name: name, Value: nbt.Long(v)}
+	}
+
+	// A horse ridden by a zombie and a missing entity
+	horse := testEntity("minecraft:horse", 10, 1, 64, 1)
+	riders := nbt.NBTTag{Type: nbt.TagList, Name: "LinksTag", Value: map[string]interface{}{"tagListType": nbt.TagCompound}}
+	rider := func(id int64) nbt.NBTTag {
+		l := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+		_ = l.SetChild(long("entityID", id))
+		return l
+	}
+	if err := riders.SetList([]nbt.NBTTag{rider(11), rider(99)}); err != nil {
+		t.Fatal(err)
+	}
+	_ = horse.SetChild(riders)
+
+	// A wolf owned by the player and leashed to a leash knot, and a cat with no owner
+	wolf := testEntity("minecraft:wolf", 12, 2, 64, 2)
+	_ = wolf.SetChild(long("OwnerNew", 500))
+	_ = wolf.SetChild(long("LeasherID", 13))
+	cat := testEntity("minecraft:cat", 14, 3, 64, 3)
+	_ = cat.SetChild(long("OwnerNew", -1))
+
+	entities, err := nbt.Encode([]nbt.NBTTag{
+		horse, testEntity("minecraft:zombie", 11, 1, 65, 1), wolf, testEntity("minecraft:leash_knot", 13, 2, 65, 2), cat,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.Entity}.Bytes(), entities)
+
+	player := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	_ = player.SetChild(long("UniqueID", 500))
+	p, _ := nbt.Encode([]nbt.NBTTag{player})
+	_ = db.Put([]byte("~local_player"), p)
+
+	r, err := w.EntityResolver()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []EntityLink{
+		{Kind: Rides, From: 11, To: 10},
+		{Kind: LeashedTo, From: 12, To: 13},
+		{Kind: OwnedBy, From: 12, To: 500},
+		{Kind: Rides, From: 99, To: 10},
+	}
+	if !reflect.DeepEqual(r.Links(), want) {
+		t.Errorf("expected links %v: got %v", want, r.Links())
+	}
+
+	if got := r.Broken(); !reflect.DeepEqual(got, want[3:]) {
+		t.Errorf("expected the missing rider's link to be broken: got %v", got)
+	}
+
+	if got := r.LinksOf(10); len(got) != 2 {
+		t.Errorf("expected 2 links of the horse: got %v", got)
+	}
+
+	if d, err := r.Describe(500); err != nil || d != "player ~local_player" {
+		t.Errorf("unexpected description of the player: %q, %v", d, err)
+	}
+
+	if d, err := r.Describe(10); err != nil || d != "minecraft:horse 10 at 1 64 1" {
+		t.Errorf("unexpected description of the horse: %q, %v", d, err)
+	}
+
+	if _, err := r.Describe(99); !errors.Is(err, ErrUnresolved) {
+		t.Errorf("expected ErrUnresolved describing a missing entity: got %v", err)
+	}
+
+	if e, ok := r.Entity(13); !ok || e.Identifier != "minecraft:leash_knot" {
+		t.Errorf("expected entity 13 to be the leash knot: got %v", e.Identifier)
+	}
+}
+
+func TestRemoveRides(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	// A boat listing a saved and a missing rider
+	boat := testEntity("minecraft:boat", 20, 1, 62, 1)
+	riders := nbt.NBTTag{Type: nbt.TagList, Name: "LinksTag", Value: map[string]interface{}{"tagListType": nbt.TagCompound}}
+	rider := func(id int64) nbt.NBTTag {
+		l := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+		_ = l.SetChild(nbt.NBTTag{Type: nbt.TagLong, Name: "entityID", Value: nbt.Long(id)})
+		return l
+	}
+	if err := riders.SetList([]nbt.NBTTag{rider(21), rider(98)}); err != nil {
+		t.Fatal(err)
+	}
+	_ = boat.SetChild(riders)
+
+	entities, err := nbt.Encode([]nbt.NBTTag{testEntity("minecraft:villager", 21, 1, 63, 1), boat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.Entity}.Bytes(), entities)
+
+	broken, err := w.BrokenRides()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := []EntityLink{{Kind: Rides, From: 98, To: 20}}; !reflect.DeepEqual(broken, want) {
+		t.Fatalf("expected broken rides %v: got %v", want, broken)
+	}
+
+	if err := w.RemoveRides(broken); err != nil {
+		t.Fatalf("unexpected error removing rides: %s", err)
+	}
+
+	r, err := w.EntityResolver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []EntityLink{{Kind: Rides, From: 21, To: 20}}; !reflect.DeepEqual(r.Links(), want) {
+		t.Errorf("expected only the saved rider to be left: got %v", r.Links())
+	}
+
+	if _, ok := r.Entity(21); !ok {
+		t.Error("expected the saved rider to be kept")
+	}
+}
@@ -0,0 +1,443 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// Severity is how serious a lint finding is.
+type Severity int
+
+const (
+	// SeverityInfo is something which may be intended but is worth knowing about.
+	SeverityInfo Severity = iota
+	// SeverityWarning is something which is likely to cause trouble in game.
+	SeverityWarning
+	// SeverityError is something which is broken.
+	SeverityError
+)
+
+var severityNames = []string{"info", "warning", "error"}
+
+func (s Severity) String() string {
+	return enumName(severityNames, int(s), "Severity")
+}
+
+// ParseSeverity returns the severity with the given name: info, warning or error.
+func ParseSeverity(s string) (Severity, error) {
+	i, err := parseEnum(severityNames, s, "severity")
+	return Severity(i), err
+}
+
+// LintFinding is a problem found in a world by a lint rule.
+type LintFinding struct {
+	Rule      string // The name of the rule or record handler which found the problem
+	Severity  Severity
+	Message   string
+	X, Y, Z   int // The block coordinates of the problem, unless Key is set
+	Dimension int
+	Key       []byte // The key of the record with the problem, for findings of record handlers
+}
+
+// LintRule is a best-practice check of a world, such as looking for builds with no lighting. Rules are run by Lint and
+// may be added with RegisterLintRule.
+type LintRule interface {
+	// Name identifies the rule, for example "unlit-builds".
+	Name() string
+	// Description explains what the rule looks for and why.
+	Description() string
+	// Check returns the problems found in the world. The Rule field of the findings may be left empty.
+	Check(w *World) ([]LintFinding, error)
+}
+
+// RecordLinter is implemented by record handlers which can check a record for problems which aren't corruption, such
+// as add-on data which is likely to cause trouble in game. Lint runs it as a rule with the name of the handler.
+type RecordLinter interface {
+	Lint(key, value []byte) ([]LintFinding, error)
+}
+
+var lintRules = struct {
+	sync.RWMutex
+	list []LintRule
+}{
+	list: []LintRule{spawnCommandBlocks{}, unlitBuilds{}, containersNearLava{}},
+}
+
+// RegisterLintRule adds a lint rule, replacing any rule with the same name.
+func RegisterLintRule(r LintRule) {
+	lintRules.Lock()
+	defer lintRules.Unlock()
+
+	for i, existing := range lintRules.list {
+		if existing.Name() == r.Name() {
+			lintRules.list[i] = r
+			return
+		}
+	}
+
+	lintRules.list = append(lintRules.list, r)
+}
+
+// LintRules returns every lint rule, in the order they were added.
+func LintRules() []LintRule {
+	lintRules.RLock()
+	defer lintRules.RUnlock()
+
+	return append([]LintRule(nil), lintRules.list...)
+}
+
+// Lint runs the lint rules with the given names, or every rule if none are given, and returns their findings, most
+// severe first. Record handlers implementing RecordLinter are run as rules with the names of the handlers.
+func (w *World) Lint(names ...string) ([]LintFinding, error) {
+	selected := make(map[string]bool)
+	for _, n := range names {
+		selected[n] = true
+	}
+	run := func(name string) bool {
+		ok := len(names) == 0 || selected[name]
+		delete(selected, name)
+		return ok
+	}
+
+	rules := make([]LintRule, 0)
+	for _, r := range LintRules() {
+		if run(r.Name()) {
+			rules = append(rules, r)
+		}
+	}
+
+	linters := make(map[string]bool)
+	handlers.RLock()
+	for _, h := range handlers.list {
+		if _, ok := h.(RecordLinter); ok && run(h.Name()) {
+			linters[h.Name()] = true
+		}
+	}
+	handlers.RUnlock()
+
+	if len(selected) > 0 {
+		unknown := make([]string, 0, len(selected))
+		for n := range selected {
+			unknown = append(unknown, n)
+		}
+		sort.Strings(unknown)
+
+		return nil, fmt.Errorf("unknown lint rules: %s", strings.Join(unknown, ", "))
+	}
+
+	findings := make([]LintFinding, 0)
+
+	for _, r := range rules {
+		found, err := r.Check(w)
+		if err != nil {
+			return nil, fmt.Errorf("checking %s: %w", r.Name(), err)
+		}
+
+		for _, f := range found {
+			if f.Rule == "" {
+				f.Rule = r.Name()
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	if len(linters) > 0 {
+		err := w.forEachHandledRecord(func(key, value []byte, h RecordHandler) error {
+			l, ok := h.(RecordLinter)
+			if !ok || !linters[h.Name()] {
+				return nil
+			}
+
+			found, err := l.Lint(key, value)
+			if err != nil {
+				return fmt.Errorf("linting key '%x' as %s: %w", key, h.Name(), err)
+			}
+
+			for _, f := range found {
+				f.Rule = h.Name()
+				f.Key = append([]byte(nil), key...)
+				findings = append(findings, f)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		return a.Y < b.Y
+	})
+
+	return findings, nil
+}
+
+// spawnChunkRadius is the distance in chunks from the world spawn within which chunks are counted as spawn chunks,
+// which are loaded whenever a player is at the spawn.
+const spawnChunkRadius = 4
+
+// spawnCommandBlocks finds command blocks in the spawn chunks.
+type spawnCommandBlocks struct{}
+
+func (spawnCommandBlocks) Name() string { return "spawn-command-blocks" }
+
+func (spawnCommandBlocks) Description() string {
+	return "Command blocks in the spawn chunks run whenever a player is at the world spawn, which is easily forgotten."
+}
+
+func (spawnCommandBlocks) Check(w *World) ([]LintFinding, error) {
+	x, _, z, err := w.SpawnPoint()
+	if err != nil {
+		return nil, fmt.Errorf("finding the world spawn: %w", err)
+	}
+
+	findings := make([]LintFinding, 0)
+	scx, scz := floorDiv(x, chunkSize), floorDiv(z, chunkSize)
+
+	for cx := scx - spawnChunkRadius; cx <= scx+spawnChunkRadius; cx++ {
+		for cz := scz - spawnChunkRadius; cz <= scz+spawnChunkRadius; cz++ {
+			entities, err := w.chunkBlockEntities(cx, cz, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, e := range entities {
+				if e.ID != "CommandBlock" {
+					continue
+				}
+
+				command := ""
+				if c, ok := e.NBT.Child("Command"); ok {
+					command, _ = c.StringValue()
+				}
+
+				findings = append(findings, LintFinding{
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("command block in the spawn chunks with the command %q", command),
+					X:        e.X, Y: e.Y, Z: e.Z,
+				})
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// lightSourceNames are substrings of the IDs of blocks which give off enough light to stop mobs spawning near them.
+var lightSourceNames = []string{
+	"torch", "lantern", "glowstone", "shroomlight", "lit_pumpkin", "campfire", "froglight", "end_rod", "beacon",
+	"lit_redstone_lamp", "candle", "conduit", "lava",
+}
+
+// isLightSource returns true if the block ID is one which gives off light.
+func isLightSource(id string) bool {
+	for _, n := range lightSourceNames {
+		if strings.Contains(id, n) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unlitBuilds finds sub chunks with many crafted blocks and no light source.
+type unlitBuilds struct{}
+
+func (unlitBuilds) Name() string { return "unlit-builds" }
+
+func (unlitBuilds) Description() string {
+	return fmt.Sprintf("Sub chunks with %d or more blocks usually placed by players, such as planks and glass, and no "+
+		"light source are likely to be dark builds where mobs can spawn. Generated structures may also be found.",
+		minBuildChunkBlocks)
+}
+
+func (unlitBuilds) Check(w *World) ([]LintFinding, error) {
+	findings := make([]LintFinding, 0)
+
+	err := w.forEachSubChunk(w.startBudget(), func(k leveldb.ChunkKey, s *subChunkData) error {
+		crafted := make([]bool, len(s.Blocks.Palette))
+		hasCrafted := false
+		for i, p := range s.Blocks.Palette {
+			id := p.BlockID()
+			if isLightSource(id) {
+				return nil
+			}
+			crafted[i] = isCrafted(id)
+			hasCrafted = hasCrafted || crafted[i]
+		}
+		if !hasCrafted {
+			return nil
+		}
+
+		count, first := 0, -1
+		for i, p := range s.Blocks.Indices {
+			if crafted[p] {
+				count++
+				if first < 0 {
+					first = i
+				}
+			}
+		}
+		if count < minBuildChunkBlocks {
+			return nil
+		}
+
+		ox, oy, oz := subChunkKeyOrigin(k)
+		x, y, z := subChunkIndexToVoxel(first)
+		findings = append(findings, LintFinding{
+			Severity:  SeverityInfo,
+			Message:   fmt.Sprintf("%d crafted blocks with no light source in the sub chunk", count),
+			X:         ox + x,
+			Y:         oy + y,
+			Z:         oz + z,
+			Dimension: int(k.Dimension),
+		})
+
+		return nil
+	})
+
+	return findings, err
+}
+
+// lavaDistance is the distance in blocks from lava within which containers are reported.
+const lavaDistance = 16
+
+// containerIDs are the IDs of block entities which hold items.
+var containerIDs = map[string]bool{
+	"Chest": true, "Barrel": true, "ShulkerBox": true, "Hopper": true, "Dispenser": true, "Dropper": true,
+}
+
+// containersNearLava finds containers close to lava, which could burn their items if a container is broken.
+type containersNearLava struct{}
+
+func (containersNearLava) Name() string { return "containers-near-lava" }
+
+func (containersNearLava) Description() string {
+	return fmt.Sprintf("Containers within %d blocks of lava risk their items being burned if the container is broken "+
+		"or the lava flows.", lavaDistance)
+}
+
+func (containersNearLava) Check(w *World) ([]LintFinding, error) {
+	records, err := w.blockEntityRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	lava := lavaFinder{w: w, positions: make(map[[4]int][][3]int)}
+	findings := make([]LintFinding, 0)
+
+	for _, r := range records {
+		dimension := int(r.chunk.Dimension)
+
+		for _, e := range r.entities {
+			if !containerIDs[e.ID] {
+				continue
+			}
+
+			near, found, err := lava.near(e.X, e.Y, e.Z, dimension)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+
+			findings = append(findings, LintFinding{
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf("%s within %d blocks of lava at %d %d %d",
+					e.ID, lavaDistance, near[0], near[1], near[2]),
+				X: e.X, Y: e.Y, Z: e.Z,
+				Dimension: dimension,
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// lavaFinder finds lava near positions, reading each sub chunk once.
+type lavaFinder struct {
+	w         *World
+	positions map[[4]int][][3]int // The lava in each sub chunk by chunk coordinates, Y index and dimension
+}
+
+// near returns the position of lava within lavaDistance of the given position. The returned bool is false if there is
+// none.
+func (l lavaFinder) near(x, y, z, dimension int) ([3]int, bool, error) {
+	for cx := floorDiv(x-lavaDistance, chunkSize); cx <= floorDiv(x+lavaDistance, chunkSize); cx++ {
+		for sy := floorDiv(y-lavaDistance, chunkSize); sy <= floorDiv(y+lavaDistance, chunkSize); sy++ {
+			for cz := floorDiv(z-lavaDistance, chunkSize); cz <= floorDiv(z+lavaDistance, chunkSize); cz++ {
+				positions, err := l.in(cx, sy, cz, dimension)
+				if err != nil {
+					return [3]int{}, false, err
+				}
+
+				for _, p := range positions {
+					dx, dy, dz := p[0]-x, p[1]-y, p[2]-z
+					if dx*dx+dy*dy+dz*dz <= lavaDistance*lavaDistance {
+						return p, true, nil
+					}
+				}
+			}
+		}
+	}
+
+	return [3]int{}, false, nil
+}
+
+// in returns the world coordinates of the lava in a sub chunk, which is none if the sub chunk isn't saved.
+func (l lavaFinder) in(cx, sy, cz, dimension int) ([][3]int, error) {
+	c := [4]int{cx, sy, cz, dimension}
+	if positions, ok := l.positions[c]; ok {
+		return positions, nil
+	}
+
+	s, err := l.w.SubChunk(cx, sy, cz, dimension)
+	if errors.Is(err, &SubChunkNotSavedError{}) || errors.Is(err, ErrOutsideHeight) {
+		l.positions[c] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	isLava := make([]bool, len(s.data.Blocks.Palette))
+	for i, p := range s.data.Blocks.Palette {
+		id := p.BlockID()
+		isLava[i] = id == "minecraft:lava" || id == "minecraft:flowing_lava"
+	}
+
+	var positions [][3]int
+	for i, p := range s.data.Blocks.Indices {
+		if isLava[p] {
+			x, y, z := subChunkIndexToVoxel(i)
+			positions = append(positions, [3]int{cx*chunkSize + x, sy*chunkSize + y, cz*chunkSize + z})
+		}
+	}
+
+	l.positions[c] = positions
+
+	return positions, nil
+}
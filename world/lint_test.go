@@ -0,0 +1,114 @@
+package world
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// testLintHandler handles records prefixed with "test_lint_" and reports those whose value is "bad".
+type testLintHandler struct{}
+
+func (testLintHandler) Name() string { return "test-lint" }
+
+func (testLintHandler) Match(key []byte) bool { return bytes.HasPrefix(key, []byte("test_lint_")) }
+
+func (testLintHandler) Decode(_, value []byte) (interface{}, error) { return string(value), nil }
+
+func (testLintHandler) Lint(_, value []byte) ([]LintFinding, error) {
+	if string(value) != "bad" {
+		return nil, nil
+	}
+	return []LintFinding{{Severity: SeverityError, Message: "bad value"}}, nil
+}
+
+// testLintRule reports one finding at the origin.
+type testLintRule struct{}
+
+func (testLintRule) Name() string { return "test-rule" }
+
+func (testLintRule) Description() string { return "A rule added by a test." }
+
+func (testLintRule) Check(*World) ([]LintFinding, error) {
+	return []LintFinding{{Severity: SeverityInfo, Message: "origin"}}, nil
+}
+
+func TestLint(t *testing.T) {
+	w := testLevelDat(t,
+		nbt.NBTTag{Name: "SpawnX", Type: nbt.TagInt, Value: float64(8)},
+		nbt.NBTTag{Name: "SpawnY", Type: nbt.TagInt, Value: float64(64)},
+		nbt.NBTTag{Name: "SpawnZ", Type: nbt.TagInt, Value: float64(8)},
+	)
+	db := mock.NewLevelDB()
+	w.db = db
+	w.subChunks = make(map[struct{ x, y, z, d int }]*subChunkData)
+
+	command := testBlockEntity("CommandBlock", 1, 64, 1)
+	_ = command.SetChild(testString("Command", "say hi"))
+	value, _ := nbt.Encode([]nbt.NBTTag{command, testBlockEntity("Chest", 5, 64, 5)})
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.BlockEntity}.Bytes(), value)
+
+	// A command block far from spawn and a chest far from lava
+	value, _ = nbt.Encode([]nbt.NBTTag{testBlockEntity("CommandBlock", 200, 64, 200),
+		testBlockEntity("Chest", 201, 64, 200)})
+	_ = db.Put(leveldb.ChunkKey{X: 12, Z: 12, Tag: leveldb.BlockEntity}.Bytes(), value)
+
+	lava := NewSubChunk(0, 4, 0, 0, BlockAir)
+	lava.setAt(5, 6, 5, Block{ID: "minecraft:lava"})
+
+	// A build without light and one with a torch
+	unlit := NewSubChunk(1, 4, 1, 0, BlockAir)
+	lit := NewSubChunk(2, 4, 2, 0, BlockAir)
+	for i := 0; i < 100; i++ {
+		unlit.setAt(i%16, i/16, 0, Block{ID: "minecraft:oak_planks"})
+		lit.setAt(i%16, i/16, 0, Block{ID: "minecraft:oak_planks"})
+	}
+	lit.setAt(0, 10, 0, Block{ID: BlockTorch})
+
+	for _, s := range []*SubChunk{lava, unlit, lit} {
+		if err := w.SetSubChunk(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	RegisterRecordHandler(testLintHandler{})
+	RegisterLintRule(testLintRule{})
+	_ = db.Put([]byte("test_lint_a"), []byte("bad"))
+	_ = db.Put([]byte("test_lint_b"), []byte("good"))
+
+	findings, err := w.Lint()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := make([]string, len(findings))
+	for i, f := range findings {
+		got[i] = fmt.Sprintf("%s %s %d %d %d %s", f.Severity, f.Rule, f.X, f.Y, f.Z, f.Key)
+	}
+	want := []string{
+		"error test-lint 0 0 0 test_lint_a",
+		"warning containers-near-lava 5 64 5 ",
+		"warning spawn-command-blocks 1 64 1 ",
+		"info test-rule 0 0 0 ",
+		"info unlit-builds 16 64 16 ",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected findings %q: got %q", want, got)
+	}
+
+	findings, err = w.Lint("spawn-command-blocks")
+	if err != nil {
+		t.Fatalf("unexpected error running one rule: %s", err)
+	}
+	if len(findings) != 1 || findings[0].Message != `command block in the spawn chunks with the command "say hi"` {
+		t.Errorf("expected the command block: got %+v", findings)
+	}
+
+	if _, err := w.Lint("no-such-rule"); err == nil {
+		t.Error("expected an error running an unknown rule")
+	}
+}
@@ -0,0 +1,291 @@
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockFileName is the name of the file in a world folder holding the claims of processes editing the world.
+const lockFileName = "mine.lock"
+
+// Claim is a part of a world claimed by a process, so that other processes don't edit it at the same time. Claims are
+// advisory: they are only respected by processes which check them with LockRegion.
+type Claim struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Command string    `json:"command"` // What the process is doing, for messages to other processes
+	Since   time.Time `json:"since"`
+
+	// All claims the whole world. Otherwise the claim is of the chunks from Min to Max inclusive in Dimension.
+	All       bool `json:"all"`
+	Dimension int  `json:"dimension"`
+	MinX      int  `json:"minX"`
+	MinZ      int  `json:"minZ"`
+	MaxX      int  `json:"maxX"`
+	MaxZ      int  `json:"maxZ"`
+}
+
+// WorldClaim returns a claim of the whole world by this process.
+func WorldClaim(command string) Claim {
+	return newClaim(command, Claim{All: true})
+}
+
+// RegionClaim returns a claim by this process of the chunks between two corners, in chunk coordinates.
+func RegionClaim(command string, minCX, minCZ, maxCX, maxCZ, dimension int) Claim {
+	return newClaim(command, Claim{Dimension: dimension, MinX: minCX, MinZ: minCZ, MaxX: maxCX, MaxZ: maxCZ})
+}
+
+func newClaim(command string, c Claim) Claim {
+	host, _ := os.Hostname()
+	c.PID, c.Host, c.Command, c.Since = os.Getpid(), host, command, time.Now().Round(time.Second)
+
+	return c
+}
+
+// Overlaps returns true if the claims share any chunk. A process's own claims never overlap, so it may open a world
+// more than once.
+func (c Claim) Overlaps(o Claim) bool {
+	if c.PID == o.PID && c.Host == o.Host {
+		return false
+	}
+
+	if c.All || o.All {
+		return true
+	}
+
+	return c.Dimension == o.Dimension &&
+		c.MinX <= o.MaxX && o.MinX <= c.MaxX &&
+		c.MinZ <= o.MaxZ && o.MinZ <= c.MaxZ
+}
+
+func (c Claim) String() string {
+	region := "the whole world"
+	if !c.All {
+		region = fmt.Sprintf("chunks %d,%d to %d,%d in dimension %d", c.MinX, c.MinZ, c.MaxX, c.MaxZ, c.Dimension)
+	}
+
+	return fmt.Sprintf("process %d on %s (%s) has claimed %s since %s", c.PID, c.Host, c.Command, region,
+		c.Since.Format(time.RFC3339))
+}
+
+// IsStale returns true if the claim was made by a process on this host which is no longer running. Claims made on
+// other hosts, such as when the world is on a network share, are never stale as the process can't be checked.
+func (c Claim) IsStale() bool {
+	host, _ := os.Hostname()
+	return c.Host == host && !processRunning(c.PID)
+}
+
+// same returns true if the claims are the same claim, such as after one has been read back from the lock file.
+func (c Claim) same(o Claim) bool {
+	a, b := c, o
+	a.Since, b.Since = time.Time{}, time.Time{}
+
+	return a == b && c.Since.Equal(o.Since)
+}
+
+// LockedError is returned by LockRegion when another process has claimed part of the region.
+type LockedError struct {
+	Claim Claim
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("world is in use: %s", e.Claim)
+}
+
+// Lock is a claim held in a world's lock file. It must be released when the process has finished with the world.
+type Lock struct {
+	worldPath string
+	claim     Claim
+}
+
+// LockRegion adds a claim to the lock file in the world folder. If a running process has claimed any of the same
+// chunks, LockRegion waits for up to the given time for the claim to be released and then returns a *LockedError.
+// Claims of processes on this host which are no longer running, such as after a crash, are removed.
+func LockRegion(worldPath string, c Claim, wait time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(wait)
+
+	for {
+		var conflict *Claim
+
+		err := updateClaims(worldPath, func(claims []Claim) []Claim {
+			claims = liveClaims(claims)
+
+			for _, o := range claims {
+				if o.Overlaps(c) {
+					conflict = &o
+					return claims
+				}
+			}
+
+			return append(claims, c)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if conflict == nil {
+			return &Lock{worldPath: worldPath, claim: c}, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, &LockedError{Claim: *conflict}
+		}
+
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// lockPollInterval is the time between checks of another process' claim while waiting for it.
+const lockPollInterval = 250 * time.Millisecond
+
+// Release removes the claim from the world's lock file. The lock file is removed when it holds no claims.
+func (l *Lock) Release() error {
+	return updateClaims(l.worldPath, func(claims []Claim) []Claim {
+		kept := make([]Claim, 0, len(claims))
+		for _, c := range liveClaims(claims) {
+			if !c.same(l.claim) {
+				kept = append(kept, c)
+			}
+		}
+
+		return kept
+	})
+}
+
+// liveClaims returns the claims which are not stale.
+func liveClaims(claims []Claim) []Claim {
+	live := make([]Claim, 0, len(claims))
+	for _, c := range claims {
+		if !c.IsStale() {
+			live = append(live, c)
+		}
+	}
+
+	return live
+}
+
+// Claims returns the claims in the lock file in the world folder, including stale claims of processes which are no
+// longer running.
+func Claims(worldPath string) ([]Claim, error) {
+	return readClaims(filepath.Join(worldPath, lockFileName))
+}
+
+// RemoveStaleClaims removes the claims of processes on this host which are no longer running and returns them.
+func RemoveStaleClaims(worldPath string) ([]Claim, error) {
+	removed := make([]Claim, 0)
+
+	err := updateClaims(worldPath, func(claims []Claim) []Claim {
+		kept := make([]Claim, 0, len(claims))
+		for _, c := range claims {
+			if c.IsStale() {
+				removed = append(removed, c)
+			} else {
+				kept = append(kept, c)
+			}
+		}
+
+		return kept
+	})
+
+	return removed, err
+}
+
+func readClaims(path string) ([]Claim, error) {
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Claim{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading lock file: %w", err)
+	}
+
+	claims := make([]Claim, 0)
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, fmt.Errorf("parsing lock file %s: %w", path, err)
+	}
+
+	return claims, nil
+}
+
+// updateClaims replaces the claims in the lock file with those returned by f, which is called with the current claims.
+// The lock file is only changed by one process at a time.
+func updateClaims(worldPath string, f func(claims []Claim) []Claim) error {
+	path := filepath.Join(worldPath, lockFileName)
+
+	unlock, err := lockFile(path + ".guard")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	claims, err := readClaims(path)
+	if err != nil {
+		return err
+	}
+
+	claims = f(claims)
+
+	if len(claims) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing lock file: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(claims, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The file is replaced whole so that other processes never read a partly written file
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing lock file: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing lock file: %w", err)
+	}
+
+	return nil
+}
+
+// Guard files older than guardTimeout were left by a process which stopped while updating the lock file.
+const (
+	guardTimeout = 10 * time.Second
+	guardWait    = 5 * time.Second
+)
+
+// lockFile creates the guard file at path, which only one process can do at a time, and returns a function which
+// removes it.
+func lockFile(path string) (unlock func(), err error) {
+	deadline := time.Now().Add(guardWait)
+
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("locking lock file: %w", err)
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > guardTimeout {
+			os.Remove(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for another process to update %s", filepath.Base(path))
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,104 @@
+package world
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testForeignClaim adds a claim of the region to the lock file as if made by another process.
+func testForeignClaim(t *testing.T, dir string, pid int, c Claim) {
+	c.PID = pid
+
+	err := updateClaims(dir, func(claims []Claim) []Claim {
+		return append(claims, c)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockRegion(t *testing.T) {
+	dir := t.TempDir()
+
+	// The parent process is running, so its claims are respected
+	testForeignClaim(t, dir, os.Getppid(), RegionClaim("restore", 0, 0, 3, 3, 0))
+
+	_, err := LockRegion(dir, RegionClaim("edit", 2, 2, 5, 5, 0), 0)
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Claim.Command != "restore" {
+		t.Fatalf("expected the overlapping claim to be locked: got %v", err)
+	}
+
+	start := time.Now()
+	if _, err := LockRegion(dir, WorldClaim("optimize"), 300*time.Millisecond); !errors.As(err, &locked) {
+		t.Errorf("expected a claim of the whole world to be locked: got %v", err)
+	}
+	if time.Since(start) < 300*time.Millisecond {
+		t.Errorf("expected to wait for the claim to be released")
+	}
+
+	for _, c := range []Claim{RegionClaim("edit", 4, 0, 5, 5, 0), RegionClaim("edit", 0, 0, 3, 3, 1)} {
+		l, err := LockRegion(dir, c, 0)
+		if err != nil {
+			t.Fatalf("expected a claim of other chunks to succeed: got %s", err)
+		}
+
+		// A process's own claims don't conflict
+		read := c
+		read.Command = "read"
+		own, err := LockRegion(dir, read, 0)
+		if err != nil {
+			t.Fatalf("expected a process not to conflict with itself: got %s", err)
+		}
+
+		for _, l := range []*Lock{l, own} {
+			if err := l.Release(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	claims, err := Claims(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(claims) != 1 || claims[0].PID != os.Getppid() {
+		t.Errorf("expected only the other process's claim to be left: got %+v", claims)
+	}
+}
+
+func TestStaleClaims(t *testing.T) {
+	dir := t.TempDir()
+
+	// A process ID which is not running
+	testForeignClaim(t, dir, 0x7ffffff0, WorldClaim("crashed"))
+
+	claims, _ := Claims(dir)
+	if len(claims) != 1 || !claims[0].IsStale() {
+		t.Fatalf("expected one stale claim: got %+v", claims)
+	}
+
+	l, err := LockRegion(dir, WorldClaim("edit"), 0)
+	if err != nil {
+		t.Fatalf("expected a stale claim to be ignored: got %s", err)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, lockFileName)); !os.IsNotExist(err) {
+		t.Errorf("expected the lock file to be removed with its last claim")
+	}
+
+	testForeignClaim(t, dir, 0x7ffffff0, WorldClaim("crashed"))
+
+	removed, err := RemoveStaleClaims(dir)
+	if err != nil || len(removed) != 1 || removed[0].Command != "crashed" {
+		t.Errorf("expected the stale claim to be removed: got %+v, %v", removed, err)
+	}
+}
@@ -0,0 +1,76 @@
+package world
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Logger receives the diagnostic messages of the package, such as warnings about unusual data which could still be
+// read. Each message is followed by alternating keys and values. A *slog.Logger satisfies Logger, so a program may
+// pass its own with SetLogger.
+type Logger interface {
+	Warn(msg string, args ...interface{})
+}
+
+// stdLogger writes messages with the log package, as "warning: msg: key=value".
+type stdLogger struct{}
+
+func (stdLogger) Warn(msg string, args ...interface{}) {
+	log.Print("warning: " + formatLogArgs(msg, args))
+}
+
+// discardLogger drops every message.
+type discardLogger struct{}
+
+func (discardLogger) Warn(string, ...interface{}) {}
+
+// formatLogArgs returns the message followed by its key value pairs.
+func formatLogArgs(msg string, args []interface{}) string {
+	b := strings.Builder{}
+	b.WriteString(msg)
+
+	for i := 0; i < len(args); i += 2 {
+		if i == 0 {
+			b.WriteString(":")
+		}
+
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", args[i])
+		}
+	}
+
+	return b.String()
+}
+
+var logger = struct {
+	sync.Mutex
+	l Logger
+}{
+	l: stdLogger{},
+}
+
+// SetLogger sets the logger receiving the diagnostic messages of every world. By default messages are written with the
+// standard log package. A nil Logger discards them.
+func SetLogger(l Logger) {
+	logger.Lock()
+	defer logger.Unlock()
+
+	if l == nil {
+		l = discardLogger{}
+	}
+
+	logger.l = l
+}
+
+// warn passes a warning to the logger.
+func warn(msg string, args ...interface{}) {
+	logger.Lock()
+	l := logger.l
+	logger.Unlock()
+
+	l.Warn(msg, args...)
+}
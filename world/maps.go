@@ -0,0 +1,130 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+const mapPrefix = "map_"
+
+// Map item and map record tag names.
+const (
+	mapItemIDTag   = "map_uuid"
+	mapIDTag       = "mapId"
+	mapParentIDTag = "parentMapId"
+)
+
+// MapIDs returns the ID of every map record in the world.
+func (w *World) MapIDs() ([]int64, error) {
+	keys, err := w.db.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("listing keys: %w", err)
+	}
+
+	ids := make([]int64, 0)
+
+	for _, key := range keys {
+		if id, ok := parseMapKey(key); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids, nil
+}
+
+// UnreferencedMaps returns the IDs of map records which are not referenced by any map item in any inventory, item frame
+// or other record, and are not the parent of a referenced map.
+func (w *World) UnreferencedMaps() ([]int64, error) {
+	referenced := make(map[int64]bool)
+	parents := make(map[int64]int64)
+
+	err := w.forEachNBTRecord(func(key []byte, tags []nbt.NBTTag) ([]nbt.NBTTag, bool, error) {
+		if id, ok := parseMapKey(key); ok {
+			if len(tags) == 1 {
+				if p, ok := tags[0].Child(mapParentIDTag); ok {
+					parents[id], _ = p.Int()
+				}
+			}
+			return nil, false, nil
+		}
+
+		for _, t := range tags {
+			nbt.Walk(t, func(_ []string, t nbt.NBTTag) {
+				if t.Name == mapItemIDTag {
+					id, _ := t.Int()
+					referenced[id] = true
+				}
+			})
+		}
+
+		return nil, false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Zoomed out copies of a map refer to the map they were made from
+	for id := range referenced {
+		for p, ok := parents[id]; ok && p != -1 && !referenced[p]; p, ok = parents[p] {
+			referenced[p] = true
+		}
+	}
+
+	ids, err := w.MapIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	unreferenced := make([]int64, 0)
+	for _, id := range ids {
+		if !referenced[id] {
+			unreferenced = append(unreferenced, id)
+		}
+	}
+
+	return unreferenced, nil
+}
+
+// DeleteMaps deletes the map records with the given IDs.
+func (w *World) DeleteMaps(ids []int64) error {
+	for _, id := range ids {
+		if err := w.delete(mapKey(id)); err != nil {
+			return fmt.Errorf("deleting map %d: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// RemapMaps changes map IDs according to remap, renaming the map records and rewriting every map item, parent map
+// reference and map ID tag which refers to them. Maps not in remap are unchanged.
+func (w *World) RemapMaps(remap map[int64]int64) error {
+	return w.RemapIDs(IDRemap{MapID: remap})
+}
+
+// NonCollidingMapIDs returns a remapping for RemapMaps which gives each map in ids which is also in taken a new ID which
+// is in neither. This allows maps from two worlds to be merged without either being lost.
+func NonCollidingMapIDs(ids, taken []int64) map[int64]int64 {
+	return nonColliding(ids, taken)
+}
+
+func mapKey(id int64) []byte {
+	return []byte(mapPrefix + strconv.FormatInt(id, 10))
+}
+
+func parseMapKey(key []byte) (int64, bool) {
+	k := string(key)
+	if !strings.HasPrefix(k, mapPrefix) {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(k[len(mapPrefix):], 10, 64)
+
+	return id, err == nil
+}
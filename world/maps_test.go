@@ -0,0 +1,79 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestMaps(t *testing.T) {
+	w, _ := testEntityWorld(t)
+
+	long := func(name string, i int64) nbt.NBTTag {
+		return nbt.NBTTag{Type: nbt.TagLong, Name: name, Value: nbt.Long(i)}
+	}
+
+	for _, m := range []nbt.NBTTag{
+		testCompound("", long(mapIDTag, 1), long(mapParentIDTag, -1)),
+		testCompound("", long(mapIDTag, 2), long(mapParentIDTag, 1)),
+		testCompound("", long(mapIDTag, 3), long(mapParentIDTag, -1)),
+	} {
+		id := childInt(m, mapIDTag)
+		if err := w.putTags(mapKey(id), []nbt.NBTTag{m}); err != nil {
+			t.Fatalf("unexpected error writing map %d: %s", id, err)
+		}
+	}
+
+	// A chest holding a zoomed out copy of map 1
+	chest := testBlockEntity("Chest", 0, 0, 0)
+	_ = chest.SetChild(testList("Items", nbt.TagCompound,
+		testCompound("", nbt.NBTTag{Type: nbt.TagString, Name: "Name", Value: "minecraft:filled_map"},
+			testCompound("tag", long(mapItemIDTag, 2))),
+	))
+
+	key := leveldb.ChunkKey{Tag: leveldb.BlockEntity}.Bytes()
+	if err := w.putTags(key, []nbt.NBTTag{chest}); err != nil {
+		t.Fatalf("unexpected error writing chest: %s", err)
+	}
+
+	unreferenced, err := w.UnreferencedMaps()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(unreferenced) != 1 || unreferenced[0] != 3 {
+		t.Fatalf("expected map 3 to be unreferenced: got %v", unreferenced)
+	}
+
+	ids, err := w.MapIDs()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	remap := NonCollidingMapIDs(ids, []int64{2, 10})
+	if len(remap) != 1 || remap[2] != 11 {
+		t.Fatalf("unexpected remapping: %v", remap)
+	}
+
+	if err := w.RemapMaps(remap); err != nil {
+		t.Fatalf("unexpected error remapping: %s", err)
+	}
+
+	if ids, _ = w.MapIDs(); len(ids) != 3 || ids[2] != 11 {
+		t.Errorf("unexpected map IDs after remapping: %v", ids)
+	}
+
+	root, err := w.singleTagRecord(key)
+	if err != nil {
+		t.Fatalf("unexpected error reading chest: %s", err)
+	}
+
+	if item, _ := root.Path("Items"); childInt(item.List()[0].Tags()[1], mapItemIDTag) != 11 {
+		t.Errorf("map item was not remapped: %+v", item)
+	}
+
+	if unreferenced, _ = w.UnreferencedMaps(); len(unreferenced) != 1 || unreferenced[0] != 3 {
+		t.Errorf("expected only map 3 to be unreferenced after remapping: got %v", unreferenced)
+	}
+}
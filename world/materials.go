@@ -0,0 +1,89 @@
+package world
+
+import (
+	"sort"
+	"strings"
+)
+
+// shulkerBoxSlots is the number of item stacks held by a shulker box.
+const shulkerBoxSlots = 27
+
+// Material is the number of one type of block needed to build something.
+type Material struct {
+	ID    string // The block ID e.g. minecraft:stone
+	Count int
+}
+
+// smallStackSuffixes are the endings of IDs of blocks which stack to 16, and unstackableSuffixes those which don't
+// stack.
+var (
+	smallStackSuffixes  = []string{"sign", "banner"}
+	unstackableSuffixes = []string{"bed", "cake", "shulker_box"}
+)
+
+// StackSize returns the number of the material which fit in one inventory slot.
+func (m Material) StackSize() int {
+	name := strings.TrimPrefix(m.ID, "minecraft:")
+
+	for _, s := range unstackableSuffixes {
+		if strings.HasSuffix(name, s) {
+			return 1
+		}
+	}
+
+	for _, s := range smallStackSuffixes {
+		if strings.HasSuffix(name, s) {
+			return 16
+		}
+	}
+
+	return 64
+}
+
+// Stacks returns the number of full stacks of the material and the number left over.
+func (m Material) Stacks() (stacks, remainder int) {
+	return m.Count / m.StackSize(), m.Count % m.StackSize()
+}
+
+// ShulkerBoxes returns the number of shulker boxes needed to carry the material.
+func (m Material) ShulkerBoxes() int {
+	slots := (m.Count + m.StackSize() - 1) / m.StackSize()
+	return (slots + shulkerBoxSlots - 1) / shulkerBoxSlots
+}
+
+// Materials returns the bill of materials for the given blocks: the number of each type of block, most numerous first.
+// Air is not counted and double slabs are counted as two slabs. Blocks made of two halves, such as doors and beds, are
+// counted once for each half.
+func Materials(blocks []Block) []Material {
+	counts := make(map[string]int)
+
+	for _, b := range blocks {
+		switch {
+		case b.ID == BlockAir || b.ID == "":
+			continue
+		case strings.Contains(b.ID, "double_") && strings.HasSuffix(b.ID, "slab"):
+			counts[strings.Replace(b.ID, "double_", "", 1)] += 2
+		default:
+			counts[b.ID]++
+		}
+	}
+
+	materials := make([]Material, 0, len(counts))
+	for id, n := range counts {
+		materials = append(materials, Material{ID: id, Count: n})
+	}
+
+	sort.Slice(materials, func(i, j int) bool {
+		if materials[i].Count != materials[j].Count {
+			return materials[i].Count > materials[j].Count
+		}
+		return materials[i].ID < materials[j].ID
+	})
+
+	return materials
+}
+
+// Materials returns the bill of materials for the blocks on the clipboard.
+func (c *Clipboard) Materials() []Material {
+	return Materials(c.Blocks)
+}
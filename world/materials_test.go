@@ -0,0 +1,41 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMaterials(t *testing.T) {
+	blocks := []Block{
+		{ID: "minecraft:air"},
+		{ID: "minecraft:oak_double_slab"},
+		{ID: "minecraft:oak_slab"},
+		{ID: "minecraft:stone"},
+		{ID: "minecraft:stone"},
+		{ID: "minecraft:stone"},
+		{ID: "minecraft:bed"},
+	}
+
+	want := []Material{{"minecraft:oak_slab", 3}, {"minecraft:stone", 3}, {"minecraft:bed", 1}}
+	if got := (&Clipboard{Blocks: blocks}).Materials(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected materials %v: got %v", want, got)
+	}
+
+	for _, c := range []struct {
+		m                 Material
+		stacks, remainder int
+		shulkers          int
+	}{
+		{Material{"minecraft:stone", 130}, 2, 2, 1},
+		{Material{"minecraft:stone", 64 * 27}, 27, 0, 1},
+		{Material{"minecraft:stone", 64*27 + 1}, 27, 1, 2},
+		{Material{"minecraft:oak_sign", 20}, 1, 4, 1},
+		{Material{"minecraft:bed", 30}, 30, 0, 2},
+	} {
+		stacks, remainder := c.m.Stacks()
+		if stacks != c.stacks || remainder != c.remainder || c.m.ShulkerBoxes() != c.shulkers {
+			t.Errorf("%v: expected %d stacks + %d in %d shulker boxes: got %d stacks + %d in %d shulker boxes",
+				c.m, c.stacks, c.remainder, c.shulkers, stacks, remainder, c.m.ShulkerBoxes())
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package world
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// ReadMCWorld reads a world exported from the game as an .mcworld file, a zip archive of the world directory, into
+// memory. No file system is used, so worlds can be inspected where there is none, such as in a browser. The world has
+// no directory, so changes to it are only made in memory and can't be saved.
+func ReadMCWorld(r io.ReaderAt, size int64) (*World, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("reading .mcworld: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	var levelDat []byte
+	levelDatDepth := -1
+
+	for _, f := range z.File {
+		name := strings.TrimPrefix(path.Clean(strings.ReplaceAll(f.Name, `\`, "/")), "/")
+		depth := strings.Count(name, "/")
+
+		// The world directory may be the root of the archive or a directory in it
+		isDB := path.Base(path.Dir(name)) == "db"
+		isLevelDat := path.Base(name) == levelDatFileName && (levelDatDepth < 0 || depth < levelDatDepth)
+		if f.FileInfo().IsDir() || (!isDB && !isLevelDat) {
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s from .mcworld: %w", name, err)
+		}
+
+		if isDB {
+			files[path.Base(name)] = data
+			continue
+		}
+		levelDat, levelDatDepth = data, depth
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no world database found in .mcworld")
+	}
+
+	db, err := leveldb.ReadDB(files)
+	if err != nil {
+		return nil, fmt.Errorf("reading world database: %w", err)
+	}
+
+	w := NewFromDB(db)
+
+	if levelDat != nil {
+		version, root, err := parseLevelDat(levelDat)
+		if err != nil {
+			return nil, err
+		}
+		w.level = &levelMetadata{storageVersion: version, root: root}
+	}
+
+	return w, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,105 @@
+//go:build !js
+// +build !js
+
+package world
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// testMCWorld returns the world directory as an .mcworld archive, with the world in a directory named prefix.
+func testMCWorld(t *testing.T, dir, prefix string) []byte {
+	buf := bytes.Buffer{}
+	z := zip.NewWriter(&buf)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		f, err := z.Create(prefix + filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestReadMCWorld(t *testing.T) {
+	dir := testLevelDat(t, nbt.NBTTag{Name: "LevelName", Type: nbt.TagString, Value: "uploaded"}).path
+
+	if err := os.Mkdir(filepath.Join(dir, "db"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := leveldb.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := leveldb.SubChunkKey(0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put(key, mock.SubChunkValue); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, prefix := range []string{"", "My World/"} {
+		data := testMCWorld(t, dir, prefix)
+
+		w, err := ReadMCWorld(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("unexpected error reading with prefix '%s': %s", prefix, err)
+		}
+
+		if name, err := w.Name(); err != nil || name != "uploaded" {
+			t.Errorf("expected name 'uploaded': got '%s' with error %v", name, err)
+		}
+
+		b, err := w.GetBlock(0, 0, 0, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if b.ID != "minecraft:crimson_planks" {
+			t.Errorf("expected minecraft:crimson_planks at 0 0 0: got %s", b.ID)
+		}
+	}
+
+	if _, err := ReadMCWorld(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Errorf("expected an error reading a file which isn't an archive")
+	}
+}
@@ -0,0 +1,152 @@
+package world
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// NearBlock is a block found by FindNearest and its distance from the origin of the search.
+type NearBlock struct {
+	Block
+	Distance float64 // The distance in blocks between the centers of the block and the origin
+}
+
+// FindNearest returns the n saved blocks with the given ID which are closest to the block at x, y, z in a dimension,
+// nearest first. Fewer are returned if there are fewer in the dimension.
+//
+// Chunks are searched in a Spiral from the origin and the search stops once no block in the remaining chunks can be
+// nearer than the blocks found. Sub chunks which are too far away or don't have the block in their palette are not
+// decoded.
+func (w *World) FindNearest(x, y, z, dimension int, id string, n int) ([]NearBlock, error) {
+	if n <= 0 {
+		return []NearBlock{}, nil
+	}
+
+	// The sub chunks of each chunk in the dimension, by their Y index
+	subChunks := make(map[ChunkPos][]int8)
+
+	err := w.eachKey(w.startBudget(), func(key []byte) error {
+		if k, ok := leveldb.ParseChunkKey(key); ok && k.Tag == leveldb.SubChunkPrefix && int(k.Dimension) == dimension {
+			c := ChunkPos{X: int(k.X), Z: int(k.Z), Dimension: dimension}
+			subChunks[c] = append(subChunks[c], k.SubChunkY)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	center := ChunkPos{X: floorDiv(x, chunkSize), Z: floorDiv(z, chunkSize), Dimension: dimension}
+
+	chunks := make([]ChunkPos, 0, len(subChunks))
+	for c := range subChunks {
+		chunks = append(chunks, c)
+	}
+	ScanOrder{Order: Spiral, Center: center}.Sort(chunks)
+
+	found := make([]NearBlock, 0, n+1)
+	idBytes := []byte(id)
+
+	// worst returns the distance a block must be nearer than to be one of the n nearest
+	worst := func() float64 {
+		if len(found) < n {
+			return math.Inf(1)
+		}
+		return found[len(found)-1].Distance
+	}
+
+	for _, c := range chunks {
+		// Every block in a ring of chunks around the center is at least this far away horizontally
+		if ring := spiralRank(c.X-center.X, c.Z-center.Z)[0]; float64((ring-1)*chunkSize+1) > worst() {
+			break
+		}
+
+		for _, sy := range subChunks[c] {
+			k := leveldb.ChunkKey{X: int32(c.X), Z: int32(c.Z), Dimension: int32(dimension),
+				Tag: leveldb.SubChunkPrefix, SubChunkY: sy}
+			ox, oy, oz := subChunkKeyOrigin(k)
+
+			if boxDistance(x, y, z, ox, oy, oz) > worst() {
+				continue
+			}
+
+			value, err := w.db.Get(k.Bytes())
+			if err != nil {
+				return nil, fmt.Errorf("getting sub chunk with key '%x': %w", k.Bytes(), err)
+			}
+
+			// Block IDs are stored as plain strings in the palette, so a sub chunk without the bytes of the ID can't
+			// have the block
+			if !bytes.Contains(value, idBytes) {
+				continue
+			}
+
+			s, err := parseSubChunk(value)
+			if err != nil {
+				return nil, fmt.Errorf("parsing sub chunk with key '%x': %w", k.Bytes(), err)
+			}
+
+			if !s.Blocks.paletteContains(id) {
+				continue
+			}
+
+			for i, p := range s.Blocks.Indices {
+				if s.Blocks.Palette[p].BlockID() != id {
+					continue
+				}
+
+				bx, by, bz := subChunkIndexToVoxel(i)
+				b := s.block(i, ox+bx, oy+by, oz+bz)
+
+				d := distance(x, y, z, b.X, b.Y, b.Z)
+				if d >= worst() {
+					continue
+				}
+
+				found = insertNear(found, NearBlock{Block: b, Distance: d})
+				if len(found) > n {
+					found = found[:n]
+				}
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// insertNear inserts a block into a list sorted by distance. Blocks at the same distance keep the order they were found.
+func insertNear(found []NearBlock, b NearBlock) []NearBlock {
+	i := sort.Search(len(found), func(i int) bool { return found[i].Distance > b.Distance })
+
+	found = append(found, NearBlock{})
+	copy(found[i+1:], found[i:])
+	found[i] = b
+
+	return found
+}
+
+// distance returns the distance between two blocks.
+func distance(x1, y1, z1, x2, y2, z2 int) float64 {
+	dx, dy, dz := float64(x2-x1), float64(y2-y1), float64(z2-z1)
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// boxDistance returns the distance from a block to the nearest block of the sub chunk with the given origin.
+func boxDistance(x, y, z, ox, oy, oz int) float64 {
+	gap := func(v, min int) int {
+		switch {
+		case v < min:
+			return min - v
+		case v > min+chunkSize-1:
+			return v - (min + chunkSize - 1)
+		}
+		return 0
+	}
+
+	return distance(0, 0, 0, gap(x, ox), gap(y, oy), gap(z, oz))
+}
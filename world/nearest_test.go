@@ -0,0 +1,90 @@
+package world
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestFindNearest(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	put := func(cx, cz int32, cy int8, d int32, blocks map[[3]int]string) {
+		_ = db.Put(leveldb.ChunkKey{X: cx, Z: cz, Dimension: d, Tag: leveldb.SubChunkPrefix, SubChunkY: cy}.Bytes(),
+			testSubChunkValue(t, blocks))
+	}
+
+	const diamond = "minecraft:diamond_ore"
+
+	put(0, 0, 0, 0, map[[3]int]string{{15, 15, 15}: "minecraft:stone"})
+	put(1, 0, 0, 0, map[[3]int]string{{0, 2, 0}: diamond, {5, 0, 0}: diamond})
+	put(-3, 2, 1, 0, map[[3]int]string{{8, 8, 8}: diamond})
+	put(0, 0, -2, 0, map[[3]int]string{{3, 3, 3}: diamond})
+	put(9, 9, 0, 0, map[[3]int]string{{0, 0, 0}: diamond})
+	put(0, 0, 0, 1, map[[3]int]string{{1, 1, 1}: diamond}) // In the Nether
+
+	for _, n := range []int{1, 2, 3, 10} {
+		got, err := w.FindNearest(2, 1, 3, 0, diamond, n)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		// Every matching block sorted by distance
+		all, err := w.FindBlocks(func(b Block, dimension int) (bool, error) {
+			return b.ID == diamond && dimension == 0, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(all, func(i, j int) bool {
+			return distance(2, 1, 3, all[i].X, all[i].Y, all[i].Z) <
+				distance(2, 1, 3, all[j].X, all[j].Y, all[j].Z)
+		})
+
+		want := n
+		if want > len(all) {
+			want = len(all)
+		}
+
+		if len(got) != want {
+			t.Fatalf("n %d: expected %d blocks: got %+v", n, want, got)
+		}
+
+		for i, b := range got {
+			if b.Block != all[i] {
+				t.Errorf("n %d: block %d: expected %+v: got %+v", n, i, all[i], b.Block)
+			}
+
+			d := distance(2, 1, 3, all[i].X, all[i].Y, all[i].Z)
+			if math.Abs(b.Distance-d) > 1e-9 {
+				t.Errorf("n %d: block %d: expected distance %f: got %f", n, i, d, b.Distance)
+			}
+		}
+	}
+
+	got, err := w.FindNearest(0, 0, 0, 1, diamond, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].X != 1 || got[0].Distance != math.Sqrt(3) {
+		t.Errorf("expected the one diamond in the Nether: got %+v", got)
+	}
+
+	if got, _ := w.FindNearest(0, 0, 0, 0, "minecraft:emerald_ore", 5); len(got) != 0 {
+		t.Errorf("expected no emerald ore: got %+v", got)
+	}
+}
+
+func TestBoxDistance(t *testing.T) {
+	if d := boxDistance(5, 5, 5, 0, 0, 0); d != 0 {
+		t.Errorf("expected 0 inside the sub chunk: got %f", d)
+	}
+
+	if d := boxDistance(-3, 19, 5, 0, 0, 0); d != 5 {
+		t.Errorf("expected 5: got %f", d)
+	}
+}
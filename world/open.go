@@ -0,0 +1,17 @@
+//go:build !js
+// +build !js
+
+package world
+
+import "github.com/danhale-git/mine/leveldb"
+
+// openDB opens the database of the world in the given directory with the pure Go LevelDB implementation, which needs
+// no cgo so the package cross compiles to any platform Go supports.
+func openDB(worldPath string) (LevelDB, error) {
+	db, err := leveldb.Open(worldPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
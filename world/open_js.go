@@ -0,0 +1,11 @@
+//go:build js
+// +build js
+
+package world
+
+import "errors"
+
+// openDB fails, as there is no file system to open a world directory in. Worlds are read with ReadMCWorld instead.
+func openDB(worldPath string) (LevelDB, error) {
+	return nil, errors.New("world directories can't be opened in WebAssembly: use ReadMCWorld")
+}
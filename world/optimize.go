@@ -0,0 +1,106 @@
+package world
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// OptimizeReport summarises the result of World.Optimize.
+type OptimizeReport struct {
+	SubChunks   int // The number of sub chunks read
+	Rewritten   int // The number of sub chunks which were written back
+	BytesBefore int // The total size of all sub chunk values before optimizing
+	BytesAfter  int // The total size of all sub chunk values after optimizing
+}
+
+// compacter is implemented by databases which can discard deleted and overwritten data.
+type compacter interface {
+	CompactRange(start, limit []byte) error
+}
+
+// optimizeCompactBatch is the number of sub chunks written back by Optimize between compactions of the keys written.
+const optimizeCompactBatch = 1024
+
+// Optimize re-encodes every sub chunk in the world with palette normalization, writing back those which became smaller.
+// If the database supports compaction, the range of keys written back is compacted after every batch of sub chunks.
+func (w *World) Optimize() (OptimizeReport, error) {
+	report := OptimizeReport{}
+
+	// The lowest and highest keys written back since the last compaction
+	var first, last []byte
+	pending := 0
+
+	compact := func() error {
+		c, ok := w.backend().(compacter)
+		if !ok || pending == 0 {
+			return nil
+		}
+
+		if err := c.CompactRange(first, append(last, 0)); err != nil {
+			return fmt.Errorf("compacting database: %w", err)
+		}
+		first, last, pending = nil, nil, 0
+
+		return nil
+	}
+
+	err := w.eachKey(w.startBudget(), func(key []byte) error {
+		if k, ok := leveldb.ParseChunkKey(key); !ok || k.Tag != leveldb.SubChunkPrefix {
+			return nil
+		}
+
+		value, err := w.db.Get(key)
+		if err != nil {
+			return fmt.Errorf("getting sub chunk with key '%x': %w", key, err)
+		}
+
+		report.SubChunks++
+		report.BytesBefore += len(value)
+
+		optimized, err := ReEncodeSubChunk(value, true)
+		if err != nil {
+			return fmt.Errorf("re-encoding sub chunk with key '%x': %w", key, err)
+		}
+
+		if len(optimized) >= len(value) {
+			report.BytesAfter += len(value)
+			return nil
+		}
+
+		if err := w.put(key, optimized); err != nil {
+			return fmt.Errorf("putting sub chunk with key '%x': %w", key, err)
+		}
+
+		report.Rewritten++
+		report.BytesAfter += len(optimized)
+
+		// The key is only valid until this function returns, so the range is kept in copies
+		if first == nil || bytes.Compare(key, first) < 0 {
+			first = append([]byte(nil), key...)
+		}
+		if last == nil || bytes.Compare(key, last) > 0 {
+			last = append([]byte(nil), key...)
+		}
+
+		if pending++; pending == optimizeCompactBatch {
+			return compact()
+		}
+
+		return nil
+	})
+
+	// Cached sub chunks are still valid as normalization doesn't change any blocks
+
+	// Sub chunks written back before the time budget ran out are still compacted
+	if err != nil && !errors.Is(err, ErrTimeBudget) {
+		return report, err
+	}
+	if cerr := compact(); cerr != nil {
+		return report, cerr
+	}
+
+	return report, err
+}
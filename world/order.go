@@ -0,0 +1,210 @@
+package world
+
+import (
+	"sort"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// Order is an order in which scans visit chunks.
+type Order int
+
+// Chunk orders.
+const (
+	// KeyOrder visits chunks in the order of their keys in the database, which is fastest but not spatial.
+	KeyOrder Order = iota
+	// RowMajor visits rows of chunks from north to south, each from west to east.
+	RowMajor
+	// Spiral visits square rings of chunks outwards from a center chunk, so the nearest chunks are visited first.
+	Spiral
+	// Hilbert visits chunks along a Hilbert curve, so chunks visited one after another are usually neighbours.
+	Hilbert
+)
+
+var orderNames = []string{"key", "row", "spiral", "hilbert"}
+
+func (o Order) String() string {
+	return enumName(orderNames, int(o), "Order")
+}
+
+// ParseOrder returns the order with the given name, e.g. spiral.
+func ParseOrder(s string) (Order, error) {
+	i, err := parseEnum(orderNames, s, "order")
+	return Order(i), err
+}
+
+// ScanOrder is the order in which scans such as FindBlocks and Stats visit sub chunks. Chunks in each dimension are
+// visited in the order, dimensions in ascending order and the sub chunks of each chunk from the bottom up.
+type ScanOrder struct {
+	Order Order
+	// Center is the chunk a Spiral starts from. Its dimension is ignored.
+	Center ChunkPos
+}
+
+// Sort sorts chunk positions into the scan order. Chunks in KeyOrder are sorted by dimension, x and z, as in Chunks.
+func (o ScanOrder) Sort(chunks []ChunkPos) {
+	var rank func(c ChunkPos) [2]int
+
+	switch o.Order {
+	case RowMajor:
+		rank = func(c ChunkPos) [2]int { return [2]int{c.Z, c.X} }
+	case Spiral:
+		rank = func(c ChunkPos) [2]int { return spiralRank(c.X-o.Center.X, c.Z-o.Center.Z) }
+	case Hilbert:
+		rank = hilbertRanker(chunks)
+	default:
+		rank = func(c ChunkPos) [2]int { return [2]int{c.X, c.Z} }
+	}
+
+	sort.Slice(chunks, func(i, j int) bool {
+		a, b := chunks[i], chunks[j]
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+
+		ra, rb := rank(a), rank(b)
+		if ra[0] != rb[0] {
+			return ra[0] < rb[0]
+		}
+		return ra[1] < rb[1]
+	})
+}
+
+// spiralRank returns the ring of a chunk at the given offset from the center, and its position clockwise around the
+// ring starting from the north west corner.
+func spiralRank(dx, dz int) [2]int {
+	r := maxInt(absInt(dx), absInt(dz))
+
+	switch {
+	case r == 0:
+		return [2]int{0, 0}
+	case dz == -r && dx < r:
+		return [2]int{r, dx + r}
+	case dx == r && dz < r:
+		return [2]int{r, 2*r + dz + r}
+	case dz == r && dx > -r:
+		return [2]int{r, 4*r + r - dx}
+	}
+
+	return [2]int{r, 6*r + r - dz}
+}
+
+// hilbertRanker returns a function giving the distance along a Hilbert curve covering the bounds of the chunks.
+func hilbertRanker(chunks []ChunkPos) func(c ChunkPos) [2]int {
+	if len(chunks) == 0 {
+		return func(ChunkPos) [2]int { return [2]int{} }
+	}
+
+	minX, minZ, maxX, maxZ := chunks[0].X, chunks[0].Z, chunks[0].X, chunks[0].Z
+	for _, c := range chunks {
+		minX, maxX = minInt(minX, c.X), maxInt(maxX, c.X)
+		minZ, maxZ = minInt(minZ, c.Z), maxInt(maxZ, c.Z)
+	}
+
+	n := 1
+	for n <= maxX-minX || n <= maxZ-minZ {
+		n *= 2
+	}
+
+	return func(c ChunkPos) [2]int {
+		return [2]int{hilbertDistance(n, c.X-minX, c.Z-minZ), 0}
+	}
+}
+
+// hilbertDistance returns the distance of x, y along a Hilbert curve filling an n by n square, where n is a power of 2.
+func hilbertDistance(n, x, y int) int {
+	d := 0
+
+	for s := n / 2; s > 0; s /= 2 {
+		rx, ry := 0, 0
+		if x&s > 0 {
+			rx = 1
+		}
+		if y&s > 0 {
+			ry = 1
+		}
+
+		d += s * s * ((3 * rx) ^ ry)
+
+		// Rotate the quadrant so the curve within it has the same orientation as the whole
+		if ry == 0 {
+			if rx == 1 {
+				x, y = s-1-x, s-1-y
+			}
+			x, y = y, x
+		}
+	}
+
+	return d
+}
+
+// SetScanOrder sets the order in which scans such as FindBlocks and Stats visit sub chunks. Orders other than KeyOrder
+// hold the position of every chunk in memory while scanning.
+func (w *World) SetScanOrder(o ScanOrder) {
+	w.scanOrder = o
+}
+
+// eachSubChunkKey calls f with the key of every sub chunk in the world in the world's scan order, stopping at the
+// first error. A *PartialResultError is returned if the time budget b runs out.
+func (w *World) eachSubChunkKey(b *scanBudget, f func(k leveldb.ChunkKey, key []byte) error) error {
+	if w.scanOrder.Order == KeyOrder {
+		return w.eachKey(b, func(key []byte) error {
+			k, ok := leveldb.ParseChunkKey(key)
+			if !ok || k.Tag != leveldb.SubChunkPrefix {
+				return nil
+			}
+
+			return f(k, key)
+		})
+	}
+
+	// The sub chunks of each chunk, by their Y index
+	subChunks := make(map[ChunkPos][]int8)
+
+	err := w.eachKey(b, func(key []byte) error {
+		if k, ok := leveldb.ParseChunkKey(key); ok && k.Tag == leveldb.SubChunkPrefix {
+			c := ChunkPos{X: int(k.X), Z: int(k.Z), Dimension: int(k.Dimension)}
+			subChunks[c] = append(subChunks[c], k.SubChunkY)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	chunks := make([]ChunkPos, 0, len(subChunks))
+	for c := range subChunks {
+		chunks = append(chunks, c)
+	}
+	w.scanOrder.Sort(chunks)
+
+	for i, c := range chunks {
+		if err := b.check(i, len(chunks), "chunks"); err != nil {
+			return err
+		}
+
+		ys := subChunks[c]
+		sort.Slice(ys, func(i, j int) bool { return ys[i] < ys[j] })
+
+		for _, y := range ys {
+			k := leveldb.ChunkKey{X: int32(c.X), Z: int32(c.Z), Dimension: int32(c.Dimension),
+				Tag: leveldb.SubChunkPrefix, SubChunkY: y}
+
+			if err := f(k, k.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// absInt returns the absolute value of i.
+func absInt(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
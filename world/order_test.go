@@ -0,0 +1,132 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+// testGrid returns the positions of a square of chunks in the overworld with the given radius around 0, 0.
+func testGrid(radius int) []ChunkPos {
+	chunks := make([]ChunkPos, 0)
+	for x := -radius; x <= radius; x++ {
+		for z := -radius; z <= radius; z++ {
+			chunks = append(chunks, ChunkPos{X: x, Z: z})
+		}
+	}
+
+	return chunks
+}
+
+func TestScanOrderSpiral(t *testing.T) {
+	chunks := testGrid(2)
+	ScanOrder{Order: Spiral}.Sort(chunks)
+
+	want := []ChunkPos{
+		{0, 0, 0},
+		{-1, -1, 0}, {0, -1, 0}, {1, -1, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {-1, 1, 0}, {-1, 0, 0},
+	}
+	if !reflect.DeepEqual(chunks[:9], want) {
+		t.Errorf("expected the center then the first ring clockwise: got %v", chunks[:9])
+	}
+
+	for i := 1; i < len(chunks); i++ {
+		a, b := chunks[i-1], chunks[i]
+		if maxInt(absInt(a.X), absInt(a.Z)) > maxInt(absInt(b.X), absInt(b.Z)) {
+			t.Errorf("chunk %v visited before nearer chunk %v", a, b)
+		}
+	}
+
+	// The spiral is around the center
+	chunks = testGrid(2)
+	ScanOrder{Order: Spiral, Center: ChunkPos{X: 2, Z: -2}}.Sort(chunks)
+	if chunks[0] != (ChunkPos{X: 2, Z: -2}) {
+		t.Errorf("expected the center first: got %v", chunks[0])
+	}
+}
+
+func TestScanOrderHilbert(t *testing.T) {
+	chunks := make([]ChunkPos, 0)
+	for x := 10; x < 18; x++ {
+		for z := -4; z < 4; z++ {
+			chunks = append(chunks, ChunkPos{X: x, Z: z})
+		}
+	}
+
+	ScanOrder{Order: Hilbert}.Sort(chunks)
+
+	seen := make(map[ChunkPos]bool)
+	for i, c := range chunks {
+		seen[c] = true
+
+		if i == 0 {
+			continue
+		}
+
+		// Every step along a curve filling a square is to a neighbour
+		if p := chunks[i-1]; absInt(p.X-c.X)+absInt(p.Z-c.Z) != 1 {
+			t.Errorf("step %d from %v to %v is not to a neighbour", i, p, c)
+		}
+	}
+
+	if len(seen) != 64 {
+		t.Errorf("expected 64 distinct chunks: got %d", len(seen))
+	}
+}
+
+func TestScanOrderRowMajor(t *testing.T) {
+	chunks := []ChunkPos{{1, 1, 0}, {0, 0, 1}, {1, 0, 0}, {0, 1, 0}, {0, 0, 0}}
+	ScanOrder{Order: RowMajor}.Sort(chunks)
+
+	want := []ChunkPos{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}, {0, 0, 1}}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("expected %v: got %v", want, chunks)
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	for _, o := range []Order{KeyOrder, RowMajor, Spiral, Hilbert} {
+		if got, err := ParseOrder(o.String()); err != nil || got != o {
+			t.Errorf("%s: expected to parse: got %s, %v", o, got, err)
+		}
+	}
+
+	if _, err := ParseOrder("random"); err == nil {
+		t.Errorf("expected error for unknown order")
+	}
+}
+
+func TestFindBlocksSpiral(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	for _, c := range testGrid(3) {
+		for _, y := range []int8{1, 0} {
+			_ = db.Put(leveldb.ChunkKey{X: int32(c.X), Z: int32(c.Z), Tag: leveldb.SubChunkPrefix, SubChunkY: y}.Bytes(),
+				testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:diamond_ore"}))
+		}
+	}
+
+	w.SetScanOrder(ScanOrder{Order: Spiral, Center: ChunkPos{X: -2, Z: 1}})
+
+	found := make([]Block, 0)
+	err := w.ForEachBlock(func(b Block, dimension int) (bool, error) {
+		return b.ID == "minecraft:diamond_ore", nil
+	}, func(b Block) error {
+		found = append(found, b)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []Block{
+		testBlock("minecraft:diamond_ore", -32, 0, 16),
+		testBlock("minecraft:diamond_ore", -32, 16, 16),
+	}
+	if len(found) != 2*49 || !reflect.DeepEqual(found[:2], want) {
+		t.Errorf("expected 98 blocks starting with the center chunk from the bottom up: got %d: %v", len(found), found[:2])
+	}
+}
@@ -0,0 +1,109 @@
+package world
+
+import (
+	"bytes"
+	"hash/fnv"
+	"sync"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// paletteCacheSize is the number of decoded palettes kept. Whole world scans meet the same few palettes, such as
+// stone with ores or air above water, in many sub chunks.
+const paletteCacheSize = 4096
+
+// palettes caches decoded sub chunk palettes by a hash of their NBT, so palettes shared by many sub chunks are only
+// decoded once. Palettes are never changed in place, only replaced or appended to, so sub chunks can share them.
+var palettes = struct {
+	sync.Mutex
+	entries      map[uint64]cachedPalette
+	hits, misses int
+}{
+	entries: make(map[uint64]cachedPalette),
+}
+
+// cachedPalette is a decoded palette and the bytes it was decoded from, to rule out hash collisions, with the bytes of
+// each entry.
+type cachedPalette struct {
+	data    []byte
+	palette []nbt.NBTTag
+	encoded []string
+}
+
+// PaletteCacheStats counts the sub chunk palettes taken from the decoded palette cache and the palettes decoded.
+type PaletteCacheStats struct {
+	Hits, Misses int
+	Entries      int // The number of palettes in the cache
+}
+
+// HitRate returns the fraction of palettes taken from the cache, or 0 if no palettes have been read.
+func (s PaletteCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// PaletteCache returns the statistics of the decoded palette cache, which is shared by every world, since the program
+// started or the cache was last reset.
+func PaletteCache() PaletteCacheStats {
+	palettes.Lock()
+	defer palettes.Unlock()
+
+	return PaletteCacheStats{Hits: palettes.hits, Misses: palettes.misses, Entries: len(palettes.entries)}
+}
+
+// ResetPaletteCache empties the decoded palette cache and zeroes its statistics.
+func ResetPaletteCache() {
+	palettes.Lock()
+	defer palettes.Unlock()
+
+	palettes.entries = make(map[uint64]cachedPalette)
+	palettes.hits, palettes.misses = 0, 0
+}
+
+// cachedPaletteFor returns the palette decoded from data and the NBT of each entry, if it is in the cache.
+func cachedPaletteFor(data []byte) ([]nbt.NBTTag, []string, bool) {
+	h := paletteHash(data)
+
+	palettes.Lock()
+	defer palettes.Unlock()
+
+	c, ok := palettes.entries[h]
+	if !ok || !bytes.Equal(c.data, data) {
+		palettes.misses++
+		return nil, nil, false
+	}
+
+	palettes.hits++
+
+	// The capacity is limited so that appending to the palette copies it rather than changing the cached one
+	return c.palette[:len(c.palette):len(c.palette)], c.encoded[:len(c.encoded):len(c.encoded)], true
+}
+
+// cachePalette adds a palette decoded from data, and the NBT of each entry, to the cache. The cache is emptied when it
+// is full.
+func cachePalette(data []byte, palette []nbt.NBTTag, encoded []string) {
+	h := paletteHash(data)
+
+	palettes.Lock()
+	defer palettes.Unlock()
+
+	if len(palettes.entries) >= paletteCacheSize {
+		palettes.entries = make(map[uint64]cachedPalette)
+	}
+
+	palettes.entries[h] = cachedPalette{
+		data:    data,
+		palette: palette[:len(palette):len(palette)],
+		encoded: encoded[:len(encoded):len(encoded)],
+	}
+}
+
+func paletteHash(data []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+
+	return h.Sum64()
+}
@@ -0,0 +1,51 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestPaletteCache(t *testing.T) {
+	ResetPaletteCache()
+
+	first, err := parseSubChunk(mock.SubChunkValue)
+	if err != nil {
+		t.Fatalf("unexpected error parsing sub chunk: %s", err)
+	}
+
+	// The block and water logged palettes are decoded
+	if s := PaletteCache(); s.Hits != 0 || s.Misses != 2 || s.Entries != 2 {
+		t.Errorf("expected 2 misses and 2 entries: got %+v", s)
+	}
+
+	second, err := parseSubChunk(mock.SubChunkValue)
+	if err != nil {
+		t.Fatalf("unexpected error parsing sub chunk again: %s", err)
+	}
+
+	s := PaletteCache()
+	if s.Hits != 2 || s.Misses != 2 || s.HitRate() != 0.5 {
+		t.Errorf("expected 2 hits and 2 misses: got %+v", s)
+	}
+
+	if !reflect.DeepEqual(first, second) {
+		t.Error("expected the cached palettes to be the same as the decoded ones")
+	}
+
+	// Adding to one sub chunk's palette must not change the cached palette
+	sub := &SubChunk{data: first}
+	sub.setAt(0, 0, 0, Block{ID: "minecraft:diamond_block"})
+
+	third, _ := parseSubChunk(mock.SubChunkValue)
+	if len(third.Blocks.Palette) != len(second.Blocks.Palette) {
+		t.Errorf("expected the cached palette to have %d entries: got %d",
+			len(second.Blocks.Palette), len(third.Blocks.Palette))
+	}
+
+	ResetPaletteCache()
+	if s := PaletteCache(); s != (PaletteCacheStats{}) || s.HitRate() != 0 {
+		t.Errorf("expected empty stats after resetting: got %+v", s)
+	}
+}
@@ -0,0 +1,277 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// playerEyeHeight is the height of a player's eyes above their feet. The position saved in player records is the
+// position of the eyes.
+const playerEyeHeight = 1.62
+
+// localPlayerKey is the key of the record of the player who owns a single player world.
+const localPlayerKey = "~local_player"
+
+// serverPlayerPrefix is the prefix of the records of players who have joined a multiplayer world, followed by an ID.
+const serverPlayerPrefix = "player_server_"
+
+// ErrPlayerNotFound is returned when no player record matches a player.
+var ErrPlayerNotFound = errors.New("player not found")
+
+// PlayerPosition is where a player is and where they respawn, as saved in their player record.
+type PlayerPosition struct {
+	Key string // The key of the player record
+
+	X, Y, Z   float64 // The position of the player's feet
+	Dimension int
+
+	// The respawn point set by a bed or respawn anchor. HasSpawn is false if the player respawns at the world spawn.
+	SpawnX, SpawnY, SpawnZ int
+	SpawnDimension         int
+	HasSpawn               bool
+}
+
+// PlayerKey returns the key of the record of a player. The player may be "local" for the owner of a single player
+// world, a full record key, or the ID following "player_server_" in a multiplayer world. Player names are not saved
+// in the world, so can't be used.
+func (w *World) PlayerKey(player string) (string, error) {
+	keys, err := w.playerKeys(w.startBudget())
+	if err != nil {
+		return "", err
+	}
+
+	want := player
+	switch {
+	case player == "local":
+		want = localPlayerKey
+	case !strings.HasPrefix(player, "player_") && player != localPlayerKey:
+		want = serverPlayerPrefix + player
+	}
+
+	i := sort.SearchStrings(keys, want)
+	if i == len(keys) || keys[i] != want {
+		return "", fmt.Errorf("%w: %s", ErrPlayerNotFound, player)
+	}
+
+	return want, nil
+}
+
+// PlayerPositions returns the position and respawn point of every player.
+func (w *World) PlayerPositions() ([]PlayerPosition, error) {
+	keys, err := w.playerKeys(w.startBudget())
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]PlayerPosition, 0, len(keys))
+
+	for _, k := range keys {
+		root, err := w.singleTagRecord([]byte(k))
+		if err != nil {
+			return nil, err
+		}
+		if root == nil || isIdentityRecord(*root) {
+			continue
+		}
+
+		positions = append(positions, parsePlayerPosition(k, *root))
+	}
+
+	return positions, nil
+}
+
+// parsePlayerPosition returns the position saved in the root tag of the player record with the given key.
+func parsePlayerPosition(key string, root nbt.NBTTag) PlayerPosition {
+	e := parseEntity(root)
+	p := PlayerPosition{
+		Key: key,
+		X:   e.X, Y: e.Y - playerEyeHeight, Z: e.Z,
+		Dimension:      int(childInt(root, "DimensionId")),
+		SpawnX:         int(childInt(root, "SpawnX")),
+		SpawnY:         int(childInt(root, "SpawnY")),
+		SpawnZ:         int(childInt(root, "SpawnZ")),
+		SpawnDimension: int(childInt(root, "SpawnDimension")),
+	}
+
+	// The game saves a Y of -1 when the player has no respawn point
+	_, ok := root.Child("SpawnY")
+	p.HasSpawn = ok && p.SpawnY != -1
+
+	return p
+}
+
+// Player is a player record: where the player is, what they carry and their experience.
+type Player struct {
+	PlayerPosition
+
+	Inventory    []Item  // The hotbar in slots 0 to 8 and the rest of the inventory in 9 to 35
+	EnderChest   []Item  // The ender chest, in slots 0 to 26
+	Armor        [4]Item // The head, chest, legs and feet slots, which have no Name when empty
+	Offhand      Item    // The item in the off hand, which has no Name if there is none
+	SelectedSlot int     // The hotbar slot of the held item
+
+	Level         int     // The experience level
+	LevelProgress float64 // The fraction of the experience needed for the next level
+
+	NBT nbt.NBTTag // The full root tag of the player record
+}
+
+// LocalPlayer returns the player who owns a single player world. ErrPlayerNotFound is returned if the world has no
+// local player, such as a world which has only been played on a server.
+func (w *World) LocalPlayer() (Player, error) {
+	root, err := w.singleTagRecord([]byte(localPlayerKey))
+	if err != nil {
+		return Player{}, err
+	}
+	if root == nil {
+		return Player{}, fmt.Errorf("%w: %s", ErrPlayerNotFound, localPlayerKey)
+	}
+
+	return parsePlayer(localPlayerKey, *root), nil
+}
+
+// Players returns every player in the world, including the local player, in the order of their record keys.
+func (w *World) Players() ([]Player, error) {
+	keys, err := w.playerKeys(w.startBudget())
+	if err != nil {
+		return nil, err
+	}
+
+	players := make([]Player, 0, len(keys))
+
+	for _, k := range keys {
+		root, err := w.singleTagRecord([]byte(k))
+		if err != nil {
+			return nil, err
+		}
+		if root == nil || isIdentityRecord(*root) {
+			continue
+		}
+
+		players = append(players, parsePlayer(k, *root))
+	}
+
+	return players, nil
+}
+
+// parsePlayer returns the player saved in the root tag of the player record with the given key.
+func parsePlayer(key string, root nbt.NBTTag) Player {
+	p := Player{
+		PlayerPosition: parsePlayerPosition(key, root),
+		Inventory:      slotItems(root, "Inventory"),
+		EnderChest:     slotItems(root, "EnderChestInventory"),
+		SelectedSlot:   int(childInt(root, "SelectedInventorySlot")),
+		Level:          int(childInt(root, "PlayerLevel")),
+		NBT:            root,
+	}
+
+	if t, ok := root.Child("PlayerLevelProgress"); ok {
+		p.LevelProgress, _ = t.Float()
+	}
+
+	if armor, ok := root.Child("Armor"); ok {
+		for i, t := range armor.List() {
+			if i < len(p.Armor) {
+				p.Armor[i] = ParseItem(t)
+			}
+		}
+	}
+
+	if offhand, ok := root.Child("Offhand"); ok {
+		if items := offhand.List(); len(items) > 0 {
+			p.Offhand = ParseItem(items[0])
+		}
+	}
+
+	return p
+}
+
+// slotItems returns the items in the list tag with the given name. Empty slots are not returned.
+func slotItems(root nbt.NBTTag, name string) []Item {
+	items := make([]Item, 0)
+
+	list, ok := root.Child(name)
+	if !ok {
+		return items
+	}
+
+	for _, t := range list.List() {
+		if i := ParseItem(t); i.Name != "" && i.Count > 0 {
+			items = append(items, i)
+		}
+	}
+
+	return items
+}
+
+// TeleportPlayer moves the player with the given record key so that their feet are at the given position, for example
+// to rescue a player stuck in a broken chunk. Their motion and fall distance are reset.
+func (w *World) TeleportPlayer(key string, x, y, z float64, dimension int) error {
+	if _, ok := subChunkRanges[dimension]; !ok {
+		return fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	return w.updatePlayer(key, func(root *nbt.NBTTag) error {
+		pos := nbt.NBTTag{Type: nbt.TagList, Name: "Pos", Value: map[string]interface{}{"tagListType": nbt.TagFloat}}
+		if err := pos.SetList([]nbt.NBTTag{
+			{Type: nbt.TagFloat, Value: x},
+			{Type: nbt.TagFloat, Value: y + playerEyeHeight},
+			{Type: nbt.TagFloat, Value: z},
+		}); err != nil {
+			return fmt.Errorf("setting position: %w", err)
+		}
+
+		if err := root.SetChild(pos); err != nil {
+			return fmt.Errorf("setting position: %w", err)
+		}
+
+		if err := root.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: "DimensionId", Value: float64(dimension)}); err != nil {
+			return fmt.Errorf("setting dimension: %w", err)
+		}
+
+		return resetFall(root)
+	})
+}
+
+// SetPlayerSpawn sets the respawn point of the player with the given record key, as if they had slept in a bed there.
+func (w *World) SetPlayerSpawn(key string, x, y, z, dimension int) error {
+	if _, ok := subChunkRanges[dimension]; !ok {
+		return fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	return w.updatePlayer(key, func(root *nbt.NBTTag) error {
+		for _, t := range []struct {
+			name  string
+			value int
+		}{
+			{"SpawnX", x}, {"SpawnY", y}, {"SpawnZ", z}, {"SpawnDimension", dimension},
+		} {
+			if err := root.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: t.name, Value: float64(t.value)}); err != nil {
+				return fmt.Errorf("setting %s: %w", t.name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// updatePlayer calls update with the root tag of a player record and saves the result.
+func (w *World) updatePlayer(key string, update func(root *nbt.NBTTag) error) error {
+	root, err := w.singleTagRecord([]byte(key))
+	if err != nil {
+		return err
+	}
+	if root == nil {
+		return fmt.Errorf("%w: %s", ErrPlayerNotFound, key)
+	}
+
+	if err := update(root); err != nil {
+		return fmt.Errorf("updating player %s: %w", key, err)
+	}
+
+	return w.putTags([]byte(key), []nbt.NBTTag{*root})
+}
@@ -0,0 +1,172 @@
+package world
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestTeleportPlayer(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	for _, k := range []string{"~local_player", "player_server_abc"} {
+		p := testEntity("minecraft:player", 1, 0.5, 300, 0.5)
+		_ = p.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: "SpawnY", Value: float64(-1)})
+		_ = p.SetChild(nbt.NBTTag{Type: nbt.TagFloat, Name: "FallDistance", Value: 250.0})
+
+		value, err := nbt.Encode([]nbt.NBTTag{p})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = db.Put([]byte(k), value)
+	}
+
+	for player, want := range map[string]string{
+		"local":             "~local_player",
+		"abc":               "player_server_abc",
+		"player_server_abc": "player_server_abc",
+	} {
+		if got, err := w.PlayerKey(player); err != nil || got != want {
+			t.Errorf("expected key %s for %s: got %s, %v", want, player, got, err)
+		}
+	}
+
+	if _, err := w.PlayerKey("steve"); !errors.Is(err, ErrPlayerNotFound) {
+		t.Errorf("expected ErrPlayerNotFound: got %v", err)
+	}
+
+	if err := w.TeleportPlayer("player_server_abc", 10.5, 64, -20.5, int(Nether)); err != nil {
+		t.Fatalf("unexpected error teleporting: %s", err)
+	}
+
+	if err := w.SetPlayerSpawn("player_server_abc", 10, 64, -21, int(Overworld)); err != nil {
+		t.Fatalf("unexpected error setting spawn: %s", err)
+	}
+
+	positions, err := w.PlayerPositions()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(positions) != 2 {
+		t.Fatalf("expected 2 players: got %d", len(positions))
+	}
+
+	if p := positions[0]; p.Key != "player_server_abc" || p.X != 10.5 || math.Abs(p.Y-64) > 0.001 ||
+		p.Z != -20.5 || p.Dimension != int(Nether) {
+		t.Errorf("unexpected position after teleporting: %+v", p)
+	}
+
+	if p := positions[0]; !p.HasSpawn || p.SpawnX != 10 || p.SpawnY != 64 || p.SpawnZ != -21 {
+		t.Errorf("unexpected spawn after setting it: %+v", p)
+	}
+
+	if positions[1].HasSpawn {
+		t.Errorf("expected the local player to have no spawn: %+v", positions[1])
+	}
+
+	root, _ := w.singleTagRecord([]byte("player_server_abc"))
+	f, _ := root.Child("FallDistance")
+	if d, _ := f.Float(); d != 0 {
+		t.Errorf("expected fall distance to be reset: got %f", d)
+	}
+
+	if err := w.TeleportPlayer("~missing", 0, 0, 0, 0); !errors.Is(err, ErrPlayerNotFound) {
+		t.Errorf("expected ErrPlayerNotFound teleporting a missing player: got %v", err)
+	}
+}
+
+func TestPlayers(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	if _, err := w.LocalPlayer(); !errors.Is(err, ErrPlayerNotFound) {
+		t.Errorf("expected ErrPlayerNotFound without a local player: got %v", err)
+	}
+
+	intTag := func(name string, v int) nbt.NBTTag {
+		return nbt.NBTTag{Type: nbt.TagInt, Name: name, Value: float64(v)}
+	}
+	str := func(name, v string) nbt.NBTTag { return nbt.NBTTag{Type: nbt.TagString, Name: name, Value: v} }
+	item := func(id string, count, slot int) nbt.NBTTag {
+		return testCompound("",
+			str("Name", id),
+			nbt.NBTTag{Type: nbt.TagByte, Name: "Count", Value: float64(count)},
+			nbt.NBTTag{Type: nbt.TagByte, Name: "Slot", Value: float64(slot)},
+		)
+	}
+
+	local := testEntity("minecraft:player", 1, 0.5, 65.62, 0.5)
+	_ = local.SetChild(intTag("PlayerLevel", 12))
+	_ = local.SetChild(nbt.NBTTag{Type: nbt.TagFloat, Name: "PlayerLevelProgress", Value: 0.25})
+	_ = local.SetChild(intTag("SelectedInventorySlot", 2))
+	_ = local.SetChild(testList("Inventory", nbt.TagCompound,
+		item("minecraft:torch", 32, 2), item("", 0, 3), item("minecraft:bread", 5, 20),
+	))
+	_ = local.SetChild(testList("EnderChestInventory", nbt.TagCompound, item("minecraft:diamond", 4, 26)))
+	_ = local.SetChild(testList("Armor", nbt.TagCompound,
+		item("minecraft:iron_helmet", 1, 0), item("", 0, 0), item("", 0, 0), item("minecraft:iron_boots", 1, 0),
+	))
+	_ = local.SetChild(testList("Offhand", nbt.TagCompound, item("minecraft:shield", 1, 0)))
+
+	server := testEntity("minecraft:player", 2, 100, 71.62, -100)
+
+	// The identity record of a server player holds the key of their player record rather than player data
+	identity := testCompound("", str("ServerId", "player_server_abc"))
+
+	for k, p := range map[string]nbt.NBTTag{
+		"~local_player":     local,
+		"player_server_abc": server,
+		"player_1234":       identity,
+	} {
+		value, err := nbt.Encode([]nbt.NBTTag{p})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = db.Put([]byte(k), value)
+	}
+
+	p, err := w.LocalPlayer()
+	if err != nil {
+		t.Fatalf("unexpected error getting the local player: %s", err)
+	}
+
+	if p.Key != "~local_player" || math.Abs(p.Y-64) > 0.001 || p.Level != 12 || p.LevelProgress != 0.25 ||
+		p.SelectedSlot != 2 {
+		t.Errorf("unexpected local player %+v", p.PlayerPosition)
+	}
+
+	if len(p.Inventory) != 2 || p.Inventory[0].Name != "minecraft:torch" || p.Inventory[0].Count != 32 ||
+		p.Inventory[1].Slot != 20 {
+		t.Errorf("expected a torch and bread in the inventory: got %+v", p.Inventory)
+	}
+
+	if len(p.EnderChest) != 1 || p.EnderChest[0].Name != "minecraft:diamond" || p.EnderChest[0].Slot != 26 {
+		t.Errorf("expected diamonds in the ender chest: got %+v", p.EnderChest)
+	}
+
+	if a := p.Armor; a[0].Name != "minecraft:iron_helmet" || a[1].Name != "" || a[3].Name != "minecraft:iron_boots" {
+		t.Errorf("expected an iron helmet and boots: got %+v", p.Armor)
+	}
+
+	if p.Offhand.Name != "minecraft:shield" {
+		t.Errorf("expected a shield in the off hand: got %+v", p.Offhand)
+	}
+
+	players, err := w.Players()
+	if err != nil {
+		t.Fatalf("unexpected error getting players: %s", err)
+	}
+
+	if len(players) != 2 || players[0].Key != "player_server_abc" || players[1].Key != "~local_player" {
+		t.Fatalf("expected the server and local players: got %d players", len(players))
+	}
+
+	if s := players[0]; s.X != 100 || s.Z != -100 || len(s.Inventory) != 0 || s.Offhand.Name != "" {
+		t.Errorf("unexpected server player %+v", s)
+	}
+}
@@ -0,0 +1,142 @@
+package world
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// PlayerStats summarizes a player record, for admins comparing the players of a multiplayer world offline.
+type PlayerStats struct {
+	PlayerPosition
+
+	GameType int     // The player's game mode, named by GameTypeName
+	Level    int     // The experience level
+	Health   float64 // Health points, 20 being full health
+	Hunger   float64 // Hunger points, 20 being full
+
+	// Where the player last died, saved since 1.19. HasDied is false if it isn't saved.
+	HasDied                bool
+	DeathX, DeathY, DeathZ int
+	DeathDimension         int
+
+	Items int // The number of items carried, worn and in the ender chest
+	Value int // A rough value of the items, see ItemValue
+}
+
+// PlayerStats returns the stats of every player, sorted by the value of their items, highest first.
+func (w *World) PlayerStats() ([]PlayerStats, error) {
+	keys, err := w.playerKeys(w.startBudget())
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]PlayerStats, 0, len(keys))
+
+	for _, k := range keys {
+		root, err := w.singleTagRecord([]byte(k))
+		if err != nil {
+			return nil, err
+		}
+		if root == nil || isIdentityRecord(*root) {
+			continue
+		}
+
+		stats = append(stats, parsePlayerStats(k, *root))
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].Value > stats[j].Value })
+
+	return stats, nil
+}
+
+func parsePlayerStats(key string, root nbt.NBTTag) PlayerStats {
+	s := PlayerStats{
+		PlayerPosition: parsePlayerPosition(key, root),
+		GameType:       int(childInt(root, "PlayerGameMode")),
+		Level:          int(childInt(root, "PlayerLevel")),
+		Health:         attribute(root, "minecraft:health"),
+		Hunger:         attribute(root, "minecraft:player.hunger"),
+		DeathX:         int(childInt(root, "DeathPositionX")),
+		DeathY:         int(childInt(root, "DeathPositionY")),
+		DeathZ:         int(childInt(root, "DeathPositionZ")),
+		DeathDimension: int(childInt(root, "DeathDimension")),
+	}
+
+	_, s.HasDied = root.Child("DeathPositionY")
+
+	for _, i := range parseEntity(root).Items() {
+		s.Items += i.Count
+		s.Value += ItemValue(i)
+	}
+
+	return s
+}
+
+// attribute returns the current value of the attribute with the given name, or 0 if it isn't saved.
+func attribute(root nbt.NBTTag, name string) float64 {
+	attributes, ok := root.Child("Attributes")
+	if !ok {
+		return 0
+	}
+
+	for _, a := range attributes.List() {
+		if n, _ := a.Child("Name"); n.Value == name {
+			c, _ := a.Child("Current")
+			v, _ := c.Float()
+			return v
+		}
+	}
+
+	return 0
+}
+
+// itemValues are the values of items worth much more than their material, in the same units as materialValues.
+var itemValues = map[string]int{
+	"minecraft:elytra":                 64,
+	"minecraft:beacon":                 64,
+	"minecraft:enchanted_golden_apple": 64,
+	"minecraft:totem_of_undying":       16,
+	"minecraft:trident":                16,
+	"minecraft:heart_of_the_sea":       16,
+	"minecraft:nether_star":            48,
+	"minecraft:shulker_shell":          8,
+	"minecraft:ancient_debris":         9,
+	"minecraft:netherite_scrap":        9,
+}
+
+// materialValues are the values of one item made of each material, checked in order against the item ID, with iron
+// worth 1. Blocks of a material are worth nine items.
+var materialValues = []struct {
+	material string
+	value    int
+}{
+	{"netherite", 36}, {"diamond", 8}, {"emerald", 4}, {"gold", 2}, {"iron", 1},
+}
+
+// ItemValue returns a rough value of an item stack, for comparing the wealth of players. Iron is worth 1, diamond 8 and
+// netherite 36, with rare items such as elytra worth more and each enchantment level adding 2. The contents of shulker
+// boxes are not counted.
+func ItemValue(i Item) int {
+	value, ok := itemValues[i.Name]
+	if !ok {
+		for _, m := range materialValues {
+			if strings.Contains(i.Name, m.material) {
+				value = m.value
+				if strings.HasSuffix(i.Name, m.material+"_block") {
+					value *= 9
+				}
+				break
+			}
+		}
+	}
+
+	value *= i.Count
+
+	for _, e := range i.Enchantments {
+		value += 2 * e.Level
+	}
+
+	return value
+}
@@ -0,0 +1,66 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestPlayerStats(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	byteTag := func(name string, v int) nbt.NBTTag { return nbt.NBTTag{Type: nbt.TagByte, Name: name, Value: v} }
+	intTag := func(name string, v int) nbt.NBTTag { return nbt.NBTTag{Type: nbt.TagInt, Name: name, Value: v} }
+	str := func(name, v string) nbt.NBTTag { return nbt.NBTTag{Type: nbt.TagString, Name: name, Value: v} }
+	item := func(id string, count int) nbt.NBTTag {
+		return testCompound("", str("Name", id), byteTag("Count", count))
+	}
+
+	rich := testEntity("minecraft:player", 1, 0.5, 65.62, 0.5)
+	_ = rich.SetChild(intTag("PlayerLevel", 30))
+	_ = rich.SetChild(intTag("DeathPositionY", -12))
+	_ = rich.SetChild(testList("Attributes", nbt.TagCompound,
+		testCompound("", str("Name", "minecraft:health"), nbt.NBTTag{Type: nbt.TagFloat, Name: "Current", Value: 14.0}),
+	))
+	_ = rich.SetChild(testList("Inventory", nbt.TagCompound,
+		item("minecraft:diamond", 3), item("minecraft:iron_block", 2), item("minecraft:elytra", 1),
+		item("minecraft:dirt", 64),
+	))
+
+	poor := testEntity("minecraft:player", 2, 0, 70, 0)
+	_ = poor.SetChild(testList("Inventory", nbt.TagCompound, item("minecraft:gold_ingot", 1)))
+
+	for k, p := range map[string]nbt.NBTTag{"player_server_a": poor, "player_server_b": rich} {
+		value, err := nbt.Encode([]nbt.NBTTag{p})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = db.Put([]byte(k), value)
+	}
+
+	stats, err := w.PlayerStats()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(stats) != 2 || stats[0].Key != "player_server_b" {
+		t.Fatalf("expected the richest player first: got %+v", stats)
+	}
+
+	s := stats[0]
+
+	// 3 diamonds, 2 iron blocks and an elytra
+	if want := 3*8 + 2*9 + 64; s.Value != want || s.Items != 70 {
+		t.Errorf("expected value %d of 70 items: got %d of %d", want, s.Value, s.Items)
+	}
+
+	if s.Level != 30 || s.Health != 14 || !s.HasDied || s.DeathY != -12 || s.Y < 63.9 || s.Y > 64.1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	if stats[1].HasDied || stats[1].Value != 2 {
+		t.Errorf("unexpected stats %+v", stats[1])
+	}
+}
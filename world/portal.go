@@ -0,0 +1,279 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+const portalsKey = "portals"
+
+// Portal is a nether portal as stored in the portals record. The teleport coordinates are the lowest corner of the
+// portal blocks and span is the portal width along its axis.
+type Portal struct {
+	Dimension     int
+	TpX, TpY, TpZ int
+	Span          int
+	AlongX        bool // The portal extends along the x axis, otherwise it extends along z
+}
+
+// PortalMismatch is a difference between the portals record and the portal blocks in the world.
+type PortalMismatch struct {
+	Portal
+	Reason string
+}
+
+// Portals returns the portals listed in the portals record.
+func (w *World) Portals() ([]Portal, error) {
+	root, err := w.portalsRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := root.Path("data", "PortalRecords")
+	if !ok {
+		return []Portal{}, nil
+	}
+
+	records := list.List()
+	portals := make([]Portal, len(records))
+
+	for i, r := range records {
+		p := Portal{}
+
+		for name, v := range map[string]*int{
+			"DimId": &p.Dimension, "TpX": &p.TpX, "TpY": &p.TpY, "TpZ": &p.TpZ, "Span": &p.Span,
+		} {
+			t, ok := r.Child(name)
+			if !ok {
+				return nil, fmt.Errorf("portal record %d has no %s tag", i, name)
+			}
+
+			n, _ := t.Int()
+			*v = int(n)
+		}
+
+		if xa, ok := r.Child("Xa"); ok {
+			n, _ := xa.Int()
+			p.AlongX = n != 0
+		}
+
+		portals[i] = p
+	}
+
+	return portals, nil
+}
+
+// ScanPortals finds every nether portal in the world by grouping connected portal blocks.
+func (w *World) ScanPortals() ([]Portal, error) {
+	blocks := make(map[[4]int]bool)
+
+	err := w.forEachSubChunk(w.startBudget(), func(k leveldb.ChunkKey, s *subChunkData) error {
+		if !s.Blocks.paletteContains(BlockPortal) {
+			return nil
+		}
+
+		ox, oy, oz := subChunkKeyOrigin(k)
+
+		for i, p := range s.Blocks.Indices {
+			if s.Blocks.Palette[p].BlockID() != BlockPortal {
+				continue
+			}
+
+			x, y, z := subChunkIndexToVoxel(i)
+			blocks[[4]int{ox + x, oy + y, oz + z, int(k.Dimension)}] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	portals := make([]Portal, 0)
+
+	for len(blocks) > 0 {
+		var start [4]int
+		for b := range blocks {
+			start = b
+			break
+		}
+
+		portals = append(portals, floodPortal(blocks, start))
+	}
+
+	sort.Slice(portals, func(i, j int) bool {
+		a, b := portals[i], portals[j]
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+		if a.TpX != b.TpX {
+			return a.TpX < b.TpX
+		}
+		if a.TpZ != b.TpZ {
+			return a.TpZ < b.TpZ
+		}
+		return a.TpY < b.TpY
+	})
+
+	return portals, nil
+}
+
+// floodPortal removes the portal blocks connected to start from blocks and returns the portal they form.
+func floodPortal(blocks map[[4]int]bool, start [4]int) Portal {
+	min, max := start, start
+	queue := [][4]int{start}
+	delete(blocks, start)
+
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+
+		for i := 0; i < 3; i++ {
+			if b[i] < min[i] {
+				min[i] = b[i]
+			}
+			if b[i] > max[i] {
+				max[i] = b[i]
+			}
+		}
+
+		for _, d := range [][3]int{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}} {
+			n := [4]int{b[0] + d[0], b[1] + d[1], b[2] + d[2], b[3]}
+			if blocks[n] {
+				delete(blocks, n)
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	p := Portal{
+		Dimension: start[3],
+		TpX:       min[0], TpY: min[1], TpZ: min[2],
+		AlongX: max[0] > min[0],
+	}
+
+	if p.AlongX {
+		p.Span = max[0] - min[0] + 1
+	} else {
+		p.Span = max[2] - min[2] + 1
+	}
+
+	return p
+}
+
+// PortalMismatches compares the portals record with the portal blocks in the world. Records with no portal blocks at
+// their teleport coordinates and portals which have no record are returned.
+func (w *World) PortalMismatches() ([]PortalMismatch, error) {
+	recorded, err := w.Portals()
+	if err != nil {
+		return nil, err
+	}
+
+	scanned, err := w.ScanPortals()
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[Portal]bool)
+	for _, p := range scanned {
+		found[p] = true
+	}
+
+	mismatches := make([]PortalMismatch, 0)
+	matched := make(map[Portal]bool)
+
+	for _, p := range recorded {
+		if found[p] {
+			matched[p] = true
+			continue
+		}
+
+		mismatches = append(mismatches, PortalMismatch{Portal: p, Reason: "recorded portal has no matching portal blocks"})
+	}
+
+	for _, p := range scanned {
+		if !matched[p] {
+			mismatches = append(mismatches, PortalMismatch{Portal: p, Reason: "portal blocks are not recorded"})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// RebuildPortals replaces the portals record with the portals found by ScanPortals.
+func (w *World) RebuildPortals() ([]Portal, error) {
+	portals, err := w.ScanPortals()
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := w.portalsRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]nbt.NBTTag, len(portals))
+	for i, p := range portals {
+		r := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+
+		xa, za := 0, 1
+		if p.AlongX {
+			xa, za = 1, 0
+		}
+
+		for _, t := range []nbt.NBTTag{
+			{Type: nbt.TagInt, Name: "DimId", Value: p.Dimension},
+			{Type: nbt.TagByte, Name: "Span", Value: p.Span},
+			{Type: nbt.TagInt, Name: "TpX", Value: p.TpX},
+			{Type: nbt.TagInt, Name: "TpY", Value: p.TpY},
+			{Type: nbt.TagInt, Name: "TpZ", Value: p.TpZ},
+			{Type: nbt.TagByte, Name: "Xa", Value: xa},
+			{Type: nbt.TagByte, Name: "Za", Value: za},
+		} {
+			_ = r.SetChild(t)
+		}
+
+		records[i] = r
+	}
+
+	list := nbt.NBTTag{Type: nbt.TagList, Name: "PortalRecords", Value: map[string]interface{}{"tagListType": nbt.TagCompound}}
+	if err := list.SetList(records); err != nil {
+		return nil, err
+	}
+
+	data, ok := root.Child("data")
+	if !ok {
+		data = nbt.NBTTag{Type: nbt.TagCompound, Name: "data", Value: []interface{}{}}
+	}
+
+	if err := data.SetChild(list); err != nil {
+		return nil, err
+	}
+
+	if err := root.SetChild(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.putTags([]byte(portalsKey), []nbt.NBTTag{root}); err != nil {
+		return nil, err
+	}
+
+	return portals, nil
+}
+
+// portalsRecord returns the root tag of the portals record, or an empty compound if there is no record.
+func (w *World) portalsRecord() (nbt.NBTTag, error) {
+	root, err := w.singleTagRecord([]byte(portalsKey))
+	if err != nil {
+		return nbt.NBTTag{}, err
+	}
+
+	if root == nil {
+		return nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}, nil
+	}
+
+	return *root, nil
+}
@@ -0,0 +1,100 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func testPaletteEntry(id string) nbt.NBTTag {
+	t := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagString, Name: "name", Value: id})
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagCompound, Name: "states", Value: []interface{}{}})
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: "version", Value: 17959425})
+
+	return t
+}
+
+// testSubChunkValue returns an encoded sub chunk of air with the given blocks set, keyed by sub chunk coordinates.
+func testSubChunkValue(t *testing.T, blocks map[[3]int]string) []byte {
+	s := subChunkData{
+		Version: 8,
+		Blocks: blockStorage{
+			BitsPerBlock: 4,
+			Indices:      make([]int, subChunkBlockCount),
+			Palette:      []nbt.NBTTag{testPaletteEntry("minecraft:air")},
+		},
+	}
+
+	palette := map[string]int{"minecraft:air": 0}
+
+	for c, id := range blocks {
+		if _, ok := palette[id]; !ok {
+			palette[id] = len(s.Blocks.Palette)
+			s.Blocks.Palette = append(s.Blocks.Palette, testPaletteEntry(id))
+		}
+
+		s.Blocks.Indices[subChunkVoxelToIndex(c[0], c[1], c[2])] = palette[id]
+	}
+
+	b, err := encodeSubChunk(&s, true)
+	if err != nil {
+		t.Fatalf("unexpected error encoding test sub chunk: %s", err)
+	}
+
+	return b
+}
+
+func TestPortals(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := &World{
+		db:        db,
+		subChunks: make(map[struct{ x, y, z, d int }]*subChunkData),
+	}
+
+	// A 2 wide, 3 tall portal along x at 2 4 5
+	blocks := make(map[[3]int]string)
+	for x := 2; x < 4; x++ {
+		for y := 4; y < 7; y++ {
+			blocks[[3]int{x, y, 5}] = BlockPortal
+		}
+	}
+
+	_ = db.Put(leveldb.ChunkKey{X: 1, Z: 0, Tag: leveldb.SubChunkPrefix, SubChunkY: 1}.Bytes(),
+		testSubChunkValue(t, blocks))
+
+	want := Portal{TpX: 18, TpY: 20, TpZ: 5, Span: 2, AlongX: true}
+
+	mismatches, err := w.PortalMismatches()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(mismatches) != 1 || mismatches[0].Portal != want {
+		t.Fatalf("expected unrecorded portal %+v: got %+v", want, mismatches)
+	}
+
+	if _, err := w.RebuildPortals(); err != nil {
+		t.Fatalf("unexpected error rebuilding portals: %s", err)
+	}
+
+	portals, err := w.Portals()
+	if err != nil {
+		t.Fatalf("unexpected error reading portals: %s", err)
+	}
+
+	if len(portals) != 1 || portals[0] != want {
+		t.Errorf("expected rebuilt portal record %+v: got %+v", want, portals)
+	}
+
+	mismatches, err = w.PortalMismatches()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(mismatches) != 0 {
+		t.Errorf("expected no mismatches after rebuilding: got %+v", mismatches)
+	}
+}
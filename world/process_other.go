@@ -0,0 +1,9 @@
+//go:build !darwin && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package world
+
+// processRunning returns true, as processes can't be checked on this platform.
+func processRunning(pid int) bool {
+	return true
+}
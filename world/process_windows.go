@@ -0,0 +1,27 @@
+package world
+
+import "syscall"
+
+// Windows API values used to check a process.
+const (
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259
+	errorAccessDenied              = syscall.Errno(5)
+)
+
+// processRunning returns true if a process with the given ID is running.
+func processRunning(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// Processes of other users can't be opened but are running
+		return err == errorAccessDenied
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+
+	return code == stillActive
+}
@@ -0,0 +1,143 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// singleTagRecord returns the root tag of a record holding a single compound tag, or nil if the record doesn't exist.
+func (w *World) singleTagRecord(key []byte) (*nbt.NBTTag, error) {
+	value, err := w.db.Get(key)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting key '%s': %w", key, err)
+	}
+
+	tags, err := nbt.Decode(value)
+	if err != nil {
+		return nil, fmt.Errorf("decoding key '%s': %w", key, err)
+	}
+
+	if len(tags) != 1 || tags[0].Type != nbt.TagCompound {
+		return nil, fmt.Errorf("key '%s' does not contain a single compound tag", key)
+	}
+
+	return &tags[0], nil
+}
+
+// putTags writes the given tags as the value of key. If there are no tags the key is deleted.
+func (w *World) putTags(key []byte, tags []nbt.NBTTag) error {
+	if len(tags) == 0 {
+		if err := w.delete(key); err != nil {
+			return fmt.Errorf("deleting key '%x': %w", key, err)
+		}
+		return nil
+	}
+
+	data, err := nbt.Encode(tags)
+	if err != nil {
+		return fmt.Errorf("encoding key '%x': %w", key, err)
+	}
+
+	if err := w.put(key, data); err != nil {
+		return fmt.Errorf("putting key '%x': %w", key, err)
+	}
+
+	return nil
+}
+
+// Record returns the tags of the record with the given key, which must hold only NBT data.
+func (w *World) Record(key []byte) ([]nbt.NBTTag, error) {
+	value, err := w.db.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("getting key '%x': %w", key, err)
+	}
+
+	tags, err := nbt.Decode(value)
+	if err != nil {
+		return nil, fmt.Errorf("decoding key '%x': %w", key, err)
+	}
+
+	return tags, nil
+}
+
+// SetRecord writes the given tags as the value of the record with the given key, creating it if it doesn't exist. It
+// is intended for records the package doesn't model, so nothing about the tags is checked except that they can be
+// encoded. Chunk records which don't hold NBT data, such as sub chunks, can't be written. If there are no tags the
+// record is deleted.
+func (w *World) SetRecord(key []byte, tags []nbt.NBTTag) error {
+	if _, ok := leveldb.ParseChunkKey(key); ok && !isNBTRecord(key) {
+		return fmt.Errorf("chunk record '%x' does not hold NBT data", key)
+	}
+
+	return w.putTags(key, tags)
+}
+
+// nbtRecordPrefixes are the prefixes of keys which hold only NBT data, other than chunk records.
+var nbtRecordPrefixes = []string{
+	"~local_player", "player_", leveldb.ActorPrefix, "map_", "VILLAGE_", "portals", "scoreboard",
+	"AutonomousEntities", "BiomeData", "mobevents", "schedulerWT", "Overworld", "Nether", "TheEnd",
+}
+
+// isNBTRecord returns true if the value of key is one or more NBT tags.
+func isNBTRecord(key []byte) bool {
+	if k, ok := leveldb.ParseChunkKey(key); ok {
+		switch k.Tag {
+		case leveldb.BlockEntity, leveldb.Entity, leveldb.PendingTicks, leveldb.RandomTicks:
+			return true
+		}
+		return false
+	}
+
+	for _, p := range nbtRecordPrefixes {
+		if strings.HasPrefix(string(key), p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forEachNBTRecord decodes every record holding NBT data and calls f with its key and tags. If f returns true the
+// returned tags are written back to the record. Iteration stops if f returns an error.
+func (w *World) forEachNBTRecord(f func(key []byte, tags []nbt.NBTTag) ([]nbt.NBTTag, bool, error)) error {
+	keys, err := w.db.Keys()
+	if err != nil {
+		return fmt.Errorf("listing keys: %w", err)
+	}
+
+	for _, key := range keys {
+		if !isNBTRecord(key) {
+			continue
+		}
+
+		value, err := w.db.Get(key)
+		if err != nil {
+			return fmt.Errorf("getting key '%x': %w", key, err)
+		}
+
+		tags, err := nbt.Decode(value)
+		if err != nil {
+			return fmt.Errorf("decoding key '%x': %w", key, err)
+		}
+
+		updated, changed, err := f(key, tags)
+		if err != nil {
+			return err
+		}
+
+		if changed {
+			if err := w.putTags(key, updated); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
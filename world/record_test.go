@@ -0,0 +1,52 @@
+package world
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestRecord(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	key := []byte("LevelChunkMetaDataDictionary")
+	if _, err := w.Record(key); !errors.Is(err, leveldb.ErrNotFound) {
+		t.Errorf("expected ErrNotFound getting a missing record: got %v", err)
+	}
+
+	tags := []nbt.NBTTag{testCompound("", testString("a", "b")), testCompound("", testString("c", "d"))}
+	if err := w.SetRecord(key, tags); err != nil {
+		t.Fatalf("unexpected error setting record: %s", err)
+	}
+
+	got, err := w.Record(key)
+	if err != nil {
+		t.Fatalf("unexpected error getting record: %s", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 tags: got %d", len(got))
+	}
+	if c, _ := got[1].Child("c"); c.Value != "d" {
+		t.Errorf("expected the second tag to be kept: got %v", got[1])
+	}
+
+	subChunk, _ := leveldb.SubChunkKey(0, 0, 0, 0)
+	if err := w.SetRecord(subChunk, tags); err == nil {
+		t.Error("expected an error setting a sub chunk record")
+	}
+
+	if err := w.SetRecord(leveldb.ChunkKey{Tag: leveldb.BlockEntity}.Bytes(), tags); err != nil {
+		t.Errorf("unexpected error setting a block entity record: %s", err)
+	}
+
+	if err := w.SetRecord(key, nil); err != nil {
+		t.Fatalf("unexpected error deleting record: %s", err)
+	}
+	if _, err := db.Get(key); !errors.Is(err, leveldb.ErrNotFound) {
+		t.Errorf("expected the record to be deleted: got %v", err)
+	}
+}
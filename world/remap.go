@@ -0,0 +1,244 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// IDKind is a kind of identifier which is unique within a world, but may collide with identifiers from another world
+// when worlds are merged or structures are imported.
+type IDKind int
+
+const (
+	MapID    IDKind = iota // The ID of a map record, referenced by map items
+	EntityID               // The unique ID of an entity or player, referenced by owners, leashes, riders and villages
+)
+
+func (k IDKind) String() string {
+	switch k {
+	case MapID:
+		return "map"
+	case EntityID:
+		return "entity"
+	}
+
+	return fmt.Sprintf("IDKind(%d)", int(k))
+}
+
+// idTags maps the names of long tags holding an ID to the kind of ID they hold.
+var idTags = map[string]IDKind{
+	mapItemIDTag:   MapID,
+	mapIDTag:       MapID,
+	mapParentIDTag: MapID,
+	"UniqueID":     EntityID,
+	"OwnerNew":     EntityID,
+	"LeasherID":    EntityID,
+	"TargetID":     EntityID,
+	"entityID":     EntityID,
+	"VillagerID":   EntityID,
+}
+
+// definingTags are the tags which define a new ID, rather than referencing an existing one.
+var definingTags = map[string]bool{
+	mapIDTag:   true,
+	"UniqueID": true,
+}
+
+// IDRemap maps old IDs to new IDs for each kind of ID. IDs which are not present are unchanged.
+type IDRemap map[IDKind]map[int64]int64
+
+// IDChange is one ID changed by an IDRemap.
+type IDChange struct {
+	Kind     IDKind
+	From, To int64
+}
+
+// Changes returns every ID changed by the remap, ordered by kind and then by the old ID, so that reports of a remap are
+// the same each time it is made.
+func (r IDRemap) Changes() []IDChange {
+	changes := make([]IDChange, 0)
+
+	for kind, m := range r {
+		for from, to := range m {
+			changes = append(changes, IDChange{Kind: kind, From: from, To: to})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].From < changes[j].From
+	})
+
+	return changes
+}
+
+// idKind returns the kind of ID held by the tag with the given name in the record with the given key.
+func idKind(key []byte, tag nbt.NBTTag) (IDKind, bool) {
+	if tag.Type != nbt.TagLong {
+		return 0, false
+	}
+
+	// Village dwellers are listed by a generically named ID tag
+	if tag.Name == "ID" && strings.HasPrefix(string(key), villagePrefix) {
+		return EntityID, true
+	}
+
+	k, ok := idTags[tag.Name]
+
+	return k, ok
+}
+
+// IDs returns every ID of each kind defined in the world, sorted in ascending order.
+func (w *World) IDs() (map[IDKind][]int64, error) {
+	found := map[IDKind]map[int64]bool{
+		MapID:    make(map[int64]bool),
+		EntityID: make(map[int64]bool),
+	}
+
+	err := w.forEachNBTRecord(func(key []byte, tags []nbt.NBTTag) ([]nbt.NBTTag, bool, error) {
+		if id, ok := parseMapKey(key); ok {
+			found[MapID][id] = true
+		}
+
+		for _, t := range tags {
+			nbt.Walk(t, func(_ []string, t nbt.NBTTag) {
+				kind, ok := idKind(key, t)
+				if !ok || !definingTags[t.Name] {
+					return
+				}
+
+				id, _ := t.Int()
+				found[kind][id] = true
+			})
+		}
+
+		return nil, false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[IDKind][]int64)
+	for kind, set := range found {
+		ids[kind] = make([]int64, 0, len(set))
+		for id := range set {
+			ids[kind] = append(ids[kind], id)
+		}
+
+		sort.Slice(ids[kind], func(i, j int) bool { return ids[kind][i] < ids[kind][j] })
+	}
+
+	return ids, nil
+}
+
+// RemapIDs rewrites every ID tag in the world according to remap, so that definitions and references stay consistent.
+// Map records are renamed to match their new map ID.
+func (w *World) RemapIDs(remap IDRemap) error {
+	renamed := make(map[int64][]nbt.NBTTag)
+
+	err := w.forEachNBTRecord(func(key []byte, tags []nbt.NBTTag) ([]nbt.NBTTag, bool, error) {
+		changed := false
+
+		for i, t := range tags {
+			tags[i] = nbt.Rewrite(t, func(t nbt.NBTTag) nbt.NBTTag {
+				kind, ok := idKind(key, t)
+				if !ok {
+					return t
+				}
+
+				id, ok := t.Int()
+				if n, remapped := remap[kind][id]; ok && remapped {
+					t.Value = nbt.Long(n)
+					changed = true
+				}
+
+				return t
+			})
+		}
+
+		if id, ok := parseMapKey(key); ok {
+			if _, remapped := remap[MapID][id]; remapped {
+				renamed[id] = tags
+				return nil, false, nil
+			}
+		}
+
+		return tags, changed, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Renamed maps are deleted before any are written so that maps may swap IDs
+	for id := range renamed {
+		if err := w.delete(mapKey(id)); err != nil {
+			return fmt.Errorf("deleting map %d: %w", id, err)
+		}
+	}
+
+	for id, tags := range renamed {
+		if err := w.putTags(mapKey(remap[MapID][id]), tags); err != nil {
+			return fmt.Errorf("writing map %d as %d: %w", id, remap[MapID][id], err)
+		}
+	}
+
+	return nil
+}
+
+// NonCollidingIDs returns a remapping for RemapIDs which gives each ID in ids which is also in taken a new ID of the
+// same kind which is in neither. Remapping one world with the result allows it to be merged with the other.
+func NonCollidingIDs(ids, taken map[IDKind][]int64) IDRemap {
+	remap := make(IDRemap)
+
+	for kind := range ids {
+		remap[kind] = nonColliding(ids[kind], taken[kind])
+	}
+
+	return remap
+}
+
+// nonColliding returns a new ID for each of ids which is also in taken. New IDs are allocated above the highest ID in
+// either list.
+func nonColliding(ids, taken []int64) map[int64]int64 {
+	used := make(map[int64]bool)
+	collides := make(map[int64]bool)
+	var max int64
+
+	for _, id := range taken {
+		used[id] = true
+		collides[id] = true
+		if id > max {
+			max = id
+		}
+	}
+
+	for _, id := range ids {
+		used[id] = true
+		if id > max {
+			max = id
+		}
+	}
+
+	remap := make(map[int64]int64)
+	next := max + 1
+
+	for _, id := range ids {
+		if !collides[id] {
+			continue
+		}
+
+		for used[next] {
+			next++
+		}
+
+		remap[id] = next
+		used[next] = true
+	}
+
+	return remap
+}
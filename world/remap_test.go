@@ -0,0 +1,91 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestRemapIDs(t *testing.T) {
+	w, _ := testEntityWorld(t)
+
+	// A wolf owned by the cow, entity 1
+	wolf := testEntity("minecraft:wolf", 4, 8, 64, 8)
+	_ = wolf.SetChild(nbt.NBTTag{Type: nbt.TagLong, Name: "OwnerNew", Value: nbt.Long(1)})
+
+	entities, err := w.legacyEntities(nil, leveldb.ChunkKey{Tag: leveldb.Entity})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tags := []nbt.NBTTag{wolf}
+	for _, e := range entities {
+		tags = append(tags, e.NBT)
+	}
+
+	if err := w.putTags(leveldb.ChunkKey{Tag: leveldb.Entity}.Bytes(), tags); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ids, err := w.IDs()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(ids[EntityID]) != 4 {
+		t.Fatalf("expected 4 entity IDs: got %v", ids[EntityID])
+	}
+
+	remap := NonCollidingIDs(ids, map[IDKind][]int64{EntityID: {1, 10}})
+	if len(remap[EntityID]) != 1 || remap[EntityID][1] != 11 {
+		t.Fatalf("unexpected remapping: %v", remap)
+	}
+
+	if err := w.RemapIDs(remap); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	entities, err = w.Entities()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, e := range entities {
+		switch e.Identifier {
+		case "minecraft:cow":
+			if e.UniqueID != 11 {
+				t.Errorf("cow unique ID was not remapped: %d", e.UniqueID)
+			}
+		case "minecraft:wolf":
+			if e.UniqueID == 4 && childInt(e.NBT, "OwnerNew") != 11 {
+				t.Errorf("wolf owner was not remapped: %d", childInt(e.NBT, "OwnerNew"))
+			}
+		}
+	}
+}
+
+func TestIDRemapChanges(t *testing.T) {
+	r := IDRemap{
+		EntityID: {9: 10, 3: 4},
+		MapID:    {7: 8, 1: 2, 5: 6},
+	}
+
+	want := []IDChange{
+		{MapID, 1, 2}, {MapID, 5, 6}, {MapID, 7, 8},
+		{EntityID, 3, 4}, {EntityID, 9, 10},
+	}
+
+	for i := 0; i < 10; i++ {
+		got := r.Changes()
+		if len(got) != len(want) {
+			t.Fatalf("expected %d changes: got %d", len(want), len(got))
+		}
+
+		for j := range want {
+			if got[j] != want[j] {
+				t.Fatalf("change %d: expected %+v: got %+v", j, want[j], got[j])
+			}
+		}
+	}
+}
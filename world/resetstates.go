@@ -0,0 +1,163 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// StateReset describes the block states and block IDs which hold the momentary redstone state of a block, and the
+// values they are reset to. Blocks copied while powered or triggered keep that state when pasted, but nothing updates
+// them until a neighbour changes, leaving redstone stuck on.
+type StateReset struct {
+	States map[string]int    // Block states and the value they are reset to
+	Blocks map[string]string // Block IDs which are replaced, keeping their states, e.g. powered by unpowered repeaters
+}
+
+// DefaultStateReset returns a StateReset for the powered, triggered and lit states of redstone components.
+//
+// Piston extension is held in the piston's block entity rather than its block states, so it is not reset.
+func DefaultStateReset() StateReset {
+	return StateReset{
+		States: map[string]int{
+			"powered_bit":        0, // Observers
+			"triggered_bit":      0, // Dispensers and droppers
+			"button_pressed_bit": 0,
+			"redstone_signal":    0, // Redstone dust, pressure plates and daylight detectors
+			"output_lit_bit":     0, // Comparators
+			"rail_data_bit":      0, // Powered, activator and detector rails
+			"toggle_bit":         0, // Hoppers disabled by redstone
+		},
+		Blocks: map[string]string{
+			"minecraft:powered_repeater":   "minecraft:unpowered_repeater",
+			"minecraft:powered_comparator": "minecraft:unpowered_comparator",
+			"minecraft:lit_redstone_lamp":  "minecraft:redstone_lamp",
+		},
+	}
+}
+
+// ResetStates resets the volatile states of the saved blocks in the region, returning the number of blocks changed.
+// Blocks outside the region which share a palette entry with a reset block are not changed.
+func (w *World) ResetStates(region Selection, r StateReset) (int, error) {
+	ranges, ok := subChunkRanges[region.Dimension]
+	if !ok {
+		return 0, fmt.Errorf("%w %d", ErrInvalidDimension, region.Dimension)
+	}
+
+	minY := maxInt(floorDiv(region.Min[1], chunkSize), int(ranges[0]))
+	maxY := minInt(floorDiv(region.Max[1], chunkSize), int(ranges[1]))
+
+	count := 0
+
+	for cx := floorDiv(region.Min[0], chunkSize); cx <= floorDiv(region.Max[0], chunkSize); cx++ {
+		for cz := floorDiv(region.Min[2], chunkSize); cz <= floorDiv(region.Max[2], chunkSize); cz++ {
+			for sy := minY; sy <= maxY; sy++ {
+				s, err := w.SubChunk(cx, sy, cz, region.Dimension)
+				if errors.Is(err, &SubChunkNotSavedError{}) {
+					continue
+				}
+				if err != nil {
+					return count, err
+				}
+
+				n := s.resetStates(region, r)
+				if n == 0 {
+					continue
+				}
+
+				if err := w.SetSubChunk(s); err != nil {
+					return count, err
+				}
+
+				count += n
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// resetStates resets the volatile states of the blocks in the part of the sub chunk inside the region, adding reset
+// palette entries as needed, and returns the number of blocks changed.
+func (s *SubChunk) resetStates(region Selection, r StateReset) int {
+	blocks := &s.data.Blocks
+	origin := [3]int{s.X * chunkSize, s.Y * chunkSize, s.Z * chunkSize}
+
+	var lo, hi [3]int
+	for i := 0; i < 3; i++ {
+		lo[i] = maxInt(region.Min[i]-origin[i], 0)
+		hi[i] = minInt(region.Max[i]-origin[i], chunkSize-1)
+	}
+
+	// The palette index each palette entry is reset to
+	reset := make(map[int]int)
+	count := 0
+
+	for x := lo[0]; x <= hi[0]; x++ {
+		for z := lo[2]; z <= hi[2]; z++ {
+			for y := lo[1]; y <= hi[1]; y++ {
+				i := subChunkVoxelToIndex(x, y, z)
+				p := blocks.Indices[i]
+
+				to, ok := reset[p]
+				if !ok {
+					to = p
+					if e, changed := r.reset(blocks.Palette[p]); changed {
+						to = len(blocks.Palette)
+						blocks.Palette = append(blocks.Palette, e)
+						blocks.BitsPerBlock = maxInt(blocks.BitsPerBlock, minimalBitsPerBlock(len(blocks.Palette)))
+					}
+					reset[p] = to
+				}
+
+				if to != p {
+					blocks.Indices[i] = to
+					count++
+				}
+			}
+		}
+	}
+
+	return count
+}
+
+// reset returns a copy of a palette entry with its volatile states reset. The returned bool is false if the entry
+// has no states or ID to reset.
+func (r StateReset) reset(entry nbt.NBTTag) (nbt.NBTTag, bool) {
+	values, _ := entry.Value.([]interface{})
+
+	// Copy the entry so that the palette entry is not changed in place
+	e := nbt.NBTTag{Type: entry.Type, Name: entry.Name, Value: append([]interface{}{}, values...)}
+	changed := false
+
+	if to, ok := r.Blocks[entry.BlockID()]; ok {
+		_ = e.SetChild(nbt.NBTTag{Type: nbt.TagString, Name: "name", Value: to})
+		changed = true
+	}
+
+	if states, ok := entry.Child("states"); ok {
+		stateValues, _ := states.Value.([]interface{})
+		newStates := nbt.NBTTag{Type: nbt.TagCompound, Name: "states", Value: append([]interface{}{}, stateValues...)}
+		statesChanged := false
+
+		for _, st := range states.Tags() {
+			v, ok := r.States[st.Name]
+			if !ok {
+				continue
+			}
+
+			if i, ok := st.Int(); ok && i != int64(v) {
+				_ = newStates.SetChild(nbt.NBTTag{Type: st.Type, Name: st.Name, Value: float64(v)})
+				statesChanged = true
+			}
+		}
+
+		if statesChanged {
+			_ = e.SetChild(newStates)
+			changed = true
+		}
+	}
+
+	return e, changed
+}
@@ -0,0 +1,78 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestResetStates(t *testing.T) {
+	entry := func(id string, states ...nbt.NBTTag) nbt.NBTTag {
+		e := testPaletteEntry(id)
+		_ = e.SetChild(testCompound("states", states...))
+		return e
+	}
+	byteTag := func(name string, v int) nbt.NBTTag { return nbt.NBTTag{Type: nbt.TagByte, Name: name, Value: v} }
+	intTag := func(name string, v int) nbt.NBTTag { return nbt.NBTTag{Type: nbt.TagInt, Name: name, Value: v} }
+
+	s := subChunkData{Version: 8, Blocks: blockStorage{
+		BitsPerBlock: 4,
+		Indices:      make([]int, subChunkBlockCount),
+		Palette: []nbt.NBTTag{
+			testPaletteEntry(BlockAir),
+			entry("minecraft:observer", intTag("facing_direction", 2), byteTag("powered_bit", 1)),
+			entry("minecraft:powered_repeater", intTag("direction", 3), intTag("repeater_delay", 1)),
+			entry("minecraft:observer", intTag("facing_direction", 2), byteTag("powered_bit", 0)),
+		},
+	}}
+	s.Blocks.Indices[subChunkVoxelToIndex(1, 1, 1)] = 1
+	s.Blocks.Indices[subChunkVoxelToIndex(5, 1, 1)] = 1
+	s.Blocks.Indices[subChunkVoxelToIndex(2, 1, 1)] = 2
+	s.Blocks.Indices[subChunkVoxelToIndex(3, 1, 1)] = 3
+
+	value, err := encodeSubChunk(&s, true)
+	if err != nil {
+		t.Fatalf("unexpected error encoding sub chunk: %s", err)
+	}
+
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.SubChunkPrefix}.Bytes(), value)
+
+	n, err := w.ResetStates(NewSelection(0, 0, 0, 3, 15, 3, 0), DefaultStateReset())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 blocks reset: got %d", n)
+	}
+
+	sub, err := w.SubChunk(0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := func(x int, name string) (string, int64) {
+		d := sub.data.Blocks
+		e := d.Palette[d.Indices[subChunkVoxelToIndex(x, 1, 1)]]
+		st, _ := e.Path("states", name)
+		i, _ := st.Int()
+		return e.BlockID(), i
+	}
+
+	if id, powered := state(1, "powered_bit"); id != "minecraft:observer" || powered != 0 {
+		t.Errorf("expected an unpowered observer in the region: got %s powered %d", id, powered)
+	}
+	if _, powered := state(5, "powered_bit"); powered != 1 {
+		t.Error("expected the observer outside the region to stay powered")
+	}
+	if id, direction := state(2, "direction"); id != "minecraft:unpowered_repeater" || direction != 3 {
+		t.Errorf("expected an unpowered repeater with direction 3: got %s direction %d", id, direction)
+	}
+
+	if n, err := w.ResetStates(NewSelection(0, 0, 0, 3, 15, 3, 0), DefaultStateReset()); err != nil || n != 0 {
+		t.Errorf("expected nothing to reset a second time: got %d, %v", n, err)
+	}
+}
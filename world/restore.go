@@ -0,0 +1,193 @@
+package world
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// RestoreReport summarises the result of World.RestoreChunks.
+type RestoreReport struct {
+	Chunks  int // Chunks copied from the backup
+	Removed int // Chunks which were not saved in the backup, so were removed to be generated again
+	Records int // Records written, including actors
+	// Moved are actors in the backup's chunks which have since moved to a chunk outside the restored area. They are
+	// left where they are, so the same actor isn't in the world twice.
+	Moved int
+}
+
+func (r RestoreReport) String() string {
+	return fmt.Sprintf("%d chunks restored, %d chunks removed, %d records written, %d actors left outside the area",
+		r.Chunks, r.Removed, r.Records, r.Moved)
+}
+
+// chunkRecords are the keys of the records of a group of chunks, keyed by chunk, and the storage IDs of the actors in
+// them.
+type chunkRecords struct {
+	keys   map[[2]int32][][]byte
+	actors map[string]bool
+}
+
+// regionRecords returns the keys of every record of the chunks between the given chunk coordinates inclusive, with
+// their actor records.
+func (w *World) regionRecords(minCX, minCZ, maxCX, maxCZ, dimension int) (chunkRecords, error) {
+	r := chunkRecords{keys: make(map[[2]int32][][]byte), actors: make(map[string]bool)}
+
+	in := func(x, z, d int32) bool {
+		return int(d) == dimension && int(x) >= minCX && int(x) <= maxCX && int(z) >= minCZ && int(z) <= maxCZ
+	}
+
+	keys, err := w.db.Keys()
+	if err != nil {
+		return r, fmt.Errorf("listing keys: %w", err)
+	}
+
+	for _, key := range keys {
+		if k, ok := leveldb.ParseChunkKey(key); ok && in(k.X, k.Z, k.Dimension) {
+			c := [2]int32{k.X, k.Z}
+			r.keys[c] = append(r.keys[c], key)
+			continue
+		}
+
+		x, z, d, ok := leveldb.ParseDigestKey(key)
+		if !ok || !in(x, z, d) {
+			continue
+		}
+
+		c := [2]int32{x, z}
+		r.keys[c] = append(r.keys[c], key)
+
+		ids, err := w.digest(x, z, d)
+		if err != nil {
+			return r, err
+		}
+
+		for _, id := range ids {
+			r.actors[string(id)] = true
+		}
+	}
+
+	return r, nil
+}
+
+// RestoreChunks replaces the chunks between the given chunk coordinates inclusive with the same chunks from a backup of
+// the world, such as to undo an accident. Every record of each chunk is replaced, including its entities and block
+// entities. Chunks which are not saved in the backup are removed from the world, so the game generates them again.
+func (w *World) RestoreChunks(backup *World, minCX, minCZ, maxCX, maxCZ, dimension int) (RestoreReport, error) {
+	var report RestoreReport
+
+	if minCX > maxCX {
+		minCX, maxCX = maxCX, minCX
+	}
+	if minCZ > maxCZ {
+		minCZ, maxCZ = maxCZ, minCZ
+	}
+
+	live, err := w.regionRecords(minCX, minCZ, maxCX, maxCZ, dimension)
+	if err != nil {
+		return report, err
+	}
+
+	saved, err := backup.regionRecords(minCX, minCZ, maxCX, maxCZ, dimension)
+	if err != nil {
+		return report, fmt.Errorf("reading backup: %w", err)
+	}
+
+	// Remove the current chunks and their actors first, so records which are not in the backup don't remain
+	for _, keys := range live.keys {
+		for _, key := range keys {
+			if err := w.delete(key); err != nil {
+				return report, fmt.Errorf("deleting key '%x': %w", key, err)
+			}
+		}
+	}
+
+	for id := range live.actors {
+		if err := w.delete(leveldb.ActorKey([]byte(id))); err != nil {
+			return report, fmt.Errorf("deleting actor '%x': %w", id, err)
+		}
+	}
+
+	// Actors which are in the world outside the area are not copied. Those removed above were inside the area.
+	moved := make(map[string]bool)
+	for id := range saved.actors {
+		if live.actors[id] {
+			continue
+		}
+
+		_, err := w.db.Get(leveldb.ActorKey([]byte(id)))
+		if err == nil {
+			moved[id] = true
+			continue
+		}
+		if !errors.Is(err, leveldb.ErrNotFound) {
+			return report, fmt.Errorf("getting actor '%x': %w", id, err)
+		}
+	}
+	report.Moved = len(moved)
+
+	for c, keys := range saved.keys {
+		for _, key := range keys {
+			value, err := backup.db.Get(key)
+			if err != nil {
+				return report, fmt.Errorf("getting key '%x' from backup: %w", key, err)
+			}
+
+			if _, _, _, ok := leveldb.ParseDigestKey(key); ok {
+				if value, err = backup.restoreActors(c, dimension, w, moved); err != nil {
+					return report, err
+				}
+				report.Records += len(value) / 8
+			}
+
+			if err := w.put(key, value); err != nil {
+				return report, fmt.Errorf("putting key '%x': %w", key, err)
+			}
+			report.Records++
+		}
+		report.Chunks++
+	}
+
+	for c := range live.keys {
+		if _, ok := saved.keys[c]; !ok {
+			report.Removed++
+		}
+	}
+
+	return report, nil
+}
+
+// restoreActors copies the actors in a chunk's digest to w, except those in skip, and returns the digest of the actors
+// which were copied.
+func (w *World) restoreActors(c [2]int32, dimension int, to *World, skip map[string]bool) ([]byte, error) {
+	ids, err := w.digest(c[0], c[1], int32(dimension))
+	if err != nil {
+		return nil, fmt.Errorf("reading backup: %w", err)
+	}
+
+	var digest bytes.Buffer
+
+	for _, id := range ids {
+		if skip[string(id)] {
+			continue
+		}
+
+		value, err := w.db.Get(leveldb.ActorKey(id))
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting actor '%x' from backup: %w", id, err)
+		}
+
+		if err := to.put(leveldb.ActorKey(id), value); err != nil {
+			return nil, fmt.Errorf("putting actor '%x': %w", id, err)
+		}
+
+		digest.Write(id)
+	}
+
+	return digest.Bytes(), nil
+}
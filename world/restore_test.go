@@ -0,0 +1,74 @@
+package world
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestRestoreChunks(t *testing.T) {
+	liveDB, backupDB := mock.NewLevelDB(), mock.NewLevelDB()
+	live, backup := &World{db: liveDB}, &World{db: backupDB}
+
+	key := func(x, z int32, y int8) []byte {
+		return leveldb.ChunkKey{X: x, Z: z, Tag: leveldb.SubChunkPrefix, SubChunkY: y}.Bytes()
+	}
+
+	original := testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:chest"})
+	id1, id2, id3 := []byte{0, 0, 0, 0, 0, 0, 0, 1}, []byte{0, 0, 0, 0, 0, 0, 0, 2}, []byte{0, 0, 0, 0, 0, 0, 0, 3}
+
+	// The backup has chunk 0 0 with two actors
+	_ = backupDB.Put(key(0, 0, 4), original)
+	_ = backupDB.Put(leveldb.DigestKey(0, 0, 0), append(append([]byte{}, id1...), id2...))
+	_ = backupDB.Put(leveldb.ActorKey(id1), []byte("cow"))
+	_ = backupDB.Put(leveldb.ActorKey(id2), []byte("old wolf"))
+
+	// Since the backup chunk 0 0 was changed, a new actor appeared in it and the wolf moved to chunk 5 5
+	_ = liveDB.Put(key(0, 0, 4), testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:lava"}))
+	_ = liveDB.Put(key(0, 0, 5), testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:lava"}))
+	_ = liveDB.Put(leveldb.DigestKey(0, 0, 0), id3)
+	_ = liveDB.Put(leveldb.ActorKey(id3), []byte("zombie"))
+	_ = liveDB.Put(leveldb.DigestKey(5, 5, 0), id2)
+	_ = liveDB.Put(leveldb.ActorKey(id2), []byte("wolf"))
+	_ = liveDB.Put(key(5, 5, 4), original)
+
+	// Chunk 1 0 was generated after the backup
+	_ = liveDB.Put(key(1, 0, 4), original)
+
+	report, err := live.RestoreChunks(backup, 1, 1, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := (RestoreReport{Chunks: 1, Removed: 1, Records: 3, Moved: 1}); report != want {
+		t.Errorf("expected report %+v: got %+v", want, report)
+	}
+
+	get := func(key []byte) []byte {
+		v, err := liveDB.Get(key)
+		if err != nil {
+			return nil
+		}
+		return v
+	}
+
+	if !bytes.Equal(get(key(0, 0, 4)), original) {
+		t.Errorf("expected sub chunk to be restored")
+	}
+
+	for _, k := range [][]byte{key(0, 0, 5), key(1, 0, 4), leveldb.ActorKey(id3)} {
+		if get(k) != nil {
+			t.Errorf("expected key '%x' not in the backup to be removed", k)
+		}
+	}
+
+	if !bytes.Equal(get(leveldb.DigestKey(0, 0, 0)), id1) || string(get(leveldb.ActorKey(id1))) != "cow" {
+		t.Errorf("expected only the cow to be restored to chunk 0 0")
+	}
+
+	if string(get(leveldb.ActorKey(id2))) != "wolf" || get(key(5, 5, 4)) == nil {
+		t.Errorf("expected records outside the area to be unchanged")
+	}
+}
@@ -0,0 +1,123 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/spill"
+)
+
+// keyScanner is implemented by databases which can list their keys without holding them all in memory.
+type keyScanner interface {
+	ForEachKey(f func(key []byte) error) error
+}
+
+// SetMemoryBudget limits the memory used by the intermediate results of operations over the whole world, such as the
+// list of keys to visit, to about the given number of bytes each. Results beyond the budget are written to temporary
+// files. A budget of 0 means no limit.
+func (w *World) SetMemoryBudget(bytes int64) {
+	w.memoryBudget = bytes
+}
+
+// eachKey calls f with every key in the database, stopping at the first error. The keys are listed before f is first
+// called, so f may change the database, and held in memory up to the memory budget and in a temporary file beyond it.
+// The key passed to f is only valid until f returns. A *PartialResultError is returned if the time budget b runs out.
+func (w *World) eachKey(b *scanBudget, f func(key []byte) error) error {
+	keys := spill.New(w.memoryBudget)
+	defer keys.Close()
+
+	if s, ok := w.db.(keyScanner); ok {
+		if err := s.ForEachKey(keys.Add); err != nil {
+			return fmt.Errorf("listing keys: %w", err)
+		}
+	} else {
+		all, err := w.db.Keys()
+		if err != nil {
+			return fmt.Errorf("listing keys: %w", err)
+		}
+
+		for _, key := range all {
+			if err := keys.Add(key); err != nil {
+				return fmt.Errorf("listing keys: %w", err)
+			}
+		}
+	}
+
+	done := 0
+
+	return keys.Each(func(key []byte) error {
+		if err := b.check(done, keys.Len(), "records"); err != nil {
+			return err
+		}
+		done++
+
+		return f(key)
+	})
+}
+
+// forEachSubChunk parses every sub chunk in the world and calls f with its key and data, in the world's scan order.
+// Iteration stops if f returns an error or the time budget b runs out.
+func (w *World) forEachSubChunk(b *scanBudget, f func(k leveldb.ChunkKey, s *subChunkData) error) error {
+	return w.eachSubChunkKey(b, func(k leveldb.ChunkKey, key []byte) error {
+		value, err := w.db.Get(key)
+		if err != nil {
+			return fmt.Errorf("getting sub chunk with key '%x': %w", key, err)
+		}
+
+		s, err := parseSubChunk(value)
+		if err != nil {
+			return fmt.Errorf("parsing sub chunk with key '%x': %w", key, err)
+		}
+
+		return f(k, s)
+	})
+}
+
+// subChunkKeyOrigin returns the world coordinates of the lowest corner of the sub chunk with the given key.
+func subChunkKeyOrigin(k leveldb.ChunkKey) (x, y, z int) {
+	return int(k.X) * chunkSize, int(k.SubChunkY) * chunkSize, int(k.Z) * chunkSize
+}
+
+// paletteContains returns true if any entry in the storage palette has the given block ID.
+func (s *blockStorage) paletteContains(id string) bool {
+	for _, p := range s.Palette {
+		if p.BlockID() == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ChunkPos is the position of a chunk in chunk coordinates.
+type ChunkPos struct {
+	X, Z      int
+	Dimension int
+}
+
+// Chunks returns the position of every chunk which has terrain saved, sorted by dimension, x and z.
+func (w *World) Chunks() ([]ChunkPos, error) {
+	generated, err := w.generatedChunks(w.startBudget())
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]ChunkPos, 0, len(generated))
+	for c := range generated {
+		chunks = append(chunks, ChunkPos{X: int(c[0]), Z: int(c[1]), Dimension: int(c[2])})
+	}
+
+	sort.Slice(chunks, func(i, j int) bool {
+		a, b := chunks[i], chunks[j]
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		return a.Z < b.Z
+	})
+
+	return chunks, nil
+}
@@ -0,0 +1,25 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+func TestChunks(t *testing.T) {
+	w, db := testEntityWorld(t)
+
+	_ = db.Put(leveldb.ChunkKey{X: -3, Z: 1, Dimension: 1, Tag: leveldb.SubChunkPrefix, SubChunkY: 2}.Bytes(), []byte{8, 0})
+	_ = db.Put(leveldb.ChunkKey{X: -3, Z: 1, Dimension: 1, Tag: leveldb.Version}.Bytes(), []byte{40})
+
+	chunks, err := w.Chunks()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []ChunkPos{{X: 0, Z: 0, Dimension: 0}, {X: -3, Z: 1, Dimension: 1}}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Fatalf("expected %+v: got %+v", want, chunks)
+	}
+}
@@ -0,0 +1,226 @@
+package world
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Wood is a wood species, which sets the logs, planks and leaves used by schematics.
+type Wood int
+
+// Wood species.
+const (
+	WoodOak Wood = iota
+	WoodBirch
+	WoodSpruce
+	WoodJungle
+)
+
+var woodNames = []string{"oak", "birch", "spruce", "jungle"}
+
+func (w Wood) String() string {
+	return enumName(woodNames, int(w), "Wood")
+}
+
+// ParseWood returns the wood species with the given name, e.g. oak.
+func ParseWood(s string) (Wood, error) {
+	i, err := parseEnum(woodNames, s, "wood")
+	return Wood(i), err
+}
+
+func (w Wood) block(suffix string) string {
+	return "minecraft:" + w.String() + "_" + suffix
+}
+
+// SchematicOptions are the parameters of a schematic. Zero values take the schematic's defaults.
+type SchematicOptions struct {
+	Size int   // The height of a tree's trunk, or the width and depth of a house
+	Wood Wood  // The species of a tree, or the wood a house is built from
+	Seed int64 // Varies random details such as missing leaves at the corners of a canopy
+}
+
+// schematic is a structure in the library, with the range of sizes it can be built at.
+type schematic struct {
+	defaultSize      func(opts SchematicOptions) int
+	minSize, maxSize int
+	build            func(b *schematicBuilder, opts SchematicOptions)
+}
+
+// schematics are the structures built by BuildSchematic, by name.
+var schematics = map[string]schematic{
+	"tree": {
+		defaultSize: func(opts SchematicOptions) int { return treeHeights[opts.Wood] },
+		minSize:     4, maxSize: 30, build: buildTree,
+	},
+	"house": {
+		defaultSize: func(SchematicOptions) int { return 7 },
+		minSize:     5, maxSize: 32, build: buildHouse,
+	},
+}
+
+// treeHeights are the default trunk heights of trees of each wood species.
+var treeHeights = map[Wood]int{WoodOak: 5, WoodBirch: 6, WoodSpruce: 8, WoodJungle: 10}
+
+// Schematics returns the names of the schematics built by BuildSchematic in alphabetical order.
+func Schematics() []string {
+	names := make([]string, 0, len(schematics))
+	for name := range schematics {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// BuildSchematic returns the named schematic as a clipboard, to be pasted into a world with an EditorSession:
+//
+//	s := world.NewEditorSession(w)
+//	s.Clipboard, err = world.BuildSchematic("tree", world.SchematicOptions{Wood: world.WoodSpruce})
+//	err = s.Paste(x, y, z, dimension)
+//
+// The trunk of a tree and the floor of a house are at the bottom of the clipboard, so paste them one block above the
+// surface. Positions around a tree's leaves are left unchanged, while the inside of a house is cleared to air.
+func BuildSchematic(name string, opts SchematicOptions) (*Clipboard, error) {
+	s, ok := schematics[name]
+	if !ok {
+		_, err := parseEnum(Schematics(), name, "schematic")
+		return nil, err
+	}
+
+	if opts.Wood < WoodOak || opts.Wood > WoodJungle {
+		return nil, fmt.Errorf("invalid wood %d", opts.Wood)
+	}
+
+	if opts.Size == 0 {
+		opts.Size = s.defaultSize(opts)
+	}
+
+	if opts.Size < s.minSize || opts.Size > s.maxSize {
+		return nil, fmt.Errorf("invalid %s size %d: must be from %d to %d", name, opts.Size, s.minSize, s.maxSize)
+	}
+
+	b := &schematicBuilder{blocks: make(map[[3]int]string), random: rand.New(rand.NewSource(opts.Seed))}
+	s.build(b, opts)
+
+	return b.clipboard(), nil
+}
+
+// schematicBuilder collects the blocks of a schematic, later blocks replacing earlier ones.
+type schematicBuilder struct {
+	blocks map[[3]int]string
+	random *rand.Rand
+}
+
+func (b *schematicBuilder) set(x, y, z int, id string) {
+	b.blocks[[3]int{x, y, z}] = id
+}
+
+// setIfEmpty sets a block only if no block has been set at its position.
+func (b *schematicBuilder) setIfEmpty(x, y, z int, id string) {
+	if _, ok := b.blocks[[3]int{x, y, z}]; !ok {
+		b.set(x, y, z, id)
+	}
+}
+
+// clipboard returns the blocks as a clipboard sized to fit them, in x, z then y order.
+func (b *schematicBuilder) clipboard() *Clipboard {
+	c := &Clipboard{Blocks: make([]Block, 0, len(b.blocks))}
+
+	for p, id := range b.blocks {
+		c.Blocks = append(c.Blocks, Block{ID: id, X: p[0], Y: p[1], Z: p[2]})
+		c.SizeX, c.SizeY, c.SizeZ = maxInt(c.SizeX, p[0]+1), maxInt(c.SizeY, p[1]+1), maxInt(c.SizeZ, p[2]+1)
+	}
+
+	sort.Slice(c.Blocks, func(i, j int) bool {
+		a, b := c.Blocks[i], c.Blocks[j]
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		return a.Y < b.Y
+	})
+
+	return c
+}
+
+// buildTree builds a tree with a trunk opts.Size blocks high in the middle of a 5x5 canopy. Spruce trees have a
+// conical canopy and other species a rounded one like the game's oak and birch trees.
+func buildTree(b *schematicBuilder, opts SchematicOptions) {
+	const center = 2
+	height := opts.Size
+	log, leaves := opts.Wood.block("log"), opts.Wood.block("leaves")
+
+	for y := 0; y < height; y++ {
+		b.set(center, y, center, log)
+	}
+
+	// leaf sets leaves within radius r of the trunk at height y, leaving out corners at radius r at random if random
+	// is true and always otherwise
+	leaf := func(y, r int, random bool) {
+		for dx := -r; dx <= r; dx++ {
+			for dz := -r; dz <= r; dz++ {
+				if r > 0 && absInt(dx) == r && absInt(dz) == r && (!random || b.random.Intn(2) == 0) {
+					continue
+				}
+				b.setIfEmpty(center+dx, y, center+dz, leaves)
+			}
+		}
+	}
+
+	if opts.Wood == WoodSpruce {
+		// Layers alternate between radius 1 and 2 from below the top down to the third block of the trunk
+		b.set(center, height, center, leaves)
+		for y := height - 1; y >= 2; y-- {
+			leaf(y, 2-(height-y)%2, false)
+		}
+		return
+	}
+
+	leaf(height, 1, false)
+	leaf(height-1, 1, true)
+	leaf(height-2, 2, true)
+	leaf(height-3, 2, true)
+}
+
+// buildHouse builds a house opts.Size blocks wide and deep, with a plank floor and roof, log corners, glass windows in
+// three walls, a doorway in the middle of the wall facing negative z and a torch in the corner opposite.
+func buildHouse(b *schematicBuilder, opts SchematicOptions) {
+	const wallHeight = 3
+	size := opts.Size
+	log, planks := opts.Wood.block("log"), opts.Wood.block("planks")
+
+	for x := 0; x < size; x++ {
+		for z := 0; z < size; z++ {
+			b.set(x, 0, z, planks)
+			b.set(x, wallHeight+1, z, planks)
+
+			edgeX, edgeZ := x == 0 || x == size-1, z == 0 || z == size-1
+
+			for y := 1; y <= wallHeight; y++ {
+				switch {
+				case edgeX && edgeZ:
+					b.set(x, y, z, log)
+				case edgeX || edgeZ:
+					b.set(x, y, z, planks)
+				default:
+					b.set(x, y, z, BlockAir)
+				}
+			}
+		}
+	}
+
+	middle := size / 2
+
+	// Windows in the middle of the walls facing positive z, negative x and positive x
+	for _, p := range [][2]int{{middle, size - 1}, {0, middle}, {size - 1, middle}} {
+		b.set(p[0], 2, p[1], BlockGlass)
+	}
+
+	b.set(middle, 1, 0, BlockAir)
+	b.set(middle, 2, 0, BlockAir)
+	b.set(1, 1, size-2, BlockTorch)
+}
@@ -0,0 +1,58 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildSchematic(t *testing.T) {
+	for _, name := range Schematics() {
+		for _, wood := range []Wood{WoodOak, WoodBirch, WoodSpruce, WoodJungle} {
+			c, err := BuildSchematic(name, SchematicOptions{Wood: wood, Seed: 1})
+			if err != nil {
+				t.Fatalf("unexpected error building %s %s: %s", wood, name, err)
+			}
+
+			counts := make(map[string]int)
+			for _, b := range c.Blocks {
+				if b.X < 0 || b.Y < 0 || b.Z < 0 || b.X >= c.SizeX || b.Y >= c.SizeY || b.Z >= c.SizeZ {
+					t.Errorf("%s %s block %+v is outside the clipboard", wood, name, b)
+				}
+				counts[b.ID]++
+			}
+
+			if counts[wood.block("log")] == 0 {
+				t.Errorf("expected %s %s to have %s logs: got %v", wood, name, wood, counts)
+			}
+
+			again, _ := BuildSchematic(name, SchematicOptions{Wood: wood, Seed: 1})
+			if !reflect.DeepEqual(c, again) {
+				t.Errorf("expected %s %s to be the same when built with the same seed", wood, name)
+			}
+		}
+	}
+
+	tree, _ := BuildSchematic("tree", SchematicOptions{Wood: WoodBirch, Size: 7})
+	if tree.SizeX != 5 || tree.SizeY != 8 || tree.SizeZ != 5 {
+		t.Errorf("expected a 5x8x5 tree: got %dx%dx%d", tree.SizeX, tree.SizeY, tree.SizeZ)
+	}
+
+	house, _ := BuildSchematic("house", SchematicOptions{})
+	if house.SizeX != 7 || house.SizeY != 5 || house.SizeZ != 7 {
+		t.Errorf("expected a 7x5x7 house: got %dx%dx%d", house.SizeX, house.SizeY, house.SizeZ)
+	}
+
+	for _, invalid := range []struct {
+		name string
+		opts SchematicOptions
+	}{
+		{"castle", SchematicOptions{}},
+		{"tree", SchematicOptions{Size: 2}},
+		{"house", SchematicOptions{Size: 100}},
+		{"tree", SchematicOptions{Wood: Wood(9)}},
+	} {
+		if _, err := BuildSchematic(invalid.name, invalid.opts); err == nil {
+			t.Errorf("expected an error building %s with %+v", invalid.name, invalid.opts)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// Server files in the directory of a Bedrock Dedicated Server. The allowlist was called the whitelist before 1.18.10.
+const (
+	permissionsFile = "permissions.json"
+	allowlistFile   = "allowlist.json"
+	whitelistFile   = "whitelist.json"
+)
+
+// ServerPlayer is a player listed in the permissions or allowlist file of a dedicated server.
+type ServerPlayer struct {
+	Name               string // The gamertag, which is only saved in the allowlist
+	XUID               string // The player's Xbox user ID
+	Permission         string // operator, member or visitor, or empty if the player is not in the permissions file
+	Allowed            bool   // True if the player is in the allowlist
+	IgnoresPlayerLimit bool
+}
+
+// ServerFiles are the players listed in the files of a dedicated server, sorted by XUID.
+type ServerFiles struct {
+	Players []ServerPlayer
+}
+
+// ServerDir returns the directory of the dedicated server a world is in, which holds the world in its worlds directory.
+// The returned bool is false if the world is not in a server directory.
+func ServerDir(worldPath string) (string, bool) {
+	dir := filepath.Dir(filepath.Dir(filepath.Clean(worldPath)))
+
+	for _, name := range []string{permissionsFile, allowlistFile, whitelistFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return dir, true
+		}
+	}
+
+	return "", false
+}
+
+// ReadServerFiles reads the permissions and allowlist files in the directory of a dedicated server. Missing files are
+// treated as empty.
+func ReadServerFiles(dir string) (ServerFiles, error) {
+	players := make(map[string]*ServerPlayer)
+	player := func(xuid string) *ServerPlayer {
+		if players[xuid] == nil {
+			players[xuid] = &ServerPlayer{XUID: xuid}
+		}
+		return players[xuid]
+	}
+
+	var permissions []struct {
+		Permission string `json:"permission"`
+		XUID       string `json:"xuid"`
+	}
+	if err := readServerFile(filepath.Join(dir, permissionsFile), &permissions); err != nil {
+		return ServerFiles{}, err
+	}
+
+	for _, p := range permissions {
+		player(p.XUID).Permission = p.Permission
+	}
+
+	var allowlist []struct {
+		Name               string `json:"name"`
+		XUID               string `json:"xuid"`
+		IgnoresPlayerLimit bool   `json:"ignoresPlayerLimit"`
+	}
+	for _, name := range []string{allowlistFile, whitelistFile} {
+		if err := readServerFile(filepath.Join(dir, name), &allowlist); err != nil {
+			return ServerFiles{}, err
+		}
+	}
+
+	for _, a := range allowlist {
+		// Players added to the allowlist by name have no XUID until they first join
+		key := a.XUID
+		if key == "" {
+			key = "name:" + a.Name
+		}
+
+		p := player(key)
+		p.XUID = a.XUID
+		p.Name = a.Name
+		p.Allowed = true
+		p.IgnoresPlayerLimit = a.IgnoresPlayerLimit
+	}
+
+	f := ServerFiles{Players: make([]ServerPlayer, 0, len(players))}
+	for _, p := range players {
+		f.Players = append(f.Players, *p)
+	}
+
+	sort.Slice(f.Players, func(i, j int) bool {
+		a, b := f.Players[i], f.Players[j]
+		if a.XUID != b.XUID {
+			return a.XUID < b.XUID
+		}
+		return a.Name < b.Name
+	})
+
+	return f, nil
+}
+
+// readServerFile decodes the JSON list in a server file, appending to v. A missing file is ignored.
+func readServerFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Player returns the player with the given XUID.
+func (f ServerFiles) Player(xuid string) (ServerPlayer, bool) {
+	i := sort.Search(len(f.Players), func(i int) bool { return f.Players[i].XUID >= xuid })
+	if xuid == "" || i == len(f.Players) || f.Players[i].XUID != xuid {
+		return ServerPlayer{}, false
+	}
+
+	return f.Players[i], true
+}
+
+// ServerPlayers returns the players in the server files with the key of their player record, e.g.
+// player_server_<uuid>, for every player record which can be matched to a player in the files.
+//
+// Player records are not keyed by XUID. Instead the server saves an identity record for each player, keyed by
+// player_ and an ID, which holds the key of the player record as ServerId. A player is matched if their XUID is the
+// ID in the identity record's key or one of the MsaId, SelfSignedId or PlatformOnlineId values in it.
+func (w *World) ServerPlayers(f ServerFiles) (map[string]ServerPlayer, error) {
+	keys, err := w.playerKeys(w.startBudget())
+	if err != nil {
+		return nil, err
+	}
+
+	players := make(map[string]ServerPlayer)
+
+	for _, k := range keys {
+		if !strings.HasPrefix(k, "player_") || strings.HasPrefix(k, serverPlayerPrefix) {
+			continue
+		}
+
+		root, err := w.singleTagRecord([]byte(k))
+		if err != nil {
+			return nil, err
+		}
+		if root == nil {
+			continue
+		}
+
+		serverID, ok := root.Child("ServerId")
+		if !ok {
+			continue
+		}
+		playerKey, _ := serverID.StringValue()
+
+		ids := []string{strings.TrimPrefix(k, "player_")}
+		for _, name := range []string{"MsaId", "SelfSignedId", "PlatformOnlineId"} {
+			if t, ok := root.Child(name); ok {
+				id, _ := t.StringValue()
+				ids = append(ids, id)
+			}
+		}
+
+		for _, id := range ids {
+			if p, ok := f.Player(id); ok {
+				players[playerKey] = p
+				break
+			}
+		}
+	}
+
+	return players, nil
+}
+
+// isIdentityRecord returns true if the root tag of a player_ record is an identity record, which links a player's
+// account IDs to their player record and holds no player data.
+func isIdentityRecord(root nbt.NBTTag) bool {
+	_, ok := root.Child("ServerId")
+	return ok
+}
@@ -0,0 +1,89 @@
+package world
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestServerPlayers(t *testing.T) {
+	dir := t.TempDir()
+	worldDir := filepath.Join(dir, "worlds", "Bedrock level")
+	if err := os.MkdirAll(worldDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"permissions.json": `[{"permission": "operator", "xuid": "2535400000000001"}]`,
+		"allowlist.json": `[{"ignoresPlayerLimit": false, "name": "Alex", "xuid": "2535400000000001"},
+			{"ignoresPlayerLimit": true, "name": "Steve", "xuid": ""}]`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if d, ok := ServerDir(worldDir); !ok || d != dir {
+		t.Fatalf("expected server directory %s: got %s, %t", dir, d, ok)
+	}
+
+	if _, ok := ServerDir(t.TempDir()); ok {
+		t.Error("expected a directory without server files not to be a server directory")
+	}
+
+	f, err := ReadServerFiles(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(f.Players) != 2 {
+		t.Fatalf("expected 2 players: got %+v", f.Players)
+	}
+
+	alex, ok := f.Player("2535400000000001")
+	if !ok || alex.Name != "Alex" || alex.Permission != "operator" || !alex.Allowed {
+		t.Errorf("unexpected player %+v", alex)
+	}
+
+	if _, ok := f.Player(""); ok {
+		t.Error("expected no player for an empty XUID")
+	}
+
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	str := func(name, v string) nbt.NBTTag { return nbt.NBTTag{Type: nbt.TagString, Name: name, Value: v} }
+	for k, root := range map[string]nbt.NBTTag{
+		"player_1a2b":          testCompound("", str("MsaId", "2535400000000001"), str("ServerId", "player_server_c3d4")),
+		"player_server_c3d4":   testEntity("minecraft:player", 1, 0, 64, 0),
+		"player_server_unused": testEntity("minecraft:player", 2, 0, 64, 0),
+	} {
+		value, err := nbt.Encode([]nbt.NBTTag{root})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = db.Put([]byte(k), value)
+	}
+
+	players, err := w.ServerPlayers(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(players) != 1 || players["player_server_c3d4"].Name != "Alex" {
+		t.Errorf("expected player_server_c3d4 to be Alex: got %+v", players)
+	}
+
+	positions, err := w.PlayerPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(positions) != 2 {
+		t.Errorf("expected the identity record not to be listed as a player: got %+v", positions)
+	}
+}
@@ -0,0 +1,97 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// ErrChunkNotGenerated is returned when setting a block in a chunk the game has never generated. The game would
+// replace the chunk with new terrain when it is next loaded.
+var ErrChunkNotGenerated = errors.New("chunk has not been generated")
+
+// SetBlock sets the block at the given coordinates to the block's ID. The coordinates of the block are ignored. The
+// sub chunk holding the block is re-encoded and written back to the database.
+func (w *World) SetBlock(x, y, z, dimension int, b Block) error {
+	b.X, b.Y, b.Z = x, y, z
+	return w.SetBlocks([]Block{b}, dimension)
+}
+
+// SetBlocks sets the blocks at the coordinates of each block in a dimension. Each sub chunk is read and written once,
+// so this is much faster than calling SetBlock for each block. Sub chunks of air are added to generated chunks where
+// needed.
+func (w *World) SetBlocks(blocks []Block, dimension int) error {
+	bySubChunk := make(map[[3]int][]Block)
+	for _, b := range blocks {
+		if err := checkHeight(b.Y, dimension); err != nil {
+			return err
+		}
+
+		c := [3]int{floorDiv(b.X, chunkSize), floorDiv(b.Y, chunkSize), floorDiv(b.Z, chunkSize)}
+
+		bySubChunk[c] = append(bySubChunk[c], b)
+	}
+
+	// Sub chunks are written in order so that failures are repeatable
+	order := make([][3]int, 0, len(bySubChunk))
+	for c := range bySubChunk {
+		order = append(order, c)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		if a[2] != b[2] {
+			return a[2] < b[2]
+		}
+		return a[1] < b[1]
+	})
+
+	for _, c := range order {
+		s, err := w.SubChunk(c[0], c[1], c[2], dimension)
+		if errors.Is(err, &SubChunkNotSavedError{}) {
+			generated, err := w.chunkGenerated(c[0], c[2], dimension)
+			if err != nil {
+				return err
+			}
+			if !generated {
+				return fmt.Errorf("setting blocks in chunk %d %d: %w", c[0], c[2], ErrChunkNotGenerated)
+			}
+
+			s = NewSubChunk(c[0], c[1], c[2], dimension, BlockAir)
+		} else if err != nil {
+			return err
+		}
+
+		for _, b := range bySubChunk[c] {
+			s.setAt(b.X-c[0]*chunkSize, b.Y-c[1]*chunkSize, b.Z-c[2]*chunkSize, b)
+		}
+
+		if err := w.SetSubChunk(s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chunkGenerated returns true if the chunk at the given chunk coordinates has a version record, which the game writes
+// for every chunk it generates.
+func (w *World) chunkGenerated(cx, cz, dimension int) (bool, error) {
+	for _, tag := range []byte{leveldb.Version, leveldb.VersionOld} {
+		k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension), Tag: tag}
+
+		_, err := w.db.Get(k.Bytes())
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, leveldb.ErrNotFound) {
+			return false, fmt.Errorf("getting chunk version with key '%x': %w", k.Bytes(), err)
+		}
+	}
+
+	return false, nil
+}
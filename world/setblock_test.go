@@ -0,0 +1,162 @@
+package world
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestSetBlock(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	// Chunk -1 -1 is generated with one saved sub chunk, chunk 5 5 is not generated
+	_ = db.Put(leveldb.ChunkKey{X: -1, Z: -1, Tag: leveldb.Version}.Bytes(), []byte{40})
+	_ = db.Put(leveldb.ChunkKey{X: -1, Z: -1, Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{15, 0, 15}: BlockStone}))
+
+	if err := w.SetBlock(-1, 0, -1, 0, Block{ID: BlockDirt}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// A sub chunk of air is added above the saved one
+	if err := w.SetBlock(-16, 40, -3, 0, Block{ID: BlockGlass}); err != nil {
+		t.Fatalf("unexpected error setting a block in an unsaved sub chunk: %s", err)
+	}
+
+	for _, c := range []struct {
+		x, y, z int
+		want    string
+	}{
+		{-1, 0, -1, BlockDirt},
+		{-2, 0, -1, BlockAir},
+		{-16, 40, -3, BlockGlass},
+		{-16, 41, -3, BlockAir},
+	} {
+		s, err := w.SubChunk(floorDiv(c.x, 16), floorDiv(c.y, 16), floorDiv(c.z, 16), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b := s.at(c.x&15, c.y&15, c.z&15); b.ID != c.want || b.X != c.x || b.Y != c.y || b.Z != c.z {
+			t.Errorf("expected %s at %d %d %d: got %s at %d %d %d", c.want, c.x, c.y, c.z, b.ID, b.X, b.Y, b.Z)
+		}
+	}
+
+	if err := w.SetBlock(80, 0, 80, 0, Block{ID: BlockDirt}); !errors.Is(err, ErrChunkNotGenerated) {
+		t.Errorf("expected ErrChunkNotGenerated: got %v", err)
+	}
+
+	if err := w.SetBlock(0, 400, 0, 0, Block{ID: BlockDirt}); err == nil {
+		t.Error("expected an error setting a block above the dimension")
+	}
+}
+
+func TestEditorSessionPaste(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.Version}.Bytes(), []byte{40})
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{1, 1, 1}: BlockStone, {2, 1, 1}: BlockDirt}))
+
+	before, _ := db.Get(leveldb.ChunkKey{Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes())
+
+	s := NewEditorSession(w)
+	if err := s.Paste(0, 0, 0, 0); !errors.Is(err, ErrEmptyClipboard) {
+		t.Errorf("expected ErrEmptyClipboard: got %v", err)
+	}
+
+	s.Select(NewSelection(1, 1, 1, 2, 1, 1, 0))
+	if err := s.Copy(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Paste(5, 6, 7, 0); err != nil {
+		t.Fatalf("unexpected error pasting: %s", err)
+	}
+
+	for pos, want := range map[[3]int]string{{5, 6, 7}: BlockStone, {6, 6, 7}: BlockDirt, {7, 6, 7}: BlockAir} {
+		b, err := w.GetBlock(pos[0], pos[1], pos[2], 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b.ID != want {
+			t.Errorf("expected %s at %v after pasting: got %s", want, pos, b.ID)
+		}
+	}
+
+	if err := s.Undo(); err != nil {
+		t.Fatalf("unexpected error undoing the paste: %s", err)
+	}
+
+	after, _ := db.Get(leveldb.ChunkKey{Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes())
+	if string(after) != string(before) {
+		t.Error("expected undo to restore the sub chunk")
+	}
+}
+
+func TestSetBlockKeepsStates(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.Version}.Bytes(), []byte{40})
+	_ = db.Put(leveldb.ChunkKey{Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes(), testSubChunkValue(t, nil))
+
+	stairs := testPaletteEntry("minecraft:oak_stairs")
+	_ = stairs.SetChild(testCompound("states",
+		nbt.NBTTag{Type: nbt.TagInt, Name: "weirdo_direction", Value: 2},
+		nbt.NBTTag{Type: nbt.TagByte, Name: "upside_down_bit", Value: 1}))
+
+	b := NewBlock(stairs)
+	b.waterLogged = true
+	if err := w.SetBlock(1, 1, 1, 0, b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got, err := w.GetBlock(1, 1, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `["upside_down_bit"=true,"weirdo_direction"=2]`
+	if got.StatesString() != want || got.Version() != b.Version() || !got.IsWaterlogged() {
+		t.Errorf("expected %s version %d water logged: got %s", want, b.Version(), got)
+	}
+
+	// A block read from the world is set with the palette entry it was read with
+	if err := w.SetBlock(2, 1, 1, 0, got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// A block read from the world whose ID was changed has no states
+	got.ID = BlockStone
+	if err := w.SetBlock(3, 1, 1, 0, got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for x, want := range map[int]string{2: want, 3: ""} {
+		b, err := w.GetBlock(x, 1, 1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b.StatesString() != want {
+			t.Errorf("expected states '%s' at %d 1 1: got '%s'", want, x, b.StatesString())
+		}
+	}
+
+	s, err := w.SubChunk(0, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Air, the stairs and stone
+	if n := len(s.data.Blocks.Palette); n != 3 {
+		t.Errorf("expected the stairs to share one palette entry, giving 3 entries: got %d", n)
+	}
+}
@@ -0,0 +1,162 @@
+package world
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// Difficulty is the world difficulty.
+type Difficulty int
+
+// Difficulty levels.
+const (
+	Peaceful Difficulty = iota
+	Easy
+	Normal
+	Hard
+)
+
+var difficultyNames = []string{"peaceful", "easy", "normal", "hard"}
+
+func (d Difficulty) String() string {
+	return enumName(difficultyNames, int(d), "Difficulty")
+}
+
+// ParseDifficulty returns the difficulty with the given name, e.g. hard.
+func ParseDifficulty(s string) (Difficulty, error) {
+	i, err := parseEnum(difficultyNames, s, "difficulty")
+	return Difficulty(i), err
+}
+
+// PermissionLevel is the permission level given to players joining the world for the first time.
+type PermissionLevel int
+
+// Permission levels.
+const (
+	Visitor PermissionLevel = iota
+	Member
+	Operator
+)
+
+var permissionLevelNames = []string{"visitor", "member", "operator"}
+
+func (p PermissionLevel) String() string {
+	return enumName(permissionLevelNames, int(p), "PermissionLevel")
+}
+
+// ParsePermissionLevel returns the permission level with the given name, e.g. member.
+func ParsePermissionLevel(s string) (PermissionLevel, error) {
+	i, err := parseEnum(permissionLevelNames, s, "permission level")
+	return PermissionLevel(i), err
+}
+
+// BroadcastMode controls who can see and join the world over Xbox Live or the platform's network.
+type BroadcastMode int
+
+// Broadcast modes.
+const (
+	NoBroadcast BroadcastMode = iota
+	InviteOnly
+	FriendsOnly
+	FriendsOfFriends
+	Public
+)
+
+var broadcastModeNames = []string{"none", "invite-only", "friends-only", "friends-of-friends", "public"}
+
+func (b BroadcastMode) String() string {
+	return enumName(broadcastModeNames, int(b), "BroadcastMode")
+}
+
+// ParseBroadcastMode returns the broadcast mode with the given name, e.g. friends-of-friends.
+func ParseBroadcastMode(s string) (BroadcastMode, error) {
+	i, err := parseEnum(broadcastModeNames, s, "broadcast mode")
+	return BroadcastMode(i), err
+}
+
+// Settings are the difficulty and multiplayer settings stored in level.dat.
+type Settings struct {
+	Difficulty         Difficulty
+	DefaultPermissions PermissionLevel
+	Multiplayer        bool // Other players may join the world
+	LANBroadcast       bool // The world is visible to players on the local network
+	XBLBroadcast       BroadcastMode
+	PlatformBroadcast  BroadcastMode
+}
+
+// validate returns an error if any setting is out of range.
+func (s Settings) validate() error {
+	for _, v := range []struct {
+		value, count int
+		name         string
+	}{
+		{int(s.Difficulty), len(difficultyNames), "difficulty"},
+		{int(s.DefaultPermissions), len(permissionLevelNames), "default permissions"},
+		{int(s.XBLBroadcast), len(broadcastModeNames), "xbox live broadcast"},
+		{int(s.PlatformBroadcast), len(broadcastModeNames), "platform broadcast"},
+	} {
+		if v.value < 0 || v.value >= v.count {
+			return fmt.Errorf("invalid %s %d", v.name, v.value)
+		}
+	}
+
+	return nil
+}
+
+// fields returns the level.dat tags holding each setting. The multiplayer settings are held twice, as the game keeps
+// an intent tag recording the setting chosen by the player alongside the setting in effect. Both are written so the
+// game doesn't restore the old value, and the intent is read as it is what the game shows in the world settings.
+func (s *Settings) fields() []levelDatField {
+	return []levelDatField{
+		{"Difficulty", nbt.TagInt, (*int)(&s.Difficulty)},
+		{"PlayerPermissionsLevel", nbt.TagInt, (*int)(&s.DefaultPermissions)},
+		{"MultiplayerGame", nbt.TagByte, &s.Multiplayer},
+		{"MultiplayerGameIntent", nbt.TagByte, &s.Multiplayer},
+		{"LANBroadcast", nbt.TagByte, &s.LANBroadcast},
+		{"LANBroadcastIntent", nbt.TagByte, &s.LANBroadcast},
+		{"XBLBroadcastIntent", nbt.TagInt, (*int)(&s.XBLBroadcast)},
+		{"PlatformBroadcastIntent", nbt.TagInt, (*int)(&s.PlatformBroadcast)},
+	}
+}
+
+// Settings reads the difficulty and multiplayer settings from level.dat.
+func (w *World) Settings() (Settings, error) {
+	s := Settings{}
+	if err := w.readLevelDatFields(s.fields()); err != nil {
+		return Settings{}, err
+	}
+
+	return s, nil
+}
+
+// SetSettings writes the difficulty and multiplayer settings to level.dat. The game overwrites level.dat when the world
+// is closed, so this must not be called while the world is open in the game or a server.
+func (w *World) SetSettings(s Settings) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	return w.setLevelDatFields(s.fields())
+}
+
+// enumName returns the name at index i of names, or the type name and number if i is out of range.
+func enumName(names []string, i int, typeName string) string {
+	if i < 0 || i >= len(names) {
+		return fmt.Sprintf("%s(%d)", typeName, i)
+	}
+
+	return names[i]
+}
+
+// parseEnum returns the index of s in names, ignoring case.
+func parseEnum(names []string, s, kind string) (int, error) {
+	for i, n := range names {
+		if strings.EqualFold(n, s) {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid %s '%s': expected one of %s", kind, s, strings.Join(names, ", "))
+}
@@ -0,0 +1,71 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestSettings(t *testing.T) {
+	intTag := func(name string, v int) nbt.NBTTag {
+		return nbt.NBTTag{Name: name, Type: nbt.TagInt, Value: float64(v)}
+	}
+	byteTag := func(name string, v int) nbt.NBTTag {
+		return nbt.NBTTag{Name: name, Type: nbt.TagByte, Value: float64(v)}
+	}
+
+	w := testLevelDat(t,
+		intTag("Difficulty", 2),
+		intTag("PlayerPermissionsLevel", 1),
+		byteTag("MultiplayerGame", 1),
+		byteTag("MultiplayerGameIntent", 1),
+		byteTag("LANBroadcast", 1),
+		byteTag("LANBroadcastIntent", 1),
+		intTag("XBLBroadcastIntent", 3),
+		intTag("PlatformBroadcastIntent", 3),
+	)
+
+	s, err := w.Settings()
+	if err != nil {
+		t.Fatalf("unexpected error reading settings: %s", err)
+	}
+
+	want := Settings{Normal, Member, true, true, FriendsOfFriends, FriendsOfFriends}
+	if s != want {
+		t.Errorf("expected settings %+v: got %+v", want, s)
+	}
+
+	s.Difficulty, err = ParseDifficulty("Hard")
+	if err != nil {
+		t.Fatalf("unexpected error parsing difficulty: %s", err)
+	}
+	s.Multiplayer = false
+	s.XBLBroadcast = InviteOnly
+
+	if err := w.SetSettings(s); err != nil {
+		t.Fatalf("unexpected error writing settings: %s", err)
+	}
+
+	got, err := w.Settings()
+	if err != nil {
+		t.Fatalf("unexpected error reading settings after writing: %s", err)
+	}
+
+	if got != s {
+		t.Errorf("expected settings %+v: got %+v", s, got)
+	}
+
+	l, _ := w.levelDat()
+	if v := childInt(l, "MultiplayerGame"); v != 0 {
+		t.Errorf("expected MultiplayerGame to be written with its intent: got %d", v)
+	}
+
+	s.DefaultPermissions = 7
+	if err := w.SetSettings(s); err == nil {
+		t.Errorf("expected error writing invalid permission level")
+	}
+
+	if _, err := ParseBroadcastMode("everyone"); err == nil {
+		t.Errorf("expected error parsing invalid broadcast mode")
+	}
+}
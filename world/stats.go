@@ -0,0 +1,291 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// Stats are statistics of a whole world, for summaries such as the report command.
+type Stats struct {
+	Chunks   map[int]int            // Saved chunks by dimension
+	Blocks   map[string]int         // Saved blocks other than air, by ID
+	Ores     map[string]map[int]int // Ore blocks by ID and by the Y coordinate of the bottom of the sub chunk holding them
+	Biomes   map[string]int         // Overworld columns by biome name, at the top of each column
+	Entities map[string]int         // Entities by identifier
+	Players  int                    // Player records
+	Builds   []Build                // Groups of neighbouring chunks with many crafted blocks, largest first
+}
+
+// Build is a group of neighbouring chunks which each have many blocks which are only found in player made structures,
+// such as planks and glass, so is likely to be a build. Villages and other generated structures are also found.
+type Build struct {
+	Dimension              int
+	MinX, MinZ, MaxX, MaxZ int // Block coordinates of the corners of the chunks
+	Chunks                 int
+	Blocks                 int // Crafted blocks in the chunks
+}
+
+// maxBuilds is the number of builds kept in Stats.
+const maxBuilds = 10
+
+// minBuildChunkBlocks is the number of crafted blocks a chunk must have to be part of a build.
+const minBuildChunkBlocks = 64
+
+// craftedBlockNames are substrings of the IDs of blocks which are rarely generated, so mostly placed by players.
+var craftedBlockNames = []string{
+	"planks", "glass", "concrete", "wool", "carpet", "stone_bricks", "brick_block", "bookshelf", "crafting_table",
+	"lantern", "torch", "bed", "quartz_block", "smooth_stone", "polished_", "door", "fence", "_stairs", "slab",
+}
+
+// isCrafted returns true if the block ID is one usually placed by players.
+func isCrafted(id string) bool {
+	for _, n := range craftedBlockNames {
+		if strings.Contains(id, n) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOre returns true if the block ID is an ore.
+func isOre(id string) bool {
+	return strings.HasSuffix(id, "_ore") || id == BlockAncientDebris
+}
+
+// Stats reads every sub chunk, biome and entity record to gather statistics of the world. If the time budget runs out
+// the statistics gathered so far are returned with a *PartialResultError.
+func (w *World) Stats() (Stats, error) {
+	s := Stats{
+		Chunks:   make(map[int]int),
+		Blocks:   make(map[string]int),
+		Ores:     make(map[string]map[int]int),
+		Biomes:   make(map[string]int),
+		Entities: make(map[string]int),
+	}
+
+	b := w.startBudget()
+
+	generated, err := w.generatedChunks(b)
+	if err != nil {
+		return s, err
+	}
+
+	for c := range generated {
+		s.Chunks[int(c[2])]++
+	}
+
+	crafted := make(map[[3]int32]int)
+
+	err = w.forEachSubChunk(b, func(k leveldb.ChunkKey, sc *subChunkData) error {
+		_, oy, _ := subChunkKeyOrigin(k)
+
+		for id, n := range sc.Blocks.counts() {
+			if id == BlockAir {
+				continue
+			}
+
+			s.Blocks[id] += n
+
+			if isOre(id) {
+				if s.Ores[id] == nil {
+					s.Ores[id] = make(map[int]int)
+				}
+				s.Ores[id][oy] += n
+			}
+
+			if isCrafted(id) {
+				crafted[[3]int32{k.X, k.Z, k.Dimension}] += n
+			}
+		}
+
+		return nil
+	})
+
+	// Builds are found in the sub chunks read so far, even if the budget ran out
+	s.Builds = findBuilds(crafted)
+	if err != nil {
+		return s, err
+	}
+
+	if err := w.overworldBiomes(b, generated, s.Biomes); err != nil {
+		return s, err
+	}
+
+	err = w.scanEntities(b, func(e Entity) error {
+		s.Entities[e.Identifier]++
+		return nil
+	})
+	if err != nil {
+		return s, err
+	}
+
+	players, err := w.playerKeys(b)
+	if err != nil {
+		return s, err
+	}
+	s.Players = len(players)
+
+	return s, nil
+}
+
+// overworldBiomes adds the number of columns of each biome in the generated overworld chunks to biomes, stopping if
+// the time budget b runs out.
+func (w *World) overworldBiomes(b *scanBudget, generated map[[3]int32]bool, biomes map[string]int) error {
+	done := 0
+
+	for c := range generated {
+		if err := b.check(done, len(generated), "chunks"); err != nil {
+			return err
+		}
+		done++
+
+		if c[2] != 0 {
+			continue
+		}
+
+		columns, ok, err := w.ChunkBiomes(int(c[0]), int(c[1]), 0, nil)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		for x := range columns {
+			for z := range columns[x] {
+				biomes[BiomeName(columns[x][z])]++
+			}
+		}
+	}
+
+	return nil
+}
+
+// findBuilds groups neighbouring chunks with enough crafted blocks, including diagonal neighbours, and returns the
+// groups with the most crafted blocks.
+func findBuilds(crafted map[[3]int32]int) []Build {
+	seen := make(map[[3]int32]bool)
+	builds := make([]Build, 0)
+
+	// Chunks are visited in a fixed order so that builds with the same size are always listed in the same order
+	chunks := make([][3]int32, 0, len(crafted))
+	for c, n := range crafted {
+		if n >= minBuildChunkBlocks {
+			chunks = append(chunks, c)
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		a, b := chunks[i], chunks[j]
+		if a[2] != b[2] {
+			return a[2] < b[2]
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		return a[1] < b[1]
+	})
+
+	for _, start := range chunks {
+		if seen[start] {
+			continue
+		}
+
+		b := Build{Dimension: int(start[2]), MinX: int(start[0]), MaxX: int(start[0]), MinZ: int(start[1]),
+			MaxZ: int(start[1])}
+
+		queue := [][3]int32{start}
+		seen[start] = true
+
+		for len(queue) > 0 {
+			c := queue[0]
+			queue = queue[1:]
+
+			b.Chunks++
+			b.Blocks += crafted[c]
+			b.MinX, b.MaxX = minInt(b.MinX, int(c[0])), maxInt(b.MaxX, int(c[0]))
+			b.MinZ, b.MaxZ = minInt(b.MinZ, int(c[1])), maxInt(b.MaxZ, int(c[1]))
+
+			for dx := int32(-1); dx <= 1; dx++ {
+				for dz := int32(-1); dz <= 1; dz++ {
+					n := [3]int32{c[0] + dx, c[1] + dz, c[2]}
+					if !seen[n] && crafted[n] >= minBuildChunkBlocks {
+						seen[n] = true
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+
+		// Convert chunk coordinates to the block coordinates of the outer corners
+		b.MinX, b.MinZ = b.MinX*chunkSize, b.MinZ*chunkSize
+		b.MaxX, b.MaxZ = b.MaxX*chunkSize+chunkSize-1, b.MaxZ*chunkSize+chunkSize-1
+
+		builds = append(builds, b)
+	}
+
+	sort.SliceStable(builds, func(i, j int) bool { return builds[i].Blocks > builds[j].Blocks })
+
+	if len(builds) > maxBuilds {
+		builds = builds[:maxBuilds]
+	}
+
+	return builds
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// LevelInfo is descriptive information about a world from level.dat.
+type LevelInfo struct {
+	Name       string
+	LastPlayed int64 // Unix time in seconds
+	Time       int64 // Ticks since the world was created
+	GameType   int
+	Seed       int64
+}
+
+// LevelInfo reads the world's name, age and other descriptive information from level.dat.
+func (w *World) LevelInfo() (LevelInfo, error) {
+	var i LevelInfo
+
+	err := w.readLevelDatFields([]levelDatField{
+		{"LevelName", nbt.TagString, &i.Name},
+		{"LastPlayed", nbt.TagLong, &i.LastPlayed},
+		{"Time", nbt.TagLong, &i.Time},
+		{"GameType", nbt.TagInt, &i.GameType},
+		{"RandomSeed", nbt.TagLong, &i.Seed},
+	})
+	if err != nil {
+		return LevelInfo{}, fmt.Errorf("reading level info: %w", err)
+	}
+
+	return i, nil
+}
+
+// gameTypeNames are the names of GameType values in level.dat.
+var gameTypeNames = map[int]string{0: "survival", 1: "creative", 2: "adventure", 5: "default", 6: "spectator"}
+
+// GameTypeName returns the name of a level.dat GameType value, e.g. survival.
+func GameTypeName(t int) string {
+	if n, ok := gameTypeNames[t]; ok {
+		return n
+	}
+
+	return fmt.Sprintf("game type %d", t)
+}
@@ -0,0 +1,119 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// testPlanks returns n planks blocks in a sub chunk, keyed by sub chunk coordinates.
+func testPlanks(n int) map[[3]int]string {
+	blocks := make(map[[3]int]string)
+	for i := 0; i < n; i++ {
+		blocks[[3]int{i % chunkSize, 0, i / chunkSize}] = "minecraft:oak_planks"
+	}
+
+	return blocks
+}
+
+func TestStats(t *testing.T) {
+	w, db := testEntityWorld(t)
+
+	key := func(x, z int32, y int8) []byte {
+		return leveldb.ChunkKey{X: x, Z: z, Tag: leveldb.SubChunkPrefix, SubChunkY: y}.Bytes()
+	}
+
+	ores := map[[3]int]string{
+		{0, 0, 0}: "minecraft:diamond_ore",
+		{1, 0, 0}: "minecraft:diamond_ore",
+		{2, 0, 0}: "minecraft:stone",
+	}
+	_ = db.Put(key(0, 0, -4), testSubChunkValue(t, ores))
+	_ = db.Put(key(0, 0, 4), testSubChunkValue(t, testPlanks(80)))
+	_ = db.Put(key(1, 1, 4), testSubChunkValue(t, testPlanks(70)))
+	_ = db.Put(key(5, 5, 4), testSubChunkValue(t, testPlanks(10)))
+
+	s, err := w.Stats()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if s.Chunks[0] != 3 {
+		t.Errorf("expected 3 overworld chunks: got %d", s.Chunks[0])
+	}
+
+	if n := s.Blocks["minecraft:oak_planks"]; n != 160 {
+		t.Errorf("expected 160 planks: got %d", n)
+	}
+
+	if _, ok := s.Blocks[BlockAir]; ok {
+		t.Errorf("expected air not to be counted")
+	}
+
+	if n := s.Ores["minecraft:diamond_ore"][-64]; n != 2 || len(s.Ores) != 1 {
+		t.Errorf("expected only 2 diamond ore at Y -64: got %v", s.Ores)
+	}
+
+	if s.Entities["minecraft:cow"] != 1 || s.Entities["minecraft:wolf"] != 1 {
+		t.Errorf("unexpected entity counts: %v", s.Entities)
+	}
+
+	// Diagonal neighbours 0 0 and 1 1 form one build and 5 5 has too few planks
+	want := Build{MinX: 0, MinZ: 0, MaxX: 31, MaxZ: 31, Chunks: 2, Blocks: 150}
+	if len(s.Builds) != 1 || s.Builds[0] != want {
+		t.Errorf("expected builds [%+v]: got %+v", want, s.Builds)
+	}
+}
+
+func TestFindBuilds(t *testing.T) {
+	crafted := map[[3]int32]int{
+		{0, 0, 0}:  100,
+		{1, 0, 0}:  100,
+		{-3, 0, 0}: 500,
+		{0, 0, 1}:  minBuildChunkBlocks - 1,
+	}
+
+	builds := findBuilds(crafted)
+
+	want := []Build{
+		{MinX: -48, MinZ: 0, MaxX: -33, MaxZ: 15, Chunks: 1, Blocks: 500},
+		{MinX: 0, MinZ: 0, MaxX: 31, MaxZ: 15, Chunks: 2, Blocks: 200},
+	}
+
+	if len(builds) != len(want) {
+		t.Fatalf("expected %d builds: got %+v", len(want), builds)
+	}
+
+	for i := range want {
+		if builds[i] != want[i] {
+			t.Errorf("build %d: expected %+v: got %+v", i, want[i], builds[i])
+		}
+	}
+}
+
+func TestStatsMemoryBudget(t *testing.T) {
+	w, db := testEntityWorld(t)
+
+	for x := int32(0); x < 20; x++ {
+		_ = db.Put(leveldb.ChunkKey{X: x, Tag: leveldb.SubChunkPrefix, SubChunkY: 4}.Bytes(),
+			testSubChunkValue(t, testPlanks(int(x)*10)))
+	}
+
+	want, err := w.Stats()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Every key list is written to a temporary file
+	w.SetMemoryBudget(1)
+
+	got, err := w.Stats()
+	if err != nil {
+		t.Fatalf("unexpected error with a memory budget: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the same stats with a memory budget: got %+v: expected %+v", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package world
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// voidY is the height below which an entity is considered lost in the void. The game removes entities 64 blocks below
+// the lowest build height, which is -64 in the overworld.
+const voidY = -128
+
+// StrayEntity is an entity whose position is invalid, either below the void, not a number or in a chunk which has never
+// been generated. Stray entities can make worlds crash or lag on load.
+type StrayEntity struct {
+	Entity
+	Reason string
+}
+
+// StrayEntities returns every entity in the world with an invalid position.
+func (w *World) StrayEntities() ([]StrayEntity, error) {
+	b := w.startBudget()
+
+	generated, err := w.generatedChunks(b)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := w.entities(b)
+	if err != nil {
+		return nil, err
+	}
+
+	strays := make([]StrayEntity, 0)
+
+	for _, e := range entities {
+		reason := ""
+
+		switch {
+		case isInvalidFloat(e.X) || isInvalidFloat(e.Y) || isInvalidFloat(e.Z):
+			reason = "position is not a number"
+		case e.Y < voidY:
+			reason = "below the void"
+		case !generated[[3]int32{int32(math.Floor(e.X / chunkSize)), int32(math.Floor(e.Z / chunkSize)), int32(e.Dimension)}]:
+			reason = "outside generated chunks"
+		default:
+			continue
+		}
+
+		strays = append(strays, StrayEntity{Entity: e, Reason: reason})
+	}
+
+	return strays, nil
+}
+
+// TeleportToSpawn moves the given entity to the world spawn point in the overworld.
+func (w *World) TeleportToSpawn(e Entity) (Entity, error) {
+	x, y, z, err := w.SpawnPoint()
+	if err != nil {
+		return Entity{}, fmt.Errorf("getting spawn point: %w", err)
+	}
+
+	// A spawn Y of 32767 tells the game to find the surface, which isn't known here, so place the entity at the top
+	// of the world and let it fall.
+	if y == math.MaxInt16 {
+		y = 320
+	}
+
+	return w.MoveEntity(e, float64(x)+0.5, float64(y), float64(z)+0.5, 0)
+}
+
+// generatedChunks returns the x, z and dimension of every chunk which has terrain saved, within the time budget b.
+func (w *World) generatedChunks(b *scanBudget) (map[[3]int32]bool, error) {
+	chunks := make(map[[3]int32]bool)
+
+	err := w.eachKey(b, func(key []byte) error {
+		k, ok := leveldb.ParseChunkKey(key)
+		if !ok {
+			return nil
+		}
+
+		switch k.Tag {
+		case leveldb.Version, leveldb.VersionOld, leveldb.SubChunkPrefix, leveldb.LegacyTerrain:
+			chunks[[3]int32{k.X, k.Z, k.Dimension}] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return chunks, nil
+}
+
+func isInvalidFloat(f float64) bool {
+	return math.IsNaN(f) || math.IsInf(f, 0)
+}
@@ -0,0 +1,236 @@
+package world
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// StreamFormat is the encoding of a record stream written by Dump and read by Load.
+type StreamFormat int
+
+const (
+	// NDJSONStream is one StreamRecord JSON object per line, for line oriented tools such as jq.
+	NDJSONStream StreamFormat = iota
+	// BinaryStream is streamMagic followed by each record as a uvarint key length, the key, a uvarint value length and
+	// the value. It is smaller and faster than NDJSON.
+	BinaryStream
+)
+
+var streamFormatNames = []string{"ndjson", "binary"}
+
+func (f StreamFormat) String() string {
+	if f < 0 || int(f) >= len(streamFormatNames) {
+		return fmt.Sprintf("StreamFormat(%d)", int(f))
+	}
+
+	return streamFormatNames[f]
+}
+
+// ParseStreamFormat returns the stream format with the given name: ndjson or binary.
+func ParseStreamFormat(s string) (StreamFormat, error) {
+	for i, n := range streamFormatNames {
+		if strings.EqualFold(n, s) {
+			return StreamFormat(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid stream format '%s': expected one of %s", s, strings.Join(streamFormatNames, ", "))
+}
+
+// streamMagic starts a binary stream, identifying it and its version.
+var streamMagic = []byte("MINEDUMP\x01")
+
+// maxStreamField is the largest key or value accepted from a binary stream, so a corrupt length can't exhaust memory.
+const maxStreamField = 64 << 20
+
+// StreamRecord is a database record in an NDJSON stream. Chunk records also have their decoded chunk key, so tools
+// can select records by position or tag. Only Key and Value are read by Load.
+type StreamRecord struct {
+	Key   string       `json:"key"`   // Hex encoded
+	Value []byte       `json:"value"` // Base64 encoded
+	Chunk *StreamChunk `json:"chunk,omitempty"`
+}
+
+// StreamChunk is the decoded key of a chunk record.
+type StreamChunk struct {
+	X         int32 `json:"x"`
+	Z         int32 `json:"z"`
+	Dimension int32 `json:"dimension"`
+	Tag       byte  `json:"tag"`
+	SubChunkY *int8 `json:"subChunkY,omitempty"` // Only for sub chunk records
+}
+
+// IsChunkKey returns true if key is the key of a chunk record, such as a sub chunk or biome record.
+func IsChunkKey(key []byte) bool {
+	_, ok := leveldb.ParseChunkKey(key)
+	return ok
+}
+
+// Dump writes every record for which include returns true to out in the given format, in key order. A nil include
+// writes every record.
+func (w *World) Dump(out io.Writer, format StreamFormat, include func(key []byte) bool) (int, error) {
+	keys, err := w.db.Keys()
+	if err != nil {
+		return 0, fmt.Errorf("listing keys: %w", err)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+
+	buf := bufio.NewWriter(out)
+	enc := json.NewEncoder(buf)
+
+	if format == BinaryStream {
+		if _, err := buf.Write(streamMagic); err != nil {
+			return 0, err
+		}
+	}
+
+	n := 0
+
+	for _, key := range keys {
+		if include != nil && !include(key) {
+			continue
+		}
+
+		value, err := w.db.Get(key)
+		if err != nil {
+			return n, fmt.Errorf("getting key '%x': %w", key, err)
+		}
+
+		switch format {
+		case NDJSONStream:
+			err = enc.Encode(newStreamRecord(key, value))
+		case BinaryStream:
+			err = writeBinaryRecord(buf, key, value)
+		default:
+			return n, fmt.Errorf("unknown stream format %d", format)
+		}
+		if err != nil {
+			return n, fmt.Errorf("writing key '%x': %w", key, err)
+		}
+
+		n++
+	}
+
+	return n, buf.Flush()
+}
+
+func newStreamRecord(key, value []byte) StreamRecord {
+	r := StreamRecord{Key: hex.EncodeToString(key), Value: value}
+
+	if k, ok := leveldb.ParseChunkKey(key); ok {
+		r.Chunk = &StreamChunk{X: k.X, Z: k.Z, Dimension: k.Dimension, Tag: k.Tag}
+		if k.Tag == leveldb.SubChunkPrefix {
+			y := k.SubChunkY
+			r.Chunk.SubChunkY = &y
+		}
+	}
+
+	return r
+}
+
+func writeBinaryRecord(w io.Writer, key, value []byte) error {
+	var l [binary.MaxVarintLen64]byte
+
+	for _, field := range [][]byte{key, value} {
+		n := binary.PutUvarint(l[:], uint64(len(field)))
+		if _, err := w.Write(l[:n]); err != nil {
+			return err
+		}
+		if _, err := w.Write(field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Load writes every record in a stream written by Dump to the world, replacing records with the same key, and returns
+// the number of records written. The format is detected from the start of the stream. Records are written as they are
+// read, so an error part way through leaves the records before it written.
+func (w *World) Load(in io.Reader) (int, error) {
+	r := bufio.NewReader(in)
+
+	head, err := r.Peek(len(streamMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("reading stream header: %w", err)
+	}
+
+	if bytes.Equal(head, streamMagic) {
+		_, _ = r.Discard(len(streamMagic))
+		return w.loadBinary(r)
+	}
+
+	return w.loadNDJSON(r)
+}
+
+func (w *World) loadNDJSON(r io.Reader) (int, error) {
+	dec := json.NewDecoder(r)
+	n := 0
+
+	for {
+		var rec StreamRecord
+		if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+			return n, nil
+		} else if err != nil {
+			return n, fmt.Errorf("decoding record %d: %w", n+1, err)
+		}
+
+		key, err := hex.DecodeString(rec.Key)
+		if err != nil || len(key) == 0 {
+			return n, fmt.Errorf("record %d has invalid key '%s'", n+1, rec.Key)
+		}
+
+		if err := w.put(key, rec.Value); err != nil {
+			return n, fmt.Errorf("putting key '%x': %w", key, err)
+		}
+
+		n++
+	}
+}
+
+func (w *World) loadBinary(r *bufio.Reader) (int, error) {
+	n := 0
+
+	for {
+		if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+			return n, nil
+		}
+
+		var fields [2][]byte
+		for i := range fields {
+			l, err := binary.ReadUvarint(r)
+			if err != nil {
+				return n, fmt.Errorf("reading length in record %d: %w", n+1, err)
+			}
+			if l > maxStreamField {
+				return n, fmt.Errorf("record %d has length %d: more than the maximum %d", n+1, l, maxStreamField)
+			}
+
+			fields[i] = make([]byte, l)
+			if _, err := io.ReadFull(r, fields[i]); err != nil {
+				return n, fmt.Errorf("reading record %d: %w", n+1, err)
+			}
+		}
+
+		if len(fields[0]) == 0 {
+			return n, fmt.Errorf("record %d has an empty key", n+1)
+		}
+
+		if err := w.put(fields[0], fields[1]); err != nil {
+			return n, fmt.Errorf("putting key '%x': %w", fields[0], err)
+		}
+
+		n++
+	}
+}
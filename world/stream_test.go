@@ -0,0 +1,85 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestDumpLoad(t *testing.T) {
+	src := mock.NewLevelDB()
+	sub := leveldb.ChunkKey{X: 2, Z: -3, Dimension: 1, Tag: leveldb.SubChunkPrefix, SubChunkY: 4}.Bytes()
+	biomes := leveldb.ChunkKey{X: 2, Z: -3, Tag: leveldb.Data3D}.Bytes()
+
+	_ = src.Put(sub, testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:stone"}))
+	_ = src.Put(biomes, []byte{1, 2, 3})
+	_ = src.Put([]byte("~local_player"), []byte{0, 0xff, '\n'})
+
+	w := NewFromDB(src)
+
+	for _, format := range []StreamFormat{NDJSONStream, BinaryStream} {
+		var buf bytes.Buffer
+
+		n, err := w.Dump(&buf, format, nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error dumping: %s", format, err)
+		}
+		if n != 3 {
+			t.Errorf("%s: expected 3 records dumped: got %d", format, n)
+		}
+
+		dst := mock.NewLevelDB()
+		if n, err := NewFromDB(dst).Load(&buf); err != nil || n != 3 {
+			t.Fatalf("%s: expected 3 records loaded: got %d, %v", format, n, err)
+		}
+
+		for _, key := range [][]byte{sub, biomes, []byte("~local_player")} {
+			want, _ := src.Get(key)
+			if got, err := dst.Get(key); err != nil || !bytes.Equal(got, want) {
+				t.Errorf("%s: key %x: expected %x: got %x, %v", format, key, want, got, err)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if n, err := w.Dump(&buf, NDJSONStream, IsChunkKey); err != nil || n != 2 {
+		t.Fatalf("expected 2 chunk records: got %d, %v", n, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per record: got %d", len(lines))
+	}
+
+	// Keys are in byte order, and the overworld biome key sorts after the nether sub chunk key
+	var rec StreamRecord
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("unexpected error decoding record: %s", err)
+	}
+
+	if rec.Chunk == nil || rec.Chunk.X != 2 || rec.Chunk.Dimension != 1 || rec.Chunk.SubChunkY == nil || *rec.Chunk.SubChunkY != 4 {
+		t.Errorf("expected decoded sub chunk key: got %+v", rec.Chunk)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for name, stream := range map[string]string{
+		"bad json":      `{"key": "00", "value": "AA=="}` + "\n{",
+		"bad key":       `{"key": "zz", "value": "AA=="}`,
+		"short binary":  string(streamMagic) + "\x05ab",
+		"huge length":   string(streamMagic) + "\xff\xff\xff\xff\x0f",
+		"empty key bin": string(streamMagic) + "\x00\x00",
+	} {
+		if _, err := NewFromDB(mock.NewLevelDB()).Load(strings.NewReader(stream)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if n, err := NewFromDB(mock.NewLevelDB()).Load(strings.NewReader("")); err != nil || n != 0 {
+		t.Errorf("expected empty stream to load nothing: got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,547 @@
+package world
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// structureVoid is the block index of positions in a structure which leave the world unchanged when it is placed.
+const structureVoid = -1
+
+// MCStructure returns the clipboard as an .mcstructure file, the format saved by structure blocks and loaded by
+// structure template features. Positions which were not copied are structure voids, which leave the world unchanged
+// when the structure is placed. origin is the world position of the lowest corner of the copied selection.
+//
+// Block states, block entities and entities are not copied to the clipboard, so they are not included.
+func (c *Clipboard) MCStructure(origin [3]int) ([]byte, error) {
+	size := [3]int{c.SizeX, c.SizeY, c.SizeZ}
+	count := 1
+	for _, n := range size {
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid structure size %v", size)
+		}
+		count *= n
+		if count > math.MaxInt32 {
+			return nil, fmt.Errorf("structure of size %v is too large", size)
+		}
+	}
+
+	blocks := make([]interface{}, count)
+	water := make([]interface{}, count)
+	for i := range blocks {
+		blocks[i], water[i] = float64(structureVoid), float64(structureVoid)
+	}
+
+	palette := make([]nbt.NBTTag, 0)
+	paletteIndex := make(map[string]int)
+	// Blocks read from the world keep their states and version
+	index := func(b Block) float64 {
+		entry := paletteEntry(b.ID)
+		if e, ok := b.ownEntry(); ok {
+			entry = e
+		}
+
+		saved := NewBlock(entry)
+		key := fmt.Sprintf("%s%s %d", saved.ID, saved.StatesString(), saved.Version())
+
+		i, ok := paletteIndex[key]
+		if !ok {
+			i = len(palette)
+			paletteIndex[key] = i
+			palette = append(palette, entry)
+		}
+		return float64(i)
+	}
+
+	for _, b := range c.Blocks {
+		if b.X < 0 || b.Y < 0 || b.Z < 0 || b.X >= size[0] || b.Y >= size[1] || b.Z >= size[2] {
+			return nil, fmt.Errorf("block %s at %d %d %d is outside the clipboard", b.ID, b.X, b.Y, b.Z)
+		}
+
+		// Indices are in x, y then z order with z changing fastest
+		i := (b.X*size[1]+b.Y)*size[2] + b.Z
+		blocks[i] = index(b)
+		if b.waterLogged {
+			water[i] = index(Block{ID: BlockWater})
+		}
+	}
+
+	intList := func(name string, values ...interface{}) nbt.NBTTag {
+		return nbt.NBTTag{Type: nbt.TagList, Name: name,
+			Value: map[string]interface{}{"tagListType": nbt.TagInt, "list": values}}
+	}
+	compound := func(name string, children ...nbt.NBTTag) nbt.NBTTag {
+		t := nbt.NBTTag{Type: nbt.TagCompound, Name: name, Value: []interface{}{}}
+		for _, child := range children {
+			_ = t.SetChild(child)
+		}
+		return t
+	}
+
+	layers := nbt.NBTTag{Type: nbt.TagList, Name: "block_indices",
+		Value: map[string]interface{}{"tagListType": nbt.TagList}}
+	_ = layers.SetList([]nbt.NBTTag{intList("", blocks...), intList("", water...)})
+
+	blockPalette := nbt.NBTTag{Type: nbt.TagList, Name: "block_palette",
+		Value: map[string]interface{}{"tagListType": nbt.TagCompound}}
+	_ = blockPalette.SetList(palette)
+
+	entities := nbt.NBTTag{Type: nbt.TagList, Name: "entities",
+		Value: map[string]interface{}{"tagListType": nbt.TagCompound}}
+
+	root := compound("",
+		nbt.NBTTag{Type: nbt.TagInt, Name: "format_version", Value: float64(1)},
+		intList("size", float64(size[0]), float64(size[1]), float64(size[2])),
+		compound("structure",
+			layers,
+			entities,
+			compound("palette", compound("default", blockPalette, compound("block_position_data"))),
+		),
+		intList("structure_world_origin", float64(origin[0]), float64(origin[1]), float64(origin[2])),
+	)
+
+	data, err := nbt.Encode([]nbt.NBTTag{root})
+	if err != nil {
+		return nil, fmt.Errorf("encoding structure: %w", err)
+	}
+
+	return data, nil
+}
+
+// mcStructure is the content of an .mcstructure file which is placed by ImportStructure.
+type mcStructure struct {
+	size    [3]int
+	blocks  []int // Palette indices of the blocks in x, y then z order with z changing fastest
+	water   []int // Palette indices of the water logging layer, or nil if the structure has none
+	palette []nbt.NBTTag
+
+	blockEntities map[int]nbt.NBTTag // Block entity data by block index
+}
+
+// parseMCStructure parses an .mcstructure file, checking that its indices are in range of its size and palette.
+func parseMCStructure(data []byte) (*mcStructure, error) {
+	tags, err := nbt.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) != 1 || tags[0].Type != nbt.TagCompound {
+		return nil, fmt.Errorf("expected one compound tag: got %d tags", len(tags))
+	}
+	root := tags[0]
+
+	s := &mcStructure{blockEntities: make(map[int]nbt.NBTTag)}
+
+	size, ok := root.Child("size")
+	if !ok || len(size.List()) != 3 {
+		return nil, fmt.Errorf("missing or invalid size")
+	}
+	count := 1
+	for i, n := range size.List() {
+		v, _ := n.Int()
+		s.size[i] = int(v)
+		if v <= 0 {
+			return nil, fmt.Errorf("invalid structure size %v", s.size)
+		}
+		count *= int(v)
+		if count > math.MaxInt32 {
+			return nil, fmt.Errorf("structure of size %v is too large", s.size)
+		}
+	}
+
+	if palette, ok := root.Path("structure", "palette", "default", "block_palette"); ok {
+		s.palette = palette.List()
+	}
+	for i, p := range s.palette {
+		if p.Type != nbt.TagCompound || p.BlockID() == "" {
+			return nil, fmt.Errorf("palette entry %d has no block name", i)
+		}
+	}
+
+	indices, ok := root.Path("structure", "block_indices")
+	if !ok || len(indices.List()) == 0 {
+		return nil, fmt.Errorf("missing block indices")
+	}
+
+	layers := make([][]int, 0, 2)
+	for n, l := range indices.List() {
+		values := l.List()
+		if n > 0 && len(values) == 0 {
+			// The water logging layer is empty in structures without water logged blocks
+			layers = append(layers, nil)
+			continue
+		}
+		if len(values) != count {
+			return nil, fmt.Errorf("layer %d has %d indices for a structure of size %v", n, len(values), s.size)
+		}
+
+		layer := make([]int, count)
+		for i, v := range values {
+			p, _ := v.Int()
+			if p < structureVoid || p >= int64(len(s.palette)) {
+				return nil, fmt.Errorf("layer %d index %d is %d, which is not in the palette", n, i, p)
+			}
+			layer[i] = int(p)
+		}
+		layers = append(layers, layer)
+	}
+	s.blocks = layers[0]
+	if len(layers) > 1 {
+		s.water = layers[1]
+	}
+
+	positionData, _ := root.Path("structure", "palette", "default", "block_position_data")
+	for _, t := range positionData.Tags() {
+		be, ok := t.Child("block_entity_data")
+		if !ok {
+			continue
+		}
+
+		i, err := strconv.Atoi(t.Name)
+		if err != nil || i < 0 || i >= count {
+			return nil, fmt.Errorf("invalid block position data index '%s'", t.Name)
+		}
+		s.blockEntities[i] = be
+	}
+
+	return s, nil
+}
+
+// index returns the block index of the given position relative to the structure's lowest corner.
+func (s *mcStructure) index(x, y, z int) int {
+	return (x*s.size[1]+y)*s.size[2] + z
+}
+
+// placed returns true if the position relative to the structure's lowest corner is inside the structure and is not a
+// structure void.
+func (s *mcStructure) placed(x, y, z int) bool {
+	if x < 0 || y < 0 || z < 0 || x >= s.size[0] || y >= s.size[1] || z >= s.size[2] {
+		return false
+	}
+
+	return s.blocks[s.index(x, y, z)] != structureVoid
+}
+
+// ImportStructure reads an .mcstructure file, the format saved by structure blocks and written by
+// Clipboard.MCStructure, and places it in a dimension with its lowest corner at the world position at. Block states and
+// water logging are kept. Structure voids leave the world unchanged, and block entities at every other position are
+// replaced by those in the structure. The number of blocks placed is returned.
+//
+// Entities in the structure are not imported.
+func (w *World) ImportStructure(r io.Reader, at [3]int, dimension int) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return 0, fmt.Errorf("reading structure: %w", err)
+	}
+
+	s, err := parseMCStructure(data)
+	if err != nil {
+		return 0, fmt.Errorf("parsing structure: %w", err)
+	}
+
+	for _, y := range []int{at[1], at[1] + s.size[1] - 1} {
+		if err := checkHeight(y, dimension); err != nil {
+			return 0, err
+		}
+	}
+
+	var first, last [3]int
+	for a := range at {
+		first[a], last[a] = floorDiv(at[a], chunkSize), floorDiv(at[a]+s.size[a]-1, chunkSize)
+	}
+
+	placed := 0
+	chunks := make([][2]int, 0)
+
+	for cx := first[0]; cx <= last[0]; cx++ {
+		for cz := first[2]; cz <= last[2]; cz++ {
+			written := false
+
+			for cy := first[1]; cy <= last[1]; cy++ {
+				n, err := w.importSubChunk(s, at, [3]int{cx, cy, cz}, dimension)
+				if err != nil {
+					return placed, err
+				}
+				placed += n
+				written = written || n > 0
+			}
+
+			if written {
+				chunks = append(chunks, [2]int{cx, cz})
+			}
+		}
+	}
+
+	for _, c := range chunks {
+		if err := w.importBlockEntities(s, at, c[0], c[1], dimension); err != nil {
+			return placed, err
+		}
+	}
+
+	return placed, nil
+}
+
+// importSubChunk places the part of the structure inside the sub chunk at the given sub chunk coordinates. The number
+// of blocks placed is returned. The sub chunk is not read or written if none are.
+func (w *World) importSubChunk(s *mcStructure, at, c [3]int, dimension int) (int, error) {
+	var lo, hi [3]int
+	for a := range c {
+		lo[a] = maxInt(at[a], c[a]*chunkSize)
+		hi[a] = minInt(at[a]+s.size[a], (c[a]+1)*chunkSize)
+	}
+
+	positions := make([][2]int, 0) // Sub chunk index and structure index of each placed block
+	for x := lo[0]; x < hi[0]; x++ {
+		for y := lo[1]; y < hi[1]; y++ {
+			for z := lo[2]; z < hi[2]; z++ {
+				if i := s.index(x-at[0], y-at[1], z-at[2]); s.blocks[i] != structureVoid {
+					positions = append(positions, [2]int{subChunkVoxelToIndex(x&15, y&15, z&15), i})
+				}
+			}
+		}
+	}
+	if len(positions) == 0 {
+		return 0, nil
+	}
+
+	sub, err := w.SubChunk(c[0], c[1], c[2], dimension)
+	if errors.Is(err, &SubChunkNotSavedError{}) {
+		generated, err := w.chunkGenerated(c[0], c[2], dimension)
+		if err != nil {
+			return 0, err
+		}
+		if !generated {
+			return 0, fmt.Errorf("importing structure in chunk %d %d: %w", c[0], c[2], ErrChunkNotGenerated)
+		}
+
+		sub = NewSubChunk(c[0], c[1], c[2], dimension, BlockAir)
+	} else if err != nil {
+		return 0, err
+	}
+
+	// Each structure palette entry is matched against the sub chunk palette once
+	entries := make(map[int]int)
+	for _, p := range positions {
+		i, b := p[0], s.blocks[p[1]]
+
+		if e, ok := entries[b]; ok {
+			sub.data.Blocks.Indices[i] = e
+		} else {
+			sub.setEntry(i, s.palette[b])
+			entries[b] = sub.data.Blocks.Indices[i]
+		}
+
+		waterLogged := false
+		if s.water != nil && s.water[p[1]] != structureVoid {
+			waterLogged = s.palette[s.water[p[1]]].BlockID() == BlockWater
+		}
+		sub.setWaterLogged(i, waterLogged)
+	}
+
+	if err := w.SetSubChunk(sub); err != nil {
+		return 0, err
+	}
+
+	return len(positions), nil
+}
+
+// importBlockEntities replaces the block entities at positions the structure placed blocks at in the chunk at the
+// given chunk coordinates, with the structure's block entities in the chunk.
+func (w *World) importBlockEntities(s *mcStructure, at [3]int, cx, cz, dimension int) error {
+	// Block entities are added in index order so that the record is repeatable
+	indices := make([]int, 0, len(s.blockEntities))
+	for i := range s.blockEntities {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	added := make([]nbt.NBTTag, 0)
+	for _, i := range indices {
+		t := s.blockEntities[i]
+		if s.blocks[i] == structureVoid {
+			continue
+		}
+
+		x := at[0] + i/(s.size[1]*s.size[2])
+		y := at[1] + i/s.size[2]%s.size[1]
+		z := at[2] + i%s.size[2]
+		if floorDiv(x, chunkSize) != cx || floorDiv(z, chunkSize) != cz {
+			continue
+		}
+
+		added = append(added, moveBlockEntity(t, x, y, z))
+	}
+
+	return w.replaceBlockEntities(cx, cz, dimension, func(x, y, z int) bool {
+		return s.placed(x-at[0], y-at[1], z-at[2])
+	}, added)
+}
+
+// FeaturePack describes a behavior pack which places a copied structure during world generation, using a structure
+// template feature and a feature rule.
+type FeaturePack struct {
+	Namespace string // The namespace of the structure, feature and rule identifiers, e.g. mine
+	Name      string // The name of the structure, used in identifiers and file names
+
+	// The feature is placed in one in Chance chunks with a biome which has BiomeTag, e.g. overworld or forest
+	Chance   int
+	BiomeTag string
+}
+
+// identifierPart matches valid namespaces and names in pack identifiers.
+var identifierPart = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// featurePackFormat is the format version of generated feature and feature rule files.
+const featurePackFormat = "1.13.0"
+
+// featurePackEngine is the minimum game version of generated packs, the first to support structure template features.
+var featurePackEngine = []int{1, 13, 0}
+
+// WriteFeaturePack writes a behavior pack to a new directory named after the pack in dir, which places the clipboard
+// on the surface during world generation. origin is the world position of the lowest corner of the copied selection.
+// The directory is returned. Copy it to a world's behavior_packs directory and add it to world_behavior_packs.json, or
+// to the game's development_behavior_packs directory, to use it.
+//
+// The pack contains manifest.json, the structure in structures/<namespace>/<name>.mcstructure, a structure template
+// feature in features/<name>_feature.json and a feature rule in feature_rules/<name>_feature_rule.json.
+func (c *Clipboard) WriteFeaturePack(dir string, p FeaturePack, origin [3]int) (string, error) {
+	if !identifierPart.MatchString(p.Namespace) || !identifierPart.MatchString(p.Name) {
+		return "", fmt.Errorf("invalid identifier '%s:%s': use lower case letters, digits and underscores",
+			p.Namespace, p.Name)
+	}
+	if p.Chance < 1 {
+		return "", fmt.Errorf("invalid chance %d: must be at least 1", p.Chance)
+	}
+	if p.BiomeTag == "" {
+		return "", errors.New("no biome tag")
+	}
+
+	structure, err := c.MCStructure(origin)
+	if err != nil {
+		return "", err
+	}
+
+	headerUUID, err := newUUID()
+	if err != nil {
+		return "", err
+	}
+	moduleUUID, err := newUUID()
+	if err != nil {
+		return "", err
+	}
+
+	structureID := p.Namespace + ":" + p.Name
+	featureID := structureID + "_feature"
+	ruleID := structureID + "_feature_rule"
+
+	manifest := map[string]interface{}{
+		"format_version": 2,
+		"header": map[string]interface{}{
+			"name":               p.Name,
+			"description":        fmt.Sprintf("Places %s during world generation", structureID),
+			"uuid":               headerUUID,
+			"version":            []int{1, 0, 0},
+			"min_engine_version": featurePackEngine,
+		},
+		"modules": []interface{}{
+			map[string]interface{}{"type": "data", "uuid": moduleUUID, "version": []int{1, 0, 0}},
+		},
+	}
+
+	feature := map[string]interface{}{
+		"format_version": featurePackFormat,
+		"minecraft:structure_template_feature": map[string]interface{}{
+			"description":       map[string]interface{}{"identifier": featureID},
+			"structure_name":    structureID,
+			"adjustment_radius": 4,
+			"facing_direction":  "random",
+			"constraints": map[string]interface{}{
+				"grounded": map[string]interface{}{},
+				"unburied": map[string]interface{}{},
+				"block_intersection": map[string]interface{}{
+					"block_allowlist": []string{BlockAir},
+				},
+			},
+		},
+	}
+
+	// Scatter one attempt uniformly in each chunk, at the surface
+	axis := map[string]interface{}{"distribution": "uniform", "extent": []int{0, chunkSize - 1}}
+	rule := map[string]interface{}{
+		"format_version": featurePackFormat,
+		"minecraft:feature_rules": map[string]interface{}{
+			"description": map[string]interface{}{"identifier": ruleID, "places_feature": featureID},
+			"conditions": map[string]interface{}{
+				"placement_pass": "surface_pass",
+				"minecraft:biome_filter": []interface{}{
+					map[string]interface{}{"test": "has_biome_tag", "operator": "==", "value": p.BiomeTag},
+				},
+			},
+			"distribution": map[string]interface{}{
+				"iterations":     1,
+				"scatter_chance": map[string]interface{}{"numerator": 1, "denominator": p.Chance},
+				"x":              axis,
+				"y":              "query.heightmap(variable.worldx, variable.worldz)",
+				"z":              axis,
+			},
+		},
+	}
+
+	root := filepath.Join(dir, p.Name)
+	if _, err := os.Stat(root); err == nil {
+		return "", fmt.Errorf("%s already exists", root)
+	}
+
+	files := []struct {
+		path string
+		data interface{}
+	}{
+		{"manifest.json", manifest},
+		{filepath.Join("structures", p.Namespace, p.Name+".mcstructure"), structure},
+		{filepath.Join("features", p.Name+"_feature.json"), feature},
+		{filepath.Join("feature_rules", p.Name+"_feature_rule.json"), rule},
+	}
+
+	for _, f := range files {
+		data, ok := f.data.([]byte)
+		if !ok {
+			if data, err = json.MarshalIndent(f.data, "", "  "); err != nil {
+				return "", fmt.Errorf("marshaling %s: %w", f.path, err)
+			}
+		}
+
+		path := filepath.Join(root, f.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", fmt.Errorf("creating directory for %s: %w", f.path, err)
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			return "", fmt.Errorf("writing %s: %w", f.path, err)
+		}
+	}
+
+	return root, nil
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating uuid: %w", err)
+	}
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,343 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestMCStructure(t *testing.T) {
+	c := &Clipboard{SizeX: 2, SizeY: 1, SizeZ: 3, Blocks: []Block{
+		{ID: BlockStone, X: 0, Y: 0, Z: 0},
+		{ID: BlockDirt, X: 1, Y: 0, Z: 2, waterLogged: true},
+		{ID: BlockStone, X: 0, Y: 0, Z: 1},
+	}}
+
+	data, err := c.MCStructure([3]int{10, -5, 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tags, err := nbt.Decode(data)
+	if err != nil {
+		t.Fatalf("unexpected error decoding structure: %s", err)
+	}
+	root := tags[0]
+
+	layer := func(l nbt.NBTTag) []int {
+		values := make([]int, 0)
+		for _, e := range l.List() {
+			i, _ := e.Int()
+			values = append(values, int(i))
+		}
+		return values
+	}
+	ints := func(names ...string) []int {
+		l, ok := root.Path(names...)
+		if !ok {
+			t.Fatalf("missing %v", names)
+		}
+		return layer(l)
+	}
+
+	if got := ints("size"); !reflect.DeepEqual(got, []int{2, 1, 3}) {
+		t.Errorf("expected size [2 1 3]: got %v", got)
+	}
+
+	if got := ints("structure_world_origin"); !reflect.DeepEqual(got, []int{10, -5, 20}) {
+		t.Errorf("expected origin [10 -5 20]: got %v", got)
+	}
+
+	palette, _ := root.Path("structure", "palette", "default", "block_palette")
+	ids := make([]string, 0)
+	for _, p := range palette.List() {
+		ids = append(ids, p.BlockID())
+	}
+	if want := []string{BlockStone, BlockDirt, BlockWater}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("expected palette %v: got %v", want, ids)
+	}
+
+	indices, _ := root.Path("structure", "block_indices")
+	layers := indices.List()
+	if len(layers) != 2 {
+		t.Fatalf("expected 2 layers: got %d", len(layers))
+	}
+
+	if got, want := layer(layers[0]), []int{0, 0, -1, -1, -1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected blocks %v: got %v", want, got)
+	}
+	if got, want := layer(layers[1]), []int{-1, -1, -1, -1, -1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected water logging %v: got %v", want, got)
+	}
+
+	c.Blocks = append(c.Blocks, Block{ID: BlockStone, X: 2})
+	if _, err := c.MCStructure([3]int{}); err == nil {
+		t.Errorf("expected error for a block outside the clipboard")
+	}
+}
+
+func TestMCStructureStates(t *testing.T) {
+	chest := testPaletteEntry("minecraft:chest")
+	_ = chest.SetChild(testCompound("states", nbt.NBTTag{Type: nbt.TagInt, Name: "facing_direction", Value: 3}))
+
+	// A chest with states, one without and a chest read from the world whose ID was changed
+	changed := NewBlock(chest)
+	changed.ID, changed.X = BlockStone, 2
+	c := &Clipboard{SizeX: 3, SizeY: 1, SizeZ: 1, Blocks: []Block{NewBlock(chest), {ID: "minecraft:chest", X: 1}, changed}}
+
+	data, err := c.MCStructure([3]int{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tags, err := nbt.Decode(data)
+	if err != nil {
+		t.Fatalf("unexpected error decoding structure: %s", err)
+	}
+
+	palette, _ := tags[0].Path("structure", "palette", "default", "block_palette")
+	states := make([]string, 0)
+	for _, p := range palette.List() {
+		states = append(states, p.BlockID()+NewBlock(p).StatesString())
+	}
+
+	want := []string{`minecraft:chest["facing_direction"=3]`, "minecraft:chest", BlockStone}
+	if !reflect.DeepEqual(states, want) {
+		t.Errorf("expected palette %v: got %v", want, states)
+	}
+}
+
+func TestWriteFeaturePack(t *testing.T) {
+	dir := t.TempDir()
+	c := &Clipboard{SizeX: 1, SizeY: 1, SizeZ: 1, Blocks: []Block{{ID: BlockStone}}}
+
+	p := FeaturePack{Namespace: "mine", Name: "hut", Chance: 20, BiomeTag: "forest"}
+
+	for _, invalid := range []FeaturePack{
+		{Namespace: "mine", Name: "Hut", Chance: 20, BiomeTag: "forest"},
+		{Namespace: "mine", Name: "hut", Chance: 0, BiomeTag: "forest"},
+		{Namespace: "mine", Name: "hut", Chance: 20},
+	} {
+		if _, err := c.WriteFeaturePack(dir, invalid, [3]int{}); err == nil {
+			t.Errorf("expected error for %+v", invalid)
+		}
+	}
+
+	root, err := c.WriteFeaturePack(dir, p, [3]int{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if root != filepath.Join(dir, "hut") {
+		t.Errorf("expected pack in %s: got %s", filepath.Join(dir, "hut"), root)
+	}
+
+	versions, err := packVersions(dir)
+	if err != nil {
+		t.Fatalf("unexpected error reading manifest: %s", err)
+	}
+	if len(versions) != 1 {
+		t.Errorf("expected one pack with a uuid: got %v", versions)
+	}
+
+	read := func(name string) map[string]interface{} {
+		data, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatalf("unexpected error reading %s: %s", name, err)
+		}
+		v := make(map[string]interface{})
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatalf("invalid JSON in %s: %s", name, err)
+		}
+		return v
+	}
+
+	feature := read("features/hut_feature.json")["minecraft:structure_template_feature"].(map[string]interface{})
+	if feature["structure_name"] != "mine:hut" {
+		t.Errorf("expected structure mine:hut: got %v", feature["structure_name"])
+	}
+
+	rule := read("feature_rules/hut_feature_rule.json")["minecraft:feature_rules"].(map[string]interface{})
+	if places := rule["description"].(map[string]interface{})["places_feature"]; places != "mine:hut_feature" {
+		t.Errorf("expected rule to place mine:hut_feature: got %v", places)
+	}
+
+	if _, err := ioutil.ReadFile(filepath.Join(root, "structures", "mine", "hut.mcstructure")); err != nil {
+		t.Errorf("unexpected error reading structure: %s", err)
+	}
+
+	if _, err := c.WriteFeaturePack(dir, p, [3]int{}); err == nil {
+		t.Errorf("expected error writing over an existing pack")
+	}
+}
+
+func TestImportStructure(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	for _, cx := range []int32{0, 1} {
+		_ = db.Put(leveldb.ChunkKey{X: cx, Tag: leveldb.Version}.Bytes(), []byte{40})
+	}
+
+	putBlockEntities := func(cx int32, tags ...nbt.NBTTag) {
+		value, err := nbt.Encode(tags)
+		if err != nil {
+			t.Fatalf("unexpected error encoding block entities: %s", err)
+		}
+		_ = db.Put(leveldb.ChunkKey{X: cx, Tag: leveldb.BlockEntity}.Bytes(), value)
+	}
+	putBlockEntities(0, testBlockEntity("Furnace", 15, 64, 0), testBlockEntity("Sign", 15, 65, 0))
+	putBlockEntities(1, testBlockEntity("Bell", 16, 65, 0))
+
+	ints := func(name string, values ...int) nbt.NBTTag {
+		elements := make([]nbt.NBTTag, len(values))
+		for i, v := range values {
+			elements[i] = nbt.NBTTag{Type: nbt.TagInt, Value: float64(v)}
+		}
+		return testList(name, nbt.TagInt, elements...)
+	}
+
+	chest := testPaletteEntry("minecraft:chest")
+	_ = chest.SetChild(testCompound("states", nbt.NBTTag{Type: nbt.TagInt, Name: "facing_direction", Value: 2}))
+
+	chestData := testBlockEntity("Chest", 0, 0, 0)
+	chestData.Name = "block_entity_data"
+	_ = chestData.SetChild(testString("CustomName", "Loot"))
+
+	// A 2x2x1 structure with a chest at 0 0 0, a void above it and a water logged stone and stone at x 1
+	root := testCompound("",
+		nbt.NBTTag{Type: nbt.TagInt, Name: "format_version", Value: 1},
+		ints("size", 2, 2, 1),
+		testCompound("structure",
+			testList("block_indices", nbt.TagList, ints("", 1, -1, 0, 0), ints("", -1, -1, 2, -1)),
+			testList("entities", nbt.TagCompound),
+			testCompound("palette", testCompound("default",
+				testList("block_palette", nbt.TagCompound,
+					testPaletteEntry(BlockStone), chest, testPaletteEntry(BlockWater)),
+				testCompound("block_position_data", testCompound("0", chestData)),
+			)),
+		),
+		ints("structure_world_origin", 0, 0, 0),
+	)
+	data, err := nbt.Encode([]nbt.NBTTag{root})
+	if err != nil {
+		t.Fatalf("unexpected error encoding structure: %s", err)
+	}
+
+	n, err := w.ImportStructure(bytes.NewReader(data), [3]int{15, 64, 0}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 blocks placed: got %d", n)
+	}
+
+	for _, c := range []struct {
+		x, y, z     int
+		want        string
+		waterLogged bool
+	}{
+		{15, 64, 0, "minecraft:chest", false},
+		{15, 65, 0, BlockAir, false},
+		{16, 64, 0, BlockStone, true},
+		{16, 65, 0, BlockStone, false},
+	} {
+		s, err := w.SubChunk(floorDiv(c.x, 16), floorDiv(c.y, 16), floorDiv(c.z, 16), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b := s.at(c.x&15, c.y&15, c.z&15); b.ID != c.want || b.waterLogged != c.waterLogged {
+			t.Errorf("expected %s (water logged %t) at %d %d %d: got %s (water logged %t)",
+				c.want, c.waterLogged, c.x, c.y, c.z, b.ID, b.waterLogged)
+		}
+	}
+
+	s, _ := w.SubChunk(0, 4, 0, 0)
+	entry := s.data.Blocks.Palette[s.data.Blocks.Indices[subChunkVoxelToIndex(15, 0, 0)]]
+	if facing, _ := entry.Path("states", "facing_direction"); !reflect.DeepEqual(facing.Value, 2.0) {
+		t.Errorf("expected the chest's facing_direction state to be kept: got %v", facing.Value)
+	}
+
+	// The furnace and bell are replaced, the sign is in a structure void and is kept
+	for _, c := range []struct {
+		x, y, z int
+		want    string
+	}{
+		{15, 64, 0, "Chest"},
+		{15, 65, 0, "Sign"},
+		{16, 65, 0, ""},
+	} {
+		e, ok, err := w.BlockEntityAt(c.x, c.y, c.z, 0)
+		if err != nil {
+			t.Fatalf("unexpected error getting block entity: %s", err)
+		}
+		if e.ID != c.want || ok != (c.want != "") {
+			t.Errorf("expected block entity '%s' at %d %d %d: got '%s'", c.want, c.x, c.y, c.z, e.ID)
+		}
+	}
+
+	chestEntity, _, _ := w.BlockEntityAt(15, 64, 0, 0)
+	if name, _ := chestEntity.NBT.Child("CustomName"); name.Value != "Loot" {
+		t.Errorf("expected the chest's data to be kept: got name %v", name.Value)
+	}
+
+	if _, err := db.Get(leveldb.ChunkKey{X: 1, Tag: leveldb.BlockEntity}.Bytes()); !errors.Is(err, leveldb.ErrNotFound) {
+		t.Errorf("expected the empty block entity record to be deleted: got %v", err)
+	}
+
+	if _, err := w.ImportStructure(bytes.NewReader(data), [3]int{80, 64, 80}, 0); !errors.Is(err, ErrChunkNotGenerated) {
+		t.Errorf("expected ErrChunkNotGenerated: got %v", err)
+	}
+
+	if _, err := w.ImportStructure(bytes.NewReader(data[:len(data)/2]), [3]int{}, 0); err == nil {
+		t.Error("expected an error importing a truncated structure")
+	}
+}
+
+func TestImportStructureRoundTrip(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+	_ = db.Put(leveldb.ChunkKey{X: -1, Z: -1, Tag: leveldb.Version}.Bytes(), []byte{40})
+	_ = db.Put(leveldb.ChunkKey{X: -1, Z: -1, Tag: leveldb.SubChunkPrefix, SubChunkY: 0}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: BlockGlass, {0, 0, 1}: BlockGlass}))
+
+	c := &Clipboard{SizeX: 3, SizeY: 2, SizeZ: 2, Blocks: []Block{
+		{ID: BlockStone, X: 0, Y: 0, Z: 0},
+		{ID: BlockDirt, X: 2, Y: 1, Z: 1, waterLogged: true},
+		{ID: BlockAir, X: 1, Y: 0, Z: 1},
+	}}
+	data, err := c.MCStructure([3]int{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := w.ImportStructure(bytes.NewReader(data), [3]int{-16, 0, -16}, 0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	s, err := w.SubChunk(-1, 0, -1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[[3]int]Block{
+		{0, 0, 0}: {ID: BlockStone},
+		{0, 0, 1}: {ID: BlockGlass},
+		{1, 0, 1}: {ID: BlockAir},
+		{2, 1, 1}: {ID: BlockDirt, waterLogged: true},
+	}
+	for p, b := range want {
+		if got := s.at(p[0], p[1], p[2]); got.ID != b.ID || got.waterLogged != b.waterLogged {
+			t.Errorf("expected %+v at %v: got %+v", b, p, got)
+		}
+	}
+}
@@ -3,14 +3,11 @@ package world
 import (
 	"bytes"
 	"encoding/binary"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
-	"math"
 
 	"github.com/danhale-git/mine/nbt"
-	"github.com/danhale-git/nbt2json"
 )
 
 const subChunkBlockCount = 4096
@@ -19,36 +16,41 @@ const chunkSize = 16
 // subChunkData is the parsed data for one 16x16 subchunk. A palette including all block states in the subchunk is indexed
 // by a slice of integers (one for each block) to determine the state and block id for each block in the palette.
 type subChunkData struct {
-	Blocks      blockStorage
-	WaterLogged blockStorage
+	Version int8
+	YIndex  int8 // The sub chunk index, saved in the sub chunk since version 9
+	Blocks  blockStorage
+	Extra   []blockStorage // Storages after the blocks, the first of which marks water logged blocks
 }
 
 type blockStorage struct {
-	Indices []int        // An index into the palette for each block in the sub chunk
-	Palette []nbt.NBTTag // A palette of block types and states
+	BitsPerBlock int          // The number of bits used to store each index
+	Indices      []int        // An index into the palette for each block in the sub chunk
+	Palette      []nbt.NBTTag // A palette of block types and states
+	// The NBT each palette entry was read from, which blocks keep as a comparable copy of their entry. Entries added
+	// to the palette since it was read are encoded when needed.
+	encoded []string
 }
 
 // subChunkOrigin returns the origin of the chunk containing the given coordinates. This is the corner block with the
 // lowest x, y and z values.
 func subChunkOrigin(x, y, z, d int) struct{ x, y, z, d int } {
 	return struct{ x, y, z, d int }{
-		int(math.Floor(float64(x) / 16)),
-		int(math.Floor(float64(y) / 16)),
-		int(math.Floor(float64(z) / 16)),
+		floorDiv(x, chunkSize),
+		floorDiv(y, chunkSize),
+		floorDiv(z, chunkSize),
 		d,
 	}
 }
 
-// worldVoxelToSubChunk returns the coordinates relative to sub chunk origin, from the given world coordinates.
+// worldVoxelToSubChunk returns the coordinates relative to sub chunk origin, from the given world coordinates. The
+// result is always 0-15, including for negative coordinates where Go's % operator would return a negative remainder.
 func worldVoxelToSubChunk(x, y, z int) (sx, sy, sz int) {
-	return x % chunkSize, y % chunkSize, z % chunkSize
+	return x - floorDiv(x, chunkSize)*chunkSize, y - floorDiv(y, chunkSize)*chunkSize, z - floorDiv(z, chunkSize)*chunkSize
 }
 
-// voxelToIndex returns the block storage index from the given sub chunk x y and z coordinates.
+// voxelToIndex returns the block storage index from the given sub chunk x y and z coordinates, which must be from 0 to
+// 15. Coordinates given to exported functions are checked with outOfChunk.
 func subChunkVoxelToIndex(x, y, z int) int {
-	if x > 15 || y > 15 || z > 15 {
-		log.Panicf("coordinates %d %d %d are invalid: sub chunk cooridnates may not exceed 0-15", x, y, z)
-	}
 	return y + z*16 + x*16*16
 }
 
@@ -65,146 +67,296 @@ func parseSubChunk(data []byte) (*subChunkData, error) {
 	r := bytes.NewReader(data)
 	s := subChunkData{}
 
-	var version int8
-	if err := readLittleEndian(r, &version); err != nil {
-		return nil, fmt.Errorf("reading version byte: %w", err)
+	var storageCount int8
+	var err error
+
+	if s.Version, storageCount, s.YIndex, err = readSubChunkHeader(r); err != nil {
+		return nil, err
 	}
 
-	var storageCount int8
+	if storageCount < 0 {
+		return nil, fmt.Errorf("invalid block storage count %d", storageCount)
+	}
 
-	switch version {
-	case 1:
-		storageCount = 1
-	case 8:
-		if err := readLittleEndian(r, &storageCount); err != nil {
-			return nil, fmt.Errorf("reading storage count: %w", err)
+	if storageCount == 0 {
+		if err := unexpectedLayout("sub chunk has no block storages"); err != nil {
+			return nil, err
 		}
-	default:
-		return nil, fmt.Errorf("unhandled subchunk block storage version: '%d'", version)
-	}
 
-	var err error
+		// A sub chunk without storages is read as air
+		s.Blocks = uniformStorage(BlockAir)
+		return &s, nil
+	}
 
-	s.Blocks.Indices, s.Blocks.Palette, err = parseBlockStorage(r)
+	s.Blocks, err = parseBlockStorage(r, 0)
 	if err != nil {
-		return nil, fmt.Errorf("parsing water logged: %s", err)
+		return nil, err
 	}
 
 	// https://minecraft.fandom.com/wiki/Bedrock_Edition_level_format
 	// In the majority of cases, there is only one storage record.
 	// A second record may be present to indicate block water-logging.
-	switch storageCount {
-	case 0:
-		panic("block storage count is 0")
-	case 1:
-		// Block storage has already been parsed above
-	case 2:
-		// Parse second block storage as water logged if it exists
-		s.WaterLogged.Indices, s.WaterLogged.Palette, err = parseBlockStorage(r)
-		if err != nil {
-			return nil, fmt.Errorf("parsing water logged: %s", err)
+	if storageCount > 2 {
+		if err := unexpectedLayout("sub chunk has %d block storages, not 1 or 2", storageCount); err != nil {
+			return nil, err
 		}
-		// Added some panicking here as the Minecraft level format seems changeable.
+	}
 
-		if len(s.WaterLogged.Palette) > 2 {
-			log.Panicf(`
-second block storage palette exceeded known max length of 2
-found these states - %+v`, s.WaterLogged.Palette)
+	for i := 1; i < int(storageCount); i++ {
+		storage, err := parseBlockStorage(r, i)
+		if err != nil {
+			return nil, err
 		}
-		if len(s.WaterLogged.Palette) > 1 && s.WaterLogged.Palette[1].BlockID() != waterID {
-			log.Panicf(`
-second block storage palette did not have '%s' at index 1 to indicate water logged blocks
-found id '%s' unexpectedly`, waterID, s.WaterLogged.Palette[1].BlockID())
+		s.Extra = append(s.Extra, storage)
+	}
+
+	if len(s.Extra) > 0 {
+		if err := validateWaterLogged(s.Extra[0]); err != nil {
+			return nil, err
 		}
+	}
+
+	return &s, nil
+}
 
+// readSubChunkHeader reads the fields before the first block storage of a sub chunk. Version 1 has one storage and no
+// further fields, version 8 adds the storage count and version 9, written since 1.18, adds the signed sub chunk index.
+func readSubChunkHeader(r *bytes.Reader) (version, storageCount, yIndex int8, err error) {
+	if err = readLittleEndian(r, &version); err != nil {
+		return 0, 0, 0, fmt.Errorf("reading version byte: %w", err)
+	}
+
+	switch version {
+	case 1:
+		return version, 1, 0, nil
+	case 8, 9:
+		if err = readLittleEndian(r, &storageCount); err != nil {
+			return 0, 0, 0, fmt.Errorf("reading storage count: %w", err)
+		}
 	default:
-		log.Panicf("unhandled storage count: %d", storageCount)
+		return 0, 0, 0, &UnsupportedVersionError{Format: "sub chunk", Version: int(version)}
 	}
 
-	return &s, nil
+	if version == 9 {
+		if err = readLittleEndian(r, &yIndex); err != nil {
+			return 0, 0, 0, fmt.Errorf("reading sub chunk index: %w", err)
+		}
+	}
+
+	return version, storageCount, yIndex, nil
 }
 
-func parseBlockStorage(r *bytes.Reader) ([]int, []nbt.NBTTag, error) {
-	var indices []int
-	var palette []nbt.NBTTag
+// parseBlockStorage reads the block storage with the given index in the sub chunk. Malformed storages return a
+// *CorruptPaletteError.
+func parseBlockStorage(r *bytes.Reader, storage int) (blockStorage, error) {
+	var err error
+	s := blockStorage{}
 
-	indices, err := stateIndices(r)
+	s.Indices, s.BitsPerBlock, err = stateIndices(r)
+	if errors.Is(err, &UnsupportedVersionError{}) {
+		return blockStorage{}, err
+	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("parsing water logged indices: %s", err)
+		return blockStorage{}, &CorruptPaletteError{Storage: storage, Err: fmt.Errorf("parsing indices: %w", err)}
 	}
 
-	palette, err = statePalette(r)
+	s.Palette, s.encoded, err = statePalette(r)
 	if err != nil {
-		return nil, nil, fmt.Errorf("parsing nbt data: %s", err)
+		return blockStorage{}, &CorruptPaletteError{Storage: storage, Err: fmt.Errorf("parsing nbt data: %w", err)}
 	}
 
-	return indices, palette, nil
+	for i, p := range s.Indices {
+		if p >= len(s.Palette) {
+			return blockStorage{}, &CorruptPaletteError{Storage: storage,
+				Err: fmt.Errorf("index %d is %d, out of range of a palette with length %d", i, p, len(s.Palette))}
+		}
+	}
+
+	return s, nil
 }
 
-// stateIndices reads a single block storage record as the integer indices into the palette. It should be called
-// the number of times returned by blockStorageCount, after calling blockStorageCount.
-func stateIndices(r *bytes.Reader) ([]int, error) {
+// stateIndices reads a single block storage record as the integer indices into the palette, returning the indices and
+// the number of bits used to store each one. It should be called the number of times returned by blockStorageCount,
+// after calling blockStorageCount.
+//
+// Indices are packed into 32 bit little endian words, lowest bits first, and are never split across words. With 3, 5
+// or 6 bits per block the high bits of each word are padding.
+func stateIndices(r *bytes.Reader) ([]int, int, error) {
 	var bitsPerBlockAndVersion byte
 	if err := readLittleEndian(r, &bitsPerBlockAndVersion); err != nil {
-		log.Fatalf("reading version byte: %s", err)
+		return nil, 0, fmt.Errorf("reading bits per block: %w", err)
 	}
 
 	bitsPerBlock := int(bitsPerBlockAndVersion >> 1)
 
+	// Version 1 is used by network packets, not save files
 	storageVersion := int(bitsPerBlockAndVersion & 1)
 	if storageVersion != 0 {
-		return nil, fmt.Errorf("invalid block storage version %d: 0 is expected for save files", storageVersion)
+		return nil, 0, &UnsupportedVersionError{Format: "block storage", Version: storageVersion}
 	}
 
-	blocksPerWord := int(math.Floor(32.0 / float64(bitsPerBlock)))
-	wordCount := int(math.Ceil(subChunkBlockCount / float64(blocksPerWord)))
-
 	indices := make([]int, subChunkBlockCount)
 
-	i := 0
+	// A storage of one block has no indices, as every block is the only palette entry
+	if bitsPerBlock == 0 {
+		return indices, 0, nil
+	}
 
-	for w := 0; w < wordCount; w++ {
-		var word int32
-		if err := readLittleEndian(r, &word); err != nil {
-			return nil, fmt.Errorf("reading word %d from raw data: %s", w, err)
-		}
+	if !validBitsPerBlockValue(bitsPerBlock) {
+		return nil, 0, fmt.Errorf("unsupported bits per block %d: expected one of %v", bitsPerBlock, validBitsPerBlock)
+	}
+
+	blocksPerWord := 32 / bitsPerBlock
+	wordCount := (subChunkBlockCount + blocksPerWord - 1) / blocksPerWord
+
+	words := make([]uint32, wordCount)
+	if err := readLittleEndian(r, words); err != nil {
+		return nil, 0, fmt.Errorf("reading %d words from raw data: %w", wordCount, err)
+	}
+
+	// Sub chunks of one block, such as those of stone underground or air in the sky, are common and need no unpacking
+	if allZero(words) {
+		return indices, bitsPerBlock, nil
+	}
+
+	mask := uint32(1)<<bitsPerBlock - 1
+	i := 0
 
+	for _, word := range words {
 		for b := 0; b < blocksPerWord && i < subChunkBlockCount; b++ {
-			indices[i] = int((word >> ((i % blocksPerWord) * bitsPerBlock)) & ((1 << bitsPerBlock) - 1))
+			indices[i] = int(word >> (b * bitsPerBlock) & mask)
 			i++
 		}
 	}
 
-	return indices, nil
+	return indices, bitsPerBlock, nil
+}
+
+// validBitsPerBlockValue returns true if bits is a bits per block value supported by the block storage format.
+func validBitsPerBlockValue(bits int) bool {
+	for _, b := range validBitsPerBlock {
+		if b == bits {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allZero returns true if every word is 0.
+func allZero(words []uint32) bool {
+	for _, w := range words {
+		if w != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// paletteIDs returns the block ID of each palette entry, indexed like the palette, so that the ID of each block can be
+// found by index instead of by reading its palette entry.
+func (s *blockStorage) paletteIDs() []string {
+	ids := make([]string, len(s.Palette))
+	for i := range s.Palette {
+		ids[i] = s.Palette[i].BlockID()
+	}
+
+	return ids
+}
+
+// uniform returns true if the storage is all one block, which is known without reading the indices when the palette
+// has one entry.
+func (s *blockStorage) uniform() bool {
+	return len(s.Palette) == 1
 }
 
 // statePalette reads the remainder of a subchunk record and returns a slice of tags. It should be called after blockStorageCount and
-// the resulting call(s) to stateIndices.
-func statePalette(r *bytes.Reader) ([]nbt.NBTTag, error) {
+// the resulting call(s) to stateIndices. The NBT each entry was read from is also returned, or nil if the entries can't
+// be told apart.
+func statePalette(r *bytes.Reader) ([]nbt.NBTTag, []string, error) {
 	var paletteSize int32
 	if err := readLittleEndian(r, &paletteSize); err != nil {
-		return nil, fmt.Errorf("reading palette size bytes: %w", err)
+		return nil, nil, fmt.Errorf("reading palette size bytes: %w", err)
+	}
+
+	// Palettes are cached by their bytes, so the bytes are found without decoding them
+	start := r.Size() - int64(r.Len())
+
+	size, err := nbt.Size(r, int(paletteSize))
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading palette: %w", err)
 	}
 
-	j, err := nbt2json.ReadNbt2Json(r, "", int(paletteSize))
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, start); err != nil {
+		return nil, nil, fmt.Errorf("reading palette: %w", err)
+	}
+
+	if palette, encoded, ok := cachedPaletteFor(data); ok {
+		_, _ = r.Seek(int64(size), io.SeekCurrent)
+		return palette, encoded, nil
+	}
+
+	palette, err := nbt.Read(r, int(paletteSize))
 	if err != nil {
-		return nil, fmt.Errorf("calling nbt2json, %w", err)
+		return nil, nil, fmt.Errorf("decoding palette: %w", err)
 	}
 
-	nbtData := struct {
-		NBT []nbt.NBTTag
-	}{}
-	if err := json.Unmarshal(j, &nbtData); err != nil {
-		return nil, fmt.Errorf("unmarshaling json, %w", err)
+	// Only palettes decoded from exactly the measured bytes are cached
+	if r.Size()-int64(r.Len()) != start+int64(size) {
+		return palette, nil, nil
 	}
 
-	if len(nbtData.NBT) != int(paletteSize) {
-		return nil, fmt.Errorf("%d nbt records returned for palette size of %d", len(nbtData.NBT), paletteSize)
+	encoded, err := splitPalette(data, len(palette))
+	if err != nil {
+		return palette, nil, nil
 	}
+	cachePalette(data, palette, encoded)
 
-	return nbtData.NBT, nil
+	return palette, encoded, nil
+}
+
+// splitPalette returns the NBT of each of the count entries of a palette.
+func splitPalette(data []byte, count int) ([]string, error) {
+	encoded := make([]string, count)
+	offset := 0
+
+	for i := range encoded {
+		n, err := nbt.Size(bytes.NewReader(data[offset:]), 1)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded[i] = string(data[offset : offset+n])
+		offset += n
+	}
+
+	return encoded, nil
 }
 
 func readLittleEndian(r io.Reader, data interface{}) error {
 	return binary.Read(r, binary.ByteOrder(binary.LittleEndian), data)
 }
+
+// waterLogged returns true if the block at index i is water logged, which is marked by water in the second storage.
+func (s *subChunkData) waterLogged(i int) bool {
+	if len(s.Extra) == 0 || len(s.Extra[0].Indices) <= i {
+		return false
+	}
+
+	w := s.Extra[0]
+	return w.Palette[w.Indices[i]].BlockID() == BlockWater
+}
+
+// clearWaterLogged marks every block as not water logged. The water logging storage is removed unless there are
+// storages after it.
+func (s *subChunkData) clearWaterLogged() {
+	switch len(s.Extra) {
+	case 0:
+	case 1:
+		s.Extra = nil
+	default:
+		s.Extra[0] = uniformStorage(BlockAir)
+	}
+}
@@ -1,9 +1,12 @@
 package world
 
 import (
+	"bytes"
+	"encoding/binary"
 	"testing"
 
 	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
 )
 
 func TestSubChunkVoxelToIndex(t *testing.T) {
@@ -22,6 +25,19 @@ func TestSubChunkVoxelToIndex(t *testing.T) {
 	}
 }
 
+func TestWorldVoxelToSubChunk(t *testing.T) {
+	for _, c := range [][6]int{
+		{0, 0, 0, 0, 0, 0},
+		{17, 319, 31, 1, 15, 15},
+		{-1, -64, -16, 15, 0, 0},
+		{-17, -49, -33, 15, 15, 15},
+	} {
+		if x, y, z := worldVoxelToSubChunk(c[0], c[1], c[2]); x != c[3] || y != c[4] || z != c[5] {
+			t.Errorf("%d %d %d: expected %d %d %d: got %d %d %d", c[0], c[1], c[2], c[3], c[4], c[5], x, y, z)
+		}
+	}
+}
+
 func TestSubChunkIndexToVoxel(t *testing.T) {
 	i := 0
 	for x := 0; x < 16; x++ {
@@ -49,7 +65,7 @@ func TestSubChunkBlocks(t *testing.T) {
 	r := mock.SubChunkReader()
 	_, _ = r.Read(make([]byte, 2))
 
-	indices, err := stateIndices(r)
+	indices, _, err := stateIndices(r)
 	if err != nil {
 		t.Errorf("unexpected error returned: %s", err)
 	}
@@ -58,3 +74,153 @@ func TestSubChunkBlocks(t *testing.T) {
 		t.Errorf("expected %d blocks state indices: got %d", subChunkBlockCount, len(indices))
 	}
 }
+
+func TestAnnotateSubChunk(t *testing.T) {
+	annotations, err := AnnotateSubChunk(mock.SubChunkValue)
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	end := 0
+	for _, a := range annotations {
+		if a.Offset != end {
+			t.Fatalf("annotation '%s' starts at %d: expected %d", a.Label, a.Offset, end)
+		}
+		end += a.Length
+	}
+
+	if end != len(mock.SubChunkValue) {
+		t.Errorf("annotations cover %d bytes: expected %d", end, len(mock.SubChunkValue))
+	}
+}
+
+func TestAnnotateUniformSubChunk(t *testing.T) {
+	value, err := encodeSubChunk(&subChunkData{Version: 8, Blocks: blockStorage{
+		Indices: make([]int, subChunkBlockCount),
+		Palette: []nbt.NBTTag{paletteEntry(BlockStone)},
+	}}, false)
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+
+	annotations, err := AnnotateSubChunk(value)
+	if err != nil {
+		t.Fatalf("unexpected error annotating: %s", err)
+	}
+
+	// The 0 bits per block header is followed directly by the palette
+	want := []string{
+		"version 8",
+		"storage count 1",
+		"storage 0: 0 bits per block, storage version 0",
+		"storage 0: palette size 1",
+		"storage 0: palette 0 NBT " + BlockStone,
+	}
+	if len(annotations) != len(want) {
+		t.Fatalf("expected %d annotations: got %+v", len(want), annotations)
+	}
+
+	end := 0
+	for i, a := range annotations {
+		if a.Label != want[i] || a.Offset != end {
+			t.Errorf("annotation %d: expected '%s' at %d: got '%s' at %d", i, want[i], end, a.Label, a.Offset)
+		}
+		end += a.Length
+	}
+
+	if end != len(value) {
+		t.Errorf("annotations cover %d bytes: expected %d", end, len(value))
+	}
+}
+
+func TestStateIndicesBitsPerBlock(t *testing.T) {
+	for _, bits := range append([]int{0}, validBitsPerBlock...) {
+		want := make([]int, subChunkBlockCount)
+		buf := bytes.Buffer{}
+		buf.WriteByte(byte(bits << 1))
+
+		if bits > 0 {
+			// Indices are never split across words, so words of 3, 5 and 6 bits per block have unused high bits
+			blocksPerWord := 32 / bits
+			words := make([]uint32, (subChunkBlockCount+blocksPerWord-1)/blocksPerWord)
+
+			for i := range want {
+				want[i] = (i*7919 + 13) % (1 << bits)
+				words[i/blocksPerWord] |= uint32(want[i]) << ((i % blocksPerWord) * bits)
+			}
+
+			_ = binary.Write(&buf, binary.LittleEndian, words)
+		}
+
+		indices, got, err := stateIndices(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%d bits: unexpected error: %s", bits, err)
+		}
+
+		if got != bits {
+			t.Errorf("%d bits: got %d bits per block", bits, got)
+		}
+
+		for i := range want {
+			if indices[i] != want[i] {
+				t.Fatalf("%d bits: index %d: expected %d: got %d", bits, i, want[i], indices[i])
+			}
+		}
+	}
+
+	for _, bits := range []int{7, 9, 15, 17} {
+		data := append([]byte{byte(bits << 1)}, make([]byte, subChunkBlockCount*4)...)
+		if _, _, err := stateIndices(bytes.NewReader(data)); err == nil {
+			t.Errorf("%d bits: expected an error for an unsupported bits per block", bits)
+		}
+	}
+
+	if _, _, err := stateIndices(bytes.NewReader([]byte{4 << 1, 0, 0})); err == nil {
+		t.Error("expected an error for truncated data")
+	}
+
+	if _, _, err := stateIndices(bytes.NewReader(nil)); err == nil {
+		t.Error("expected an error for empty data")
+	}
+}
+
+func TestParseSubChunkVersion9(t *testing.T) {
+	// The same sub chunk with a version 9 header and a sub chunk index of -4
+	value := append([]byte{9, 2, 0xfc}, mock.SubChunkValue[2:]...)
+
+	s, err := parseSubChunk(value)
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err)
+	}
+
+	if s.Version != 9 || s.YIndex != -4 {
+		t.Errorf("expected version 9 and index -4: got version %d and index %d", s.Version, s.YIndex)
+	}
+
+	original, err := parseSubChunk(mock.SubChunkValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.Extra) != len(original.Extra) ||
+		s.Blocks.Palette[s.Blocks.Indices[100]].BlockID() != original.Blocks.Palette[original.Blocks.Indices[100]].BlockID() {
+		t.Error("expected the blocks to match the version 8 sub chunk")
+	}
+
+	b, err := encodeSubChunk(s, false)
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+
+	if !bytes.Equal(b, value) {
+		t.Error("re-encoded version 9 sub chunk is not identical to the original")
+	}
+
+	if _, err := AnnotateSubChunk(value); err != nil {
+		t.Errorf("unexpected error annotating: %s", err)
+	}
+
+	if _, err := parseSubChunkPalette(value); err != nil {
+		t.Errorf("unexpected error reading the palette: %s", err)
+	}
+}
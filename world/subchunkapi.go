@@ -0,0 +1,237 @@
+package world
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+// blockStateVersion is the block state version written in the palette entries of blocks set with SubChunk.SetAt.
+const blockStateVersion = 17959425
+
+// SubChunk is the 16x16x16 blocks of a chunk at one sub chunk Y index. Blocks are addressed by their coordinates
+// within the sub chunk, each from 0 to 15.
+type SubChunk struct {
+	X, Y, Z   int // The position of the sub chunk in chunk coordinates, Y being the sub chunk index
+	Dimension int
+	data      *subChunkData
+}
+
+// NewSubChunk returns a sub chunk at the given position in chunk coordinates filled with one block, e.g. BlockAir.
+func NewSubChunk(cx, sy, cz, dimension int, id string) *SubChunk {
+	return &SubChunk{X: cx, Y: sy, Z: cz, Dimension: dimension, data: &subChunkData{
+		Version: 9,
+		YIndex:  int8(sy),
+		Blocks:  uniformStorage(id),
+	}}
+}
+
+// uniformStorage returns a block storage with every position set to the block with the given ID.
+func uniformStorage(id string) blockStorage {
+	return blockStorage{
+		BitsPerBlock: 1,
+		Indices:      make([]int, subChunkBlockCount),
+		Palette:      []nbt.NBTTag{paletteEntry(id)},
+	}
+}
+
+// SubChunk returns the saved sub chunk at the given position in chunk coordinates. If it isn't saved a
+// *SubChunkNotSavedError is returned.
+func (w *World) SubChunk(cx, sy, cz, dimension int) (*SubChunk, error) {
+	k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension), Tag: leveldb.SubChunkPrefix,
+		SubChunkY: int8(sy)}
+
+	value, err := w.SubChunkValue(cx*chunkSize, sy*chunkSize, cz*chunkSize, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := parseSubChunk(value)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sub chunk with key '%x': %w", k.Bytes(), err)
+	}
+
+	return &SubChunk{X: cx, Y: sy, Z: cz, Dimension: dimension, data: s}, nil
+}
+
+// SetSubChunk saves a sub chunk, replacing any saved at the same position. Its palette is normalized as by Optimize.
+func (w *World) SetSubChunk(s *SubChunk) error {
+	k := leveldb.ChunkKey{X: int32(s.X), Z: int32(s.Z), Dimension: int32(s.Dimension), Tag: leveldb.SubChunkPrefix,
+		SubChunkY: int8(s.Y)}
+
+	// The index saved in version 9 sub chunks must match the key
+	s.data.YIndex = int8(s.Y)
+
+	value, err := encodeSubChunk(s.data, true)
+	if err != nil {
+		return fmt.Errorf("encoding sub chunk with key '%x': %w", k.Bytes(), err)
+	}
+
+	if err := w.put(k.Bytes(), value); err != nil {
+		return fmt.Errorf("putting sub chunk with key '%x': %w", k.Bytes(), err)
+	}
+
+	return nil
+}
+
+// At returns the block at the given coordinates within the sub chunk. The block has world coordinates. If a coordinate
+// is outside 0 to 15 an *OutOfBoundsError is returned.
+func (s *SubChunk) At(x, y, z int) (Block, error) {
+	if err := outOfChunk("sub chunk", x, y, z); err != nil {
+		return Block{}, err
+	}
+
+	return s.at(x, y, z), nil
+}
+
+// at is At for coordinates known to be within the sub chunk.
+func (s *SubChunk) at(x, y, z int) Block {
+	return s.data.block(subChunkVoxelToIndex(x, y, z), s.X*chunkSize+x, s.Y*chunkSize+y, s.Z*chunkSize+z)
+}
+
+// SetAt sets the block at the given coordinates within the sub chunk. The coordinates of the block are ignored. A block
+// read from the world keeps its states and version. Other blocks take the first palette entry with their ID and no
+// states, or a new entry if there is none. If a coordinate is outside 0 to 15 an *OutOfBoundsError is returned.
+func (s *SubChunk) SetAt(x, y, z int, b Block) error {
+	if err := outOfChunk("sub chunk", x, y, z); err != nil {
+		return err
+	}
+
+	s.setAt(x, y, z, b)
+
+	return nil
+}
+
+// setAt is SetAt for coordinates known to be within the sub chunk.
+func (s *SubChunk) setAt(x, y, z int, b Block) {
+	i := subChunkVoxelToIndex(x, y, z)
+	blocks := &s.data.Blocks
+
+	if e, ok := b.ownEntry(); ok {
+		s.setEncodedEntry(i, b.entry, e)
+		s.setWaterLogged(i, b.waterLogged)
+		return
+	}
+
+	p := -1
+	for j, e := range blocks.Palette {
+		if states, ok := e.Child("states"); e.BlockID() == b.ID && (!ok || len(states.Tags()) == 0) {
+			p = j
+			break
+		}
+	}
+
+	if p < 0 {
+		p = len(blocks.Palette)
+		blocks.Palette = append(blocks.Palette, paletteEntry(b.ID))
+		blocks.BitsPerBlock = maxInt(blocks.BitsPerBlock, minimalBitsPerBlock(len(blocks.Palette)))
+	}
+
+	blocks.Indices[i] = p
+
+	s.setWaterLogged(i, b.waterLogged)
+}
+
+// setEncodedEntry sets the block at index i to the palette entry e, which is encoded as NBT. Entries are matched by
+// their encoding, so a block read from any sub chunk finds the same entry in this one.
+func (s *SubChunk) setEncodedEntry(i int, encoded string, e nbt.NBTTag) {
+	blocks := &s.data.Blocks
+
+	for j := range blocks.Palette {
+		if blocks.encodedEntry(j) == encoded {
+			blocks.Indices[i] = j
+			return
+		}
+	}
+
+	// The encoding is kept if every entry before it has one. Shared encodings have no spare capacity, so are copied.
+	if len(blocks.encoded) == len(blocks.Palette) {
+		blocks.encoded = append(blocks.encoded, encoded)
+	}
+	blocks.Palette = append(blocks.Palette, e)
+	blocks.BitsPerBlock = maxInt(blocks.BitsPerBlock, minimalBitsPerBlock(len(blocks.Palette)))
+	blocks.Indices[i] = len(blocks.Palette) - 1
+}
+
+// setWaterLogged sets whether the block at index i is water logged, adding the water logged storage if it is needed.
+func (s *SubChunk) setWaterLogged(i int, waterLogged bool) {
+	if len(s.data.Extra) == 0 {
+		if !waterLogged {
+			return
+		}
+
+		// Version 1 sub chunks have only one storage
+		if s.data.Version == 1 {
+			s.data.Version = 8
+		}
+		s.data.Extra = []blockStorage{uniformStorage(BlockAir)}
+	}
+	w := &s.data.Extra[0]
+
+	// Water logging storages written by add-ons may not have the usual [air, water] palette
+	id := BlockAir
+	if waterLogged {
+		id = BlockWater
+	}
+
+	for j, e := range w.Palette {
+		if e.BlockID() == id {
+			w.Indices[i] = j
+			return
+		}
+	}
+
+	w.Palette = append(w.Palette, paletteEntry(id))
+	w.BitsPerBlock = maxInt(w.BitsPerBlock, minimalBitsPerBlock(len(w.Palette)))
+	w.Indices[i] = len(w.Palette) - 1
+}
+
+// PaletteBlocks returns the IDs of the blocks in the sub chunk's palette, in palette order without duplicates. The
+// palette may have blocks which are no longer used by any position.
+func (s *SubChunk) PaletteBlocks() []string {
+	ids := make([]string, 0, len(s.data.Blocks.Palette))
+	seen := make(map[string]bool)
+
+	for _, p := range s.data.Blocks.Palette {
+		if id := p.BlockID(); !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// IsUniform returns true if every block in the sub chunk has the same ID.
+func (s *SubChunk) IsUniform() bool {
+	b := s.data.Blocks
+	if b.uniform() {
+		return true
+	}
+
+	first := b.Palette[b.Indices[0]].BlockID()
+
+	for _, p := range b.Indices {
+		if b.Palette[p].BlockID() != first {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Counts returns the number of blocks in the sub chunk with each block ID.
+func (s *SubChunk) Counts() BlockCounts {
+	return s.data.Blocks.counts()
+}
+
+// paletteEntry returns a palette entry for a block with no states.
+func paletteEntry(id string) nbt.NBTTag {
+	t := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagString, Name: "name", Value: id})
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagCompound, Name: "states", Value: []interface{}{}})
+	_ = t.SetChild(nbt.NBTTag{Type: nbt.TagInt, Name: "version", Value: blockStateVersion})
+
+	return t
+}
@@ -0,0 +1,105 @@
+package world
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestSubChunk(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	_ = db.Put(leveldb.ChunkKey{X: 2, Z: -1, Tag: leveldb.SubChunkPrefix, SubChunkY: 1}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{1, 2, 3}: BlockStone, {4, 5, 6}: BlockStone}))
+
+	s, err := w.SubChunk(2, 1, -1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error getting sub chunk: %s", err)
+	}
+
+	if b, err := s.At(1, 2, 3); err != nil || b.ID != BlockStone || b.X != 33 || b.Y != 18 || b.Z != -13 {
+		t.Errorf("expected stone at 33 18 -13: got %s at %d %d %d, %v", b.ID, b.X, b.Y, b.Z, err)
+	}
+
+	if got := s.Counts(); !reflect.DeepEqual(got, BlockCounts{BlockAir: 4094, BlockStone: 2}) {
+		t.Errorf("unexpected counts %v", got)
+	}
+
+	if s.IsUniform() {
+		t.Error("expected a sub chunk with stone and air not to be uniform")
+	}
+
+	if err := s.SetAt(0, 0, 0, Block{ID: BlockDirt, waterLogged: true}); err != nil {
+		t.Fatalf("unexpected error setting a block: %s", err)
+	}
+	s.setAt(1, 2, 3, Block{ID: BlockAir})
+	s.setAt(4, 5, 6, Block{ID: BlockAir})
+
+	if got := s.PaletteBlocks(); !reflect.DeepEqual(got, []string{BlockAir, BlockStone, BlockDirt}) {
+		t.Errorf("unexpected palette blocks %v", got)
+	}
+
+	if err := w.SetSubChunk(s); err != nil {
+		t.Fatalf("unexpected error setting sub chunk: %s", err)
+	}
+
+	b, err := w.GetBlock(32, 16, -16, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.ID != BlockDirt || !b.waterLogged {
+		t.Errorf("expected water logged dirt after saving: got %s, water logged %t", b.ID, b.waterLogged)
+	}
+
+	s, err = w.SubChunk(2, 1, -1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving normalizes the palette, dropping the unused stone
+	if got := s.PaletteBlocks(); !reflect.DeepEqual(got, []string{BlockAir, BlockDirt}) {
+		t.Errorf("unexpected palette blocks after saving %v", got)
+	}
+
+	s.setAt(0, 0, 0, Block{ID: BlockAir})
+	if !s.IsUniform() {
+		t.Error("expected a sub chunk of air to be uniform")
+	}
+
+	var notSaved *SubChunkNotSavedError
+	if _, err := w.SubChunk(0, 0, 0, 0); !errors.As(err, &notSaved) {
+		t.Errorf("expected a SubChunkNotSavedError for an unsaved sub chunk: got %v", err)
+	}
+}
+
+func TestSubChunkLargePalette(t *testing.T) {
+	w := NewFromDB(mock.NewLevelDB())
+
+	s := NewSubChunk(0, 1, 0, 0, BlockStone)
+	for i := 0; i < 40; i++ {
+		s.setAt(i%16, i/16, 0, Block{ID: testBlockID(i)})
+	}
+
+	if err := w.SetSubChunk(s); err != nil {
+		t.Fatalf("unexpected error setting sub chunk with a large palette: %s", err)
+	}
+
+	b, err := w.GetBlock(7, 18, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.ID != testBlockID(39) {
+		t.Errorf("expected %s: got %s", testBlockID(39), b.ID)
+	}
+}
+
+// testBlockID returns a distinct block ID for each i.
+func testBlockID(i int) string {
+	return "test:block_" + string(rune('a'+i%26)) + string(rune('a'+i/26))
+}
@@ -0,0 +1,228 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/danhale-git/mine/leveldb"
+)
+
+// subChunkRanges are the lowest and highest sub chunk indices in each dimension: the overworld, nether and end.
+var subChunkRanges = map[int][2]int8{
+	int(Overworld): {-4, 19},
+	int(Nether):    {0, 7},
+	int(End):       {0, 15},
+}
+
+// SurfaceOptions change which block ChunkSurfaceWith finds as the surface of a column.
+type SurfaceOptions struct {
+	// MaxY ignores blocks above this height, slicing through the dimension. nil means no limit.
+	MaxY *int
+	// BelowCeiling skips the solid blocks at the top of each column down to the first air, so the surface is the floor
+	// below a ceiling such as the Nether's bedrock roof. Unsaved sub chunks count as air.
+	BelowCeiling bool
+}
+
+// ChunkSurface returns the highest saved block which is not air in each column of the chunk with the given chunk
+// coordinates, indexed [x][z] from the lowest corner of the chunk. Columns with no saved blocks have an empty ID.
+func (w *World) ChunkSurface(cx, cz, dimension int) (*[chunkSize][chunkSize]Block, error) {
+	return w.ChunkSurfaceWith(cx, cz, dimension, SurfaceOptions{})
+}
+
+// ChunkSurfaceWith is ChunkSurface with options for dimensions where the highest block isn't the interesting one.
+// Columns where no block matches the options have an empty ID.
+func (w *World) ChunkSurfaceWith(cx, cz, dimension int, opts SurfaceOptions) (*[chunkSize][chunkSize]Block, error) {
+	r, ok := subChunkRanges[dimension]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	surface := &[chunkSize][chunkSize]Block{}
+	remaining := chunkSize * chunkSize
+
+	// Columns which have passed below the ceiling, or all of them if there is no ceiling to find
+	var open [chunkSize][chunkSize]bool
+	for x := range open {
+		for z := range open[x] {
+			open[x][z] = !opts.BelowCeiling
+		}
+	}
+
+	for sy := int(r[1]); sy >= int(r[0]) && remaining > 0; sy-- {
+		k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension),
+			Tag: leveldb.SubChunkPrefix, SubChunkY: int8(sy)}
+
+		if _, oy, _ := subChunkKeyOrigin(k); opts.MaxY != nil && oy > *opts.MaxY {
+			continue
+		}
+
+		value, err := w.db.Get(k.Bytes())
+		if errors.Is(err, leveldb.ErrNotFound) {
+			for x := range open {
+				for z := range open[x] {
+					open[x][z] = true
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting sub chunk with key '%x': %w", k.Bytes(), err)
+		}
+
+		s, err := parseSubChunk(value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing sub chunk with key '%x': %w", k.Bytes(), err)
+		}
+
+		// A sub chunk of air is the same as an unsaved one
+		if s.Blocks.uniform() && s.Blocks.Palette[0].BlockID() == BlockAir {
+			for x := range open {
+				for z := range open[x] {
+					open[x][z] = true
+				}
+			}
+			continue
+		}
+
+		ox, oy, oz := subChunkKeyOrigin(k)
+		ids := s.Blocks.paletteIDs()
+
+		for x := 0; x < chunkSize; x++ {
+			for z := 0; z < chunkSize; z++ {
+				if surface[x][z].ID != "" {
+					continue
+				}
+
+				for y := chunkSize - 1; y >= 0; y-- {
+					if opts.MaxY != nil && oy+y > *opts.MaxY {
+						continue
+					}
+
+					id := ids[s.Blocks.Indices[subChunkVoxelToIndex(x, y, z)]]
+					if id == BlockAir {
+						open[x][z] = true
+						continue
+					}
+
+					if open[x][z] {
+						surface[x][z] = Block{ID: id, X: ox + x, Y: oy + y, Z: oz + z}
+						remaining--
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return surface, nil
+}
+
+// ChunkDigest returns a hash of the sub chunk and biome records of a chunk, which changes when any block or biome in the
+// chunk changes. The returned bool is false if the chunk has no saved sub chunks.
+func (w *World) ChunkDigest(cx, cz, dimension int) (uint64, bool, error) {
+	r, ok := subChunkRanges[dimension]
+	if !ok {
+		return 0, false, fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	h := fnv.New64a()
+	found := false
+
+	for sy := int(r[0]); sy <= int(r[1]); sy++ {
+		k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension),
+			Tag: leveldb.SubChunkPrefix, SubChunkY: int8(sy)}
+
+		value, err := w.db.Get(k.Bytes())
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, false, fmt.Errorf("getting sub chunk with key '%x': %w", k.Bytes(), err)
+		}
+
+		found = true
+
+		// The sub chunk index and length separate the values so different splits can't collide
+		_, _ = fmt.Fprintf(h, "%d:%d:", sy, len(value))
+		_, _ = h.Write(value)
+	}
+
+	for _, tag := range []byte{leveldb.Data3D, leveldb.Data2D} {
+		k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension), Tag: tag}
+
+		value, err := w.db.Get(k.Bytes())
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, false, fmt.Errorf("getting key '%x': %w", k.Bytes(), err)
+		}
+
+		_, _ = fmt.Fprintf(h, "t%d:%d:", tag, len(value))
+		_, _ = h.Write(value)
+	}
+
+	return h.Sum64(), found, nil
+}
+
+// ChunkCaveAir returns the fraction of saved blocks below the given surface which are air, in each column of the chunk
+// with the given chunk coordinates. High values show caves and other hollows under the surface. Columns with no
+// surface block are 0.
+func (w *World) ChunkCaveAir(cx, cz, dimension int, surface *[chunkSize][chunkSize]Block) (*[chunkSize][chunkSize]float64, error) {
+	r, ok := subChunkRanges[dimension]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	var air, total [chunkSize][chunkSize]int
+
+	for sy := int(r[0]); sy <= int(r[1]); sy++ {
+		k := leveldb.ChunkKey{X: int32(cx), Z: int32(cz), Dimension: int32(dimension),
+			Tag: leveldb.SubChunkPrefix, SubChunkY: int8(sy)}
+
+		value, err := w.db.Get(k.Bytes())
+		if errors.Is(err, leveldb.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting sub chunk with key '%x': %w", k.Bytes(), err)
+		}
+
+		s, err := parseSubChunk(value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing sub chunk with key '%x': %w", k.Bytes(), err)
+		}
+
+		_, oy, _ := subChunkKeyOrigin(k)
+		ids := s.Blocks.paletteIDs()
+
+		for x := 0; x < chunkSize; x++ {
+			for z := 0; z < chunkSize; z++ {
+				top := surface[x][z]
+				if top.ID == "" {
+					continue
+				}
+
+				for y := 0; y < chunkSize && oy+y < top.Y; y++ {
+					total[x][z]++
+					if ids[s.Blocks.Indices[subChunkVoxelToIndex(x, y, z)]] == BlockAir {
+						air[x][z]++
+					}
+				}
+			}
+		}
+	}
+
+	caves := &[chunkSize][chunkSize]float64{}
+
+	for x := 0; x < chunkSize; x++ {
+		for z := 0; z < chunkSize; z++ {
+			if total[x][z] > 0 {
+				caves[x][z] = float64(air[x][z]) / float64(total[x][z])
+			}
+		}
+	}
+
+	return caves, nil
+}
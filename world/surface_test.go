@@ -0,0 +1,128 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/mock"
+)
+
+func TestChunkSurface(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := &World{db: db}
+
+	key := func(sy int8) []byte {
+		return leveldb.ChunkKey{X: 1, Z: -1, Tag: leveldb.SubChunkPrefix, SubChunkY: sy}.Bytes()
+	}
+
+	_ = db.Put(key(-4), testSubChunkValue(t, map[[3]int]string{{0, 0, 0}: "minecraft:bedrock", {1, 0, 0}: "minecraft:bedrock"}))
+	_ = db.Put(key(4), testSubChunkValue(t, map[[3]int]string{{0, 3, 0}: "minecraft:grass", {0, 1, 0}: "minecraft:dirt"}))
+
+	s, err := w.ChunkSurface(1, -1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error getting surface: %s", err)
+	}
+
+	want := map[[2]int]Block{
+		{0, 0}: {ID: "minecraft:grass", X: 16, Y: 67, Z: -16},
+		{1, 0}: {ID: "minecraft:bedrock", X: 17, Y: -64, Z: -16},
+		{2, 0}: {},
+	}
+
+	for c, b := range want {
+		if s[c[0]][c[1]] != b {
+			t.Errorf("column %d %d: expected %+v: got %+v", c[0], c[1], b, s[c[0]][c[1]])
+		}
+	}
+
+	caves, err := w.ChunkCaveAir(1, -1, 0, s)
+	if err != nil {
+		t.Fatalf("unexpected error getting cave air: %s", err)
+	}
+
+	// Column 0 0 has 16 saved blocks in sub chunk -4 with bedrock at the bottom, then 3 below the grass at 67 in sub
+	// chunk 4 with dirt at 65
+	if want := 17.0 / 19; caves[0][0] != want {
+		t.Errorf("expected column 0 0 to be %f air: got %f", want, caves[0][0])
+	}
+
+	if caves[1][0] != 0 || caves[2][0] != 0 {
+		t.Errorf("expected no air below bedrock or in an empty column: got %f %f", caves[1][0], caves[2][0])
+	}
+
+	before, ok, err := w.ChunkDigest(1, -1, 0)
+	if err != nil || !ok {
+		t.Fatalf("expected chunk digest: got %t, %v", ok, err)
+	}
+
+	_ = db.Put(key(5), testSubChunkValue(t, map[[3]int]string{{2, 0, 0}: "minecraft:stone"}))
+
+	if after, _, _ := w.ChunkDigest(1, -1, 0); after == before {
+		t.Errorf("expected digest to change when a sub chunk is added")
+	}
+
+	if _, ok, _ := w.ChunkDigest(5, 5, 0); ok {
+		t.Errorf("expected no digest for an unsaved chunk")
+	}
+}
+
+func TestChunkSurfaceWith(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := &World{db: db}
+
+	key := func(sy int8) []byte {
+		return leveldb.ChunkKey{X: 0, Z: 0, Dimension: 1, Tag: leveldb.SubChunkPrefix, SubChunkY: sy}.Bytes()
+	}
+
+	// A Nether column with a netherrack floor at 33 and a bedrock roof from 126 to 127
+	_ = db.Put(key(2), testSubChunkValue(t, map[[3]int]string{{0, 1, 0}: "minecraft:netherrack"}))
+	_ = db.Put(key(7), testSubChunkValue(t, map[[3]int]string{
+		{0, 14, 0}: "minecraft:bedrock", {0, 15, 0}: "minecraft:bedrock",
+		{1, 15, 0}: "minecraft:bedrock",
+	}))
+
+	cases := []struct {
+		name string
+		opts SurfaceOptions
+		want Block
+	}{
+		{"roof", SurfaceOptions{}, Block{ID: "minecraft:bedrock", Y: 127}},
+		{"below ceiling", SurfaceOptions{BelowCeiling: true}, Block{ID: "minecraft:netherrack", Y: 33}},
+		{"slice", SurfaceOptions{MaxY: intPtr(126)}, Block{ID: "minecraft:bedrock", Y: 126}},
+		{"slice below roof", SurfaceOptions{MaxY: intPtr(100)}, Block{ID: "minecraft:netherrack", Y: 33}},
+	}
+
+	for _, c := range cases {
+		s, err := w.ChunkSurfaceWith(0, 0, 1, c.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", c.name, err)
+		}
+
+		if s[0][0] != c.want {
+			t.Errorf("%s: expected %+v: got %+v", c.name, c.want, s[0][0])
+		}
+	}
+
+	s, err := w.ChunkSurfaceWith(0, 0, 1, SurfaceOptions{BelowCeiling: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if s[1][0].ID != "" {
+		t.Errorf("expected no floor below the roof in column 1 0: got %+v", s[1][0])
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func BenchmarkChunkSurface(b *testing.B) {
+	w := NewFromDB(mock.ValidLevelDB())
+
+	for i := 0; i < b.N; i++ {
+		if _, err := w.ChunkSurface(0, 0, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
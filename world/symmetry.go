@@ -0,0 +1,108 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Axis is one of the three world axes.
+type Axis int
+
+// Axes.
+const (
+	AxisX Axis = iota
+	AxisY
+	AxisZ
+)
+
+var axisNames = []string{"x", "y", "z"}
+
+func (a Axis) String() string {
+	return enumName(axisNames, int(a), "Axis")
+}
+
+// ParseAxis returns the axis with the given name, x, y or z.
+func ParseAxis(s string) (Axis, error) {
+	i, err := parseEnum(axisNames, s, "axis")
+	return Axis(i), err
+}
+
+// SymmetryIssue is a pair of blocks which should be the same in a mirror symmetric build, but are not. Coordinates are
+// relative to the lowest corner of the clipboard.
+type SymmetryIssue struct {
+	X, Y, Z                   int
+	ID                        string // The block found at X Y Z, or an empty string if it is not saved
+	MirrorX, MirrorY, MirrorZ int
+	MirrorID                  string // The block found at the mirrored position, which X Y Z is expected to match
+}
+
+func (i SymmetryIssue) String() string {
+	id := func(s string) string {
+		if s == "" {
+			return "nothing"
+		}
+		return s
+	}
+
+	return fmt.Sprintf("%d %d %d is %s: expected %s to match %d %d %d",
+		i.X, i.Y, i.Z, id(i.ID), id(i.MirrorID), i.MirrorX, i.MirrorY, i.MirrorZ)
+}
+
+// SymmetryIssues returns every pair of blocks which differ when the clipboard is mirrored along the given axis, about
+// the center of the clipboard. Each pair is reported once, from the side nearest the lowest corner. Only block IDs are
+// compared, so blocks such as stairs which should face opposite directions in a symmetric build are not reported.
+func (c *Clipboard) SymmetryIssues(axis Axis) ([]SymmetryIssue, error) {
+	if axis < AxisX || axis > AxisZ {
+		return nil, fmt.Errorf("invalid axis %d", axis)
+	}
+
+	size := [3]int{c.SizeX, c.SizeY, c.SizeZ}
+
+	blocks := make(map[[3]int]string, len(c.Blocks))
+	for _, b := range c.Blocks {
+		blocks[[3]int{b.X, b.Y, b.Z}] = b.ID
+	}
+
+	issues := make([]SymmetryIssue, 0)
+
+	for x := 0; x < size[0]; x++ {
+		for y := 0; y < size[1]; y++ {
+			for z := 0; z < size[2]; z++ {
+				p := [3]int{x, y, z}
+				m := p
+				m[axis] = size[axis] - 1 - p[axis]
+
+				// Visit each pair once, skipping the blocks on the mirror plane
+				if m[axis] <= p[axis] {
+					continue
+				}
+
+				if a, b := blocks[p], blocks[m]; a != b && !(isAir(a) && isAir(b)) {
+					issues = append(issues, SymmetryIssue{
+						X: x, Y: y, Z: z, ID: a,
+						MirrorX: m[0], MirrorY: m[1], MirrorZ: m[2], MirrorID: b,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.Z < b.Z
+	})
+
+	return issues, nil
+}
+
+// isAir returns true for air and for blocks which aren't saved, which are treated the same when comparing builds.
+func isAir(id string) bool {
+	return id == "" || strings.EqualFold(id, BlockAir)
+}
@@ -0,0 +1,45 @@
+package world
+
+import (
+	"testing"
+)
+
+func TestSymmetryIssues(t *testing.T) {
+	// A 5x1x2 row which is symmetric along x apart from one block, and has a missing block compared with air
+	c := &Clipboard{SizeX: 5, SizeY: 1, SizeZ: 2, Blocks: []Block{
+		{ID: "minecraft:stone", X: 0, Z: 0},
+		{ID: "minecraft:stone", X: 4, Z: 0},
+		{ID: "minecraft:glass", X: 2, Z: 0},
+		{ID: "minecraft:dirt", X: 1, Z: 1},
+		{ID: "minecraft:stone", X: 3, Z: 1},
+		{ID: "minecraft:air", X: 0, Z: 1},
+	}}
+
+	issues, err := c.SymmetryIssues(AxisX)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue: got %v", issues)
+	}
+
+	want := SymmetryIssue{X: 1, Z: 1, ID: "minecraft:dirt", MirrorX: 3, MirrorZ: 1, MirrorID: "minecraft:stone"}
+	if issues[0] != want {
+		t.Errorf("expected %v: got %v", want, issues[0])
+	}
+
+	issues, err = c.SymmetryIssues(AxisZ)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Every column differs between z 0 and z 1
+	if len(issues) != 5 {
+		t.Errorf("expected 5 issues along z: got %v", issues)
+	}
+
+	if _, err := ParseAxis("w"); err == nil {
+		t.Errorf("expected error parsing invalid axis")
+	}
+}
@@ -0,0 +1,129 @@
+package world
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Tile is a part of a region made of whole chunks, which parallel exporters process independently of the region's
+// other tiles.
+type Tile struct {
+	Index int // The position of the tile in the order returned by Tiler.Tiles
+
+	// Core is the part of the region in the tile. The cores of a region's tiles don't overlap and cover the region.
+	Core Selection
+	// Region is the core expanded by the tiler's overlap on every side but the top and bottom, for exporters which
+	// need the neighbours of blocks at the edge of the core. It may extend beyond the region being tiled.
+	Region Selection
+	// Chunks are the chunks with blocks in Region.
+	Chunks []ChunkPos
+}
+
+// Tiler splits regions into chunk aligned tiles. Tiles are aligned to a grid of Size by Size chunks starting at chunk
+// 0 0, so the same part of the world is always in the same tile, however the region is given. The same region always
+// gives the same tiles in the same order, so exporters which combine the output of each tile in tile order are
+// deterministic whatever the number of workers.
+type Tiler struct {
+	Size    int       // The width and depth of a tile in chunks. Values below 1 make tiles of one chunk.
+	Overlap int       // The number of blocks each tile's Region extends beyond its Core
+	Order   ScanOrder // The order of tiles. A Spiral starts from the tile containing the Center chunk.
+}
+
+// Tiles returns the tiles covering the region, from the bottom to the top of the region, in the tiler's order.
+func (t Tiler) Tiles(region Selection) []Tile {
+	tileChunks := maxInt(t.Size, 1)
+	size := tileChunks * chunkSize
+
+	grid := make([]ChunkPos, 0)
+	for tx := floorDiv(region.Min[0], size); tx <= floorDiv(region.Max[0], size); tx++ {
+		for tz := floorDiv(region.Min[2], size); tz <= floorDiv(region.Max[2], size); tz++ {
+			grid = append(grid, ChunkPos{X: tx, Z: tz, Dimension: region.Dimension})
+		}
+	}
+
+	order := t.Order
+	order.Center.X, order.Center.Z = floorDiv(order.Center.X, tileChunks), floorDiv(order.Center.Z, tileChunks)
+	order.Sort(grid)
+
+	tiles := make([]Tile, len(grid))
+
+	for i, g := range grid {
+		core := region
+		core.Min[0], core.Max[0] = maxInt(region.Min[0], g.X*size), minInt(region.Max[0], g.X*size+size-1)
+		core.Min[2], core.Max[2] = maxInt(region.Min[2], g.Z*size), minInt(region.Max[2], g.Z*size+size-1)
+
+		r := core
+		for _, axis := range []int{0, 2} {
+			r.Min[axis] -= t.Overlap
+			r.Max[axis] += t.Overlap
+		}
+
+		chunks := make([]ChunkPos, 0)
+		for cx := floorDiv(r.Min[0], chunkSize); cx <= floorDiv(r.Max[0], chunkSize); cx++ {
+			for cz := floorDiv(r.Min[2], chunkSize); cz <= floorDiv(r.Max[2], chunkSize); cz++ {
+				chunks = append(chunks, ChunkPos{X: cx, Z: cz, Dimension: region.Dimension})
+			}
+		}
+
+		tiles[i] = Tile{Index: i, Core: core, Region: r, Chunks: chunks}
+	}
+
+	return tiles
+}
+
+// Run calls f with each tile of the region from the given number of goroutines, or one per CPU if workers is less than
+// 1. Tiles are started in order. After f returns an error no more tiles are started, and the error of the first tile in
+// tile order which failed is returned.
+//
+// f must be safe to call concurrently. Reading a world from several goroutines is safe while nothing writes to it.
+func (t Tiler) Run(region Selection, workers int, f func(Tile) error) error {
+	tiles := t.Tiles(region)
+
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	errs := make([]error, len(tiles))
+	next := make(chan Tile)
+
+	var failed bool
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < minInt(workers, len(tiles)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tile := range next {
+				if err := f(tile); err != nil {
+					errs[tile.Index] = err
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, tile := range tiles {
+		mu.Lock()
+		stop := failed
+		mu.Unlock()
+		if stop {
+			break
+		}
+
+		next <- tile
+	}
+
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package world
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestTiler(t *testing.T) {
+	region := NewSelection(-20, 0, 5, 40, 10, 20, 0)
+
+	tiles := Tiler{Size: 2, Overlap: 1}.Tiles(region)
+
+	// The region spans tiles -1 to 1 along x and 0 along z
+	if len(tiles) != 3 {
+		t.Fatalf("expected 3 tiles: got %d", len(tiles))
+	}
+
+	want := Tile{
+		Index:  0,
+		Core:   NewSelection(-20, 0, 5, -1, 10, 20, 0),
+		Region: NewSelection(-21, 0, 4, 0, 10, 21, 0),
+		Chunks: []ChunkPos{{-2, 0, 0}, {-2, 1, 0}, {-1, 0, 0}, {-1, 1, 0}, {0, 0, 0}, {0, 1, 0}},
+	}
+	if !reflect.DeepEqual(tiles[0], want) {
+		t.Errorf("expected first tile %+v: got %+v", want, tiles[0])
+	}
+
+	// The cores cover every column of the region once
+	covered := make(map[[2]int]int)
+	for _, tile := range tiles {
+		for x := tile.Core.Min[0]; x <= tile.Core.Max[0]; x++ {
+			for z := tile.Core.Min[2]; z <= tile.Core.Max[2]; z++ {
+				covered[[2]int{x, z}]++
+			}
+		}
+	}
+	if x, _, z := region.Size(); len(covered) != x*z {
+		t.Errorf("expected %d columns covered: got %d", x*z, len(covered))
+	}
+	for c, n := range covered {
+		if n != 1 {
+			t.Errorf("expected column %v in one tile: got %d", c, n)
+		}
+	}
+
+	spiral := Tiler{Size: 2, Order: ScanOrder{Order: Spiral, Center: ChunkPos{X: 2}}}.Tiles(region)
+	if spiral[0].Core.Min[0] != 32 {
+		t.Errorf("expected the spiral to start from the tile holding chunk 2 0: got %+v", spiral[0].Core)
+	}
+}
+
+func TestTilerRun(t *testing.T) {
+	region := NewSelection(0, 0, 0, 100, 0, 100, 0)
+	tiler := Tiler{}
+
+	var mu sync.Mutex
+	visited := make(map[int]bool)
+
+	err := tiler.Run(region, 3, func(tile Tile) error {
+		mu.Lock()
+		defer mu.Unlock()
+		visited[tile.Index] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := len(tiler.Tiles(region)); len(visited) != n {
+		t.Errorf("expected %d tiles visited: got %d", n, len(visited))
+	}
+
+	fail := errors.New("fail")
+	err = tiler.Run(region, 0, func(tile Tile) error {
+		if tile.Index == 5 {
+			return fail
+		}
+		return nil
+	})
+	if !errors.Is(err, fail) {
+		t.Errorf("expected the tile error: got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package world
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// traceRecord is a single database operation, written as one JSON line in trace mode.
+type traceRecord struct {
+	Time     time.Time     `json:"time"`
+	Op       string        `json:"op"`
+	Key      string        `json:"key"`
+	Bytes    int           `json:"bytes"` // Value length, or key count for keys operations
+	Duration time.Duration `json:"durationNs"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// traceDB wraps a LevelDB and logs every key it touches.
+type traceDB struct {
+	db  LevelDB
+	enc *json.Encoder
+	mu  sync.Mutex
+}
+
+// Trace enables trace mode, logging every LevelDB key read or written by the world to out as one JSON object per line.
+// This is useful for debugging unexpected behaviour and measuring I/O patterns. Passing a nil writer disables tracing.
+func (w *World) Trace(out io.Writer) {
+	if t, ok := w.db.(*traceDB); ok {
+		w.db = t.db
+	}
+
+	if out == nil {
+		return
+	}
+
+	w.db = &traceDB{db: w.db, enc: json.NewEncoder(out)}
+}
+
+func (t *traceDB) Get(key []byte) ([]byte, error) {
+	start := time.Now()
+	value, err := t.db.Get(key)
+	t.log("get", key, len(value), start, err)
+
+	return value, err
+}
+
+func (t *traceDB) Put(key, value []byte) error {
+	start := time.Now()
+	err := t.db.Put(key, value)
+	t.log("put", key, len(value), start, err)
+
+	return err
+}
+
+func (t *traceDB) Delete(key []byte) error {
+	start := time.Now()
+	err := t.db.Delete(key)
+	t.log("delete", key, 0, start, err)
+
+	return err
+}
+
+func (t *traceDB) Keys() ([][]byte, error) {
+	start := time.Now()
+	keys, err := t.db.Keys()
+	t.log("keys", nil, len(keys), start, err)
+
+	return keys, err
+}
+
+func (t *traceDB) ForEachKey(f func(key []byte) error) error {
+	start := time.Now()
+	n := 0
+
+	count := func(key []byte) error {
+		n++
+		return f(key)
+	}
+
+	var err error
+	if s, ok := t.db.(keyScanner); ok {
+		err = s.ForEachKey(count)
+	} else {
+		var keys [][]byte
+		if keys, err = t.db.Keys(); err == nil {
+			for _, key := range keys {
+				if err = count(key); err != nil {
+					break
+				}
+			}
+		}
+	}
+
+	t.log("keys", nil, n, start, err)
+
+	return err
+}
+
+func (t *traceDB) log(op string, key []byte, n int, start time.Time, err error) {
+	r := traceRecord{
+		Time:     start,
+		Op:       op,
+		Key:      hex.EncodeToString(key),
+		Bytes:    n,
+		Duration: time.Since(start),
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_ = t.enc.Encode(r)
+}
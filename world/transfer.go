@@ -0,0 +1,86 @@
+package world
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// TransferEntity moves the entity with the given unique ID, with its full NBT, to a position in any dimension, for
+// example to rescue a pet stuck in the Nether. Anything riding the entity is moved with it. The entity's motion and fall
+// distance are reset so that it doesn't take damage on arrival. The chunk containing the position must have been
+// generated by the game, or ErrChunkNotGenerated is returned. The moved entities are returned, the given entity first.
+func (w *World) TransferEntity(id int64, x, y, z float64, dimension int) ([]Entity, error) {
+	if _, ok := subChunkRanges[dimension]; !ok {
+		return nil, fmt.Errorf("%w %d", ErrInvalidDimension, dimension)
+	}
+
+	cx, cz := int(math.Floor(x/chunkSize)), int(math.Floor(z/chunkSize))
+
+	generated, err := w.chunkGenerated(cx, cz, dimension)
+	if err != nil {
+		return nil, err
+	}
+	if !generated {
+		return nil, fmt.Errorf("transferring entity to chunk %d %d: %w", cx, cz, ErrChunkNotGenerated)
+	}
+
+	r, err := w.EntityResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	e, ok := r.Entity(id)
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrUnresolved, id)
+	}
+
+	entities := []Entity{e}
+	for _, l := range r.LinksOf(id) {
+		if l.Kind != Rides || l.To != id {
+			continue
+		}
+		if rider, ok := r.Entity(l.From); ok {
+			entities = append(entities, rider)
+		}
+	}
+
+	moved := make([]Entity, len(entities))
+
+	for i, e := range entities {
+		if err := resetFall(&e.NBT); err != nil {
+			return nil, fmt.Errorf("resetting motion of %s %d: %w", e.Identifier, e.UniqueID, err)
+		}
+
+		if moved[i], err = w.MoveEntity(e, x, y, z, dimension); err != nil {
+			return nil, fmt.Errorf("moving %s %d: %w", e.Identifier, e.UniqueID, err)
+		}
+	}
+
+	return moved, nil
+}
+
+// resetFall sets the motion and fall distance in an entity's compound tag to zero, where they are saved.
+func resetFall(t *nbt.NBTTag) error {
+	if motion, ok := t.Child("Motion"); ok {
+		if err := motion.SetList([]nbt.NBTTag{
+			{Type: nbt.TagFloat, Value: 0.0},
+			{Type: nbt.TagFloat, Value: 0.0},
+			{Type: nbt.TagFloat, Value: 0.0},
+		}); err != nil {
+			return err
+		}
+
+		if err := t.SetChild(motion); err != nil {
+			return err
+		}
+	}
+
+	if fall, ok := t.Child("FallDistance"); ok {
+		fall.Value = 0.0
+		return t.SetChild(fall)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package world
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func TestTransferEntity(t *testing.T) {
+	w, db := testEntityWorld(t)
+
+	// The wolf is falling and ridden by the cow
+	wolf := testEntity("minecraft:wolf", 3, 1600, 64, 1600)
+	riders := nbt.NBTTag{Type: nbt.TagList, Name: "LinksTag", Value: map[string]interface{}{"tagListType": nbt.TagCompound}}
+	rider := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	_ = rider.SetChild(nbt.NBTTag{Type: nbt.TagLong, Name: "entityID", Value: nbt.Long(1)})
+	_ = riders.SetList([]nbt.NBTTag{rider})
+	_ = wolf.SetChild(riders)
+	_ = wolf.SetChild(nbt.NBTTag{Type: nbt.TagFloat, Name: "FallDistance", Value: 30.0})
+
+	actor, err := nbt.Encode([]nbt.NBTTag{wolf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Put(leveldb.ActorKey([]byte{0, 0, 0, 1, 0, 0, 0, 3}), actor)
+
+	if _, err := w.TransferEntity(3, 40, 70, 40, int(Nether)); !errors.Is(err, ErrChunkNotGenerated) {
+		t.Fatalf("expected ErrChunkNotGenerated: got %v", err)
+	}
+
+	_ = db.Put(leveldb.ChunkKey{X: 2, Z: 2, Dimension: int32(Nether), Tag: leveldb.Version}.Bytes(), []byte{40})
+
+	moved, err := w.TransferEntity(3, 40, 70, 40, int(Nether))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(moved) != 2 || moved[0].UniqueID != 3 || moved[1].UniqueID != 1 {
+		t.Fatalf("expected the wolf and its rider to be moved: got %+v", moved)
+	}
+
+	if ids, _ := db.Get(leveldb.DigestKey(2, 2, int32(Nether))); len(ids) != 8 {
+		t.Errorf("expected the wolf to be listed in the nether digest: got %x", ids)
+	}
+
+	entities, err := w.Entities()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range entities {
+		if e.UniqueID != 1 && e.UniqueID != 3 {
+			continue
+		}
+
+		if e.Dimension != int(Nether) || e.X != 40 || e.Y != 70 || e.Z != 40 {
+			t.Errorf("expected %s at 40 70 40 in the nether: got %.0f %.0f %.0f in %d",
+				e.Identifier, e.X, e.Y, e.Z, e.Dimension)
+		}
+
+		if f, ok := e.NBT.Child("FallDistance"); ok {
+			if d, _ := f.Float(); d != 0 {
+				t.Errorf("expected fall distance to be reset: got %f", d)
+			}
+		}
+	}
+
+	if _, err := w.TransferEntity(999, 40, 70, 40, int(Nether)); !errors.Is(err, ErrUnresolved) {
+		t.Errorf("expected ErrUnresolved transferring a missing entity: got %v", err)
+	}
+}
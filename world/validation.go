@@ -0,0 +1,111 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// PaletteValidation is how sub chunks are read whose block storages don't have the layout the package expects: a
+// storage of blocks, optionally followed by a water logging storage with the palette [air, water]. Some add-ons
+// legitimately write other layouts, such as extra storages.
+type PaletteValidation int
+
+const (
+	// WarnPalette logs a warning the first time each unexpected layout is read, and reads the sub chunk as well as
+	// possible. Unexpected storages are kept when the sub chunk is written back.
+	WarnPalette PaletteValidation = iota
+	// StrictPalette fails to read the sub chunk with an error wrapping ErrUnexpectedPalette.
+	StrictPalette
+)
+
+var paletteValidationNames = []string{"warn", "strict"}
+
+func (v PaletteValidation) String() string {
+	return enumName(paletteValidationNames, int(v), "PaletteValidation")
+}
+
+// ParsePaletteValidation returns the palette validation policy with the given name, warn or strict.
+func ParsePaletteValidation(s string) (PaletteValidation, error) {
+	i, err := parseEnum(paletteValidationNames, s, "palette validation")
+	return PaletteValidation(i), err
+}
+
+// UnmarshalText decodes a palette validation policy name, so config files may give one.
+func (v *PaletteValidation) UnmarshalText(text []byte) error {
+	p, err := ParsePaletteValidation(string(text))
+	if err != nil {
+		return err
+	}
+
+	*v = p
+
+	return nil
+}
+
+// ErrUnexpectedPalette is returned when reading a sub chunk with an unexpected block storage layout under
+// StrictPalette validation.
+var ErrUnexpectedPalette = errors.New("unexpected block storage layout")
+
+// maxPaletteWarnings is the number of distinct unexpected layouts warned about, after which warnings stop.
+const maxPaletteWarnings = 100
+
+var paletteValidation = struct {
+	sync.Mutex
+	policy PaletteValidation
+	warned map[string]bool
+}{
+	warned: make(map[string]bool),
+}
+
+// SetPaletteValidation sets how sub chunks with unexpected block storage layouts are read by every world. The default
+// is WarnPalette.
+func SetPaletteValidation(v PaletteValidation) {
+	paletteValidation.Lock()
+	defer paletteValidation.Unlock()
+
+	paletteValidation.policy = v
+}
+
+// unexpectedLayout reports an unexpected block storage layout described by the format and arguments. An error is
+// returned under StrictPalette validation, otherwise a warning is logged if the layout hasn't been seen before.
+func unexpectedLayout(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+
+	paletteValidation.Lock()
+
+	if paletteValidation.policy == StrictPalette {
+		paletteValidation.Unlock()
+		return fmt.Errorf("%w: %s", ErrUnexpectedPalette, msg)
+	}
+
+	first := !paletteValidation.warned[msg] && len(paletteValidation.warned) < maxPaletteWarnings
+	if first {
+		paletteValidation.warned[msg] = true
+	}
+
+	paletteValidation.Unlock()
+
+	if first {
+		warn(ErrUnexpectedPalette.Error(), "layout", msg)
+	}
+
+	return nil
+}
+
+// validateWaterLogged checks that a second block storage has the palette [air, water] or a prefix of it.
+func validateWaterLogged(s blockStorage) error {
+	ids := make([]string, len(s.Palette))
+	for i, p := range s.Palette {
+		ids[i] = p.BlockID()
+	}
+
+	if len(ids) > 2 || (len(ids) > 0 && ids[0] != BlockAir && ids[0] != BlockWater) ||
+		(len(ids) > 1 && ids[1] != BlockWater) {
+		return unexpectedLayout("water logging storage palette is [%s], not [%s, %s]",
+			strings.Join(ids, ", "), BlockAir, BlockWater)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package world
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testLogger records warnings in the form written by the default logger.
+type testLogger struct {
+	warnings []string
+}
+
+func (l *testLogger) Warn(msg string, args ...interface{}) {
+	l.warnings = append(l.warnings, formatLogArgs(msg, args))
+}
+
+func TestPaletteValidation(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	defer SetLogger(stdLogger{})
+	defer SetPaletteValidation(WarnPalette)
+	paletteValidation.warned = make(map[string]bool)
+
+	storage := func(ids ...string) blockStorage {
+		s := blockStorage{BitsPerBlock: 4, Indices: make([]int, subChunkBlockCount)}
+		for _, id := range ids {
+			s.Palette = append(s.Palette, paletteEntry(id))
+		}
+		return s
+	}
+
+	// The water logging storage has an add-on block and there is a third storage
+	odd := storage(BlockAir, "addon:glow", BlockWater)
+	odd.Indices[1] = 1
+	odd.Indices[2] = 2
+	extra := storage(BlockAir, BlockStone)
+	extra.Indices[0] = 1
+
+	value, err := encodeSubChunk(&subChunkData{Version: 8, Blocks: storage(BlockAir),
+		Extra: []blockStorage{odd, extra}}, false)
+	if err != nil {
+		t.Fatalf("unexpected error encoding sub chunk: %s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		s, err := parseSubChunk(value)
+		if err != nil {
+			t.Fatalf("unexpected error reading with warnings: %s", err)
+		}
+		if len(s.Extra) != 2 {
+			t.Fatalf("expected 2 extra storages: got %d", len(s.Extra))
+		}
+
+		// Extra storages are written back
+		again, err := encodeSubChunk(s, false)
+		if err != nil {
+			t.Fatalf("unexpected error encoding sub chunk: %s", err)
+		}
+		if !bytes.Equal(again, value) {
+			t.Errorf("expected the sub chunk to round trip unchanged")
+		}
+	}
+
+	// Each layout is warned about once
+	if got := strings.Count(logged.String(), "warning: "); got != 2 {
+		t.Errorf("expected 2 warnings: got %d:\n%s", got, logged.String())
+	}
+
+	// Warnings go to the logger which is set, with the layout as an argument
+	l := &testLogger{}
+	SetLogger(l)
+	paletteValidation.warned = make(map[string]bool)
+	if _, err := parseSubChunk(value); err != nil {
+		t.Fatalf("unexpected error reading with a logger: %s", err)
+	}
+	if len(l.warnings) != 2 || !strings.Contains(l.warnings[0], "layout=") {
+		t.Errorf("expected 2 warnings with the layout passed to the logger: got %v", l.warnings)
+	}
+
+	SetLogger(nil)
+	paletteValidation.warned = make(map[string]bool)
+	logged.Reset()
+	if _, err := parseSubChunk(value); err != nil || logged.Len() > 0 {
+		t.Errorf("expected warnings to be discarded without a logger: got %v, %q", err, logged.String())
+	}
+
+	// Water logging uses the water entry wherever it is in the palette
+	s, _ := parseSubChunk(value)
+	sub := &SubChunk{data: s}
+	if b := sub.at(0, 2, 0); !b.waterLogged {
+		t.Errorf("expected the block at index 2 to be water logged")
+	}
+	sub.setWaterLogged(3, true)
+	if got := s.Extra[0].Palette[s.Extra[0].Indices[3]].BlockID(); got != BlockWater {
+		t.Errorf("expected %s at index 3 of the water logging storage: got %s", BlockWater, got)
+	}
+
+	empty := []byte{8, 0}
+	if s, err := parseSubChunk(empty); err != nil {
+		t.Errorf("unexpected error reading a sub chunk without storages: %s", err)
+	} else if id := s.Blocks.Palette[s.Blocks.Indices[0]].BlockID(); id != BlockAir {
+		t.Errorf("expected a sub chunk without storages to be air: got %s", id)
+	}
+
+	SetPaletteValidation(StrictPalette)
+
+	for name, v := range map[string][]byte{"extra storages": value, "no storages": empty} {
+		if _, err := parseSubChunk(v); !errors.Is(err, ErrUnexpectedPalette) {
+			t.Errorf("expected ErrUnexpectedPalette reading a sub chunk with %s: got %v", name, err)
+		}
+	}
+
+	// The usual layout is always valid
+	valid, _ := encodeSubChunk(&subChunkData{Version: 8, Blocks: storage(BlockStone),
+		Extra: []blockStorage{storage(BlockAir, BlockWater)}}, false)
+	if _, err := parseSubChunk(valid); err != nil {
+		t.Errorf("unexpected error reading a valid sub chunk: %s", err)
+	}
+
+	if _, err := parseSubChunk([]byte{8, 0x80}); err == nil || errors.Is(err, ErrUnexpectedPalette) {
+		t.Errorf("expected an invalid storage count error: got %v", err)
+	}
+
+	var v PaletteValidation
+	if err := v.UnmarshalText([]byte("Strict")); err != nil || v != StrictPalette {
+		t.Errorf("expected strict: got %s, %v", v, err)
+	}
+	if _, err := ParsePaletteValidation("lenient"); err == nil {
+		t.Error("expected an error parsing an invalid policy")
+	}
+}
@@ -0,0 +1,350 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+const villagePrefix = "VILLAGE_"
+
+const (
+	villageDwellersSuffix = "_DWELLERS"
+	villagePOISuffix      = "_POI"
+)
+
+// Village is a village as stored in its VILLAGE_ records.
+type Village struct {
+	ID       string
+	Dwellers []int64      // The unique IDs of the villagers, iron golems and cats belonging to the village
+	POIs     []VillagePOI // Points of interest such as beds, bells and workstations
+}
+
+// VillagePOI is a point of interest claimed by a villager.
+type VillagePOI struct {
+	VillagerID int64
+	Name       string
+	X, Y, Z    int
+}
+
+// VillageProblem is a village dweller which no longer exists as an entity, or a point of interest which references a
+// missing villager or a chunk which is not saved. These are usually left behind by external edits and break iron golem
+// farms and villager linking.
+type VillageProblem struct {
+	VillageID string
+	Dweller   int64       // The unique ID of a missing dweller, if the problem is a dweller
+	POI       *VillagePOI // The point of interest, if the problem is a point of interest
+	Reason    string
+}
+
+// Villages returns every village in the world.
+func (w *World) Villages() ([]Village, error) {
+	ids, err := w.villageIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	villages := make([]Village, len(ids))
+
+	for i, id := range ids {
+		v := Village{ID: id}
+
+		if v.Dwellers, err = w.villageDwellers(id); err != nil {
+			return nil, err
+		}
+
+		if v.POIs, err = w.villagePOIs(id); err != nil {
+			return nil, err
+		}
+
+		villages[i] = v
+	}
+
+	return villages, nil
+}
+
+// VillageProblems returns every village dweller which doesn't exist as an entity and every point of interest which is
+// claimed by a missing villager or is in a chunk which is not saved.
+func (w *World) VillageProblems() ([]VillageProblem, error) {
+	villages, err := w.Villages()
+	if err != nil {
+		return nil, err
+	}
+
+	b := w.startBudget()
+
+	entities, err := w.entities(b)
+	if err != nil {
+		return nil, err
+	}
+
+	alive := make(map[int64]bool)
+	for _, e := range entities {
+		alive[e.UniqueID] = true
+	}
+
+	generated, err := w.generatedChunks(b)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make([]VillageProblem, 0)
+
+	for _, v := range villages {
+		for _, d := range v.Dwellers {
+			if !alive[d] {
+				problems = append(problems, VillageProblem{VillageID: v.ID, Dweller: d, Reason: "dweller entity does not exist"})
+			}
+		}
+
+		for i := range v.POIs {
+			p := v.POIs[i]
+			reason := ""
+
+			switch {
+			case p.VillagerID != -1 && !alive[p.VillagerID]:
+				reason = "claimed by a villager which does not exist"
+			case !generated[[3]int32{int32(math.Floor(float64(p.X) / chunkSize)), int32(math.Floor(float64(p.Z) / chunkSize)), 0}]:
+				reason = "in a chunk which is not saved"
+			default:
+				continue
+			}
+
+			problems = append(problems, VillageProblem{VillageID: v.ID, POI: &p, Reason: reason})
+		}
+	}
+
+	return problems, nil
+}
+
+// RemoveVillageProblems removes the given dwellers and points of interest from their village records.
+func (w *World) RemoveVillageProblems(problems []VillageProblem) error {
+	dwellers := make(map[string]map[int64]bool)
+	pois := make(map[string]map[VillagePOI]bool)
+
+	for _, p := range problems {
+		if p.POI != nil {
+			if pois[p.VillageID] == nil {
+				pois[p.VillageID] = make(map[VillagePOI]bool)
+			}
+			pois[p.VillageID][*p.POI] = true
+			continue
+		}
+
+		if dwellers[p.VillageID] == nil {
+			dwellers[p.VillageID] = make(map[int64]bool)
+		}
+		dwellers[p.VillageID][p.Dweller] = true
+	}
+
+	for id, remove := range dwellers {
+		key := []byte(villagePrefix + id + villageDwellersSuffix)
+
+		err := w.updateVillageRecord(key, "Dwellers", func(group nbt.NBTTag) (nbt.NBTTag, error) {
+			actors, ok := group.Child("actors")
+			if !ok {
+				return group, nil
+			}
+
+			kept := make([]nbt.NBTTag, 0)
+			for _, a := range actors.List() {
+				if !remove[childInt(a, "ID")] {
+					kept = append(kept, a)
+				}
+			}
+
+			if err := actors.SetList(kept); err != nil {
+				return group, err
+			}
+
+			return group, group.SetChild(actors)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for id, remove := range pois {
+		key := []byte(villagePrefix + id + villagePOISuffix)
+
+		err := w.updateVillageRecord(key, "POI", func(claim nbt.NBTTag) (nbt.NBTTag, error) {
+			instances, ok := claim.Child("instances")
+			if !ok {
+				return claim, nil
+			}
+
+			villager := childInt(claim, "VillagerID")
+
+			kept := make([]nbt.NBTTag, 0)
+			for _, i := range instances.List() {
+				if !remove[parseVillagePOI(villager, i)] {
+					kept = append(kept, i)
+				}
+			}
+
+			if err := instances.SetList(kept); err != nil {
+				return claim, err
+			}
+
+			return claim, claim.SetChild(instances)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// villageIDs returns the IDs of all villages with records in the world. The ID is the part of the key between the
+// VILLAGE_ prefix and the record type suffix.
+func (w *World) villageIDs() ([]string, error) {
+	keys, err := w.db.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("listing keys: %w", err)
+	}
+
+	found := make(map[string]bool)
+
+	for _, key := range keys {
+		k := string(key)
+		if !strings.HasPrefix(k, villagePrefix) {
+			continue
+		}
+
+		i := strings.LastIndex(k, "_")
+		if i <= len(villagePrefix) {
+			continue
+		}
+
+		found[k[len(villagePrefix):i]] = true
+	}
+
+	ids := make([]string, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+func (w *World) villageDwellers(id string) ([]int64, error) {
+	groups, err := w.villageRecordList([]byte(villagePrefix+id+villageDwellersSuffix), "Dwellers")
+	if err != nil {
+		return nil, err
+	}
+
+	dwellers := make([]int64, 0)
+
+	for _, g := range groups {
+		actors, ok := g.Child("actors")
+		if !ok {
+			continue
+		}
+
+		for _, a := range actors.List() {
+			dwellers = append(dwellers, childInt(a, "ID"))
+		}
+	}
+
+	return dwellers, nil
+}
+
+func (w *World) villagePOIs(id string) ([]VillagePOI, error) {
+	claims, err := w.villageRecordList([]byte(villagePrefix+id+villagePOISuffix), "POI")
+	if err != nil {
+		return nil, err
+	}
+
+	pois := make([]VillagePOI, 0)
+
+	for _, c := range claims {
+		villager := childInt(c, "VillagerID")
+
+		instances, ok := c.Child("instances")
+		if !ok {
+			continue
+		}
+
+		for _, i := range instances.List() {
+			pois = append(pois, parseVillagePOI(villager, i))
+		}
+	}
+
+	return pois, nil
+}
+
+func parseVillagePOI(villager int64, instance nbt.NBTTag) VillagePOI {
+	p := VillagePOI{
+		VillagerID: villager,
+		X:          int(childInt(instance, "X")),
+		Y:          int(childInt(instance, "Y")),
+		Z:          int(childInt(instance, "Z")),
+	}
+
+	if n, ok := instance.Child("Name"); ok {
+		p.Name, _ = n.StringValue()
+	}
+
+	return p
+}
+
+// villageRecordList returns the elements of the named list in a village record. A missing record has no elements.
+func (w *World) villageRecordList(key []byte, list string) ([]nbt.NBTTag, error) {
+	root, err := w.singleTagRecord(key)
+	if err != nil || root == nil {
+		return nil, err
+	}
+
+	l, ok := root.Child(list)
+	if !ok {
+		return nil, nil
+	}
+
+	return l.List(), nil
+}
+
+// updateVillageRecord replaces each element of the named list in a village record with the result of update.
+func (w *World) updateVillageRecord(key []byte, list string, update func(nbt.NBTTag) (nbt.NBTTag, error)) error {
+	root, err := w.singleTagRecord(key)
+	if err != nil || root == nil {
+		return err
+	}
+
+	l, ok := root.Child(list)
+	if !ok {
+		return nil
+	}
+
+	elements := l.List()
+	for i, e := range elements {
+		if elements[i], err = update(e); err != nil {
+			return fmt.Errorf("updating %s %d in '%s': %w", list, i, key, err)
+		}
+	}
+
+	if err := l.SetList(elements); err != nil {
+		return err
+	}
+
+	if err := root.SetChild(l); err != nil {
+		return err
+	}
+
+	return w.putTags(key, []nbt.NBTTag{*root})
+}
+
+func childInt(t nbt.NBTTag, name string) int64 {
+	c, ok := t.Child(name)
+	if !ok {
+		return 0
+	}
+
+	i, _ := c.Int()
+
+	return i
+}
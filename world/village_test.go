@@ -0,0 +1,88 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/danhale-git/mine/leveldb"
+	"github.com/danhale-git/mine/nbt"
+)
+
+func testList(name string, listType byte, elements ...nbt.NBTTag) nbt.NBTTag {
+	l := nbt.NBTTag{Type: nbt.TagList, Name: name, Value: map[string]interface{}{"tagListType": listType}}
+	_ = l.SetList(elements)
+
+	return l
+}
+
+func testCompound(name string, children ...nbt.NBTTag) nbt.NBTTag {
+	c := nbt.NBTTag{Type: nbt.TagCompound, Name: name, Value: []interface{}{}}
+	for _, child := range children {
+		_ = c.SetChild(child)
+	}
+
+	return c
+}
+
+func TestVillageProblems(t *testing.T) {
+	w, db := testEntityWorld(t)
+
+	long := func(name string, i int64) nbt.NBTTag {
+		return nbt.NBTTag{Type: nbt.TagLong, Name: name, Value: nbt.Long(i)}
+	}
+	integer := func(name string, i int) nbt.NBTTag {
+		return nbt.NBTTag{Type: nbt.TagInt, Name: name, Value: i}
+	}
+	poi := func(x, z int) nbt.NBTTag {
+		return testCompound("", integer("X", x), integer("Y", 64), integer("Z", z),
+			nbt.NBTTag{Type: nbt.TagString, Name: "Name", Value: "bed"})
+	}
+
+	// Entity 1 exists, entity 99 does not
+	dwellers := testCompound("", testList("Dwellers", nbt.TagCompound,
+		testCompound("", testList("actors", nbt.TagCompound,
+			testCompound("", long("ID", 1)),
+			testCompound("", long("ID", 99)),
+		)),
+	))
+
+	pois := testCompound("", testList("POI", nbt.TagCompound,
+		testCompound("", long("VillagerID", 1), testList("instances", nbt.TagCompound, poi(1, 1), poi(500, 500))),
+		testCompound("", long("VillagerID", 99), testList("instances", nbt.TagCompound, poi(2, 2))),
+	))
+
+	for key, tag := range map[string]nbt.NBTTag{
+		"VILLAGE_abc_DWELLERS": dwellers,
+		"VILLAGE_abc_POI":      pois,
+	} {
+		if err := w.putTags([]byte(key), []nbt.NBTTag{tag}); err != nil {
+			t.Fatalf("unexpected error writing %s: %s", key, err)
+		}
+	}
+
+	problems, err := w.VillageProblems()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(problems) != 3 {
+		t.Fatalf("expected 3 problems: got %+v", problems)
+	}
+
+	if err := w.RemoveVillageProblems(problems); err != nil {
+		t.Fatalf("unexpected error removing problems: %s", err)
+	}
+
+	villages, err := w.Villages()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	v := villages[0]
+	if v.ID != "abc" || len(v.Dwellers) != 1 || v.Dwellers[0] != 1 || len(v.POIs) != 1 || v.POIs[0].X != 1 {
+		t.Errorf("unexpected village after repair: %+v", v)
+	}
+
+	if _, err := db.Get(leveldb.ChunkKey{Tag: leveldb.Version}.Bytes()); err != nil {
+		t.Errorf("unrelated record was modified: %s", err)
+	}
+}
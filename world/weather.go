@@ -0,0 +1,97 @@
+package world
+
+import (
+	"fmt"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// DayLength is the number of ticks in a full day and night cycle.
+const DayLength = 24000
+
+// Weather is the time and weather state stored in level.dat.
+type Weather struct {
+	Time           int64   // Ticks counted by the day cycle since the world was created
+	RainLevel      float64 // 0 when clear and 1 when raining
+	RainTime       int     // Ticks until the rain starts or stops
+	LightningLevel float64 // 0 when clear and 1 during a thunderstorm
+	LightningTime  int     // Ticks until a thunderstorm starts or stops
+	DaylightCycle  bool    // The dodaylightcycle game rule
+	WeatherCycle   bool    // The doweathercycle game rule
+}
+
+// TimeOfDay returns the time in ticks since the start of the current day, where 0 is sunrise and 6000 is noon.
+func (wt Weather) TimeOfDay() int {
+	t := int(wt.Time % DayLength)
+	if t < 0 {
+		t += DayLength
+	}
+
+	return t
+}
+
+// SetTimeOfDay sets the time of day without changing the day count.
+func (wt *Weather) SetTimeOfDay(ticks int) {
+	wt.Time += int64(ticks - wt.TimeOfDay())
+}
+
+// Raining returns true if it is raining or snowing.
+func (wt Weather) Raining() bool {
+	return wt.RainLevel > 0
+}
+
+// Thundering returns true during a thunderstorm.
+func (wt Weather) Thundering() bool {
+	return wt.LightningLevel > 0
+}
+
+// Clear stops any rain or thunderstorm for the given number of ticks.
+func (wt *Weather) Clear(duration int) {
+	wt.RainLevel, wt.RainTime = 0, duration
+	wt.LightningLevel, wt.LightningTime = 0, duration
+}
+
+// Rain starts rain without thunder, lasting the given number of ticks.
+func (wt *Weather) Rain(duration int) {
+	wt.RainLevel, wt.RainTime = 1, duration
+	wt.LightningLevel, wt.LightningTime = 0, duration
+}
+
+// Thunder starts a thunderstorm lasting the given number of ticks.
+func (wt *Weather) Thunder(duration int) {
+	wt.RainLevel, wt.RainTime = 1, duration
+	wt.LightningLevel, wt.LightningTime = 1, duration
+}
+
+// fields returns the level.dat tags holding each field.
+func (wt *Weather) fields() []levelDatField {
+	return []levelDatField{
+		{"Time", nbt.TagLong, &wt.Time},
+		{"rainLevel", nbt.TagFloat, &wt.RainLevel},
+		{"rainTime", nbt.TagInt, &wt.RainTime},
+		{"lightningLevel", nbt.TagFloat, &wt.LightningLevel},
+		{"lightningTime", nbt.TagInt, &wt.LightningTime},
+		{"dodaylightcycle", nbt.TagByte, &wt.DaylightCycle},
+		{"doweathercycle", nbt.TagByte, &wt.WeatherCycle},
+	}
+}
+
+// Weather reads the time and weather state from level.dat.
+func (w *World) Weather() (Weather, error) {
+	wt := Weather{}
+	if err := w.readLevelDatFields(wt.fields()); err != nil {
+		return Weather{}, err
+	}
+
+	return wt, nil
+}
+
+// SetWeather writes the time and weather state to level.dat. The game overwrites level.dat when the world is closed,
+// so this must not be called while the world is open in the game or a server.
+func (w *World) SetWeather(wt Weather) error {
+	if wt.RainTime < 0 || wt.LightningTime < 0 {
+		return fmt.Errorf("weather timers must not be negative: got rain %d lightning %d", wt.RainTime, wt.LightningTime)
+	}
+
+	return w.setLevelDatFields(wt.fields())
+}
@@ -0,0 +1,105 @@
+package world
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/danhale-git/mine/nbt"
+)
+
+// testLevelDat writes a level.dat file holding the given tags to a new temporary world directory.
+func testLevelDat(t *testing.T, tags ...nbt.NBTTag) *World {
+	dir := t.TempDir()
+
+	root := nbt.NBTTag{Type: nbt.TagCompound, Value: []interface{}{}}
+	for _, tag := range tags {
+		if err := root.SetChild(tag); err != nil {
+			t.Fatalf("unexpected error setting %s: %s", tag.Name, err)
+		}
+	}
+
+	body, err := nbt.Encode([]nbt.NBTTag{root})
+	if err != nil {
+		t.Fatalf("unexpected error encoding level.dat: %s", err)
+	}
+
+	data := make([]byte, levelDatHeaderSize)
+	binary.LittleEndian.PutUint32(data[0:4], 9)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(body)))
+
+	if err := ioutil.WriteFile(filepath.Join(dir, levelDatFileName), append(data, body...), 0644); err != nil {
+		t.Fatalf("unexpected error writing level.dat: %s", err)
+	}
+
+	w := &World{path: dir}
+	if err := w.loadLevelDat(); err != nil {
+		t.Fatalf("unexpected error loading level.dat: %s", err)
+	}
+
+	return w
+}
+
+func TestWeather(t *testing.T) {
+	w := testLevelDat(t,
+		nbt.NBTTag{Name: "LevelName", Type: nbt.TagString, Value: "test"},
+		nbt.NBTTag{Name: "Time", Type: nbt.TagLong, Value: nbt.Long(3*DayLength + 13000)},
+		nbt.NBTTag{Name: "rainLevel", Type: nbt.TagFloat, Value: 1.0},
+		nbt.NBTTag{Name: "rainTime", Type: nbt.TagInt, Value: 5000.0},
+		nbt.NBTTag{Name: "lightningLevel", Type: nbt.TagFloat, Value: 1.0},
+		nbt.NBTTag{Name: "lightningTime", Type: nbt.TagInt, Value: 0.0},
+		nbt.NBTTag{Name: "dodaylightcycle", Type: nbt.TagByte, Value: 1.0},
+		nbt.NBTTag{Name: "doweathercycle", Type: nbt.TagByte, Value: 0.0},
+	)
+
+	wt, err := w.Weather()
+	if err != nil {
+		t.Fatalf("unexpected error reading weather: %s", err)
+	}
+
+	if !wt.Thundering() || !wt.Raining() || wt.RainTime != 5000 || !wt.DaylightCycle || wt.WeatherCycle {
+		t.Errorf("unexpected weather read: %+v", wt)
+	}
+
+	if wt.TimeOfDay() != 13000 {
+		t.Errorf("expected time of day 13000: got %d", wt.TimeOfDay())
+	}
+
+	wt.SetTimeOfDay(1000)
+	wt.Clear(12000)
+	wt.WeatherCycle = true
+
+	if err := w.SetWeather(wt); err != nil {
+		t.Fatalf("unexpected error writing weather: %s", err)
+	}
+
+	got, err := w.Weather()
+	if err != nil {
+		t.Fatalf("unexpected error reading weather after writing: %s", err)
+	}
+
+	if got != wt {
+		t.Errorf("expected weather %+v: got %+v", wt, got)
+	}
+
+	if got.Time != 3*DayLength+1000 {
+		t.Errorf("expected time to stay on day 3: got %d", got.Time)
+	}
+
+	l, err := w.levelDat()
+	if err != nil {
+		t.Fatalf("unexpected error reading level.dat: %s", err)
+	}
+
+	if n, ok := l.Child("LevelName"); !ok {
+		t.Errorf("expected other level.dat tags to be kept")
+	} else if s, _ := n.StringValue(); s != "test" {
+		t.Errorf("expected LevelName 'test': got '%s'", s)
+	}
+
+	wt.RainTime = -1
+	if err := w.SetWeather(wt); err == nil {
+		t.Errorf("expected error writing negative rain time")
+	}
+}
@@ -1,46 +1,89 @@
 package world
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"time"
 
 	"github.com/danhale-git/mine/leveldb"
-	"github.com/midnightfreddie/McpeTool/world"
 )
 
-const waterID = "minecraft:water"
-
 // BlockAPI modifies block data.
 type BlockAPI interface {
 	GetBlock(x, y, z, dimension int) (Block, error)
 }
 
-// LevelDB returns data from a leveldb database.
+// LevelDB reads and writes data in a leveldb database. A database may also implement io.Closer, to be closed with the
+// world, and ForEachKey(func(key []byte) error) error, to list keys without holding them all.
 type LevelDB interface {
 	Get(key []byte) ([]byte, error)
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	Keys() ([][]byte, error)
 }
 
 type World struct {
+	path      string
 	db        LevelDB
 	subChunks map[struct{ x, y, z, d int }]*subChunkData
+	observers []func(ChangeEvent)
+	journal   *[]journalEntry // The previous values of records changed by the current editor session step, if any
+	// The number of bytes of intermediate results held in memory by whole world operations, 0 meaning no limit
+	memoryBudget int64
+	scanOrder    ScanOrder      // The order in which scans visit sub chunks
+	timeBudget   time.Duration  // How long each whole world scan may run, 0 meaning no limit
+	deadline     time.Time      // The time at which whole world scans stop, zero meaning none
+	level        *levelMetadata // The content of level.dat, nil if the world has none
 }
 
+// New opens the world in the given directory.
 func New(path string) (*World, error) {
-	w := World{}
-	w.subChunks = make(map[struct{ x, y, z, d int }]*subChunkData)
-	l, err := world.OpenWorld(path)
+	db, err := openDB(path)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	w := &World{path: path, db: db, subChunks: make(map[struct{ x, y, z, d int }]*subChunkData)}
+
+	if err := w.loadLevelDat(); err != nil {
+		w.Close()
+		return nil, err
 	}
 
-	w.db = &l
+	return w, nil
+}
 
-	return &w, nil
+// NewFromDB returns a World backed by the given database, such as an in memory database. There is no world directory, so
+// files such as level.dat can't be read and the world has no metadata.
+func NewFromDB(db LevelDB) *World {
+	return &World{
+		db:        db,
+		subChunks: make(map[struct{ x, y, z, d int }]*subChunkData),
+	}
+}
+
+// Close closes the world database. The world may not be used after calling Close.
+func (w *World) Close() error {
+	if c, ok := w.backend().(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// backend returns the database without any tracing wrapper.
+func (w *World) backend() LevelDB {
+	if t, ok := w.db.(*traceDB); ok {
+		return t.db
+	}
+
+	return w.db
 }
 
 // TODO: Don't get the sub chunk from the DB every time, cache it
 
-// GetBlock returns the block at the given coordinates.
+// GetBlock returns the block at the given coordinates, with its block states.
 func (w *World) GetBlock(x, y, z, dimension int) (Block, error) {
 	origin := subChunkOrigin(x, y, z, dimension)
 
@@ -48,20 +91,9 @@ func (w *World) GetBlock(x, y, z, dimension int) (Block, error) {
 	var ok bool
 
 	if sc, ok = w.subChunks[origin]; !ok {
-		key, err := leveldb.SubChunkKey(
-			x, y, z,
-			dimension,
-		)
-
-		value, err := w.db.Get(key)
+		value, err := w.SubChunkValue(x, y, z, dimension)
 		if err != nil {
-
-			// TODO: Make a PR to give this error a type - https://github.com/midnightfreddie/goleveldb/blob/fb12d34a9c1f2c7615bb9b258d09400cd315502f/leveldb/errors/errors.go#L19
-
-			if err.Error() == "leveldb: not found" {
-				return Block{}, &SubChunkNotSavedError{origin}
-			}
-			return Block{}, fmt.Errorf("getting sub chunk with key '%x': %w", key, err)
+			return Block{}, err
 		}
 
 		sc, err = parseSubChunk(value)
@@ -72,39 +104,31 @@ func (w *World) GetBlock(x, y, z, dimension int) (Block, error) {
 		w.subChunks[origin] = sc
 	}
 
-	voxelIndex := subChunkVoxelToIndex(worldVoxelToSubChunk(x, y, z))
-
-	blockIndex := sc.Blocks.Indices[voxelIndex]
-	blockID := sc.Blocks.Palette[blockIndex].BlockID()
-
-	waterLogged := false
-	if len(sc.WaterLogged.Indices) > 0 && len(sc.WaterLogged.Indices) >= voxelIndex {
-		waterIndex := sc.WaterLogged.Indices[voxelIndex]
-		blockID := sc.WaterLogged.Palette[waterIndex].BlockID()
-		waterLogged = blockID == waterID
-	}
-
-	return Block{
-		ID: blockID,
-		X:  x, Y: y, Z: z,
-		waterLogged: waterLogged,
-	}, nil
+	return sc.block(subChunkVoxelToIndex(worldVoxelToSubChunk(x, y, z)), x, y, z), nil
 }
 
-// SubChunkNotSavedError is returned if a requested sub chunk is not present in the world database.
-type SubChunkNotSavedError struct {
-	origin struct{ x, y, z, d int }
-}
+// SubChunkValue returns the raw database value of the sub chunk containing the given coordinates. An error wrapping
+// ErrInvalidDimension or ErrOutsideHeight is returned for coordinates outside the dimensions of the world.
+func (w *World) SubChunkValue(x, y, z, dimension int) ([]byte, error) {
+	if err := checkHeight(y, dimension); err != nil {
+		return nil, err
+	}
 
-// TODO: State the dimension in the error message, when dimensions are supported
+	key, err := leveldb.SubChunkKey(
+		x, y, z,
+		dimension,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("building sub chunk key: %w", err)
+	}
 
-func (e *SubChunkNotSavedError) Error() string {
-	return fmt.Sprintf("chunk with origin %d %d %d is not stored in this world database",
-		e.origin.x, e.origin.y, e.origin.z)
-}
+	value, err := w.db.Get(key)
+	if err != nil {
+		if errors.Is(err, leveldb.ErrNotFound) {
+			return nil, &SubChunkNotSavedError{subChunkOrigin(x, y, z, dimension)}
+		}
+		return nil, fmt.Errorf("getting sub chunk with key '%x': %w", key, err)
+	}
 
-// Is implements Is(error) to support errors.Is()
-func (e *SubChunkNotSavedError) Is(tgt error) bool {
-	_, ok := tgt.(*SubChunkNotSavedError)
-	return ok
+	return value, nil
 }
@@ -1,14 +1,17 @@
 package world
 
 import (
-	"fmt"
+	"bytes"
+	"encoding/json"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/danhale-git/mine/leveldb"
 	"github.com/danhale-git/mine/mock"
+	"github.com/danhale-git/mine/nbt"
 )
 
 var testWorld *World
@@ -26,6 +29,11 @@ func init() {
 		return
 	}
 
+	// The test world is not committed, only benchmark against it if it is present
+	if _, err := os.Stat(filepath.Join(wd, worldDirName)); os.IsNotExist(err) {
+		return
+	}
+
 	testWorld, err = New(filepath.Join(wd, worldDirName))
 	if err != nil {
 		log.Fatalf("unexpected error opening world: %s", err)
@@ -36,7 +44,7 @@ var result Block
 
 func BenchmarkGetBlock(b *testing.B) {
 	if testWorld == nil {
-		fmt.Println("test world is nil, are you in the world package directory?")
+		b.Skip("test world is nil, are you in the world package directory?")
 	}
 
 	var r Block
@@ -58,10 +66,22 @@ func TestGetBlock(t *testing.T) {
 		subChunks: make(map[struct{ x, y, z, d int }]*subChunkData),
 	}
 
+	// The blocks of the mock sub chunk, with the palette entries they were read from.
+	block := func(y int, waterLogged bool, id string, states ...nbt.NBTTag) Block {
+		b := NewBlock(testCompound("",
+			testString("name", id),
+			testCompound("states", states...),
+			nbt.NBTTag{Type: nbt.TagInt, Name: "version", Value: int32(17879555)},
+		))
+		b.Y, b.waterLogged = y, waterLogged
+
+		return b
+	}
+
 	expected := []Block{
-		{Y: 0, ID: "minecraft:crimson_planks", waterLogged: false, X: 0, Z: 0},
-		{Y: 1, ID: "minecraft:fence", waterLogged: true, X: 0, Z: 0},
-		{Y: 2, ID: "minecraft:air", waterLogged: false, X: 0, Z: 0},
+		block(0, false, "minecraft:crimson_planks"),
+		block(1, true, "minecraft:fence", testString("wood_type", "oak")),
+		block(2, false, "minecraft:air"),
 	}
 
 	for y := 0; y < 3; y++ {
@@ -75,3 +95,142 @@ func TestGetBlock(t *testing.T) {
 		}
 	}
 }
+
+func TestGetBlockNegative(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := NewFromDB(db)
+
+	// The lowest sub chunk of the overworld, below chunk -1 -1
+	_ = db.Put(leveldb.ChunkKey{X: -1, Z: -1, Tag: leveldb.SubChunkPrefix, SubChunkY: -4}.Bytes(),
+		testSubChunkValue(t, map[[3]int]string{{15, 0, 15}: BlockBedrock, {0, 15, 1}: BlockStone}))
+
+	for _, c := range []struct {
+		x, y, z int
+		want    string
+	}{
+		{-1, -64, -1, BlockBedrock},
+		{-16, -49, -15, BlockStone},
+		{-16, -64, -16, BlockAir},
+	} {
+		b, err := w.GetBlock(c.x, c.y, c.z, 0)
+		if err != nil {
+			t.Fatalf("unexpected error getting %d %d %d: %s", c.x, c.y, c.z, err)
+		}
+
+		if b.ID != c.want {
+			t.Errorf("expected %s at %d %d %d: got %s", c.want, c.x, c.y, c.z, b.ID)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	w := World{
+		db:        mock.ValidLevelDB(),
+		subChunks: make(map[struct{ x, y, z, d int }]*subChunkData),
+	}
+
+	buf := bytes.Buffer{}
+	w.Trace(&buf)
+
+	if _, err := w.GetBlock(0, 0, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	r := traceRecord{}
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("unexpected error decoding trace: %s", err)
+	}
+
+	if r.Op != "get" || r.Key != "00000000000000002f00" || r.Bytes != len(mock.SubChunkValue) {
+		t.Errorf("unexpected trace record: %+v", r)
+	}
+
+	w.Trace(nil)
+	buf.Reset()
+
+	if _, err := w.SubChunkValue(0, 0, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("trace written after tracing was disabled: %s", buf.String())
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	db := mock.NewLevelDB()
+	w := World{
+		db:        db,
+		subChunks: make(map[struct{ x, y, z, d int }]*subChunkData),
+	}
+
+	key, _ := leveldb.SubChunkKey(0, 0, 0, 0)
+	_ = db.Put(key, mock.SubChunkValue)
+	_ = db.Put([]byte("~local_player"), []byte{1, 2, 3})
+
+	report, err := w.Optimize()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if report.SubChunks != 1 {
+		t.Errorf("expected 1 sub chunk to be read: got %d", report.SubChunks)
+	}
+
+	if report.BytesAfter > report.BytesBefore {
+		t.Errorf("optimized size %d is larger than original size %d", report.BytesAfter, report.BytesBefore)
+	}
+
+	b, err := w.GetBlock(0, 1, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if b.ID != "minecraft:fence" || !b.waterLogged {
+		t.Errorf("unexpected block after optimizing: %+v", b)
+	}
+}
+
+// compactingDB records the ranges of keys compacted in a mock database.
+type compactingDB struct {
+	*mock.LevelDB
+	ranges [][2][]byte
+}
+
+func (c *compactingDB) CompactRange(start, limit []byte) error {
+	c.ranges = append(c.ranges, [2][]byte{start, limit})
+	return nil
+}
+
+func TestOptimizeCompactsRewrittenKeys(t *testing.T) {
+	db := &compactingDB{LevelDB: mock.NewLevelDB()}
+	w := NewFromDB(db)
+
+	// Stone stored with more bits per block than it needs, which optimizing makes smaller
+	value, err := encodeSubChunk(&subChunkData{Version: 8, Blocks: blockStorage{
+		BitsPerBlock: 4,
+		Indices:      make([]int, subChunkBlockCount),
+		Palette:      []nbt.NBTTag{paletteEntry(BlockStone)},
+	}}, false)
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+
+	low, _ := leveldb.SubChunkKey(0, 0, 0, 0)
+	high, _ := leveldb.SubChunkKey(100, 0, 50, 0)
+	_ = db.Put(low, value)
+	_ = db.Put(high, value)
+
+	report, err := w.Optimize()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if report.Rewritten != 2 {
+		t.Fatalf("expected 2 sub chunks to be rewritten: got %d", report.Rewritten)
+	}
+
+	// Both keys are compacted in one range, which ends after the highest
+	if len(db.ranges) != 1 || !bytes.Equal(db.ranges[0][0], low) || !bytes.Equal(db.ranges[0][1], append(high, 0)) {
+		t.Errorf("expected keys %x to %x00 to be compacted: got %x", low, high, db.ranges)
+	}
+}